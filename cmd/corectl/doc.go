@@ -50,6 +50,37 @@ otherwise it will create a client token.
 
     corectl create-token [-net] [name]
 
+Split Key
+
+Subcommand 'split-key' splits the MockHSM private key for the given xpub
+into Shamir shares, printed one per line in hex. Any quorum of the shares
+can restore the key; fewer reveal nothing about it.
+Each split is written to the log on stderr.
+
+    corectl split-key [-n shares] [-k quorum] [xpub]
+
+Flag -n sets the number of shares. The default is 5.
+
+Flag -k sets the quorum. The default is 3.
+
+Verify Key Shares
+
+Subcommand 'verify-key-shares' checks that the given shares restore
+the private key for xpub, without storing anything.
+
+    corectl verify-key-shares [xpub] [share]...
+
+Restore Key
+
+Subcommand 'restore-key' reconstructs the private key for xpub
+from a quorum of shares and stores it in the MockHSM.
+It fails if the shares don't match xpub.
+Each restore is written to the log on stderr.
+
+    corectl restore-key [-a alias] [xpub] [share]...
+
+Flag -a sets the alias of the restored key.
+
 Reset
 
 Subcommand 'reset' resets the database so the Chain Core can be configured again.
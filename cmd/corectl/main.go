@@ -16,6 +16,7 @@ import (
 	"chain/core/migrate"
 	"chain/core/mockhsm"
 	"chain/crypto/ed25519"
+	"chain/crypto/ed25519/chainkd"
 	"chain/database/sql"
 	"chain/env"
 	"chain/log"
@@ -40,6 +41,9 @@ var commands = map[string]*command{
 	"create-token":         {createToken},
 	"config":               {configNongenerator},
 	"reset":                {reset},
+	"split-key":            {splitKey},
+	"verify-key-shares":    {verifyKeyShares},
+	"restore-key":          {restoreKey},
 }
 
 func main() {
@@ -206,6 +210,101 @@ func configNongenerator(db *sql.DB, args []string) {
 	}
 }
 
+func splitKey(db *sql.DB, args []string) {
+	const usage = "usage: corectl split-key [-n shares] [-k quorum] [xpub]"
+	var flags flag.FlagSet
+	flagN := flags.Int("n", 5, "number of `shares` to produce")
+	flagK := flags.Int("k", 3, "number of shares needed to restore (`quorum`)")
+	flags.Usage = func() {
+		fmt.Println(usage)
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	flags.Parse(args)
+	args = flags.Args()
+	if len(args) != 1 {
+		fatalln(usage)
+	}
+	xpub := parseXPub(args[0])
+
+	hsm := mockhsm.New(db)
+	shares, err := hsm.XSplit(context.Background(), xpub, *flagN, *flagK)
+	if err != nil {
+		fatalln("error:", err)
+	}
+	for _, s := range shares {
+		fmt.Printf("%x\n", s)
+	}
+	flushAuditLog()
+}
+
+func verifyKeyShares(db *sql.DB, args []string) {
+	const usage = "usage: corectl verify-key-shares [xpub] [share]..."
+	if len(args) < 2 {
+		fatalln(usage)
+	}
+	xpub := parseXPub(args[0])
+
+	hsm := mockhsm.New(db)
+	err := hsm.XVerifyShares(context.Background(), xpub, parseShares(args[1:]))
+	if err != nil {
+		fatalln("error:", err)
+	}
+	fmt.Println("ok")
+	flushAuditLog()
+}
+
+func restoreKey(db *sql.DB, args []string) {
+	const usage = "usage: corectl restore-key [-a alias] [xpub] [share]..."
+	var flags flag.FlagSet
+	flagA := flags.String("a", "", "`alias` for the restored key")
+	flags.Usage = func() {
+		fmt.Println(usage)
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	flags.Parse(args)
+	args = flags.Args()
+	if len(args) < 2 {
+		fatalln(usage)
+	}
+	xpub := parseXPub(args[0])
+
+	hsm := mockhsm.New(db)
+	_, err := hsm.XRestore(context.Background(), *flagA, xpub, parseShares(args[1:]))
+	if err != nil {
+		fatalln("error:", err)
+	}
+	flushAuditLog()
+}
+
+// flushAuditLog copies the buffered log to stderr.
+// Key ceremony commands call it even on success,
+// so the log records every split and restore.
+func flushAuditLog() {
+	io.Copy(os.Stderr, &logbuf)
+}
+
+func parseXPub(s string) (xpub chainkd.XPub) {
+	err := xpub.UnmarshalText([]byte(s))
+	if err != nil {
+		fatalln("error: invalid xpub:", err)
+	}
+	return xpub
+}
+
+func parseShares(args []string) [][]byte {
+	var shares [][]byte
+	for _, arg := range args {
+		b, err := hex.DecodeString(arg)
+		if err != nil {
+			fatalln("error: invalid share:", arg)
+		}
+		shares = append(shares, b)
+	}
+	return shares
+}
+
 func fatalln(v ...interface{}) {
 	io.Copy(os.Stderr, &logbuf)
 	fmt.Fprintln(os.Stderr, v...)
@@ -9,13 +9,19 @@ import (
 	"net/http"
 	"os"
 
+	"chain/core"
 	"chain/core/coreunsafe"
+	"chain/core/fault"
+	"chain/core/generator"
 	"chain/database/pg"
 	"chain/env"
 	"chain/log"
 )
 
-var reset = env.String("RESET", "")
+var (
+	reset          = env.String("RESET", "")
+	faultInjection = env.Bool("FAULT_INJECTION", false)
+)
 
 func resetInDevIfRequested(db pg.DB) {
 	if *reset != "" {
@@ -42,3 +48,29 @@ func authLoopbackInDev(req *http.Request) bool {
 	a, err := net.ResolveTCPAddr("tcp", req.RemoteAddr)
 	return err == nil && a.IP.IsLoopback()
 }
+
+// injectFaults wraps h and signers with a fault injector
+// if FAULT_INJECTION is set. Its admin API is served
+// at /debug/faults to connections from the local host.
+func injectFaults(h http.Handler, signers []generator.BlockSigner) (http.Handler, []generator.BlockSigner) {
+	if !*faultInjection {
+		return h, signers
+	}
+	inj := new(fault.Injector)
+	var wrapped []generator.BlockSigner
+	for _, s := range signers {
+		wrapped = append(wrapped, inj.BlockSigner(s))
+	}
+	faulty := inj.Handler(h, core.WriteHTTPError)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/debug/faults" {
+			if !authLoopbackInDev(req) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			inj.ServeHTTP(w, req)
+			return
+		}
+		faulty.ServeHTTP(w, req)
+	}), wrapped
+}
@@ -20,7 +20,9 @@ import (
 	"chain/core"
 	"chain/core/accesstoken"
 	"chain/core/account"
+	"chain/core/account/utxodb"
 	"chain/core/asset"
+	"chain/core/blocklist"
 	"chain/core/blocksigner"
 	"chain/core/fetch"
 	"chain/core/generator"
@@ -64,6 +66,30 @@ var (
 	rpsToken      = env.Int("RATELIMIT_TOKEN", 0)       // reqs/sec
 	rpsRemoteAddr = env.Int("RATELIMIT_REMOTE_ADDR", 0) // reqs/sec
 	indexTxs      = env.Bool("INDEX_TRANSACTIONS", true)
+	prepareHot    = env.Bool("PREPARE_HOT_QUERIES", true)
+	gzipMinSize   = env.Int("GZIP_MIN_SIZE", 1024) // bytes
+	enableHTTP2   = env.Bool("HTTP2", false)
+	idleTimeout   = env.Duration("HTTP_IDLE_TIMEOUT", 2*time.Minute)
+	auditHSM      = env.Bool("HSM_AUDIT", false)
+	minOutputs    = env.StringSlice("MIN_OUTPUT_AMOUNTS")     // assetid=amount,...
+	feeProgram    = env.String("FEE_CONTROL_PROGRAM", "")     // hex
+	minFees       = env.StringSlice("MIN_FEE_PER_KB")         // assetid=amount,...
+	retainOutputs = env.Duration("SPENT_OUTPUT_RETENTION", 0) // 0 keeps spent outputs forever
+	confirmedOnly = env.Bool("SPEND_CONFIRMED_ONLY", false)   // don't chain on pending txs
+	singleUse     = env.Bool("SINGLE_USE_PROGRAMS", false)    // don't pay used account programs
+	changeOutputs = env.Int("CHANGE_OUTPUTS", 1)              // outputs to split change into
+	snapshotDir   = env.String("SNAPSHOT_DIR", "")            // experimental; default is postgres
+	poolBatch     = env.Duration("POOL_BATCH_WINDOW", 0)      // 0 inserts each pool tx on its own
+	poolBatchSize = env.Int("POOL_BATCH_SIZE", 100)
+	maxFutureTime = env.Duration("MAX_FUTURE_TIME", 7*24*time.Hour)
+	blockAdmins   = env.StringSlice("BLOCKLIST_ADMIN_KEYS") // hex ed25519 pubkeys,...
+	blockQuorum   = env.Int("BLOCKLIST_QUORUM", 1)
+	txPriorities  = env.StringSlice("PRIORITY_CLASSES") // coreid=class,...; generator only
+
+	// concurrent requests per endpoint class; 0 means unlimited
+	maxQueries = env.Int("CONCURRENCY_QUERY", 0)
+	maxBuilds  = env.Int("CONCURRENCY_BUILD", 0)
+	maxSubmits = env.Int("CONCURRENCY_SUBMIT", 0)
 
 	// build vars; initialized by the linker
 	buildTag    = "dev"
@@ -75,6 +101,10 @@ var (
 
 	blockPeriod              = 1 * time.Second
 	expireReservationsPeriod = time.Minute
+	pruneOutputsPeriod       = time.Hour
+	pullSubscriptionsPeriod  = time.Minute
+	forwardFundsPeriod       = time.Minute
+	walletTiersPeriod        = time.Minute
 )
 
 func init() {
@@ -106,6 +136,13 @@ func main() {
 	}
 	resetInDevIfRequested(db)
 
+	if *prepareHot {
+		err = db.PrepareHot(ctx, utxodb.HotQueries...)
+		if err != nil {
+			chainlog.Fatal(ctx, chainlog.KeyError, err)
+		}
+	}
+
 	config, err := core.LoadConfig(ctx, db)
 	if err != nil {
 		chainlog.Fatal(ctx, chainlog.KeyError, err)
@@ -136,6 +173,7 @@ func main() {
 			DB:           db,
 			AltAuth:      authLoopbackInDev,
 			AccessTokens: &accesstoken.CredentialStore{DB: db},
+			GzipMinSize:  *gzipMinSize,
 		}
 	}
 
@@ -155,10 +193,13 @@ func main() {
 		Handler:      secureheader.DefaultConfig,
 		ReadTimeout:  httpReadTimeout,
 		WriteTimeout: httpWriteTimeout,
-		// Disable HTTP/2 for now until the Go implementation is more stable.
+		IdleTimeout:  *idleTimeout,
+	}
+	if !*enableHTTP2 {
+		// Disable HTTP/2 by default until the Go implementation is more stable.
 		// https://github.com/golang/go/issues/16450
 		// https://github.com/golang/go/issues/17071
-		TLSNextProto: map[string]func(*http.Server, *tls.Conn, http.Handler){},
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
 	}
 	if *tlsCrt != "" {
 		cert, err := tls.X509KeyPair([]byte(*tlsCrt), []byte(*tlsKey))
@@ -200,16 +241,31 @@ func launchConfiguredCore(ctx context.Context, db *sql.DB, config *core.Config,
 		chainlog.Fatal(ctx, chainlog.KeyError, err)
 	}
 	store, pool := txdb.New(db)
+	if *snapshotDir != "" {
+		snapshots, err := txdb.NewDirSnapshotStore(*snapshotDir)
+		if err != nil {
+			chainlog.Fatal(ctx, chainlog.KeyError, err)
+		}
+		store.SetSnapshotStore(snapshots)
+	}
+	if *poolBatch > 0 {
+		pool = txdb.NewBatchPool(ctx, db, *poolBatch, *poolBatchSize)
+	}
 	c, err := protocol.NewChain(ctx, config.BlockchainID, store, pool, heights)
 	if err != nil {
 		chainlog.Fatal(ctx, chainlog.KeyError, err)
 	}
+	c.MaxFutureTime = *maxFutureTime
 
 	// Setup the transaction query indexer to index every transaction.
 	indexer := query.NewIndexer(db, c)
+	indexer.SetRetention(*retainOutputs)
 
 	assets := asset.NewRegistry(db, c)
+	assets.TrackIssuances()
 	accounts := account.NewManager(db, c)
+	accounts.SpendConfirmedOnly(*confirmedOnly)
+	accounts.SingleUsePrograms(*singleUse)
 	if *indexTxs {
 		indexer.RegisterAnnotator(assets.AnnotateTxs)
 		indexer.RegisterAnnotator(accounts.AnnotateTxs)
@@ -217,8 +273,10 @@ func launchConfiguredCore(ctx context.Context, db *sql.DB, config *core.Config,
 		accounts.IndexAccounts(indexer)
 		c.AddBlockCallback(indexer.IndexTransactions)
 	}
+	assets.TrackDefinitions()
 
 	hsm := mockhsm.New(db)
+	hsm.Audit = *auditHSM
 	var generatorSigners []generator.BlockSigner
 	var signBlockHandler func(context.Context, *bc.Block) ([]byte, error)
 	if config.IsSigner {
@@ -244,23 +302,64 @@ func launchConfiguredCore(ctx context.Context, db *sql.DB, config *core.Config,
 		c.MaxIssuanceWindow = config.MaxIssuanceWindow
 	}
 
+	adminKeys, err := blocklist.ParseAdminKeys(*blockAdmins)
+	if err != nil {
+		chainlog.Fatal(ctx, chainlog.KeyError, err)
+	}
+	blocked := blocklist.New(db, adminKeys, *blockQuorum)
+	if config.IsGenerator {
+		c.TxFilter = blocked
+	}
+
+	if config.IsGenerator && len(*txPriorities) > 0 {
+		classes, err := generator.ParsePriorityClasses(*txPriorities)
+		if err != nil {
+			chainlog.Fatal(ctx, chainlog.KeyError, err)
+		}
+		generator.SetPriorityClasses(classes)
+		c.TxPriority = generator.TxPriority{}
+	}
+
+	minOutputAmounts, err := txbuilder.ParseMinOutputAmounts(*minOutputs)
+	if err != nil {
+		chainlog.Fatal(ctx, chainlog.KeyError, err)
+	}
+	accounts.SplitChange(account.ChangePolicy{
+		Outputs:    *changeOutputs,
+		MinAmounts: minOutputAmounts,
+	})
+
+	fees, err := feePolicy(*feeProgram, *minFees)
+	if err != nil {
+		chainlog.Fatal(ctx, chainlog.KeyError, err)
+	}
+
 	// GC old submitted txs periodically.
 	go core.CleanupSubmittedTxs(ctx, db)
 
 	h := &core.Handler{
-		Chain:        c,
-		Store:        store,
-		Assets:       assets,
-		Accounts:     accounts,
-		HSM:          hsm,
-		TxFeeds:      &txfeed.Tracker{DB: db},
-		Indexer:      indexer,
-		AccessTokens: &accesstoken.CredentialStore{DB: db},
-		Config:       config,
-		DB:           db,
-		Addr:         *listenAddr,
-		Signer:       signBlockHandler,
-		AltAuth:      authLoopbackInDev,
+		Chain:            c,
+		Store:            store,
+		Assets:           assets,
+		Accounts:         accounts,
+		HSM:              hsm,
+		TxFeeds:          &txfeed.Tracker{DB: db},
+		Indexer:          indexer,
+		AccessTokens:     &accesstoken.CredentialStore{DB: db},
+		Config:           config,
+		DB:               db,
+		Addr:             *listenAddr,
+		Signer:           signBlockHandler,
+		AltAuth:          authLoopbackInDev,
+		GzipMinSize:      *gzipMinSize,
+		MinOutputAmounts: minOutputAmounts,
+		Fees:             fees,
+		Blocklist:        blocked,
+		ConcurrencyLimits: map[string]int{
+			core.ClassQuery:  *maxQueries,
+			core.ClassBuild:  *maxBuilds,
+			core.ClassSubmit: *maxSubmits,
+		},
 	}
 	if *rpsToken > 0 {
 		h.RequestLimits = append(h.RequestLimits, core.RequestLimit{
@@ -282,11 +381,17 @@ func launchConfiguredCore(ctx context.Context, db *sql.DB, config *core.Config,
 		fetchhealth = h.HealthSetter("fetch")
 	)
 
+	handler, generatorSigners := injectFaults(h, generatorSigners)
+
 	// Note, it's important for any services that will install blockchain
 	// callbacks to be initialized before leader.Run() and the http server,
 	// otherwise there's a data race within protocol.Chain.
 	go leader.Run(db, *listenAddr, func(ctx context.Context) {
 		go h.Accounts.ExpireReservations(ctx, expireReservationsPeriod)
+		go indexer.PruneSpentOutputs(ctx, pruneOutputsPeriod)
+		go h.PullSubscriptions(ctx, pullSubscriptionsPeriod)
+		go h.ForwardFunds(ctx, forwardFundsPeriod)
+		go h.RunWalletTiers(ctx, walletTiersPeriod)
 		if config.IsGenerator {
 			go generator.Generate(ctx, c, generatorSigners, db, blockPeriod, genhealth)
 		} else {
@@ -296,7 +401,7 @@ func launchConfiguredCore(ctx context.Context, db *sql.DB, config *core.Config,
 
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set(rpc.HeaderBlockchainID, config.BlockchainID.String())
-		h.ServeHTTP(w, req)
+		handler.ServeHTTP(w, req)
 	})
 }
 
@@ -341,6 +446,26 @@ func (s *remoteSigner) String() string {
 	return s.Client.BaseURL
 }
 
+// feePolicy builds the core's fee policy from its configuration.
+// A minimum fee needs a program for fees to pay to.
+func feePolicy(program string, minFees []string) (txbuilder.FeePolicy, error) {
+	var p txbuilder.FeePolicy
+	prog, err := hex.DecodeString(program)
+	if err != nil {
+		return p, errors.Wrap(err, "decoding FEE_CONTROL_PROGRAM")
+	}
+	mins, err := txbuilder.ParseMinFees(minFees)
+	if err != nil {
+		return p, err
+	}
+	if len(mins) > 0 && len(prog) == 0 {
+		return p, errors.New("MIN_FEE_PER_KB requires FEE_CONTROL_PROGRAM")
+	}
+	p.Program = prog
+	p.MinPerKB = mins
+	return p, nil
+}
+
 func logWriter() io.Writer {
 	dropmsg := []byte("\nlog data dropped\n")
 	rotation := &errlog{w: rotation.Create(logFile, *logSize, *logCount)}
@@ -5,6 +5,7 @@ package main
 import (
 	"net/http"
 
+	"chain/core/generator"
 	"chain/database/pg"
 )
 
@@ -13,3 +14,7 @@ func resetInDevIfRequested(db pg.DB) {}
 func authLoopbackInDev(req *http.Request) bool {
 	return false
 }
+
+func injectFaults(h http.Handler, signers []generator.BlockSigner) (http.Handler, []generator.BlockSigner) {
+	return h, signers
+}
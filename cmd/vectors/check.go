@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type report struct {
+	Passed     int
+	Skipped    int
+	Mismatches []string
+}
+
+// check compares results, the JSON output of another
+// implementation, with the expected vectors in v.
+// Results are matched to vectors by kind and name.
+// Each field of a result is compared separately, so
+// one wrong hash doesn't hide the rest.
+func check(v *vectors, results []byte) (*report, error) {
+	want, err := sections(v)
+	if err != nil {
+		return nil, err
+	}
+	var got map[string][]map[string]interface{}
+	err = json.Unmarshal(results, &got)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(report)
+	var kinds []string
+	for kind := range want {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		gotByName := make(map[string]map[string]interface{})
+		for _, g := range got[kind] {
+			name, _ := g["name"].(string)
+			gotByName[name] = g
+		}
+		for _, w := range want[kind] {
+			name := w["name"].(string)
+			g, ok := gotByName[name]
+			if !ok {
+				r.Skipped++
+				continue
+			}
+			pass := true
+			for _, field := range sortedKeys(w) {
+				gv, ok := g[field]
+				if !ok {
+					continue
+				}
+				if !reflect.DeepEqual(gv, w[field]) {
+					pass = false
+					r.Mismatches = append(r.Mismatches, fmt.Sprintf("%s %q: %s is %s, want %s", kind, name, field, toJSON(gv), toJSON(w[field])))
+				}
+			}
+			if pass {
+				r.Passed++
+			}
+		}
+	}
+	return r, nil
+}
+
+// sections converts v to the generic form results are
+// unmarshaled into, so the two compare field by field.
+func sections(v *vectors) (map[string][]map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var s map[string][]map[string]interface{}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"chain/crypto/ed25519"
+	chainjson "chain/encoding/json"
+	"chain/protocol/bc"
+	"chain/protocol/validation"
+	"chain/protocol/vm"
+)
+
+type vectors struct {
+	Transactions []txVector      `json:"transactions"`
+	Blocks       []blockVector   `json:"blocks"`
+	AssetIDs     []assetIDVector `json:"asset_ids"`
+	Programs     []programVector `json:"programs"`
+	Evaluations  []evalVector    `json:"evaluations"`
+}
+
+type txVector struct {
+	Name string `json:"name"`
+
+	// Hex is the transaction, serialized with all
+	// serialization flags set.
+	Hex chainjson.HexBytes `json:"hex"`
+
+	ID             bc.Hash   `json:"id"`
+	WitnessHash    bc.Hash   `json:"witness_hash"`
+	SigHashes      []bc.Hash `json:"sighashes"`
+	SerializedSize int64     `json:"serialized_size"`
+}
+
+type blockVector struct {
+	Name string `json:"name"`
+
+	// Hex is the block, serialized with its witness
+	// and transactions.
+	Hex chainjson.HexBytes `json:"hex"`
+
+	Hash bc.Hash `json:"hash"`
+
+	// TransactionsMerkleRoot is computed from the block's
+	// transactions, and is also the value in its header.
+	TransactionsMerkleRoot bc.Hash `json:"transactions_merkle_root"`
+}
+
+type assetIDVector struct {
+	Name             string             `json:"name"`
+	IssuanceProgram  chainjson.HexBytes `json:"issuance_program"`
+	InitialBlockHash bc.Hash            `json:"initial_block_hash"`
+	VMVersion        uint64             `json:"vm_version"`
+	AssetID          bc.AssetID         `json:"asset_id"`
+}
+
+type programVector struct {
+	Name string             `json:"name"`
+	Asm  string             `json:"asm"`
+	Hex  chainjson.HexBytes `json:"hex"`
+
+	// Disassembly is the canonical text of Hex, which
+	// may differ from Asm in spelling.
+	Disassembly string `json:"disassembly"`
+}
+
+type evalVector struct {
+	Name string `json:"name"`
+
+	// Tx is the hex-encoded transaction whose input
+	// at index Input is verified.
+	Tx    chainjson.HexBytes `json:"tx"`
+	Input int                `json:"input"`
+
+	// OK is whether the input's program succeeds. A program
+	// that fails with an error is not OK.
+	OK bool `json:"ok"`
+}
+
+// The fixed values the vectors are built from. Everything
+// is deterministic, so that gen always prints the same vectors.
+var (
+	initialBlock = hashOf(1)
+	prevTx       = hashOf(2)
+	otherAsset   = bc.AssetID(hashOf(3))
+	nonce        = []byte{0xde, 0xad, 0xbe, 0xef, 0, 0, 0, 1}
+	longRefData  = bytes.Repeat([]byte("ref"), 100)
+	trueProg     = mustAssemble("TRUE")
+)
+
+func hashOf(b byte) (h bc.Hash) {
+	for i := range h {
+		h[i] = b
+	}
+	return h
+}
+
+func mustAssemble(asm string) []byte {
+	prog, err := vm.Assemble(asm)
+	if err != nil {
+		panic(err)
+	}
+	return prog
+}
+
+func generate() (*vectors, error) {
+	v := new(vectors)
+
+	issuanceProg := mustAssemble("1 2 ADD 3 NUMEQUAL")
+	assetID := bc.ComputeAssetID(issuanceProg, initialBlock, 1)
+
+	txs := []struct {
+		name string
+		data bc.TxData
+	}{
+		{"empty", bc.TxData{Version: 1}},
+		{"issuance", bc.TxData{
+			Version: 1,
+			MinTime: 1482192000000,
+			MaxTime: 1482192300000,
+			Inputs: []*bc.TxInput{
+				bc.NewIssuanceInput(nonce, 1000, []byte("issue"), initialBlock, issuanceProg, [][]byte{{1}, {}}),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(assetID, 1000, trueProg, nil),
+			},
+		}},
+		{"spends", bc.TxData{
+			Version: 1,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(prevTx, 0, [][]byte{[]byte("arg")}, assetID, 600, trueProg, nil),
+				bc.NewSpendInput(prevTx, 1, nil, otherAsset, 1<<40, trueProg, []byte("in")),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(assetID, 600, trueProg, []byte("out")),
+				bc.NewTxOutput(otherAsset, 1<<40-1, trueProg, nil),
+				bc.NewTxOutput(otherAsset, 1, mustAssemble("FAIL"), nil),
+			},
+			ReferenceData: longRefData,
+		}},
+	}
+	for _, c := range txs {
+		tx := bc.NewTx(c.data)
+		text, err := tx.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		tv := txVector{
+			Name:           c.name,
+			ID:             tx.Hash,
+			WitnessHash:    tx.WitnessHash(),
+			SigHashes:      []bc.Hash{},
+			SerializedSize: tx.SerializedSize(),
+		}
+		tv.Hex, err = hex.DecodeString(string(text))
+		if err != nil {
+			return nil, err
+		}
+		hasher := bc.NewSigHasher(&tx.TxData)
+		for i := range tx.Inputs {
+			tv.SigHashes = append(tv.SigHashes, hasher.Hash(i))
+		}
+		v.Transactions = append(v.Transactions, tv)
+	}
+
+	blocks := []struct {
+		name string
+		txs  []*bc.Tx
+	}{
+		{"no transactions", nil},
+		{"one transaction", []*bc.Tx{bc.NewTx(txs[1].data)}},
+		{"three transactions", []*bc.Tx{bc.NewTx(txs[0].data), bc.NewTx(txs[1].data), bc.NewTx(txs[2].data)}},
+	}
+	for i, c := range blocks {
+		b := &bc.Block{
+			BlockHeader: bc.BlockHeader{
+				Version:                bc.NewBlockVersion,
+				Height:                 uint64(i + 2),
+				PreviousBlockHash:      initialBlock,
+				TimestampMS:            1482192000000 + uint64(i),
+				TransactionsMerkleRoot: validation.CalcMerkleRoot(c.txs),
+				AssetsMerkleRoot:       hashOf(4),
+				ConsensusProgram:       trueProg,
+				Witness:                [][]byte{[]byte("witness")},
+			},
+			Transactions: c.txs,
+		}
+		text, err := b.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		bv := blockVector{
+			Name:                   c.name,
+			Hash:                   b.Hash(),
+			TransactionsMerkleRoot: b.TransactionsMerkleRoot,
+		}
+		bv.Hex, err = hex.DecodeString(string(text))
+		if err != nil {
+			return nil, err
+		}
+		v.Blocks = append(v.Blocks, bv)
+	}
+
+	assets := []struct {
+		name string
+		prog []byte
+		vmv  uint64
+	}{
+		{"empty program", nil, 1},
+		{"true program", trueProg, 1},
+		{"issuance program", issuanceProg, 1},
+		{"vm version 2", trueProg, 2},
+	}
+	for _, c := range assets {
+		v.AssetIDs = append(v.AssetIDs, assetIDVector{
+			Name:             c.name,
+			IssuanceProgram:  c.prog,
+			InitialBlockHash: initialBlock,
+			VMVersion:        c.vmv,
+			AssetID:          bc.ComputeAssetID(c.prog, initialBlock, c.vmv),
+		})
+	}
+
+	programs := []struct{ name, asm string }{
+		{"empty", ""},
+		{"small ints", "0 1 16 17 -1"},
+		{"large int", "9223372036854775807"},
+		{"data", "0x0102 'abc'"},
+		{"pushdata1", "0x" + hex.EncodeToString(bytes.Repeat([]byte{0xaa}, 80))},
+		{"jumps", "1 JUMPIF:$a FAIL $a TRUE"},
+		{"arithmetic", "1 2 ADD 3 NUMEQUAL"},
+		{"introspection", "TXSIGHASH 0x" + hex.EncodeToString(make([]byte, 32)) + " CHECKSIG"},
+	}
+	for _, c := range programs {
+		prog, err := vm.Assemble(c.asm)
+		if err != nil {
+			return nil, err
+		}
+		dis, err := vm.Disassemble(prog)
+		if err != nil {
+			return nil, err
+		}
+		v.Programs = append(v.Programs, programVector{
+			Name:        c.name,
+			Asm:         c.asm,
+			Hex:         prog,
+			Disassembly: dis,
+		})
+	}
+
+	evals, err := evaluations(issuanceProg, assetID)
+	if err != nil {
+		return nil, err
+	}
+	v.Evaluations = evals
+	return v, nil
+}
+
+// evaluations returns vectors for single-input transactions
+// whose programs exercise the VM's arithmetic, control flow,
+// signature checking, and transaction introspection.
+func evaluations(issuanceProg []byte, assetID bc.AssetID) ([]evalVector, error) {
+	seed := bytes.Repeat([]byte{7}, 32)
+	pub, priv, err := ed25519.GenerateKey(bytes.NewReader(seed))
+	if err != nil {
+		return nil, err
+	}
+	sigProg := mustAssemble("TXSIGHASH 0x" + hex.EncodeToString(pub) + " CHECKSIG")
+
+	spend := func(prog []byte, args [][]byte) *bc.TxData {
+		return &bc.TxData{
+			Version: 1,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(prevTx, 0, args, assetID, 5, prog, nil),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(assetID, 5, trueProg, nil),
+			},
+		}
+	}
+	signed := func(msgFn func(bc.Hash) []byte) *bc.TxData {
+		tx := spend(sigProg, nil)
+		h := tx.HashForSig(0)
+		tx.Inputs[0].SetArguments([][]byte{ed25519.Sign(priv, msgFn(h))})
+		return tx
+	}
+
+	cases := []struct {
+		name string
+		tx   *bc.TxData
+	}{
+		{"true", spend(trueProg, nil)},
+		{"false", spend(mustAssemble("FALSE"), nil)},
+		{"fail", spend(mustAssemble("FAIL"), nil)},
+		{"arithmetic", spend(mustAssemble("ADD 5 NUMEQUAL"), [][]byte{{2}, {3}})},
+		{"arithmetic wrong", spend(mustAssemble("ADD 5 NUMEQUAL"), [][]byte{{2}, {4}})},
+		{"division by zero", spend(mustAssemble("1 0 DIV"), nil)},
+		{"jump", spend(mustAssemble("JUMPIF:$a FAIL $a TRUE"), [][]byte{{1}})},
+		{"jump not taken", spend(mustAssemble("JUMPIF:$a FAIL $a TRUE"), [][]byte{{}})},
+		{"signature", signed(func(h bc.Hash) []byte { return h[:] })},
+		{"signature on other message", signed(func(h bc.Hash) []byte {
+			h[0] ^= 1
+			return h[:]
+		})},
+		{"check output", spend(mustAssemble("0 0 5 0x"+hex.EncodeToString(assetID[:])+" 1 0x"+hex.EncodeToString(trueProg)+" CHECKOUTPUT"), nil)},
+		{"check output wrong amount", spend(mustAssemble("0 0 6 0x"+hex.EncodeToString(assetID[:])+" 1 0x"+hex.EncodeToString(trueProg)+" CHECKOUTPUT"), nil)},
+		{"issuance", &bc.TxData{
+			Version: 1,
+			Inputs: []*bc.TxInput{
+				bc.NewIssuanceInput(nonce, 5, nil, initialBlock, issuanceProg, nil),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(assetID, 5, trueProg, nil),
+			},
+		}},
+	}
+
+	var evals []evalVector
+	for _, c := range cases {
+		tx := bc.NewTx(*c.tx)
+		text, err := tx.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		ok, _ := vm.VerifyTxInput(tx, 0)
+		ev := evalVector{Name: c.name, OK: ok}
+		ev.Tx, err = hex.DecodeString(string(text))
+		if err != nil {
+			return nil, err
+		}
+		evals = append(evals, ev)
+	}
+	return evals, nil
+}
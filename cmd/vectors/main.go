@@ -0,0 +1,92 @@
+// Command vectors prints test vectors for the Chain protocol's
+// serialization, hashing, and virtual machine, and checks the
+// results of other implementations against them.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const help = `
+Command vectors prints canonical test vectors for the data
+structures in protocol/bc and the programs run by protocol/vm,
+or checks another implementation's results against them.
+
+	vectors gen >vectors.json
+	vectors check results.json
+
+The vectors are a JSON object with one array per kind of vector.
+Each vector has a name, the inputs to compute from, and the
+expected results:
+
+	transactions  hex → id, witness_hash, sighashes, serialized_size
+	blocks        hex → hash, transactions_merkle_root
+	asset_ids     issuance_program, initial_block_hash,
+	              vm_version → asset_id
+	programs      asm → hex, disassembly
+	evaluations   tx, input → ok
+
+An implementation under test reads vectors.json, computes the
+results itself, and writes them in the same form. Check compares
+them with the expected results and reports every difference.
+Vectors and fields left out of the results are skipped, so an
+implementation may be checked one kind of vector at a time.
+`
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	os.Exit(1)
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, help)
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 1 {
+		fmt.Println(strings.TrimSpace(help))
+		return
+	}
+
+	v, err := generate()
+	if err != nil {
+		fatalf("error generating vectors: %s\n", err)
+	}
+
+	switch args[0] {
+	case "gen":
+		j, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			fatalf("error json-marshaling: %s\n", err)
+		}
+		fmt.Println(string(j))
+	case "check":
+		if len(args) != 2 {
+			fatalf("usage: vectors check results.json\n")
+		}
+		results, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			fatalf("%s\n", err)
+		}
+		r, err := check(v, results)
+		if err != nil {
+			fatalf("error checking results: %s\n", err)
+		}
+		for _, m := range r.Mismatches {
+			fmt.Println(m)
+		}
+		fmt.Printf("%d passed, %d failed, %d skipped\n", r.Passed, len(r.Mismatches), r.Skipped)
+		if len(r.Mismatches) > 0 {
+			os.Exit(1)
+		}
+	default:
+		fatalf("unknown command %q\n", args[0])
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// The vectors in testdata are the ones published for other
+// implementations. After a deliberate protocol change,
+// regenerate them with
+//
+//	go test chain/cmd/vectors -update
+var update = flag.Bool("update", false, "rewrite testdata/vectors.json")
+
+const vectorsFile = "testdata/vectors.json"
+
+func TestGoldenVectors(t *testing.T) {
+	v, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		err = ioutil.WriteFile(vectorsFile, got, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated vectors differ from %s; if the change is deliberate, run with -update", vectorsFile)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	v, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := check(v, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(v.Transactions) + len(v.Blocks) + len(v.AssetIDs) + len(v.Programs) + len(v.Evaluations)
+	if r.Passed != total || r.Skipped != 0 || len(r.Mismatches) != 0 {
+		t.Errorf("check(own results) = %+v, want %d passed", r, total)
+	}
+
+	// Drop all but one kind of vector, and get
+	// one field of one vector wrong.
+	bad := v.Transactions[1]
+	bad.ID[0] ^= 1
+	partial := &vectors{Transactions: []txVector{v.Transactions[0], bad}}
+	results, err = json.Marshal(partial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = check(v, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Passed != 1 || r.Skipped != total-2 || len(r.Mismatches) != 1 {
+		t.Fatalf("check(partial results) = %+v, want 1 passed, %d skipped, 1 mismatch", r, total-2)
+	}
+	if !strings.HasPrefix(r.Mismatches[0], `transactions "issuance": id is`) {
+		t.Errorf("mismatch = %q, want it to name the transaction and field", r.Mismatches[0])
+	}
+}
@@ -2,19 +2,36 @@ package core
 
 import (
 	"context"
-	"errors"
 
 	"chain/core/accesstoken"
+	"chain/errors"
 	"chain/net/http/httpjson"
 )
 
 var errCurrentToken = errors.New("token cannot delete itself")
 
-func (h *Handler) createAccessToken(ctx context.Context, x struct{ ID, Type string }) (*accesstoken.Token, error) {
-	return h.AccessTokens.Create(ctx, x.ID, x.Type)
+func (h *Handler) createAccessToken(ctx context.Context, x struct {
+	ID, Type     string
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+}) (*accesstoken.Token, error) {
+	if x.AccountID == "" && x.AccountAlias == "" {
+		return h.AccessTokens.Create(ctx, x.ID, x.Type)
+	}
+	if x.Type != "" && x.Type != "client" {
+		return nil, errors.WithDetail(accesstoken.ErrBadType, "account-scoped tokens must be client tokens")
+	}
+	if x.AccountID == "" {
+		acc, err := h.Accounts.FindByAlias(ctx, x.AccountAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account alias %s", x.AccountAlias)
+		}
+		x.AccountID = acc.ID
+	}
+	return h.AccessTokens.CreateForAccount(ctx, x.ID, x.AccountID)
 }
 
-func (h *Handler) listAccessTokens(ctx context.Context, x requestQuery) (*page, error) {
+func (h *Handler) listAccessTokens(ctx context.Context, x QueryRequest) (*Page, error) {
 	limit := x.PageSize
 	if limit == 0 {
 		limit = defGenericPageSize
@@ -28,7 +45,7 @@ func (h *Handler) listAccessTokens(ctx context.Context, x requestQuery) (*page,
 	outQuery := x
 	x.After = next
 
-	return &page{
+	return &Page{
 		Items:    httpjson.Array(tokens),
 		LastPage: len(tokens) < limit,
 		Next:     outQuery,
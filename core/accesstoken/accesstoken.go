@@ -36,7 +36,12 @@ type Token struct {
 	Token   string    `json:"token,omitempty"`
 	Type    string    `json:"type"`
 	Created time.Time `json:"created_at"`
-	sortID  string
+
+	// AccountID, if set, limits a client token
+	// to operations on a single account.
+	AccountID string `json:"account_id,omitempty"`
+
+	sortID string
 }
 
 type CredentialStore struct {
@@ -45,6 +50,16 @@ type CredentialStore struct {
 
 // Create generates a new access token with the given ID.
 func (cs *CredentialStore) Create(ctx context.Context, id, typ string) (*Token, error) {
+	return cs.create(ctx, id, typ, "")
+}
+
+// CreateForAccount generates a new client access token with the
+// given ID, limited to operations on the account with accountID.
+func (cs *CredentialStore) CreateForAccount(ctx context.Context, id, accountID string) (*Token, error) {
+	return cs.create(ctx, id, "client", accountID)
+}
+
+func (cs *CredentialStore) create(ctx context.Context, id, typ, accountID string) (*Token, error) {
 	if !validIDRegexp.MatchString(id) {
 		return nil, errors.WithDetailf(ErrBadID, "invalid id %q", id)
 	}
@@ -62,15 +77,15 @@ func (cs *CredentialStore) Create(ctx context.Context, id, typ string) (*Token,
 	sha3pool.Sum256(hashedSecret[:], secret[:])
 
 	const q = `
-		INSERT INTO access_tokens (id, type, hashed_secret)
-		VALUES($1, $2, $3)
+		INSERT INTO access_tokens (id, type, hashed_secret, account_id)
+		VALUES($1, $2, $3, NULLIF($4, ''))
 		RETURNING created, sort_id
 	`
 	var (
 		created time.Time
 		sortID  string
 	)
-	err = cs.DB.QueryRow(ctx, q, id, typ, hashedSecret[:]).Scan(&created, &sortID)
+	err = cs.DB.QueryRow(ctx, q, id, typ, hashedSecret[:], accountID).Scan(&created, &sortID)
 	if pg.IsUniqueViolation(err) {
 		return nil, errors.WithDetailf(ErrDuplicateID, "id %q already in use", id)
 	}
@@ -79,16 +94,24 @@ func (cs *CredentialStore) Create(ctx context.Context, id, typ string) (*Token,
 	}
 
 	return &Token{
-		ID:      id,
-		Token:   fmt.Sprintf("%s:%x", id, secret),
-		Type:    typ,
-		Created: created,
-		sortID:  sortID,
+		ID:        id,
+		Token:     fmt.Sprintf("%s:%x", id, secret),
+		Type:      typ,
+		Created:   created.UTC(),
+		AccountID: accountID,
+		sortID:    sortID,
 	}, nil
 }
 
 // Check returns whether or not an id-secret pair is a valid access token.
 func (cs *CredentialStore) Check(ctx context.Context, id, typ string, secret []byte) (bool, error) {
+	valid, _, err := cs.Lookup(ctx, id, typ, secret)
+	return valid, err
+}
+
+// Lookup is like Check, but also returns the account ID
+// the token is limited to, if any.
+func (cs *CredentialStore) Lookup(ctx context.Context, id, typ string, secret []byte) (valid bool, accountID string, err error) {
 	var (
 		toHash [tokenSize]byte
 		hashed [32]byte
@@ -96,14 +119,16 @@ func (cs *CredentialStore) Check(ctx context.Context, id, typ string, secret []b
 	copy(toHash[:], secret)
 	sha3pool.Sum256(hashed[:], toHash[:])
 
-	const q = `SELECT EXISTS(SELECT 1 FROM access_tokens WHERE id=$1 AND type=$2 AND hashed_secret=$3)`
-	var valid bool
-	err := cs.DB.QueryRow(ctx, q, id, typ, hashed[:]).Scan(&valid)
+	const q = `
+		SELECT COUNT(*) > 0, COALESCE(MIN(account_id), '') FROM access_tokens
+		WHERE id=$1 AND type=$2 AND hashed_secret=$3
+	`
+	err = cs.DB.QueryRow(ctx, q, id, typ, hashed[:]).Scan(&valid, &accountID)
 	if err != nil {
-		return false, err
+		return false, "", err
 	}
 
-	return valid, nil
+	return valid, accountID, nil
 }
 
 // List lists all access tokens.
@@ -112,18 +137,19 @@ func (cs *CredentialStore) List(ctx context.Context, typ, after string, limit in
 		limit = defaultLimit
 	}
 	const q = `
-		SELECT id, type, sort_id, created FROM access_tokens
+		SELECT id, type, sort_id, created, COALESCE(account_id, '') FROM access_tokens
 		WHERE ($1='' OR type=$1::access_token_type) AND ($2='' OR sort_id<$2)
 		ORDER BY sort_id DESC
 		LIMIT $3
 	`
 	var tokens []*Token
-	err := pg.ForQueryRows(ctx, cs.DB, q, typ, after, limit, func(id, typ, sortID string, created time.Time) {
+	err := pg.ForQueryRows(ctx, cs.DB, q, typ, after, limit, func(id, typ, sortID string, created time.Time, accountID string) {
 		tokens = append(tokens, &Token{
-			ID:      id,
-			Type:    typ,
-			Created: created,
-			sortID:  sortID,
+			ID:        id,
+			Type:      typ,
+			Created:   created.UTC(),
+			AccountID: accountID,
+			sortID:    sortID,
 		})
 	})
 	if err != nil {
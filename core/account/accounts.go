@@ -5,24 +5,36 @@ import (
 	"context"
 	stdsql "database/sql"
 	"encoding/json"
+	"math"
 	"sync"
 	"time"
 
 	"github.com/golang/groupcache/lru"
+	"github.com/lib/pq"
 
 	"chain/core/account/utxodb"
 	"chain/core/signers"
+	"chain/core/txbuilder"
 	"chain/crypto/ed25519/chainkd"
 	"chain/database/pg"
 	"chain/database/sql"
 	"chain/errors"
 	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
 	"chain/protocol/vmutil"
 )
 
 const maxAccountCache = 100
 
-var ErrDuplicateAlias = errors.New("duplicate account alias")
+// RestoreWindow is how long after archiving an account can be restored.
+const RestoreWindow = 30 * 24 * time.Hour
+
+var (
+	ErrDuplicateAlias = errors.New("duplicate account alias")
+	ErrRestoreWindow  = errors.New("account restore window has passed")
+	ErrBadUnlockTime  = errors.New("invalid unlock time")
+)
 
 func NewManager(db *sql.DB, chain *protocol.Chain) *Manager {
 	return &Manager{
@@ -39,6 +51,9 @@ type Manager struct {
 	chain   *protocol.Chain
 	utxoDB  *utxodb.Reserver
 	indexer Saver
+	change  ChangePolicy
+
+	singleUse bool
 
 	cacheMu sync.Mutex
 	cache   *lru.Cache
@@ -53,16 +68,89 @@ func (m *Manager) IndexAccounts(indexer Saver) {
 	m.chain.AddBlockCallback(m.indexAccountUTXOs)
 }
 
+// SpendConfirmedOnly sets whether spend actions may reserve outputs
+// of transactions still waiting to be confirmed. By default they
+// may, so a new transaction can chain on the change of one that
+// is still pending. It must be called before m is used.
+func (m *Manager) SpendConfirmedOnly(confirmedOnly bool) {
+	m.utxoDB.ConfirmedOnly = confirmedOnly
+}
+
+// ChangePolicy controls how spend actions pay change back
+// to the account.
+//
+// A single change output concentrates an account's funds in
+// one output, which only one transaction at a time can spend.
+// Splitting change keeps several outputs available, so that
+// busy accounts can build transactions in parallel.
+type ChangePolicy struct {
+	// Outputs is the number of outputs to split change into.
+	// Zero or one makes a single change output.
+	Outputs int
+
+	// MinAmounts gives the smallest change output a split
+	// may make for each asset. Change too small to split
+	// Outputs ways is split fewer ways.
+	MinAmounts txbuilder.MinOutputAmounts
+}
+
+// SplitChange sets the policy for change made by spend actions.
+// By default, change is a single output. It must be called
+// before m is used.
+func (m *Manager) SplitChange(p ChangePolicy) {
+	m.change = p
+}
+
+// split returns the amounts of the change outputs
+// for amount units of assetID.
+func (p ChangePolicy) split(assetID bc.AssetID, amount uint64) []uint64 {
+	n := uint64(1)
+	if p.Outputs > 1 {
+		n = uint64(p.Outputs)
+	}
+	if min := p.MinAmounts[assetID]; min > 0 && amount/min < n {
+		n = amount / min
+	}
+	if n > amount {
+		n = amount
+	}
+	if n <= 1 {
+		return []uint64{amount}
+	}
+	parts := make([]uint64, n)
+	for i := range parts {
+		parts[i] = amount / n
+	}
+	parts[0] += amount % n
+	return parts
+}
+
 // ExpireReservations removes reservations that have expired periodically.
 // It blocks until the context is canceled.
 func (m *Manager) ExpireReservations(ctx context.Context, period time.Duration) {
 	m.utxoDB.ExpireReservations(ctx, period)
 }
 
+// CancelReservations releases the reservations holding any of outs.
+// It returns the number of reservations released.
+func (m *Manager) CancelReservations(ctx context.Context, outs []bc.Outpoint) (int64, error) {
+	return m.utxoDB.Cancel(ctx, outs)
+}
+
+// ExtendReservations makes the reservations holding any of outs
+// expire at exp instead. It returns the number of reservations changed.
+func (m *Manager) ExtendReservations(ctx context.Context, outs []bc.Outpoint, exp time.Time) (int64, error) {
+	return m.utxoDB.Extend(ctx, outs, exp)
+}
+
 type Account struct {
 	*signers.Signer
 	Alias string
 	Tags  map[string]interface{}
+
+	// ArchivedAt is the time the account was archived,
+	// or nil if it is not archived.
+	ArchivedAt *time.Time
 }
 
 // Create creates a new Account.
@@ -121,6 +209,114 @@ func (m *Manager) FindByAlias(ctx context.Context, alias string) (*signers.Signe
 	return m.findByID(ctx, accountID)
 }
 
+// Archive archives the account with ID id, hiding it from default
+// account listings. Its control programs, outputs, and history are
+// kept, and it can be restored within RestoreWindow. Archiving an
+// archived account has no effect.
+func (m *Manager) Archive(ctx context.Context, id string) (*Account, error) {
+	const q = `UPDATE accounts SET archived_at = COALESCE(archived_at, now()) WHERE account_id=$1`
+	return m.update(ctx, id, q)
+}
+
+// Restore restores the archived account with ID id,
+// if it was archived less than RestoreWindow ago.
+func (m *Manager) Restore(ctx context.Context, id string) (*Account, error) {
+	acc, err := m.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if acc.ArchivedAt == nil {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "account %s is not archived", id)
+	}
+	if time.Since(*acc.ArchivedAt) > RestoreWindow {
+		return nil, errors.WithDetailf(ErrRestoreWindow, "account %s was archived at %s", id, acc.ArchivedAt.Format(time.RFC3339))
+	}
+	const q = `UPDATE accounts SET archived_at = NULL WHERE account_id=$1`
+	return m.update(ctx, id, q)
+}
+
+// UpdateTags patches the tags of the account with ID id.
+// Each tag in patch is set to its new value, or removed if
+// the value is nil; tags not in patch are left as they are.
+func (m *Manager) UpdateTags(ctx context.Context, id string, patch map[string]interface{}) (*Account, error) {
+	set := make(map[string]interface{})
+	remove := pq.StringArray{}
+	for k, v := range patch {
+		if v == nil {
+			remove = append(remove, k)
+		} else {
+			set[k] = v
+		}
+	}
+	setJSON, err := json.Marshal(set)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	const q = `
+		UPDATE accounts
+		SET tags = NULLIF((COALESCE(tags, '{}') || $2::jsonb) - $3::text[], '{}')
+		WHERE account_id=$1
+	`
+	return m.update(ctx, id, q, string(setJSON), remove)
+}
+
+// update runs the update q on the account with ID id,
+// then reindexes the account. Args are the parameters of q
+// after id.
+func (m *Manager) update(ctx context.Context, id string, q string, args ...interface{}) (*Account, error) {
+	res, err := m.db.Exec(ctx, q, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if n == 0 {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "account id: %s", id)
+	}
+	acc, err := m.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = m.indexAnnotatedAccount(ctx, acc)
+	if err != nil {
+		return nil, errors.Wrap(err, "indexing annotated account")
+	}
+	return acc, nil
+}
+
+// find returns the account with ID id.
+func (m *Manager) find(ctx context.Context, id string) (*Account, error) {
+	signer, err := m.findByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	const q = `SELECT alias, tags, archived_at FROM accounts WHERE account_id=$1`
+	var (
+		alias      stdsql.NullString
+		tags       []byte
+		archivedAt pq.NullTime
+	)
+	err = m.db.QueryRow(ctx, q, id).Scan(&alias, &tags, &archivedAt)
+	if err == stdsql.ErrNoRows {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "account id: %s", id)
+	} else if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	acc := &Account{Signer: signer, Alias: alias.String}
+	if len(tags) > 0 {
+		err = json.Unmarshal(tags, &acc.Tags)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+	}
+	if archivedAt.Valid {
+		acc.ArchivedAt = &archivedAt.Time
+	}
+	return acc, nil
+}
+
 // findByID returns an account's Signer record by its ID.
 func (m *Manager) findByID(ctx context.Context, id string) (*signers.Signer, error) {
 	m.cacheMu.Lock()
@@ -159,7 +355,7 @@ func (m *Manager) CreateControlProgram(ctx context.Context, accountID string, ch
 	if err != nil {
 		return nil, err
 	}
-	err = m.insertAccountControlProgram(ctx, account.ID, idx, control, change)
+	err = m.insertAccountControlProgram(ctx, account.ID, idx, control, change, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -167,12 +363,54 @@ func (m *Manager) CreateControlProgram(ctx context.Context, accountID string, ch
 	return control, nil
 }
 
-func (m *Manager) insertAccountControlProgram(ctx context.Context, accountID string, idx uint64, control []byte, change bool) error {
+// CreateTimelockedControlProgram creates a control program tied
+// to the Account, like CreateControlProgram, that cannot be spent
+// before the millisecond timestamp unlockTime. Outputs to it
+// count toward the account's balance, but spend actions pass
+// them over until they unlock.
+func (m *Manager) CreateTimelockedControlProgram(ctx context.Context, accountID string, unlockTime uint64) ([]byte, error) {
+	if unlockTime == 0 || unlockTime > math.MaxInt64 {
+		return nil, errors.WithDetail(ErrBadUnlockTime, "unlock time must be positive and at most 2^63-1")
+	}
+	account, err := m.findByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := m.nextIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	path := signers.Path(account, signers.AccountKeySpace, idx)
+	derivedXPubs := chainkd.DeriveXPubs(account.XPubs, path)
+	derivedPKs := chainkd.XPubKeys(derivedXPubs)
+	multisig, err := vmutil.P2SPMultiSigProgram(derivedPKs, account.Quorum)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature arguments for the multisig program are
+	// left on the stack for it, so the usual signing
+	// instructions for the key index still apply.
+	b := vmutil.NewBuilder()
+	b.AddOp(vm.OP_MINTIME).AddInt64(int64(unlockTime)).AddOp(vm.OP_GREATERTHANOREQUAL).AddOp(vm.OP_VERIFY)
+	b.AddRawBytes(multisig)
+	control := b.Program
+
+	err = m.insertAccountControlProgram(ctx, account.ID, idx, control, false, unlockTime)
+	if err != nil {
+		return nil, err
+	}
+	return control, nil
+}
+
+func (m *Manager) insertAccountControlProgram(ctx context.Context, accountID string, idx uint64, control []byte, change bool, unlockTime uint64) error {
 	const q = `
-		INSERT INTO account_control_programs (signer_id, key_index, control_program, change)
-		VALUES($1, $2, $3, $4)
+		INSERT INTO account_control_programs (signer_id, key_index, control_program, change, unlock_time)
+		VALUES($1, $2, $3, $4, NULLIF($5::bigint, 0))
 	`
-	_, err := m.db.Exec(ctx, q, accountID, idx, control, change)
+	_, err := m.db.Exec(ctx, q, accountID, idx, control, change, int64(unlockTime))
 	return errors.Wrap(err)
 }
 
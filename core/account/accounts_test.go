@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"testing"
 
+	"chain/database/pg"
 	"chain/database/pg/pgtest"
 	"chain/errors"
 	"chain/protocol/prottest"
@@ -147,3 +148,72 @@ func TestFindByAlias(t *testing.T) {
 		t.Errorf("expected found account to be %v, instead found %v", account, found)
 	}
 }
+
+func TestArchiveRestore(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	m := NewManager(db, prottest.NewChain(t))
+	ctx := context.Background()
+	account := m.createTestAccount(ctx, t, "", nil)
+
+	archived, err := m.Archive(ctx, account.ID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if archived.ArchivedAt == nil {
+		t.Fatal("archived account has no archive time")
+	}
+	restored, err := m.Restore(ctx, account.ID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if restored.ArchivedAt != nil {
+		t.Errorf("restored account has archive time %s", restored.ArchivedAt)
+	}
+	_, err = m.Restore(ctx, account.ID)
+	if errors.Root(err) != pg.ErrUserInputNotFound {
+		t.Errorf("restoring unarchived account: got error %v, want %v", err, pg.ErrUserInputNotFound)
+	}
+
+	// Past the restore window, the account stays archived.
+	_, err = m.Archive(ctx, account.ID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = db.Exec(ctx, `UPDATE accounts SET archived_at = now() - interval '31 days' WHERE account_id=$1`, account.ID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = m.Restore(ctx, account.ID)
+	if errors.Root(err) != ErrRestoreWindow {
+		t.Errorf("restoring after window: got error %v, want %v", err, ErrRestoreWindow)
+	}
+}
+
+func TestUpdateTags(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	m := NewManager(db, prottest.NewChain(t))
+	ctx := context.Background()
+	account := m.createTestAccount(ctx, t, "", map[string]interface{}{"region": "US", "unit": "retail"})
+
+	updated, err := m.UpdateTags(ctx, account.ID, map[string]interface{}{"region": "EU", "unit": nil, "desk": "fx"})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	want := map[string]interface{}{"region": "EU", "desk": "fx"}
+	if !reflect.DeepEqual(updated.Tags, want) {
+		t.Errorf("tags = %v, want %v", updated.Tags, want)
+	}
+
+	updated, err = m.UpdateTags(ctx, account.ID, map[string]interface{}{"region": nil, "desk": nil})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if updated.Tags != nil {
+		t.Errorf("tags = %v, want none", updated.Tags)
+	}
+
+	_, err = m.UpdateTags(ctx, "nonexistent", nil)
+	if errors.Root(err) != pg.ErrUserInputNotFound {
+		t.Errorf("updating nonexistent account: got error %v, want %v", err, pg.ErrUserInputNotFound)
+	}
+}
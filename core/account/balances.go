@@ -0,0 +1,89 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// Balance is an account's holdings of one asset.
+//
+// Confirmed counts only outputs in blocks. Pending also counts
+// transactions submitted by this core that are not yet in a
+// block: their outputs to the account are added, and the
+// outputs they spend are subtracted. Transactions submitted
+// by other cores are not known until they land.
+type Balance struct {
+	AssetID   bc.AssetID `json:"asset_id"`
+	Confirmed uint64     `json:"confirmed_amount"`
+	Pending   uint64     `json:"pending_amount"`
+}
+
+// Balances returns accountID's balances, ordered by asset ID.
+func (m *Manager) Balances(ctx context.Context, accountID string) ([]Balance, error) {
+	const q = `
+		SELECT asset_id,
+			COALESCE(SUM(amount) FILTER (WHERE confirmed_in IS NOT NULL), 0),
+			COALESCE(SUM(amount) FILTER (WHERE pending_spend_expiry_height IS NULL), 0)
+		FROM account_utxos WHERE account_id = $1
+		GROUP BY asset_id ORDER BY asset_id
+	`
+	rows, err := m.db.Query(ctx, q, accountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying account balances")
+	}
+	defer rows.Close()
+
+	var balances []Balance
+	for rows.Next() {
+		var (
+			assetID string
+			b       Balance
+		)
+		err = rows.Scan(&assetID, &b.Confirmed, &b.Pending)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning account balance")
+		}
+		err = b.AssetID.UnmarshalText([]byte(assetID))
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding asset id")
+		}
+		balances = append(balances, b)
+	}
+	return balances, errors.Wrap(rows.Err())
+}
+
+// SpendableBalances returns the amounts of each asset that
+// accountID can spend now without waiting: the sum of its
+// confirmed outputs that are not reserved, time-locked, or
+// spent by a pending transaction. They are ordered by asset ID,
+// and assets with nothing spendable are left out.
+func (m *Manager) SpendableBalances(ctx context.Context, accountID string) ([]bc.AssetAmount, error) {
+	const q = `
+		SELECT asset_id, SUM(amount) FROM account_utxos
+		WHERE account_id = $1
+			AND confirmed_in IS NOT NULL
+			AND reservation_id IS NULL
+			AND pending_spend_expiry_height IS NULL
+			AND (unlock_time IS NULL OR unlock_time <= $2)
+		GROUP BY asset_id ORDER BY asset_id
+	`
+	rows, err := m.db.Query(ctx, q, accountID, bc.Millis(time.Now()))
+	if err != nil {
+		return nil, errors.Wrap(err, "querying spendable balances")
+	}
+	defer rows.Close()
+
+	var amounts []bc.AssetAmount
+	for rows.Next() {
+		var a bc.AssetAmount
+		err = rows.Scan(&a.AssetID, &a.Amount)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning spendable balance")
+		}
+		amounts = append(amounts, a)
+	}
+	return amounts, errors.Wrap(rows.Err())
+}
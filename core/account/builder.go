@@ -2,12 +2,14 @@ package account
 
 import (
 	"context"
+	stdsql "database/sql"
 	"encoding/json"
 	"time"
 
 	"chain/core/account/utxodb"
 	"chain/core/signers"
 	"chain/core/txbuilder"
+	"chain/database/pg"
 	chainjson "chain/encoding/json"
 	"chain/errors"
 	"chain/protocol/bc"
@@ -55,8 +57,7 @@ func (a *spendAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.
 		OutputIndex: a.TxOut,
 		ClientToken: a.ClientToken,
 	}
-	utxodbSources := []utxodb.Source{utxodbSource}
-	reserved, change, err := a.accounts.utxoDB.Reserve(ctx, utxodbSources, maxTime)
+	reserved, change, err := a.accounts.reserve(ctx, utxodbSource, maxTime)
 	if err != nil {
 		return nil, errors.Wrap(err, "reserving utxos")
 	}
@@ -65,9 +66,15 @@ func (a *spendAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.
 		txins      []*bc.TxInput
 		tplInsts   []*txbuilder.SigningInstruction
 		changeOuts []*bc.TxOutput
+		minTimeMS  uint64
 	)
 
 	for _, r := range reserved {
+		// A time-locked output is reserved only once it has
+		// unlocked, but its program still checks the mintime.
+		if r.UnlockTime > minTimeMS {
+			minTimeMS = r.UnlockTime
+		}
 		txInput, sigInst, err := utxoToInputs(ctx, acct, r, a.ReferenceData)
 		if err != nil {
 			return nil, errors.Wrap(err, "creating inputs")
@@ -76,15 +83,70 @@ func (a *spendAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.
 		txins = append(txins, txInput)
 		tplInsts = append(tplInsts, sigInst)
 	}
-	if len(change) > 0 {
-		acp, err := a.accounts.CreateControlProgram(ctx, a.AccountID, true)
-		if err != nil {
-			return nil, errors.Wrap(err, "creating control program")
+	if change > 0 {
+		for _, amount := range a.accounts.change.split(a.AssetID, change) {
+			acp, err := a.accounts.CreateControlProgram(ctx, a.AccountID, true)
+			if err != nil {
+				return nil, errors.Wrap(err, "creating control program")
+			}
+			changeOuts = append(changeOuts, bc.NewTxOutput(a.AssetID, amount, acp, nil))
 		}
-		changeOuts = append(changeOuts, bc.NewTxOutput(a.AssetID, change[0].Amount, acp, nil))
 	}
 
-	return &txbuilder.BuildResult{Inputs: txins, Outputs: changeOuts, SigningInstructions: tplInsts}, nil
+	return &txbuilder.BuildResult{Inputs: txins, Outputs: changeOuts, SigningInstructions: tplInsts, MinTimeMS: minTimeMS}, nil
+}
+
+// reserve reserves outputs to cover src until exp, and returns
+// them with the amount of change they leave. Change below the
+// asset's minimum output amount would be a dust output, which
+// built transactions may not have, so in that case reserve
+// covers the minimum in addition to src instead, if the account
+// has the funds. Otherwise it settles for the dust change, and
+// the transaction's dust check reports it.
+func (m *Manager) reserve(ctx context.Context, src utxodb.Source, exp time.Time) ([]*utxodb.UTXO, uint64, error) {
+	reserved, change, err := m.reserveSource(ctx, src, exp)
+	if err != nil {
+		return nil, 0, err
+	}
+	min := m.change.MinAmounts[src.AssetID]
+	if change == 0 || change >= min || src.TxHash != nil {
+		return reserved, change, nil
+	}
+
+	var outs []bc.Outpoint
+	for _, r := range reserved {
+		outs = append(outs, r.Outpoint)
+	}
+	_, err = m.utxoDB.Cancel(ctx, outs)
+	if err != nil {
+		return nil, 0, err
+	}
+	more := src
+	more.Amount += min
+	reserved, change, err = m.reserveSource(ctx, more, exp)
+	if root := errors.Root(err); root == utxodb.ErrInsufficient || root == utxodb.ErrReserved {
+		return m.reserveSource(ctx, src, exp)
+	} else if err != nil {
+		return nil, 0, err
+	}
+	return reserved, change + min, nil
+}
+
+// reserveSource reserves outputs to cover src until exp, and
+// returns them with the amount of change they leave. A request
+// retried with a client token gets its earlier reservation back,
+// which reserve may have enlarged, so the change is counted from
+// the outputs rather than taken from the reservation.
+func (m *Manager) reserveSource(ctx context.Context, src utxodb.Source, exp time.Time) ([]*utxodb.UTXO, uint64, error) {
+	reserved, _, err := m.utxoDB.Reserve(ctx, []utxodb.Source{src}, exp)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total uint64
+	for _, r := range reserved {
+		total += r.Amount
+	}
+	return reserved, total - src.Amount, nil
 }
 
 func (m *Manager) NewSpendUTXOAction(outpoint bc.Outpoint) txbuilder.Action {
@@ -106,12 +168,15 @@ type spendUTXOAction struct {
 	TxHash   bc.Hash `json:"transaction_id"`
 	TxOut    uint32  `json:"position"`
 
+	// AccountID, if set, is the account the output must belong to.
+	AccountID string `json:"account_id"`
+
 	ReferenceData chainjson.Map `json:"reference_data"`
 	ClientToken   *string       `json:"client_token"`
 }
 
 func (a *spendUTXOAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
-	r, err := a.accounts.utxoDB.ReserveUTXO(ctx, a.TxHash, a.TxOut, a.ClientToken, maxTime)
+	r, err := a.accounts.utxoDB.ReserveUTXO(ctx, a.TxHash, a.TxOut, a.AccountID, a.ClientToken, maxTime)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +194,7 @@ func (a *spendUTXOAction) Build(ctx context.Context, maxTime time.Time) (*txbuil
 	return &txbuilder.BuildResult{
 		Inputs:              []*bc.TxInput{txInput},
 		SigningInstructions: []*txbuilder.SigningInstruction{sigInst},
+		MinTimeMS:           r.UnlockTime,
 	}, nil
 }
 
@@ -151,6 +217,46 @@ func utxoToInputs(ctx context.Context, account *signers.Signer, u *utxodb.UTXO,
 	return txInput, sigInst, nil
 }
 
+// SigningInstruction returns a signing instruction for an input
+// of amt controlled by prog, which must be a control program
+// created by this manager.
+func (m *Manager) SigningInstruction(ctx context.Context, prog []byte, amt bc.AssetAmount) (*txbuilder.SigningInstruction, error) {
+	const q = `SELECT signer_id, key_index FROM account_control_programs WHERE control_program=$1`
+	var (
+		accountID string
+		keyIndex  uint64
+	)
+	err := m.db.QueryRow(ctx, q, prog).Scan(&accountID, &keyIndex)
+	if err == stdsql.ErrNoRows {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "control program %x", prog)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "looking up control program")
+	}
+	account, err := m.findByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	sigInst := &txbuilder.SigningInstruction{AssetAmount: amt}
+	path := signers.Path(account, signers.AccountKeySpace, keyIndex)
+	sigInst.AddWitnessKeys(txbuilder.KeyIDs(account.XPubs, path), account.Quorum)
+	return sigInst, nil
+}
+
+// ProgramAccount returns the ID of the account that prog
+// belongs to, which must be a control program created by
+// this manager.
+func (m *Manager) ProgramAccount(ctx context.Context, prog []byte) (string, error) {
+	const q = `SELECT signer_id FROM account_control_programs WHERE control_program=$1`
+	var accountID string
+	err := m.db.QueryRow(ctx, q, prog).Scan(&accountID)
+	if err == stdsql.ErrNoRows {
+		return "", errors.WithDetailf(pg.ErrUserInputNotFound, "control program %x", prog)
+	}
+	return accountID, errors.Wrap(err, "looking up control program")
+}
+
 func (m *Manager) NewControlAction(amt bc.AssetAmount, accountID string, refData chainjson.Map) txbuilder.Action {
 	return &controlAction{
 		accounts:      m,
@@ -181,3 +287,28 @@ func (a *controlAction) Build(ctx context.Context, maxTime time.Time) (*txbuilde
 	out := bc.NewTxOutput(a.AssetID, a.Amount, acp, a.ReferenceData)
 	return &txbuilder.BuildResult{Outputs: []*bc.TxOutput{out}}, nil
 }
+
+func (m *Manager) DecodeControlTimelockedAction(data []byte) (txbuilder.Action, error) {
+	a := &controlTimelockedAction{accounts: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// controlTimelockedAction pays to an account, like controlAction,
+// at a control program that cannot be spent before UnlockTime.
+type controlTimelockedAction struct {
+	accounts *Manager
+	bc.AssetAmount
+	AccountID     string           `json:"account_id"`
+	UnlockTime    chainjson.Millis `json:"unlock_time"`
+	ReferenceData chainjson.Map    `json:"reference_data"`
+}
+
+func (a *controlTimelockedAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	acp, err := a.accounts.CreateTimelockedControlProgram(ctx, a.AccountID, uint64(a.UnlockTime))
+	if err != nil {
+		return nil, err
+	}
+	out := bc.NewTxOutput(a.AssetID, a.Amount, acp, a.ReferenceData)
+	return &txbuilder.BuildResult{Outputs: []*bc.TxOutput{out}}, nil
+}
@@ -3,11 +3,13 @@ package account_test
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"chain/core/account"
+	"chain/core/account/utxodb"
 	"chain/core/asset"
 	"chain/core/coretest"
 	"chain/core/query"
@@ -103,6 +105,47 @@ func TestAccountSourceUTXOReserve(t *testing.T) {
 	}
 }
 
+func TestAccountSourceUTXOWrongAccount(t *testing.T) {
+	var (
+		_, db    = pgtest.NewDB(t, pgtest.SchemaPath)
+		ctx      = context.Background()
+		c        = prottest.NewChain(t)
+		assets   = asset.NewRegistry(db, c)
+		accounts = account.NewManager(db, c)
+		indexer  = query.NewIndexer(db, c)
+
+		accID   = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		otherID = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		asset   = coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+		out     = coretest.IssueAssets(ctx, t, c, assets, accounts, asset, 2, accID)
+	)
+
+	// Make a block so that account UTXOs are available to spend.
+	assets.IndexAssets(indexer)
+	accounts.IndexAccounts(indexer)
+	prottest.MakeBlock(t, c)
+
+	decode := func(accountID string) txbuilder.Action {
+		data := fmt.Sprintf(`{"transaction_id": "%s", "position": %d, "account_id": "%s"}`, out.Hash, out.Index, accountID)
+		source, err := accounts.DecodeSpendUTXOAction([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return source
+	}
+
+	_, err := decode(otherID).Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != utxodb.ErrWrongAccount {
+		t.Fatalf("err = %v want %v", err, utxodb.ErrWrongAccount)
+	}
+
+	// The failed attempt must not leave the output reserved.
+	_, err = decode(accID).Build(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
 func TestAccountSourceReserveIdempotency(t *testing.T) {
 	var (
 		_, db    = pgtest.NewDB(t, pgtest.SchemaPath)
@@ -209,6 +252,43 @@ func TestAccountSourceWithTxHash(t *testing.T) {
 	}
 }
 
+func TestAccountSourceConfirmedOnly(t *testing.T) {
+	var (
+		_, db    = pgtest.NewDB(t, pgtest.SchemaPath)
+		ctx      = context.Background()
+		c        = prottest.NewChain(t)
+		assets   = asset.NewRegistry(db, c)
+		accounts = account.NewManager(db, c)
+
+		acc      = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		asset    = coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+		assetAmt = bc.AssetAmount{AssetID: asset, Amount: 1}
+	)
+
+	// Leave the issuance pending, as if it had just been submitted.
+	tx := coretest.Transfer(ctx, t, c, []txbuilder.Action{
+		assets.NewIssueAction(assetAmt, nil),
+		accounts.NewControlAction(assetAmt, acc, nil),
+	})
+	err := accounts.IndexUnconfirmedUTXOs(ctx, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	accounts.SpendConfirmedOnly(true)
+	source := accounts.NewSpendAction(assetAmt, acc, nil, nil, nil, nil)
+	_, err = source.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != utxodb.ErrInsufficient {
+		t.Errorf("spend pending output confirmed-only: got error %v, want %v", err, utxodb.ErrInsufficient)
+	}
+
+	accounts.SpendConfirmedOnly(false)
+	_, err = source.Build(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
 func programInAccount(ctx context.Context, t testing.TB, db pg.DB, program []byte, account string) bool {
 	const q = `SELECT signer_id=$1 FROM account_control_programs WHERE control_program=$2`
 	var in bool
@@ -221,3 +301,83 @@ func programInAccount(ctx context.Context, t testing.TB, db pg.DB, program []byt
 	}
 	return in
 }
+
+func TestAccountSourceTimelocked(t *testing.T) {
+	var (
+		_, db    = pgtest.NewDB(t, pgtest.SchemaPath)
+		ctx      = context.Background()
+		c        = prottest.NewChain(t)
+		assets   = asset.NewRegistry(db, c)
+		accounts = account.NewManager(db, c)
+		indexer  = query.NewIndexer(db, c)
+
+		acc      = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		asset    = coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+		assetAmt = bc.AssetAmount{AssetID: asset, Amount: 1}
+	)
+
+	unlock := bc.Millis(time.Now().Add(time.Hour))
+	control, err := accounts.DecodeControlTimelockedAction([]byte(fmt.Sprintf(
+		`{"asset_id": "%s", "amount": 1, "account_id": "%s", "unlock_time": %d}`,
+		asset, acc, unlock,
+	)))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	coretest.Transfer(ctx, t, c, []txbuilder.Action{
+		assets.NewIssueAction(assetAmt, nil),
+		control,
+	})
+	assets.IndexAssets(indexer)
+	accounts.IndexAccounts(indexer)
+	prottest.MakeBlock(t, c)
+
+	source := accounts.NewSpendAction(assetAmt, acc, nil, nil, nil, nil)
+	_, err = source.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != utxodb.ErrInsufficient {
+		t.Errorf("spend locked output: got error %v, want %v", err, utxodb.ErrInsufficient)
+	}
+}
+
+func TestAccountSourceDustChange(t *testing.T) {
+	var (
+		_, db    = pgtest.NewDB(t, pgtest.SchemaPath)
+		ctx      = context.Background()
+		c        = prottest.NewChain(t)
+		assets   = asset.NewRegistry(db, c)
+		accounts = account.NewManager(db, c)
+		indexer  = query.NewIndexer(db, c)
+
+		acc1  = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		acc2  = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		asset = coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+	)
+	accounts.SplitChange(account.ChangePolicy{MinAmounts: txbuilder.MinOutputAmounts{asset: 5}})
+
+	coretest.IssueAssets(ctx, t, c, assets, accounts, asset, 10, acc1)
+	coretest.IssueAssets(ctx, t, c, assets, accounts, asset, 10, acc1)
+	coretest.IssueAssets(ctx, t, c, assets, accounts, asset, 10, acc2)
+	assets.IndexAssets(indexer)
+	accounts.IndexAccounts(indexer)
+	prottest.MakeBlock(t, c)
+
+	// One output would leave change of 2, below the minimum,
+	// so acc1 spends both.
+	assetAmt := bc.AssetAmount{AssetID: asset, Amount: 8}
+	res, err := accounts.NewSpendAction(assetAmt, acc1, nil, nil, nil, nil).Build(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(res.Inputs) != 2 || len(res.Outputs) != 1 || res.Outputs[0].Amount != 12 {
+		t.Errorf("acc1 spent %d inputs with change %+v, want 2 inputs with change 12", len(res.Inputs), res.Outputs)
+	}
+
+	// acc2 has nothing more to spend, so it keeps the dust change.
+	res, err = accounts.NewSpendAction(assetAmt, acc2, nil, nil, nil, nil).Build(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(res.Inputs) != 1 || len(res.Outputs) != 1 || res.Outputs[0].Amount != 2 {
+		t.Errorf("acc2 spent %d inputs with change %+v, want 1 input with change 2", len(res.Inputs), res.Outputs)
+	}
+}
@@ -0,0 +1,32 @@
+package account
+
+import (
+	"reflect"
+	"testing"
+
+	"chain/core/txbuilder"
+	"chain/protocol/bc"
+)
+
+func TestChangePolicySplit(t *testing.T) {
+	assetID := bc.AssetID{1}
+	cases := []struct {
+		policy ChangePolicy
+		amount uint64
+		want   []uint64
+	}{
+		{ChangePolicy{}, 10, []uint64{10}},
+		{ChangePolicy{Outputs: 1}, 10, []uint64{10}},
+		{ChangePolicy{Outputs: 3}, 10, []uint64{4, 3, 3}},
+		{ChangePolicy{Outputs: 3}, 2, []uint64{1, 1}},
+		{ChangePolicy{Outputs: 4, MinAmounts: txbuilder.MinOutputAmounts{assetID: 4}}, 10, []uint64{5, 5}},
+		{ChangePolicy{Outputs: 4, MinAmounts: txbuilder.MinOutputAmounts{assetID: 20}}, 10, []uint64{10}},
+		{ChangePolicy{Outputs: 4, MinAmounts: txbuilder.MinOutputAmounts{bc.AssetID{2}: 20}}, 10, []uint64{4, 2, 2, 2}},
+	}
+	for i, c := range cases {
+		got := c.policy.split(assetID, c.amount)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("case %d: split(%d) = %v want %v", i, c.amount, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package account
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// ErrNothingToConsolidate is returned by Consolidate when the
+// account has fewer than two spendable outputs of the asset.
+var ErrNothingToConsolidate = errors.New("too few outputs to consolidate")
+
+// Consolidate chooses outputs of accountID's to merge into one,
+// so that the account's holdings of assetID can later be spent
+// with fewer inputs. It returns up to maxInputs of the account's
+// smallest spendable outputs of the asset, and their total.
+//
+// Outputs that are reserved, time-locked, or spent by pending
+// transactions are skipped, as are unconfirmed outputs if the
+// Manager spends only confirmed outputs.
+func (m *Manager) Consolidate(ctx context.Context, accountID string, assetID bc.AssetID, maxInputs int) ([]bc.Outpoint, uint64, error) {
+	const q = `
+		SELECT tx_hash, index, amount FROM account_utxos
+		WHERE account_id = $1 AND asset_id = $2
+			AND reservation_id IS NULL
+			AND pending_spend_expiry_height IS NULL
+			AND (unlock_time IS NULL OR unlock_time <= $3)
+			AND ($4 = FALSE OR confirmed_in IS NOT NULL)
+		ORDER BY amount ASC, tx_hash, index
+		LIMIT $5
+	`
+	rows, err := m.db.Query(ctx, q, accountID, assetID, bc.Millis(time.Now()), m.utxoDB.ConfirmedOnly, maxInputs)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "querying account outputs")
+	}
+	defer rows.Close()
+
+	var (
+		outs  []bc.Outpoint
+		total uint64
+	)
+	for rows.Next() {
+		var (
+			out    bc.Outpoint
+			amount uint64
+		)
+		err = rows.Scan(&out.Hash, &out.Index, &amount)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "scanning account output")
+		}
+		if amount > math.MaxUint64-total {
+			// Leave the rest for another consolidation.
+			break
+		}
+		outs = append(outs, out)
+		total += amount
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, errors.Wrap(err)
+	}
+	if len(outs) < 2 {
+		return nil, 0, errors.WithDetailf(ErrNothingToConsolidate, "account has %d spendable outputs of the asset", len(outs))
+	}
+	return outs, total, nil
+}
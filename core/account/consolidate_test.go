@@ -0,0 +1,61 @@
+package account_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chain/core/account"
+	"chain/core/asset"
+	"chain/core/coretest"
+	"chain/core/query"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+)
+
+func TestConsolidate(t *testing.T) {
+	var (
+		_, db    = pgtest.NewDB(t, pgtest.SchemaPath)
+		ctx      = context.Background()
+		c        = prottest.NewChain(t)
+		accounts = account.NewManager(db, c)
+		assets   = asset.NewRegistry(db, c)
+		indexer  = query.NewIndexer(db, c)
+
+		accID   = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		otherID = coretest.CreateAccount(ctx, t, accounts, "", nil)
+		assetID = coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+		out5    = coretest.IssueAssets(ctx, t, c, assets, accounts, assetID, 5, accID)
+		out1    = coretest.IssueAssets(ctx, t, c, assets, accounts, assetID, 1, accID)
+		out3    = coretest.IssueAssets(ctx, t, c, assets, accounts, assetID, 3, accID)
+		_       = coretest.IssueAssets(ctx, t, c, assets, accounts, assetID, 7, otherID)
+	)
+	assets.IndexAssets(indexer)
+	accounts.IndexAccounts(indexer)
+	prottest.MakeBlock(t, c)
+
+	outs, total, err := accounts.Consolidate(ctx, accID, assetID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bc.Outpoint{out1.Outpoint, out3.Outpoint}
+	if !reflect.DeepEqual(outs, want) || total != 4 {
+		t.Errorf("Consolidate(max 2) = %v, %d; want %v, 4", outs, total, want)
+	}
+
+	outs, total, err = accounts.Consolidate(ctx, accID, assetID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, out5.Outpoint)
+	if !reflect.DeepEqual(outs, want) || total != 9 {
+		t.Errorf("Consolidate(max 10) = %v, %d; want %v, 9", outs, total, want)
+	}
+
+	_, _, err = accounts.Consolidate(ctx, otherID, assetID, 10)
+	if errors.Root(err) != account.ErrNothingToConsolidate {
+		t.Errorf("Consolidate(one output) error = %v, want %v", err, account.ErrNothingToConsolidate)
+	}
+}
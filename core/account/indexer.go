@@ -45,19 +45,24 @@ func (m *Manager) indexAnnotatedAccount(ctx context.Context, a *Account) error {
 			"account_derivation_path": jsonPath,
 		})
 	}
-	return m.indexer.SaveAnnotatedAccount(ctx, a.ID, map[string]interface{}{
+	annotated := map[string]interface{}{
 		"id":     a.ID,
 		"alias":  a.Alias,
 		"keys":   keys,
 		"tags":   a.Tags,
 		"quorum": a.Quorum,
-	})
+	}
+	if a.ArchivedAt != nil {
+		annotated["archived_at"] = a.ArchivedAt.UTC()
+	}
+	return m.indexer.SaveAnnotatedAccount(ctx, a.ID, annotated)
 }
 
 type output struct {
 	state.Output
-	AccountID string
-	keyIndex  uint64
+	AccountID  string
+	keyIndex   uint64
+	unlockTime uint64
 }
 
 // IndexUnconfirmedUTXOs looks up a transaction's control programs for matching
@@ -77,8 +82,25 @@ func (m *Manager) IndexUnconfirmedUTXOs(ctx context.Context, tx *bc.Tx) error {
 	if err != nil {
 		return errors.Wrap(err, "loading account info")
 	}
-	err = m.upsertUnconfirmedAccountOutputs(ctx, accOuts, m.chain.Height()+unconfirmedExpiration)
-	return errors.Wrap(err, "upserting confirmed account utxos")
+	expiryHeight := m.chain.Height() + unconfirmedExpiration
+	err = m.upsertUnconfirmedAccountOutputs(ctx, accOuts, expiryHeight)
+	if err != nil {
+		return errors.Wrap(err, "upserting confirmed account utxos")
+	}
+	err = m.markProgramsUsed(ctx, accOuts)
+	if err != nil {
+		return err
+	}
+
+	// Mark the utxos this tx spends, so pending balances leave
+	// them out. The mark expires like the tx's own outputs.
+	spentTxHash, spentIndex := prevoutDBKeys(tx)
+	const spendQ = `
+		UPDATE account_utxos SET pending_spend_expiry_height = $3
+		WHERE (tx_hash, index) IN (SELECT unnest($1::text[]), unnest($2::integer[]))
+	`
+	_, err = m.db.Exec(ctx, spendQ, spentTxHash, spentIndex, expiryHeight)
+	return errors.Wrap(err, "marking pending spent account utxos")
 }
 
 func (m *Manager) indexAccountUTXOs(ctx context.Context, b *bc.Block) error {
@@ -103,6 +125,10 @@ func (m *Manager) indexAccountUTXOs(ctx context.Context, b *bc.Block) error {
 	if err != nil {
 		return errors.Wrap(err, "upserting confirmed account utxos")
 	}
+	err = m.markProgramsUsed(ctx, accOuts)
+	if err != nil {
+		return err
+	}
 
 	// Delete consumed account UTXOs.
 	deltxhash, delindex := prevoutDBKeys(b.Transactions...)
@@ -121,7 +147,18 @@ func (m *Manager) indexAccountUTXOs(ctx context.Context, b *bc.Block) error {
 		DELETE FROM account_utxos WHERE expiry_height <= $1 AND confirmed_in IS NULL
 	`
 	_, err = m.db.Exec(ctx, expiryQ, b.Height)
-	return errors.Wrap(err, "deleting expired account utxos")
+	if err != nil {
+		return errors.Wrap(err, "deleting expired account utxos")
+	}
+
+	// Likewise, utxos whose spending tx has not been confirmed
+	// after several blocks count as unspent again.
+	const spendExpiryQ = `
+		UPDATE account_utxos SET pending_spend_expiry_height = NULL
+		WHERE pending_spend_expiry_height <= $1
+	`
+	_, err = m.db.Exec(ctx, spendExpiryQ, b.Height)
+	return errors.Wrap(err, "expiring pending spends of account utxos")
 }
 
 func prevoutDBKeys(txs ...*bc.Tx) (txhash pq.StringArray, index pg.Uint32s) {
@@ -156,16 +193,17 @@ func (m *Manager) loadAccountInfo(ctx context.Context, outs []*state.Output) ([]
 	result := make([]*output, 0, len(outs))
 
 	const q = `
-		SELECT signer_id, key_index, control_program
+		SELECT signer_id, key_index, control_program, COALESCE(unlock_time, 0)
 		FROM account_control_programs
 		WHERE control_program IN (SELECT unnest($1::bytea[]))
 	`
-	err := pg.ForQueryRows(ctx, m.db, q, scripts, func(accountID string, keyIndex uint64, program []byte) {
+	err := pg.ForQueryRows(ctx, m.db, q, scripts, func(accountID string, keyIndex uint64, program []byte, unlockTime uint64) {
 		for _, out := range outsByScript[string(program)] {
 			newOut := &output{
-				Output:    *out,
-				AccountID: accountID,
-				keyIndex:  keyIndex,
+				Output:     *out,
+				AccountID:  accountID,
+				keyIndex:   keyIndex,
+				unlockTime: unlockTime,
 			}
 			result = append(result, newOut)
 		}
@@ -189,6 +227,7 @@ func (m *Manager) upsertUnconfirmedAccountOutputs(ctx context.Context, outs []*o
 		cpIndex   pq.Int64Array
 		program   pq.ByteaArray
 		metadata  pq.ByteaArray
+		unlock    pq.Int64Array
 	)
 	for _, out := range outs {
 		txHash = append(txHash, out.Outpoint.Hash.String())
@@ -199,13 +238,15 @@ func (m *Manager) upsertUnconfirmedAccountOutputs(ctx context.Context, outs []*o
 		cpIndex = append(cpIndex, int64(out.keyIndex))
 		program = append(program, out.ControlProgram)
 		metadata = append(metadata, out.ReferenceData)
+		unlock = append(unlock, int64(out.unlockTime))
 	}
 
 	const q = `
 		INSERT INTO account_utxos (tx_hash, index, asset_id, amount, account_id, control_program_index,
-			control_program, metadata, expiry_height)
+			control_program, metadata, expiry_height, unlock_time)
 		SELECT unnest($1::text[]), unnest($2::bigint[]), unnest($3::text[]),  unnest($4::bigint[]),
-			   unnest($5::text[]), unnest($6::bigint[]), unnest($7::bytea[]), unnest($8::bytea[]), $9
+			   unnest($5::text[]), unnest($6::bigint[]), unnest($7::bytea[]), unnest($8::bytea[]), $9,
+			   unnest($10::bigint[])
 		ON CONFLICT (tx_hash, index) DO NOTHING;
 	`
 	_, err := m.db.Exec(ctx, q,
@@ -218,6 +259,7 @@ func (m *Manager) upsertUnconfirmedAccountOutputs(ctx context.Context, outs []*o
 		program,
 		metadata,
 		expiryHeight,
+		unlock,
 	)
 	return errors.Wrap(err)
 }
@@ -236,6 +278,7 @@ func (m *Manager) upsertConfirmedAccountOutputs(ctx context.Context, outs []*out
 		program   pq.ByteaArray
 		metadata  pq.ByteaArray
 		blockPos  pg.Uint32s
+		unlock    pq.Int64Array
 	)
 	for _, out := range outs {
 		txHash = append(txHash, out.Outpoint.Hash.String())
@@ -246,15 +289,16 @@ func (m *Manager) upsertConfirmedAccountOutputs(ctx context.Context, outs []*out
 		cpIndex = append(cpIndex, int64(out.keyIndex))
 		program = append(program, out.ControlProgram)
 		metadata = append(metadata, out.ReferenceData)
+		unlock = append(unlock, int64(out.unlockTime))
 		blockPos = append(blockPos, pos[out.Outpoint.Hash])
 	}
 
 	const q = `
 		INSERT INTO account_utxos (tx_hash, index, asset_id, amount, account_id, control_program_index,
-			control_program, metadata, confirmed_in, block_pos, block_timestamp, expiry_height)
+			control_program, metadata, confirmed_in, block_pos, block_timestamp, expiry_height, unlock_time)
 		SELECT unnest($1::text[]), unnest($2::bigint[]), unnest($3::text[]),  unnest($4::bigint[]),
 			   unnest($5::text[]), unnest($6::bigint[]), unnest($7::bytea[]), unnest($8::bytea[]),
-			   $9, unnest($10::bigint[]), $11, NULL
+			   $9, unnest($10::bigint[]), $11, NULL, unnest($12::bigint[])
 		ON CONFLICT (tx_hash, index) DO UPDATE SET
 			confirmed_in    = excluded.confirmed_in,
 			block_pos       = excluded.block_pos,
@@ -273,6 +317,7 @@ func (m *Manager) upsertConfirmedAccountOutputs(ctx context.Context, outs []*out
 		block.Height,
 		blockPos,
 		block.TimestampMS,
+		unlock,
 	)
 	return errors.Wrap(err)
 }
@@ -0,0 +1,39 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/errors"
+)
+
+// DefaultReceiverExpiry is how long a receiver lasts
+// if no expiration time is given.
+const DefaultReceiverExpiry = 30 * 24 * time.Hour
+
+// CreateReceiver creates a receiver for payments to the account
+// with ID accountID: a new control program of the account that
+// payers should not pay after expiresAt. A zero expiresAt means
+// DefaultReceiverExpiry from now.
+//
+// Expiration is enforced when payers build transactions, not by
+// the protocol: outputs to an expired receiver's control program
+// still belong to the account.
+func (m *Manager) CreateReceiver(ctx context.Context, accountID string, expiresAt time.Time, memo string) (*txbuilder.Receiver, error) {
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(DefaultReceiverExpiry)
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, errors.WithDetail(txbuilder.ErrBadReceiver, "expires_at is in the past")
+	}
+	program, err := m.CreateControlProgram(ctx, accountID, false)
+	if err != nil {
+		return nil, err
+	}
+	return &txbuilder.Receiver{
+		ControlProgram: program,
+		ExpiresAt:      expiresAt.UTC(),
+		Memo:           memo,
+	}, nil
+}
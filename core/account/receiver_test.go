@@ -0,0 +1,58 @@
+package account
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestCreateReceiver(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	m := NewManager(db, prottest.NewChain(t))
+	ctx := context.Background()
+
+	account, err := m.Create(ctx, []string{dummyXPub}, 1, "", nil, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	_, err = m.CreateReceiver(ctx, account.ID, time.Now().Add(-time.Minute), "")
+	if errors.Root(err) != txbuilder.ErrBadReceiver {
+		t.Errorf("CreateReceiver(past) error = %v, want %v", err, txbuilder.ErrBadReceiver)
+	}
+
+	r, err := m.CreateReceiver(ctx, account.ID, time.Time{}, "invoice 42")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if d := time.Until(r.ExpiresAt); d < DefaultReceiverExpiry-time.Minute || d > DefaultReceiverExpiry {
+		t.Errorf("receiver expires in %s, want about %s", d, DefaultReceiverExpiry)
+	}
+	if r.Memo != "invoice 42" {
+		t.Errorf("memo = %q, want %q", r.Memo, "invoice 42")
+	}
+
+	// The receiver's control program belongs to the account.
+	owner, err := m.ProgramAccount(ctx, r.ControlProgram)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if owner != account.ID {
+		t.Errorf("receiver control program belongs to %q, want %q", owner, account.ID)
+	}
+
+	// Paying an expired receiver fails.
+	r.ExpiresAt = time.Now().Add(-time.Second)
+	action := txbuilder.NewControlReceiverAction(bc.AssetAmount{Amount: 1}, r, nil)
+	_, err = action.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != txbuilder.ErrReceiverExpired {
+		t.Errorf("Build(expired) error = %v, want %v", err, txbuilder.ErrReceiverExpired)
+	}
+}
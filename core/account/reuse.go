@@ -0,0 +1,82 @@
+package account
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// ErrProgramReused is returned when a transaction would pay a
+// control program of an account a second time while single-use
+// control programs are enforced.
+var ErrProgramReused = errors.New("account control program has already been used")
+
+// SingleUsePrograms sets whether transactions built by this core
+// may pay a control program of one of its accounts that has
+// already received an output. Reusing a control program links
+// payments to the same payee, so privacy-sensitive deployments
+// should forbid it. Every new account control program is derived
+// from a fresh key index, so payees always get an unused one.
+// It must be called before m is used.
+func (m *Manager) SingleUsePrograms(singleUse bool) {
+	m.singleUse = singleUse
+}
+
+// CheckProgramReuse returns ErrProgramReused if m enforces
+// single-use control programs and tx pays a control program
+// of one of its accounts that has already been used, or pays
+// one more than once.
+func (m *Manager) CheckProgramReuse(ctx context.Context, tx *bc.TxData) error {
+	if !m.singleUse {
+		return nil
+	}
+	count := make(map[string]int, len(tx.Outputs))
+	var progs pq.ByteaArray
+	for _, out := range tx.Outputs {
+		p := string(out.ControlProgram)
+		if count[p] == 0 {
+			progs = append(progs, out.ControlProgram)
+		}
+		count[p]++
+	}
+
+	const q = `
+		SELECT control_program, used FROM account_control_programs
+		WHERE control_program IN (SELECT unnest($1::bytea[]))
+	`
+	var reused []byte
+	err := pg.ForQueryRows(ctx, m.db, q, progs, func(prog []byte, used bool) {
+		if reused == nil && (used || count[string(prog)] > 1) {
+			reused = prog
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "checking control program reuse")
+	}
+	if reused != nil {
+		return errors.WithDetailf(ErrProgramReused, "control program %x", reused)
+	}
+	return nil
+}
+
+// markProgramsUsed records that the control programs
+// of outs have received outputs.
+func (m *Manager) markProgramsUsed(ctx context.Context, outs []*output) error {
+	if len(outs) == 0 {
+		return nil
+	}
+	var progs pq.ByteaArray
+	for _, out := range outs {
+		progs = append(progs, out.ControlProgram)
+	}
+	const q = `
+		UPDATE account_control_programs SET used = true
+		WHERE control_program IN (SELECT unnest($1::bytea[])) AND NOT used
+	`
+	_, err := m.db.Exec(ctx, q, progs)
+	return errors.Wrap(err, "marking used account control programs")
+}
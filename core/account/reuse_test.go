@@ -0,0 +1,64 @@
+package account
+
+import (
+	"context"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/protocol/state"
+	"chain/testutil"
+)
+
+func TestCheckProgramReuse(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	m := NewManager(db, prottest.NewChain(t))
+	ctx := context.Background()
+
+	account, err := m.Create(ctx, []string{dummyXPub}, 1, "", nil, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	prog, err := m.CreateControlProgram(ctx, account.ID, false)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	pay := func(n int) *bc.TxData {
+		tx := new(bc.TxData)
+		for i := 0; i < n; i++ {
+			tx.Outputs = append(tx.Outputs, bc.NewTxOutput(bc.AssetID{}, 1, prog, nil))
+		}
+		return tx
+	}
+
+	// Reuse is allowed by default.
+	err = m.CheckProgramReuse(ctx, pay(2))
+	if err != nil {
+		t.Errorf("CheckProgramReuse(default) error = %v", err)
+	}
+
+	m.SingleUsePrograms(true)
+	err = m.CheckProgramReuse(ctx, pay(1))
+	if err != nil {
+		t.Errorf("CheckProgramReuse(unused) error = %v", err)
+	}
+	err = m.CheckProgramReuse(ctx, pay(2))
+	if errors.Root(err) != ErrProgramReused {
+		t.Errorf("CheckProgramReuse(twice) error = %v, want %v", err, ErrProgramReused)
+	}
+
+	out := &output{
+		Output:    state.Output{TxOutput: *bc.NewTxOutput(bc.AssetID{}, 1, prog, nil)},
+		AccountID: account.ID,
+	}
+	err = m.markProgramsUsed(ctx, []*output{out})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = m.CheckProgramReuse(ctx, pay(1))
+	if errors.Root(err) != ErrProgramReused {
+		t.Errorf("CheckProgramReuse(used) error = %v, want %v", err, ErrProgramReused)
+	}
+}
@@ -29,11 +29,57 @@ var (
 	// new change outputs will be created
 	// in sufficient amounts to satisfy the request.
 	ErrReserved = errors.New("reservation found outputs already reserved")
+
+	// ErrUnconfirmed indicates that a specific output could not be
+	// reserved because it is not yet confirmed in a block and the
+	// Reserver only spends confirmed outputs.
+	ErrUnconfirmed = errors.New("reservation found output unconfirmed")
+
+	// ErrLocked indicates that a specific output could not be
+	// reserved because it is time-locked until a later time.
+	ErrLocked = errors.New("reservation found output time-locked")
+
+	// ErrWrongAccount indicates that a specific output could not be
+	// reserved because it belongs to an account other than the one
+	// the caller named.
+	ErrWrongAccount = errors.New("reservation found output of another account")
 )
 
+const (
+	reserveUTXOQ = `
+		SELECT * FROM reserve_utxo($1, $2, $3, $4)
+			AS (reservation_id INT, already_existed BOOLEAN, utxo_exists BOOLEAN)
+	`
+	reservedUTXOQ = `
+		SELECT account_id, asset_id, amount, control_program_index, control_program,
+			confirmed_in IS NOT NULL, COALESCE(unlock_time, 0)
+		FROM account_utxos
+		WHERE reservation_id = $1 LIMIT 1
+	`
+	reserveUTXOsQ = `
+		SELECT * FROM reserve_utxos($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			AS (reservation_id INT, already_existed BOOLEAN, existing_change BIGINT, amount BIGINT, insufficient BOOLEAN)
+	`
+	reservedUTXOsQ = `
+		SELECT a.tx_hash, a.index, a.amount, a.control_program_index, a.control_program,
+			COALESCE(a.unlock_time, 0)
+		FROM account_utxos a
+		WHERE reservation_id = $1
+	`
+)
+
+// HotQueries are the queries run on every spend action.
+// They are worth preparing ahead of time; see sql.DB.PrepareHot.
+var HotQueries = []string{reserveUTXOQ, reservedUTXOQ, reserveUTXOsQ, reservedUTXOsQ}
+
 type (
 	Reserver struct {
 		DB *sql.DB
+
+		// ConfirmedOnly makes the Reserver skip outputs of
+		// transactions that are still pending, so built
+		// transactions never chain on unconfirmed change.
+		ConfirmedOnly bool
 	}
 
 	UTXO struct {
@@ -43,6 +89,10 @@ type (
 
 		AccountID           string
 		ControlProgramIndex uint64
+
+		// UnlockTime is the millisecond timestamp before which
+		// a time-locked output cannot be spent, or 0.
+		UnlockTime uint64
 	}
 
 	// Change represents reserved units beyond what was asked for.
@@ -62,7 +112,10 @@ type (
 	}
 )
 
-func (res *Reserver) ReserveUTXO(ctx context.Context, txHash bc.Hash, pos uint32, clientToken *string, exp time.Time) (*UTXO, error) {
+// ReserveUTXO reserves the output at pos in the transaction txHash
+// until exp. If accountID is not empty, the output must belong
+// to that account.
+func (res *Reserver) ReserveUTXO(ctx context.Context, txHash bc.Hash, pos uint32, accountID string, clientToken *string, exp time.Time) (*UTXO, error) {
 	dbtx, err := res.DB.Begin(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "begin transaction for reserving utxos")
@@ -74,24 +127,12 @@ func (res *Reserver) ReserveUTXO(ctx context.Context, txHash bc.Hash, pos uint32
 		return nil, errors.Wrap(err, "acquire lock for reserving utxos")
 	}
 
-	const (
-		reserveQ = `
-			SELECT * FROM reserve_utxo($1, $2, $3, $4)
-				AS (reservation_id INT, already_existed BOOLEAN, utxo_exists BOOLEAN)
-		`
-		utxosQ = `
-			SELECT account_id, asset_id, amount, control_program_index, control_program
-			FROM account_utxos
-			WHERE reservation_id = $1 LIMIT 1
-		`
-	)
-
 	var (
 		reservationID  int32
 		alreadyExisted bool
 		utxoExists     bool
 	)
-	err = dbtx.QueryRow(ctx, reserveQ, txHash, pos, exp, clientToken).Scan(
+	err = dbtx.QueryRow(ctx, reserveUTXOQ, txHash, pos, exp, clientToken).Scan(
 		&reservationID,
 		&alreadyExisted,
 		&utxoExists,
@@ -107,20 +148,33 @@ func (res *Reserver) ReserveUTXO(ctx context.Context, txHash bc.Hash, pos uint32
 	}
 
 	var (
-		accountID    string
+		ownerID      string
 		assetID      bc.AssetID
 		amount       uint64
 		programIndex uint64
 		controlProg  []byte
+		confirmed    bool
+		unlockTime   uint64
 	)
 
-	err = dbtx.QueryRow(ctx, utxosQ, reservationID).Scan(&accountID, &assetID, &amount, &programIndex, &controlProg)
+	err = dbtx.QueryRow(ctx, reservedUTXOQ, reservationID).Scan(&ownerID, &assetID, &amount, &programIndex, &controlProg, &confirmed, &unlockTime)
 	if err == stdsql.ErrNoRows {
 		return nil, pg.ErrUserInputNotFound
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, "query reservation member")
 	}
+	if accountID != "" && ownerID != accountID {
+		// Rolling back releases the reservation.
+		return nil, errors.WithDetailf(ErrWrongAccount, "output does not belong to account %s", accountID)
+	}
+	if res.ConfirmedOnly && !confirmed {
+		// Rolling back releases the reservation.
+		return nil, ErrUnconfirmed
+	}
+	if unlockTime > bc.Millis(time.Now()) {
+		return nil, errors.WithDetailf(ErrLocked, "output is locked until %s", time.Unix(0, int64(unlockTime)*int64(time.Millisecond)).UTC().Format(time.RFC3339))
+	}
 
 	err = dbtx.Commit(ctx)
 	if err != nil {
@@ -137,8 +191,9 @@ func (res *Reserver) ReserveUTXO(ctx context.Context, txHash bc.Hash, pos uint32
 			Amount:  amount,
 		},
 		Script:              controlProg,
-		AccountID:           accountID,
+		AccountID:           ownerID,
 		ControlProgramIndex: programIndex,
+		UnlockTime:          unlockTime,
 	}
 
 	return utxo, nil
@@ -166,18 +221,7 @@ func (res *Reserver) Reserve(ctx context.Context, sources []Source, exp time.Tim
 		}
 	}()
 
-	const (
-		reserveQ = `
-		SELECT * FROM reserve_utxos($1, $2, $3, $4, $5, $6, $7)
-		    AS (reservation_id INT, already_existed BOOLEAN, existing_change BIGINT, amount BIGINT, insufficient BOOLEAN)
-		`
-		utxosQ = `
-			SELECT a.tx_hash, a.index, a.amount, a.control_program_index, a.control_program
-			FROM account_utxos a
-			WHERE reservation_id = $1
-		`
-	)
-
+	now := bc.Millis(time.Now())
 	for _, source := range sources {
 		var (
 			txHash   stdsql.NullString
@@ -206,7 +250,7 @@ func (res *Reserver) Reserve(ctx context.Context, sources []Source, exp time.Tim
 		//  * already_existed will be TRUE
 		//  * existing_change will be the change value for the existing
 		//    reservation row.
-		err = dbtx.QueryRow(ctx, reserveQ, source.AssetID, source.AccountID, txHash, outIndex, source.Amount, exp, source.ClientToken).Scan(
+		err = dbtx.QueryRow(ctx, reserveUTXOsQ, source.AssetID, source.AccountID, txHash, outIndex, source.Amount, exp, source.ClientToken, res.ConfirmedOnly, now).Scan(
 			&reservationID,
 			&alreadyExisted,
 			&existingChange,
@@ -217,6 +261,9 @@ func (res *Reserver) Reserve(ctx context.Context, sources []Source, exp time.Tim
 			return nil, nil, errors.Wrap(err, "reserve utxos")
 		}
 		if reservationID <= 0 {
+			if insufficient && res.ConfirmedOnly {
+				return nil, nil, errors.WithDetail(ErrInsufficient, "outputs of pending transactions are not spent until they are confirmed")
+			}
 			if insufficient {
 				return nil, nil, ErrInsufficient
 			}
@@ -232,12 +279,13 @@ func (res *Reserver) Reserve(ctx context.Context, sources []Source, exp time.Tim
 			change = append(change, Change{source, reservedAmount - source.Amount})
 		}
 
-		err = pg.ForQueryRows(ctx, dbtx, utxosQ, reservationID, func(
+		err = pg.ForQueryRows(ctx, dbtx, reservedUTXOsQ, reservationID, func(
 			hash bc.Hash,
 			index uint32,
 			amount uint64,
 			programIndex uint64,
 			script []byte,
+			unlockTime uint64,
 		) {
 			utxo := UTXO{
 				Outpoint:            bc.Outpoint{Hash: hash, Index: index},
@@ -245,6 +293,7 @@ func (res *Reserver) Reserve(ctx context.Context, sources []Source, exp time.Tim
 				AssetAmount:         bc.AssetAmount{AssetID: source.AssetID, Amount: amount},
 				AccountID:           source.AccountID,
 				ControlProgramIndex: programIndex,
+				UnlockTime:          unlockTime,
 			}
 			reserved = append(reserved, &utxo)
 		})
@@ -261,6 +310,47 @@ func (res *Reserver) Reserve(ctx context.Context, sources []Source, exp time.Tim
 	return reserved, change, err
 }
 
+// reservationsQ selects the reservations holding any of the
+// outpoints given as arrays of tx hashes and indexes.
+const reservationsQ = `
+	SELECT reservation_id FROM account_utxos
+	WHERE (tx_hash, index) IN (SELECT unnest($1::text[]), unnest($2::integer[]))
+		AND reservation_id IS NOT NULL
+`
+
+// Cancel releases the reservations holding any of outs, so the
+// reserved outputs can be spent by other transactions right away.
+// It returns the number of reservations released.
+func (res *Reserver) Cancel(ctx context.Context, outs []bc.Outpoint) (int64, error) {
+	txHashes, indexes := outpointDBKeys(outs)
+	q := `DELETE FROM reservations WHERE reservation_id IN (` + reservationsQ + `)`
+	r, err := res.DB.Exec(ctx, q, txHashes, indexes)
+	if err != nil {
+		return 0, errors.Wrap(err, "canceling reservations")
+	}
+	return r.RowsAffected()
+}
+
+// Extend sets the expiry of the reservations holding any of outs
+// to exp. It returns the number of reservations changed.
+func (res *Reserver) Extend(ctx context.Context, outs []bc.Outpoint, exp time.Time) (int64, error) {
+	txHashes, indexes := outpointDBKeys(outs)
+	q := `UPDATE reservations SET expiry = $3 WHERE reservation_id IN (` + reservationsQ + `)`
+	r, err := res.DB.Exec(ctx, q, txHashes, indexes, exp)
+	if err != nil {
+		return 0, errors.Wrap(err, "extending reservations")
+	}
+	return r.RowsAffected()
+}
+
+func outpointDBKeys(outs []bc.Outpoint) (txHashes pq.StringArray, indexes pg.Uint32s) {
+	for _, o := range outs {
+		txHashes = append(txHashes, o.Hash.String())
+		indexes = append(indexes, o.Index)
+	}
+	return txHashes, indexes
+}
+
 // ExpireReservations is meant to be run as a goroutine. It loops,
 // calling the expire_reservations() pl/pgsql function to
 // remove expired reservations from the reservations table.
@@ -0,0 +1,53 @@
+package utxodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol/bc"
+)
+
+// BenchmarkReserve measures a spend's reservation of one
+// output, with and without HotQueries prepared:
+//
+//	go test -run - -bench Reserve chain/core/account/utxodb
+func BenchmarkReserve(b *testing.B) {
+	b.Run("unprepared", func(b *testing.B) { benchReserve(b, false) })
+	b.Run("prepared", func(b *testing.B) { benchReserve(b, true) })
+}
+
+func benchReserve(b *testing.B, prepare bool) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(b, pgtest.SchemaPath)
+	pgtest.Exec(ctx, db, b, `
+		INSERT INTO account_utxos (tx_hash, index, asset_id, amount, account_id,
+			control_program_index, control_program, metadata, confirmed_in)
+		SELECT md5(i::text) || md5(i::text), 0, $1, 10, 'acc1', i, '\x51', '', 1
+		FROM generate_series(1, 1000) i
+	`, bc.AssetID{1}.String())
+	if prepare {
+		err := db.PrepareHot(ctx, HotQueries...)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	res := &Reserver{DB: db}
+	src := Source{AssetID: bc.AssetID{1}, AccountID: "acc1", Amount: 10}
+	exp := time.Now().Add(time.Hour)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		utxos, _, err := res.Reserve(ctx, []Source{src}, exp)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		_, err = res.Cancel(ctx, []bc.Outpoint{utxos[0].Outpoint})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
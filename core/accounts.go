@@ -4,7 +4,10 @@ import (
 	"context"
 	"sync"
 
+	"chain/core/account"
 	"chain/core/signers"
+	"chain/errors"
+	"chain/net/http/httpjson"
 )
 
 // This type enforces JSON field ordering in API output.
@@ -14,6 +17,8 @@ type accountResponse struct {
 	Keys   interface{} `json:"keys"`
 	Quorum interface{} `json:"quorum"`
 	Tags   interface{} `json:"tags"`
+
+	ArchivedAt interface{} `json:"archived_at,omitempty"`
 }
 
 type accountKey struct {
@@ -45,7 +50,7 @@ func (h *Handler) createAccount(ctx context.Context, ins []struct {
 			acc, err := h.Accounts.Create(ctx, ins[i].RootXPubs, ins[i].Quorum, ins[i].Alias, ins[i].Tags, ins[i].ClientToken)
 			if err != nil {
 				logHTTPError(ctx, err)
-				responses[i], _ = errInfo(err)
+				responses[i], _ = errInfo(ctx, err)
 			} else {
 				path := signers.Path(acc.Signer, signers.AccountKeySpace)
 				var keys []accountKey
@@ -71,3 +76,148 @@ func (h *Handler) createAccount(ctx context.Context, ins []struct {
 	wg.Wait()
 	return responses
 }
+
+// getAccountBalance returns an account's balance of each asset,
+// both confirmed and including transactions this core has
+// submitted that are not yet confirmed.
+//
+// POST /get-account-balance
+func (h *Handler) getAccountBalance(ctx context.Context, in struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+}) (interface{}, error) {
+	if in.AccountID == "" && in.AccountAlias != "" {
+		acc, err := h.Accounts.FindByAlias(ctx, in.AccountAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account alias %s", in.AccountAlias)
+		}
+		in.AccountID = acc.ID
+	}
+	if in.AccountID == "" {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing account_id or account_alias")
+	}
+	err := checkAccountScope(ctx, in.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := h.Accounts.Balances(ctx, in.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if balances == nil {
+		balances = []account.Balance{}
+	}
+	return map[string]interface{}{"account_id": in.AccountID, "balances": balances}, nil
+}
+
+// getAccountActivity reports the net change in each asset held
+// by an account between two block heights, with the transactions
+// making it, for reconciliation.
+//
+// POST /get-account-activity
+func (h *Handler) getAccountActivity(ctx context.Context, in struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+	FromHeight   uint64 `json:"from_height"`
+	ToHeight     uint64 `json:"to_height"`
+}) (interface{}, error) {
+	id, err := h.accountID(ctx, in.AccountID, in.AccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAccountScope(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if in.ToHeight == 0 {
+		in.ToHeight = h.Chain.Height()
+	}
+	if in.FromHeight > in.ToHeight {
+		return nil, errors.WithDetailf(httpjson.ErrBadRequest, "from_height %d is after to_height %d", in.FromHeight, in.ToHeight)
+	}
+	deltas, txs, truncated, err := h.Indexer.AccountActivity(ctx, id, in.FromHeight, in.ToHeight)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"account_id":   id,
+		"from_height":  in.FromHeight,
+		"to_height":    in.ToHeight,
+		"deltas":       deltas,
+		"transactions": txs,
+		"truncated":    truncated,
+	}, nil
+}
+
+// archiveAccount archives an account, hiding it from
+// /list-accounts unless include_archived is set.
+//
+// POST /archive-account
+func (h *Handler) archiveAccount(ctx context.Context, in struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+}) (interface{}, error) {
+	id, err := h.accountID(ctx, in.AccountID, in.AccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := h.Accounts.Archive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"account_id": acc.ID, "archived_at": acc.ArchivedAt}, nil
+}
+
+// restoreAccount restores an archived account, within
+// account.RestoreWindow of archiving it.
+//
+// POST /restore-account
+func (h *Handler) restoreAccount(ctx context.Context, in struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+}) (interface{}, error) {
+	id, err := h.accountID(ctx, in.AccountID, in.AccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := h.Accounts.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"account_id": acc.ID, "archived_at": acc.ArchivedAt}, nil
+}
+
+// updateAccountTags patches an account's tags. A tag with
+// a null value is removed; other tags are left as they are.
+//
+// POST /update-account-tags
+func (h *Handler) updateAccountTags(ctx context.Context, in struct {
+	AccountID    string                 `json:"account_id"`
+	AccountAlias string                 `json:"account_alias"`
+	Tags         map[string]interface{} `json:"tags"`
+}) (interface{}, error) {
+	id, err := h.accountID(ctx, in.AccountID, in.AccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := h.Accounts.UpdateTags(ctx, id, in.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"account_id": acc.ID, "tags": acc.Tags}, nil
+}
+
+// accountID returns id, or else the ID of the account with alias.
+func (h *Handler) accountID(ctx context.Context, id, alias string) (string, error) {
+	if id == "" && alias != "" {
+		acc, err := h.Accounts.FindByAlias(ctx, alias)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid account alias %s", alias)
+		}
+		id = acc.ID
+	}
+	if id == "" {
+		return "", errors.WithDetail(httpjson.ErrBadRequest, "missing account_id or account_alias")
+	}
+	return id, nil
+}
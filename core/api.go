@@ -13,13 +13,23 @@ import (
 	"chain/core/accesstoken"
 	"chain/core/account"
 	"chain/core/asset"
+	"chain/core/auction"
+	"chain/core/blocklist"
+	"chain/core/channel"
+	"chain/core/crowdfund"
+	"chain/core/escrow"
+	"chain/core/forward"
+	"chain/core/htlc"
 	"chain/core/leader"
 	"chain/core/mockhsm"
 	"chain/core/query"
 	"chain/core/rpc"
+	"chain/core/subscription"
+	"chain/core/tier"
 	"chain/core/txbuilder"
 	"chain/core/txdb"
 	"chain/core/txfeed"
+	"chain/core/voting"
 	"chain/database/pg"
 	"chain/encoding/json"
 	"chain/errors"
@@ -47,7 +57,14 @@ var (
 	errLeaderElection = errors.New("no leader; pending election")
 )
 
-// Handler serves the Chain HTTP API
+// Handler serves the Chain HTTP API.
+//
+// Its exported methods, such as BuildTransaction,
+// SubmitTransaction, and ListTransactions, perform the same
+// operations in process, for programs embedding a core. The
+// HTTP endpoints are thin wrappers around them. They are safe
+// for concurrent use, and need a configured Handler, with its
+// fields set up as cmd/cored does.
 type Handler struct {
 	Chain         *protocol.Chain
 	Store         *txdb.Store
@@ -64,9 +81,43 @@ type Handler struct {
 	Signer        func(context.Context, *bc.Block) ([]byte, error)
 	RequestLimits []RequestLimit
 
+	// ConcurrencyLimits caps the number of concurrent requests
+	// to each class of endpoint (ClassQuery, ClassBuild, ClassSubmit).
+	// Requests over the limit wait for a free slot.
+	// Classes without a positive limit are unlimited.
+	ConcurrencyLimits map[string]int
+
+	// GzipMinSize is the smallest response body, in bytes,
+	// that will be gzip-compressed for clients that accept it.
+	GzipMinSize int
+
+	// MinOutputAmounts is enforced on built transactions and on
+	// transactions submitted to this core, including those
+	// submitted to a generator by other cores.
+	MinOutputAmounts txbuilder.MinOutputAmounts
+
+	// Blocklist lists the outputs whose spends the generator
+	// refuses to include in blocks.
+	Blocklist *blocklist.List
+
+	// Fees is the network's fee policy. Its program is the
+	// destination of fees requested in builds. Its minimums are
+	// enforced on transactions submitted to this core, including
+	// those submitted to a generator by other cores.
+	Fees txbuilder.FeePolicy
+
 	once           sync.Once
 	handler        http.Handler
 	actionDecoders map[string]func(data []byte) (txbuilder.Action, error)
+	crowdfund      *crowdfund.Manager
+	subscriptions  *subscription.Manager
+	forwarding     *forward.Manager
+	tiers          *tier.Manager
+	channels       *channel.Manager
+	escrow         *escrow.Manager
+	auctions       *auction.Manager
+	voting         *voting.Manager
+	htlcs          *htlc.Manager
 
 	healthMu     sync.Mutex
 	healthErrors map[string]interface{}
@@ -78,6 +129,86 @@ type RequestLimit struct {
 	PerSecond int
 }
 
+// Endpoint classes for Handler.ConcurrencyLimits.
+const (
+	ClassQuery  = "query"
+	ClassBuild  = "build"
+	ClassSubmit = "submit"
+)
+
+var endpointClasses = map[string]string{
+	"/list-accounts":                      ClassQuery,
+	"/list-assets":                        ClassQuery,
+	"/list-asset-definitions":             ClassQuery,
+	"/list-asset-definition-schemas":      ClassQuery,
+	"/list-transaction-feeds":             ClassQuery,
+	"/list-transactions":                  ClassQuery,
+	"/list-transactions-by-end-to-end-id": ClassQuery,
+	"/trace-transactions":                 ClassQuery,
+	"/list-asset-holders":                 ClassQuery,
+	"/list-retirements":                   ClassQuery,
+	"/utxo-stats":                         ClassQuery,
+	"/block-stats":                        ClassQuery,
+	"/get-block-finality":                 ClassQuery,
+	"/decode-transaction":                 ClassQuery,
+	"/get-signing-progress":               ClassQuery,
+	"/assemble-program":                   ClassQuery,
+	"/get-crowdfund-campaign":             ClassQuery,
+	"/list-subscriptions":                 ClassQuery,
+	"/get-subscription":                   ClassQuery,
+	"/list-forwarding-rules":              ClassQuery,
+	"/get-forwarding-rule":                ClassQuery,
+	"/list-wallet-tiers":                  ClassQuery,
+	"/get-wallet-tier":                    ClassQuery,
+	"/get-blocklist":                      ClassQuery,
+	"/get-payment-channel":                ClassQuery,
+	"/get-escrow":                         ClassQuery,
+	"/get-auction":                        ClassQuery,
+	"/list-auctions":                      ClassQuery,
+	"/tally-votes":                        ClassQuery,
+	"/list-htlcs":                         ClassQuery,
+	"/disassemble-program":                ClassQuery,
+	"/build-account-sweep":                ClassBuild,
+	"/build-account-consolidation":        ClassBuild,
+	"/build-transfer":                     ClassBuild,
+	"/list-balances":                      ClassQuery,
+	"/get-account-balance":                ClassQuery,
+	"/get-account-activity":               ClassQuery,
+	"/list-unspent-outputs":               ClassQuery,
+	"/build-transaction":                  ClassBuild,
+	"/build-transaction-from-pain001":     ClassBuild,
+	"/cancel-reservation":                 ClassBuild,
+	"/extend-reservation":                 ClassBuild,
+	"/submit-transaction":                 ClassSubmit,
+	networkRPCPrefix + "submit":           ClassSubmit,
+}
+
+// concurrencyLimited applies h.ConcurrencyLimits to next,
+// publishing the in-flight and queued request counts for
+// each limited class.
+func (h *Handler) concurrencyLimited(next http.Handler) http.Handler {
+	limited := make(map[string]http.Handler)
+	for class, n := range h.ConcurrencyLimits {
+		if n <= 0 {
+			continue
+		}
+		inflight, queued := new(expvar.Int), new(expvar.Int)
+		concurrencyInflight.Set(class, inflight)
+		concurrencyQueued.Set(class, queued)
+		limited[class] = limit.Concurrency(next, alwaysError(errRateLimited), n, inflight, queued)
+	}
+	if len(limited) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if l, ok := limited[endpointClasses[req.URL.Path]]; ok {
+			l.ServeHTTP(w, req)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
 func maxBytes(h http.Handler) http.Handler {
 	const maxReqSize = 1e5 // 100kB
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -91,14 +222,49 @@ func maxBytes(h http.Handler) http.Handler {
 }
 
 func (h *Handler) init() {
+	h.crowdfund = crowdfund.NewManager(h.Accounts, h.Indexer)
+	h.subscriptions = subscription.NewManager(h.DB, h.Accounts, h.Indexer)
+	h.forwarding = forward.NewManager(h.DB, h.Accounts)
+	h.tiers = tier.NewManager(h.DB, h.Accounts)
+	h.channels = channel.NewManager(h.DB, h.Accounts, h.Indexer, h.HSM)
+	h.escrow = escrow.NewManager(h.Accounts, h.Indexer)
+	h.auctions = auction.NewManager(h.Chain, h.Accounts, h.Indexer)
+	h.voting = voting.NewManager(h.Accounts, h.Assets, h.Indexer)
+	h.htlcs = htlc.NewManager(h.Accounts, h.Indexer)
+
 	// Setup the available transact actions.
 	h.actionDecoders = map[string]func(data []byte) (txbuilder.Action, error){
 		"control_account":                h.Accounts.DecodeControlAction,
+		"control_account_timelocked":     h.Accounts.DecodeControlTimelockedAction,
 		"control_program":                txbuilder.DecodeControlProgramAction,
+		"control_receiver":               txbuilder.DecodeControlReceiverAction,
+		"retire":                         txbuilder.DecodeRetireAction,
 		"issue":                          h.Assets.DecodeIssueAction,
 		"spend_account":                  h.Accounts.DecodeSpendAction,
 		"spend_account_unspent_output":   h.Accounts.DecodeSpendUTXOAction,
 		"set_transaction_reference_data": txbuilder.DecodeSetTxRefDataAction,
+		"crowdfund_pledge":               h.crowdfund.DecodePledgeAction,
+		"crowdfund_claim":                h.crowdfund.DecodeClaimAction,
+		"crowdfund_refund":               h.crowdfund.DecodeRefundAction,
+		"subscription_pull":              h.subscriptions.DecodePullAction,
+		"subscription_top_up":            h.subscriptions.DecodeTopUpAction,
+		"subscription_cancel":            h.subscriptions.DecodeCancelAction,
+		"payment_channel_fund":           h.channels.DecodeFundAction,
+		"payment_channel_close":          h.channels.DecodeCloseAction,
+		"payment_channel_settle":         h.channels.DecodeSettleAction,
+		"payment_channel_dispute":        h.channels.DecodeDisputeAction,
+		"escrow":                         h.escrow.DecodeEscrowAction,
+		"escrow_release":                 h.escrow.DecodeReleaseAction,
+		"escrow_refund":                  h.escrow.DecodeRefundAction,
+		"auction_offer":                  h.auctions.DecodeOfferAction,
+		"auction_buy":                    h.auctions.DecodeBuyAction,
+		"auction_cancel":                 h.auctions.DecodeCancelAction,
+		"voting_issue":                   h.voting.DecodeIssueAction,
+		"voting_cast":                    h.voting.DecodeCastAction,
+		"voting_recast":                  h.voting.DecodeRecastAction,
+		"htlc":                           h.htlcs.DecodeLockAction,
+		"htlc_claim":                     h.htlcs.DecodeClaimAction,
+		"htlc_refund":                    h.htlcs.DecodeRefundAction,
 	}
 
 	// Setup the muxer.
@@ -115,9 +281,21 @@ func (h *Handler) init() {
 
 	m.Handle("/create-account", needConfig(h.createAccount))
 	m.Handle("/create-asset", needConfig(h.createAsset))
+	m.Handle("/archive-account", needConfig(h.archiveAccount))
+	m.Handle("/restore-account", needConfig(h.restoreAccount))
+	m.Handle("/update-account-tags", needConfig(h.updateAccountTags))
+	m.Handle("/archive-asset", needConfig(h.archiveAsset))
+	m.Handle("/restore-asset", needConfig(h.restoreAsset))
+	m.Handle("/list-asset-definitions", needConfig(h.listAssetDefinitions))
+	m.Handle("/register-asset-definition-schema", needConfig(h.registerAssetDefinitionSchema))
+	m.Handle("/list-asset-definition-schemas", needConfig(h.listAssetDefinitionSchemas))
 	m.Handle("/build-transaction", needConfig(h.build))
+	m.Handle("/build-transaction-from-pain001", needConfig(h.buildPain001))
+	m.Handle("/cancel-reservation", needConfig(h.cancelReservation))
+	m.Handle("/extend-reservation", needConfig(h.extendReservation))
 	m.Handle("/submit-transaction", needConfig(h.submit))
 	m.Handle("/create-control-program", needConfig(h.createControlProgram))
+	m.Handle("/create-account-receiver", needConfig(h.createAccountReceiver))
 	m.Handle("/create-transaction-feed", needConfig(h.createTxFeed))
 	m.Handle("/get-transaction-feed", needConfig(h.getTxFeed))
 	m.Handle("/update-transaction-feed", needConfig(h.updateTxFeed))
@@ -126,15 +304,55 @@ func (h *Handler) init() {
 	m.Handle("/mockhsm/list-keys", needConfig(h.mockhsmListKeys))
 	m.Handle("/mockhsm/delkey", needConfig(h.mockhsmDelKey))
 	m.Handle("/mockhsm/sign-transaction", needConfig(h.mockhsmSignTemplates))
-	m.Handle("/list-accounts", needConfig(h.listAccounts))
-	m.Handle("/list-assets", needConfig(h.listAssets))
+	m.Handle("/list-accounts", needConfig(h.ListAccounts))
+	m.Handle("/list-assets", needConfig(h.ListAssets))
 	m.Handle("/list-transaction-feeds", needConfig(h.listTxFeeds))
-	m.Handle("/list-transactions", needConfig(h.listTransactions))
-	m.Handle("/list-balances", needConfig(h.listBalances))
-	m.Handle("/list-unspent-outputs", needConfig(h.listUnspentOutputs))
+	m.Handle("/list-transactions", needConfig(h.ListTransactions))
+	m.Handle("/list-transactions-by-end-to-end-id", needConfig(h.listTransactionsByEndToEndID))
+	m.Handle("/trace-transactions", needConfig(h.traceTransactions))
+	m.Handle("/list-asset-holders", needConfig(h.listAssetHolders))
+	m.Handle("/list-retirements", needConfig(h.listRetirements))
+	m.Handle("/utxo-stats", needConfig(h.utxoStats))
+	m.Handle("/block-stats", needConfig(h.blockStats))
+	m.Handle("/get-block-finality", needConfig(h.getBlockFinality))
+	m.Handle("/decode-transaction", needConfig(h.decodeTransaction))
+	m.Handle("/get-signing-progress", needConfig(h.getSigningProgress))
+	m.Handle("/assemble-program", needConfig(h.assembleProgram))
+	m.Handle("/get-crowdfund-campaign", needConfig(h.getCrowdfundCampaign))
+	m.Handle("/create-subscription", needConfig(h.createSubscription))
+	m.Handle("/list-subscriptions", needConfig(h.listSubscriptions))
+	m.Handle("/get-subscription", needConfig(h.getSubscription))
+	m.Handle("/create-forwarding-rule", needConfig(h.createForwardingRule))
+	m.Handle("/list-forwarding-rules", needConfig(h.listForwardingRules))
+	m.Handle("/get-forwarding-rule", needConfig(h.getForwardingRule))
+	m.Handle("/delete-forwarding-rule", needConfig(h.deleteForwardingRule))
+	m.Handle("/create-wallet-tier", needConfig(h.createWalletTier))
+	m.Handle("/list-wallet-tiers", needConfig(h.listWalletTiers))
+	m.Handle("/get-wallet-tier", needConfig(h.getWalletTier))
+	m.Handle("/delete-wallet-tier", needConfig(h.deleteWalletTier))
+	m.Handle("/add-wallet-tier-template", needConfig(h.addWalletTierTemplate))
+	m.Handle("/update-blocklist", needConfig(h.updateBlocklist))
+	m.Handle("/get-blocklist", needConfig(h.getBlocklist))
+	m.Handle("/create-payment-channel", needConfig(h.createPaymentChannel))
+	m.Handle("/get-payment-channel", needConfig(h.getPaymentChannel))
+	m.Handle("/pay-payment-channel", needConfig(h.payPaymentChannel))
+	m.Handle("/accept-payment-channel-state", needConfig(h.acceptPaymentChannelState))
+	m.Handle("/get-escrow", needConfig(h.getEscrow))
+	m.Handle("/get-auction", needConfig(h.getAuction))
+	m.Handle("/list-auctions", needConfig(h.listAuctions))
+	m.Handle("/tally-votes", needConfig(h.tallyVotes))
+	m.Handle("/list-htlcs", needConfig(h.listHTLCs))
+	m.Handle("/disassemble-program", needConfig(h.disassembleProgram))
+	m.Handle("/build-account-sweep", needConfig(h.buildAccountSweep))
+	m.Handle("/build-account-consolidation", needConfig(h.buildAccountConsolidation))
+	m.Handle("/build-transfer", needConfig(h.buildTransferLegs))
+	m.Handle("/list-balances", needConfig(h.ListBalances))
+	m.Handle("/get-account-balance", needConfig(h.getAccountBalance))
+	m.Handle("/get-account-activity", needConfig(h.getAccountActivity))
+	m.Handle("/list-unspent-outputs", needConfig(h.ListUnspentOutputs))
 	m.Handle("/reset", needConfig(h.reset))
 
-	m.Handle(networkRPCPrefix+"submit", needConfig(h.Chain.AddTx))
+	m.Handle(networkRPCPrefix+"submit", needConfig(h.admitTx))
 	m.Handle(networkRPCPrefix+"get-blocks", needConfig(h.getBlocksRPC)) // DEPRECATED: use get-block instead
 	m.Handle(networkRPCPrefix+"get-block", needConfig(h.getBlockRPC))
 	m.Handle(networkRPCPrefix+"get-snapshot-info", needConfig(h.getSnapshotInfoRPC))
@@ -170,13 +388,14 @@ func (h *Handler) init() {
 		tokens:   h.AccessTokens,
 		tokenMap: make(map[string]tokenResult),
 		alt:      h.AltAuth,
-	}).handler(latencyHandler)
+	}).handler(h.concurrencyLimited(accountScoped(latencyHandler)))
 	handler = maxBytes(handler)
 	handler = webAssetsHandler(handler)
 	for _, l := range h.RequestLimits {
 		handler = limit.Handler(handler, alwaysError(errRateLimited), l.PerSecond, l.Burst, l.Key)
 	}
-	handler = gzip.Handler{Handler: handler}
+	handler = gzip.Handler{Handler: handler, MinSize: h.GzipMinSize}
+	handler = localeHandler(handler)
 	handler = coreCounter(handler)
 	handler = reqid.Handler(handler)
 	handler = timeoutContextHandler(handler)
@@ -210,8 +429,10 @@ type ConfigSigner struct {
 	URL         string        `json:"url"`
 }
 
-// Used as a request object for api queries
-type requestQuery struct {
+// QueryRequest is the request of the list operations,
+// such as ListTransactions, and the Next field of their
+// result pages.
+type QueryRequest struct {
 	Filter       string        `json:"filter,omitempty"`
 	FilterParams []interface{} `json:"filter_params,omitempty"`
 	SumBy        []string      `json:"sum_by,omitempty"`
@@ -227,13 +448,14 @@ type requestQuery struct {
 	// should be included. It has no relationship to time.
 	After string `json:"after"`
 
-	// These two are used for time-range queries like /list-transactions
-	StartTimeMS uint64 `json:"start_time,omitempty"`
-	EndTimeMS   uint64 `json:"end_time,omitempty"`
+	// These two are used for time-range queries like /list-transactions.
+	// Like TimestampMS, they accept milliseconds or RFC 3339 times.
+	StartTimeMS json.Millis `json:"start_time,omitempty"`
+	EndTimeMS   json.Millis `json:"end_time,omitempty"`
 
 	// This is used for point-in-time queries like /list-balances
 	// TODO(bobg): Different request structs for endpoints with different needs
-	TimestampMS uint64 `json:"timestamp,omitempty"`
+	TimestampMS json.Millis `json:"timestamp,omitempty"`
 
 	// This is used for filtering results from /list-access-tokens
 	// Value must be "client" or "network"
@@ -241,12 +463,21 @@ type requestQuery struct {
 
 	// Aliases is used to filter results from /mockshm/list-keys
 	Aliases []string `json:"aliases,omitempty"`
+
+	// IncludeArchived is used by /list-accounts and /list-assets
+	// to list archived items too.
+	IncludeArchived bool `json:"include_archived,omitempty"`
+
+	// AccountID and AccountAlias are used by /list-htlcs.
+	AccountID    string `json:"account_id,omitempty"`
+	AccountAlias string `json:"account_alias,omitempty"`
 }
 
-// Used as a response object for api queries
-type page struct {
+// Page is a page of results of a list operation. Pass
+// Next to the same operation for the next page.
+type Page struct {
 	Items    interface{}  `json:"items"`
-	Next     requestQuery `json:"next"`
+	Next     QueryRequest `json:"next"`
 	LastPage bool         `json:"last_page"`
 }
 
@@ -13,7 +13,6 @@ import (
 	"chain/core/query"
 	"chain/core/txbuilder"
 	"chain/database/pg/pgtest"
-	chainjson "chain/encoding/json"
 	"chain/errors"
 	"chain/protocol/bc"
 	"chain/protocol/prottest"
@@ -250,14 +249,14 @@ func TestTransfer(t *testing.T) {
 		]}
 	`
 	buildReqStr := fmt.Sprintf(buildReqFmt, assetIDStr, account1ID, assetIDStr, account2ID)
-	var buildReq buildRequest
+	var buildReq BuildRequest
 	err = json.Unmarshal([]byte(buildReqStr), &buildReq)
 	if err != nil {
 		t.Log(errors.Stack(err))
 		t.Fatal(err)
 	}
 
-	buildResult, err := handler.build(ctx, []*buildRequest{&buildReq})
+	buildResult, err := handler.build(ctx, []*BuildRequest{&buildReq})
 	if err != nil {
 		t.Log(errors.Stack(err))
 		t.Fatal(err)
@@ -284,7 +283,7 @@ func TestTransfer(t *testing.T) {
 		t.Fatal(err)
 	}
 	coretest.SignTxTemplate(t, ctx, txTemplate, &testutil.TestXPrv)
-	_, err = handler.submitSingle(ctx, c, submitSingleArg{tpl: txTemplate, wait: chainjson.Duration{time.Millisecond}})
+	_, err = handler.SubmitTransaction(ctx, txTemplate, time.Millisecond)
 	if err != nil && err != context.DeadlineExceeded {
 		testutil.FatalErr(t, err)
 	}
@@ -303,7 +302,7 @@ func TestTransfer(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	buildResult, err = handler.build(ctx, []*buildRequest{&buildReq})
+	buildResult, err = handler.build(ctx, []*BuildRequest{&buildReq})
 	if err != nil {
 		t.Log(errors.Stack(err))
 		t.Fatal(err)
@@ -329,7 +328,7 @@ func TestTransfer(t *testing.T) {
 		t.Log(errors.Stack(err))
 		t.Fatal(err)
 	}
-	_, err = handler.submitSingle(ctx, c, submitSingleArg{tpl: txTemplate, wait: chainjson.Duration{time.Millisecond}})
+	_, err = handler.SubmitTransaction(ctx, txTemplate, time.Millisecond)
 	if err != nil && err != context.DeadlineExceeded {
 		testutil.FatalErr(t, err)
 	}
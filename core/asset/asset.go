@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/golang/groupcache/lru"
 	"github.com/lib/pq"
@@ -25,7 +26,13 @@ import (
 
 const maxAssetCache = 100
 
-var ErrDuplicateAlias = errors.New("duplicate asset alias")
+// RestoreWindow is how long after archiving an asset can be restored.
+const RestoreWindow = 30 * 24 * time.Hour
+
+var (
+	ErrDuplicateAlias = errors.New("duplicate asset alias")
+	ErrRestoreWindow  = errors.New("asset restore window has passed")
+)
 
 func NewRegistry(db pg.DB, chain *protocol.Chain) *Registry {
 	return &Registry{
@@ -60,11 +67,33 @@ type Asset struct {
 	InitialBlockHash bc.Hash
 	Signer           *signers.Signer
 	Tags             map[string]interface{}
+	IssuancePolicy   *IssuancePolicy
 	sortID           string
+
+	// ArchivedAt is the time the asset was archived,
+	// or nil if it is not archived.
+	ArchivedAt *time.Time
+
+	// DefinitionSchemaID is the ID of the schema that the
+	// asset's definitions must conform to, or empty.
+	DefinitionSchemaID string
 }
 
-// Define defines a new Asset.
-func (reg *Registry) Define(ctx context.Context, xpubs []string, quorum int, definition map[string]interface{}, alias string, tags map[string]interface{}, clientToken *string) (*Asset, error) {
+// Define defines a new Asset. If policy is not nil,
+// it limits the issuances this core will build. If schemaID
+// is not empty, definition must conform to that schema.
+func (reg *Registry) Define(ctx context.Context, xpubs []string, quorum int, definition map[string]interface{}, alias string, tags map[string]interface{}, policy *IssuancePolicy, schemaID string, clientToken *string) (*Asset, error) {
+	if policy != nil {
+		err := policy.validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := reg.checkDefinition(ctx, schemaID, definition)
+	if err != nil {
+		return nil, err
+	}
+
 	assetSigner, err := signers.Create(ctx, reg.db, "asset", xpubs, quorum, clientToken)
 	if err != nil {
 		return nil, err
@@ -84,12 +113,14 @@ func (reg *Registry) Define(ctx context.Context, xpubs []string, quorum int, def
 	}
 
 	asset := &Asset{
-		Definition:       definition,
-		IssuanceProgram:  issuanceProgram,
-		InitialBlockHash: reg.initialBlockHash,
-		AssetID:          bc.ComputeAssetID(issuanceProgram, reg.initialBlockHash, 1),
-		Signer:           assetSigner,
-		Tags:             tags,
+		Definition:         definition,
+		IssuanceProgram:    issuanceProgram,
+		InitialBlockHash:   reg.initialBlockHash,
+		AssetID:            bc.ComputeAssetID(issuanceProgram, reg.initialBlockHash, 1),
+		Signer:             assetSigner,
+		Tags:               tags,
+		IssuancePolicy:     policy,
+		DefinitionSchemaID: schemaID,
 	}
 	if alias != "" {
 		asset.Alias = &alias
@@ -113,6 +144,61 @@ func (reg *Registry) Define(ctx context.Context, xpubs []string, quorum int, def
 	return asset, nil
 }
 
+// Archive archives the asset with ID id, hiding it from default
+// asset listings. Its history is kept, and it can be restored
+// within RestoreWindow. Archiving an archived asset has no effect.
+func (reg *Registry) Archive(ctx context.Context, id bc.AssetID) (*Asset, error) {
+	const q = `UPDATE assets SET archived_at = COALESCE(archived_at, now()) WHERE id=$1`
+	return reg.setArchived(ctx, id, q)
+}
+
+// Restore restores the archived asset with ID id,
+// if it was archived less than RestoreWindow ago.
+func (reg *Registry) Restore(ctx context.Context, id bc.AssetID) (*Asset, error) {
+	a, err := assetQuery(ctx, reg.db, "assets.id=$1", id)
+	if err != nil {
+		return nil, err
+	}
+	if a.ArchivedAt == nil {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "asset %s is not archived", id)
+	}
+	if time.Since(*a.ArchivedAt) > RestoreWindow {
+		return nil, errors.WithDetailf(ErrRestoreWindow, "asset %s was archived at %s", id, a.ArchivedAt.Format(time.RFC3339))
+	}
+	const q = `UPDATE assets SET archived_at = NULL WHERE id=$1`
+	return reg.setArchived(ctx, id, q)
+}
+
+// setArchived runs the update q on the asset with ID id,
+// then reindexes the asset.
+func (reg *Registry) setArchived(ctx context.Context, id bc.AssetID, q string) (*Asset, error) {
+	res, err := reg.db.Exec(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if n == 0 {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "asset id: %s", id)
+	}
+
+	reg.cacheMu.Lock()
+	reg.cache.Remove(id)
+	reg.cacheMu.Unlock()
+
+	a, err := reg.findByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = reg.indexAnnotatedAsset(ctx, a)
+	if err != nil {
+		return nil, errors.Wrap(err, "indexing annotated asset")
+	}
+	return a, nil
+}
+
 // findByID retrieves an Asset record along with its signer, given an assetID.
 func (reg *Registry) findByID(ctx context.Context, id bc.AssetID) (*Asset, error) {
 	reg.cacheMu.Lock()
@@ -143,8 +229,9 @@ func (reg *Registry) FindByAlias(ctx context.Context, alias string) (*Asset, err
 func (reg *Registry) insertAsset(ctx context.Context, asset *Asset, clientToken *string) (*Asset, error) {
 	const q = `
 		INSERT INTO assets
-			(id, alias, signer_id, initial_block_hash, issuance_program, definition, client_token)
-		VALUES($1, $2, $3, $4, $5, $6, $7)
+			(id, alias, signer_id, initial_block_hash, issuance_program, definition, client_token,
+			max_issuance, one_time_issuance, definition_schema_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
 		ON CONFLICT (client_token) DO NOTHING
 		RETURNING sort_id
   `
@@ -158,11 +245,22 @@ func (reg *Registry) insertAsset(ctx context.Context, asset *Asset, clientToken
 		signerID = sql.NullString{Valid: true, String: asset.Signer.ID}
 	}
 
+	var (
+		maxIssuance sql.NullInt64
+		oneTime     bool
+	)
+	if p := asset.IssuancePolicy; p != nil {
+		if p.MaxAmount != nil {
+			maxIssuance = sql.NullInt64{Valid: true, Int64: int64(*p.MaxAmount)}
+		}
+		oneTime = p.OneTime
+	}
+
 	err = reg.db.QueryRow(
 		ctx, q,
 		asset.AssetID, asset.Alias, signerID,
 		asset.InitialBlockHash, asset.IssuanceProgram,
-		defParams, clientToken,
+		defParams, clientToken, maxIssuance, oneTime, asset.DefinitionSchemaID,
 	).Scan(&asset.sortID)
 
 	if pg.IsUniqueViolation(err) {
@@ -211,7 +309,9 @@ func assetQuery(ctx context.Context, db pg.DB, pred string, args ...interface{})
 			assets.initial_block_hash, assets.sort_id,
 			signers.id, COALESCE(signers.type, ''), COALESCE(signers.xpubs, '{}'),
 			COALESCE(signers.quorum, 0), COALESCE(signers.key_index, 0),
-			asset_tags.tags
+			asset_tags.tags, assets.archived_at,
+			assets.max_issuance, assets.one_time_issuance,
+			COALESCE(assets.definition_schema_id, '')
 		FROM assets
 		LEFT JOIN signers ON signers.id=assets.signer_id
 		LEFT JOIN asset_tags ON asset_tags.asset_id=assets.id
@@ -228,6 +328,9 @@ func assetQuery(ctx context.Context, db pg.DB, pred string, args ...interface{})
 		keyIndex   uint64
 		xpubs      []string
 		tags       []byte
+		archivedAt pq.NullTime
+		maxIssue   sql.NullInt64
+		oneTime    bool
 	)
 	err := db.QueryRow(ctx, fmt.Sprintf(baseQ, pred), args...).Scan(
 		&a.AssetID,
@@ -242,6 +345,10 @@ func assetQuery(ctx context.Context, db pg.DB, pred string, args ...interface{})
 		&quorum,
 		&keyIndex,
 		&tags,
+		&archivedAt,
+		&maxIssue,
+		&oneTime,
+		&a.DefinitionSchemaID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, pg.ErrUserInputNotFound
@@ -274,6 +381,18 @@ func assetQuery(ctx context.Context, db pg.DB, pred string, args ...interface{})
 		}
 	}
 
+	if archivedAt.Valid {
+		a.ArchivedAt = &archivedAt.Time
+	}
+
+	if maxIssue.Valid || oneTime {
+		a.IssuancePolicy = &IssuancePolicy{OneTime: oneTime}
+		if maxIssue.Valid {
+			max := uint64(maxIssue.Int64)
+			a.IssuancePolicy.MaxAmount = &max
+		}
+	}
+
 	return &a, nil
 }
 
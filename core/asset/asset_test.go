@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"chain/database/pg/pgtest"
+	"chain/errors"
 	"chain/protocol/prottest"
 	"chain/testutil"
 )
@@ -15,7 +16,7 @@ func TestDefineAsset(t *testing.T) {
 	ctx := context.Background()
 
 	keys := []string{testutil.TestXPub.String()}
-	asset, err := r.Define(ctx, keys, 1, nil, "", nil, nil)
+	asset, err := r.Define(ctx, keys, 1, nil, "", nil, nil, "", nil)
 	if err != nil {
 		testutil.FatalErr(t, err)
 	}
@@ -40,11 +41,11 @@ func TestDefineAssetIdempotency(t *testing.T) {
 	ctx := context.Background()
 	token := "test_token"
 	keys := []string{testutil.TestXPub.String()}
-	asset0, err := r.Define(ctx, keys, 1, nil, "", nil, &token)
+	asset0, err := r.Define(ctx, keys, 1, nil, "", nil, nil, "", &token)
 	if err != nil {
 		testutil.FatalErr(t, err)
 	}
-	asset1, err := r.Define(ctx, keys, 1, nil, "", nil, &token)
+	asset1, err := r.Define(ctx, keys, 1, nil, "", nil, nil, "", &token)
 	if err != nil {
 		testutil.FatalErr(t, err)
 	}
@@ -59,7 +60,7 @@ func TestFindAssetByID(t *testing.T) {
 	r := NewRegistry(pgtest.NewTx(t), prottest.NewChain(t))
 	ctx := context.Background()
 	keys := []string{testutil.TestXPub.String()}
-	asset, err := r.Define(ctx, keys, 1, nil, "", nil, nil)
+	asset, err := r.Define(ctx, keys, 1, nil, "", nil, nil, "", nil)
 	if err != nil {
 		testutil.FatalErr(t, err)
 	}
@@ -79,7 +80,7 @@ func TestAssetByClientToken(t *testing.T) {
 	keys := []string{testutil.TestXPub.String()}
 	token := "test_token"
 
-	asset, err := r.Define(ctx, keys, 1, nil, "", nil, &token)
+	asset, err := r.Define(ctx, keys, 1, nil, "", nil, nil, "", &token)
 	if err != nil {
 		testutil.FatalErr(t, err)
 	}
@@ -92,3 +93,42 @@ func TestAssetByClientToken(t *testing.T) {
 		t.Fatalf("assetByClientToken(\"test_token\")=%x, want %x", found.AssetID[:], asset.AssetID[:])
 	}
 }
+
+func TestArchiveRestoreAsset(t *testing.T) {
+	r := NewRegistry(pgtest.NewTx(t), prottest.NewChain(t))
+	ctx := context.Background()
+	keys := []string{testutil.TestXPub.String()}
+	asset, err := r.Define(ctx, keys, 1, nil, "", nil, nil, "", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	archived, err := r.Archive(ctx, asset.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if archived.ArchivedAt == nil {
+		t.Fatal("archived asset has no archive time")
+	}
+	restored, err := r.Restore(ctx, asset.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if restored.ArchivedAt != nil {
+		t.Errorf("restored asset has archive time %s", restored.ArchivedAt)
+	}
+
+	// Past the restore window, the asset stays archived.
+	_, err = r.Archive(ctx, asset.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = r.db.Exec(ctx, `UPDATE assets SET archived_at = now() - interval '31 days' WHERE id=$1`, asset.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = r.Restore(ctx, asset.AssetID)
+	if errors.Root(err) != ErrRestoreWindow {
+		t.Errorf("restoring after window: got error %v, want %v", err, ErrRestoreWindow)
+	}
+}
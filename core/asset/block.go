@@ -33,6 +33,15 @@ func (reg *Registry) indexAnnotatedAsset(ctx context.Context, a *Asset) error {
 		"tags":             a.Tags,
 		"is_local":         "no",
 	}
+	if a.ArchivedAt != nil {
+		m["archived_at"] = a.ArchivedAt.UTC()
+	}
+	if a.IssuancePolicy != nil {
+		m["issuance_policy"] = a.IssuancePolicy
+	}
+	if a.DefinitionSchemaID != "" {
+		m["definition_schema_id"] = a.DefinitionSchemaID
+	}
 	if a.Signer != nil {
 		var keys []map[string]interface{}
 		path := signers.Path(a.Signer, signers.AssetKeySpace)
@@ -32,6 +32,10 @@ type issueAction struct {
 	assets *Registry
 	bc.AssetAmount
 	ReferenceData chainjson.Map `json:"reference_data"`
+
+	// Definition, if set, publishes an updated definition
+	// for the asset in the issuance's reference data.
+	Definition map[string]interface{} `json:"definition"`
 }
 
 func (a *issueAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
@@ -42,13 +46,28 @@ func (a *issueAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.
 	if err != nil {
 		return nil, err
 	}
+	refData := a.ReferenceData
+	if a.Definition != nil {
+		err = a.assets.checkDefinition(ctx, asset.DefinitionSchemaID, a.Definition)
+		if err != nil {
+			return nil, err
+		}
+		refData, err = withDefinition(refData, a.Definition)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var nonce [8]byte
 	_, err = rand.Read(nonce[:])
 	if err != nil {
 		return nil, err
 	}
-	txin := bc.NewIssuanceInput(nonce[:], a.Amount, a.ReferenceData, asset.InitialBlockHash, asset.IssuanceProgram, nil)
+	err = a.assets.reserveIssuance(ctx, asset, a.Amount, nonce[:], maxTime)
+	if err != nil {
+		return nil, err
+	}
+	txin := bc.NewIssuanceInput(nonce[:], a.Amount, refData, asset.InitialBlockHash, asset.IssuanceProgram, nil)
 
 	tplIn := &txbuilder.SigningInstruction{AssetAmount: a.AssetAmount}
 	path := signers.Path(asset.Signer, signers.AssetKeySpace)
@@ -61,3 +80,18 @@ func (a *issueAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.
 		MinTimeMS:           bc.Millis(time.Now()),
 	}, nil
 }
+
+// withDefinition adds def to the reference data refData
+// under DefinitionKey.
+func withDefinition(refData chainjson.Map, def map[string]interface{}) (chainjson.Map, error) {
+	m := make(map[string]interface{})
+	if len(refData) > 0 {
+		err := json.Unmarshal(refData, &m)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding reference data")
+		}
+	}
+	m[DefinitionKey] = def
+	b, err := json.Marshal(m)
+	return b, errors.Wrap(err, "encoding reference data")
+}
@@ -0,0 +1,175 @@
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// DefinitionKey is the key, in the reference data of an issuance
+// input, of an updated definition for the issued asset.
+//
+// An asset's original definition is part of its issuance program,
+// and so of its asset ID, and cannot change. Issuers publish later
+// definitions in the reference data of their issuances instead,
+// which the issuance signatures commit to.
+const DefinitionKey = "asset_definition"
+
+// DefinitionVersion is one version of an asset's definition, with
+// the transaction that introduced it. Version 1 is the definition
+// in the issuance program, introduced by the first issuance seen.
+type DefinitionVersion struct {
+	Version     int                    `json:"version"`
+	Definition  map[string]interface{} `json:"definition"`
+	TxHash      bc.Hash                `json:"transaction_id"`
+	BlockHeight uint64                 `json:"block_height"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// TrackDefinitions records the definition history of each asset
+// issued in blocks landing on the chain, and applies definition
+// updates to the registry.
+func (reg *Registry) TrackDefinitions() {
+	reg.chain.AddBlockCallback(reg.updateDefinitions)
+}
+
+// updateDefinitions records the original definition of each
+// asset first issued in b, and each updated definition published
+// in b. It is safe to run more than once for the same block.
+func (reg *Registry) updateDefinitions(ctx context.Context, b *bc.Block) error {
+	const insertOrigQ = `
+		INSERT INTO asset_definitions (asset_id, version, definition, tx_hash, block_height, block_time)
+		VALUES ($1, 1, $2, $3, $4, $5)
+		ON CONFLICT (asset_id, version) DO NOTHING
+	`
+	const insertUpdateQ = `
+		INSERT INTO asset_definitions (asset_id, version, definition, tx_hash, block_height, block_time)
+		SELECT $1, COALESCE(MAX(version), 1) + 1, $2, $3, $4, $5
+		FROM asset_definitions WHERE asset_id = $1
+		HAVING NOT EXISTS (
+			SELECT 1 FROM asset_definitions WHERE asset_id = $1 AND tx_hash = $3 AND version > 1
+		)
+	`
+	for _, tx := range b.Transactions {
+		for _, in := range tx.Inputs {
+			if !in.IsIssuance() {
+				continue
+			}
+			assetID := in.AssetID()
+
+			orig, err := definitionFromProgram(in.IssuanceProgram())
+			if err == nil {
+				_, err = reg.db.Exec(ctx, insertOrigQ, assetID, string(jsonDefinition(orig)), tx.Hash, b.Height, b.Time())
+				if err != nil {
+					return errors.Wrap(err, "recording original asset definition")
+				}
+			}
+
+			def, ok := definitionUpdate(in.ReferenceData)
+			if !ok {
+				continue
+			}
+			_, err = reg.db.Exec(ctx, insertUpdateQ, assetID, string(def), tx.Hash, b.Height, b.Time())
+			if err != nil {
+				return errors.Wrap(err, "recording asset definition")
+			}
+			err = reg.setDefinition(ctx, assetID, def)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// definitionUpdate returns the updated definition published
+// in the reference data of an issuance input, if there is one.
+func definitionUpdate(refData []byte) ([]byte, bool) {
+	var m map[string]json.RawMessage
+	if json.Unmarshal(refData, &m) != nil {
+		return nil, false
+	}
+	def, ok := m[DefinitionKey]
+	if !ok {
+		return nil, false
+	}
+	var obj map[string]interface{}
+	if json.Unmarshal(def, &obj) != nil || obj == nil {
+		return nil, false
+	}
+	return def, true
+}
+
+// jsonDefinition returns def, or null if def is not
+// a JSON object, as are some definitions in programs.
+func jsonDefinition(def []byte) []byte {
+	var obj map[string]interface{}
+	if json.Unmarshal(def, &obj) != nil {
+		return []byte("null")
+	}
+	return def
+}
+
+// setDefinition makes def the current definition of the
+// asset with ID id, if it is in the registry, and reindexes
+// the asset.
+func (reg *Registry) setDefinition(ctx context.Context, id bc.AssetID, def []byte) error {
+	const q = `UPDATE assets SET definition = $2 WHERE id = $1`
+	res, err := reg.db.Exec(ctx, q, id, string(def))
+	if err != nil {
+		return errors.Wrap(err, "updating asset definition")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "updating asset definition")
+	}
+	if n == 0 {
+		return nil
+	}
+
+	reg.cacheMu.Lock()
+	reg.cache.Remove(id)
+	reg.cacheMu.Unlock()
+
+	a, err := reg.findByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(reg.indexAnnotatedAsset(ctx, a), "indexing annotated asset")
+}
+
+// Definitions returns the definition history of the
+// asset with ID id, oldest first.
+func (reg *Registry) Definitions(ctx context.Context, id bc.AssetID) ([]*DefinitionVersion, error) {
+	const q = `
+		SELECT version, definition, tx_hash, block_height, block_time
+		FROM asset_definitions WHERE asset_id = $1 ORDER BY version
+	`
+	rows, err := reg.db.Query(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying asset definitions")
+	}
+	defer rows.Close()
+
+	versions := []*DefinitionVersion{}
+	for rows.Next() {
+		var (
+			v   DefinitionVersion
+			def []byte
+		)
+		err = rows.Scan(&v.Version, &def, &v.TxHash, &v.BlockHeight, &v.Timestamp)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning asset definition")
+		}
+		err = json.Unmarshal(def, &v.Definition)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding asset definition")
+		}
+		v.Timestamp = v.Timestamp.UTC()
+		versions = append(versions, &v)
+	}
+	return versions, errors.Wrap(rows.Err())
+}
@@ -0,0 +1,68 @@
+package asset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestUpdateDefinitions(t *testing.T) {
+	r := NewRegistry(pgtest.NewTx(t), prottest.NewChain(t))
+	ctx := context.Background()
+
+	orig := map[string]interface{}{"currency": "USD"}
+	a, err := r.Define(ctx, []string{testutil.TestXPub.String()}, 1, orig, "", nil, nil, "", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	updated := map[string]interface{}{"currency": "USD", "issuer": "acme"}
+	refData, err := withDefinition([]byte(`{"memo":"update"}`), updated)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	b := &bc.Block{
+		BlockHeader: bc.BlockHeader{Height: 2},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{Inputs: []*bc.TxInput{
+				bc.NewIssuanceInput(nil, 1, refData, a.InitialBlockHash, a.IssuanceProgram, nil),
+			}}),
+		},
+	}
+	// Running the same block twice records the update once.
+	for i := 0; i < 2; i++ {
+		err = r.updateDefinitions(ctx, b)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	versions, err := r.Definitions(ctx, a.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("got %d definition versions, want 2", len(versions))
+	}
+	for i, want := range []map[string]interface{}{orig, updated} {
+		if versions[i].Version != i+1 || !reflect.DeepEqual(versions[i].Definition, want) {
+			t.Errorf("version %d = %+v, want definition %v", i+1, versions[i], want)
+		}
+		if versions[i].TxHash != b.Transactions[0].Hash {
+			t.Errorf("version %d tx = %x, want %x", i+1, versions[i].TxHash[:], b.Transactions[0].Hash[:])
+		}
+	}
+
+	got, err := r.findByID(ctx, a.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !reflect.DeepEqual(got.Definition, updated) {
+		t.Errorf("definition = %v, want %v", got.Definition, updated)
+	}
+}
@@ -0,0 +1,250 @@
+package asset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"chain/errors"
+)
+
+// jsonSchema is a compiled JSON Schema. It supports the subset of
+// draft 4 that describes the shape of asset definitions:
+//
+//   type, enum, properties, required, additionalProperties,
+//   items, minItems, maxItems, minLength, maxLength, pattern,
+//   minimum, maximum
+//
+// The annotation keywords $schema, id, title, description, and
+// default are accepted and ignored. Any other keyword is an error,
+// so that a schema never appears to enforce a rule that it does not.
+type jsonSchema struct {
+	types                []string
+	enum                 []interface{}
+	properties           map[string]*jsonSchema
+	required             []string
+	additionalProperties *bool
+	items                *jsonSchema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+}
+
+type rawSchema struct {
+	Type                 json.RawMessage            `json:"type"`
+	Enum                 []interface{}              `json:"enum"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties *bool                      `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	Pattern              *string                    `json:"pattern"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+
+	Schema      interface{} `json:"$schema"`
+	ID          interface{} `json:"id"`
+	Title       interface{} `json:"title"`
+	Description interface{} `json:"description"`
+	Default     interface{} `json:"default"`
+}
+
+var schemaTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+// compileSchema parses and checks the JSON Schema in data.
+func compileSchema(data []byte) (*jsonSchema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var raw rawSchema
+	err := dec.Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &jsonSchema{
+		enum:                 raw.Enum,
+		required:             raw.Required,
+		additionalProperties: raw.AdditionalProperties,
+		minItems:             raw.MinItems,
+		maxItems:             raw.MaxItems,
+		minLength:            raw.MinLength,
+		maxLength:            raw.MaxLength,
+		minimum:              raw.Minimum,
+		maximum:              raw.Maximum,
+	}
+	if len(raw.Type) > 0 {
+		if raw.Type[0] == '[' {
+			err = json.Unmarshal(raw.Type, &s.types)
+		} else {
+			s.types = make([]string, 1)
+			err = json.Unmarshal(raw.Type, &s.types[0])
+		}
+		if err != nil {
+			return nil, errors.New("type must be a string or an array of strings")
+		}
+		for _, t := range s.types {
+			if !schemaTypes[t] {
+				return nil, fmt.Errorf("unknown type %q", t)
+			}
+		}
+	}
+	if len(raw.Properties) > 0 {
+		s.properties = make(map[string]*jsonSchema)
+		for name, sub := range raw.Properties {
+			s.properties[name], err = compileSchema(sub)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %s", name, err)
+			}
+		}
+	}
+	if len(raw.Items) > 0 {
+		s.items, err = compileSchema(raw.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %s", err)
+		}
+	}
+	if raw.Pattern != nil {
+		s.pattern, err = regexp.Compile(*raw.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %s", err)
+		}
+	}
+	return s, nil
+}
+
+// validate returns an error describing the first way in
+// which v, a value decoded by encoding/json, does not
+// conform to s. Path is the location of v, for errors.
+func (s *jsonSchema) validate(v interface{}, path string) error {
+	if len(s.types) > 0 && !s.hasType(v) {
+		return fmt.Errorf("%s: must be of type %s", path, typeList(s.types))
+	}
+	if len(s.enum) > 0 {
+		var ok bool
+		for _, e := range s.enum {
+			if reflect.DeepEqual(e, v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%s: must be one of the enumerated values", path)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names) // report errors deterministically
+		for _, name := range names {
+			sub, ok := s.properties[name]
+			if !ok {
+				if s.additionalProperties != nil && !*s.additionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			err := sub.validate(v[name], path+"."+name)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return fmt.Errorf("%s: must have at least %d items", path, *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return fmt.Errorf("%s: must have at most %d items", path, *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				err := s.items.validate(item, fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			return fmt.Errorf("%s: must be at least %d characters", path, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fmt.Errorf("%s: must be at most %d characters", path, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s: must match %q", path, s.pattern)
+		}
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			return fmt.Errorf("%s: must be at least %g", path, *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			return fmt.Errorf("%s: must be at most %g", path, *s.maximum)
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) hasType(v interface{}) bool {
+	for _, t := range s.types {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && v == float64(int64(v))) {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case nil:
+			if t == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeList(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return fmt.Sprintf("%v", types)
+}
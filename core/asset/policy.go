@@ -0,0 +1,178 @@
+package asset
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+var (
+	ErrBadIssuancePolicy = errors.New("invalid issuance policy")
+	ErrIssuanceCap       = errors.New("issuance would exceed the asset's issuance policy")
+)
+
+// IssuancePolicy limits how much of a local asset this core
+// will issue. MaxAmount, if set, caps the total amount issued
+// over the life of the asset. OneTime allows only a single
+// issuance.
+//
+// The policy is enforced when this core builds issuances, not
+// by the protocol: a holder of the asset keys can still sign
+// an issuance elsewhere. Each issuance this core builds reserves
+// its amount against the policy until it is confirmed or its
+// transaction expires, so issuances built before the next block
+// cannot together break the policy.
+type IssuancePolicy struct {
+	MaxAmount *uint64 `json:"max_amount,omitempty"`
+	OneTime   bool    `json:"one_time"`
+}
+
+func (p *IssuancePolicy) validate() error {
+	if p.MaxAmount != nil && (*p.MaxAmount == 0 || *p.MaxAmount > math.MaxInt64) {
+		return errors.WithDetailf(ErrBadIssuancePolicy, "max_amount must be between 1 and %d", int64(math.MaxInt64))
+	}
+	return nil
+}
+
+// TrackIssuances counts the amounts issued in each block landing
+// on the chain, for the assets with an issuance policy, so that
+// the policy can be enforced.
+func (reg *Registry) TrackIssuances() {
+	reg.chain.AddBlockCallback(reg.countIssuances)
+}
+
+// issuanceGrace is how long after a transaction's max time an
+// issuance reservation is held, so that the block confirming the
+// transaction can reach this core before the reservation lapses.
+const issuanceGrace = time.Minute
+
+// countIssuances adds the amounts issued in b to the issued
+// totals of the assets with an issuance policy, and releases the
+// reservations of those issuances. The height guard makes it safe
+// to run more than once for the same block.
+func (reg *Registry) countIssuances(ctx context.Context, b *bc.Block) error {
+	amounts := make(map[bc.AssetID]uint64)
+	var resAssetIDs pq.StringArray
+	var resNonces pq.ByteaArray
+	for _, tx := range b.Transactions {
+		for _, in := range tx.Inputs {
+			if ii, ok := in.TypedInput.(*bc.IssuanceInput); ok {
+				amounts[in.AssetID()] += in.Amount()
+				resAssetIDs = append(resAssetIDs, in.AssetID().String())
+				resNonces = append(resNonces, ii.Nonce)
+			}
+		}
+	}
+	if len(amounts) == 0 {
+		return nil
+	}
+
+	var assetIDs, issued pq.StringArray
+	for assetID, amount := range amounts {
+		assetIDs = append(assetIDs, assetID.String())
+		issued = append(issued, strconv.FormatUint(amount, 10))
+	}
+
+	const q = `
+		UPDATE assets SET issued_amount = issued_amount + x.amount, issued_height = $3
+		FROM (SELECT unnest($1::text[]) AS id, unnest($2::numeric[]) AS amount) AS x
+		WHERE assets.id = x.id AND assets.issued_height < $3
+			AND (assets.max_issuance IS NOT NULL OR assets.one_time_issuance)
+	`
+	_, err := reg.db.Exec(ctx, q, assetIDs, issued, b.Height)
+	if err != nil {
+		return errors.Wrap(err, "counting issuances")
+	}
+
+	// Release the reservations only once the amounts are counted,
+	// so that a crash in between can only overcount.
+	const releaseQ = `
+		WITH released AS (
+			DELETE FROM issuance_reservations r
+			USING (SELECT unnest($1::text[]) AS asset_id, unnest($2::bytea[]) AS nonce) AS x
+			WHERE r.asset_id = x.asset_id AND r.nonce = x.nonce
+			RETURNING r.asset_id, r.amount
+		)
+		UPDATE assets SET pending_issuance = pending_issuance - x.amount
+		FROM (SELECT asset_id, SUM(amount) AS amount FROM released GROUP BY asset_id) AS x
+		WHERE assets.id = x.asset_id
+	`
+	_, err = reg.db.Exec(ctx, releaseQ, resAssetIDs, resNonces)
+	return errors.Wrap(err, "releasing issuance reservations")
+}
+
+// reserveIssuance reserves amount of asset a against its issuance
+// policy, for the issuance with the given nonce in a transaction
+// with max time maxTime. It returns ErrIssuanceCap if the issuance,
+// together with the confirmed issuances and the reservations of
+// other issuances, would break the policy.
+func (reg *Registry) reserveIssuance(ctx context.Context, a *Asset, amount uint64, nonce []byte, maxTime time.Time) error {
+	p := a.IssuancePolicy
+	if p == nil || (p.MaxAmount == nil && !p.OneTime) {
+		return nil
+	}
+
+	err := reg.expireIssuanceReservations(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The conditions are checked and the reservation made in one
+	// statement. The UPDATE locks the asset's row, so concurrent
+	// reservations of the same asset see each other's amounts.
+	const q = `
+		WITH reserved AS (
+			UPDATE assets SET pending_issuance = pending_issuance + $2
+			WHERE id = $1
+				AND (NOT one_time_issuance OR (issued_height = 0 AND pending_issuance = 0))
+				AND (max_issuance IS NULL OR issued_amount + pending_issuance + $2 <= max_issuance)
+			RETURNING id
+		)
+		INSERT INTO issuance_reservations (asset_id, nonce, amount, expiry)
+		SELECT id, $3, $2, $4 FROM reserved
+	`
+	res, err := reg.db.Exec(ctx, q, a.AssetID, amount, nonce, maxTime.Add(issuanceGrace))
+	if err != nil {
+		return errors.Wrap(err, "reserving issuance")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "reserving issuance")
+	}
+	if n > 0 {
+		return nil
+	}
+
+	if p.OneTime {
+		return errors.WithDetailf(ErrIssuanceCap, "asset %s has already been issued", a.AssetID)
+	}
+	var issued, pending uint64
+	const countQ = `SELECT issued_amount, pending_issuance FROM assets WHERE id = $1`
+	err = reg.db.QueryRow(ctx, countQ, a.AssetID).Scan(&issued, &pending)
+	if err != nil {
+		return errors.Wrap(err, "looking up issued amount")
+	}
+	return errors.WithDetailf(ErrIssuanceCap, "%d of %d already issued, %d more pending", issued, *p.MaxAmount, pending)
+}
+
+// expireIssuanceReservations releases the issuance
+// reservations whose transactions can no longer land.
+func (reg *Registry) expireIssuanceReservations(ctx context.Context) error {
+	const q = `
+		WITH expired AS (
+			DELETE FROM issuance_reservations WHERE expiry < now()
+			RETURNING asset_id, amount
+		)
+		UPDATE assets SET pending_issuance = pending_issuance - x.amount
+		FROM (SELECT asset_id, SUM(amount) AS amount FROM expired GROUP BY asset_id) AS x
+		WHERE assets.id = x.asset_id
+	`
+	_, err := reg.db.Exec(ctx, q)
+	return errors.Wrap(err, "expiring issuance reservations")
+}
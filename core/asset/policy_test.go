@@ -0,0 +1,118 @@
+package asset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestIssuancePolicy(t *testing.T) {
+	r := NewRegistry(pgtest.NewTx(t), prottest.NewChain(t))
+	ctx := context.Background()
+	keys := []string{testutil.TestXPub.String()}
+
+	max := uint64(100)
+	capped, err := r.Define(ctx, keys, 1, nil, "", nil, &IssuancePolicy{MaxAmount: &max}, "", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	once, err := r.Define(ctx, keys, 1, nil, "", nil, &IssuancePolicy{OneTime: true}, "", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	fresh, err := r.Define(ctx, keys, 1, nil, "", nil, &IssuancePolicy{OneTime: true}, "", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	got, err := r.findByID(ctx, capped.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !reflect.DeepEqual(got.IssuancePolicy, capped.IssuancePolicy) {
+		t.Errorf("policy = %+v, want %+v", got.IssuancePolicy, capped.IssuancePolicy)
+	}
+
+	issue := func(a *Asset, amount uint64) *bc.TxInput {
+		return bc.NewIssuanceInput(nil, amount, nil, a.InitialBlockHash, a.IssuanceProgram, nil)
+	}
+	b := &bc.Block{
+		BlockHeader: bc.BlockHeader{Height: 2},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{Inputs: []*bc.TxInput{issue(capped, 60), issue(once, 1)}}),
+		},
+	}
+	// Counting the same block twice counts it once.
+	for i := 0; i < 2; i++ {
+		err = r.countIssuances(ctx, b)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	// Issuances built before the next block are counted
+	// against the policy as well.
+	maxTime := time.Now().Add(time.Minute)
+	cases := []struct {
+		a      *Asset
+		amount uint64
+		nonce  byte
+		want   error
+	}{
+		{capped, 41, 1, ErrIssuanceCap},
+		{capped, 30, 2, nil},
+		{capped, 11, 3, ErrIssuanceCap},
+		{capped, 10, 4, nil},
+		{once, 1, 5, ErrIssuanceCap},
+		{fresh, 1, 6, nil},
+		{fresh, 1, 7, ErrIssuanceCap},
+	}
+	for _, c := range cases {
+		err := r.reserveIssuance(ctx, c.a, c.amount, []byte{c.nonce}, maxTime)
+		if errors.Root(err) != c.want {
+			t.Errorf("reserveIssuance(%d) = %v, want %v", c.amount, err, c.want)
+		}
+	}
+
+	// Once an issuance is confirmed, its reservation is released
+	// and its amount counted only once.
+	b = &bc.Block{
+		BlockHeader: bc.BlockHeader{Height: 3},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{Inputs: []*bc.TxInput{
+				bc.NewIssuanceInput([]byte{2}, 30, nil, capped.InitialBlockHash, capped.IssuanceProgram, nil),
+			}}),
+		},
+	}
+	err = r.countIssuances(ctx, b)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	var issued, pending uint64
+	err = r.db.QueryRow(ctx, `SELECT issued_amount, pending_issuance FROM assets WHERE id = $1`, capped.AssetID).Scan(&issued, &pending)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if issued != 90 || pending != 10 {
+		t.Errorf("issued, pending = %d, %d, want 90, 10", issued, pending)
+	}
+
+	// An expired reservation no longer counts.
+	pgtest.Exec(ctx, r.db, t, `UPDATE issuance_reservations SET expiry = now() - interval '1 second'`)
+	err = r.reserveIssuance(ctx, fresh, 1, []byte{9}, maxTime)
+	if err != nil {
+		t.Errorf("reserveIssuance(fresh) after expiry = %v, want nil", err)
+	}
+
+	zero := uint64(0)
+	_, err = r.Define(ctx, keys, 1, nil, "", nil, &IssuancePolicy{MaxAmount: &zero}, "", nil)
+	if errors.Root(err) != ErrBadIssuancePolicy {
+		t.Errorf("defining with zero cap: got error %v, want %v", err, ErrBadIssuancePolicy)
+	}
+}
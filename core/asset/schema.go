@@ -0,0 +1,146 @@
+package asset
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"chain/database/pg"
+	"chain/errors"
+)
+
+var (
+	ErrBadSchema     = errors.New("invalid asset definition schema")
+	ErrNonconforming = errors.New("asset definition does not conform to its schema")
+)
+
+// DefinitionSchema is a JSON Schema registered with this core.
+// An asset created with a schema must have a definition that
+// conforms to it, as must each updated definition it issues.
+//
+// Schemas cannot be changed once registered, so that existing
+// definitions keep conforming to them.
+type DefinitionSchema struct {
+	ID        string          `json:"id"`
+	Alias     *string         `json:"alias"`
+	Schema    json.RawMessage `json:"schema"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// RegisterSchema stores schema, a JSON Schema for asset
+// definitions, under an optional alias.
+func (reg *Registry) RegisterSchema(ctx context.Context, schema json.RawMessage, alias string) (*DefinitionSchema, error) {
+	_, err := compileSchema(schema)
+	if err != nil {
+		return nil, errors.WithDetail(ErrBadSchema, err.Error())
+	}
+	s := &DefinitionSchema{Schema: schema}
+	if alias != "" {
+		s.Alias = &alias
+	}
+	const q = `
+		INSERT INTO asset_definition_schemas (alias, schema) VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+	err = reg.db.QueryRow(ctx, q, s.Alias, string(schema)).Scan(&s.ID, &s.CreatedAt)
+	if pg.IsUniqueViolation(err) {
+		return nil, errors.WithDetail(ErrDuplicateAlias, "a schema with the provided alias already exists")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "inserting asset definition schema")
+	}
+	s.CreatedAt = s.CreatedAt.UTC()
+	return s, nil
+}
+
+const selectSchemaQ = `SELECT id, alias, schema, created_at FROM asset_definition_schemas `
+
+func scanSchema(s interface {
+	Scan(...interface{}) error
+}) (*DefinitionSchema, error) {
+	var (
+		ds     DefinitionSchema
+		alias  sql.NullString
+		schema []byte
+	)
+	err := s.Scan(&ds.ID, &alias, &schema, &ds.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if alias.Valid {
+		ds.Alias = &alias.String
+	}
+	ds.Schema = schema
+	ds.CreatedAt = ds.CreatedAt.UTC()
+	return &ds, nil
+}
+
+// FindSchema returns the schema with the given ID,
+// or if id is empty, with the given alias.
+func (reg *Registry) FindSchema(ctx context.Context, id, alias string) (*DefinitionSchema, error) {
+	q, arg := selectSchemaQ+`WHERE id = $1`, id
+	if id == "" {
+		q, arg = selectSchemaQ+`WHERE alias = $1`, alias
+	}
+	s, err := scanSchema(reg.db.QueryRow(ctx, q, arg))
+	if err == sql.ErrNoRows {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "asset definition schema: %s", arg)
+	}
+	return s, errors.Wrap(err, "looking up asset definition schema")
+}
+
+// ListSchemas returns the schemas with IDs after after,
+// in ID order, at most limit of them.
+func (reg *Registry) ListSchemas(ctx context.Context, after string, limit int) ([]*DefinitionSchema, error) {
+	const q = selectSchemaQ + `WHERE id > $1 ORDER BY id LIMIT $2`
+	rows, err := reg.db.Query(ctx, q, after, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying asset definition schemas")
+	}
+	defer rows.Close()
+
+	var schemas []*DefinitionSchema
+	for rows.Next() {
+		s, err := scanSchema(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning asset definition schema")
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, errors.Wrap(rows.Err())
+}
+
+// checkDefinition returns ErrNonconforming if def does not
+// conform to the schema with ID schemaID. An empty schemaID
+// means the asset has no schema, and any definition is valid.
+func (reg *Registry) checkDefinition(ctx context.Context, schemaID string, def map[string]interface{}) error {
+	if schemaID == "" {
+		return nil
+	}
+	s, err := reg.FindSchema(ctx, schemaID, "")
+	if err != nil {
+		return err
+	}
+	compiled, err := compileSchema(s.Schema)
+	if err != nil {
+		return errors.Wrap(err, "compiling asset definition schema")
+	}
+
+	// Round-trip def through JSON so that its numbers are float64,
+	// whatever decoder produced it.
+	b, err := json.Marshal(def)
+	if err != nil {
+		return errors.Wrap(err, "encoding asset definition")
+	}
+	var v interface{}
+	err = json.Unmarshal(b, &v)
+	if err != nil {
+		return errors.Wrap(err, "decoding asset definition")
+	}
+
+	err = compiled.validate(v, "definition")
+	if err != nil {
+		return errors.WithDetail(ErrNonconforming, err.Error())
+	}
+	return nil
+}
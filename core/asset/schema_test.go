@@ -0,0 +1,129 @@
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+const currencySchema = `{
+	"type": "object",
+	"required": ["currency"],
+	"properties": {
+		"currency": {"type": "string", "pattern": "^[A-Z]{3}$"},
+		"decimals": {"type": "integer", "minimum": 0, "maximum": 18},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+	},
+	"additionalProperties": false
+}`
+
+func TestValidateSchema(t *testing.T) {
+	s, err := compileSchema([]byte(currencySchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		def string
+		ok  bool
+	}{
+		{`{"currency": "USD"}`, true},
+		{`{"currency": "USD", "decimals": 2, "tags": ["fiat"]}`, true},
+		{`{}`, false},
+		{`null`, false},
+		{`{"currency": "usd"}`, false},
+		{`{"currency": "USD", "decimals": 2.5}`, false},
+		{`{"currency": "USD", "decimals": 19}`, false},
+		{`{"currency": "USD", "tags": ["a", "b", "c"]}`, false},
+		{`{"currency": "USD", "tags": [1]}`, false},
+		{`{"currency": "USD", "issuer": "acme"}`, false},
+	}
+	for _, c := range cases {
+		var v interface{}
+		err := json.Unmarshal([]byte(c.def), &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.validate(v, "definition")
+		if (err == nil) != c.ok {
+			t.Errorf("validate(%s) = %v, want ok %v", c.def, err, c.ok)
+		}
+	}
+}
+
+func TestCompileSchemaErrors(t *testing.T) {
+	cases := []string{
+		`[]`,
+		`{"type": "decimal"}`,
+		`{"type": 1}`,
+		`{"pattern": "("}`,
+		`{"properties": {"a": {"oneOf": []}}}`,
+		`{"items": {"format": "date"}}`,
+	}
+	for _, c := range cases {
+		_, err := compileSchema([]byte(c))
+		if err == nil {
+			t.Errorf("compileSchema(%s) succeeded, want error", c)
+		}
+	}
+}
+
+func TestDefineWithSchema(t *testing.T) {
+	r := NewRegistry(pgtest.NewTx(t), prottest.NewChain(t))
+	ctx := context.Background()
+	keys := []string{testutil.TestXPub.String()}
+
+	_, err := r.RegisterSchema(ctx, []byte(`{"type": "strange"}`), "")
+	if errors.Root(err) != ErrBadSchema {
+		t.Errorf("RegisterSchema(bad) = %v, want %v", err, ErrBadSchema)
+	}
+	s, err := r.RegisterSchema(ctx, []byte(currencySchema), "currency")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	found, err := r.FindSchema(ctx, "", "currency")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if found.ID != s.ID {
+		t.Errorf("FindSchema(alias) = %s, want %s", found.ID, s.ID)
+	}
+
+	_, err = r.Define(ctx, keys, 1, map[string]interface{}{"name": "dollars"}, "", nil, nil, s.ID, nil)
+	if errors.Root(err) != ErrNonconforming {
+		t.Errorf("Define(nonconforming) = %v, want %v", err, ErrNonconforming)
+	}
+	a, err := r.Define(ctx, keys, 1, map[string]interface{}{"currency": "USD"}, "", nil, nil, s.ID, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	a, err = r.findByID(ctx, a.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if a.DefinitionSchemaID != s.ID {
+		t.Errorf("DefinitionSchemaID = %q, want %q", a.DefinitionSchemaID, s.ID)
+	}
+
+	// Updated definitions must conform too.
+	issue := &issueAction{
+		assets:      r,
+		AssetAmount: bc.AssetAmount{AssetID: a.AssetID, Amount: 1},
+		Definition:  map[string]interface{}{"currency": "dollars"},
+	}
+	_, err = issue.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrNonconforming {
+		t.Errorf("Build(nonconforming update) = %v, want %v", err, ErrNonconforming)
+	}
+	issue.Definition = map[string]interface{}{"currency": "EUR"}
+	_, err = issue.Build(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
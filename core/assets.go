@@ -2,10 +2,15 @@ package core
 
 import (
 	"context"
+	stdjson "encoding/json"
 	"sync"
 
+	"chain/core/asset"
 	"chain/core/signers"
 	"chain/encoding/json"
+	"chain/errors"
+	"chain/net/http/httpjson"
+	"chain/protocol/bc"
 )
 
 type (
@@ -19,6 +24,9 @@ type (
 		Definition      interface{} `json:"definition"`
 		Tags            interface{} `json:"tags"`
 		IsLocal         interface{} `json:"is_local"`
+		IssuancePolicy  interface{} `json:"issuance_policy,omitempty"`
+		SchemaID        interface{} `json:"definition_schema_id,omitempty"`
+		ArchivedAt      interface{} `json:"archived_at,omitempty"`
 	}
 	assetOrError struct {
 		*assetResponse
@@ -40,6 +48,15 @@ func (h *Handler) createAsset(ctx context.Context, ins []struct {
 	Definition map[string]interface{}
 	Tags       map[string]interface{}
 
+	// IssuancePolicy, if set, limits the amount this core
+	// will issue of the asset.
+	IssuancePolicy *asset.IssuancePolicy `json:"issuance_policy"`
+
+	// DefinitionSchemaID, or DefinitionSchemaAlias, names a
+	// registered schema that the definition must conform to.
+	DefinitionSchemaID    string `json:"definition_schema_id"`
+	DefinitionSchemaAlias string `json:"definition_schema_alias"`
+
 	// ClientToken is the application's unique token for the asset. Every asset
 	// should have a unique client token. The client token is used to ensure
 	// idempotency of create asset requests. Duplicate create asset requests
@@ -53,6 +70,17 @@ func (h *Handler) createAsset(ctx context.Context, ins []struct {
 	for i := 0; i < len(responses); i++ {
 		go func(i int) {
 			defer wg.Done()
+			schemaID := ins[i].DefinitionSchemaID
+			if schemaID == "" && ins[i].DefinitionSchemaAlias != "" {
+				s, err := h.Assets.FindSchema(ctx, "", ins[i].DefinitionSchemaAlias)
+				if err != nil {
+					logHTTPError(ctx, err)
+					res, _ := errInfo(ctx, err)
+					responses[i] = assetOrError{detailedError: &res}
+					return
+				}
+				schemaID = s.ID
+			}
 			asset, err := h.Assets.Define(
 				ctx,
 				ins[i].RootXPubs,
@@ -60,11 +88,13 @@ func (h *Handler) createAsset(ctx context.Context, ins []struct {
 				ins[i].Definition,
 				ins[i].Alias,
 				ins[i].Tags,
+				ins[i].IssuancePolicy,
+				schemaID,
 				ins[i].ClientToken,
 			)
 			if err != nil {
 				logHTTPError(ctx, err)
-				res, _ := errInfo(err)
+				res, _ := errInfo(ctx, err)
 				responses[i] = assetOrError{detailedError: &res}
 			} else {
 				var keys []assetKey
@@ -87,6 +117,12 @@ func (h *Handler) createAsset(ctx context.Context, ins []struct {
 					Tags:            asset.Tags,
 					IsLocal:         "yes",
 				}
+				if asset.IssuancePolicy != nil {
+					r.IssuancePolicy = asset.IssuancePolicy
+				}
+				if asset.DefinitionSchemaID != "" {
+					r.SchemaID = asset.DefinitionSchemaID
+				}
 				responses[i] = assetOrError{assetResponse: r}
 			}
 		}(i)
@@ -95,3 +131,110 @@ func (h *Handler) createAsset(ctx context.Context, ins []struct {
 	wg.Wait()
 	return responses, nil
 }
+
+// archiveAsset archives an asset, hiding it from
+// /list-assets unless include_archived is set.
+//
+// POST /archive-asset
+func (h *Handler) archiveAsset(ctx context.Context, in struct {
+	AssetID    bc.AssetID `json:"asset_id"`
+	AssetAlias string     `json:"asset_alias"`
+}) (interface{}, error) {
+	id, err := h.assetID(ctx, in.AssetID, in.AssetAlias)
+	if err != nil {
+		return nil, err
+	}
+	a, err := h.Assets.Archive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"asset_id": a.AssetID, "archived_at": a.ArchivedAt}, nil
+}
+
+// restoreAsset restores an archived asset, within
+// asset.RestoreWindow of archiving it.
+//
+// POST /restore-asset
+func (h *Handler) restoreAsset(ctx context.Context, in struct {
+	AssetID    bc.AssetID `json:"asset_id"`
+	AssetAlias string     `json:"asset_alias"`
+}) (interface{}, error) {
+	id, err := h.assetID(ctx, in.AssetID, in.AssetAlias)
+	if err != nil {
+		return nil, err
+	}
+	a, err := h.Assets.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"asset_id": a.AssetID, "archived_at": a.ArchivedAt}, nil
+}
+
+// listAssetDefinitions lists every version of an asset's
+// definition, oldest first, with the transaction that
+// introduced each.
+//
+// POST /list-asset-definitions
+func (h *Handler) listAssetDefinitions(ctx context.Context, in struct {
+	AssetID    bc.AssetID `json:"asset_id"`
+	AssetAlias string     `json:"asset_alias"`
+}) (interface{}, error) {
+	id, err := h.assetID(ctx, in.AssetID, in.AssetAlias)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := h.Assets.Definitions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"asset_id": id, "items": versions}, nil
+}
+
+// registerAssetDefinitionSchema registers a JSON Schema
+// that asset definitions can be required to conform to.
+//
+// POST /register-asset-definition-schema
+func (h *Handler) registerAssetDefinitionSchema(ctx context.Context, in struct {
+	Alias  string             `json:"alias"`
+	Schema stdjson.RawMessage `json:"schema"`
+}) (*asset.DefinitionSchema, error) {
+	if len(in.Schema) == 0 {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing schema")
+	}
+	return h.Assets.RegisterSchema(ctx, in.Schema, in.Alias)
+}
+
+// POST /list-asset-definition-schemas
+func (h *Handler) listAssetDefinitionSchemas(ctx context.Context, in QueryRequest) (Page, error) {
+	limit := defGenericPageSize
+
+	schemas, err := h.Assets.ListSchemas(ctx, in.After, limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	out := in
+	if len(schemas) > 0 {
+		out.After = schemas[len(schemas)-1].ID
+	}
+	return Page{
+		Items:    httpjson.Array(schemas),
+		LastPage: len(schemas) < limit,
+		Next:     out,
+	}, nil
+}
+
+// assetID returns id, or else the ID of the asset with alias.
+func (h *Handler) assetID(ctx context.Context, id bc.AssetID, alias string) (bc.AssetID, error) {
+	if id == (bc.AssetID{}) && alias != "" {
+		a, err := h.Assets.FindByAlias(ctx, alias)
+		if err != nil {
+			return id, errors.Wrapf(err, "invalid asset alias %s", alias)
+		}
+		id = a.AssetID
+	}
+	if id == (bc.AssetID{}) {
+		return id, errors.WithDetail(httpjson.ErrBadRequest, "missing asset_id or asset_alias")
+	}
+	return id, nil
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/auction"
+	"chain/core/query"
+	"chain/errors"
+	"chain/net/http/httpjson"
+)
+
+// getAuction returns an open auction, with its current price.
+// Auctions are not stored, so the request gives the auction's
+// parameters.
+//
+// POST /get-auction
+func (h *Handler) getAuction(ctx context.Context, in struct {
+	Auction auction.Auction `json:"auction"`
+}) (*auction.Listing, error) {
+	return h.auctions.Find(ctx, &in.Auction)
+}
+
+// listAuctions lists the open auctions whose lots match
+// the request's output filter, if any.
+//
+// POST /list-auctions
+func (h *Handler) listAuctions(ctx context.Context, in QueryRequest) (Page, error) {
+	var after *query.OutputsAfter
+	if in.After != "" {
+		var err error
+		after, err = query.DecodeOutputsAfter(in.After)
+		if err != nil {
+			return Page{}, errors.Wrap(err, "decoding `after`")
+		}
+	}
+	limit := defGenericPageSize
+	listings, next, err := h.auctions.List(ctx, in.Filter, in.FilterParams, after, limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	out := in
+	if next != nil {
+		out.After = next.String()
+	}
+	return Page{
+		Items:    httpjson.Array(listings),
+		LastPage: next == nil,
+		Next:     out,
+	}, nil
+}
@@ -0,0 +1,294 @@
+// Package auction implements descending-price (Dutch) auctions. A
+// seller locks a lot of one asset in a contract output, offered at a
+// price in another asset that falls over time from a start price to
+// a floor. Any buyer can take the lot by paying the current price to
+// the seller, and the seller can cancel the auction until then.
+package auction
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"chain/core/account"
+	"chain/core/query"
+	"chain/core/query/filter"
+	"chain/crypto/sha3pool"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+var (
+	ErrBadAuction = errors.New("invalid auction")
+	ErrNotFunded  = errors.New("auction has no lot")
+)
+
+// Clause selectors, the first witness argument to a contract.
+const (
+	clauseBuy    = 0
+	clauseCancel = 1
+)
+
+// contractName marks lots in their reference data.
+const contractName = "dutch_auction"
+
+// maxValue bounds prices and times, so that the
+// contract's price arithmetic cannot overflow.
+const maxValue = 1 << 62
+
+// Auction describes a Dutch auction. An auction is identified
+// by its parameters alone; it is not stored, except in the
+// reference data of its lot.
+type Auction struct {
+	// AssetID and Amount are the lot.
+	AssetID bc.AssetID `json:"asset_id"`
+	Amount  uint64     `json:"amount"`
+
+	// The price, in PaymentAssetID, is StartPrice until Start,
+	// a millisecond Unix timestamp. It then falls by Decrement
+	// at the end of every Interval milliseconds, until it
+	// reaches FloorPrice.
+	PaymentAssetID bc.AssetID `json:"payment_asset_id"`
+	StartPrice     uint64     `json:"start_price"`
+	FloorPrice     uint64     `json:"floor_price"`
+	Decrement      uint64     `json:"decrement"`
+	Interval       uint64     `json:"interval"`
+	Start          uint64     `json:"start"`
+
+	// Seller receives the payment, and can cancel the auction.
+	// It must be a multisig control program, such as one
+	// created for an account.
+	Seller chainjson.HexBytes `json:"seller_program"`
+}
+
+// Validate checks that a's parameters are usable.
+func (a *Auction) Validate() error {
+	if a.Amount == 0 || a.Amount > maxValue {
+		return errors.WithDetail(ErrBadAuction, "amount must be positive and at most 2^62")
+	}
+	if a.FloorPrice == 0 || a.FloorPrice > a.StartPrice || a.StartPrice > maxValue {
+		return errors.WithDetail(ErrBadAuction, "prices must be positive, at most 2^62, and the floor at most the start price")
+	}
+	if a.Decrement == 0 || a.Decrement > maxValue || (a.StartPrice > a.FloorPrice && a.Decrement > a.StartPrice-a.FloorPrice) {
+		return errors.WithDetail(ErrBadAuction, "decrement must be positive and at most the difference of the prices")
+	}
+	if a.Interval == 0 || a.Interval > maxValue || a.Start > maxValue {
+		return errors.WithDetail(ErrBadAuction, "interval must be positive, and interval and start at most 2^62")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(a.Seller); err != nil {
+		return errors.WithDetail(ErrBadAuction, "seller program must be a multisig program")
+	}
+	return nil
+}
+
+// ID returns the hash that identifies a.
+func (a *Auction) ID() bc.Hash {
+	var buf [48]byte
+	binary.LittleEndian.PutUint64(buf[:8], a.Amount)
+	binary.LittleEndian.PutUint64(buf[8:16], a.StartPrice)
+	binary.LittleEndian.PutUint64(buf[16:24], a.FloorPrice)
+	binary.LittleEndian.PutUint64(buf[24:32], a.Decrement)
+	binary.LittleEndian.PutUint64(buf[32:40], a.Interval)
+	binary.LittleEndian.PutUint64(buf[40:], a.Start)
+
+	var h bc.Hash
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(a.AssetID[:])
+	sha.Write(a.PaymentAssetID[:])
+	sha.Write(buf[:])
+	sha.Write(a.Seller)
+	sha.Read(h[:])
+	return h
+}
+
+// maxSteps is the number of price decrements
+// after which the price is at the floor.
+func (a *Auction) maxSteps() uint64 {
+	return (a.StartPrice-a.FloorPrice)/a.Decrement + 1
+}
+
+// Price returns the price of a at the millisecond
+// Unix timestamp t.
+func (a *Auction) Price(t uint64) uint64 {
+	var steps uint64
+	if t > a.Start {
+		steps = (t - a.Start) / a.Interval
+	}
+	if steps > a.maxSteps() {
+		steps = a.maxSteps()
+	}
+	drop := steps * a.Decrement
+	if drop >= a.StartPrice-a.FloorPrice {
+		return a.FloorPrice
+	}
+	return a.StartPrice - drop
+}
+
+// Program returns the control program for a's lot.
+//
+// Its witness arguments are a clause selector and, to cancel,
+// the arguments to the seller's program. A buy must pay the price
+// at the transaction's mintime to the seller, in the output at the
+// input's index. The buyer takes the lot in any other output. A
+// cancel may spend the lot freely.
+func (a *Auction) Program() []byte {
+	b := vmutil.NewAssembler()
+	b.AddOp(vm.OP_DEPTH).AddInt64(1).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the selector
+	b.Jump(vm.OP_JUMPIF, "cancel")
+
+	// buy
+	b.AddOp(vm.OP_MINTIME).AddInt64(int64(a.Start)).AddOp(vm.OP_SUB).AddInt64(0).AddOp(vm.OP_MAX)
+	b.AddInt64(int64(a.Interval)).AddOp(vm.OP_DIV)
+	b.AddInt64(int64(a.maxSteps())).AddOp(vm.OP_MIN).AddInt64(int64(a.Decrement)).AddOp(vm.OP_MUL)
+	b.AddInt64(int64(a.StartPrice)).AddOp(vm.OP_SWAP).AddOp(vm.OP_SUB).AddInt64(int64(a.FloorPrice)).AddOp(vm.OP_MAX)
+	b.AddOp(vm.OP_INDEX).AddData(nil).AddOp(vm.OP_ROT)
+	b.AddData(a.PaymentAssetID[:]).AddInt64(1).AddData(a.Seller).AddOp(vm.OP_CHECKOUTPUT)
+	b.Jump(vm.OP_JUMP, "end")
+
+	b.Label("cancel")
+	b.AddRawBytes(a.Seller)
+	b.Label("end")
+	return b.Build()
+}
+
+// Manager builds transactions for auctions and finds them.
+type Manager struct {
+	chain    *protocol.Chain
+	accounts *account.Manager
+	indexer  *query.Indexer
+}
+
+func NewManager(chain *protocol.Chain, accounts *account.Manager, indexer *query.Indexer) *Manager {
+	return &Manager{chain: chain, accounts: accounts, indexer: indexer}
+}
+
+// Listing is an open auction: its lot is in an unspent
+// contract output.
+type Listing struct {
+	ID            bc.Hash  `json:"id"`
+	Auction       *Auction `json:"auction"`
+	CurrentPrice  uint64   `json:"current_price"`
+	TransactionID bc.Hash  `json:"transaction_id"`
+	Position      uint32   `json:"position"`
+
+	// pricedAt is the time, in milliseconds, of CurrentPrice.
+	pricedAt uint64
+}
+
+// Find returns the listing of a, or ErrNotFunded
+// if its lot is not in a confirmed, unspent output.
+func (m *Manager) Find(ctx context.Context, a *Auction) (*Listing, error) {
+	err := a.Validate()
+	if err != nil {
+		return nil, err
+	}
+	vals := []interface{}{a.ID().String()}
+	var after *query.OutputsAfter
+	for {
+		listings, next, err := m.List(ctx, "reference_data.auction_id=$1", vals, after, 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range listings {
+			if l.ID == a.ID() {
+				return l, nil
+			}
+		}
+		if next == nil {
+			return nil, errors.WithDetailf(ErrNotFunded, "auction %s", a.ID())
+		}
+		after = next
+	}
+}
+
+// List returns the open auctions whose lots match the output
+// filter filt, if any, with parameters vals, at most limit of them,
+// and the cursor for the next page, which is nil on the last page.
+// Outputs that only claim to be lots, by their reference data,
+// but do not match their auction's program, asset, or amount
+// are skipped.
+func (m *Manager) List(ctx context.Context, filt string, vals []interface{}, after *query.OutputsAfter, limit int) ([]*Listing, *query.OutputsAfter, error) {
+	cond := fmt.Sprintf("reference_data.contract=$%d", len(vals)+1)
+	if filt != "" {
+		cond = fmt.Sprintf("(%s) AND %s", filt, cond)
+	}
+	p, err := filter.Parse(cond)
+	if err != nil {
+		return nil, nil, err
+	}
+	vals = append(vals[:len(vals):len(vals)], contractName)
+
+	now := m.priceTime()
+	outs, next, err := m.indexer.Outputs(ctx, p, vals, now, after, limit)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "querying auction lots")
+	}
+	listings := []*Listing{}
+	for _, o := range outs {
+		raw, ok := o.(*json.RawMessage)
+		if !ok || raw == nil {
+			return nil, nil, errors.Wrap(fmt.Errorf("unexpected %T in Indexer.Outputs output", o))
+		}
+		var out struct {
+			TransactionID  bc.Hash            `json:"transaction_id"`
+			Position       uint32             `json:"position"`
+			AssetID        bc.AssetID         `json:"asset_id"`
+			Amount         uint64             `json:"amount"`
+			ControlProgram chainjson.HexBytes `json:"control_program"`
+			ReferenceData  struct {
+				Auction *Auction `json:"auction"`
+			} `json:"reference_data"`
+		}
+		err = json.Unmarshal(*raw, &out)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "decoding auction lot")
+		}
+		a := out.ReferenceData.Auction
+		if a == nil || a.Validate() != nil || out.AssetID != a.AssetID || out.Amount != a.Amount || !bytes.Equal(out.ControlProgram, a.Program()) {
+			continue
+		}
+		listings = append(listings, &Listing{
+			ID:            a.ID(),
+			Auction:       a,
+			CurrentPrice:  a.Price(now),
+			TransactionID: out.TransactionID,
+			Position:      out.Position,
+			pricedAt:      now,
+		})
+	}
+	if len(outs) < limit {
+		next = nil
+	}
+	return listings, next, nil
+}
+
+// priceTime returns the time, in milliseconds, at which auctions
+// are priced: the core's clock, or the latest block's timestamp if
+// that is later. A buy sets it as its transaction's mintime, so
+// every later block accepts the purchase at the quoted price.
+func (m *Manager) priceTime() uint64 {
+	now := bc.Millis(time.Now())
+	if b, _ := m.chain.State(); b != nil && b.TimestampMS > now {
+		now = b.TimestampMS
+	}
+	return now
+}
+
+// refData is the reference data of a's lot, which lets
+// buyers find the auction without being told its terms.
+func refData(a *Auction) ([]byte, error) {
+	b, err := json.Marshal(map[string]interface{}{
+		"contract":   contractName,
+		"auction_id": a.ID().String(),
+		"auction":    a,
+	})
+	return b, errors.Wrap(err)
+}
@@ -0,0 +1,192 @@
+package auction
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"chain/core/contracttest"
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+func TestPrice(t *testing.T) {
+	a := &Auction{StartPrice: 100, FloorPrice: 35, Decrement: 10, Interval: 1000, Start: 5000}
+	cases := []struct {
+		t    uint64
+		want uint64
+	}{
+		{0, 100},
+		{5000, 100},
+		{5999, 100},
+		{6000, 90},
+		{10999, 50},
+		{11000, 40},
+		{12000, 35},
+		{1 << 62, 35},
+	}
+	for _, c := range cases {
+		if got := a.Price(c.t); got != c.want {
+			t.Errorf("Price(%d) = %d, want %d", c.t, got, c.want)
+		}
+	}
+}
+
+func TestProgram(t *testing.T) {
+	var (
+		progs [2][]byte
+		privs [2]ed25519.PrivateKey
+	)
+	for i := range progs {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		progs[i], err = vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pub}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+	}
+	seller, other := privs[0], privs[1]
+
+	a := &Auction{
+		AssetID:        bc.AssetID{1},
+		Amount:         5,
+		PaymentAssetID: bc.AssetID{2},
+		StartPrice:     100,
+		FloorPrice:     35,
+		Decrement:      10,
+		Interval:       1000,
+		Start:          5000,
+		Seller:         progs[0],
+	}
+	err := a.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		clause   int64
+		minTime  uint64
+		amount   uint64
+		assetID  bc.AssetID
+		payee    []byte
+		signer   ed25519.PrivateKey
+		wantPass bool
+	}{
+		{"buy before start", clauseBuy, 1000, 100, a.PaymentAssetID, a.Seller, nil, true},
+		{"buy after a step", clauseBuy, 6500, 90, a.PaymentAssetID, a.Seller, nil, true},
+		{"buy at floor", clauseBuy, 20000, 35, a.PaymentAssetID, a.Seller, nil, true},
+		{"buy with no mintime", clauseBuy, 0, 100, a.PaymentAssetID, a.Seller, nil, true},
+		{"underpay", clauseBuy, 6500, 80, a.PaymentAssetID, a.Seller, nil, false},
+		{"overpay", clauseBuy, 6500, 100, a.PaymentAssetID, a.Seller, nil, false},
+		{"pay in lot asset", clauseBuy, 6500, 90, a.AssetID, a.Seller, nil, false},
+		{"pay someone else", clauseBuy, 6500, 90, a.PaymentAssetID, progs[1], nil, false},
+		{"cancel by seller", clauseCancel, 6500, 5, a.AssetID, a.Seller, seller, true},
+		{"cancel by other", clauseCancel, 6500, 5, a.AssetID, progs[1], other, false},
+	}
+	for _, tc := range cases {
+		tx := &bc.TxData{
+			Version: 1,
+			MinTime: tc.minTime,
+			MaxTime: 1 << 40,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{3}, 0, nil, a.AssetID, a.Amount, a.Program(), nil),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(tc.assetID, tc.amount, tc.payee, nil),
+			},
+		}
+		if tc.signer != nil {
+			contracttest.Sign(tx, tc.signer, contracttest.Ints(tc.clause))
+		} else {
+			tx.Inputs[0].SetArguments([][]byte{vm.Int64Bytes(tc.clause)})
+		}
+
+		ok, err := vm.VerifyTxInput(bc.NewTx(*tx), 0)
+		if ok != tc.wantPass {
+			t.Errorf("%s: got ok=%t (err %v), want %t", tc.name, ok, err, tc.wantPass)
+		}
+	}
+}
+
+func TestBuildAndFind(t *testing.T) {
+	ctx := context.Background()
+	core := contracttest.NewCore(t)
+	m := NewManager(core.Chain, core.Accounts, core.Indexer)
+
+	seller, lotAsset := core.Fund(ctx, t, 10)
+	buyer, payAsset := core.Fund(ctx, t, 100)
+	interval := uint64(time.Hour / time.Millisecond)
+	a := &Auction{
+		AssetID:        lotAsset,
+		Amount:         5,
+		PaymentAssetID: payAsset,
+		StartPrice:     100,
+		FloorPrice:     40,
+		Decrement:      10,
+		Interval:       interval,
+		Start:          bc.Millis(time.Now()) - 2*interval - interval/2,
+		Seller:         core.Program(ctx, t, seller),
+	}
+	offer := func(a *Auction) {
+		core.Submit(ctx, t, contracttest.Action(t, m.DecodeOfferAction, map[string]interface{}{
+			"auction":    a,
+			"account_id": seller,
+		}))
+	}
+	checkClosed := func(a *Auction) {
+		_, err := m.Find(ctx, a)
+		if errors.Root(err) != ErrNotFunded {
+			t.Fatalf("Find: got error %v, want %v", err, ErrNotFunded)
+		}
+	}
+
+	checkClosed(a)
+	offer(a)
+	l, err := m.Find(ctx, a)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if l.ID != a.ID() || l.CurrentPrice != 80 {
+		t.Errorf("listing %s at %d, want %s at 80", l.ID, l.CurrentPrice, a.ID())
+	}
+	listings, _, err := m.List(ctx, "", nil, nil, 10)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(listings) != 1 || listings[0].ID != a.ID() {
+		t.Errorf("List = %+v, want the listing of %s", listings, a.ID())
+	}
+
+	// The buy pays the quoted price to the seller in the
+	// output at the lot's index, and takes the lot.
+	tx := core.Submit(ctx, t, contracttest.Action(t, m.DecodeBuyAction, map[string]interface{}{
+		"auction":    a,
+		"account_id": buyer,
+	}))
+	if out := tx.Outputs[0]; out.AssetID != payAsset || out.Amount != 80 || !bytes.Equal(out.ControlProgram, a.Seller) {
+		t.Errorf("buy pays %d of %s to %x, want 80 of %s to the seller", out.Amount, out.AssetID, out.ControlProgram, payAsset)
+	}
+	last := tx.Outputs[len(tx.Outputs)-1]
+	if last.AssetID != lotAsset || last.Amount != 5 || bytes.Equal(last.ControlProgram, a.Seller) {
+		t.Errorf("buy takes %d of %s to %x, want the lot for the buyer", last.Amount, last.AssetID, last.ControlProgram)
+	}
+	checkClosed(a)
+
+	cancelled := *a
+	cancelled.StartPrice = 90
+	offer(&cancelled)
+	tx = core.Submit(ctx, t, contracttest.Action(t, m.DecodeCancelAction, map[string]interface{}{"auction": &cancelled}))
+	if len(tx.Outputs) != 1 || tx.Outputs[0].Amount != 5 || !bytes.Equal(tx.Outputs[0].ControlProgram, a.Seller) {
+		t.Errorf("cancel outputs = %+v, want the lot back to the seller", tx.Outputs)
+	}
+	checkClosed(&cancelled)
+}
@@ -0,0 +1,139 @@
+package auction
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func (m *Manager) DecodeOfferAction(data []byte) (txbuilder.Action, error) {
+	a := &offerAction{auctions: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// offerAction spends Auction's lot from an account
+// into a contract output, opening the auction.
+type offerAction struct {
+	auctions  *Manager
+	Auction   Auction `json:"auction"`
+	AccountID string  `json:"account_id"`
+}
+
+func (a *offerAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	au := &a.Auction
+	err := au.Validate()
+	if err != nil {
+		return nil, err
+	}
+	amt := bc.AssetAmount{AssetID: au.AssetID, Amount: au.Amount}
+	res, err := a.auctions.accounts.NewSpendAction(amt, a.AccountID, nil, nil, nil, nil).Build(ctx, maxTime)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refData(au)
+	if err != nil {
+		return nil, err
+	}
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(au.AssetID, au.Amount, au.Program(), ref))
+	return res, nil
+}
+
+func (m *Manager) DecodeBuyAction(data []byte) (txbuilder.Action, error) {
+	a := &buyAction{auctions: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// buyAction takes Auction's lot into an account, paying the
+// current price to the seller from the same account. The price
+// is fixed by the transaction's mintime, which is the time the
+// auction was priced at when building, so the buyer pays the
+// price that List quotes. It must be the first action of its
+// transaction.
+type buyAction struct {
+	auctions  *Manager
+	Auction   Auction `json:"auction"`
+	AccountID string  `json:"account_id"`
+}
+
+func (a *buyAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	au := &a.Auction
+	l, err := a.auctions.Find(ctx, au)
+	if err != nil {
+		return nil, err
+	}
+	now, price := l.pricedAt, l.CurrentPrice
+
+	// The contract needs only its clause selector; the buyer's
+	// signatures on the payment commit to the whole transaction.
+	sigInst := &txbuilder.SigningInstruction{AssetAmount: bc.AssetAmount{AssetID: au.AssetID, Amount: au.Amount}}
+	sigInst.AddDataWitness(vm.Int64Bytes(clauseBuy))
+	res := &txbuilder.BuildResult{
+		Inputs:              []*bc.TxInput{bc.NewSpendInput(l.TransactionID, l.Position, nil, au.AssetID, au.Amount, au.Program(), nil)},
+		Outputs:             []*bc.TxOutput{bc.NewTxOutput(au.PaymentAssetID, price, au.Seller, nil)},
+		SigningInstructions: []*txbuilder.SigningInstruction{sigInst},
+		MinTimeMS:           now,
+	}
+
+	amt := bc.AssetAmount{AssetID: au.PaymentAssetID, Amount: price}
+	payment, err := a.auctions.accounts.NewSpendAction(amt, a.AccountID, nil, nil, nil, nil).Build(ctx, maxTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "building payment")
+	}
+	res.Inputs = append(res.Inputs, payment.Inputs...)
+	res.Outputs = append(res.Outputs, payment.Outputs...)
+	res.SigningInstructions = append(res.SigningInstructions, payment.SigningInstructions...)
+
+	acp, err := a.auctions.accounts.CreateControlProgram(ctx, a.AccountID, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating control program for the lot")
+	}
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(au.AssetID, au.Amount, acp, nil))
+	return res, nil
+}
+
+func (m *Manager) DecodeCancelAction(data []byte) (txbuilder.Action, error) {
+	a := &cancelAction{auctions: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// cancelAction returns Auction's lot to the seller, closing
+// the auction. The seller program must belong to an account
+// in this core.
+type cancelAction struct {
+	auctions *Manager
+	Auction  Auction `json:"auction"`
+}
+
+func (a *cancelAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	au := &a.Auction
+	l, err := a.auctions.Find(ctx, au)
+	if err != nil {
+		return nil, err
+	}
+	amt := bc.AssetAmount{AssetID: au.AssetID, Amount: au.Amount}
+	sigInst, err := a.auctions.accounts.SigningInstruction(ctx, au.Seller, amt)
+	if err != nil {
+		return nil, errors.WithDetail(err, "seller program is not controlled by this core")
+	}
+
+	// The contract finds its selector at the bottom of
+	// the stack, below the arguments of the seller's program.
+	sig := sigInst.WitnessComponents
+	sigInst.WitnessComponents = nil
+	sigInst.AddDataWitness(vm.Int64Bytes(clauseCancel))
+	sigInst.WitnessComponents = append(sigInst.WitnessComponents, sig...)
+
+	return &txbuilder.BuildResult{
+		Inputs:              []*bc.TxInput{bc.NewSpendInput(l.TransactionID, l.Position, nil, au.AssetID, au.Amount, au.Program(), nil)},
+		Outputs:             []*bc.TxOutput{bc.NewTxOutput(au.AssetID, au.Amount, au.Seller, nil)},
+		SigningInstructions: []*txbuilder.SigningInstruction{sigInst},
+	}, nil
+}
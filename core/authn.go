@@ -28,24 +28,30 @@ type apiAuthn struct {
 
 type tokenResult struct {
 	valid      bool
+	accountID  string
 	lastLookup time.Time
 }
 
 func (a *apiAuthn) handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-		err := a.auth(req)
+		accountID, err := a.auth(req)
 		if err != nil {
 			WriteHTTPError(req.Context(), rw, err)
 			return
 		}
+		if accountID != "" {
+			req = req.WithContext(withAccountScope(req.Context(), accountID))
+		}
 		next.ServeHTTP(rw, req)
 	})
 }
 
-func (a *apiAuthn) auth(req *http.Request) error {
+// auth authenticates req. If the request's token is
+// limited to an account, it returns the account ID.
+func (a *apiAuthn) auth(req *http.Request) (string, error) {
 	user, pw, ok := req.BasicAuth()
 	if !ok && a.alt(req) {
-		return nil
+		return "", nil
 	}
 
 	typ := "client"
@@ -55,30 +61,30 @@ func (a *apiAuthn) auth(req *http.Request) error {
 	return a.cachedAuthCheck(req.Context(), typ, user, pw)
 }
 
-func (a *apiAuthn) authCheck(ctx context.Context, typ, user, pw string) (bool, error) {
+func (a *apiAuthn) authCheck(ctx context.Context, typ, user, pw string) (bool, string, error) {
 	pwBytes, err := hex.DecodeString(pw)
 	if err != nil {
-		return false, nil
+		return false, "", nil
 	}
-	return a.tokens.Check(ctx, user, typ, pwBytes)
+	return a.tokens.Lookup(ctx, user, typ, pwBytes)
 }
 
-func (a *apiAuthn) cachedAuthCheck(ctx context.Context, typ, user, pw string) error {
+func (a *apiAuthn) cachedAuthCheck(ctx context.Context, typ, user, pw string) (string, error) {
 	a.tokenMu.Lock()
 	res, ok := a.tokenMap[typ+user+pw]
 	a.tokenMu.Unlock()
 	if !ok || time.Now().After(res.lastLookup.Add(tokenExpiry)) {
-		valid, err := a.authCheck(ctx, typ, user, pw)
+		valid, accountID, err := a.authCheck(ctx, typ, user, pw)
 		if err != nil {
-			return errors.Wrap(err)
+			return "", errors.Wrap(err)
 		}
-		res = tokenResult{valid: valid, lastLookup: time.Now()}
+		res = tokenResult{valid: valid, accountID: accountID, lastLookup: time.Now()}
 		a.tokenMu.Lock()
 		a.tokenMap[typ+user+pw] = res
 		a.tokenMu.Unlock()
 	}
 	if !res.valid {
-		return errNotAuthenticated
+		return "", errNotAuthenticated
 	}
-	return nil
+	return res.accountID, nil
 }
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+
+	"chain/core/blocklist"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+)
+
+// updateBlocklist applies an update to the blocklist signed by a
+// quorum of admin keys. Signatures are over the SHA3-256 hash of
+// the exact bytes of the update as sent. The blocklist is enforced
+// by the generator, so updates must be sent to it.
+//
+// POST /update-blocklist
+func (h *Handler) updateBlocklist(ctx context.Context, in struct {
+	Update     json.RawMessage      `json:"update"`
+	Signatures []chainjson.HexBytes `json:"signatures"`
+}) (interface{}, error) {
+	if !h.Config.IsGenerator {
+		return nil, errors.WithDetail(blocklist.ErrBadUpdate, "blocklist updates must be sent to the generator")
+	}
+	version, err := h.Blocklist.Apply(ctx, in.Update, in.Signatures)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"version": version}, nil
+}
+
+// getBlocklist returns the blocklist's version and entries.
+//
+// POST /get-blocklist
+func (h *Handler) getBlocklist(ctx context.Context) (interface{}, error) {
+	version, entries, err := h.Blocklist.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"version": version, "entries": entries}, nil
+}
@@ -0,0 +1,306 @@
+// Package blocklist maintains a list of control programs and
+// outpoints that a generator refuses to include spends of in
+// blocks, as a permissioned network may be ordered to by a court.
+//
+// The list is changed only by updates signed by a quorum of
+// admin keys.
+package blocklist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"chain/crypto/ed25519"
+	"chain/crypto/sha3pool"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// refreshPeriod is how long Check uses the list it loaded
+// before checking the database for updates.
+const refreshPeriod = time.Second
+
+var (
+	ErrBlocked   = errors.New("transaction spends a blocked output")
+	ErrBadUpdate = errors.New("invalid blocklist update")
+)
+
+// Entry blocks spends of outputs with ControlProgram,
+// or of the output at Outpoint. Exactly one is set.
+type Entry struct {
+	ID             string             `json:"id"`
+	ControlProgram chainjson.HexBytes `json:"control_program,omitempty"`
+	Outpoint       *bc.Outpoint       `json:"outpoint,omitempty"`
+	Reason         string             `json:"reason"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// Update adds and removes entries. It takes effect only if
+// Version is one more than the list's current version, so
+// that a signed update cannot be applied twice.
+type Update struct {
+	Version uint64   `json:"version"`
+	Add     []*Entry `json:"add"`
+	Remove  []string `json:"remove"`
+}
+
+// List is the blocklist.
+type List struct {
+	db        pg.DB
+	adminKeys []ed25519.PublicKey
+	quorum    int
+
+	mu        sync.Mutex
+	loadedAt  time.Time
+	version   uint64
+	programs  map[string]*Entry
+	outpoints map[bc.Outpoint]*Entry
+}
+
+// New returns the blocklist stored in db. Updates must be
+// signed by quorum of adminKeys. With no admin keys, the
+// list cannot be updated.
+func New(db pg.DB, adminKeys []ed25519.PublicKey, quorum int) *List {
+	return &List{db: db, adminKeys: adminKeys, quorum: quorum}
+}
+
+// Refresh brings the list up to date with the database.
+// Together with Check, it makes l a protocol.TxFilter.
+func (l *List) Refresh(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.refresh(ctx)
+}
+
+// Check returns ErrBlocked if tx spends an output blocked
+// by the list, as of the latest Refresh.
+func (l *List) Check(tx *bc.Tx) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, in := range tx.Inputs {
+		if in.IsIssuance() {
+			continue
+		}
+		if e, ok := l.outpoints[in.Outpoint()]; ok {
+			return errors.WithDetailf(ErrBlocked, "input %d spends a blocked outpoint (entry %s): %s", i, e.ID, e.Reason)
+		}
+		if e, ok := l.programs[string(in.ControlProgram())]; ok {
+			return errors.WithDetailf(ErrBlocked, "input %d spends a blocked control program (entry %s): %s", i, e.ID, e.Reason)
+		}
+	}
+	return nil
+}
+
+// refresh reloads the list if it was last loaded more than
+// refreshPeriod ago and has changed since. Updates can be
+// applied by any process of the core, so the list is read
+// from the database. l.mu must be held.
+func (l *List) refresh(ctx context.Context) error {
+	if time.Since(l.loadedAt) < refreshPeriod {
+		return nil
+	}
+	var version uint64
+	err := l.db.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM blocklist_version`).Scan(&version)
+	if err != nil {
+		return errors.Wrap(err, "looking up blocklist version")
+	}
+	if version == l.version && l.programs != nil {
+		l.loadedAt = time.Now()
+		return nil
+	}
+
+	entries, err := l.entries(ctx)
+	if err != nil {
+		return err
+	}
+	l.programs = make(map[string]*Entry)
+	l.outpoints = make(map[bc.Outpoint]*Entry)
+	for _, e := range entries {
+		if e.Outpoint != nil {
+			l.outpoints[*e.Outpoint] = e
+		} else {
+			l.programs[string(e.ControlProgram)] = e
+		}
+	}
+	l.version = version
+	l.loadedAt = time.Now()
+	return nil
+}
+
+// Entries returns the current version of the
+// list and its entries, oldest first.
+func (l *List) Entries(ctx context.Context) (uint64, []*Entry, error) {
+	var version uint64
+	err := l.db.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM blocklist_version`).Scan(&version)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "looking up blocklist version")
+	}
+	entries, err := l.entries(ctx)
+	return version, entries, err
+}
+
+func (l *List) entries(ctx context.Context) ([]*Entry, error) {
+	const q = `
+		SELECT id, control_program, COALESCE(tx_hash, ''), COALESCE(output_index, 0), reason, created_at
+		FROM blocklist ORDER BY created_at, id
+	`
+	rows, err := l.db.Query(ctx, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying blocklist")
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+	for rows.Next() {
+		var (
+			e      Entry
+			prog   []byte
+			txHash string
+			index  uint32
+		)
+		err = rows.Scan(&e.ID, &prog, &txHash, &index, &e.Reason, &e.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning blocklist entry")
+		}
+		if txHash != "" {
+			e.Outpoint = &bc.Outpoint{Index: index}
+			err = e.Outpoint.Hash.UnmarshalText([]byte(txHash))
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding blocked outpoint")
+			}
+		} else {
+			e.ControlProgram = prog
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		entries = append(entries, &e)
+	}
+	return entries, errors.Wrap(rows.Err())
+}
+
+// Apply applies update, the JSON encoding of an Update, if
+// sigs has signatures by a quorum of distinct admin keys over
+// the SHA3-256 hash of exactly those bytes. It returns the
+// list's new version.
+func (l *List) Apply(ctx context.Context, update []byte, sigs []chainjson.HexBytes) (uint64, error) {
+	if len(l.adminKeys) == 0 {
+		return 0, errors.WithDetail(ErrBadUpdate, "no blocklist admin keys are configured")
+	}
+	if n := l.signers(update, sigs); n < l.quorum {
+		return 0, errors.WithDetailf(ErrBadUpdate, "signed by %d admin keys, need %d", n, l.quorum)
+	}
+
+	var u Update
+	err := json.Unmarshal(update, &u)
+	if err != nil {
+		return 0, errors.WithDetail(ErrBadUpdate, err.Error())
+	}
+
+	var (
+		progs   pq.ByteaArray
+		hashes  pq.StringArray
+		indexes pg.Uint32s
+		reasons pq.StringArray
+	)
+	for i, e := range u.Add {
+		if (len(e.ControlProgram) == 0) == (e.Outpoint == nil) {
+			return 0, errors.WithDetailf(ErrBadUpdate, "entry %d: provide exactly one of control_program or outpoint", i)
+		}
+		var (
+			hash  string
+			index uint32
+		)
+		if e.Outpoint != nil {
+			hash, index = e.Outpoint.Hash.String(), e.Outpoint.Index
+		}
+		progs = append(progs, e.ControlProgram)
+		hashes = append(hashes, hash)
+		indexes = append(indexes, index)
+		reasons = append(reasons, e.Reason)
+	}
+
+	// Bump the version, and apply the changes only if that
+	// succeeded, in a single statement so that concurrent
+	// updates with the same version cannot both apply.
+	const q = `
+		WITH bump AS (
+			INSERT INTO blocklist_version (version)
+			SELECT $1 WHERE $1 = 1 OR EXISTS (SELECT 1 FROM blocklist_version)
+			ON CONFLICT (singleton) DO UPDATE SET version = excluded.version
+				WHERE blocklist_version.version = excluded.version - 1
+			RETURNING version
+		), removed AS (
+			DELETE FROM blocklist
+			WHERE id IN (SELECT unnest($2::text[])) AND EXISTS (SELECT 1 FROM bump)
+		), added AS (
+			INSERT INTO blocklist (control_program, tx_hash, output_index, reason)
+			SELECT NULLIF(prog, ''::bytea), NULLIF(hash, ''), CASE WHEN hash = '' THEN NULL ELSE idx END, reason
+			FROM (
+				SELECT unnest($3::bytea[]) AS prog, unnest($4::text[]) AS hash,
+					unnest($5::integer[]) AS idx, unnest($6::text[]) AS reason
+			) AS x
+			WHERE EXISTS (SELECT 1 FROM bump)
+		)
+		SELECT COUNT(*) FROM bump
+	`
+	var bumped int
+	err = l.db.QueryRow(ctx, q, u.Version, pq.StringArray(u.Remove), progs, hashes, indexes, reasons).Scan(&bumped)
+	if err != nil {
+		return 0, errors.Wrap(err, "applying blocklist update")
+	}
+	if bumped == 0 {
+		return 0, errors.WithDetailf(ErrBadUpdate, "version %d is not the next blocklist version", u.Version)
+	}
+
+	l.mu.Lock()
+	l.loadedAt = time.Time{} // reload on the next check
+	l.mu.Unlock()
+	return u.Version, nil
+}
+
+// signers counts the distinct admin keys with a
+// signature in sigs over the hash of msg.
+func (l *List) signers(msg []byte, sigs []chainjson.HexBytes) int {
+	var hash [32]byte
+	sha3pool.Sum256(hash[:], msg)
+
+	used := make([]bool, len(l.adminKeys))
+	var n int
+	for _, sig := range sigs {
+		for i, key := range l.adminKeys {
+			if !used[i] && ed25519.Verify(key, hash[:], sig) {
+				used[i] = true
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// ParseAdminKeys parses hex-encoded ed25519 public keys.
+func ParseAdminKeys(items []string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, item := range items {
+		var key chainjson.HexBytes
+		err := key.UnmarshalText([]byte(item))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid blocklist admin key %q", item)
+		}
+		for _, k := range keys {
+			if bytes.Equal(k, key) {
+				return nil, fmt.Errorf("duplicate blocklist admin key %q", item)
+			}
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	return keys, nil
+}
@@ -0,0 +1,122 @@
+package blocklist
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"chain/crypto/ed25519"
+	"chain/crypto/sha3pool"
+	"chain/database/pg/pgtest"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+func TestApplyAndCheck(t *testing.T) {
+	ctx := context.Background()
+	var (
+		pubs  []ed25519.PublicKey
+		privs []ed25519.PrivateKey
+	)
+	for i := 0; i < 3; i++ {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubs, privs = append(pubs, pub), append(privs, priv)
+	}
+	l := New(pgtest.NewTx(t), pubs, 2)
+
+	sign := func(update string, keys ...ed25519.PrivateKey) []chainjson.HexBytes {
+		var hash [32]byte
+		sha3pool.Sum256(hash[:], []byte(update))
+		var sigs []chainjson.HexBytes
+		for _, k := range keys {
+			sigs = append(sigs, ed25519.Sign(k, hash[:]))
+		}
+		return sigs
+	}
+
+	blockedTx := bc.Hash{1}
+	update := fmt.Sprintf(`{"version":1,"add":[
+		{"control_program":"51","reason":"order 1"},
+		{"outpoint":{"hash":"%s","index":2},"reason":"order 2"}
+	]}`, blockedTx)
+
+	// One signature, or the same key twice, is below the quorum.
+	for _, sigs := range [][]chainjson.HexBytes{
+		sign(update, privs[0]),
+		sign(update, privs[0], privs[0]),
+	} {
+		_, err := l.Apply(ctx, []byte(update), sigs)
+		if errors.Root(err) != ErrBadUpdate {
+			t.Errorf("Apply(%d sigs) = %v, want %v", len(sigs), err, ErrBadUpdate)
+		}
+	}
+
+	version, err := l.Apply(ctx, []byte(update), sign(update, privs[0], privs[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+	// The same update cannot be applied twice.
+	_, err = l.Apply(ctx, []byte(update), sign(update, privs[0], privs[2]))
+	if errors.Root(err) != ErrBadUpdate {
+		t.Errorf("replayed Apply = %v, want %v", err, ErrBadUpdate)
+	}
+
+	spend := func(prog []byte, out bc.Outpoint) *bc.Tx {
+		return bc.NewTx(bc.TxData{Inputs: []*bc.TxInput{
+			bc.NewSpendInput(out.Hash, out.Index, nil, bc.AssetID{}, 1, prog, nil),
+		}})
+	}
+	cases := []struct {
+		tx   *bc.Tx
+		want error
+	}{
+		{spend([]byte{0x51}, bc.Outpoint{Hash: bc.Hash{2}}), ErrBlocked},
+		{spend([]byte{0x52}, bc.Outpoint{Hash: blockedTx, Index: 2}), ErrBlocked},
+		{spend([]byte{0x52}, bc.Outpoint{Hash: blockedTx, Index: 1}), nil},
+	}
+	err = l.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range cases {
+		err := l.Check(c.tx)
+		if errors.Root(err) != c.want {
+			t.Errorf("case %d: Check() = %v, want %v", i, err, c.want)
+		}
+	}
+
+	_, entries, err := l.Entries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	var progEntry string
+	for _, e := range entries {
+		if e.Outpoint == nil {
+			progEntry = e.ID
+		}
+	}
+	update = fmt.Sprintf(`{"version":2,"remove":["%s"]}`, progEntry)
+	_, err = l.Apply(ctx, []byte(update), sign(update, privs[1], privs[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.Check(cases[0].tx)
+	if err != nil {
+		t.Errorf("Check() after removal = %v, want nil", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"time"
 
 	"chain/core/mockhsm"
 	"chain/crypto/ed25519"
@@ -63,7 +64,7 @@ func (s *Signer) String() string {
 // is used as the httpjson handler for /rpc/signer/sign-block.
 //
 // This function fails if this node has ever signed a different block at the
-// same height as b.
+// same height as b, or if b's timestamp is too far ahead of this node's clock.
 func (s *Signer) ValidateAndSignBlock(ctx context.Context, b *bc.Block) ([]byte, error) {
 	err := s.c.WaitForBlockSoon(ctx, b.Height-1)
 	if err != nil {
@@ -82,6 +83,10 @@ func (s *Signer) ValidateAndSignBlock(ctx context.Context, b *bc.Block) ([]byte,
 	if !bytes.Equal(b.ConsensusProgram, prev.ConsensusProgram) {
 		return nil, errors.Wrap(ErrConsensusChange)
 	}
+	err = protocol.CheckBlockTime(b, time.Now())
+	if err != nil {
+		return nil, err
+	}
 	err = s.c.ValidateBlockForSig(ctx, b)
 	if err != nil {
 		return nil, errors.Wrap(err, "validating block for signature")
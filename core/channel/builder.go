@@ -0,0 +1,267 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func (m *Manager) DecodeFundAction(data []byte) (txbuilder.Action, error) {
+	a := &fundAction{channels: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// fundAction locks Amount in the funding contract of the channel
+// with ID ChannelID. The amount must come from other actions,
+// such as spend_account.
+type fundAction struct {
+	channels  *Manager
+	ChannelID string `json:"channel_id"`
+	Amount    uint64 `json:"amount"`
+}
+
+func (a *fundAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	r, err := a.channels.Find(ctx, a.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if a.Amount == 0 {
+		return nil, errors.WithDetail(txbuilder.ErrBadAmount, "funding amount must be positive")
+	}
+	ref, err := refData(r.Channel)
+	if err != nil {
+		return nil, err
+	}
+	out := bc.NewTxOutput(r.Channel.AssetID, a.Amount, r.Channel.Program(), ref)
+	return &txbuilder.BuildResult{Outputs: []*bc.TxOutput{out}}, nil
+}
+
+func (m *Manager) DecodeCloseAction(data []byte) (txbuilder.Action, error) {
+	a := &closeAction{channels: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// closeAction puts the latest state of the channel with ID
+// ChannelID on-chain, starting its dispute window. The window
+// closes Timeout after the transaction's maxtime, so the
+// transaction's time to live must be at most Timeout. It must
+// be the first action of its transaction.
+type closeAction struct {
+	channels  *Manager
+	ChannelID string `json:"channel_id"`
+}
+
+func (a *closeAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	r, err := a.channels.Find(ctx, a.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	c := r.Channel
+	k, err := a.channels.contract(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if k.Settlement != nil {
+		return nil, errors.WithDetail(ErrBadState, "payment channel is already closing")
+	}
+	st, err := a.channels.signedState(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	minTime, maxTimeMS := bc.Millis(time.Now()), bc.Millis(maxTime)
+	if maxTimeMS > minTime+c.Timeout {
+		return nil, errors.WithDetailf(ErrDispute, "transaction time to live must be at most the channel timeout of %dms", c.Timeout)
+	}
+	deadline := maxTimeMS + c.Timeout
+
+	res, err := a.channels.spendContract(ctx, r, k,
+		vm.Int64Bytes(int64(st.Sequence)),
+		vm.Int64Bytes(int64(st.BalanceA)),
+		st.SignatureA,
+		st.SignatureB,
+		vm.Int64Bytes(int64(deadline)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refData(c)
+	if err != nil {
+		return nil, err
+	}
+	prog := c.SettlementProgram(st.Sequence, st.BalanceA, deadline)
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(c.AssetID, k.Amount, prog, ref))
+	res.MinTimeMS = minTime
+	return res, nil
+}
+
+func (m *Manager) DecodeSettleAction(data []byte) (txbuilder.Action, error) {
+	a := &settleAction{channels: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// settleAction pays out the balances of the state put on-chain
+// for the channel with ID ChannelID, once its dispute window has
+// closed. It must be the first action of its transaction.
+type settleAction struct {
+	channels  *Manager
+	ChannelID string `json:"channel_id"`
+}
+
+func (a *settleAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	r, err := a.channels.Find(ctx, a.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	k, err := a.channels.contract(ctx, r.Channel)
+	if err != nil {
+		return nil, err
+	}
+	s := k.Settlement
+	if s == nil {
+		return nil, errors.WithDetail(ErrBadState, "payment channel is not closing")
+	}
+	if bc.Millis(time.Now()) <= s.Deadline {
+		return nil, errors.WithDetailf(ErrDispute, "the dispute window closes at %d", s.Deadline)
+	}
+
+	res, err := a.channels.spendContract(ctx, r, k, vm.Int64Bytes(clausePayout))
+	if err != nil {
+		return nil, err
+	}
+	res.Outputs = append(res.Outputs, payouts(r.Channel, k.Amount, s.BalanceA)...)
+	res.MinTimeMS = s.Deadline + 1
+	return res, nil
+}
+
+func (m *Manager) DecodeDisputeAction(data []byte) (txbuilder.Action, error) {
+	a := &disputeAction{channels: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// disputeAction replaces the state put on-chain for the channel
+// with ID ChannelID with the latest state, which must be newer,
+// and pays out its balances. The transaction's maxtime must be
+// within the dispute window. It must be the first action of its
+// transaction.
+type disputeAction struct {
+	channels  *Manager
+	ChannelID string `json:"channel_id"`
+}
+
+func (a *disputeAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	r, err := a.channels.Find(ctx, a.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	k, err := a.channels.contract(ctx, r.Channel)
+	if err != nil {
+		return nil, err
+	}
+	s := k.Settlement
+	if s == nil {
+		return nil, errors.WithDetail(ErrBadState, "payment channel is not closing")
+	}
+	if bc.Millis(maxTime) > s.Deadline {
+		return nil, errors.WithDetailf(ErrDispute, "the dispute window closes at %d", s.Deadline)
+	}
+	st, err := a.channels.signedState(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if st.Sequence <= s.Sequence {
+		return nil, errors.WithDetailf(ErrBadState, "the state on-chain, %d, is the latest", s.Sequence)
+	}
+
+	res, err := a.channels.spendContract(ctx, r, k,
+		vm.Int64Bytes(clauseDispute),
+		vm.Int64Bytes(int64(st.Sequence)),
+		vm.Int64Bytes(int64(st.BalanceA)),
+		st.SignatureA,
+		st.SignatureB,
+	)
+	if err != nil {
+		return nil, err
+	}
+	res.Outputs = append(res.Outputs, payouts(r.Channel, k.Amount, st.BalanceA)...)
+	return res, nil
+}
+
+// signedState returns the latest state of r that both parties
+// have signed. Only the latest state, or the one before it, can
+// be signed by both.
+func (m *Manager) signedState(ctx context.Context, r *Record) (*State, error) {
+	if r.State.signed() {
+		return r.State, nil
+	}
+	if r.State.Sequence == 0 {
+		return nil, errors.WithDetail(ErrBadState, "the initial state is not signed by both parties")
+	}
+	const q = `
+		SELECT balance_a, balance_b, signature_a, signature_b FROM payment_channel_states
+		WHERE channel_id = $1 AND sequence = $2
+	`
+	var sigA, sigB []byte
+	st := &State{Sequence: r.State.Sequence - 1}
+	err := m.db.QueryRow(ctx, q, r.ID, st.Sequence).Scan(&st.BalanceA, &st.BalanceB, &sigA, &sigB)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading payment channel state")
+	}
+	st.SignatureA, st.SignatureB = sigA, sigB
+	return st, nil
+}
+
+// payouts returns the outputs paying the balances of a
+// state to the parties, skipping zero balances, as the
+// settlement program requires.
+func payouts(c *Channel, amount, balanceA uint64) []*bc.TxOutput {
+	var outs []*bc.TxOutput
+	if balanceA > 0 {
+		outs = append(outs, bc.NewTxOutput(c.AssetID, balanceA, c.ProgramA, nil))
+	}
+	if balanceA < amount {
+		outs = append(outs, bc.NewTxOutput(c.AssetID, amount-balanceA, c.ProgramB, nil))
+	}
+	return outs
+}
+
+// spendContract returns a build result spending k, signed for by
+// the account of this core's party, with args as the arguments
+// following the party's side.
+func (m *Manager) spendContract(ctx context.Context, r *Record, k *Contract, args ...[]byte) (*txbuilder.BuildResult, error) {
+	c := r.Channel
+	amt := bc.AssetAmount{AssetID: c.AssetID, Amount: k.Amount}
+	sigInst, err := m.accounts.SigningInstruction(ctx, c.program(r.Side), amt)
+	if err != nil {
+		return nil, errors.WithDetailf(err, "program_%s is not controlled by this core", r.Side)
+	}
+
+	// The contract finds its arguments at the bottom of
+	// the stack, below those of the party's program.
+	actor := int64(actorA)
+	if r.Side == SideB {
+		actor = actorB
+	}
+	sig := sigInst.WitnessComponents
+	sigInst.WitnessComponents = nil
+	sigInst.AddDataWitness(vm.Int64Bytes(actor))
+	for _, arg := range args {
+		sigInst.AddDataWitness(arg)
+	}
+	sigInst.WitnessComponents = append(sigInst.WitnessComponents, sig...)
+
+	in := bc.NewSpendInput(k.TransactionID, k.Position, nil, c.AssetID, k.Amount, k.ControlProgram, nil)
+	return &txbuilder.BuildResult{
+		Inputs:              []*bc.TxInput{in},
+		SigningInstructions: []*txbuilder.SigningInstruction{sigInst},
+	}, nil
+}
@@ -0,0 +1,403 @@
+// Package channel implements payment channels between two
+// parties. The parties lock funds in a contract output, then pay
+// each other by exchanging signed states of the channel off-chain,
+// each state giving, with a higher sequence number, how much of
+// the funds belongs to each party. Either party closes the channel
+// by putting a state on-chain. The other party then has a dispute
+// window to replace it with a newer state, after which the funds
+// are paid out accordingly.
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"chain/core/account"
+	"chain/core/mockhsm"
+	"chain/core/query"
+	"chain/core/query/filter"
+	"chain/crypto/ed25519"
+	"chain/crypto/sha3pool"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+var (
+	ErrBadChannel = errors.New("invalid payment channel")
+	ErrNotFunded  = errors.New("payment channel has no contract output")
+	ErrBadState   = errors.New("invalid payment channel state")
+	ErrDispute    = errors.New("payment channel dispute window")
+)
+
+// Sides of a channel, which are also the first witness argument
+// to its contracts, naming the party whose program signs.
+const (
+	SideA = "a"
+	SideB = "b"
+
+	actorA = 0
+	actorB = 1
+)
+
+// Clause selectors of a settlement contract, its second
+// witness argument.
+const (
+	clausePayout  = 0
+	clauseDispute = 1
+)
+
+// settlementPrefixLen is the length of the pushes of a state
+// and deadline that begin a settlement program.
+const settlementPrefixLen = 3 * 9
+
+// maxTimeout bounds Timeout, so that the deadlines
+// computed from it stay within the VM's number range.
+const maxTimeout = 1 << 61
+
+// Channel describes the terms of a payment channel. Like its ID,
+// they do not change over the channel's life.
+type Channel struct {
+	AssetID bc.AssetID `json:"asset_id"`
+
+	// ProgramA and ProgramB receive the parties' payouts, and one
+	// of them signs each transaction spending a contract. Both must
+	// be multisig control programs, such as ones created for accounts.
+	ProgramA chainjson.HexBytes `json:"program_a"`
+	ProgramB chainjson.HexBytes `json:"program_b"`
+
+	// KeyA and KeyB are the ed25519 public keys with which the
+	// parties sign the channel's states.
+	KeyA chainjson.HexBytes `json:"key_a"`
+	KeyB chainjson.HexBytes `json:"key_b"`
+
+	// Timeout is the length of the dispute window, in milliseconds.
+	Timeout uint64 `json:"timeout"`
+}
+
+// Validate checks that c's terms are usable.
+func (c *Channel) Validate() error {
+	if c.Timeout == 0 || c.Timeout > maxTimeout {
+		return errors.WithDetail(ErrBadChannel, "timeout must be positive and at most 2^61")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(c.ProgramA); err != nil {
+		return errors.WithDetail(ErrBadChannel, "program_a must be a multisig program")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(c.ProgramB); err != nil {
+		return errors.WithDetail(ErrBadChannel, "program_b must be a multisig program")
+	}
+	if len(c.KeyA) != ed25519.PublicKeySize || len(c.KeyB) != ed25519.PublicKeySize {
+		return errors.WithDetailf(ErrBadChannel, "state keys must be %d-byte ed25519 public keys", ed25519.PublicKeySize)
+	}
+	return nil
+}
+
+// ID returns the hash that identifies c. Contract outputs
+// record it in their reference data.
+func (c *Channel) ID() bc.Hash {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], c.Timeout)
+
+	var h bc.Hash
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(c.AssetID[:])
+	sha.Write(buf[:])
+	for _, b := range [][]byte{c.ProgramA, c.ProgramB, c.KeyA, c.KeyB} {
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(b)))
+		sha.Write(buf[:])
+		sha.Write(b)
+	}
+	sha.Read(h[:])
+	return h
+}
+
+// StateHash returns the message that both parties sign for the
+// state of c with sequence number seq, in which party A holds
+// balanceA and party B the rest of the contract's amount.
+func (c *Channel) StateHash(seq, balanceA uint64) []byte {
+	id := c.ID()
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], seq)
+	binary.LittleEndian.PutUint64(buf[8:], balanceA)
+
+	var h [32]byte
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(id[:])
+	sha.Write(buf[:])
+	sha.Read(h[:])
+	return h[:]
+}
+
+// Program returns the control program of c's funding contract.
+//
+// Its witness arguments are the side of the party closing the
+// channel (0 for A, 1 for B), a state's sequence number and
+// balance for A, both parties' signatures of the state, and a
+// deadline, followed by the arguments to the closing party's
+// program. The contract must be relocked in full, in the output
+// at the input's index, under the settlement program for the
+// state and deadline. The deadline must be at least Timeout after
+// the transaction's maxtime, and at most twice Timeout after its
+// mintime.
+func (c *Channel) Program() []byte {
+	a := vmutil.NewAssembler()
+	c.checkState(a, 1)
+	pick(a, 2).AddInt64(0).AddOp(vm.OP_AMOUNT).AddOp(vm.OP_1ADD).AddOp(vm.OP_WITHIN).AddOp(vm.OP_VERIFY)
+	pick(a, 5).AddOp(vm.OP_MAXTIME).AddInt64(int64(c.Timeout)).AddOp(vm.OP_ADD)
+	a.AddOp(vm.OP_GREATERTHANOREQUAL).AddOp(vm.OP_VERIFY)
+	pick(a, 5).AddOp(vm.OP_MINTIME).AddInt64(2 * int64(c.Timeout)).AddOp(vm.OP_ADD)
+	a.AddOp(vm.OP_LESSTHANOREQUAL).AddOp(vm.OP_VERIFY)
+
+	// Build the settlement program, then relock the contract under it.
+	for i, arg := range []int64{1, 2, 5} {
+		a.AddData([]byte{byte(vm.OP_DATA_8)})
+		pad8(pick(a, arg)).AddOp(vm.OP_CAT)
+		if i > 0 {
+			a.AddOp(vm.OP_CAT)
+		}
+	}
+	a.AddData(c.settlementBody()).AddOp(vm.OP_CAT).AddOp(vm.OP_TOALTSTACK)
+	a.AddOp(vm.OP_INDEX).AddData(nil).AddOp(vm.OP_AMOUNT)
+	a.AddData(c.AssetID[:]).AddInt64(1).AddOp(vm.OP_FROMALTSTACK).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	c.actor(a)
+	return a.Build()
+}
+
+// SettlementProgram returns the control program of c's
+// settlement contract for the state with sequence number seq
+// and balance for A balanceA, with deadline deadline.
+//
+// The program begins by pushing seq, balanceA, and deadline as
+// 8 bytes each. Its witness arguments are the side of the party
+// spending the contract, a clause selector, and the clause's
+// arguments, followed by the arguments to the spending party's
+// program. The clauses are:
+//
+//	payout:  after the deadline, either party may pay out
+//	         the state's balances.
+//	dispute: until the deadline, either party may present a
+//	         state with a higher sequence number and both
+//	         parties' signatures, and pay out its balances.
+//
+// Payouts go to A in the output at the input's index and to B
+// in the next one, skipping a party whose balance is zero.
+func (c *Channel) SettlementProgram(seq, balanceA, deadline uint64) []byte {
+	var prefix []byte
+	for _, n := range []uint64{seq, balanceA, deadline} {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], n)
+		prefix = append(prefix, vm.PushdataBytes(buf[:])...)
+	}
+	return append(prefix, c.settlementBody()...)
+}
+
+// settlementBody is the code of a settlement program, which
+// follows the pushes of its state and deadline.
+func (c *Channel) settlementBody() []byte {
+	// Jump addresses count from the start of the whole
+	// program, so assemble the body after room for the pushes.
+	a := vmutil.NewAssembler()
+	a.AddRawBytes(make([]byte, settlementPrefixLen))
+	pick(a, 1)
+	a.Jump(vm.OP_JUMPIF, "dispute")
+
+	// payout
+	a.AddOp(vm.OP_MINTIME).AddOp(vm.OP_LESSTHAN).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_NIP)
+	a.Jump(vm.OP_JUMP, "pay")
+
+	a.Label("dispute")
+	a.AddOp(vm.OP_MAXTIME).AddOp(vm.OP_GREATERTHANOREQUAL).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_DROP)
+	pick(a, 2).AddOp(vm.OP_LESSTHAN).AddOp(vm.OP_VERIFY)
+	c.checkState(a, 2)
+	pick(a, 3)
+
+	// Pay out, with A's balance on top of the stack.
+	a.Label("pay")
+	a.AddOp(vm.OP_DUP).AddInt64(0).AddOp(vm.OP_AMOUNT).AddOp(vm.OP_1ADD).AddOp(vm.OP_WITHIN).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_DUP).AddOp(vm.OP_AMOUNT).AddOp(vm.OP_SWAP).AddOp(vm.OP_SUB).AddOp(vm.OP_TOALTSTACK)
+	a.AddOp(vm.OP_INDEX).AddOp(vm.OP_SWAP)
+	a.AddOp(vm.OP_DUP).AddOp(vm.OP_NOT)
+	a.Jump(vm.OP_JUMPIF, "skipA")
+	a.AddOp(vm.OP_OVER).AddData(nil).AddOp(vm.OP_ROT)
+	a.AddData(c.AssetID[:]).AddInt64(1).AddData(c.ProgramA).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_1ADD)
+	a.Jump(vm.OP_JUMP, "payB")
+	a.Label("skipA")
+	a.AddOp(vm.OP_DROP)
+	a.Label("payB")
+	a.AddOp(vm.OP_FROMALTSTACK)
+	a.AddOp(vm.OP_DUP).AddOp(vm.OP_NOT)
+	a.Jump(vm.OP_JUMPIF, "skipB")
+	a.AddData(nil).AddOp(vm.OP_SWAP)
+	a.AddData(c.AssetID[:]).AddInt64(1).AddData(c.ProgramB).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	a.Jump(vm.OP_JUMP, "paid")
+	a.Label("skipB")
+	a.AddOp(vm.OP_DROP).AddOp(vm.OP_DROP)
+	a.Label("paid")
+	c.actor(a)
+	return a.Build()[settlementPrefixLen:]
+}
+
+// checkState adds code verifying that the witness arguments
+// at i, a sequence number, and i+1, a balance for A, are a
+// state signed by A and B in the arguments at i+2 and i+3.
+func (c *Channel) checkState(a *vmutil.Assembler, i int64) {
+	id := c.ID()
+	a.AddData(id[:])
+	pad8(pick(a, i)).AddOp(vm.OP_CAT)
+	pad8(pick(a, i+1)).AddOp(vm.OP_CAT)
+	a.AddOp(vm.OP_SHA3).AddOp(vm.OP_DUP).AddOp(vm.OP_TOALTSTACK)
+	pick(a, i+2).AddOp(vm.OP_SWAP).AddData(c.KeyA).AddOp(vm.OP_CHECKSIG).AddOp(vm.OP_VERIFY)
+	pick(a, i+3).AddOp(vm.OP_FROMALTSTACK).AddData(c.KeyB).AddOp(vm.OP_CHECKSIG).AddOp(vm.OP_VERIFY)
+}
+
+// actor adds the program of the party named by
+// the first witness argument, ending the program.
+func (c *Channel) actor(a *vmutil.Assembler) {
+	pick(a, 0)
+	a.Jump(vm.OP_JUMPIF, "b")
+	a.AddRawBytes(c.ProgramA)
+	a.Jump(vm.OP_JUMP, "end")
+	a.Label("b")
+	a.AddRawBytes(c.ProgramB)
+	a.Label("end")
+}
+
+// pick adds code copying the witness argument at i, counting
+// from the bottom of the stack, to the top of the stack.
+func pick(a *vmutil.Assembler, i int64) *vmutil.Assembler {
+	a.AddOp(vm.OP_DEPTH).AddInt64(i + 1).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK)
+	return a
+}
+
+// pad8 adds code extending the number on top of
+// the stack to its 8-byte little-endian form.
+func pad8(a *vmutil.Assembler) *vmutil.Assembler {
+	a.AddData(make([]byte, 8)).AddOp(vm.OP_CAT).AddInt64(8).AddOp(vm.OP_LEFT)
+	return a
+}
+
+// settlement parses prog as a settlement program of c,
+// returning its state and deadline, and whether it is one.
+func (c *Channel) settlement(prog []byte) (seq, balanceA, deadline uint64, ok bool) {
+	if len(prog) < settlementPrefixLen {
+		return 0, 0, 0, false
+	}
+	for i := 0; i < settlementPrefixLen; i += 9 {
+		if prog[i] != byte(vm.OP_DATA_8) {
+			return 0, 0, 0, false
+		}
+	}
+	seq = binary.LittleEndian.Uint64(prog[1:9])
+	balanceA = binary.LittleEndian.Uint64(prog[10:18])
+	deadline = binary.LittleEndian.Uint64(prog[19:27])
+	return seq, balanceA, deadline, bytes.Equal(prog, c.SettlementProgram(seq, balanceA, deadline))
+}
+
+// Manager stores the payment channels of this core's parties,
+// exchanges their states with the other parties' cores, and
+// builds transactions for them.
+type Manager struct {
+	db       pg.DB
+	accounts *account.Manager
+	indexer  *query.Indexer
+	hsm      *mockhsm.HSM
+}
+
+func NewManager(db pg.DB, accounts *account.Manager, indexer *query.Indexer, hsm *mockhsm.HSM) *Manager {
+	return &Manager{db: db, accounts: accounts, indexer: indexer, hsm: hsm}
+}
+
+// Contract is the unspent contract output of a channel. If the
+// channel is closing, Settlement gives the state put on-chain.
+type Contract struct {
+	TransactionID  bc.Hash            `json:"transaction_id"`
+	Position       uint32             `json:"position"`
+	Amount         uint64             `json:"amount"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+	Settlement     *Settlement        `json:"settlement"`
+}
+
+// Settlement is the state of a closing channel,
+// which is paid out after Deadline.
+type Settlement struct {
+	Sequence uint64 `json:"sequence"`
+	BalanceA uint64 `json:"balance_a"`
+	Deadline uint64 `json:"deadline"`
+}
+
+// contract returns the unspent contract output of c.
+// Outputs that only claim to belong to c, by their reference
+// data, but have some other control program or asset are
+// skipped. If there are several, the first one found is used.
+func (m *Manager) contract(ctx context.Context, c *Channel) (*Contract, error) {
+	p, err := filter.Parse("reference_data.payment_channel=$1")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	vals := []interface{}{c.ID().String()}
+	timestampMS := bc.Millis(time.Now())
+	funding := c.Program()
+
+	const limit = 100
+	var after *query.OutputsAfter
+	for {
+		outs, next, err := m.indexer.Outputs(ctx, p, vals, timestampMS, after, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "querying contract outputs")
+		}
+		for _, o := range outs {
+			raw, ok := o.(*json.RawMessage)
+			if !ok || raw == nil {
+				return nil, errors.Wrap(fmt.Errorf("unexpected %T in Indexer.Outputs output", o))
+			}
+			var out struct {
+				TransactionID  bc.Hash            `json:"transaction_id"`
+				Position       uint32             `json:"position"`
+				AssetID        bc.AssetID         `json:"asset_id"`
+				Amount         uint64             `json:"amount"`
+				ControlProgram chainjson.HexBytes `json:"control_program"`
+			}
+			err = json.Unmarshal(*raw, &out)
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding contract output")
+			}
+			if out.AssetID != c.AssetID {
+				continue
+			}
+			k := &Contract{
+				TransactionID:  out.TransactionID,
+				Position:       out.Position,
+				Amount:         out.Amount,
+				ControlProgram: out.ControlProgram,
+			}
+			if seq, bal, deadline, ok := c.settlement(out.ControlProgram); ok {
+				k.Settlement = &Settlement{Sequence: seq, BalanceA: bal, Deadline: deadline}
+			} else if !bytes.Equal(out.ControlProgram, funding) {
+				continue
+			}
+			return k, nil
+		}
+		if len(outs) < limit {
+			return nil, errors.WithDetailf(ErrNotFunded, "payment channel %s", c.ID())
+		}
+		after = next
+	}
+}
+
+// refData is the reference data of every contract output of c.
+func refData(c *Channel) ([]byte, error) {
+	b, err := json.Marshal(map[string]string{"payment_channel": c.ID().String()})
+	return b, errors.Wrap(err)
+}
@@ -0,0 +1,275 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"chain/core/contracttest"
+	"chain/core/coretest"
+	"chain/core/mockhsm"
+	"chain/core/txbuilder"
+	"chain/crypto/ed25519"
+	"chain/database/pg/pgtest"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+type party struct {
+	prog     []byte
+	priv     ed25519.PrivateKey // signs transactions
+	statePub []byte
+	state    ed25519.PrivateKey // signs states
+}
+
+func newParty(t *testing.T) *party {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statePub, statePriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &party{prog: prog, priv: priv, statePub: statePub, state: statePriv}
+}
+
+func TestPrograms(t *testing.T) {
+	pa, pb := newParty(t), newParty(t)
+	c := &Channel{
+		AssetID:  bc.AssetID{1},
+		ProgramA: pa.prog,
+		ProgramB: pb.prog,
+		KeyA:     pa.statePub,
+		KeyB:     pb.statePub,
+		Timeout:  100,
+	}
+	err := c.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// state returns the witness arguments of a state
+	// with both parties' signatures.
+	state := func(seq, balanceA int64) [][]byte {
+		h := c.StateHash(uint64(seq), uint64(balanceA))
+		return [][]byte{vm.Int64Bytes(seq), vm.Int64Bytes(balanceA), ed25519.Sign(pa.state, h), ed25519.Sign(pb.state, h)}
+	}
+	args := func(parts ...interface{}) [][]byte {
+		var out [][]byte
+		for _, p := range parts {
+			switch p := p.(type) {
+			case int64:
+				out = append(out, vm.Int64Bytes(p))
+			case int:
+				out = append(out, vm.Int64Bytes(int64(p)))
+			case [][]byte:
+				out = append(out, p...)
+			}
+		}
+		return out
+	}
+	out := func(amount uint64, prog []byte) *bc.TxOutput {
+		return bc.NewTxOutput(c.AssetID, amount, prog, nil)
+	}
+	mismatched := state(2, 30)
+	mismatched[0] = vm.Int64Bytes(3)
+
+	funding := c.Program()
+	settlement := c.SettlementProgram(2, 30, 1300)
+	cases := []struct {
+		name     string
+		prog     []byte
+		minTime  uint64
+		maxTime  uint64
+		args     [][]byte
+		outputs  []*bc.TxOutput
+		signer   *party
+		wantPass bool
+	}{
+		{"close", funding, 1150, 1200, args(actorA, state(2, 30), 1300), []*bc.TxOutput{out(50, settlement)}, pa, true},
+		{"close by b", funding, 1150, 1200, args(actorB, state(2, 30), 1300), []*bc.TxOutput{out(50, settlement)}, pb, true},
+		{"close signed by the other party", funding, 1150, 1200, args(actorA, state(2, 30), 1300), []*bc.TxOutput{out(50, settlement)}, pb, false},
+		{"close with bad state signature", funding, 1150, 1200, args(actorA, mismatched, 1300), []*bc.TxOutput{out(50, c.SettlementProgram(3, 30, 1300))}, pa, false},
+		{"close with balance over amount", funding, 1150, 1200, args(actorA, state(2, 51), 1300), []*bc.TxOutput{out(50, c.SettlementProgram(2, 51, 1300))}, pa, false},
+		{"close with short dispute window", funding, 1150, 1200, args(actorA, state(2, 30), 1299), []*bc.TxOutput{out(50, c.SettlementProgram(2, 30, 1299))}, pa, false},
+		{"close with long dispute window", funding, 1050, 1200, args(actorA, state(2, 30), 1300), []*bc.TxOutput{out(50, settlement)}, pa, false},
+		{"close to another state", funding, 1150, 1200, args(actorA, state(2, 30), 1300), []*bc.TxOutput{out(50, c.SettlementProgram(2, 0, 1300))}, pa, false},
+		{"close to a partial amount", funding, 1150, 1200, args(actorA, state(2, 30), 1300), []*bc.TxOutput{out(40, settlement), out(10, pa.prog)}, pa, false},
+		{"payout", settlement, 1301, 2000, args(actorA, clausePayout), []*bc.TxOutput{out(30, pa.prog), out(20, pb.prog)}, pa, true},
+		{"payout by b", settlement, 1301, 2000, args(actorB, clausePayout), []*bc.TxOutput{out(30, pa.prog), out(20, pb.prog)}, pb, true},
+		{"payout before deadline", settlement, 1300, 2000, args(actorA, clausePayout), []*bc.TxOutput{out(30, pa.prog), out(20, pb.prog)}, pa, false},
+		{"payout of wrong balances", settlement, 1301, 2000, args(actorA, clausePayout), []*bc.TxOutput{out(31, pa.prog), out(19, pb.prog)}, pa, false},
+		{"payout of all to a", c.SettlementProgram(2, 50, 1300), 1301, 2000, args(actorA, clausePayout), []*bc.TxOutput{out(50, pa.prog)}, pa, true},
+		{"payout of all to b", c.SettlementProgram(2, 0, 1300), 1301, 2000, args(actorB, clausePayout), []*bc.TxOutput{out(50, pb.prog)}, pb, true},
+		{"dispute", settlement, 1000, 1300, args(actorB, clauseDispute, state(3, 10)), []*bc.TxOutput{out(10, pa.prog), out(40, pb.prog)}, pb, true},
+		{"dispute with same state", settlement, 1000, 1300, args(actorB, clauseDispute, state(2, 10)), []*bc.TxOutput{out(10, pa.prog), out(40, pb.prog)}, pb, false},
+		{"dispute with bad state signature", settlement, 1000, 1300, args(actorB, clauseDispute, mismatched), []*bc.TxOutput{out(30, pa.prog), out(20, pb.prog)}, pb, false},
+		{"dispute after deadline", settlement, 1000, 1301, args(actorB, clauseDispute, state(3, 10)), []*bc.TxOutput{out(10, pa.prog), out(40, pb.prog)}, pb, false},
+		{"dispute paying old balances", settlement, 1000, 1300, args(actorB, clauseDispute, state(3, 10)), []*bc.TxOutput{out(30, pa.prog), out(20, pb.prog)}, pb, false},
+		{"dispute signed by the other party", settlement, 1000, 1300, args(actorB, clauseDispute, state(3, 10)), []*bc.TxOutput{out(10, pa.prog), out(40, pb.prog)}, pa, false},
+	}
+	for _, tc := range cases {
+		tx := &bc.TxData{
+			Version: 1,
+			MinTime: tc.minTime,
+			MaxTime: tc.maxTime,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{2}, 0, nil, c.AssetID, 50, tc.prog, nil),
+			},
+			Outputs: tc.outputs,
+		}
+		contracttest.Sign(tx, tc.signer.priv, tc.args)
+
+		ok, err := vm.VerifyTxInput(bc.NewTx(*tx), 0)
+		if ok != tc.wantPass {
+			t.Errorf("%s: got ok=%t (err %v), want %t", tc.name, ok, err, tc.wantPass)
+		}
+	}
+}
+
+func TestSettlement(t *testing.T) {
+	c := &Channel{AssetID: bc.AssetID{1}, ProgramA: []byte{1}, ProgramB: []byte{2}, KeyA: []byte{3}, KeyB: []byte{4}, Timeout: 100}
+	seq, bal, deadline, ok := c.settlement(c.SettlementProgram(2, 30, 1300))
+	if !ok || seq != 2 || bal != 30 || deadline != 1300 {
+		t.Errorf("settlement = %d, %d, %d, %t, want 2, 30, 1300, true", seq, bal, deadline, ok)
+	}
+	d := *c
+	d.Timeout = 101
+	if _, _, _, ok := c.settlement(d.SettlementProgram(2, 30, 1300)); ok {
+		t.Error("settlement accepted the program of another channel")
+	}
+	if _, _, _, ok := c.settlement(c.Program()); ok {
+		t.Error("settlement accepted a funding program")
+	}
+}
+
+func TestBuildAndStatus(t *testing.T) {
+	ctx := context.Background()
+	core := contracttest.NewCore(t)
+	hsmA := mockhsm.New(core.DB)
+	m := NewManager(core.DB, core.Accounts, core.Indexer, hsmA)
+
+	// Party B's core needs only its own store of the
+	// channel, to countersign the states A sends it.
+	_, dbB := pgtest.NewDB(t, pgtest.SchemaPath)
+	hsmB := mockhsm.New(dbB)
+	peer := NewManager(dbB, nil, nil, hsmB)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			ChannelID string             `json:"channel_id"`
+			Sequence  uint64             `json:"sequence"`
+			BalanceA  uint64             `json:"balance_a"`
+			BalanceB  uint64             `json:"balance_b"`
+			Signature chainjson.HexBytes `json:"signature"`
+		}
+		err := json.NewDecoder(req.Body).Decode(&in)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		st := &State{Sequence: in.Sequence, BalanceA: in.BalanceA, BalanceB: in.BalanceB}
+		sig, err := peer.Accept(ctx, in.ChannelID, st, in.Signature)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"signature": chainjson.HexBytes(sig)})
+	}))
+	defer srv.Close()
+
+	accA, assetID := core.Fund(ctx, t, 100)
+	accB := coretest.CreateAccount(ctx, t, core.Accounts, "", nil)
+	xpubA, err := hsmA.XCreate(ctx, "")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	xpubB, err := hsmB.XCreate(ctx, "")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	c := &Channel{
+		AssetID:  assetID,
+		ProgramA: core.Program(ctx, t, accA),
+		ProgramB: core.Program(ctx, t, accB),
+		KeyA:     chainjson.HexBytes(xpubA.XPub.PublicKey()),
+		KeyB:     chainjson.HexBytes(xpubB.XPub.PublicKey()),
+		Timeout:  uint64(time.Hour / time.Millisecond),
+	}
+	r, err := m.Create(ctx, c, SideA, xpubA.XPub.String(), 100, 0, srv.URL, "")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = peer.Create(ctx, c, SideB, xpubB.XPub.String(), 100, 0, "http://party-a.example", "")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	checkStatus := func(want string, amount uint64) *Status {
+		st, err := m.Status(ctx, r.ID)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if st.Status != want {
+			t.Fatalf("status = %s, want %s", st.Status, want)
+		}
+		if st.Contract != nil && st.Contract.Amount != amount {
+			t.Errorf("contract amount = %d, want %d", st.Contract.Amount, amount)
+		}
+		return st
+	}
+	action := func(decode func([]byte) (txbuilder.Action, error)) txbuilder.Action {
+		return contracttest.Action(t, decode, map[string]interface{}{"channel_id": r.ID})
+	}
+	checkStatus("unfunded", 0)
+
+	// B countersigns the initial state before A funds the channel.
+	_, err = m.Pay(ctx, r.ID, 0)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	core.Submit(ctx, t,
+		core.Accounts.NewSpendAction(bc.AssetAmount{AssetID: assetID, Amount: 100}, accA, nil, nil, nil, nil),
+		contracttest.Action(t, m.DecodeFundAction, map[string]interface{}{"channel_id": r.ID, "amount": 100}),
+	)
+	checkStatus("open", 100)
+
+	_, err = m.Pay(ctx, r.ID, 25)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	core.Submit(ctx, t, action(m.DecodeCloseAction))
+	st := checkStatus("closing", 100)
+	if s := st.Contract.Settlement; s.Sequence != 1 || s.BalanceA != 75 {
+		t.Errorf("settlement = %+v, want sequence 1 with balance_a 75", s)
+	}
+
+	// The settlement waits out the dispute window, but a
+	// newer state can replace it in the meantime.
+	_, err = action(m.DecodeSettleAction).Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrDispute {
+		t.Errorf("early settle: got error %v, want %v", err, ErrDispute)
+	}
+	_, err = m.Pay(ctx, r.ID, 5)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	tx := core.Submit(ctx, t, action(m.DecodeDisputeAction))
+	if len(tx.Outputs) != 2 ||
+		tx.Outputs[0].Amount != 70 || !bytes.Equal(tx.Outputs[0].ControlProgram, c.ProgramA) ||
+		tx.Outputs[1].Amount != 30 || !bytes.Equal(tx.Outputs[1].ControlProgram, c.ProgramB) {
+		t.Errorf("dispute outputs = %+v, want 70 to A and 30 to B", tx.Outputs)
+	}
+	checkStatus("unfunded", 0)
+}
@@ -0,0 +1,399 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"time"
+
+	"chain/core/rpc"
+	"chain/crypto/ed25519"
+	"chain/crypto/ed25519/chainkd"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+)
+
+// Record is a payment channel in which this core is one party.
+// This core signs states with the key XPub, whose public key is
+// the channel's state key for Side. PeerURL is the address of
+// the other party's core, which it calls to exchange states.
+type Record struct {
+	ID       string   `json:"id"`
+	Channel  *Channel `json:"channel"`
+	Side     string   `json:"side"`
+	XPub     string   `json:"xpub"`
+	Capacity uint64   `json:"capacity"`
+	PeerURL  string   `json:"peer_url"`
+	State    *State   `json:"state"`
+
+	peerAccessToken string
+}
+
+// State is a state of a channel, with the signatures
+// of the parties that have signed it so far.
+type State struct {
+	Sequence   uint64             `json:"sequence"`
+	BalanceA   uint64             `json:"balance_a"`
+	BalanceB   uint64             `json:"balance_b"`
+	SignatureA chainjson.HexBytes `json:"signature_a"`
+	SignatureB chainjson.HexBytes `json:"signature_b"`
+}
+
+func (s *State) signed() bool {
+	return len(s.SignatureA) > 0 && len(s.SignatureB) > 0
+}
+
+// balance returns the balance of side in s.
+func (s *State) balance(side string) uint64 {
+	if side == SideA {
+		return s.BalanceA
+	}
+	return s.BalanceB
+}
+
+// Status describes the state of a channel on-chain.
+type Status struct {
+	*Record
+	Status   string    `json:"status"` // "unfunded", "open", or "closing"
+	Contract *Contract `json:"contract"`
+}
+
+// Create stores c with this core's party at side, signing
+// with xpub, and an initial state of sequence number 0 with
+// the given balances, signed by this core. The channel's
+// funding contract should not be funded until both parties
+// have signed the initial state, since the contract can only
+// be spent by presenting a state.
+func (m *Manager) Create(ctx context.Context, c *Channel, side, xpub string, balanceA, balanceB uint64, peerURL, peerAccessToken string) (*Record, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if side != SideA && side != SideB {
+		return nil, errors.WithDetail(ErrBadChannel, `side must be "a" or "b"`)
+	}
+	var key chainkd.XPub
+	err = key.UnmarshalText([]byte(xpub))
+	if err != nil {
+		return nil, errors.WithDetail(ErrBadChannel, "invalid xpub")
+	}
+	if !bytes.Equal(key.PublicKey(), c.stateKey(side)) {
+		return nil, errors.WithDetailf(ErrBadChannel, "xpub does not match key_%s", side)
+	}
+	capacity := balanceA + balanceB
+	if capacity < balanceA || capacity == 0 || capacity > math.MaxInt64 {
+		return nil, errors.WithDetail(ErrBadChannel, "balances must add up to a positive amount less than 2^63")
+	}
+	if peerURL == "" {
+		return nil, errors.WithDetail(ErrBadChannel, "missing peer_url")
+	}
+	terms, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	r := &Record{
+		ID:              c.ID().String(),
+		Channel:         c,
+		Side:            side,
+		XPub:            xpub,
+		Capacity:        capacity,
+		PeerURL:         peerURL,
+		peerAccessToken: peerAccessToken,
+	}
+	st := &State{Sequence: 0, BalanceA: balanceA, BalanceB: balanceB}
+	err = m.sign(ctx, r, st)
+	if err != nil {
+		return nil, err
+	}
+
+	const q = `
+		INSERT INTO payment_channels (id, side, xpub, capacity, peer_url, peer_access_token, terms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = m.db.Exec(ctx, q, r.ID, r.Side, r.XPub, r.Capacity, r.PeerURL, peerAccessToken, terms)
+	if pg.IsUniqueViolation(err) {
+		return nil, errors.WithDetail(ErrBadChannel, "payment channel already exists")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "inserting payment channel")
+	}
+	err = m.insertState(ctx, r.ID, st)
+	if err != nil {
+		return nil, err
+	}
+	r.State = st
+	return r, nil
+}
+
+// Find returns the stored channel with the given ID,
+// with its latest state.
+func (m *Manager) Find(ctx context.Context, id string) (*Record, error) {
+	const q = `
+		SELECT side, xpub, capacity, peer_url, peer_access_token, terms
+		FROM payment_channels WHERE id = $1
+	`
+	r := &Record{ID: id}
+	var terms []byte
+	err := m.db.QueryRow(ctx, q, id).Scan(&r.Side, &r.XPub, &r.Capacity, &r.PeerURL, &r.peerAccessToken, &terms)
+	if err == sql.ErrNoRows {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "payment channel %s", id)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "loading payment channel")
+	}
+	r.Channel = new(Channel)
+	err = json.Unmarshal(terms, r.Channel)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding payment channel terms")
+	}
+
+	const stateQ = `
+		SELECT sequence, balance_a, balance_b, signature_a, signature_b
+		FROM payment_channel_states WHERE channel_id = $1
+		ORDER BY sequence DESC LIMIT 1
+	`
+	var (
+		st         State
+		sigA, sigB []byte
+	)
+	err = m.db.QueryRow(ctx, stateQ, id).Scan(&st.Sequence, &st.BalanceA, &st.BalanceB, &sigA, &sigB)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading payment channel state")
+	}
+	st.SignatureA, st.SignatureB = sigA, sigB
+	r.State = &st
+	return r, nil
+}
+
+// Status reports on the stored channel with the given ID,
+// and its confirmed contract output. A channel is open while
+// its funding contract is unspent, and closing once a state
+// has been put on-chain.
+func (m *Manager) Status(ctx context.Context, id string) (*Status, error) {
+	r, err := m.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{Record: r, Status: "unfunded"}
+	st.Contract, err = m.contract(ctx, r.Channel)
+	if err != nil && errors.Root(err) != ErrNotFunded {
+		return nil, err
+	}
+	if st.Contract != nil {
+		st.Status = "open"
+		if st.Contract.Settlement != nil {
+			st.Status = "closing"
+		}
+	}
+	return st, nil
+}
+
+// Pay moves amount from this core's party to the other party in
+// a new state of the channel with the given ID. This core signs
+// the state and has the other party's core countersign it.
+//
+// An amount of 0 does not make a new state. Instead it has the
+// other party's core countersign the latest state, if it has not
+// already, as is needed for the initial state, or when a payment
+// was signed here but the other core could not be reached.
+func (m *Manager) Pay(ctx context.Context, id string, amount uint64) (*Record, error) {
+	r, err := m.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	st := r.State
+	if amount > 0 {
+		if !st.signed() {
+			return nil, errors.WithDetail(ErrBadState, "the latest state is not signed by both parties; pay 0 to retry it")
+		}
+		if amount > st.balance(r.Side) {
+			return nil, errors.WithDetailf(ErrBadState, "balance is %d", st.balance(r.Side))
+		}
+		next := &State{Sequence: st.Sequence + 1, BalanceA: st.BalanceA, BalanceB: st.BalanceB}
+		if r.Side == SideA {
+			next.BalanceA, next.BalanceB = next.BalanceA-amount, next.BalanceB+amount
+		} else {
+			next.BalanceA, next.BalanceB = next.BalanceA+amount, next.BalanceB-amount
+		}
+		err = m.sign(ctx, r, next)
+		if err != nil {
+			return nil, err
+		}
+		err = m.insertState(ctx, id, next)
+		if err != nil {
+			return nil, err
+		}
+		st = next
+	} else if st.signed() {
+		return r, nil
+	}
+
+	peerSig, err := m.requestSignature(ctx, r, st)
+	if err != nil {
+		return nil, err
+	}
+	if r.Side == SideA {
+		st.SignatureB = peerSig
+	} else {
+		st.SignatureA = peerSig
+	}
+	err = m.updateSignatures(ctx, id, st)
+	if err != nil {
+		return nil, err
+	}
+	r.State = st
+	return r, nil
+}
+
+// requestSignature sends st, signed by this core, to the other
+// party's core, and returns the other party's signature of it.
+func (m *Manager) requestSignature(ctx context.Context, r *Record, st *State) ([]byte, error) {
+	ownSig := st.SignatureA
+	if r.Side == SideB {
+		ownSig = st.SignatureB
+	}
+	req := struct {
+		ChannelID string             `json:"channel_id"`
+		Sequence  uint64             `json:"sequence"`
+		BalanceA  uint64             `json:"balance_a"`
+		BalanceB  uint64             `json:"balance_b"`
+		Signature chainjson.HexBytes `json:"signature"`
+	}{r.ID, st.Sequence, st.BalanceA, st.BalanceB, ownSig}
+	var resp struct {
+		Signature chainjson.HexBytes `json:"signature"`
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	client := &rpc.Client{BaseURL: r.PeerURL, AccessToken: r.peerAccessToken}
+	err := client.Call(ctx, "/accept-payment-channel-state", req, &resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "requesting countersignature")
+	}
+	if !ed25519.Verify(r.Channel.stateKey(peerSide(r.Side)), r.Channel.StateHash(st.Sequence, st.BalanceA), resp.Signature) {
+		return nil, errors.WithDetail(ErrBadState, "other party's core returned an invalid signature")
+	}
+	return resp.Signature, nil
+}
+
+// Accept countersigns st, a state of the channel with the given ID
+// signed by the other party with sig, and stores it. It returns
+// this core's signature of st.
+//
+// It accepts the latest state again, or a state that follows it and
+// does not lower this core's party's balance. Another state could
+// take funds from the party, so it is refused.
+func (m *Manager) Accept(ctx context.Context, id string, st *State, sig []byte) ([]byte, error) {
+	r, err := m.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	peer := peerSide(r.Side)
+	if !ed25519.Verify(r.Channel.stateKey(peer), r.Channel.StateHash(st.Sequence, st.BalanceA), sig) {
+		return nil, errors.WithDetail(ErrBadState, "invalid signature")
+	}
+	if st.BalanceA+st.BalanceB != r.Capacity || st.BalanceA > r.Capacity {
+		return nil, errors.WithDetailf(ErrBadState, "balances must add up to %d", r.Capacity)
+	}
+	latest := r.State
+	switch {
+	case st.Sequence == latest.Sequence:
+		if st.BalanceA != latest.BalanceA {
+			return nil, errors.WithDetail(ErrBadState, "state conflicts with the latest state")
+		}
+	case st.Sequence == latest.Sequence+1:
+		if !latest.signed() {
+			return nil, errors.WithDetail(ErrBadState, "another state is pending")
+		}
+		if st.balance(r.Side) < latest.balance(r.Side) {
+			return nil, errors.WithDetail(ErrBadState, "state lowers this party's balance")
+		}
+	default:
+		return nil, errors.WithDetailf(ErrBadState, "sequence must be %d or %d", latest.Sequence, latest.Sequence+1)
+	}
+
+	next := &State{Sequence: st.Sequence, BalanceA: st.BalanceA, BalanceB: st.BalanceB}
+	if peer == SideA {
+		next.SignatureA = sig
+	} else {
+		next.SignatureB = sig
+	}
+	err = m.sign(ctx, r, next)
+	if err != nil {
+		return nil, err
+	}
+	if next.Sequence == latest.Sequence {
+		err = m.updateSignatures(ctx, id, next)
+	} else {
+		err = m.insertState(ctx, id, next)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Side == SideA {
+		return next.SignatureA, nil
+	}
+	return next.SignatureB, nil
+}
+
+// sign adds this core's signature to st.
+func (m *Manager) sign(ctx context.Context, r *Record, st *State) error {
+	var xpub chainkd.XPub
+	err := xpub.UnmarshalText([]byte(r.XPub))
+	if err != nil {
+		return errors.Wrap(err, "parsing xpub")
+	}
+	sig, err := m.hsm.XSign(ctx, xpub, nil, r.Channel.StateHash(st.Sequence, st.BalanceA))
+	if err != nil {
+		return errors.Wrap(err, "signing payment channel state")
+	}
+	if r.Side == SideA {
+		st.SignatureA = sig
+	} else {
+		st.SignatureB = sig
+	}
+	return nil
+}
+
+func (m *Manager) insertState(ctx context.Context, id string, st *State) error {
+	const q = `
+		INSERT INTO payment_channel_states
+			(channel_id, sequence, balance_a, balance_b, signature_a, signature_b)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := m.db.Exec(ctx, q, id, st.Sequence, st.BalanceA, st.BalanceB, []byte(st.SignatureA), []byte(st.SignatureB))
+	if pg.IsUniqueViolation(err) {
+		return errors.WithDetailf(ErrBadState, "state %d already exists", st.Sequence)
+	}
+	return errors.Wrap(err, "inserting payment channel state")
+}
+
+func (m *Manager) updateSignatures(ctx context.Context, id string, st *State) error {
+	const q = `
+		UPDATE payment_channel_states SET signature_a = $3, signature_b = $4
+		WHERE channel_id = $1 AND sequence = $2
+	`
+	_, err := m.db.Exec(ctx, q, id, st.Sequence, []byte(st.SignatureA), []byte(st.SignatureB))
+	return errors.Wrap(err, "updating payment channel state")
+}
+
+func (c *Channel) stateKey(side string) ed25519.PublicKey {
+	if side == SideA {
+		return ed25519.PublicKey(c.KeyA)
+	}
+	return ed25519.PublicKey(c.KeyB)
+}
+
+func (c *Channel) program(side string) []byte {
+	if side == SideA {
+		return c.ProgramA
+	}
+	return c.ProgramB
+}
+
+func peerSide(side string) string {
+	if side == SideA {
+		return SideB
+	}
+	return SideA
+}
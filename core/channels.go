@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/channel"
+	chainjson "chain/encoding/json"
+)
+
+// createPaymentChannel stores a payment channel in which this
+// core is the party at side, signing states with the Mock HSM
+// key xpub. Each party's core stores the channel with the same
+// terms and initial balances, then one of them pays 0 to have the
+// initial state signed by both before the channel is funded.
+//
+// POST /create-payment-channel
+func (h *Handler) createPaymentChannel(ctx context.Context, in struct {
+	Channel         channel.Channel `json:"channel"`
+	Side            string          `json:"side"`
+	XPub            string          `json:"xpub"`
+	BalanceA        uint64          `json:"balance_a"`
+	BalanceB        uint64          `json:"balance_b"`
+	PeerURL         string          `json:"peer_url"`
+	PeerAccessToken string          `json:"peer_access_token"`
+}) (*channel.Record, error) {
+	return h.channels.Create(ctx, &in.Channel, in.Side, in.XPub, in.BalanceA, in.BalanceB, in.PeerURL, in.PeerAccessToken)
+}
+
+// POST /get-payment-channel
+func (h *Handler) getPaymentChannel(ctx context.Context, in struct {
+	ID string `json:"id"`
+}) (*channel.Status, error) {
+	return h.channels.Status(ctx, in.ID)
+}
+
+// payPaymentChannel pays amount to the other party of a payment
+// channel, off-chain, by exchanging a new state with its core.
+//
+// POST /pay-payment-channel
+func (h *Handler) payPaymentChannel(ctx context.Context, in struct {
+	ChannelID string `json:"channel_id"`
+	Amount    uint64 `json:"amount"`
+}) (*channel.Record, error) {
+	return h.channels.Pay(ctx, in.ChannelID, in.Amount)
+}
+
+// acceptPaymentChannelState is called by the core of
+// the other party of a payment channel to have a new state
+// countersigned.
+//
+// POST /accept-payment-channel-state
+func (h *Handler) acceptPaymentChannelState(ctx context.Context, in struct {
+	ChannelID string             `json:"channel_id"`
+	Sequence  uint64             `json:"sequence"`
+	BalanceA  uint64             `json:"balance_a"`
+	BalanceB  uint64             `json:"balance_b"`
+	Signature chainjson.HexBytes `json:"signature"`
+}) (interface{}, error) {
+	st := &channel.State{Sequence: in.Sequence, BalanceA: in.BalanceA, BalanceB: in.BalanceB}
+	sig, err := h.channels.Accept(ctx, in.ChannelID, st, in.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"signature": chainjson.HexBytes(sig)}, nil
+}
@@ -0,0 +1,35 @@
+// Package contracttest provides helpers for testing the
+// contract packages, such as escrow and htlc.
+package contracttest
+
+import (
+	"chain/crypto/ed25519"
+	"chain/crypto/sha3pool"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+// Sign commits input 0 of tx to the whole transaction, as
+// txbuilder does for a complete transaction, and puts args
+// ahead of the signature arguments, followed by their count.
+func Sign(tx *bc.TxData, priv ed25519.PrivateKey, args [][]byte) {
+	h := bc.NewSigHasher(tx).Hash(0)
+	prog := vmutil.NewBuilder().AddData(h[:]).AddOp(vm.OP_TXSIGHASH).AddOp(vm.OP_EQUAL).Program
+	var progHash [32]byte
+	sha3pool.Sum256(progHash[:], prog)
+	sig := ed25519.Sign(priv, progHash[:])
+
+	witness := append([][]byte(nil), args...)
+	witness = append(witness, vm.Int64Bytes(int64(len(args))), sig, prog)
+	tx.Inputs[0].SetArguments(witness)
+}
+
+// Ints encodes ns as VM numbers, for use as arguments to Sign.
+func Ints(ns ...int64) [][]byte {
+	var args [][]byte
+	for _, n := range ns {
+		args = append(args, vm.Int64Bytes(n))
+	}
+	return args
+}
@@ -0,0 +1,102 @@
+package contracttest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"chain/core/account"
+	"chain/core/asset"
+	"chain/core/coretest"
+	"chain/core/query"
+	"chain/core/txbuilder"
+	"chain/database/pg"
+	"chain/database/pg/pgtest"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+// Core is a core on a fresh test database that indexes
+// each block as cored does, so contract outputs can be
+// found by their reference data once they land.
+// All of its keys are testutil.TestXPub.
+type Core struct {
+	DB       pg.DB
+	Chain    *protocol.Chain
+	Assets   *asset.Registry
+	Accounts *account.Manager
+	Indexer  *query.Indexer
+}
+
+func NewCore(t testing.TB) *Core {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t)
+	assets := asset.NewRegistry(db, c)
+	accounts := account.NewManager(db, c)
+	indexer := query.NewIndexer(db, c)
+	assets.IndexAssets(indexer)
+	accounts.IndexAccounts(indexer)
+	indexer.RegisterAnnotator(assets.AnnotateTxs)
+	indexer.RegisterAnnotator(accounts.AnnotateTxs)
+	c.AddBlockCallback(indexer.IndexTransactions)
+	return &Core{DB: db, Chain: c, Assets: assets, Accounts: accounts, Indexer: indexer}
+}
+
+// Fund creates an account holding amount of a new asset,
+// in a new block.
+func (c *Core) Fund(ctx context.Context, t testing.TB, amount uint64) (accountID string, assetID bc.AssetID) {
+	accountID = coretest.CreateAccount(ctx, t, c.Accounts, "", nil)
+	assetID = coretest.CreateAsset(ctx, t, c.Assets, nil, "", nil)
+	coretest.IssueAssets(ctx, t, c.Chain, c.Assets, c.Accounts, assetID, amount, accountID)
+	prottest.MakeBlock(t, c.Chain)
+	return accountID, assetID
+}
+
+// Program returns a new control program of an account.
+func (c *Core) Program(ctx context.Context, t testing.TB, accountID string) []byte {
+	prog, err := c.Accounts.CreateControlProgram(ctx, accountID, false)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	return prog
+}
+
+// Submit builds a transaction from actions, signs it,
+// and lands it in a new block.
+func (c *Core) Submit(ctx context.Context, t testing.TB, actions ...txbuilder.Action) *bc.Tx {
+	return c.SubmitBy(ctx, t, time.Now().Add(time.Minute), actions...)
+}
+
+// SubmitBy is like Submit, for a transaction
+// whose maxtime is maxTime.
+func (c *Core) SubmitBy(ctx context.Context, t testing.TB, maxTime time.Time, actions ...txbuilder.Action) *bc.Tx {
+	tpl, err := txbuilder.Build(ctx, nil, actions, maxTime)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	coretest.SignTxTemplate(t, ctx, tpl, nil)
+	tx := bc.NewTx(*tpl.Transaction)
+	err = txbuilder.FinalizeTx(ctx, c.Chain, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	prottest.MakeBlock(t, c.Chain)
+	return tx
+}
+
+// Action marshals v to JSON and decodes it with decode,
+// as the API decodes an action of a build request.
+func Action(t testing.TB, decode func([]byte) (txbuilder.Action, error), v interface{}) txbuilder.Action {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := decode(data)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	return a
+}
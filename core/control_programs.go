@@ -35,7 +35,7 @@ func (h *Handler) createControlProgram(ctx context.Context, ins []struct {
 			}
 			if err != nil {
 				logHTTPError(ctx, err)
-				responses[i], _ = errInfo(err)
+				responses[i], _ = errInfo(ctx, err)
 			} else {
 				responses[i] = prog
 			}
@@ -64,6 +64,10 @@ func (h *Handler) createAccountControlProgram(ctx context.Context, input []byte)
 		}
 		accountID = acc.ID
 	}
+	err = checkAccountScope(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
 
 	controlProgram, err := h.Accounts.CreateControlProgram(ctx, accountID, false)
 	if err != nil {
@@ -89,6 +89,7 @@ func (h *Handler) leaderInfo(ctx context.Context) (map[string]interface{}, error
 	var (
 		generatorHeight  *uint64
 		generatorFetched *time.Time
+		clockDriftMS     *int64
 		snapshot         = fetch.SnapshotProgress()
 		localHeight      = h.Chain.Height()
 	)
@@ -111,6 +112,11 @@ func (h *Handler) leaderInfo(ctx context.Context) (map[string]interface{}, error
 		if !fetchTime.IsZero() {
 			generatorHeight, generatorFetched = &fetchHeight, &fetchTime
 		}
+
+		if drift, measured := fetch.ClockDrift(); !measured.IsZero() {
+			ms := int64(drift / time.Millisecond)
+			clockDriftMS = &ms
+		}
 	}
 
 	buildCommit := json.RawMessage(expvar.Get("buildcommit").String())
@@ -127,6 +133,7 @@ func (h *Handler) leaderInfo(ctx context.Context) (map[string]interface{}, error
 		"block_height":                      localHeight,
 		"generator_block_height":            generatorHeight,
 		"generator_block_height_fetched_at": generatorFetched,
+		"generator_clock_drift_ms":          clockDriftMS,
 		"is_production":                     isProduction(),
 		"network_rpc_version":               networkRPCVersion,
 		"build_commit":                      &buildCommit,
@@ -28,7 +28,7 @@ func CreateAccount(ctx context.Context, t testing.TB, accounts *account.Manager,
 
 func CreateAsset(ctx context.Context, t testing.TB, assets *asset.Registry, def map[string]interface{}, alias string, tags map[string]interface{}) bc.AssetID {
 	keys := []string{testutil.TestXPub.String()}
-	asset, err := assets.Define(ctx, keys, 1, def, alias, tags, nil)
+	asset, err := assets.Define(ctx, keys, 1, def, alias, tags, nil, "", nil)
 	if err != nil {
 		testutil.FatalErr(t, err)
 	}
@@ -0,0 +1,143 @@
+package coretest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"chain/core/account"
+	"chain/core/asset"
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+// Manifest declares a set of assets, accounts, and initial
+// issuances to create. All keys are testutil.TestXPub.
+//
+// A manifest is usually read from a JSON file:
+//
+//	{
+//	  "assets": [{"alias": "gold", "definition": {"name": "Gold"}}],
+//	  "accounts": [{"alias": "alice", "tags": {"team": "ops"}}],
+//	  "issuances": [{"asset_alias": "gold", "account_alias": "alice", "amount": 100}]
+//	}
+type Manifest struct {
+	Assets []struct {
+		Alias      string                 `json:"alias"`
+		Definition map[string]interface{} `json:"definition"`
+		Tags       map[string]interface{} `json:"tags"`
+	} `json:"assets"`
+	Accounts []struct {
+		Alias string                 `json:"alias"`
+		Tags  map[string]interface{} `json:"tags"`
+	} `json:"accounts"`
+	Issuances []struct {
+		AssetAlias   string `json:"asset_alias"`
+		AccountAlias string `json:"account_alias"`
+		Amount       uint64 `json:"amount"`
+	} `json:"issuances"`
+}
+
+// Seeded holds the IDs of everything named in a Manifest,
+// keyed by alias.
+type Seeded struct {
+	AssetIDs   map[string]bc.AssetID
+	AccountIDs map[string]string
+}
+
+// LoadManifest reads a JSON manifest from the file at path.
+func LoadManifest(t testing.TB, path string) *Manifest {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	m := new(Manifest)
+	err = json.Unmarshal(b, m)
+	if err != nil {
+		testutil.FatalErr(t, errors.Wrap(err, "parsing manifest "+path))
+	}
+	return m
+}
+
+// Seed materializes m. It is idempotent: assets and accounts
+// that already exist (by alias) are reused, and each account is
+// issued only what it lacks of the total the manifest gives it
+// of each asset, so seeding the same manifest twice leaves
+// balances unchanged. Seed then makes a block, so the balances
+// are confirmed. It requires accounts to index c's blocks; see
+// account.Manager.IndexAccounts.
+func Seed(ctx context.Context, t testing.TB, c *protocol.Chain, assets *asset.Registry, accounts *account.Manager, m *Manifest) *Seeded {
+	s := &Seeded{
+		AssetIDs:   make(map[string]bc.AssetID),
+		AccountIDs: make(map[string]string),
+	}
+
+	for _, a := range m.Assets {
+		existing, err := assets.FindByAlias(ctx, a.Alias)
+		if errors.Root(err) == pg.ErrUserInputNotFound {
+			s.AssetIDs[a.Alias] = CreateAsset(ctx, t, assets, a.Definition, a.Alias, a.Tags)
+			continue
+		} else if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		s.AssetIDs[a.Alias] = existing.AssetID
+	}
+
+	for _, a := range m.Accounts {
+		existing, err := accounts.FindByAlias(ctx, a.Alias)
+		if errors.Root(err) == pg.ErrUserInputNotFound {
+			s.AccountIDs[a.Alias] = CreateAccount(ctx, t, accounts, a.Alias, a.Tags)
+			continue
+		} else if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		s.AccountIDs[a.Alias] = existing.ID
+	}
+
+	// want holds the total of each asset for each account.
+	want := make(map[string]map[bc.AssetID]uint64)
+	var accountIDs []string // in manifest order
+	for i, iss := range m.Issuances {
+		assetID, ok := s.AssetIDs[iss.AssetAlias]
+		if !ok {
+			t.Fatalf("issuance %d: asset %q is not in the manifest", i, iss.AssetAlias)
+		}
+		accountID, ok := s.AccountIDs[iss.AccountAlias]
+		if !ok {
+			t.Fatalf("issuance %d: account %q is not in the manifest", i, iss.AccountAlias)
+		}
+		if want[accountID] == nil {
+			want[accountID] = make(map[bc.AssetID]uint64)
+			accountIDs = append(accountIDs, accountID)
+		}
+		want[accountID][assetID] += iss.Amount
+	}
+
+	var issued bool
+	for _, accountID := range accountIDs {
+		balances, err := accounts.Balances(ctx, accountID)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		have := make(map[bc.AssetID]uint64)
+		for _, b := range balances {
+			have[b.AssetID] = b.Confirmed
+		}
+		for _, a := range m.Assets {
+			assetID := s.AssetIDs[a.Alias]
+			if amount := want[accountID][assetID]; amount > have[assetID] {
+				IssueAssets(ctx, t, c, assets, accounts, assetID, amount-have[assetID], accountID)
+				issued = true
+			}
+		}
+	}
+	if issued {
+		prottest.MakeBlock(t, c)
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package coretest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chain/core/account"
+	"chain/core/asset"
+	"chain/core/query"
+	"chain/database/pg/pgtest"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestSeed(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	assets := asset.NewRegistry(db, c)
+	accounts := account.NewManager(db, c)
+	accounts.IndexAccounts(query.NewIndexer(db, c))
+	m := LoadManifest(t, "testdata/manifest.json")
+
+	type balance struct {
+		account, asset string
+		want           uint64
+	}
+	check := func(s *Seeded, cases ...balance) {
+		for _, tc := range cases {
+			balances, err := accounts.Balances(ctx, s.AccountIDs[tc.account])
+			if err != nil {
+				testutil.FatalErr(t, err)
+			}
+			var got uint64
+			for _, b := range balances {
+				if b.AssetID == s.AssetIDs[tc.asset] {
+					got = b.Confirmed
+				}
+			}
+			if got != tc.want {
+				t.Errorf("%s has %d %s, want %d", tc.account, got, tc.asset, tc.want)
+			}
+		}
+	}
+
+	s1 := Seed(ctx, t, c, assets, accounts, m)
+	check(s1, balance{"alice", "gold", 120}, balance{"alice", "silver", 0}, balance{"bob", "silver", 50})
+
+	// Seeding again reuses everything and issues nothing.
+	height := c.Height()
+	s2 := Seed(ctx, t, c, assets, accounts, m)
+	if !reflect.DeepEqual(s2, s1) {
+		t.Errorf("second seed = %+v, want %+v", s2, s1)
+	}
+	if c.Height() != height {
+		t.Errorf("second seed made %d blocks, want 0", c.Height()-height)
+	}
+	check(s2, balance{"alice", "gold", 120}, balance{"alice", "silver", 0}, balance{"bob", "silver", 50})
+
+	// An issuance added to the manifest later is made,
+	// even though its asset already exists.
+	m.Issuances = append(m.Issuances, m.Issuances[2])
+	m.Issuances[3].AccountAlias = "alice"
+	Seed(ctx, t, c, assets, accounts, m)
+	check(s1, balance{"alice", "gold", 120}, balance{"alice", "silver", 50}, balance{"bob", "silver", 50})
+}
@@ -37,19 +37,24 @@ func ResetBlockchain(ctx context.Context, db pg.DB) error {
 			annotated_assets,
 			annotated_outputs,
 			annotated_txs,
+			asset_definitions,
 			asset_tags,
 			assets,
 			blocks,
 			config,
 			generator_pending_block,
+			issuance_reservations,
 			leader,
+			output_tags_version,
 			pool_txs,
 			query_blocks,
 			reservations,
+			retirements,
 			signed_blocks,
 			signers,
 			snapshots,
 			submitted_txs,
+			transaction_labels,
 			txfeeds
 			RESTART IDENTITY;
 	`
@@ -70,7 +75,7 @@ func ResetEverything(ctx context.Context, db pg.DB) error {
 		return errors.Wrap(err)
 	}
 
-	const q = `TRUNCATE mockhsm, access_tokens RESTART IDENTITY;`
+	const q = `TRUNCATE mockhsm, access_tokens, asset_definition_schemas RESTART IDENTITY;`
 	_, err = db.Exec(ctx, q)
 	return errors.Wrap(err)
 }
@@ -0,0 +1,19 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/crowdfund"
+)
+
+// getCrowdfundCampaign reports on the unspent pledges to a
+// crowdfunding campaign, and whether it is open, funded, or
+// past its deadline. Campaigns are not stored, so the request
+// gives the campaign's parameters.
+//
+// POST /get-crowdfund-campaign
+func (h *Handler) getCrowdfundCampaign(ctx context.Context, in struct {
+	Campaign crowdfund.Campaign `json:"campaign"`
+}) (*crowdfund.Status, error) {
+	return h.crowdfund.Status(ctx, &in.Campaign)
+}
@@ -0,0 +1,170 @@
+package crowdfund
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+func (m *Manager) DecodePledgeAction(data []byte) (txbuilder.Action, error) {
+	a := &pledgeAction{crowdfund: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// pledgeAction spends Amount from an account into a pledge
+// to Campaign, refundable to a new control program for the
+// same account.
+type pledgeAction struct {
+	crowdfund *Manager
+	Campaign  Campaign `json:"campaign"`
+	AccountID string   `json:"account_id"`
+	Amount    uint64   `json:"amount"`
+}
+
+func (a *pledgeAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	err := a.Campaign.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if a.Amount == 0 {
+		return nil, errors.WithDetail(txbuilder.ErrBadAmount, "pledge amount must be positive")
+	}
+	if bc.Millis(maxTime) > a.Campaign.Deadline {
+		return nil, errors.WithDetail(ErrDeadline, "pledges must land before the deadline")
+	}
+
+	amt := bc.AssetAmount{AssetID: a.Campaign.AssetID, Amount: a.Amount}
+	res, err := a.crowdfund.accounts.NewSpendAction(amt, a.AccountID, nil, nil, nil, nil).Build(ctx, maxTime)
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err := a.crowdfund.accounts.CreateControlProgram(ctx, a.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := PledgeProgram(&a.Campaign, refund)
+	if err != nil {
+		return nil, err
+	}
+	refData, err := json.Marshal(map[string]string{
+		"crowdfund_campaign":       a.Campaign.ID().String(),
+		"crowdfund_refund_program": hex.EncodeToString(refund),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(a.Campaign.AssetID, a.Amount, prog, refData))
+	return res, nil
+}
+
+func (m *Manager) DecodeClaimAction(data []byte) (txbuilder.Action, error) {
+	a := &claimAction{crowdfund: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// claimAction spends every unspent pledge to Campaign, paying
+// the target to the beneficiary in the first output and any
+// excess to the beneficiary in a second. The beneficiary
+// program must belong to an account in this core.
+type claimAction struct {
+	crowdfund *Manager
+	Campaign  Campaign `json:"campaign"`
+}
+
+func (a *claimAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	c := &a.Campaign
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if bc.Millis(maxTime) > c.Deadline {
+		return nil, errors.WithDetail(ErrDeadline, "the claim's ttl must end by the deadline")
+	}
+
+	pledges, err := a.crowdfund.pledges(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	res := new(txbuilder.BuildResult)
+	var total uint64
+	for _, p := range pledges {
+		amt := bc.AssetAmount{AssetID: c.AssetID, Amount: p.Amount}
+		sigInst, err := a.crowdfund.accounts.SigningInstruction(ctx, c.Beneficiary, amt)
+		if err != nil {
+			return nil, errors.WithDetail(err, "beneficiary program is not controlled by this core")
+		}
+		in := bc.NewSpendInput(p.TransactionID, p.Position, nil, c.AssetID, p.Amount, p.ControlProgram, nil)
+		res.Inputs = append(res.Inputs, in)
+		res.SigningInstructions = append(res.SigningInstructions, sigInst)
+		total += p.Amount
+	}
+	if total < c.Target {
+		return nil, errors.WithDetailf(ErrUnderfunded, "pledged %d of %d", total, c.Target)
+	}
+
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(c.AssetID, c.Target, c.Beneficiary, nil))
+	if total > c.Target {
+		res.Outputs = append(res.Outputs, bc.NewTxOutput(c.AssetID, total-c.Target, c.Beneficiary, nil))
+	}
+	return res, nil
+}
+
+func (m *Manager) DecodeRefundAction(data []byte) (txbuilder.Action, error) {
+	a := &refundAction{crowdfund: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// refundAction returns the pledge at TxHash and TxOut to its
+// refund program, once Campaign's deadline has passed. The
+// refund program must belong to an account in this core.
+type refundAction struct {
+	crowdfund *Manager
+	Campaign  Campaign `json:"campaign"`
+	TxHash    bc.Hash  `json:"transaction_id"`
+	TxOut     uint32   `json:"position"`
+}
+
+func (a *refundAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	c := &a.Campaign
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if bc.Millis(time.Now()) <= c.Deadline {
+		return nil, errors.WithDetail(ErrDeadline, "pledges can be refunded only after the deadline")
+	}
+
+	pledges, err := a.crowdfund.pledges(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pledges {
+		if p.TransactionID != a.TxHash || p.Position != a.TxOut {
+			continue
+		}
+		amt := bc.AssetAmount{AssetID: c.AssetID, Amount: p.Amount}
+		sigInst, err := a.crowdfund.accounts.SigningInstruction(ctx, p.RefundProgram, amt)
+		if err != nil {
+			return nil, errors.WithDetail(err, "refund program is not controlled by this core")
+		}
+		in := bc.NewSpendInput(p.TransactionID, p.Position, nil, c.AssetID, p.Amount, p.ControlProgram, nil)
+		return &txbuilder.BuildResult{
+			Inputs:              []*bc.TxInput{in},
+			Outputs:             []*bc.TxOutput{bc.NewTxOutput(c.AssetID, p.Amount, p.RefundProgram, nil)},
+			SigningInstructions: []*txbuilder.SigningInstruction{sigInst},
+			MinTimeMS:           c.Deadline + 1,
+		}, nil
+	}
+	return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "no unspent pledge to the campaign at %s:%d", a.TxHash, a.TxOut)
+}
@@ -0,0 +1,241 @@
+// Package crowdfund implements assurance contracts, in which
+// pledges toward a target amount are released to a beneficiary
+// only if the target is reached by a deadline. Otherwise each
+// pledger can reclaim their pledge after the deadline.
+package crowdfund
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"chain/core/account"
+	"chain/core/query"
+	"chain/core/query/filter"
+	"chain/crypto/sha3pool"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+var (
+	ErrBadCampaign = errors.New("invalid crowdfunding campaign")
+	ErrUnderfunded = errors.New("campaign has not reached its target")
+	ErrDeadline    = errors.New("too early or too late for campaign deadline")
+)
+
+// Campaign describes a crowdfunding campaign. A campaign is
+// identified by its parameters alone; it is not stored.
+type Campaign struct {
+	AssetID bc.AssetID `json:"asset_id"`
+	Target  uint64     `json:"target"`
+
+	// Deadline is a millisecond Unix timestamp. Claims must land
+	// by the deadline, and refunds after it.
+	Deadline uint64 `json:"deadline"`
+
+	// Beneficiary receives the target amount in a claim, and must
+	// sign the claim. It must be a multisig control program, such
+	// as one created for an account.
+	Beneficiary chainjson.HexBytes `json:"beneficiary_program"`
+}
+
+// Validate checks that c's parameters are usable.
+func (c *Campaign) Validate() error {
+	if c.Target == 0 || c.Target > math.MaxInt64 {
+		return errors.WithDetail(ErrBadCampaign, "target must be positive and at most 2^63-1")
+	}
+	if c.Deadline == 0 || c.Deadline > math.MaxInt64 {
+		return errors.WithDetail(ErrBadCampaign, "deadline must be positive and at most 2^63-1")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(c.Beneficiary); err != nil {
+		return errors.WithDetail(ErrBadCampaign, "beneficiary program must be a multisig program")
+	}
+	return nil
+}
+
+// ID returns the hash that identifies c. Pledge outputs
+// record it in their reference data.
+func (c *Campaign) ID() bc.Hash {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], c.Target)
+	binary.LittleEndian.PutUint64(buf[8:], c.Deadline)
+
+	var h bc.Hash
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(c.AssetID[:])
+	sha.Write(buf[:])
+	sha.Write(c.Beneficiary)
+	sha.Read(h[:])
+	return h
+}
+
+// PledgeProgram returns the control program for a pledge to c
+// that can be refunded to refund, a multisig control program.
+//
+// Before the deadline, the pledge can be spent only in a transaction
+// whose first output pays the target amount to the beneficiary,
+// and only with the beneficiary's signature. After the deadline,
+// it can be spent with the signature of the refund program's keys.
+func PledgeProgram(c *Campaign, refund []byte) ([]byte, error) {
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(refund); err != nil {
+		return nil, errors.WithDetail(ErrBadCampaign, "refund program must be a multisig program")
+	}
+
+	// The signature arguments for either multisig program are
+	// left on the stack for it, so each one runs unchanged.
+	claim := vmutil.NewBuilder()
+	claim.AddOp(vm.OP_MAXTIME).AddInt64(int64(c.Deadline)).AddOp(vm.OP_LESSTHANOREQUAL).AddOp(vm.OP_VERIFY)
+	claim.AddInt64(0).AddData(nil).AddInt64(int64(c.Target)).AddData(c.AssetID[:]).AddInt64(1).AddData(c.Beneficiary)
+	claim.AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	claim.AddRawBytes(c.Beneficiary)
+
+	b := vmutil.NewBuilder()
+	b.AddOp(vm.OP_MINTIME).AddInt64(int64(c.Deadline)).AddOp(vm.OP_GREATERTHAN)
+	refundAt := len(b.Program) + 5 + len(claim.Program) + 5
+	b.AddOp(vm.OP_JUMPIF).AddRawBytes(jumpAddr(refundAt))
+	b.AddRawBytes(claim.Program)
+	b.AddOp(vm.OP_JUMP).AddRawBytes(jumpAddr(refundAt + len(refund)))
+	b.AddRawBytes(refund)
+	return b.Program, nil
+}
+
+func jumpAddr(pc int) []byte {
+	var addr [4]byte
+	binary.LittleEndian.PutUint32(addr[:], uint32(pc))
+	return addr[:]
+}
+
+// Manager builds transactions for campaigns and reports
+// on their pledges.
+type Manager struct {
+	accounts *account.Manager
+	indexer  *query.Indexer
+}
+
+func NewManager(accounts *account.Manager, indexer *query.Indexer) *Manager {
+	return &Manager{accounts: accounts, indexer: indexer}
+}
+
+// Pledge is an unspent pledge output.
+type Pledge struct {
+	TransactionID  bc.Hash            `json:"transaction_id"`
+	Position       uint32             `json:"position"`
+	Amount         uint64             `json:"amount"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+	RefundProgram  chainjson.HexBytes `json:"refund_program"`
+}
+
+// Status describes the progress of a campaign.
+type Status struct {
+	ID       bc.Hash   `json:"id"`
+	Status   string    `json:"status"` // "open", "funded", or "expired"
+	Target   uint64    `json:"target"`
+	Pledged  uint64    `json:"pledged"`
+	Deadline uint64    `json:"deadline"`
+	Pledges  []*Pledge `json:"pledges"`
+}
+
+// Status reports on the confirmed, unspent pledges to c.
+// Spent pledges have been claimed or refunded, so
+// they no longer count.
+func (m *Manager) Status(ctx context.Context, c *Campaign) (*Status, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	pledges, err := m.pledges(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Status{
+		ID:       c.ID(),
+		Target:   c.Target,
+		Deadline: c.Deadline,
+		Pledges:  pledges,
+	}
+	for _, p := range pledges {
+		s.Pledged += p.Amount
+	}
+	switch {
+	case bc.Millis(time.Now()) > c.Deadline:
+		s.Status = "expired"
+	case s.Pledged >= c.Target:
+		s.Status = "funded"
+	default:
+		s.Status = "open"
+	}
+	return s, nil
+}
+
+// pledges returns the unspent outputs that pledge to c.
+// Outputs that only claim to, by their reference data, but
+// have some other control program or asset are skipped.
+func (m *Manager) pledges(ctx context.Context, c *Campaign) ([]*Pledge, error) {
+	p, err := filter.Parse("reference_data.crowdfund_campaign=$1")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	vals := []interface{}{c.ID().String()}
+	timestampMS := bc.Millis(time.Now())
+
+	const limit = 100
+	var (
+		pledges []*Pledge
+		after   *query.OutputsAfter
+	)
+	for {
+		outs, next, err := m.indexer.Outputs(ctx, p, vals, timestampMS, after, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "querying pledges")
+		}
+		for _, o := range outs {
+			raw, ok := o.(*json.RawMessage)
+			if !ok || raw == nil {
+				return nil, errors.Wrap(fmt.Errorf("unexpected %T in Indexer.Outputs output", o))
+			}
+			var out struct {
+				TransactionID  bc.Hash            `json:"transaction_id"`
+				Position       uint32             `json:"position"`
+				AssetID        bc.AssetID         `json:"asset_id"`
+				Amount         uint64             `json:"amount"`
+				ControlProgram chainjson.HexBytes `json:"control_program"`
+				ReferenceData  struct {
+					RefundProgram string `json:"crowdfund_refund_program"`
+				} `json:"reference_data"`
+			}
+			err = json.Unmarshal(*raw, &out)
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding pledge")
+			}
+			refund, err := hex.DecodeString(out.ReferenceData.RefundProgram)
+			if err != nil || out.AssetID != c.AssetID {
+				continue
+			}
+			want, err := PledgeProgram(c, refund)
+			if err != nil || !bytes.Equal(want, out.ControlProgram) {
+				continue
+			}
+			pledges = append(pledges, &Pledge{
+				TransactionID:  out.TransactionID,
+				Position:       out.Position,
+				Amount:         out.Amount,
+				ControlProgram: out.ControlProgram,
+				RefundProgram:  refund,
+			})
+		}
+		if len(outs) < limit {
+			return pledges, nil
+		}
+		after = next
+	}
+}
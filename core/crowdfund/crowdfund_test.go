@@ -0,0 +1,174 @@
+package crowdfund
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"chain/core/contracttest"
+	"chain/core/coretest"
+	"chain/core/txbuilder"
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+func TestPledgeProgram(t *testing.T) {
+	benePub, benePriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refundPub, refundPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beneProg, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{benePub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refundProg, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{refundPub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Campaign{
+		AssetID:     bc.AssetID{1},
+		Target:      100,
+		Deadline:    1000,
+		Beneficiary: beneProg,
+	}
+	err = c.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pledgeProg, err := PledgeProgram(c, refundProg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		minTime  uint64
+		maxTime  uint64
+		amount   uint64
+		outProg  []byte
+		signer   ed25519.PrivateKey
+		wantPass bool
+	}{
+		{"claim", 0, 1000, 100, beneProg, benePriv, true},
+		{"claim after deadline", 0, 1001, 100, beneProg, benePriv, false},
+		{"claim short of target", 0, 1000, 99, beneProg, benePriv, false},
+		{"claim to other program", 0, 1000, 100, refundProg, benePriv, false},
+		{"claim signed by pledger", 0, 1000, 100, beneProg, refundPriv, false},
+		{"refund", 1001, 2000, 100, refundProg, refundPriv, true},
+		{"refund signed by beneficiary", 1001, 2000, 100, refundProg, benePriv, false},
+	}
+	for _, tc := range cases {
+		tx := &bc.TxData{
+			Version: 1,
+			MinTime: tc.minTime,
+			MaxTime: tc.maxTime,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{2}, 0, nil, c.AssetID, tc.amount, pledgeProg, nil),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(c.AssetID, tc.amount, tc.outProg, nil),
+			},
+		}
+		contracttest.Sign(tx, tc.signer, nil)
+
+		ok, err := vm.VerifyTxInput(bc.NewTx(*tx), 0)
+		if ok != tc.wantPass {
+			t.Errorf("%s: got ok=%t (err %v), want %t", tc.name, ok, err, tc.wantPass)
+		}
+	}
+}
+
+func TestBuildAndStatus(t *testing.T) {
+	ctx := context.Background()
+	core := contracttest.NewCore(t)
+	m := NewManager(core.Accounts, core.Indexer)
+
+	pledger, assetID := core.Fund(ctx, t, 130)
+	bene := coretest.CreateAccount(ctx, t, core.Accounts, "", nil)
+	c := &Campaign{
+		AssetID:     assetID,
+		Target:      100,
+		Deadline:    bc.Millis(time.Now().Add(time.Hour)),
+		Beneficiary: core.Program(ctx, t, bene),
+	}
+	checkStatus := func(c *Campaign, want string, pledged uint64) {
+		st, err := m.Status(ctx, c)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if st.Status != want || st.Pledged != pledged {
+			t.Fatalf("status = %s with %d pledged, want %s with %d", st.Status, st.Pledged, want, pledged)
+		}
+	}
+	pledge := func(c *Campaign, amount uint64) txbuilder.Action {
+		return contracttest.Action(t, m.DecodePledgeAction, map[string]interface{}{
+			"campaign":   c,
+			"account_id": pledger,
+			"amount":     amount,
+		})
+	}
+	checkStatus(c, "open", 0)
+
+	core.Submit(ctx, t, pledge(c, 60))
+	checkStatus(c, "open", 60)
+
+	// A claim short of the target fails.
+	claim := contracttest.Action(t, m.DecodeClaimAction, map[string]interface{}{"campaign": c})
+	_, err := claim.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrUnderfunded {
+		t.Errorf("early claim: got error %v, want %v", err, ErrUnderfunded)
+	}
+
+	core.Submit(ctx, t, pledge(c, 50))
+	checkStatus(c, "funded", 110)
+
+	// The claim pays the target first, then the excess.
+	tx := core.Submit(ctx, t, claim)
+	var paid []uint64
+	for _, out := range tx.Outputs {
+		if !bytes.Equal(out.ControlProgram, c.Beneficiary) {
+			t.Fatalf("claim pays %x, want only the beneficiary", out.ControlProgram)
+		}
+		paid = append(paid, out.Amount)
+	}
+	if !reflect.DeepEqual(paid, []uint64{100, 10}) {
+		t.Errorf("claim paid %v, want [100 10]", paid)
+	}
+	checkStatus(c, "open", 0)
+
+	// A pledge to a campaign that misses its target is
+	// refunded once the deadline passes.
+	deadline := time.Now().Add(2 * time.Second)
+	expiring := *c
+	expiring.Deadline = bc.Millis(deadline)
+	tx = core.SubmitBy(ctx, t, deadline.Add(-time.Second), pledge(&expiring, 20))
+
+	refund := contracttest.Action(t, m.DecodeRefundAction, map[string]interface{}{
+		"campaign":       &expiring,
+		"transaction_id": tx.Hash,
+		"position":       len(tx.Outputs) - 1,
+	})
+	_, err = refund.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrDeadline {
+		t.Errorf("early refund: got error %v, want %v", err, ErrDeadline)
+	}
+
+	time.Sleep(deadline.Sub(time.Now()) + 10*time.Millisecond)
+	checkStatus(&expiring, "expired", 20)
+	tx = core.Submit(ctx, t, refund)
+	if len(tx.Outputs) != 1 || tx.Outputs[0].Amount != 20 {
+		t.Fatalf("refund outputs = %+v, want one of 20", tx.Outputs)
+	}
+	checkStatus(&expiring, "expired", 0)
+}
@@ -7,14 +7,25 @@ import (
 	"chain/core/account"
 	"chain/core/account/utxodb"
 	"chain/core/asset"
+	"chain/core/auction"
+	"chain/core/blocklist"
 	"chain/core/blocksigner"
+	"chain/core/channel"
+	"chain/core/crowdfund"
+	"chain/core/escrow"
+	"chain/core/forward"
+	"chain/core/htlc"
+	"chain/core/iso20022"
 	"chain/core/mockhsm"
 	"chain/core/query"
 	"chain/core/query/filter"
 	"chain/core/rpc"
 	"chain/core/signers"
+	"chain/core/subscription"
+	"chain/core/tier"
 	"chain/core/txbuilder"
 	"chain/core/txfeed"
+	"chain/core/voting"
 	"chain/database/pg"
 	"chain/errors"
 	"chain/net/http/httpjson"
@@ -59,10 +70,13 @@ var (
 		errRateLimited:               errorInfo{429, "CH007", "Request limit exceeded"},
 		errLeaderElection:            errorInfo{503, "CH008", "Electing a new leader for the core; try again soon"},
 		errNotAuthenticated:          errorInfo{401, "CH009", "Request could not be authenticated"},
+		errAccountScope:              errorInfo{403, "CH010", "Access token is limited to one account"},
 		asset.ErrDuplicateAlias:      errorInfo{400, "CH050", "Alias already exists"},
 		account.ErrDuplicateAlias:    errorInfo{400, "CH050", "Alias already exists"},
 		txfeed.ErrDuplicateAlias:     errorInfo{400, "CH050", "Alias already exists"},
 		mockhsm.ErrDuplicateKeyAlias: errorInfo{400, "CH050", "Alias already exists"},
+		account.ErrRestoreWindow:     errorInfo{400, "CH051", "Restore window has passed"},
+		asset.ErrRestoreWindow:       errorInfo{400, "CH051", "Restore window has passed"},
 
 		// Core error namespace
 		errUnconfigured:                errorInfo{400, "CH100", "This core still needs to be configured"},
@@ -95,6 +109,7 @@ var (
 		query.ErrBadAfter:               errorInfo{400, "CH600", "Malformed pagination parameter `after`"},
 		query.ErrParameterCountMismatch: errorInfo{400, "CH601", "Incorrect number of parameters to filter"},
 		filter.ErrBadFilter:             errorInfo{400, "CH602", "Malformed query filter"},
+		query.ErrPruned:                 errorInfo{400, "CH603", "Output history before the retention period has been pruned"},
 
 		// Transaction error namespace (7xx)
 		// Build error namespace (70x)
@@ -104,6 +119,10 @@ var (
 		errBadAction:            errorInfo{400, "CH703", "Invalid action object"},
 		txbuilder.ErrBadAmount:  errorInfo{400, "CH704", "Invalid asset amount"},
 		txbuilder.ErrBlankCheck: errorInfo{400, "CH705", "Unsafe transaction: leaves assets to be taken without requiring payment"},
+		iso20022.ErrBadMessage:  errorInfo{400, "CH706", "Invalid ISO 20022 payment message"},
+		errBadEndToEndID:        errorInfo{400, "CH707", "End-to-end ID does not match transaction reference data"},
+		txbuilder.ErrDustOutput: errorInfo{400, "CH708", "Output amount is below the asset's minimum"},
+		errBadFee:               errorInfo{400, "CH709", "Invalid fee"},
 
 		// Submit error namespace (73x)
 		txbuilder.ErrMissingRawTx:          errorInfo{400, "CH730", "Missing raw transaction"},
@@ -112,14 +131,79 @@ var (
 		txbuilder.ErrBadWitnessComponent:   errorInfo{400, "CH733", "Invalid witness component"},
 		txbuilder.ErrRejected:              errorInfo{400, "CH735", "Transaction rejected"},
 		txbuilder.ErrNoTxSighashCommitment: errorInfo{400, "CH736", "Transaction is not final, additional actions still allowed"},
+		txbuilder.ErrBadSigHashMode:        errorInfo{400, "CH737", "Invalid sighash mode"},
+		txbuilder.ErrInsufficientFee:       errorInfo{400, "CH738", "Transaction fee is below the minimum"},
+		errBadMinTime:                      errorInfo{400, "CH739", "Min time is too far in the future"},
+
+		// voting action error namespace (71x)
+		voting.ErrBadBallot: errorInfo{400, "CH710", "Invalid ballot"},
+		voting.ErrIssued:    errorInfo{400, "CH711", "Voting rights for this ballot were already issued"},
+		voting.ErrDeadline:  errorInfo{400, "CH712", "Action is not allowed after the ballot deadline"},
+
+		// htlc action error namespace (72x)
+		htlc.ErrBadHTLC:    errorInfo{400, "CH720", "Invalid HTLC"},
+		htlc.ErrNotFunded:  errorInfo{400, "CH721", "HTLC has no contract outputs"},
+		htlc.ErrBadSecret:  errorInfo{400, "CH722", "Secret does not match the HTLC hash"},
+		htlc.ErrExpired:    errorInfo{400, "CH723", "HTLC has timed out"},
+		htlc.ErrNotExpired: errorInfo{400, "CH724", "HTLC has not timed out"},
+
+		// escrow action error namespace (74x)
+		escrow.ErrBadEscrow: errorInfo{400, "CH740", "Invalid escrow"},
+		escrow.ErrNotFunded: errorInfo{400, "CH741", "Escrow has no contract outputs"},
+
+		// auction action error namespace (75x)
+		auction.ErrBadAuction: errorInfo{400, "CH750", "Invalid auction"},
+		auction.ErrNotFunded:  errorInfo{400, "CH751", "Auction is not open"},
 
 		// account action error namespace (76x)
-		utxodb.ErrInsufficient: errorInfo{400, "CH760", "Insufficient funds for tx"},
-		utxodb.ErrReserved:     errorInfo{400, "CH761", "Some outputs are reserved; try again"},
+		utxodb.ErrInsufficient:          errorInfo{400, "CH760", "Insufficient funds for tx"},
+		utxodb.ErrReserved:              errorInfo{400, "CH761", "Some outputs are reserved; try again"},
+		utxodb.ErrUnconfirmed:           errorInfo{400, "CH762", "Output is not yet confirmed"},
+		utxodb.ErrLocked:                errorInfo{400, "CH763", "Output is time-locked"},
+		account.ErrBadUnlockTime:        errorInfo{400, "CH764", "Invalid unlock time"},
+		account.ErrNothingToConsolidate: errorInfo{400, "CH765", "Too few outputs to consolidate"},
+		account.ErrProgramReused:        errorInfo{400, "CH766", "Control program has already been used"},
+		utxodb.ErrWrongAccount:          errorInfo{400, "CH767", "Output belongs to another account"},
+
+		// payment channel error namespace (77x)
+		channel.ErrBadChannel: errorInfo{400, "CH770", "Invalid payment channel"},
+		channel.ErrNotFunded:  errorInfo{400, "CH771", "Payment channel has no contract output"},
+		channel.ErrBadState:   errorInfo{400, "CH772", "Invalid payment channel state"},
+		channel.ErrDispute:    errorInfo{400, "CH773", "Action is not allowed at this time relative to the dispute window"},
+
+		// crowdfunding action error namespace (78x)
+		crowdfund.ErrBadCampaign: errorInfo{400, "CH780", "Invalid crowdfunding campaign"},
+		crowdfund.ErrUnderfunded: errorInfo{400, "CH781", "Campaign has not reached its target"},
+		crowdfund.ErrDeadline:    errorInfo{400, "CH782", "Action is not allowed at this time relative to the campaign deadline"},
+
+		// subscription action error namespace (79x)
+		subscription.ErrBadSubscription: errorInfo{400, "CH790", "Invalid subscription"},
+		subscription.ErrNotFunded:       errorInfo{400, "CH791", "Subscription has no funded contract"},
+		subscription.ErrPeriod:          errorInfo{400, "CH792", "Billing period has not started"},
 
 		// Mock HSM error namespace (80x)
 		mockhsm.ErrInvalidAfter:         errorInfo{400, "CH801", "Invalid `after` in query"},
 		mockhsm.ErrTooManyAliasesToList: errorInfo{400, "CH802", "Too many aliases to list"},
+
+		// forwarding error namespace (81x)
+		forward.ErrBadRule: errorInfo{400, "CH810", "Invalid forwarding rule"},
+
+		// asset issuance policy and definition schema error namespace (82x)
+		asset.ErrBadIssuancePolicy: errorInfo{400, "CH820", "Invalid issuance policy"},
+		asset.ErrIssuanceCap:       errorInfo{400, "CH821", "Issuance would exceed the asset's issuance policy"},
+		asset.ErrBadSchema:         errorInfo{400, "CH822", "Invalid asset definition schema"},
+		asset.ErrNonconforming:     errorInfo{400, "CH823", "Asset definition does not conform to its schema"},
+
+		// wallet tier error namespace (83x)
+		tier.ErrBadTier: errorInfo{400, "CH830", "Invalid wallet tier"},
+
+		// blocklist error namespace (84x)
+		blocklist.ErrBlocked:   errorInfo{400, "CH840", "Transaction spends a blocked output"},
+		blocklist.ErrBadUpdate: errorInfo{400, "CH841", "Invalid blocklist update"},
+
+		// receiver error namespace (85x)
+		txbuilder.ErrBadReceiver:     errorInfo{400, "CH850", "Invalid receiver"},
+		txbuilder.ErrReceiverExpired: errorInfo{400, "CH851", "Receiver has expired"},
 	}
 )
 
@@ -127,7 +211,8 @@ var (
 // and a suitable response body describing err
 // by consulting the global lookup table.
 // If no entry is found, it returns infoInternal.
-func errInfo(err error) (body detailedError, info errorInfo) {
+// The body's message is in the locale of ctx.
+func errInfo(ctx context.Context, err error) (body detailedError, info errorInfo) {
 	root := errors.Root(err)
 	// Some types cannot be used as map keys, for example slices.
 	// If an error's underlying type is one of these, don't panic.
@@ -137,6 +222,9 @@ func errInfo(err error) (body detailedError, info errorInfo) {
 			info = infoInternal
 			body = detailedError{infoInternal, "", nil, true}
 		}
+		// Only the message sent to the client is translated;
+		// info, used for logging, stays in English.
+		body.Message = localizeMessage(ctx, body.ChainCode, body.Message)
 	}()
 	info, ok := errorInfoTab[root]
 	if !ok {
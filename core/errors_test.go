@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -21,7 +22,7 @@ func TestErrInfo(t *testing.T) {
 	}
 
 	for _, test := range cases {
-		_, info := errInfo(test.err)
+		_, info := errInfo(context.Background(), test.err)
 		got := info.HTTPStatus
 		if got != test.want {
 			t.Errorf("errInfo(%#v) = %d want %d", test.err, got, test.want)
@@ -0,0 +1,18 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/escrow"
+)
+
+// getEscrow reports on the unspent contract outputs of an
+// escrow. Escrows are not stored, so the request gives the
+// escrow's parameters.
+//
+// POST /get-escrow
+func (h *Handler) getEscrow(ctx context.Context, in struct {
+	Escrow escrow.Escrow `json:"escrow"`
+}) (*escrow.Status, error) {
+	return h.escrow.Status(ctx, &in.Escrow)
+}
@@ -0,0 +1,127 @@
+package escrow
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func (m *Manager) DecodeEscrowAction(data []byte) (txbuilder.Action, error) {
+	a := &escrowAction{escrow: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// escrowAction spends Amount from an account into
+// a contract output of Escrow.
+type escrowAction struct {
+	escrow    *Manager
+	Escrow    Escrow `json:"escrow"`
+	AccountID string `json:"account_id"`
+	Amount    uint64 `json:"amount"`
+}
+
+func (a *escrowAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	err := a.Escrow.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if a.Amount == 0 {
+		return nil, errors.WithDetail(txbuilder.ErrBadAmount, "escrow amount must be positive")
+	}
+
+	amt := bc.AssetAmount{AssetID: a.Escrow.AssetID, Amount: a.Amount}
+	res, err := a.escrow.accounts.NewSpendAction(amt, a.AccountID, nil, nil, nil, nil).Build(ctx, maxTime)
+	if err != nil {
+		return nil, err
+	}
+	refData, err := json.Marshal(map[string]string{"escrow": a.Escrow.ID().String()})
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(a.Escrow.AssetID, a.Amount, a.Escrow.Program(), refData))
+	return res, nil
+}
+
+func (m *Manager) DecodeReleaseAction(data []byte) (txbuilder.Action, error) {
+	a := &settleAction{escrow: m, clause: clauseRelease}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+func (m *Manager) DecodeRefundAction(data []byte) (txbuilder.Action, error) {
+	a := &settleAction{escrow: m, clause: clauseRefund}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// settleAction spends every unspent contract output of Escrow,
+// paying each to the seller for a release or to the buyer for
+// a refund, in the output at the same position as its input.
+// It must be the first action of its transaction.
+//
+// It is signed by the party giving up the funds if that party's
+// program belongs to an account in this core, and otherwise
+// by the arbiter, whose program must.
+type settleAction struct {
+	escrow *Manager
+	clause int64
+	Escrow Escrow `json:"escrow"`
+}
+
+func (a *settleAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	e := &a.Escrow
+	err := e.Validate()
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := a.escrow.contracts(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	if len(contracts) == 0 {
+		return nil, errors.WithDetailf(ErrNotFunded, "escrow %s", e.ID())
+	}
+
+	party, payee := e.Buyer, e.Seller
+	if a.clause == clauseRefund {
+		party, payee = e.Seller, e.Buyer
+	}
+	prog := e.Program()
+	res := new(txbuilder.BuildResult)
+	for _, c := range contracts {
+		amt := bc.AssetAmount{AssetID: e.AssetID, Amount: c.Amount}
+		signer := int64(signerParty)
+		sigInst, err := a.escrow.accounts.SigningInstruction(ctx, party, amt)
+		if errors.Root(err) == pg.ErrUserInputNotFound {
+			signer = signerArbiter
+			sigInst, err = a.escrow.accounts.SigningInstruction(ctx, e.Arbiter, amt)
+			if errors.Root(err) == pg.ErrUserInputNotFound {
+				err = errors.WithDetail(err, "neither the party's program nor the arbiter program is controlled by this core")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// The contract finds its arguments at the bottom of
+		// the stack, below those of the signer's program.
+		sig := sigInst.WitnessComponents
+		sigInst.WitnessComponents = nil
+		sigInst.AddDataWitness(vm.Int64Bytes(a.clause))
+		sigInst.AddDataWitness(vm.Int64Bytes(signer))
+		sigInst.WitnessComponents = append(sigInst.WitnessComponents, sig...)
+
+		in := bc.NewSpendInput(c.TransactionID, c.Position, nil, e.AssetID, c.Amount, prog, nil)
+		res.Inputs = append(res.Inputs, in)
+		res.SigningInstructions = append(res.SigningInstructions, sigInst)
+		res.Outputs = append(res.Outputs, bc.NewTxOutput(e.AssetID, c.Amount, payee, nil))
+	}
+	return res, nil
+}
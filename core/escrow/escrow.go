@@ -0,0 +1,223 @@
+// Package escrow implements two-party escrow with an arbiter. A
+// buyer locks funds in a contract output, which can be released to
+// the seller or refunded to the buyer. Either party can concede to
+// the other, the buyer by releasing and the seller by refunding,
+// and the arbiter can decide either way.
+package escrow
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"chain/core/account"
+	"chain/core/query"
+	"chain/core/query/filter"
+	"chain/crypto/sha3pool"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+var (
+	ErrBadEscrow = errors.New("invalid escrow")
+	ErrNotFunded = errors.New("escrow has no contract outputs")
+)
+
+// Clause selectors, the first witness argument to a contract.
+const (
+	clauseRelease = 0
+	clauseRefund  = 1
+)
+
+// Signer selectors, the second witness argument to a contract.
+const (
+	signerParty   = 0
+	signerArbiter = 1
+)
+
+// Escrow describes an escrow. An escrow is identified
+// by its parameters alone; it is not stored.
+type Escrow struct {
+	AssetID bc.AssetID `json:"asset_id"`
+
+	// Buyer receives refunds and Seller receives releases. The
+	// buyer signs releases and the seller signs refunds, unless
+	// Arbiter signs instead. All three must be multisig control
+	// programs, such as ones created for accounts.
+	Buyer   chainjson.HexBytes `json:"buyer_program"`
+	Seller  chainjson.HexBytes `json:"seller_program"`
+	Arbiter chainjson.HexBytes `json:"arbiter_program"`
+}
+
+// Validate checks that e's parameters are usable.
+func (e *Escrow) Validate() error {
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(e.Buyer); err != nil {
+		return errors.WithDetail(ErrBadEscrow, "buyer program must be a multisig program")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(e.Seller); err != nil {
+		return errors.WithDetail(ErrBadEscrow, "seller program must be a multisig program")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(e.Arbiter); err != nil {
+		return errors.WithDetail(ErrBadEscrow, "arbiter program must be a multisig program")
+	}
+	return nil
+}
+
+// ID returns the hash that identifies e. Contract outputs
+// record it in their reference data.
+func (e *Escrow) ID() bc.Hash {
+	var h bc.Hash
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(e.AssetID[:])
+	for _, prog := range [][]byte{e.Buyer, e.Seller, e.Arbiter} {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(prog)))
+		sha.Write(n[:])
+		sha.Write(prog)
+	}
+	sha.Read(h[:])
+	return h
+}
+
+// Program returns the control program for e's contract outputs.
+//
+// Its witness arguments are a clause selector, a signer selector,
+// and the arguments to the signer's program. A release pays the
+// whole contract to the seller, and a refund to the buyer, in the
+// output at the input's index. The signer is the party who gives
+// up the funds, or the arbiter.
+func (e *Escrow) Program() []byte {
+	a := vmutil.NewAssembler()
+	a.AddOp(vm.OP_DEPTH).AddInt64(1).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the clause
+	a.Jump(vm.OP_JUMPIF, "refund")
+
+	// release
+	a.AddOp(vm.OP_INDEX).AddData(nil).AddOp(vm.OP_AMOUNT)
+	a.AddData(e.AssetID[:]).AddInt64(1).AddData(e.Seller).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_DEPTH).AddInt64(2).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the signer
+	a.Jump(vm.OP_JUMPIF, "arbiter")
+	a.AddRawBytes(e.Buyer)
+	a.Jump(vm.OP_JUMP, "end")
+
+	a.Label("refund")
+	a.AddOp(vm.OP_INDEX).AddData(nil).AddOp(vm.OP_AMOUNT)
+	a.AddData(e.AssetID[:]).AddInt64(1).AddData(e.Buyer).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_DEPTH).AddInt64(2).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the signer
+	a.Jump(vm.OP_JUMPIF, "arbiter")
+	a.AddRawBytes(e.Seller)
+	a.Jump(vm.OP_JUMP, "end")
+
+	a.Label("arbiter")
+	a.AddRawBytes(e.Arbiter)
+	a.Label("end")
+	return a.Build()
+}
+
+// Manager builds transactions for escrows and reports
+// on their contracts.
+type Manager struct {
+	accounts *account.Manager
+	indexer  *query.Indexer
+}
+
+func NewManager(accounts *account.Manager, indexer *query.Indexer) *Manager {
+	return &Manager{accounts: accounts, indexer: indexer}
+}
+
+// Contract is an unspent contract output of an escrow.
+type Contract struct {
+	TransactionID bc.Hash `json:"transaction_id"`
+	Position      uint32  `json:"position"`
+	Amount        uint64  `json:"amount"`
+}
+
+// Status describes the funds held by an escrow.
+type Status struct {
+	ID        bc.Hash     `json:"id"`
+	Status    string      `json:"status"` // "funded" or "unfunded"
+	Amount    uint64      `json:"amount"`
+	Contracts []*Contract `json:"contracts"`
+}
+
+// Status reports on the confirmed, unspent contract outputs
+// of e. Once released or refunded, an escrow is unfunded again.
+func (m *Manager) Status(ctx context.Context, e *Escrow) (*Status, error) {
+	err := e.Validate()
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := m.contracts(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	s := &Status{ID: e.ID(), Status: "unfunded", Contracts: contracts}
+	for _, c := range contracts {
+		s.Amount += c.Amount
+	}
+	if len(contracts) > 0 {
+		s.Status = "funded"
+	}
+	return s, nil
+}
+
+// contracts returns the unspent contract outputs of e.
+// Outputs that only claim to belong to e, by their reference
+// data, but have some other control program or asset are
+// skipped.
+func (m *Manager) contracts(ctx context.Context, e *Escrow) ([]*Contract, error) {
+	p, err := filter.Parse("reference_data.escrow=$1")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	vals := []interface{}{e.ID().String()}
+	timestampMS := bc.Millis(time.Now())
+	prog := e.Program()
+
+	const limit = 100
+	var (
+		contracts = []*Contract{}
+		after     *query.OutputsAfter
+	)
+	for {
+		outs, next, err := m.indexer.Outputs(ctx, p, vals, timestampMS, after, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "querying escrow contracts")
+		}
+		for _, o := range outs {
+			raw, ok := o.(*json.RawMessage)
+			if !ok || raw == nil {
+				return nil, errors.Wrap(fmt.Errorf("unexpected %T in Indexer.Outputs output", o))
+			}
+			var out struct {
+				TransactionID  bc.Hash            `json:"transaction_id"`
+				Position       uint32             `json:"position"`
+				AssetID        bc.AssetID         `json:"asset_id"`
+				Amount         uint64             `json:"amount"`
+				ControlProgram chainjson.HexBytes `json:"control_program"`
+			}
+			err = json.Unmarshal(*raw, &out)
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding escrow contract")
+			}
+			if out.AssetID != e.AssetID || !bytes.Equal(out.ControlProgram, prog) {
+				continue
+			}
+			contracts = append(contracts, &Contract{
+				TransactionID: out.TransactionID,
+				Position:      out.Position,
+				Amount:        out.Amount,
+			})
+		}
+		if len(outs) < limit {
+			return contracts, nil
+		}
+		after = next
+	}
+}
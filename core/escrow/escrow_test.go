@@ -0,0 +1,182 @@
+package escrow
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"chain/core/contracttest"
+	"chain/core/coretest"
+	"chain/core/txbuilder"
+	"chain/crypto/ed25519"
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+func TestProgram(t *testing.T) {
+	var (
+		progs [3][]byte
+		privs [3]ed25519.PrivateKey
+	)
+	for i := range progs {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		progs[i], err = vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pub}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+	}
+	buyer, seller, arbiter := privs[0], privs[1], privs[2]
+
+	e := &Escrow{AssetID: bc.AssetID{1}, Buyer: progs[0], Seller: progs[1], Arbiter: progs[2]}
+	err := e.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pay := func(prog []byte) []*bc.TxOutput {
+		return []*bc.TxOutput{bc.NewTxOutput(e.AssetID, 50, prog, nil)}
+	}
+
+	cases := []struct {
+		name     string
+		args     []int64
+		outputs  []*bc.TxOutput
+		signer   ed25519.PrivateKey
+		wantPass bool
+	}{
+		{"release by buyer", []int64{clauseRelease, signerParty}, pay(e.Seller), buyer, true},
+		{"release by arbiter", []int64{clauseRelease, signerArbiter}, pay(e.Seller), arbiter, true},
+		{"release by seller", []int64{clauseRelease, signerParty}, pay(e.Seller), seller, false},
+		{"release to buyer", []int64{clauseRelease, signerParty}, pay(e.Buyer), buyer, false},
+		{"refund by seller", []int64{clauseRefund, signerParty}, pay(e.Buyer), seller, true},
+		{"refund by arbiter", []int64{clauseRefund, signerArbiter}, pay(e.Buyer), arbiter, true},
+		{"refund by buyer", []int64{clauseRefund, signerParty}, pay(e.Buyer), buyer, false},
+		{"refund to seller", []int64{clauseRefund, signerArbiter}, pay(e.Seller), arbiter, false},
+		{"arbiter as party", []int64{clauseRelease, signerParty}, pay(e.Seller), arbiter, false},
+	}
+	for _, tc := range cases {
+		tx := &bc.TxData{
+			Version: 1,
+			MaxTime: 2000,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{2}, 0, nil, e.AssetID, 50, e.Program(), nil),
+			},
+			Outputs: tc.outputs,
+		}
+		contracttest.Sign(tx, tc.signer, contracttest.Ints(tc.args...))
+
+		ok, err := vm.VerifyTxInput(bc.NewTx(*tx), 0)
+		if ok != tc.wantPass {
+			t.Errorf("%s: got ok=%t (err %v), want %t", tc.name, ok, err, tc.wantPass)
+		}
+	}
+}
+
+func TestBuildAndStatus(t *testing.T) {
+	ctx := context.Background()
+	core := contracttest.NewCore(t)
+	m := NewManager(core.Accounts, core.Indexer)
+
+	buyer, assetID := core.Fund(ctx, t, 100)
+	seller := coretest.CreateAccount(ctx, t, core.Accounts, "", nil)
+	arbiter := coretest.CreateAccount(ctx, t, core.Accounts, "", nil)
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Escrow{
+		AssetID: assetID,
+		Buyer:   core.Program(ctx, t, buyer),
+		Seller:  core.Program(ctx, t, seller),
+		Arbiter: core.Program(ctx, t, arbiter),
+	}
+	checkStatus := func(e *Escrow, want string, amount uint64, contracts int) {
+		st, err := m.Status(ctx, e)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if st.Status != want || st.Amount != amount || len(st.Contracts) != contracts {
+			t.Fatalf("status = %s with %d in %d contracts, want %s with %d in %d",
+				st.Status, st.Amount, len(st.Contracts), want, amount, contracts)
+		}
+	}
+	fund := func(e *Escrow, amount uint64) {
+		core.Submit(ctx, t, contracttest.Action(t, m.DecodeEscrowAction, map[string]interface{}{
+			"escrow":     e,
+			"account_id": buyer,
+			"amount":     amount,
+		}))
+	}
+	release := func(e *Escrow) txbuilder.Action {
+		return contracttest.Action(t, m.DecodeReleaseAction, map[string]interface{}{"escrow": e})
+	}
+
+	// Each contract is paid out in its own output.
+	checkPaid := func(tx *bc.Tx, payee []byte, amount uint64, contracts int) {
+		if len(tx.Outputs) != contracts {
+			t.Fatalf("got %d outputs, want %d", len(tx.Outputs), contracts)
+		}
+		var sum uint64
+		for i, out := range tx.Outputs {
+			if !bytes.Equal(out.ControlProgram, payee) {
+				t.Errorf("output %d pays %x, want %x", i, out.ControlProgram, payee)
+			}
+			sum += out.Amount
+		}
+		if sum != amount {
+			t.Errorf("paid %d, want %d", sum, amount)
+		}
+	}
+
+	checkStatus(e, "unfunded", 0, 0)
+	_, err = release(e).Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrNotFunded {
+		t.Errorf("release of unfunded escrow: got error %v, want %v", err, ErrNotFunded)
+	}
+
+	fund(e, 40)
+	fund(e, 30)
+	checkStatus(e, "funded", 70, 2)
+
+	// The buyer's account signs the release.
+	tx := core.Submit(ctx, t, release(e))
+	checkPaid(tx, e.Seller, 70, 2)
+	checkStatus(e, "unfunded", 0, 0)
+
+	// A refund is signed by the seller's account.
+	fund(e, 10)
+	tx = core.Submit(ctx, t, contracttest.Action(t, m.DecodeRefundAction, map[string]interface{}{"escrow": e}))
+	checkPaid(tx, e.Buyer, 10, 1)
+	checkStatus(e, "unfunded", 0, 0)
+
+	// When the buyer is not an account of this core,
+	// the arbiter signs the release.
+	withArbiter := *e
+	withArbiter.Buyer = foreign
+	fund(&withArbiter, 10)
+	tx = core.Submit(ctx, t, release(&withArbiter))
+	checkPaid(tx, e.Seller, 10, 1)
+
+	// When neither is, the release can't be built here.
+	neither := withArbiter
+	neither.Arbiter = foreign
+	fund(&neither, 10)
+	_, err = release(&neither).Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != pg.ErrUserInputNotFound {
+		t.Errorf("release signed by neither: got error %v, want %v", err, pg.ErrUserInputNotFound)
+	}
+	checkStatus(&neither, "funded", 10, 1)
+}
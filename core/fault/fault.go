@@ -0,0 +1,187 @@
+// Package fault injects failures into a running Chain Core
+// so that clients can exercise their retry and idempotency
+// handling. It is meant for development builds only.
+//
+// Faults are attached to named points:
+//
+//	http:<path>  an API request to path, such as http:/build-transaction
+//	signer       a block signature request from the generator
+//
+// A fault may delay the operation, fail it, or both.
+// Failed API requests get the same response as an
+// unexpected internal error, such as a database failure.
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/net/http/httpjson"
+	"chain/protocol/bc"
+)
+
+// ErrInjected is returned from operations failed by an injected fault.
+var ErrInjected = errors.New("injected fault")
+
+// PointSigner is the fault point for block signature requests.
+const PointSigner = "signer"
+
+// Fault describes a failure to inject at a point.
+type Fault struct {
+	Point string             `json:"point"`
+	Delay chainjson.Duration `json:"delay"`
+	Fail  bool               `json:"fail"`
+
+	// Count is the number of times the fault will trigger
+	// before it is removed. Zero means until cleared.
+	Count int `json:"count"`
+}
+
+// Injector holds the set of active faults.
+// The zero value is an Injector with no faults.
+type Injector struct {
+	mu     sync.Mutex
+	faults map[string]*Fault
+}
+
+// Set adds f, replacing any fault already at f.Point.
+func (inj *Injector) Set(f Fault) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if inj.faults == nil {
+		inj.faults = make(map[string]*Fault)
+	}
+	inj.faults[f.Point] = &f
+}
+
+// Clear removes the fault at point.
+// If point is empty, it removes all faults.
+func (inj *Injector) Clear(point string) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if point == "" {
+		inj.faults = nil
+		return
+	}
+	delete(inj.faults, point)
+}
+
+// List returns the active faults, ordered by point.
+func (inj *Injector) List() []Fault {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	var points []string
+	for p := range inj.faults {
+		points = append(points, p)
+	}
+	sort.Strings(points)
+	a := make([]Fault, 0, len(points))
+	for _, p := range points {
+		a = append(a, *inj.faults[p])
+	}
+	return a
+}
+
+// Trigger applies the fault at point, if any.
+// It waits out the fault's delay, or until ctx is done,
+// then returns ErrInjected if the fault fails operations.
+func (inj *Injector) Trigger(ctx context.Context, point string) error {
+	inj.mu.Lock()
+	f, ok := inj.faults[point]
+	var fault Fault
+	if ok {
+		fault = *f
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				delete(inj.faults, point)
+			}
+		}
+	}
+	inj.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if fault.Delay.Duration > 0 {
+		select {
+		case <-time.After(fault.Delay.Duration):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if fault.Fail {
+		return errors.WithDetailf(ErrInjected, "at %s", point)
+	}
+	return nil
+}
+
+// Handler wraps next, triggering the http:<path> fault
+// for each request. Failed requests are reported with writeErr.
+func (inj *Injector) Handler(next http.Handler, writeErr func(context.Context, http.ResponseWriter, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		err := inj.Trigger(ctx, "http:"+req.URL.Path)
+		if err != nil {
+			writeErr(ctx, w, err)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// ServeHTTP implements the admin API.
+// GET lists the active faults, POST sets the fault
+// in the request body, and DELETE clears the fault
+// named by the "point" query parameter (or all faults).
+func (inj *Injector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	switch req.Method {
+	case "GET":
+	case "POST":
+		var f Fault
+		err := json.NewDecoder(req.Body).Decode(&f)
+		if err != nil || f.Point == "" {
+			http.Error(w, "request body must be a fault object with a point", http.StatusBadRequest)
+			return
+		}
+		inj.Set(f)
+	case "DELETE":
+		inj.Clear(req.URL.Query().Get("point"))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	httpjson.Write(ctx, w, http.StatusOK, inj.List())
+}
+
+// BlockSigner signs blocks.
+// It is satisfied by generator.BlockSigner.
+type BlockSigner interface {
+	SignBlock(context.Context, *bc.Block) ([]byte, error)
+}
+
+// BlockSigner returns a BlockSigner that triggers
+// the signer fault before delegating to s.
+func (inj *Injector) BlockSigner(s BlockSigner) BlockSigner {
+	return &blockSigner{inj: inj, s: s}
+}
+
+type blockSigner struct {
+	inj *Injector
+	s   BlockSigner
+}
+
+func (b *blockSigner) SignBlock(ctx context.Context, block *bc.Block) ([]byte, error) {
+	err := b.inj.Trigger(ctx, PointSigner)
+	if err != nil {
+		return nil, err
+	}
+	return b.s.SignBlock(ctx, block)
+}
@@ -0,0 +1,72 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	chainjson "chain/encoding/json"
+	"chain/errors"
+)
+
+func TestTriggerCount(t *testing.T) {
+	ctx := context.Background()
+	var inj Injector
+	inj.Set(Fault{Point: "p", Fail: true, Count: 2})
+
+	for i := 0; i < 2; i++ {
+		err := inj.Trigger(ctx, "p")
+		if errors.Root(err) != ErrInjected {
+			t.Fatalf("trigger %d: err = %v want %v", i, err, ErrInjected)
+		}
+	}
+	err := inj.Trigger(ctx, "p")
+	if err != nil {
+		t.Fatalf("after count exhausted: err = %v want nil", err)
+	}
+	if n := len(inj.List()); n != 0 {
+		t.Errorf("len(List()) = %d want 0", n)
+	}
+}
+
+func TestTriggerDelay(t *testing.T) {
+	var inj Injector
+	inj.Set(Fault{Point: "p", Delay: chainjson.Duration{Duration: time.Hour}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := inj.Trigger(ctx, "p")
+	if err != context.DeadlineExceeded {
+		t.Errorf("err = %v want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var inj Injector
+	inj.Set(Fault{Point: "http:/fail", Fail: true})
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	writeErr := func(ctx context.Context, w http.ResponseWriter, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	h := inj.Handler(next, writeErr)
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/fail", http.StatusInternalServerError},
+		{"/ok", http.StatusNoContent},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", c.path, nil))
+		if rec.Code != c.want {
+			t.Errorf("%s: status = %d want %d", c.path, rec.Code, c.want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"math"
 	"math/rand"
 	"net"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,6 +30,10 @@ var (
 	generatorHeightFetchedAt time.Time
 	generatorLock            sync.Mutex
 
+	clockDrift           time.Duration
+	clockDriftMeasuredAt time.Time
+	clockDriftLock       sync.Mutex
+
 	downloadingSnapshot   *Snapshot
 	downloadingSnapshotMu sync.Mutex
 )
@@ -41,6 +46,44 @@ func GeneratorHeight() (uint64, time.Time) {
 	return h, t
 }
 
+// ClockDrift returns how far the generator's clock was ahead of
+// the local clock, as measured by the latest block fetched at
+// the generator's height, and when that block was fetched.
+// A block can show only that the generator's clock is ahead:
+// one behind the local clock may just be old, since the
+// generator makes no blocks while it has no transactions.
+// So the drift is never negative. Network delay makes it
+// look smaller than it is.
+func ClockDrift() (time.Duration, time.Time) {
+	clockDriftLock.Lock()
+	defer clockDriftLock.Unlock()
+	return clockDrift, clockDriftMeasuredAt
+}
+
+// checkClockDrift records the clock drift shown by b, a block
+// just fetched, if it is the generator's latest block. Older
+// blocks say nothing about the generator's clock now. It returns
+// an error if b is too far ahead of the local clock.
+func checkClockDrift(ctx context.Context, b *bc.Block) error {
+	if gh, _ := GeneratorHeight(); b.Height < gh {
+		return nil
+	}
+	now := time.Now()
+	drift := b.Time().Sub(now)
+	if drift < 0 {
+		drift = 0
+	}
+	clockDriftLock.Lock()
+	clockDrift, clockDriftMeasuredAt = drift, now
+	clockDriftLock.Unlock()
+
+	err := protocol.CheckBlockTime(b, now)
+	if err != nil {
+		log.Error(ctx, err)
+	}
+	return err
+}
+
 func SnapshotProgress() *Snapshot {
 	downloadingSnapshotMu.Lock()
 	defer downloadingSnapshotMu.Unlock()
@@ -89,6 +132,7 @@ func Fetch(ctx context.Context, c *protocol.Chain, peer *rpc.Client, health func
 	}
 
 	blockch, errch := DownloadBlocks(ctx, peer, height+1)
+	validatedch := prevalidateBlocks(ctx, c, blockch)
 
 	var nfailures uint
 	for {
@@ -99,7 +143,7 @@ func Fetch(ctx context.Context, c *protocol.Chain, peer *rpc.Client, health func
 		case err = <-errch:
 			health(err)
 			logNetworkError(ctx, err)
-		case b := <-blockch:
+		case b := <-validatedch:
 			for {
 				prevSnapshot, prevBlock, err = applyBlock(ctx, c, prevSnapshot, prevBlock, b)
 				if err == protocol.ErrBadBlock {
@@ -117,7 +161,7 @@ func Fetch(ctx context.Context, c *protocol.Chain, peer *rpc.Client, health func
 			}
 
 			height++
-			health(nil)
+			health(checkClockDrift(ctx, b))
 			nfailures = 0
 		}
 	}
@@ -130,6 +174,10 @@ func Fetch(ctx context.Context, c *protocol.Chain, peer *rpc.Client, health func
 // and the other for reading errors. Progress will halt unless callers are
 // reading from both. DownloadBlocks will continue even if it encounters errors,
 // until its context is done.
+//
+// When the generator is known to be more than one block ahead,
+// up to downloadWindow blocks are requested at once. Blocks are
+// still delivered in order.
 func DownloadBlocks(ctx context.Context, peer *rpc.Client, height uint64) (chan *bc.Block, chan error) {
 	blockch := make(chan *bc.Block)
 	errch := make(chan error)
@@ -143,14 +191,18 @@ func DownloadBlocks(ctx context.Context, peer *rpc.Client, height uint64) (chan
 				close(errch)
 				return
 			default:
-				block, err := getBlock(ctx, peer, height, timeoutBackoffDur(ntimeouts))
+				blocks, err := getBlocks(ctx, peer, height, downloadCount(height), timeoutBackoffDur(ntimeouts))
+				for _, block := range blocks {
+					blockch <- block
+					height++
+				}
 				if err != nil {
 					errch <- err
 					nfailures++
 					time.Sleep(backoffDur(nfailures))
 					continue
 				}
-				if block == nil {
+				if len(blocks) == 0 {
 					// Request time out. There might not have been any blocks published,
 					// or there was a network error or it just took too long to process the
 					// request.
@@ -158,15 +210,106 @@ func DownloadBlocks(ctx context.Context, peer *rpc.Client, height uint64) (chan
 					continue
 				}
 
-				blockch <- block
 				ntimeouts, nfailures = 0, 0
-				height++
 			}
 		}
 	}()
 	return blockch, errch
 }
 
+// downloadWindow is the most blocks DownloadBlocks
+// requests at once.
+const downloadWindow = 8
+
+// downloadCount returns how many blocks to request starting
+// at height, based on the last known generator height.
+func downloadCount(height uint64) int {
+	gh, _ := GeneratorHeight()
+	if gh <= height {
+		return 1
+	}
+	if gh-height+1 > downloadWindow {
+		return downloadWindow
+	}
+	return int(gh - height + 1)
+}
+
+// getBlocks requests the n blocks starting at height concurrently.
+// It returns the longest run of them, starting at height, that
+// arrived, and the error for the first block that didn't, if any.
+// A block that timed out ends the run without an error.
+func getBlocks(ctx context.Context, peer *rpc.Client, height uint64, n int, timeout time.Duration) ([]*bc.Block, error) {
+	var (
+		blocks = make([]*bc.Block, n)
+		errs   = make([]error, n)
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			blocks[i], errs[i] = getBlock(ctx, peer, height+uint64(i), timeout)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range blocks {
+		if errs[i] != nil {
+			return blocks[:i], errs[i]
+		}
+		if blocks[i] == nil {
+			return blocks[:i], nil
+		}
+	}
+	return blocks, nil
+}
+
+// prevalidateBlocks passes blocks from in to the returned channel,
+// first validating their transactions concurrently so that the
+// checks made while applying each block are served by the
+// chain's prevalidated tx cache. Since it runs alongside
+// applyBlock, the next block's transactions are checked while
+// the current block is written to the database.
+func prevalidateBlocks(ctx context.Context, c *protocol.Chain, in <-chan *bc.Block) <-chan *bc.Block {
+	out := make(chan *bc.Block, 1)
+	go func() {
+		defer close(out)
+		for b := range in {
+			prevalidateTxs(c, b.Transactions)
+			select {
+			case out <- b:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func prevalidateTxs(c *protocol.Chain, txs []*bc.Tx) {
+	var (
+		next  int64 = -1
+		wg    sync.WaitGroup
+		procs = runtime.GOMAXPROCS(0)
+	)
+	for i := 0; i < procs && i < len(txs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j := atomic.AddInt64(&next, 1)
+				if j >= int64(len(txs)) {
+					return
+				}
+				// Errors are cached and reported again when
+				// the block is applied.
+				c.ValidateTxCached(txs[j])
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func pollGeneratorHeight(ctx context.Context, peer *rpc.Client) {
 	updateGeneratorHeight(ctx, peer)
 
@@ -0,0 +1,140 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"chain/core/rpc"
+	"chain/protocol/bc"
+)
+
+// setGeneratorHeight sets the known generator height to h,
+// and returns a func that restores the previous one.
+func setGeneratorHeight(h uint64) func() {
+	generatorLock.Lock()
+	defer generatorLock.Unlock()
+	prev, prevAt := generatorHeight, generatorHeightFetchedAt
+	generatorHeight, generatorHeightFetchedAt = h, time.Now()
+	return func() {
+		generatorLock.Lock()
+		defer generatorLock.Unlock()
+		generatorHeight, generatorHeightFetchedAt = prev, prevAt
+	}
+}
+
+// newPeer starts a server for /rpc/get-block that answers
+// each request with the result of serve for the requested
+// height: a block at that height after the given delay,
+// an error status if fail is set, or nothing until the
+// request is canceled if hang is set.
+func newPeer(t *testing.T, serve func(height uint64) (delay time.Duration, fail, hang bool)) (*rpc.Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var height uint64
+		err := json.NewDecoder(req.Body).Decode(&height)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		delay, fail, hang := serve(height)
+		if hang {
+			<-req.Context().Done()
+			return
+		}
+		time.Sleep(delay)
+		if fail {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(rw).Encode(&bc.Block{BlockHeader: bc.BlockHeader{Height: height}})
+	}))
+	return &rpc.Client{BaseURL: server.URL}, server.Close
+}
+
+func TestDownloadCount(t *testing.T) {
+	cases := []struct {
+		generator, height uint64
+		want              int
+	}{
+		{0, 1, 1},
+		{5, 5, 1},
+		{5, 7, 1},
+		{6, 5, 2},
+		{12, 5, downloadWindow},
+		{100, 5, downloadWindow},
+	}
+	for _, c := range cases {
+		restore := setGeneratorHeight(c.generator)
+		got := downloadCount(c.height)
+		restore()
+		if got != c.want {
+			t.Errorf("generator at %d: downloadCount(%d) = %d, want %d", c.generator, c.height, got, c.want)
+		}
+	}
+}
+
+func TestGetBlocks(t *testing.T) {
+	ctx := context.Background()
+	peer, stop := newPeer(t, func(height uint64) (time.Duration, bool, bool) {
+		return 0, height == 4, height == 7
+	})
+	defer stop()
+
+	check := func(height uint64, n int, wantLen int, wantErr bool) {
+		blocks, err := getBlocks(ctx, peer, height, n, 100*time.Millisecond)
+		if (err != nil) != wantErr {
+			t.Errorf("getBlocks(%d, %d) error = %v, want error %t", height, n, err, wantErr)
+		}
+		if len(blocks) != wantLen {
+			t.Fatalf("getBlocks(%d, %d) got %d blocks, want %d", height, n, len(blocks), wantLen)
+		}
+		for i, b := range blocks {
+			if b.Height != height+uint64(i) {
+				t.Errorf("getBlocks(%d, %d) block %d has height %d", height, n, i, b.Height)
+			}
+		}
+	}
+	check(1, 3, 3, false) // all arrive
+	check(1, 5, 3, true)  // block 4 fails
+	check(5, 3, 2, false) // block 7 times out
+	check(7, 1, 0, false) // the first block times out
+}
+
+func TestDownloadBlocksOrder(t *testing.T) {
+	const top = 10
+	defer setGeneratorHeight(top)()
+
+	// Later blocks are served sooner, so the concurrent
+	// requests of a window complete out of order.
+	peer, stop := newPeer(t, func(height uint64) (time.Duration, bool, bool) {
+		if height > top {
+			return 0, false, true
+		}
+		return time.Duration(top-height) * 5 * time.Millisecond, false, false
+	})
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	blockch, errch := DownloadBlocks(ctx, peer, 1)
+	for want := uint64(1); want <= top; want++ {
+		select {
+		case b := <-blockch:
+			if b.Height != want {
+				t.Fatalf("got block %d, want %d", b.Height, want)
+			}
+		case err := <-errch:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block %d", want)
+		}
+	}
+
+	cancel()
+	for range errch {
+		// Drain the error of the canceled request, so
+		// the download goroutine can see ctx is done.
+	}
+}
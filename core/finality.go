@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+
+	"chain/crypto/ed25519"
+	"chain/crypto/sha3pool"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// finalityDomain prefixes the message of a finality statement,
+// so that its signature cannot pass for a block signature.
+const finalityDomain = "chain finality statement\x00"
+
+// finalityStatement attests that a block, and so each transaction
+// in it, is final. A block joins the blockchain only once it carries
+// the signatures of a quorum of block signers, which satisfy the
+// consensus program of the block before it.
+type finalityStatement struct {
+	BlockchainID     bc.Hash              `json:"blockchain_id"`
+	BlockHeight      uint64               `json:"block_height"`
+	BlockHash        bc.Hash              `json:"block_hash"`
+	Timestamp        uint64               `json:"timestamp"`
+	TransactionIDs   []bc.Hash            `json:"transaction_ids"`
+	SignedBlockHash  bc.Hash              `json:"signed_block_hash"`
+	ConsensusProgram chainjson.HexBytes   `json:"consensus_program"`
+	BlockSignatures  []chainjson.HexBytes `json:"block_signatures"`
+
+	// A block signer also signs the statement with its block key.
+	// The message is the SHA3-256 hash of finalityDomain, the
+	// blockchain ID, and the block hash.
+	SignerPubkey chainjson.HexBytes `json:"signer_pubkey,omitempty"`
+	Signature    chainjson.HexBytes `json:"signature,omitempty"`
+}
+
+// getBlockFinality returns a finality statement for a block, by
+// default the latest, listing the block's transactions with the
+// quorum's signatures of the block as evidence.
+//
+// POST /get-block-finality
+func (h *Handler) getBlockFinality(ctx context.Context, in struct {
+	BlockHeight uint64 `json:"block_height"`
+}) (*finalityStatement, error) {
+	height := in.BlockHeight
+	if height == 0 {
+		height = h.Chain.Height()
+	}
+	if height == 0 || height > h.Chain.Height() {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "block height %d", in.BlockHeight)
+	}
+	b, err := h.Store.GetBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first block has no signatures, and is final
+	// by being the one the blockchain ID commits to.
+	var consensusProgram []byte
+	if height > 1 {
+		prev, err := h.Store.GetBlock(ctx, height-1)
+		if err != nil {
+			return nil, err
+		}
+		consensusProgram = prev.ConsensusProgram
+	}
+
+	st := &finalityStatement{
+		BlockchainID:     h.Config.BlockchainID,
+		BlockHeight:      b.Height,
+		BlockHash:        b.Hash(),
+		Timestamp:        b.TimestampMS,
+		TransactionIDs:   make([]bc.Hash, 0, len(b.Transactions)),
+		SignedBlockHash:  b.HashForSig(),
+		ConsensusProgram: consensusProgram,
+		BlockSignatures:  make([]chainjson.HexBytes, 0, len(b.Witness)),
+	}
+	for _, tx := range b.Transactions {
+		st.TransactionIDs = append(st.TransactionIDs, tx.Hash)
+	}
+	for _, sig := range b.Witness {
+		st.BlockSignatures = append(st.BlockSignatures, sig)
+	}
+
+	if h.Config.IsSigner {
+		pub, err := hex.DecodeString(h.Config.BlockPub)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding block pubkey")
+		}
+		var msg [32]byte
+		sha := sha3pool.Get256()
+		sha.Write([]byte(finalityDomain))
+		sha.Write(st.BlockchainID[:])
+		sha.Write(st.BlockHash[:])
+		sha.Read(msg[:])
+		sha3pool.Put256(sha)
+
+		st.Signature, err = h.HSM.Sign(ctx, ed25519.PublicKey(pub), msg[:])
+		if err != nil {
+			return nil, errors.Wrap(err, "signing finality statement")
+		}
+		st.SignerPubkey = pub
+	}
+	return st, nil
+}
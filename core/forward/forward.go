@@ -0,0 +1,172 @@
+// Package forward implements automatic forwarding of the funds
+// an account receives to another account or control program, as
+// for exchange deposit accounts that are emptied into a central
+// one.
+package forward
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"chain/core/account"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+var ErrBadRule = errors.New("invalid forwarding rule")
+
+// Rule forwards the confirmed funds of AccountID to exactly one
+// of DestinationAccountID or ControlProgram. If AssetID is set,
+// the rule covers only that asset, and takes precedence over a
+// rule for the same account with no asset, which covers all
+// assets.
+type Rule struct {
+	ID                   string             `json:"id"`
+	AccountID            string             `json:"account_id"`
+	AssetID              *bc.AssetID        `json:"asset_id,omitempty"`
+	DestinationAccountID string             `json:"destination_account_id,omitempty"`
+	ControlProgram       chainjson.HexBytes `json:"control_program,omitempty"`
+	CreatedAt            time.Time          `json:"created_at"`
+}
+
+// Validate checks that r's fields are usable.
+func (r *Rule) Validate() error {
+	if r.AccountID == "" {
+		return errors.WithDetail(ErrBadRule, "missing account")
+	}
+	if (r.DestinationAccountID == "") == (len(r.ControlProgram) == 0) {
+		return errors.WithDetail(ErrBadRule, "provide exactly one of a destination account or control program")
+	}
+	if r.DestinationAccountID == r.AccountID {
+		return errors.WithDetail(ErrBadRule, "cannot forward an account to itself")
+	}
+	return nil
+}
+
+// Manager stores forwarding rules and carries them out.
+type Manager struct {
+	db       pg.DB
+	accounts *account.Manager
+}
+
+func NewManager(db pg.DB, accounts *account.Manager) *Manager {
+	return &Manager{db: db, accounts: accounts}
+}
+
+// assetKey is the asset_id column for r: the asset ID, or
+// empty for a rule covering all assets.
+func assetKey(r *Rule) string {
+	if r.AssetID == nil {
+		return ""
+	}
+	return r.AssetID.String()
+}
+
+// Create stores r and returns it with its ID and creation time
+// set. If there is already a rule for the same account and
+// asset, its destination is replaced, and it keeps its ID.
+// Programs of the destination account must be created on this
+// core, and the source account's keys must be in its Mock HSM,
+// for the forwarding transactions to be built and signed.
+func (m *Manager) Create(ctx context.Context, r *Rule) (*Rule, error) {
+	err := r.Validate()
+	if err != nil {
+		return nil, err
+	}
+	const q = `
+		INSERT INTO forwarding_rules (account_id, asset_id, destination_account_id, control_program)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		ON CONFLICT (account_id, asset_id) DO UPDATE
+			SET destination_account_id = excluded.destination_account_id,
+				control_program = excluded.control_program
+		RETURNING id, created_at
+	`
+	err = m.db.QueryRow(ctx, q, r.AccountID, assetKey(r), r.DestinationAccountID, []byte(r.ControlProgram)).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting forwarding rule")
+	}
+	r.CreatedAt = r.CreatedAt.UTC()
+	return r, nil
+}
+
+const selectRuleQ = `
+	SELECT id, account_id, asset_id, COALESCE(destination_account_id, ''),
+		COALESCE(control_program, ''::bytea), created_at
+	FROM forwarding_rules
+`
+
+func scanRule(s interface {
+	Scan(...interface{}) error
+}) (*Rule, error) {
+	var (
+		r       Rule
+		assetID string
+		prog    []byte
+	)
+	err := s.Scan(&r.ID, &r.AccountID, &assetID, &r.DestinationAccountID, &prog, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if assetID != "" {
+		r.AssetID = new(bc.AssetID)
+		err = r.AssetID.UnmarshalText([]byte(assetID))
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding asset id")
+		}
+	}
+	if len(prog) > 0 {
+		r.ControlProgram = prog
+	}
+	r.CreatedAt = r.CreatedAt.UTC()
+	return &r, nil
+}
+
+// Find returns the rule with the given ID.
+func (m *Manager) Find(ctx context.Context, id string) (*Rule, error) {
+	r, err := scanRule(m.db.QueryRow(ctx, selectRuleQ+`WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "forwarding rule id: %s", id)
+	}
+	return r, errors.Wrap(err, "looking up forwarding rule")
+}
+
+// List returns the rules with IDs after after,
+// in ID order, at most limit of them.
+func (m *Manager) List(ctx context.Context, after string, limit int) ([]*Rule, error) {
+	const q = selectRuleQ + `WHERE id > $1 ORDER BY id LIMIT $2`
+	rows, err := m.db.Query(ctx, q, after, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying forwarding rules")
+	}
+	defer rows.Close()
+
+	var rules []*Rule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning forwarding rule")
+		}
+		rules = append(rules, r)
+	}
+	return rules, errors.Wrap(rows.Err())
+}
+
+// Delete deletes the rule with the given ID. Its
+// events are kept.
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	res, err := m.db.Exec(ctx, `DELETE FROM forwarding_rules WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrap(err, "deleting forwarding rule")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "deleting forwarding rule")
+	}
+	if n == 0 {
+		return errors.WithDetailf(pg.ErrUserInputNotFound, "forwarding rule id: %s", id)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package forward
+
+import (
+	"context"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		r    Rule
+		good bool
+	}{
+		{Rule{AccountID: "a", DestinationAccountID: "b"}, true},
+		{Rule{AccountID: "a", ControlProgram: []byte{1}}, true},
+		{Rule{DestinationAccountID: "b"}, false},
+		{Rule{AccountID: "a"}, false},
+		{Rule{AccountID: "a", DestinationAccountID: "b", ControlProgram: []byte{1}}, false},
+		{Rule{AccountID: "a", DestinationAccountID: "a"}, false},
+	}
+	for i, c := range cases {
+		err := c.r.Validate()
+		if (err == nil) != c.good {
+			t.Errorf("case %d: Validate() = %v, want good = %v", i, err, c.good)
+		}
+		if err != nil && errors.Root(err) != ErrBadRule {
+			t.Errorf("case %d: error = %v, want %v", i, err, ErrBadRule)
+		}
+	}
+}
+
+func TestRuleFor(t *testing.T) {
+	asset1, asset2 := bc.AssetID{1}, bc.AssetID{2}
+	all := &Rule{ID: "all"}
+	one := &Rule{ID: "one", AssetID: &asset1}
+
+	if got := ruleFor([]*Rule{one, all}, asset1); got != one {
+		t.Errorf("ruleFor(asset1) = %v, want the asset's rule", got)
+	}
+	if got := ruleFor([]*Rule{one, all}, asset2); got != all {
+		t.Errorf("ruleFor(asset2) = %v, want the all-asset rule", got)
+	}
+	if got := ruleFor([]*Rule{one}, asset2); got != nil {
+		t.Errorf("ruleFor(asset2) = %v, want nil", got)
+	}
+}
+
+func TestCreateRule(t *testing.T) {
+	// Use a database, not a transaction, so that each
+	// event gets a later created_at than the one before.
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	m := NewManager(db, nil)
+
+	r1, err := m.Create(ctx, &Rule{AccountID: "acc1", DestinationAccountID: "acc2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replacing the rule's destination keeps its ID.
+	r2, err := m.Create(ctx, &Rule{AccountID: "acc1", ControlProgram: []byte{0x51}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2.ID != r1.ID {
+		t.Errorf("replaced rule ID = %s, want %s", r2.ID, r1.ID)
+	}
+	got, err := m.Find(ctx, r1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DestinationAccountID != "" || string(got.ControlProgram) != "\x51" || got.AssetID != nil {
+		t.Errorf("Find() = %+v, want the replacement", got)
+	}
+
+	assetID := bc.AssetID{1}
+	_, err = m.Create(ctx, &Rule{AccountID: "acc1", AssetID: &assetID, DestinationAccountID: "acc3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := m.List(ctx, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("List() returned %d rules, want 2", len(rules))
+	}
+
+	amt := bc.AssetAmount{AssetID: assetID, Amount: 5}
+	for i := 0; i < 2; i++ {
+		err = m.recordEvent(ctx, r1, amt, EventFailed, "no keys")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = m.recordEvent(ctx, r1, amt, EventForwarded, "txhash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := m.Events(ctx, r1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Kind != EventFailed || events[1].Kind != EventForwarded {
+		t.Errorf("events = %+v, want one failure and one forward", events)
+	}
+
+	err = m.Delete(ctx, r1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Find(ctx, r1.ID)
+	if err == nil {
+		t.Error("Find(deleted rule) succeeded")
+	}
+}
@@ -0,0 +1,171 @@
+package forward
+
+import (
+	"context"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+)
+
+// Kinds of Event.
+const (
+	EventForwarded = "forwarded"
+	EventFailed    = "failed"
+)
+
+// Event records an attempt to forward an account's
+// holdings of one asset under a rule.
+type Event struct {
+	AssetID bc.AssetID `json:"asset_id"`
+	Amount  uint64     `json:"amount"`
+	Kind    string     `json:"kind"`
+	Detail  string     `json:"detail"`
+	Time    time.Time  `json:"time"`
+}
+
+// Events lists the events of the rule with the given ID,
+// oldest first.
+func (m *Manager) Events(ctx context.Context, ruleID string) ([]*Event, error) {
+	const q = `
+		SELECT asset_id, amount, kind, detail, created_at FROM forwarding_events
+		WHERE rule_id = $1 ORDER BY created_at
+	`
+	rows, err := m.db.Query(ctx, q, ruleID)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying forwarding events")
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		e := new(Event)
+		err = rows.Scan(&e.AssetID, &e.Amount, &e.Kind, &e.Detail, &e.Time)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning forwarding event")
+		}
+		e.Time = e.Time.UTC()
+		events = append(events, e)
+	}
+	return events, errors.Wrap(rows.Err())
+}
+
+// recordEvent records an event for rule r. An event that
+// repeats the latest one for the same asset, such as the
+// same failure on every attempt, is not recorded again.
+func (m *Manager) recordEvent(ctx context.Context, r *Rule, amt bc.AssetAmount, kind, detail string) error {
+	const q = `
+		INSERT INTO forwarding_events (rule_id, asset_id, amount, kind, detail)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM (
+				SELECT kind, detail FROM forwarding_events
+				WHERE rule_id = $1 AND asset_id = $2
+				ORDER BY created_at DESC LIMIT 1
+			) AS latest WHERE kind = $4 AND detail = $5
+		)
+	`
+	_, err := m.db.Exec(ctx, q, r.ID, amt.AssetID, amt.Amount, kind, detail)
+	return errors.Wrap(err, "recording forwarding event")
+}
+
+// ForwardAll forwards the spendable funds of each account with
+// a rule, one transaction per asset, and records an event for
+// the outcome. It signs the transactions with sign and submits
+// them with submit.
+//
+// Only confirmed outputs that are not reserved or time-locked
+// are forwarded, so funds are forwarded once they land, and an
+// output is not forwarded twice while a forwarding transaction
+// that spends it is pending.
+func (m *Manager) ForwardAll(ctx context.Context, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) error {
+	const limit = 100
+	var (
+		after string
+		rules = make(map[string][]*Rule) // by account ID
+	)
+	for {
+		page, err := m.List(ctx, after, limit)
+		if err != nil {
+			return err
+		}
+		for _, r := range page {
+			rules[r.AccountID] = append(rules[r.AccountID], r)
+		}
+		if len(page) < limit {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+
+	for accountID, accRules := range rules {
+		err := m.forwardAccount(ctx, accountID, accRules, sign, submit)
+		if err != nil {
+			log.Error(ctx, err, "forwarding account "+accountID)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) forwardAccount(ctx context.Context, accountID string, rules []*Rule, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) error {
+	balances, err := m.accounts.SpendableBalances(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	for _, amt := range balances {
+		r := ruleFor(rules, amt.AssetID)
+		if r == nil {
+			continue
+		}
+		txHash, err := m.forward(ctx, r, amt, sign, submit)
+		if err != nil {
+			err = m.recordEvent(ctx, r, amt, EventFailed, err.Error())
+		} else {
+			err = m.recordEvent(ctx, r, amt, EventForwarded, txHash.String())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ruleFor returns the rule among rules, which are all for
+// the same account, that covers assetID, or nil if none does.
+func ruleFor(rules []*Rule, assetID bc.AssetID) *Rule {
+	var all *Rule
+	for _, r := range rules {
+		if r.AssetID == nil {
+			all = r
+		} else if *r.AssetID == assetID {
+			return r
+		}
+	}
+	return all
+}
+
+func (m *Manager) forward(ctx context.Context, r *Rule, amt bc.AssetAmount, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) (bc.Hash, error) {
+	dest := txbuilder.NewControlProgramAction(amt, r.ControlProgram, nil)
+	if r.DestinationAccountID != "" {
+		dest = m.accounts.NewControlAction(amt, r.DestinationAccountID, nil)
+	}
+	actions := []txbuilder.Action{
+		m.accounts.NewSpendAction(amt, r.AccountID, nil, nil, nil, nil),
+		dest,
+	}
+	tpl, err := txbuilder.Build(ctx, nil, actions, time.Now().Add(5*time.Minute))
+	if err != nil {
+		return bc.Hash{}, err
+	}
+	err = txbuilder.Sign(ctx, tpl, txbuilder.SigningXPubs(tpl), sign)
+	if err != nil {
+		return bc.Hash{}, err
+	}
+	err = submit(ctx, tpl)
+	if err != nil {
+		return bc.Hash{}, err
+	}
+	return bc.NewTx(*tpl.Transaction).Hash, nil
+}
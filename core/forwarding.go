@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"chain/core/forward"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/log"
+	"chain/net/http/httpjson"
+	"chain/protocol/bc"
+)
+
+// createForwardingRule stores a rule forwarding the funds an
+// account receives, of one asset or of all assets, to another
+// account or to a control program.
+//
+// POST /create-forwarding-rule
+func (h *Handler) createForwardingRule(ctx context.Context, in struct {
+	AccountID               string             `json:"account_id"`
+	AccountAlias            string             `json:"account_alias"`
+	AssetID                 *bc.AssetID        `json:"asset_id"`
+	AssetAlias              string             `json:"asset_alias"`
+	DestinationAccountID    string             `json:"destination_account_id"`
+	DestinationAccountAlias string             `json:"destination_account_alias"`
+	ControlProgram          chainjson.HexBytes `json:"control_program"`
+}) (*forward.Rule, error) {
+	accountID, err := h.accountID(ctx, in.AccountID, in.AccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	if in.AssetAlias != "" {
+		a, err := h.Assets.FindByAlias(ctx, in.AssetAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid asset alias %s", in.AssetAlias)
+		}
+		in.AssetID = &a.AssetID
+	}
+	if in.DestinationAccountAlias != "" {
+		acc, err := h.Accounts.FindByAlias(ctx, in.DestinationAccountAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account alias %s", in.DestinationAccountAlias)
+		}
+		in.DestinationAccountID = acc.ID
+	}
+	return h.forwarding.Create(ctx, &forward.Rule{
+		AccountID:            accountID,
+		AssetID:              in.AssetID,
+		DestinationAccountID: in.DestinationAccountID,
+		ControlProgram:       in.ControlProgram,
+	})
+}
+
+// POST /list-forwarding-rules
+func (h *Handler) listForwardingRules(ctx context.Context, in QueryRequest) (Page, error) {
+	limit := defGenericPageSize
+
+	rules, err := h.forwarding.List(ctx, in.After, limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	out := in
+	if len(rules) > 0 {
+		out.After = rules[len(rules)-1].ID
+	}
+	return Page{
+		Items:    httpjson.Array(rules),
+		LastPage: len(rules) < limit,
+		Next:     out,
+	}, nil
+}
+
+// getForwardingRule returns a rule and the events
+// of forwarding funds under it.
+//
+// POST /get-forwarding-rule
+func (h *Handler) getForwardingRule(ctx context.Context, in struct {
+	ID string `json:"id"`
+}) (interface{}, error) {
+	r, err := h.forwarding.Find(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := h.forwarding.Events(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		*forward.Rule
+		Events []*forward.Event `json:"events"`
+	}{r, events}, nil
+}
+
+// deleteForwardingRule stops forwarding under a rule.
+// Its events are kept.
+//
+// POST /delete-forwarding-rule
+func (h *Handler) deleteForwardingRule(ctx context.Context, in struct {
+	ID string `json:"id"`
+}) error {
+	return h.forwarding.Delete(ctx, in.ID)
+}
+
+// ForwardFunds forwards the spendable funds of accounts with
+// forwarding rules every period, signing with the Mock HSM.
+// It blocks until ctx is canceled.
+func (h *Handler) ForwardFunds(ctx context.Context, period time.Duration) {
+	h.once.Do(h.init)
+	ticker := time.NewTicker(period)
+	for {
+		select {
+		case <-ticker.C:
+			err := h.forwarding.ForwardAll(ctx, h.mockhsmSignTemplate, h.submitWait)
+			if err != nil {
+				log.Error(ctx, err)
+			}
+		case <-ctx.Done():
+			ticker.Stop()
+			return
+		}
+	}
+}
@@ -70,6 +70,7 @@ func (g *generator) commitBlock(ctx context.Context, b *bc.Block, s *state.Snaps
 
 	g.latestBlock = b
 	g.latestSnapshot = s
+	recordInclusions(b)
 	return nil
 }
 
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"chain/metrics"
+	"chain/protocol/bc"
+)
+
+const (
+	// inclusionRange is the largest latency the inclusion
+	// histograms distinguish.
+	inclusionRange = time.Minute
+
+	// maxSubmissionAge is how long a submission is tracked.
+	// A transaction with no max time could otherwise stay
+	// in the submissions map forever.
+	maxSubmissionAge = time.Hour
+
+	// maxSources caps the number of per-source histograms.
+	// Sources come from a client-set header, so inclusion
+	// latencies of sources past the cap are recorded under
+	// otherSource instead of publishing a new expvar each.
+	maxSources  = 32
+	otherSource = "other"
+)
+
+// submission is a pending transaction's
+// inclusion-latency bookkeeping.
+type submission struct {
+	source  string
+	class   int
+	start   time.Time
+	maxTime uint64
+}
+
+var (
+	inclusionMu      sync.Mutex
+	submissions      = map[bc.Hash]submission{}
+	inclusion        *metrics.RotatingLatency
+	sourceInclusions = map[string]*metrics.RotatingLatency{}
+)
+
+// RecordSubmission notes that tx was accepted into the pool of
+// the generator from source, the ID of the submitting core, or
+// "local" for transactions submitted to the generator itself.
+// When tx lands in a block, the time it took is recorded in the
+// expvars generator.inclusion and generator.inclusion.<source>.
+// Call it only once the pool has accepted tx.
+//
+// The time of a future-dated transaction is counted from
+// its min time, since it can't be included before then.
+func RecordSubmission(tx *bc.Tx, source string) {
+	if source == "" {
+		source = "local"
+	}
+	start := time.Now()
+	if tx.MinTime > bc.Millis(start) {
+		start = time.Unix(0, int64(tx.MinTime*uint64(time.Millisecond)))
+	}
+
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+	if _, ok := submissions[tx.Hash]; ok {
+		return // keep the first submission of a retried tx
+	}
+	submissions[tx.Hash] = submission{
+		source:  source,
+		class:   priorityClasses[source],
+		start:   start,
+		maxTime: tx.MaxTime,
+	}
+}
+
+// recordInclusions records the inclusion latency of each
+// submitted transaction in b, and forgets submissions that
+// expired or grew too old without being included.
+func recordInclusions(b *bc.Block) {
+	now := time.Now()
+
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+	for _, tx := range b.Transactions {
+		sub, ok := submissions[tx.Hash]
+		if !ok {
+			continue
+		}
+		delete(submissions, tx.Hash)
+		d := now.Sub(sub.start)
+		inclusionLatency("generator.inclusion", &inclusion).Record(d)
+		source := sub.source
+		if sourceInclusions[source] == nil && len(sourceInclusions) >= maxSources {
+			source = otherSource
+		}
+		l := sourceInclusions[source]
+		inclusionLatency("generator.inclusion."+source, &l).Record(d)
+		sourceInclusions[source] = l
+		if priorityClasses != nil {
+			l := classInclusions[sub.class]
+			inclusionLatency("generator.inclusion.class."+strconv.Itoa(sub.class), &l).Record(d)
+			classInclusions[sub.class] = l
+		}
+	}
+	for hash, sub := range submissions {
+		if sub.maxTime > 0 && sub.maxTime < b.TimestampMS || now.Sub(sub.start) > maxSubmissionAge {
+			delete(submissions, hash)
+		}
+	}
+}
+
+// inclusionLatency returns *l, first creating and publishing
+// it under key if it is nil. We don't want to publish metrics
+// that aren't meaningful. The caller must hold inclusionMu.
+func inclusionLatency(key string, l **metrics.RotatingLatency) *metrics.RotatingLatency {
+	if *l == nil {
+		*l = metrics.NewRotatingLatency(5, inclusionRange)
+		metrics.PublishLatency(key, *l)
+	}
+	return *l
+}
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"chain/protocol/bc"
+)
+
+func TestRecordInclusions(t *testing.T) {
+	now := bc.Millis(time.Now())
+	included := bc.NewTx(bc.TxData{MinTime: now - 1, MaxTime: now + 60000})
+	expired := bc.NewTx(bc.TxData{MinTime: now - 2, MaxTime: now - 1})
+	pending := bc.NewTx(bc.TxData{MinTime: now - 3, MaxTime: now + 60000})
+
+	RecordSubmission(included, "core1")
+	RecordSubmission(expired, "")
+	RecordSubmission(pending, "")
+
+	recordInclusions(&bc.Block{
+		BlockHeader:  bc.BlockHeader{TimestampMS: now},
+		Transactions: []*bc.Tx{included},
+	})
+
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+	if _, ok := submissions[included.Hash]; ok {
+		t.Error("included tx still tracked")
+	}
+	if _, ok := submissions[expired.Hash]; ok {
+		t.Error("expired tx still tracked")
+	}
+	if sub, ok := submissions[pending.Hash]; !ok || sub.source != "local" {
+		t.Errorf("pending tx submission = %+v, %v, want source local", sub, ok)
+	}
+	if sourceInclusions["core1"] == nil {
+		t.Error("no inclusion latency recorded for core1")
+	}
+	if sourceInclusions["local"] != nil {
+		t.Error("inclusion latency recorded for local")
+	}
+}
+
+func TestRecordInclusionsLimits(t *testing.T) {
+	now := time.Now()
+	stale := bc.NewTx(bc.TxData{MinTime: 1})
+	inclusionMu.Lock()
+	submissions[stale.Hash] = submission{source: "local", start: now.Add(-2 * maxSubmissionAge)}
+	inclusionMu.Unlock()
+
+	// Include one transaction from each of more
+	// sources than get their own histogram.
+	var txs []*bc.Tx
+	for i := 0; i <= maxSources; i++ {
+		tx := bc.NewTx(bc.TxData{MinTime: uint64(i + 2)})
+		RecordSubmission(tx, fmt.Sprintf("limit%d", i))
+		txs = append(txs, tx)
+	}
+	recordInclusions(&bc.Block{
+		BlockHeader:  bc.BlockHeader{TimestampMS: bc.Millis(now)},
+		Transactions: txs,
+	})
+
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+	if _, ok := submissions[stale.Hash]; ok {
+		t.Error("stale tx still tracked")
+	}
+	if len(sourceInclusions) > maxSources+1 {
+		t.Errorf("%d sources have histograms, want at most %d", len(sourceInclusions), maxSources+1)
+	}
+	if sourceInclusions[otherSource] == nil {
+		t.Errorf("no inclusion latency recorded for %s", otherSource)
+	}
+}
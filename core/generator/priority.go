@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"chain/errors"
+	"chain/metrics"
+	"chain/protocol/bc"
+)
+
+// priorityAgeStep is how long a pending transaction waits
+// to rise by one priority class. Transactions passed over for
+// a full block are submitted again by their core, keeping
+// their first submission time, so every transaction
+// eventually outranks newer ones of any class.
+const priorityAgeStep = 10 * time.Second
+
+var (
+	priorityClasses map[string]int
+	classInclusions = map[int]*metrics.RotatingLatency{}
+)
+
+// SetPriorityClasses sets the priority class of transactions
+// submitted by each core, keyed by its core ID, or "local"
+// for transactions submitted to the generator itself.
+// Cores not in classes are in class 0.
+//
+// Once classes are set, the inclusion latency of each class
+// is also recorded, in the expvar generator.inclusion.class.<class>.
+func SetPriorityClasses(classes map[string]int) {
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+	priorityClasses = classes
+}
+
+// ParsePriorityClasses parses items of the form coreid=class.
+func ParsePriorityClasses(items []string) (map[string]int, error) {
+	classes := make(map[string]int, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.IndexByte(item, '=')
+		if i <= 0 {
+			return nil, errors.New("priority class " + strconv.Quote(item) + " is not of the form coreid=class")
+		}
+		class, err := strconv.Atoi(item[i+1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing class in "+strconv.Quote(item))
+		}
+		classes[item[:i]] = class
+	}
+	return classes, nil
+}
+
+// TxPriority ranks pool transactions by the priority class
+// of the core that submitted them, plus one class for each
+// priorityAgeStep they have waited. It implements
+// protocol.TxPriority.
+type TxPriority struct{}
+
+// Priority returns the priority of tx.
+// Transactions never submitted are in class 0.
+func (TxPriority) Priority(tx *bc.Tx) int {
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+	sub, ok := submissions[tx.Hash]
+	if !ok {
+		return 0
+	}
+	return sub.class + int(time.Since(sub.start)/priorityAgeStep)
+}
@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"chain/protocol/bc"
+)
+
+func TestParsePriorityClasses(t *testing.T) {
+	got, err := ParsePriorityClasses([]string{"core1=2", " local=-1 ", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"core1": 2, "local": -1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePriorityClasses = %v, want %v", got, want)
+	}
+
+	for _, item := range []string{"core1", "=1", "core1=high"} {
+		_, err := ParsePriorityClasses([]string{item})
+		if err == nil {
+			t.Errorf("ParsePriorityClasses(%q) err = nil, want error", item)
+		}
+	}
+}
+
+func TestTxPriority(t *testing.T) {
+	SetPriorityClasses(map[string]int{"prio1": 3})
+	defer SetPriorityClasses(nil)
+
+	now := bc.Millis(time.Now())
+	high := bc.NewTx(bc.TxData{MinTime: now - 10})
+	low := bc.NewTx(bc.TxData{MinTime: now - 11})
+	aged := bc.NewTx(bc.TxData{MinTime: now - 12})
+	unknown := bc.NewTx(bc.TxData{MinTime: now - 13})
+	RecordSubmission(high, "prio1")
+	RecordSubmission(low, "prio2")
+	inclusionMu.Lock()
+	submissions[aged.Hash] = submission{source: "prio2", start: time.Now().Add(-5 * priorityAgeStep)}
+	inclusionMu.Unlock()
+
+	cases := []struct {
+		tx   *bc.Tx
+		want int
+	}{
+		{high, 3},
+		{low, 0},
+		{aged, 5},
+		{unknown, 0},
+	}
+	for i, c := range cases {
+		if got := (TxPriority{}).Priority(c.tx); got != c.want {
+			t.Errorf("case %d: Priority = %d, want %d", i, got, c.want)
+		}
+	}
+
+	recordInclusions(&bc.Block{
+		BlockHeader:  bc.BlockHeader{TimestampMS: now},
+		Transactions: []*bc.Tx{high},
+	})
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+	if classInclusions[3] == nil {
+		t.Error("no inclusion latency recorded for class 3")
+	}
+}
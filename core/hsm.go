@@ -18,12 +18,12 @@ func (h *Handler) mockhsmCreateKey(ctx context.Context, in struct{ Alias string
 	return result, nil
 }
 
-func (h *Handler) mockhsmListKeys(ctx context.Context, query requestQuery) (page, error) {
+func (h *Handler) mockhsmListKeys(ctx context.Context, query QueryRequest) (Page, error) {
 	limit := defGenericPageSize
 
 	xpubs, after, err := h.HSM.ListKeys(ctx, query.Aliases, query.After, limit)
 	if err != nil {
-		return page{}, err
+		return Page{}, err
 	}
 
 	var items []interface{}
@@ -33,7 +33,7 @@ func (h *Handler) mockhsmListKeys(ctx context.Context, query requestQuery) (page
 
 	query.After = after
 
-	return page{
+	return Page{
 		Items:    httpjson.Array(items),
 		LastPage: len(xpubs) < limit,
 		Next:     query,
@@ -47,14 +47,24 @@ func (h *Handler) mockhsmDelKey(ctx context.Context, xpub chainkd.XPub) error {
 func (h *Handler) mockhsmSignTemplates(ctx context.Context, x struct {
 	Txs   []*txbuilder.Template `json:"transactions"`
 	XPubs []string              `json:"xpubs"`
+
+	// SigHashMode, if set, is applied to the signing
+	// instructions the xpubs sign. See txbuilder.SignWithMode.
+	SigHashMode txbuilder.SigHashMode `json:"sighash_mode"`
 }) []interface{} {
 	resp := make([]interface{}, 0, len(x.Txs))
 	for _, tx := range x.Txs {
-		err := txbuilder.Sign(ctx, tx, x.XPubs, h.mockhsmSignTemplate)
+		var err error
+		if x.SigHashMode != txbuilder.SigHashAll {
+			err = txbuilder.SignWithMode(ctx, tx, x.XPubs, x.SigHashMode, h.mockhsmSignTemplate)
+		} else {
+			err = txbuilder.Sign(ctx, tx, x.XPubs, h.mockhsmSignTemplate)
+		}
 		if err != nil {
-			info, _ := errInfo(err)
+			info, _ := errInfo(ctx, err)
 			resp = append(resp, info)
 		} else {
+			tx.EstimatedSize = txbuilder.EstimateSize(tx)
 			resp = append(resp, tx)
 		}
 	}
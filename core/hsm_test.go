@@ -81,7 +81,9 @@ func TestMockHSM(t *testing.T) {
 	outTmpls := h.mockhsmSignTemplates(ctx, struct {
 		Txs   []*txbuilder.Template `json:"transactions"`
 		XPubs []string              `json:"xpubs"`
-	}{[]*txbuilder.Template{tmpl}, []string{xpub1.XPub.String()}})
+
+		SigHashMode txbuilder.SigHashMode `json:"sighash_mode"`
+	}{[]*txbuilder.Template{tmpl}, []string{xpub1.XPub.String()}, txbuilder.SigHashAll})
 	if len(outTmpls) != 1 {
 		t.Fatalf("expected 1 output template, got %d", len(outTmpls))
 	}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/query"
+	"chain/errors"
+	"chain/net/http/httpjson"
+)
+
+// listHTLCs lists the outstanding HTLCs that an account is the
+// sender or recipient of.
+//
+// POST /list-htlcs
+func (h *Handler) listHTLCs(ctx context.Context, in QueryRequest) (Page, error) {
+	if in.AccountID == "" && in.AccountAlias != "" {
+		acc, err := h.Accounts.FindByAlias(ctx, in.AccountAlias)
+		if err != nil {
+			return Page{}, errors.Wrapf(err, "invalid account alias %s", in.AccountAlias)
+		}
+		in.AccountID = acc.ID
+	}
+	if in.AccountID == "" {
+		return Page{}, errors.WithDetail(httpjson.ErrBadRequest, "missing account_id or account_alias")
+	}
+	err := checkAccountScope(ctx, in.AccountID)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var after *query.OutputsAfter
+	if in.After != "" {
+		after, err = query.DecodeOutputsAfter(in.After)
+		if err != nil {
+			return Page{}, errors.Wrap(err, "decoding `after`")
+		}
+	}
+	limit := defGenericPageSize
+	contracts, next, err := h.htlcs.List(ctx, in.AccountID, after, limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	out := in
+	if next != nil {
+		out.After = next.String()
+	}
+	return Page{
+		Items:    httpjson.Array(contracts),
+		LastPage: next == nil,
+		Next:     out,
+	}, nil
+}
@@ -0,0 +1,170 @@
+package htlc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"chain/core/txbuilder"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func (m *Manager) DecodeLockAction(data []byte) (txbuilder.Action, error) {
+	a := &lockAction{htlc: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// lockAction spends Amount from an account into
+// a contract output of HTLC.
+type lockAction struct {
+	htlc      *Manager
+	HTLC      HTLC   `json:"htlc"`
+	AccountID string `json:"account_id"`
+	Amount    uint64 `json:"amount"`
+}
+
+func (a *lockAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	h := &a.HTLC
+	err := h.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if a.Amount == 0 {
+		return nil, errors.WithDetail(txbuilder.ErrBadAmount, "htlc amount must be positive")
+	}
+	if bc.Millis(maxTime) >= h.Timeout {
+		return nil, errors.WithDetail(ErrExpired, "the transaction's ttl reaches past the timeout")
+	}
+
+	amt := bc.AssetAmount{AssetID: h.AssetID, Amount: a.Amount}
+	res, err := a.htlc.accounts.NewSpendAction(amt, a.AccountID, nil, nil, nil, nil).Build(ctx, maxTime)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refData(h)
+	if err != nil {
+		return nil, err
+	}
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(h.AssetID, a.Amount, h.Program(), ref))
+	return res, nil
+}
+
+func (m *Manager) DecodeClaimAction(data []byte) (txbuilder.Action, error) {
+	a := &claimAction{htlc: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// claimAction pays every unspent contract output of HTLC to
+// the recipient, whose program must belong to an account in
+// this core, before the timeout. It must be the first action
+// of its transaction.
+//
+// Each payment carries the secret in its reference data, as
+// well as in its input's witness, so that the sender can find
+// it with a transaction query to claim the other side of a swap.
+type claimAction struct {
+	htlc   *Manager
+	HTLC   HTLC               `json:"htlc"`
+	Secret chainjson.HexBytes `json:"secret"`
+}
+
+func (a *claimAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	h := &a.HTLC
+	err := h.Validate()
+	if err != nil {
+		return nil, err
+	}
+	err = h.CheckSecret(a.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if bc.Millis(maxTime) >= h.Timeout {
+		return nil, errors.WithDetail(ErrExpired, "a claim's ttl must end before the timeout")
+	}
+	ref, err := json.Marshal(map[string]string{
+		"htlc_id":     h.ID().String(),
+		"htlc_secret": hex.EncodeToString(a.Secret),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return a.htlc.spendContracts(ctx, h, h.Recipient, ref, clauseClaim, a.Secret)
+}
+
+func (m *Manager) DecodeRefundAction(data []byte) (txbuilder.Action, error) {
+	a := &refundAction{htlc: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// refundAction pays every unspent contract output of HTLC back
+// to the sender, whose program must belong to an account in this
+// core, once the timeout has passed. It must be the first action
+// of its transaction.
+type refundAction struct {
+	htlc *Manager
+	HTLC HTLC `json:"htlc"`
+}
+
+func (a *refundAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	h := &a.HTLC
+	err := h.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if bc.Millis(time.Now()) < h.Timeout {
+		return nil, errors.WithDetailf(ErrNotExpired, "refunds are possible from %s", time.Unix(0, int64(h.Timeout)*int64(time.Millisecond)).UTC().Format(time.RFC3339))
+	}
+	res, err := a.htlc.spendContracts(ctx, h, h.Sender, nil, clauseRefund)
+	if err != nil {
+		return nil, err
+	}
+	res.MinTimeMS = h.Timeout
+	return res, nil
+}
+
+// spendContracts spends every unspent contract output of h with
+// the given clause and data arguments, signed by the owner of
+// payee, and pays each to payee in the output at the same position
+// as its input.
+func (m *Manager) spendContracts(ctx context.Context, h *HTLC, payee, refData []byte, clause int64, args ...[]byte) (*txbuilder.BuildResult, error) {
+	contracts, err := m.contracts(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	if len(contracts) == 0 {
+		return nil, errors.WithDetailf(ErrNotFunded, "htlc %s", h.ID())
+	}
+
+	prog := h.Program()
+	res := new(txbuilder.BuildResult)
+	for _, c := range contracts {
+		amt := bc.AssetAmount{AssetID: h.AssetID, Amount: c.Amount}
+		sigInst, err := m.accounts.SigningInstruction(ctx, payee, amt)
+		if err != nil {
+			return nil, errors.WithDetail(err, "the signer's program is not controlled by this core")
+		}
+
+		// The contract finds its arguments at the bottom of
+		// the stack, below those of the signer's program.
+		sig := sigInst.WitnessComponents
+		sigInst.WitnessComponents = nil
+		sigInst.AddDataWitness(vm.Int64Bytes(clause))
+		for _, arg := range args {
+			sigInst.AddDataWitness(arg)
+		}
+		sigInst.WitnessComponents = append(sigInst.WitnessComponents, sig...)
+
+		in := bc.NewSpendInput(c.TransactionID, c.Position, nil, h.AssetID, c.Amount, prog, nil)
+		res.Inputs = append(res.Inputs, in)
+		res.SigningInstructions = append(res.SigningInstructions, sigInst)
+		res.Outputs = append(res.Outputs, bc.NewTxOutput(h.AssetID, c.Amount, payee, refData))
+	}
+	return res, nil
+}
@@ -0,0 +1,286 @@
+// Package htlc implements hash time-locked contracts, the building
+// block of atomic swaps across chains. A sender locks funds in a
+// contract output that the recipient can claim by revealing a secret
+// whose SHA-256 hash is fixed in the contract, until a timeout. After
+// the timeout, only the sender can take the funds back. Two HTLCs on
+// the same hash, one on each chain, make a swap: claiming one reveals
+// the secret that claims the other.
+package htlc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"chain/core/account"
+	"chain/core/query"
+	"chain/core/query/filter"
+	"chain/crypto/sha3pool"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+var (
+	ErrBadHTLC    = errors.New("invalid htlc")
+	ErrNotFunded  = errors.New("htlc has no contract outputs")
+	ErrBadSecret  = errors.New("secret does not match hash")
+	ErrExpired    = errors.New("htlc has timed out")
+	ErrNotExpired = errors.New("htlc has not timed out")
+)
+
+// Clause selectors, the first witness argument to a contract.
+const (
+	clauseClaim  = 0
+	clauseRefund = 1
+)
+
+// SecretSize is the size of an HTLC secret in bytes. The
+// contract accepts no other size, as is usual for HTLCs on
+// other chains, so that a secret revealed on one chain is
+// always usable on the other.
+const SecretSize = 32
+
+// contractName marks contract outputs in their reference data.
+const contractName = "htlc"
+
+// HTLC describes a hash time-locked contract. An HTLC is identified
+// by its parameters alone; it is not stored, except in the
+// reference data of its contract outputs.
+type HTLC struct {
+	AssetID bc.AssetID `json:"asset_id"`
+
+	// Hash is the SHA-256 hash of the secret.
+	Hash chainjson.HexBytes `json:"hash"`
+
+	// Timeout is a millisecond Unix timestamp. The recipient
+	// can claim the funds before it, and the sender can take
+	// them back from then on.
+	Timeout uint64 `json:"timeout"`
+
+	// Sender and Recipient sign refunds and claims, and receive
+	// them. Both must be multisig control programs, such as ones
+	// created for accounts.
+	Sender    chainjson.HexBytes `json:"sender_program"`
+	Recipient chainjson.HexBytes `json:"recipient_program"`
+}
+
+// Validate checks that h's parameters are usable.
+func (h *HTLC) Validate() error {
+	if len(h.Hash) != sha256.Size {
+		return errors.WithDetailf(ErrBadHTLC, "hash must be %d bytes", sha256.Size)
+	}
+	if h.Timeout == 0 || h.Timeout > math.MaxInt64 {
+		return errors.WithDetail(ErrBadHTLC, "timeout must be positive and at most 2^63-1")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(h.Sender); err != nil {
+		return errors.WithDetail(ErrBadHTLC, "sender program must be a multisig program")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(h.Recipient); err != nil {
+		return errors.WithDetail(ErrBadHTLC, "recipient program must be a multisig program")
+	}
+	return nil
+}
+
+// ID returns the hash that identifies h. Contract outputs
+// record it in their reference data.
+func (h *HTLC) ID() bc.Hash {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], h.Timeout)
+
+	var id bc.Hash
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(h.AssetID[:])
+	sha.Write(buf[:])
+	for _, b := range [][]byte{h.Hash, h.Sender, h.Recipient} {
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(b)))
+		sha.Write(buf[:])
+		sha.Write(b)
+	}
+	sha.Read(id[:])
+	return id
+}
+
+// CheckSecret reports whether secret is the secret of h.
+func (h *HTLC) CheckSecret(secret []byte) error {
+	sum := sha256.Sum256(secret)
+	if len(secret) != SecretSize || !bytes.Equal(sum[:], h.Hash) {
+		return errors.WithDetailf(ErrBadSecret, "secret must be %d bytes with SHA-256 hash %x", SecretSize, []byte(h.Hash))
+	}
+	return nil
+}
+
+// Program returns the control program for h's contract outputs.
+//
+// Its witness arguments are a clause selector, for a claim the
+// secret, and the arguments to the signer's program. A claim
+// must have a maxtime before the timeout and is signed by the
+// recipient; a refund must have a mintime at or after the
+// timeout and is signed by the sender.
+func (h *HTLC) Program() []byte {
+	a := vmutil.NewAssembler()
+	a.AddOp(vm.OP_DEPTH).AddInt64(1).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the clause
+	a.Jump(vm.OP_JUMPIF, "refund")
+
+	// claim
+	a.AddOp(vm.OP_MAXTIME).AddInt64(int64(h.Timeout)).AddOp(vm.OP_LESSTHAN).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_DEPTH).AddInt64(2).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the secret
+	a.AddOp(vm.OP_SIZE).AddInt64(SecretSize).AddOp(vm.OP_EQUALVERIFY)
+	a.AddOp(vm.OP_SHA256).AddData(h.Hash).AddOp(vm.OP_EQUALVERIFY)
+	a.AddRawBytes(h.Recipient)
+	a.Jump(vm.OP_JUMP, "end")
+
+	a.Label("refund")
+	a.AddOp(vm.OP_MINTIME).AddInt64(int64(h.Timeout)).AddOp(vm.OP_GREATERTHANOREQUAL).AddOp(vm.OP_VERIFY)
+	a.AddRawBytes(h.Sender)
+	a.Label("end")
+	return a.Build()
+}
+
+// Manager builds transactions for HTLCs and finds them.
+type Manager struct {
+	accounts *account.Manager
+	indexer  *query.Indexer
+}
+
+func NewManager(accounts *account.Manager, indexer *query.Indexer) *Manager {
+	return &Manager{accounts: accounts, indexer: indexer}
+}
+
+// Contract is an unspent contract output of an HTLC.
+type Contract struct {
+	ID            bc.Hash `json:"id"`
+	HTLC          *HTLC   `json:"htlc"`
+	Status        string  `json:"status"`         // "open" or "timed_out"
+	Role          string  `json:"role,omitempty"` // "sender" or "recipient"
+	TransactionID bc.Hash `json:"transaction_id"`
+	Position      uint32  `json:"position"`
+	Amount        uint64  `json:"amount"`
+}
+
+// List returns the outstanding HTLCs that the account accountID
+// is the sender or recipient of, from a page of at most limit
+// contract outputs, and the cursor for the next page, which is nil
+// on the last page. A page may hold fewer HTLCs than limit, or
+// none, before the last page.
+func (m *Manager) List(ctx context.Context, accountID string, after *query.OutputsAfter, limit int) ([]*Contract, *query.OutputsAfter, error) {
+	contracts, next, err := m.list(ctx, "reference_data.contract=$1", []interface{}{contractName}, after, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	mine := []*Contract{}
+	for _, c := range contracts {
+		for _, p := range []struct {
+			role string
+			prog []byte
+		}{{"sender", c.HTLC.Sender}, {"recipient", c.HTLC.Recipient}} {
+			id, err := m.accounts.ProgramAccount(ctx, p.prog)
+			if err != nil && errors.Root(err) != pg.ErrUserInputNotFound {
+				return nil, nil, err
+			}
+			if id == accountID {
+				c.Role = p.role
+				mine = append(mine, c)
+				break
+			}
+		}
+	}
+	return mine, next, nil
+}
+
+// contracts returns the unspent contract outputs of h.
+func (m *Manager) contracts(ctx context.Context, h *HTLC) ([]*Contract, error) {
+	vals := []interface{}{h.ID().String()}
+	var (
+		all   []*Contract
+		after *query.OutputsAfter
+	)
+	for {
+		contracts, next, err := m.list(ctx, "reference_data.htlc_id=$1", vals, after, 100)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, contracts...)
+		if next == nil {
+			return all, nil
+		}
+		after = next
+	}
+}
+
+// list returns the contract outputs matching the output filter
+// filt with parameters vals. Outputs that only claim to be HTLC
+// contracts, by their reference data, but do not match their
+// HTLC's program or asset are skipped.
+func (m *Manager) list(ctx context.Context, filt string, vals []interface{}, after *query.OutputsAfter, limit int) ([]*Contract, *query.OutputsAfter, error) {
+	p, err := filter.Parse(filt)
+	if err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+	now := bc.Millis(time.Now())
+	outs, next, err := m.indexer.Outputs(ctx, p, vals, now, after, limit)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "querying htlc contracts")
+	}
+	contracts := []*Contract{}
+	for _, o := range outs {
+		raw, ok := o.(*json.RawMessage)
+		if !ok || raw == nil {
+			return nil, nil, errors.Wrap(fmt.Errorf("unexpected %T in Indexer.Outputs output", o))
+		}
+		var out struct {
+			TransactionID  bc.Hash            `json:"transaction_id"`
+			Position       uint32             `json:"position"`
+			AssetID        bc.AssetID         `json:"asset_id"`
+			Amount         uint64             `json:"amount"`
+			ControlProgram chainjson.HexBytes `json:"control_program"`
+			ReferenceData  struct {
+				HTLC *HTLC `json:"htlc"`
+			} `json:"reference_data"`
+		}
+		err = json.Unmarshal(*raw, &out)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "decoding htlc contract")
+		}
+		h := out.ReferenceData.HTLC
+		if h == nil || h.Validate() != nil || out.AssetID != h.AssetID || !bytes.Equal(out.ControlProgram, h.Program()) {
+			continue
+		}
+		c := &Contract{
+			ID:            h.ID(),
+			HTLC:          h,
+			Status:        "open",
+			TransactionID: out.TransactionID,
+			Position:      out.Position,
+			Amount:        out.Amount,
+		}
+		if now >= h.Timeout {
+			c.Status = "timed_out"
+		}
+		contracts = append(contracts, c)
+	}
+	if len(outs) < limit {
+		next = nil
+	}
+	return contracts, next, nil
+}
+
+// refData is the reference data of h's contract outputs, which
+// lets the recipient find the HTLC without being told its terms.
+func refData(h *HTLC) ([]byte, error) {
+	b, err := json.Marshal(map[string]interface{}{
+		"contract": contractName,
+		"htlc_id":  h.ID().String(),
+		"htlc":     h,
+	})
+	return b, errors.Wrap(err)
+}
@@ -0,0 +1,194 @@
+package htlc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"chain/core/contracttest"
+	"chain/core/coretest"
+	"chain/core/txbuilder"
+	"chain/crypto/ed25519"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+func TestProgram(t *testing.T) {
+	var (
+		progs [2][]byte
+		privs [2]ed25519.PrivateKey
+	)
+	for i := range progs {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		progs[i], err = vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pub}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+	}
+	sender, recipient := privs[0], privs[1]
+
+	secret := make([]byte, SecretSize)
+	secret[0] = 1
+	hash := sha256.Sum256(secret)
+	short := []byte{1}
+	shortHash := sha256.Sum256(short)
+
+	h := &HTLC{AssetID: bc.AssetID{1}, Hash: hash[:], Timeout: 1000, Sender: progs[0], Recipient: progs[1]}
+	err := h.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortHTLC := *h
+	shortHTLC.Hash = shortHash[:]
+
+	cases := []struct {
+		name     string
+		htlc     *HTLC
+		minTime  uint64
+		maxTime  uint64
+		args     [][]byte
+		signer   ed25519.PrivateKey
+		wantPass bool
+	}{
+		{"claim", h, 0, 999, [][]byte{vm.Int64Bytes(clauseClaim), secret}, recipient, true},
+		{"claim at timeout", h, 0, 1000, [][]byte{vm.Int64Bytes(clauseClaim), secret}, recipient, false},
+		{"claim with wrong secret", h, 0, 999, [][]byte{vm.Int64Bytes(clauseClaim), make([]byte, SecretSize)}, recipient, false},
+		{"claim with short secret", &shortHTLC, 0, 999, [][]byte{vm.Int64Bytes(clauseClaim), short}, recipient, false},
+		{"claim by sender", h, 0, 999, [][]byte{vm.Int64Bytes(clauseClaim), secret}, sender, false},
+		{"refund", h, 1000, 2000, [][]byte{vm.Int64Bytes(clauseRefund)}, sender, true},
+		{"refund before timeout", h, 999, 2000, [][]byte{vm.Int64Bytes(clauseRefund)}, sender, false},
+		{"refund by recipient", h, 1000, 2000, [][]byte{vm.Int64Bytes(clauseRefund)}, recipient, false},
+	}
+	for _, tc := range cases {
+		tx := &bc.TxData{
+			Version: 1,
+			MinTime: tc.minTime,
+			MaxTime: tc.maxTime,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{2}, 0, nil, h.AssetID, 50, tc.htlc.Program(), nil),
+			},
+			Outputs: []*bc.TxOutput{bc.NewTxOutput(h.AssetID, 50, progs[1], nil)},
+		}
+		contracttest.Sign(tx, tc.signer, tc.args)
+
+		ok, err := vm.VerifyTxInput(bc.NewTx(*tx), 0)
+		if ok != tc.wantPass {
+			t.Errorf("%s: got ok=%t (err %v), want %t", tc.name, ok, err, tc.wantPass)
+		}
+	}
+}
+
+func TestCheckSecret(t *testing.T) {
+	secret := make([]byte, SecretSize)
+	hash := sha256.Sum256(secret)
+	h := &HTLC{Hash: hash[:]}
+	if err := h.CheckSecret(secret); err != nil {
+		t.Errorf("CheckSecret(secret) = %v, want nil", err)
+	}
+	if err := h.CheckSecret(secret[1:]); err == nil {
+		t.Error("CheckSecret(short secret) = nil, want error")
+	}
+}
+
+func TestBuildAndList(t *testing.T) {
+	ctx := context.Background()
+	core := contracttest.NewCore(t)
+	m := NewManager(core.Accounts, core.Indexer)
+
+	sender, assetID := core.Fund(ctx, t, 100)
+	recipient := coretest.CreateAccount(ctx, t, core.Accounts, "", nil)
+	secret := bytes.Repeat([]byte{7}, SecretSize)
+	hash := sha256.Sum256(secret)
+	h := &HTLC{
+		AssetID:   assetID,
+		Hash:      hash[:],
+		Timeout:   bc.Millis(time.Now().Add(time.Hour)),
+		Sender:    core.Program(ctx, t, sender),
+		Recipient: core.Program(ctx, t, recipient),
+	}
+	checkList := func(accountID, role string, want ...uint64) {
+		contracts, _, err := m.List(ctx, accountID, nil, 100)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if len(contracts) != len(want) {
+			t.Fatalf("%s has %d htlcs, want %d", role, len(contracts), len(want))
+		}
+		for i, c := range contracts {
+			if c.Role != role || c.Amount != want[i] {
+				t.Errorf("%s's htlc %d: %s of %d, want %s of %d", role, i, c.Role, c.Amount, role, want[i])
+			}
+		}
+	}
+	lock := func(h *HTLC, amount uint64, maxTime time.Time) {
+		core.SubmitBy(ctx, t, maxTime, contracttest.Action(t, m.DecodeLockAction, map[string]interface{}{
+			"htlc":       h,
+			"account_id": sender,
+			"amount":     amount,
+		}))
+	}
+	claim := func(secret []byte) txbuilder.Action {
+		return contracttest.Action(t, m.DecodeClaimAction, map[string]interface{}{
+			"htlc":   h,
+			"secret": chainjson.HexBytes(secret),
+		})
+	}
+	refund := func(h *HTLC) txbuilder.Action {
+		return contracttest.Action(t, m.DecodeRefundAction, map[string]interface{}{"htlc": h})
+	}
+	checkList(sender, "sender")
+
+	lock(h, 60, time.Now().Add(time.Minute))
+	checkList(sender, "sender", 60)
+	checkList(recipient, "recipient", 60)
+
+	_, err := refund(h).Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrNotExpired {
+		t.Errorf("early refund: got error %v, want %v", err, ErrNotExpired)
+	}
+	_, err = claim(make([]byte, SecretSize)).Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrBadSecret {
+		t.Errorf("claim with the wrong secret: got error %v, want %v", err, ErrBadSecret)
+	}
+
+	// The claim reveals the secret in the payment's
+	// reference data, for the other side of a swap.
+	tx := core.Submit(ctx, t, claim(secret))
+	if len(tx.Outputs) != 1 || tx.Outputs[0].Amount != 60 || !bytes.Equal(tx.Outputs[0].ControlProgram, h.Recipient) {
+		t.Fatalf("claim outputs = %+v, want 60 to the recipient", tx.Outputs)
+	}
+	if !bytes.Contains(tx.Outputs[0].ReferenceData, []byte(hex.EncodeToString(secret))) {
+		t.Errorf("claim reference data %s does not reveal the secret", tx.Outputs[0].ReferenceData)
+	}
+	checkList(sender, "sender")
+
+	// After the timeout, the sender takes the funds back.
+	timeout := time.Now().Add(2 * time.Second)
+	expiring := *h
+	expiring.Timeout = bc.Millis(timeout)
+	lock(&expiring, 40, timeout.Add(-time.Second))
+	time.Sleep(timeout.Sub(time.Now()) + 10*time.Millisecond)
+	contracts, _, err := m.List(ctx, sender, nil, 100)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(contracts) != 1 || contracts[0].Status != "timed_out" {
+		t.Fatalf("sender's htlcs = %+v, want one timed out", contracts)
+	}
+	tx = core.Submit(ctx, t, refund(&expiring))
+	if len(tx.Outputs) != 1 || tx.Outputs[0].Amount != 40 || !bytes.Equal(tx.Outputs[0].ControlProgram, h.Sender) {
+		t.Fatalf("refund outputs = %+v, want 40 to the sender", tx.Outputs)
+	}
+	checkList(sender, "sender")
+}
@@ -26,7 +26,7 @@ func jsonHandler(f interface{}) http.Handler {
 // associated with the error.
 func WriteHTTPError(ctx context.Context, w http.ResponseWriter, err error) {
 	logHTTPError(ctx, err)
-	body, info := errInfo(err)
+	body, info := errInfo(ctx, err)
 	httpjson.Write(ctx, w, info.HTTPStatus, body)
 }
 
@@ -37,7 +37,7 @@ func logHTTPError(ctx context.Context, err error) {
 		errorMessage = err.Error()
 	}
 
-	_, info := errInfo(err)
+	_, info := errInfo(ctx, err)
 	keyvals := []interface{}{
 		"status", info.HTTPStatus,
 		"chaincode", info.ChainCode,
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"chain/core/iso20022"
+	"chain/core/txbuilder"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/net/http/reqid"
+)
+
+// POST /build-transaction-from-pain001
+//
+// Each credit transfer in the pain.001 message becomes one
+// transaction template. Debtor accounts are account aliases.
+// Creditor accounts are account aliases or, failing that,
+// hex-encoded control programs. Currency codes are asset aliases.
+func (h *Handler) buildPain001(ctx context.Context, in struct {
+	Message string             `json:"message"`
+	TTL     chainjson.Duration `json:"ttl"`
+}) (interface{}, error) {
+	msg, err := iso20022.ParsePain001([]byte(in.Message))
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]interface{}, len(msg.Transfers))
+	statuses := make([]iso20022.TxStatus, len(msg.Transfers))
+	var wg sync.WaitGroup
+	wg.Add(len(responses))
+
+	for i := range msg.Transfers {
+		go func(i int) {
+			defer wg.Done()
+
+			t := msg.Transfers[i]
+			statuses[i] = iso20022.TxStatus{Transfer: t, Status: iso20022.StatusAccepted}
+			subctx := reqid.NewSubContext(ctx, reqid.New())
+			tpl, err := h.buildTransfer(subctx, msg.ID, t, in.TTL)
+			if err != nil {
+				logHTTPError(ctx, err)
+				info, _ := errInfo(ctx, err)
+				responses[i] = info
+				statuses[i].Status = iso20022.StatusRejected
+				statuses[i].Reason = info.ChainCode + " " + info.Message
+			} else {
+				responses[i] = tpl
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	report, err := iso20022.StatusReport(msg.ID+"-STS", msg, statuses, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"transactions":  responses,
+		"status_report": string(report),
+	}, nil
+}
+
+func (h *Handler) buildTransfer(ctx context.Context, msgID string, t iso20022.Transfer, ttl chainjson.Duration) (*txbuilder.Template, error) {
+	spend := map[string]interface{}{
+		"type":          "spend_account",
+		"account_alias": t.DebtorAccount,
+		"asset_alias":   t.Currency,
+		"amount":        t.Amount,
+	}
+	control := map[string]interface{}{
+		"type":        "control_account",
+		"asset_alias": t.Currency,
+		"amount":      t.Amount,
+	}
+	_, err := h.Accounts.FindByAlias(ctx, t.CreditorAccount)
+	if errors.Root(err) == pg.ErrUserInputNotFound {
+		prog, hexErr := hex.DecodeString(t.CreditorAccount)
+		if hexErr != nil || len(prog) == 0 {
+			return nil, err
+		}
+		control["type"] = "control_program"
+		control["control_program"] = t.CreditorAccount
+	} else if err != nil {
+		return nil, err
+	} else {
+		control["account_alias"] = t.CreditorAccount
+	}
+
+	refData := map[string]interface{}{
+		"message_id":      msgID,
+		"payment_info_id": t.PaymentInfoID,
+	}
+	if t.InstructionID != "" {
+		refData["instruction_id"] = t.InstructionID
+	}
+	if t.Remittance != "" {
+		refData["remittance_information"] = t.Remittance
+	}
+	setRefData := map[string]interface{}{
+		"type":           "set_transaction_reference_data",
+		"reference_data": refData,
+	}
+
+	return h.BuildTransaction(ctx, &BuildRequest{
+		Actions:    []map[string]interface{}{spend, control, setRefData},
+		TTL:        ttl,
+		EndToEndID: t.EndToEndID,
+	})
+}
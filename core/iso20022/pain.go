@@ -0,0 +1,263 @@
+// Package iso20022 translates ISO 20022 customer payment messages
+// to and from the shapes Chain Core works with.
+//
+// It understands a subset of pain.001 (customer credit transfer
+// initiation) sufficient to describe account-to-account transfers,
+// and produces the matching pain.002 (customer payment status report).
+package iso20022
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"chain/errors"
+)
+
+const (
+	pain001Name = "pain.001.001.03"
+	pain002NS   = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"
+)
+
+// ErrBadMessage is returned for messages that cannot be parsed
+// or that describe transfers Chain Core can't represent.
+var ErrBadMessage = errors.New("invalid pain.001 message")
+
+// Transaction status codes from the ISO 20022 external code list.
+const (
+	StatusAccepted = "ACTC" // accepted after technical validation
+	StatusRejected = "RJCT"
+	StatusPartial  = "PART"
+)
+
+type (
+	document struct {
+		XMLName  xml.Name       `xml:"Document"`
+		Initiate *initiateTrans `xml:"CstmrCdtTrfInitn"`
+	}
+
+	initiateTrans struct {
+		GroupHeader struct {
+			MsgID    string `xml:"MsgId"`
+			NbOfTxs  string `xml:"NbOfTxs"`
+			Creation string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		PaymentInfos []paymentInfo `xml:"PmtInf"`
+	}
+
+	paymentInfo struct {
+		ID             string         `xml:"PmtInfId"`
+		DebtorAccount  accountRef     `xml:"DbtrAcct"`
+		CreditTransfer []creditTxInfo `xml:"CdtTrfTxInf"`
+	}
+
+	creditTxInfo struct {
+		PaymentID struct {
+			InstrID    string `xml:"InstrId"`
+			EndToEndID string `xml:"EndToEndId"`
+		} `xml:"PmtId"`
+		Amount struct {
+			Instructed struct {
+				Currency string `xml:"Ccy,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"InstdAmt"`
+		} `xml:"Amt"`
+		CreditorAccount accountRef `xml:"CdtrAcct"`
+		Remittance      struct {
+			Unstructured []string `xml:"Ustrd"`
+		} `xml:"RmtInf"`
+	}
+
+	accountRef struct {
+		Other string `xml:"Id>Othr>Id"`
+		IBAN  string `xml:"Id>IBAN"`
+	}
+)
+
+func (a accountRef) id() string {
+	if a.Other != "" {
+		return a.Other
+	}
+	return a.IBAN
+}
+
+// Message is a parsed pain.001 customer credit transfer initiation.
+type Message struct {
+	ID        string
+	Transfers []Transfer
+}
+
+// Transfer is a single credit transfer from a pain.001 message.
+// Account identifiers and currency codes are left uninterpreted;
+// the caller decides how they map onto accounts and assets.
+type Transfer struct {
+	PaymentInfoID   string
+	InstructionID   string
+	EndToEndID      string
+	DebtorAccount   string
+	CreditorAccount string
+	Currency        string
+	Amount          uint64
+	Remittance      string
+}
+
+// ParsePain001 parses a pain.001 XML document.
+func ParsePain001(b []byte) (*Message, error) {
+	var doc document
+	err := xml.Unmarshal(b, &doc)
+	if err != nil {
+		return nil, errors.WithDetail(ErrBadMessage, err.Error())
+	}
+	if doc.Initiate == nil {
+		return nil, errors.WithDetail(ErrBadMessage, "missing CstmrCdtTrfInitn element")
+	}
+	cti := doc.Initiate
+	if cti.GroupHeader.MsgID == "" {
+		return nil, errors.WithDetail(ErrBadMessage, "missing GrpHdr/MsgId")
+	}
+
+	m := &Message{ID: cti.GroupHeader.MsgID}
+	for i, pi := range cti.PaymentInfos {
+		debtor := pi.DebtorAccount.id()
+		if debtor == "" {
+			return nil, errors.WithDetailf(ErrBadMessage, "missing debtor account on payment information %d", i)
+		}
+		for j, ct := range pi.CreditTransfer {
+			amount, err := parseAmount(ct.Amount.Instructed.Value)
+			if err != nil {
+				return nil, errors.WithDetailf(ErrBadMessage, "payment information %d, transfer %d: %s", i, j, err)
+			}
+			creditor := ct.CreditorAccount.id()
+			if creditor == "" {
+				return nil, errors.WithDetailf(ErrBadMessage, "payment information %d, transfer %d: missing creditor account", i, j)
+			}
+			if ct.Amount.Instructed.Currency == "" {
+				return nil, errors.WithDetailf(ErrBadMessage, "payment information %d, transfer %d: missing currency", i, j)
+			}
+			m.Transfers = append(m.Transfers, Transfer{
+				PaymentInfoID:   pi.ID,
+				InstructionID:   ct.PaymentID.InstrID,
+				EndToEndID:      ct.PaymentID.EndToEndID,
+				DebtorAccount:   debtor,
+				CreditorAccount: creditor,
+				Currency:        ct.Amount.Instructed.Currency,
+				Amount:          amount,
+				Remittance:      strings.Join(ct.Remittance.Unstructured, " "),
+			})
+		}
+	}
+
+	if len(m.Transfers) == 0 {
+		return nil, errors.WithDetail(ErrBadMessage, "message contains no credit transfers")
+	}
+	if n := cti.GroupHeader.NbOfTxs; n != "" && n != strconv.Itoa(len(m.Transfers)) {
+		return nil, errors.WithDetailf(ErrBadMessage, "GrpHdr/NbOfTxs is %s but message contains %d transfers", n, len(m.Transfers))
+	}
+	return m, nil
+}
+
+// parseAmount converts a decimal ISO 20022 amount into asset units.
+// Chain Core amounts are integers, so any nonzero fractional part
+// is rejected rather than rounded.
+func parseAmount(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if strings.Trim(frac, "0") != "" {
+		return 0, errors.New("amount " + s + " is not a whole number of units")
+	}
+	n, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil || n == 0 {
+		return 0, errors.New("invalid amount " + strconv.Quote(s))
+	}
+	return n, nil
+}
+
+// TxStatus is the outcome of processing one Transfer.
+type TxStatus struct {
+	Transfer
+	Status string // StatusAccepted or StatusRejected
+	Reason string // optional additional information
+}
+
+type (
+	statusDocument struct {
+		XMLName xml.Name     `xml:"Document"`
+		NS      string       `xml:"xmlns,attr"`
+		Report  statusReport `xml:"CstmrPmtStsRpt"`
+	}
+
+	statusReport struct {
+		GroupHeader struct {
+			MsgID    string `xml:"MsgId"`
+			Creation string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		OrigGroup struct {
+			MsgID   string `xml:"OrgnlMsgId"`
+			MsgName string `xml:"OrgnlMsgNmId"`
+			NbOfTxs int    `xml:"OrgnlNbOfTxs"`
+			GrpSts  string `xml:"GrpSts"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		OrigPayments []origPayment `xml:"OrgnlPmtInfAndSts"`
+	}
+
+	origPayment struct {
+		ID       string         `xml:"OrgnlPmtInfId"`
+		TxStatus []txInfoStatus `xml:"TxInfAndSts"`
+	}
+
+	txInfoStatus struct {
+		InstrID    string `xml:"OrgnlInstrId,omitempty"`
+		EndToEndID string `xml:"OrgnlEndToEndId,omitempty"`
+		Status     string `xml:"TxSts"`
+		Reason     string `xml:"StsRsnInf>AddtlInf,omitempty"`
+	}
+)
+
+// StatusReport produces a pain.002 document reporting statuses
+// for the transfers of the original message m.
+// Statuses must appear in the same order as m.Transfers.
+func StatusReport(msgID string, m *Message, statuses []TxStatus, now time.Time) ([]byte, error) {
+	var doc statusDocument
+	doc.NS = pain002NS
+	r := &doc.Report
+	r.GroupHeader.MsgID = msgID
+	r.GroupHeader.Creation = now.UTC().Format("2006-01-02T15:04:05")
+	r.OrigGroup.MsgID = m.ID
+	r.OrigGroup.MsgName = pain001Name
+	r.OrigGroup.NbOfTxs = len(statuses)
+
+	var accepted int
+	for _, s := range statuses {
+		if s.Status == StatusAccepted {
+			accepted++
+		}
+		if n := len(r.OrigPayments); n == 0 || r.OrigPayments[n-1].ID != s.PaymentInfoID {
+			r.OrigPayments = append(r.OrigPayments, origPayment{ID: s.PaymentInfoID})
+		}
+		p := &r.OrigPayments[len(r.OrigPayments)-1]
+		p.TxStatus = append(p.TxStatus, txInfoStatus{
+			InstrID:    s.InstructionID,
+			EndToEndID: s.EndToEndID,
+			Status:     s.Status,
+			Reason:     s.Reason,
+		})
+	}
+	switch accepted {
+	case len(statuses):
+		r.OrigGroup.GrpSts = StatusAccepted
+	case 0:
+		r.OrigGroup.GrpSts = StatusRejected
+	default:
+		r.OrigGroup.GrpSts = StatusPartial
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return append([]byte(xml.Header), b...), nil
+}
@@ -0,0 +1,120 @@
+package iso20022
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"chain/errors"
+)
+
+const samplePain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>MSG-1</MsgId>
+      <CreDtTm>2016-10-20T10:00:00</CreDtTm>
+      <NbOfTxs>2</NbOfTxs>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <DbtrAcct><Id><Othr><Id>alice</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><InstrId>I-1</InstrId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="USD">100.00</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>bob</Id></Othr></Id></CdtrAcct>
+        <RmtInf><Ustrd>invoice</Ustrd><Ustrd>42</Ustrd></RmtInf>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">5</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestParsePain001(t *testing.T) {
+	m, err := ParsePain001([]byte(samplePain001))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != "MSG-1" {
+		t.Errorf("ID = %q want MSG-1", m.ID)
+	}
+	want := []Transfer{{
+		PaymentInfoID:   "PMT-1",
+		InstructionID:   "I-1",
+		EndToEndID:      "E2E-1",
+		DebtorAccount:   "alice",
+		CreditorAccount: "bob",
+		Currency:        "USD",
+		Amount:          100,
+		Remittance:      "invoice 42",
+	}, {
+		PaymentInfoID:   "PMT-1",
+		EndToEndID:      "E2E-2",
+		DebtorAccount:   "alice",
+		CreditorAccount: "DE89370400440532013000",
+		Currency:        "EUR",
+		Amount:          5,
+	}}
+	if len(m.Transfers) != len(want) {
+		t.Fatalf("got %d transfers want %d", len(m.Transfers), len(want))
+	}
+	for i := range want {
+		if m.Transfers[i] != want[i] {
+			t.Errorf("transfer %d = %+v want %+v", i, m.Transfers[i], want[i])
+		}
+	}
+}
+
+func TestParsePain001Errors(t *testing.T) {
+	cases := []string{
+		`not xml`,
+		`<Document></Document>`,
+		strings.Replace(samplePain001, "100.00", "100.50", 1),
+		strings.Replace(samplePain001, "<NbOfTxs>2", "<NbOfTxs>3", 1),
+		strings.Replace(samplePain001, ` Ccy="EUR"`, "", 1),
+		strings.Replace(samplePain001, "<MsgId>MSG-1</MsgId>", "", 1),
+	}
+	for i, c := range cases {
+		_, err := ParsePain001([]byte(c))
+		if errors.Root(err) != ErrBadMessage {
+			t.Errorf("case %d: err = %v want %v", i, err, ErrBadMessage)
+		}
+	}
+}
+
+func TestStatusReport(t *testing.T) {
+	m, err := ParsePain001([]byte(samplePain001))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2016, 10, 20, 11, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		statuses []string
+		want     string
+	}{
+		{[]string{StatusAccepted, StatusAccepted}, "<GrpSts>ACTC</GrpSts>"},
+		{[]string{StatusAccepted, StatusRejected}, "<GrpSts>PART</GrpSts>"},
+		{[]string{StatusRejected, StatusRejected}, "<GrpSts>RJCT</GrpSts>"},
+	}
+	for _, c := range cases {
+		var statuses []TxStatus
+		for i, s := range c.statuses {
+			statuses = append(statuses, TxStatus{Transfer: m.Transfers[i], Status: s})
+		}
+		b, err := StatusReport("RPT-1", m, statuses, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(b)
+		for _, w := range []string{c.want, "<OrgnlMsgId>MSG-1</OrgnlMsgId>", "<OrgnlEndToEndId>E2E-2</OrgnlEndToEndId>", "<CreDtTm>2016-10-20T11:00:00</CreDtTm>"} {
+			if !strings.Contains(got, w) {
+				t.Errorf("report missing %s:\n%s", w, got)
+			}
+		}
+	}
+}
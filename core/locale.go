@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type localeKey int
+
+const errorLocaleKey localeKey = 0
+
+// localeHandler picks the locale of error messages for each
+// request from its Accept-Language header. Error codes are the
+// same in every locale; only the messages are translated.
+func localeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if locale := matchLocale(req.Header.Get("Accept-Language")); locale != "" {
+			ctx := context.WithValue(req.Context(), errorLocaleKey, locale)
+			req = req.WithContext(ctx)
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// matchLocale returns the most preferred locale in header, an
+// Accept-Language value, that has an error message catalog, or ""
+// for English. A language range such as "es-MX" matches the
+// catalog for "es". English, or "*", ends the search, since it is
+// always available.
+func matchLocale(header string) string {
+	type lang struct {
+		tag string
+		q   float64
+	}
+	var langs []lang
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		l := lang{tag: strings.ToLower(strings.TrimSpace(fields[0])), q: 1}
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				q, err := strconv.ParseFloat(f[2:], 64)
+				if err != nil {
+					q = 0
+				}
+				l.q = q
+			}
+		}
+		if l.tag != "" && l.q > 0 {
+			langs = append(langs, l)
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	for _, l := range langs {
+		tag := l.tag
+		if i := strings.IndexByte(tag, '-'); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag == "en" || tag == "*" {
+			return ""
+		}
+		if _, ok := errorMessages[tag]; ok {
+			return tag
+		}
+	}
+	return ""
+}
+
+// localizeMessage returns the message for code in the
+// locale of ctx, or msg if there is no translation.
+func localizeMessage(ctx context.Context, code, msg string) string {
+	locale, _ := ctx.Value(errorLocaleKey).(string)
+	if m, ok := errorMessages[locale][code]; ok {
+		return m
+	}
+	return msg
+}
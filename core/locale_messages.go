@@ -0,0 +1,307 @@
+package core
+
+// errorMessages holds translations of error messages, by locale
+// and then by error code. A code missing from a catalog falls
+// back to the English message in errorInfoTab.
+var errorMessages = map[string]map[string]string{
+	"es": {
+		"CH000": "Error de la API de Chain",
+		"CH001": "La solicitud ha excedido el tiempo de espera",
+		"CH002": "No encontrado",
+		"CH003": "Cuerpo de la solicitud no válido",
+		"CH004": "Encabezado de la solicitud no válido",
+		"CH006": "No encontrado",
+		"CH007": "Se ha superado el límite de solicitudes",
+		"CH008": "Se está eligiendo un nuevo líder para el core; inténtelo de nuevo en breve",
+		"CH009": "No se pudo autenticar la solicitud",
+		"CH010": "El token de acceso está limitado a una sola cuenta",
+		"CH050": "El alias ya existe",
+		"CH051": "El plazo para restaurar ha vencido",
+		"CH100": "Este core aún debe configurarse",
+		"CH101": "Este core ya está configurado",
+		"CH102": "La URL del generador devolvió una respuesta no válida",
+		"CH103": "La XPub de bloque proporcionada no es válida",
+		"CH104": "Un core par opera en una red de blockchain distinta",
+		"CH105": "La altura solicitada está demasiado adelantada",
+		"CH106": "La URL del firmante de bloques no es válida",
+		"CH107": "La clave pública del firmante de bloques no es válida",
+		"CH108": "El quórum debe ser mayor que 0 si hay firmantes",
+		"CH110": "Solo se puede restablecer en un sistema de desarrollo",
+		"CH120": "No se puede activar la autenticación de clientes sin tokens de cliente",
+		"CH150": "No se firmará un bloque con un cambio de consenso",
+		"CH200": "El quórum debe ser mayor que 1 y menor o igual que el número de xpubs",
+		"CH201": "Formato de xpub no válido",
+		"CH202": "Se requiere al menos una xpub",
+		"CH203": "El tipo obtenido no coincide con el esperado",
+		"CH204": "Las XPubs raíz no pueden contener la misma clave más de una vez",
+		"CH300": "ID de token de acceso vacío o con formato incorrecto",
+		"CH301": "Los tokens de acceso deben ser de tipo client o network",
+		"CH302": "El ID de token de acceso ya está en uso",
+		"CH310": "El token de acceso usado para autenticar esta solicitud no se puede eliminar",
+		"CH600": "Parámetro de paginación `after` con formato incorrecto",
+		"CH601": "Número incorrecto de parámetros para el filtro",
+		"CH602": "Filtro de consulta con formato incorrecto",
+		"CH603": "El historial de salidas anterior al periodo de retención se ha depurado",
+		"CH700": "Los datos de referencia no coinciden con los de la transacción anterior",
+		"CH701": "Tipo de acción no válido",
+		"CH702": "Alias no válido en la acción",
+		"CH703": "Objeto de acción no válido",
+		"CH704": "Cantidad de activo no válida",
+		"CH705": "Transacción insegura: deja activos que pueden tomarse sin exigir un pago",
+		"CH706": "Mensaje de pago ISO 20022 no válido",
+		"CH707": "El ID de extremo a extremo no coincide con los datos de referencia de la transacción",
+		"CH708": "La cantidad de la salida es inferior al mínimo del activo",
+		"CH709": "Comisión no válida",
+		"CH710": "Votación no válida",
+		"CH711": "Ya se emitieron los derechos de voto de esta votación",
+		"CH712": "La acción no está permitida después del plazo de la votación",
+		"CH720": "HTLC no válido",
+		"CH721": "El HTLC no tiene salidas de contrato",
+		"CH722": "El secreto no coincide con el hash del HTLC",
+		"CH723": "El HTLC ha vencido",
+		"CH724": "El HTLC aún no ha vencido",
+		"CH730": "Falta la transacción en bruto",
+		"CH731": "Demasiadas instrucciones de firma en la plantilla para la transacción",
+		"CH732": "Índice de entrada de la transacción no válido",
+		"CH733": "Componente de testigo no válido",
+		"CH735": "Transacción rechazada",
+		"CH736": "La transacción no es definitiva; aún se permiten acciones adicionales",
+		"CH737": "Modo de sighash no válido",
+		"CH738": "La comisión de la transacción es inferior al mínimo",
+		"CH739": "El tiempo mínimo está demasiado lejos en el futuro",
+		"CH740": "Depósito en garantía no válido",
+		"CH741": "El depósito en garantía no tiene salidas de contrato",
+		"CH750": "Subasta no válida",
+		"CH751": "La subasta no está abierta",
+		"CH760": "Fondos insuficientes para la transacción",
+		"CH761": "Algunas salidas están reservadas; inténtelo de nuevo",
+		"CH762": "La salida aún no está confirmada",
+		"CH763": "La salida está bloqueada temporalmente",
+		"CH764": "Hora de desbloqueo no válida",
+		"CH765": "Hay muy pocas salidas para consolidar",
+		"CH766": "El programa de control ya se ha utilizado",
+		"CH767": "La salida pertenece a otra cuenta",
+		"CH770": "Canal de pago no válido",
+		"CH771": "El canal de pago no tiene salida de contrato",
+		"CH772": "Estado del canal de pago no válido",
+		"CH773": "La acción no está permitida en este momento respecto al periodo de disputa",
+		"CH780": "Campaña de micromecenazgo no válida",
+		"CH781": "La campaña no ha alcanzado su objetivo",
+		"CH782": "La acción no está permitida en este momento respecto al plazo de la campaña",
+		"CH790": "Suscripción no válida",
+		"CH791": "La suscripción no tiene un contrato con fondos",
+		"CH792": "El periodo de facturación no ha comenzado",
+		"CH801": "Valor de `after` no válido en la consulta",
+		"CH802": "Demasiados alias para listar",
+		"CH810": "Regla de reenvío no válida",
+		"CH820": "Política de emisión no válida",
+		"CH821": "La emisión superaría la política de emisión del activo",
+		"CH822": "Esquema de definición de activo no válido",
+		"CH823": "La definición del activo no se ajusta a su esquema",
+		"CH830": "Nivel de billetera no válido",
+		"CH840": "La transacción gasta una salida bloqueada",
+		"CH841": "Actualización de la lista de bloqueo no válida",
+		"CH850": "Receptor no válido",
+		"CH851": "El receptor ha caducado",
+	},
+	"fr": {
+		"CH000": "Erreur de l'API Chain",
+		"CH001": "La requête a expiré",
+		"CH002": "Introuvable",
+		"CH003": "Corps de requête non valide",
+		"CH004": "En-tête de requête non valide",
+		"CH006": "Introuvable",
+		"CH007": "Limite de requêtes dépassée",
+		"CH008": "Élection d'un nouveau leader pour le core en cours ; réessayez bientôt",
+		"CH009": "La requête n'a pas pu être authentifiée",
+		"CH010": "Le jeton d'accès est limité à un seul compte",
+		"CH050": "L'alias existe déjà",
+		"CH051": "Le délai de restauration est dépassé",
+		"CH100": "Ce core doit encore être configuré",
+		"CH101": "Ce core est déjà configuré",
+		"CH102": "L'URL du générateur a renvoyé une réponse non valide",
+		"CH103": "La XPub de bloc fournie n'est pas valide",
+		"CH104": "Un core pair fonctionne sur un autre réseau blockchain",
+		"CH105": "La hauteur demandée est trop en avance",
+		"CH106": "L'URL du signataire de blocs n'est pas valide",
+		"CH107": "La clé publique du signataire de blocs n'est pas valide",
+		"CH108": "Le quorum doit être supérieur à 0 s'il y a des signataires",
+		"CH110": "La réinitialisation n'est possible que sur un système de développement",
+		"CH120": "Impossible d'activer l'authentification des clients sans jetons client",
+		"CH150": "Refus de signer un bloc comportant un changement de consensus",
+		"CH200": "Le quorum doit être supérieur à 1 et inférieur ou égal au nombre de xpubs",
+		"CH201": "Format de xpub non valide",
+		"CH202": "Au moins une xpub est requise",
+		"CH203": "Le type obtenu ne correspond pas au type attendu",
+		"CH204": "Les XPubs racines ne peuvent pas contenir la même clé plus d'une fois",
+		"CH300": "ID de jeton d'accès vide ou mal formé",
+		"CH301": "Les jetons d'accès doivent être de type client ou network",
+		"CH302": "L'ID de jeton d'accès est déjà utilisé",
+		"CH310": "Le jeton d'accès utilisé pour authentifier cette requête ne peut pas être supprimé",
+		"CH600": "Paramètre de pagination `after` mal formé",
+		"CH601": "Nombre incorrect de paramètres pour le filtre",
+		"CH602": "Filtre de requête mal formé",
+		"CH603": "L'historique des sorties antérieur à la période de rétention a été purgé",
+		"CH700": "Les données de référence ne correspondent pas à celles de la transaction précédente",
+		"CH701": "Type d'action non valide",
+		"CH702": "Alias non valide dans l'action",
+		"CH703": "Objet d'action non valide",
+		"CH704": "Montant d'actif non valide",
+		"CH705": "Transaction non sûre : des actifs peuvent être pris sans paiement",
+		"CH706": "Message de paiement ISO 20022 non valide",
+		"CH707": "L'ID de bout en bout ne correspond pas aux données de référence de la transaction",
+		"CH708": "Le montant de la sortie est inférieur au minimum de l'actif",
+		"CH709": "Frais non valides",
+		"CH710": "Scrutin non valide",
+		"CH711": "Les droits de vote de ce scrutin ont déjà été émis",
+		"CH712": "L'action n'est pas autorisée après la date limite du scrutin",
+		"CH720": "HTLC non valide",
+		"CH721": "Le HTLC n'a aucune sortie de contrat",
+		"CH722": "Le secret ne correspond pas au hachage du HTLC",
+		"CH723": "Le HTLC a expiré",
+		"CH724": "Le HTLC n'a pas encore expiré",
+		"CH730": "Transaction brute manquante",
+		"CH731": "Trop d'instructions de signature dans le modèle pour la transaction",
+		"CH732": "Index d'entrée de transaction non valide",
+		"CH733": "Composant de témoin non valide",
+		"CH735": "Transaction rejetée",
+		"CH736": "La transaction n'est pas définitive ; des actions supplémentaires sont encore autorisées",
+		"CH737": "Mode sighash non valide",
+		"CH738": "Les frais de transaction sont inférieurs au minimum",
+		"CH739": "L'heure minimale est trop éloignée dans le futur",
+		"CH740": "Séquestre non valide",
+		"CH741": "Le séquestre n'a aucune sortie de contrat",
+		"CH750": "Enchère non valide",
+		"CH751": "L'enchère n'est pas ouverte",
+		"CH760": "Fonds insuffisants pour la transaction",
+		"CH761": "Certaines sorties sont réservées ; réessayez",
+		"CH762": "La sortie n'est pas encore confirmée",
+		"CH763": "La sortie est verrouillée dans le temps",
+		"CH764": "Heure de déverrouillage non valide",
+		"CH765": "Trop peu de sorties à consolider",
+		"CH766": "Le programme de contrôle a déjà été utilisé",
+		"CH767": "La sortie appartient à un autre compte",
+		"CH770": "Canal de paiement non valide",
+		"CH771": "Le canal de paiement n'a aucune sortie de contrat",
+		"CH772": "État du canal de paiement non valide",
+		"CH773": "L'action n'est pas autorisée à ce moment par rapport à la période de contestation",
+		"CH780": "Campagne de financement participatif non valide",
+		"CH781": "La campagne n'a pas atteint son objectif",
+		"CH782": "L'action n'est pas autorisée à ce moment par rapport à la date limite de la campagne",
+		"CH790": "Abonnement non valide",
+		"CH791": "L'abonnement n'a aucun contrat approvisionné",
+		"CH792": "La période de facturation n'a pas commencé",
+		"CH801": "Valeur `after` non valide dans la requête",
+		"CH802": "Trop d'alias à lister",
+		"CH810": "Règle de transfert non valide",
+		"CH820": "Politique d'émission non valide",
+		"CH821": "L'émission dépasserait la politique d'émission de l'actif",
+		"CH822": "Schéma de définition d'actif non valide",
+		"CH823": "La définition de l'actif n'est pas conforme à son schéma",
+		"CH830": "Niveau de portefeuille non valide",
+		"CH840": "La transaction dépense une sortie bloquée",
+		"CH841": "Mise à jour de la liste de blocage non valide",
+		"CH850": "Destinataire non valide",
+		"CH851": "Le destinataire a expiré",
+	},
+	"de": {
+		"CH000": "Fehler der Chain-API",
+		"CH001": "Zeitüberschreitung der Anfrage",
+		"CH002": "Nicht gefunden",
+		"CH003": "Ungültiger Anfragetext",
+		"CH004": "Ungültiger Anfrage-Header",
+		"CH006": "Nicht gefunden",
+		"CH007": "Anfragelimit überschritten",
+		"CH008": "Für den Core wird ein neuer Leader gewählt; bitte versuchen Sie es gleich erneut",
+		"CH009": "Die Anfrage konnte nicht authentifiziert werden",
+		"CH010": "Das Zugriffstoken ist auf ein Konto beschränkt",
+		"CH050": "Der Alias existiert bereits",
+		"CH051": "Die Frist für die Wiederherstellung ist abgelaufen",
+		"CH100": "Dieser Core muss noch konfiguriert werden",
+		"CH101": "Dieser Core ist bereits konfiguriert",
+		"CH102": "Die Generator-URL hat eine ungültige Antwort geliefert",
+		"CH103": "Die angegebene Block-XPub ist ungültig",
+		"CH104": "Ein Peer-Core arbeitet in einem anderen Blockchain-Netzwerk",
+		"CH105": "Die angeforderte Höhe liegt zu weit voraus",
+		"CH106": "Die URL des Blocksignierers ist ungültig",
+		"CH107": "Der öffentliche Schlüssel des Blocksignierers ist ungültig",
+		"CH108": "Das Quorum muss größer als 0 sein, wenn es Signierer gibt",
+		"CH110": "Zurücksetzen ist nur in einem Entwicklungssystem möglich",
+		"CH120": "Client-Authentifizierung kann ohne Client-Tokens nicht aktiviert werden",
+		"CH150": "Ein Block mit einer Konsensänderung wird nicht signiert",
+		"CH200": "Das Quorum muss größer als 1 und höchstens gleich der Anzahl der xpubs sein",
+		"CH201": "Ungültiges xpub-Format",
+		"CH202": "Mindestens eine xpub ist erforderlich",
+		"CH203": "Der abgerufene Typ entspricht nicht dem erwarteten Typ",
+		"CH204": "Die Root-XPubs dürfen denselben Schlüssel nicht mehrfach enthalten",
+		"CH300": "Fehlerhafte oder leere Zugriffstoken-ID",
+		"CH301": "Zugriffstokens müssen vom Typ client oder network sein",
+		"CH302": "Die Zugriffstoken-ID wird bereits verwendet",
+		"CH310": "Das Zugriffstoken, mit dem diese Anfrage authentifiziert wurde, kann nicht gelöscht werden",
+		"CH600": "Fehlerhafter Paginierungsparameter `after`",
+		"CH601": "Falsche Anzahl von Parametern für den Filter",
+		"CH602": "Fehlerhafter Abfragefilter",
+		"CH603": "Der Ausgabeverlauf vor dem Aufbewahrungszeitraum wurde entfernt",
+		"CH700": "Die Referenzdaten stimmen nicht mit denen der vorherigen Transaktion überein",
+		"CH701": "Ungültiger Aktionstyp",
+		"CH702": "Ungültiger Alias in der Aktion",
+		"CH703": "Ungültiges Aktionsobjekt",
+		"CH704": "Ungültiger Asset-Betrag",
+		"CH705": "Unsichere Transaktion: Assets können ohne Zahlung entnommen werden",
+		"CH706": "Ungültige ISO-20022-Zahlungsnachricht",
+		"CH707": "Die End-to-End-ID stimmt nicht mit den Referenzdaten der Transaktion überein",
+		"CH708": "Der Ausgabebetrag liegt unter dem Minimum des Assets",
+		"CH709": "Ungültige Gebühr",
+		"CH710": "Ungültige Abstimmung",
+		"CH711": "Die Stimmrechte für diese Abstimmung wurden bereits ausgegeben",
+		"CH712": "Die Aktion ist nach dem Stichtag der Abstimmung nicht zulässig",
+		"CH720": "Ungültiger HTLC",
+		"CH721": "Der HTLC hat keine Vertragsausgaben",
+		"CH722": "Das Geheimnis passt nicht zum Hash des HTLC",
+		"CH723": "Der HTLC ist abgelaufen",
+		"CH724": "Der HTLC ist noch nicht abgelaufen",
+		"CH730": "Rohtransaktion fehlt",
+		"CH731": "Zu viele Signaturanweisungen in der Vorlage für die Transaktion",
+		"CH732": "Ungültiger Eingabeindex der Transaktion",
+		"CH733": "Ungültige Witness-Komponente",
+		"CH735": "Transaktion abgelehnt",
+		"CH736": "Die Transaktion ist nicht endgültig; weitere Aktionen sind noch zulässig",
+		"CH737": "Ungültiger Sighash-Modus",
+		"CH738": "Die Transaktionsgebühr liegt unter dem Minimum",
+		"CH739": "Die Mindestzeit liegt zu weit in der Zukunft",
+		"CH740": "Ungültiges Treuhandkonto",
+		"CH741": "Das Treuhandkonto hat keine Vertragsausgaben",
+		"CH750": "Ungültige Auktion",
+		"CH751": "Die Auktion ist nicht geöffnet",
+		"CH760": "Unzureichende Mittel für die Transaktion",
+		"CH761": "Einige Ausgaben sind reserviert; bitte erneut versuchen",
+		"CH762": "Die Ausgabe ist noch nicht bestätigt",
+		"CH763": "Die Ausgabe ist zeitlich gesperrt",
+		"CH764": "Ungültige Entsperrzeit",
+		"CH765": "Zu wenige Ausgaben zum Konsolidieren",
+		"CH766": "Das Kontrollprogramm wurde bereits verwendet",
+		"CH767": "Die Ausgabe gehört zu einem anderen Konto",
+		"CH770": "Ungültiger Zahlungskanal",
+		"CH771": "Der Zahlungskanal hat keine Vertragsausgabe",
+		"CH772": "Ungültiger Zustand des Zahlungskanals",
+		"CH773": "Die Aktion ist zu diesem Zeitpunkt in Bezug auf die Einspruchsfrist nicht zulässig",
+		"CH780": "Ungültige Crowdfunding-Kampagne",
+		"CH781": "Die Kampagne hat ihr Ziel nicht erreicht",
+		"CH782": "Die Aktion ist zu diesem Zeitpunkt in Bezug auf den Stichtag der Kampagne nicht zulässig",
+		"CH790": "Ungültiges Abonnement",
+		"CH791": "Das Abonnement hat keinen gedeckten Vertrag",
+		"CH792": "Der Abrechnungszeitraum hat noch nicht begonnen",
+		"CH801": "Ungültiger Wert für `after` in der Abfrage",
+		"CH802": "Zu viele Aliase zum Auflisten",
+		"CH810": "Ungültige Weiterleitungsregel",
+		"CH820": "Ungültige Ausgaberichtlinie",
+		"CH821": "Die Ausgabe würde die Ausgaberichtlinie des Assets überschreiten",
+		"CH822": "Ungültiges Schema für Asset-Definitionen",
+		"CH823": "Die Asset-Definition entspricht nicht ihrem Schema",
+		"CH830": "Ungültige Wallet-Stufe",
+		"CH840": "Die Transaktion gibt eine gesperrte Ausgabe aus",
+		"CH841": "Ungültige Aktualisierung der Sperrliste",
+		"CH850": "Ungültiger Empfänger",
+		"CH851": "Der Empfänger ist abgelaufen",
+	},
+}
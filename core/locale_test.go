@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"chain/database/pg"
+)
+
+func TestMatchLocale(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"es", "es"},
+		{"es-MX", "es"},
+		{"FR-ca, en;q=0.8", "fr"},
+		{"en-US, fr;q=0.9", ""},
+		{"it, de;q=0.5, fr;q=0.7", "fr"},
+		{"de;q=0, fr;q=0.1", "fr"},
+		{"*, de;q=0.5", ""},
+		{"it, pt", ""},
+		{"de;q=bogus", ""},
+	}
+	for _, c := range cases {
+		got := matchLocale(c.header)
+		if got != c.want {
+			t.Errorf("matchLocale(%q) = %q want %q", c.header, got, c.want)
+		}
+	}
+}
+
+func TestLocalizedErrInfo(t *testing.T) {
+	ctx := context.WithValue(context.Background(), errorLocaleKey, "es")
+	body, info := errInfo(ctx, pg.ErrUserInputNotFound)
+	if body.ChainCode != "CH002" || body.Message != "No encontrado" {
+		t.Errorf("got body %s %q, want CH002 %q", body.ChainCode, body.Message, "No encontrado")
+	}
+	if info.Message != "Not found" {
+		t.Errorf("got info message %q, want English", info.Message)
+	}
+}
+
+// Every translated message must be for an error code in use,
+// so that a catalog can't drift from errorInfoTab unnoticed.
+func TestErrorMessageCodes(t *testing.T) {
+	codes := map[string]bool{infoInternal.ChainCode: true}
+	for _, info := range errorInfoTab {
+		codes[info.ChainCode] = true
+	}
+	for locale, catalog := range errorMessages {
+		for code := range catalog {
+			if !codes[code] {
+				t.Errorf("%s catalog has unknown code %s", locale, code)
+			}
+		}
+	}
+}
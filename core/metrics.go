@@ -69,3 +69,10 @@ func countCore(id string) {
 	}
 	coresSeen[id] = true
 }
+
+// Per-class request counts for endpoints with concurrency limits.
+// See Handler.ConcurrencyLimits.
+var (
+	concurrencyInflight = expvar.NewMap("concurrency.inflight")
+	concurrencyQueued   = expvar.NewMap("concurrency.queued")
+)
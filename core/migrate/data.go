@@ -24,4 +24,28 @@ func init() {
 var migrations = []migration{
 	{Name: "2016-10-17.0.core.schema-snapshot.sql", SQL: "--\n-- PostgreSQL database dump\n--\n\n-- Dumped from database version 9.5.2\n-- Dumped by pg_dump version 9.5.2\n\nSET statement_timeout = 0;\nSET lock_timeout = 0;\nSET client_encoding = 'UTF8';\nSET standard_conforming_strings = on;\nSET check_function_bodies = false;\nSET client_min_messages = warning;\nSET row_security = off;\n\n--\n-- Name: plpgsql; Type: EXTENSION; Schema: -; Owner: -\n--\n\nCREATE EXTENSION IF NOT EXISTS plpgsql WITH SCHEMA pg_catalog;\n\n\n--\n--\n\n\n\nSET search_path = public, pg_catalog;\n\n--\n-- Name: access_token_type; Type: TYPE; Schema: public; Owner: -\n--\n\nCREATE TYPE access_token_type AS ENUM (\n    'client',\n    'network'\n);\n\n\n--\n-- Name: b32enc_crockford(bytea); Type: FUNCTION; Schema: public; Owner: -\n--\n\nCREATE FUNCTION b32enc_crockford(src bytea) RETURNS text\n    LANGUAGE plpgsql IMMUTABLE\n    AS $$\n\t-- Adapted from the Go package encoding/base32.\n\t-- See https://golang.org/src/encoding/base32/base32.go.\n\t-- NOTE(kr): this function does not pad its output\nDECLARE\n\t-- alphabet is the base32 alphabet defined\n\t-- by Douglas Crockford. It preserves lexical\n\t-- order and avoids visually-similar symbols.\n\t-- See http://www.crockford.com/wrmg/base32.html.\n\talphabet text := '0123456789ABCDEFGHJKMNPQRSTVWXYZ';\n\tdst text := '';\n\tn integer;\n\tb0 integer;\n\tb1 integer;\n\tb2 integer;\n\tb3 integer;\n\tb4 integer;\n\tb5 integer;\n\tb6 integer;\n\tb7 integer;\nBEGIN\n\tFOR r IN 0..(length(src)-1) BY 5\n\tLOOP\n\t\tb0:=0; b1:=0; b2:=0; b3:=0; b4:=0; b5:=0; b6:=0; b7:=0;\n\n\t\t-- Unpack 8x 5-bit source blocks into an 8 byte\n\t\t-- destination quantum\n\t\tn := length(src) - r;\n\t\tIF n >= 5 THEN\n\t\t\tb7 := get_byte(src, r+4) & 31;\n\t\t\tb6 := get_byte(src, r+4) >> 5;\n\t\tEND IF;\n\t\tIF n >= 4 THEN\n\t\t\tb6 := b6 | (get_byte(src, r+3) << 3) & 31;\n\t\t\tb5 := (get_byte(src, r+3) >> 2) & 31;\n\t\t\tb4 := get_byte(src, r+3) >> 7;\n\t\tEND IF;\n\t\tIF n >= 3 THEN\n\t\t\tb4 := b4 | (get_byte(src, r+2) << 1) & 31;\n\t\t\tb3 := (get_byte(src, r+2) >> 4) & 31;\n\t\tEND IF;\n\t\tIF n >= 2 THEN\n\t\t\tb3 := b3 | (get_byte(src, r+1) << 4) & 31;\n\t\t\tb2 := (get_byte(src, r+1) >> 1) & 31;\n\t\t\tb1 := (get_byte(src, r+1) >> 6) & 31;\n\t\tEND IF;\n\t\tb1 := b1 | (get_byte(src, r) << 2) & 31;\n\t\tb0 := get_byte(src, r) >> 3;\n\n\t\t-- Encode 5-bit blocks using the base32 alphabet\n\t\tdst := dst || substr(alphabet, b0+1, 1);\n\t\tdst := dst || substr(alphabet, b1+1, 1);\n\t\tIF n >= 2 THEN\n\t\t\tdst := dst || substr(alphabet, b2+1, 1);\n\t\t\tdst := dst || substr(alphabet, b3+1, 1);\n\t\tEND IF;\n\t\tIF n >= 3 THEN\n\t\t\tdst := dst || substr(alphabet, b4+1, 1);\n\t\tEND IF;\n\t\tIF n >= 4 THEN\n\t\t\tdst := dst || substr(alphabet, b5+1, 1);\n\t\t\tdst := dst || substr(alphabet, b6+1, 1);\n\t\tEND IF;\n\t\tIF n >= 5 THEN\n\t\t\tdst := dst || substr(alphabet, b7+1, 1);\n\t\tEND IF;\n\tEND LOOP;\n\tRETURN dst;\nEND;\n$$;\n\n\n--\n-- Name: cancel_reservation(integer); Type: FUNCTION; Schema: public; Owner: -\n--\n\nCREATE FUNCTION cancel_reservation(inp_reservation_id integer) RETURNS void\n    LANGUAGE plpgsql\n    AS $$\nBEGIN\n    DELETE FROM reservations WHERE reservation_id = inp_reservation_id;\nEND;\n$$;\n\n\n--\n-- Name: create_reservation(text, text, timestamp with time zone, text); Type: FUNCTION; Schema: public; Owner: -\n--\n\nCREATE FUNCTION create_reservation(inp_asset_id text, inp_account_id text, inp_expiry timestamp with time zone, inp_idempotency_key text, OUT reservation_id integer, OUT already_existed boolean, OUT existing_change bigint) RETURNS record\n    LANGUAGE plpgsql\n    AS $$\nDECLARE\n    row RECORD;\nBEGIN\n    INSERT INTO reservations (asset_id, account_id, expiry, idempotency_key)\n        VALUES (inp_asset_id, inp_account_id, inp_expiry, inp_idempotency_key)\n        ON CONFLICT (idempotency_key) DO NOTHING\n        RETURNING reservations.reservation_id, FALSE AS already_existed, CAST(0 AS BIGINT) AS existing_change INTO row;\n    -- Iff the insert was successful, then a row is returned. The IF NOT FOUND check\n    -- will be true iff the insert failed because the row already exists.\n    IF NOT FOUND THEN\n        SELECT r.reservation_id, TRUE AS already_existed, r.change AS existing_change INTO STRICT row\n            FROM reservations r\n            WHERE r.idempotency_key = inp_idempotency_key;\n    END IF;\n    reservation_id := row.reservation_id;\n    already_existed := row.already_existed;\n    existing_change := row.existing_change;\nEND;\n$$;\n\n\n--\n-- Name: expire_reservations(); Type: FUNCTION; Schema: public; Owner: -\n--\n\nCREATE FUNCTION expire_reservations() RETURNS void\n    LANGUAGE plpgsql\n    AS $$\nBEGIN\n    DELETE FROM reservations WHERE expiry < CURRENT_TIMESTAMP;\nEND;\n$$;\n\n\n--\n-- Name: next_chain_id(text); Type: FUNCTION; Schema: public; Owner: -\n--\n\nCREATE FUNCTION next_chain_id(prefix text) RETURNS text\n    LANGUAGE plpgsql\n    AS $$\n\t-- Adapted from the technique published by Instagram.\n\t-- See http://instagram-engineering.tumblr.com/post/10853187575/sharding-ids-at-instagram.\nDECLARE\n\tour_epoch_ms bigint := 1433333333333; -- do not change\n\tseq_id bigint;\n\tnow_ms bigint;     -- from unix epoch, not ours\n\tshard_id int := 4; -- must be different on each shard\n\tn bigint;\nBEGIN\n\tSELECT nextval('chain_id_seq') % 1024 INTO seq_id;\n\tSELECT FLOOR(EXTRACT(EPOCH FROM clock_timestamp()) * 1000) INTO now_ms;\n\tn := (now_ms - our_epoch_ms) << 23;\n\tn := n | (shard_id << 10);\n\tn := n | (seq_id);\n\tRETURN prefix || b32enc_crockford(int8send(n));\nEND;\n$$;\n\n\n--\n-- Name: reserve_utxo(text, bigint, timestamp with time zone, text); Type: FUNCTION; Schema: public; Owner: -\n--\n\nCREATE FUNCTION reserve_utxo(inp_tx_hash text, inp_out_index bigint, inp_expiry timestamp with time zone, inp_idempotency_key text) RETURNS record\n    LANGUAGE plpgsql\n    AS $$\nDECLARE\n    res RECORD;\n    row RECORD;\n    ret RECORD;\nBEGIN\n    SELECT * FROM create_reservation(NULL, NULL, inp_expiry, inp_idempotency_key) INTO STRICT res;\n    IF res.already_existed THEN\n      SELECT res.reservation_id, res.already_existed, res.existing_change, CAST(0 AS BIGINT) AS amount, FALSE AS insufficient INTO ret;\n      RETURN ret;\n    END IF;\n\n    SELECT tx_hash, index, amount INTO row\n        FROM account_utxos u\n        WHERE inp_tx_hash = tx_hash\n              AND inp_out_index = index\n              AND reservation_id IS NULL\n        LIMIT 1\n        FOR UPDATE\n        SKIP LOCKED;\n    IF FOUND THEN\n        UPDATE account_utxos SET reservation_id = res.reservation_id\n            WHERE (tx_hash, index) = (row.tx_hash, row.index);\n    ELSE\n      PERFORM cancel_reservation(res.reservation_id);\n      res.reservation_id := 0;\n    END IF;\n\n    SELECT res.reservation_id, res.already_existed, EXISTS(SELECT tx_hash FROM account_utxos WHERE tx_hash = inp_tx_hash AND index = inp_out_index) INTO ret;\n    RETURN ret;\nEND;\n$$;\n\n\n--\n-- Name: reserve_utxos(text, text, text, bigint, bigint, timestamp with time zone, text); Type: FUNCTION; Schema: public; Owner: -\n--\n\nCREATE FUNCTION reserve_utxos(inp_asset_id text, inp_account_id text, inp_tx_hash text, inp_out_index bigint, inp_amt bigint, inp_expiry timestamp with time zone, inp_idempotency_key text) RETURNS record\n    LANGUAGE plpgsql\n    AS $$\nDECLARE\n    res RECORD;\n    row RECORD;\n    ret RECORD;\n    available BIGINT := 0;\n    unavailable BIGINT := 0;\nBEGIN\n    SELECT * FROM create_reservation(inp_asset_id, inp_account_id, inp_expiry, inp_idempotency_key) INTO STRICT res;\n    IF res.already_existed THEN\n      SELECT res.reservation_id, res.already_existed, res.existing_change, CAST(0 AS BIGINT) AS amount, FALSE AS insufficient INTO ret;\n      RETURN ret;\n    END IF;\n\n    LOOP\n        SELECT tx_hash, index, amount INTO row\n            FROM account_utxos u\n            WHERE asset_id = inp_asset_id\n                  AND inp_account_id = account_id\n                  AND (inp_tx_hash IS NULL OR inp_tx_hash = tx_hash)\n                  AND (inp_out_index IS NULL OR inp_out_index = index)\n                  AND reservation_id IS NULL\n            LIMIT 1\n            FOR UPDATE\n            SKIP LOCKED;\n        IF FOUND THEN\n            UPDATE account_utxos SET reservation_id = res.reservation_id\n                WHERE (tx_hash, index) = (row.tx_hash, row.index);\n            available := available + row.amount;\n            IF available >= inp_amt THEN\n                EXIT;\n            END IF;\n        ELSE\n            EXIT;\n        END IF;\n    END LOOP;\n\n    IF available < inp_amt THEN\n        SELECT SUM(change) AS change INTO STRICT row\n            FROM reservations\n            WHERE asset_id = inp_asset_id AND account_id = inp_account_id;\n        unavailable := row.change;\n        PERFORM cancel_reservation(res.reservation_id);\n        res.reservation_id := 0;\n    ELSE\n        UPDATE reservations SET change = available - inp_amt\n            WHERE reservation_id = res.reservation_id;\n    END IF;\n\n    SELECT res.reservation_id, res.already_existed, CAST(0 AS BIGINT) AS existing_change, available AS amount, (available+unavailable < inp_amt) AS insufficient INTO ret;\n    RETURN ret;\nEND;\n$$;\n\n\nSET default_tablespace = '';\n\nSET default_with_oids = false;\n\n--\n-- Name: access_tokens; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE access_tokens (\n    id text NOT NULL,\n    sort_id text DEFAULT next_chain_id('at'::text),\n    type access_token_type NOT NULL,\n    hashed_secret bytea NOT NULL,\n    created timestamp with time zone DEFAULT now() NOT NULL\n);\n\n\n--\n-- Name: account_control_program_seq; Type: SEQUENCE; Schema: public; Owner: -\n--\n\nCREATE SEQUENCE account_control_program_seq\n    START WITH 10001\n    INCREMENT BY 10000\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\n\n\n--\n-- Name: account_control_programs; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE account_control_programs (\n    id text DEFAULT next_chain_id('acp'::text) NOT NULL,\n    signer_id text NOT NULL,\n    key_index bigint NOT NULL,\n    control_program bytea NOT NULL,\n    change boolean NOT NULL\n);\n\n\n--\n-- Name: account_utxos; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE account_utxos (\n    tx_hash text NOT NULL,\n    index integer NOT NULL,\n    asset_id text NOT NULL,\n    amount bigint NOT NULL,\n    account_id text NOT NULL,\n    control_program_index bigint NOT NULL,\n    reservation_id integer,\n    control_program bytea NOT NULL,\n    metadata bytea NOT NULL,\n    confirmed_in bigint,\n    block_pos integer,\n    block_timestamp bigint,\n    expiry_height bigint\n);\n\n\n--\n-- Name: accounts; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE accounts (\n    account_id text NOT NULL,\n    tags jsonb,\n    alias text\n);\n\n\n--\n-- Name: annotated_accounts; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE annotated_accounts (\n    id text NOT NULL,\n    data jsonb NOT NULL\n);\n\n\n--\n-- Name: annotated_assets; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE annotated_assets (\n    id text NOT NULL,\n    data jsonb NOT NULL,\n    sort_id text NOT NULL\n);\n\n\n--\n-- Name: annotated_outputs; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE annotated_outputs (\n    block_height bigint NOT NULL,\n    tx_pos integer NOT NULL,\n    output_index integer NOT NULL,\n    tx_hash text NOT NULL,\n    data jsonb NOT NULL,\n    timespan int8range NOT NULL\n);\n\n\n--\n-- Name: annotated_txs; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE annotated_txs (\n    block_height bigint NOT NULL,\n    tx_pos integer NOT NULL,\n    tx_hash text NOT NULL,\n    data jsonb NOT NULL\n);\n\n\n--\n-- Name: asset_tags; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE asset_tags (\n    asset_id text NOT NULL,\n    tags jsonb\n);\n\n\n--\n-- Name: assets; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE assets (\n    id text NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL,\n    definition_mutable boolean DEFAULT false NOT NULL,\n    sort_id text DEFAULT next_chain_id('asset'::text) NOT NULL,\n    issuance_program bytea NOT NULL,\n    client_token text,\n    initial_block_hash text NOT NULL,\n    signer_id text,\n    definition jsonb,\n    alias text,\n    first_block_height bigint\n);\n\n\n--\n-- Name: assets_key_index_seq; Type: SEQUENCE; Schema: public; Owner: -\n--\n\nCREATE SEQUENCE assets_key_index_seq\n    START WITH 1\n    INCREMENT BY 1\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\n\n\n--\n-- Name: blocks; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE blocks (\n    block_hash text NOT NULL,\n    height bigint NOT NULL,\n    data bytea NOT NULL,\n    header bytea NOT NULL\n);\n\n\n--\n-- Name: chain_id_seq; Type: SEQUENCE; Schema: public; Owner: -\n--\n\nCREATE SEQUENCE chain_id_seq\n    START WITH 1\n    INCREMENT BY 1\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\n\n\n--\n-- Name: config; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE config (\n    singleton boolean DEFAULT true NOT NULL,\n    is_signer boolean,\n    is_generator boolean,\n    blockchain_id text NOT NULL,\n    configured_at timestamp with time zone NOT NULL,\n    generator_url text DEFAULT ''::text NOT NULL,\n    block_xpub text DEFAULT ''::text NOT NULL,\n    remote_block_signers bytea DEFAULT '\\x'::bytea NOT NULL,\n    generator_access_token text DEFAULT ''::text NOT NULL,\n    max_issuance_window_ms bigint,\n    CONSTRAINT config_singleton CHECK (singleton)\n);\n\n\n--\n-- Name: generator_pending_block; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE generator_pending_block (\n    singleton boolean DEFAULT true NOT NULL,\n    data bytea NOT NULL,\n    CONSTRAINT generator_pending_block_singleton CHECK (singleton)\n);\n\n\n--\n-- Name: leader; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE leader (\n    singleton boolean DEFAULT true NOT NULL,\n    leader_key text NOT NULL,\n    expiry timestamp with time zone DEFAULT '1970-01-01 00:00:00-08'::timestamp with time zone NOT NULL,\n    address text NOT NULL,\n    CONSTRAINT leader_singleton CHECK (singleton)\n);\n\n\n--\n-- Name: mockhsm_sort_id_seq; Type: SEQUENCE; Schema: public; Owner: -\n--\n\nCREATE SEQUENCE mockhsm_sort_id_seq\n    START WITH 1\n    INCREMENT BY 1\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\n\n\n--\n-- Name: mockhsm; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE mockhsm (\n    pub bytea NOT NULL,\n    prv bytea NOT NULL,\n    alias text,\n    sort_id bigint DEFAULT nextval('mockhsm_sort_id_seq'::regclass) NOT NULL,\n    key_type text DEFAULT 'chain_kd'::text NOT NULL\n);\n\n\n--\n-- Name: pool_tx_sort_id_seq; Type: SEQUENCE; Schema: public; Owner: -\n--\n\nCREATE SEQUENCE pool_tx_sort_id_seq\n    START WITH 1\n    INCREMENT BY 1\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\n\n\n--\n-- Name: pool_txs; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE UNLOGGED TABLE pool_txs (\n    tx_hash text NOT NULL,\n    data bytea NOT NULL,\n    sort_id bigint DEFAULT nextval('pool_tx_sort_id_seq'::regclass) NOT NULL\n);\n\n\n--\n-- Name: query_blocks; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE query_blocks (\n    height bigint NOT NULL,\n    \"timestamp\" bigint NOT NULL\n);\n\n\n--\n-- Name: reservation_seq; Type: SEQUENCE; Schema: public; Owner: -\n--\n\nCREATE SEQUENCE reservation_seq\n    START WITH 1\n    INCREMENT BY 1\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\n\n\n--\n-- Name: reservations; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE reservations (\n    reservation_id integer DEFAULT nextval('reservation_seq'::regclass) NOT NULL,\n    asset_id text,\n    account_id text,\n    expiry timestamp with time zone DEFAULT '1970-01-01 00:00:00-08'::timestamp with time zone NOT NULL,\n    change bigint DEFAULT 0 NOT NULL,\n    idempotency_key text\n);\n\n\n--\n-- Name: signed_blocks; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE signed_blocks (\n    block_height bigint NOT NULL,\n    block_hash text NOT NULL\n);\n\n\n--\n-- Name: signers; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE signers (\n    id text NOT NULL,\n    type text NOT NULL,\n    key_index bigint NOT NULL,\n    xpubs text[] NOT NULL,\n    quorum integer NOT NULL,\n    client_token text\n);\n\n\n--\n-- Name: signers_key_index_seq; Type: SEQUENCE; Schema: public; Owner: -\n--\n\nCREATE SEQUENCE signers_key_index_seq\n    START WITH 1\n    INCREMENT BY 1\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\n\n\n--\n-- Name: signers_key_index_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -\n--\n\nALTER SEQUENCE signers_key_index_seq OWNED BY signers.key_index;\n\n\n--\n-- Name: snapshots; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE snapshots (\n    height bigint NOT NULL,\n    data bytea NOT NULL\n);\n\n\n--\n-- Name: submitted_txs; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE submitted_txs (\n    tx_id text NOT NULL,\n    height bigint NOT NULL,\n    submitted_at timestamp without time zone DEFAULT now() NOT NULL\n);\n\n\n--\n-- Name: txfeeds; Type: TABLE; Schema: public; Owner: -\n--\n\nCREATE TABLE txfeeds (\n    id text DEFAULT next_chain_id('cur'::text) NOT NULL,\n    alias text,\n    filter text,\n    after text,\n    client_token text NOT NULL\n);\n\n\n--\n-- Name: key_index; Type: DEFAULT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY signers ALTER COLUMN key_index SET DEFAULT nextval('signers_key_index_seq'::regclass);\n\n\n--\n-- Name: access_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY access_tokens\n    ADD CONSTRAINT access_tokens_pkey PRIMARY KEY (id);\n\n\n--\n-- Name: account_tags_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY accounts\n    ADD CONSTRAINT account_tags_pkey PRIMARY KEY (account_id);\n\n\n--\n-- Name: account_utxos_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY account_utxos\n    ADD CONSTRAINT account_utxos_pkey PRIMARY KEY (tx_hash, index);\n\n\n--\n-- Name: accounts_alias_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY accounts\n    ADD CONSTRAINT accounts_alias_key UNIQUE (alias);\n\n\n--\n-- Name: annotated_accounts_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY annotated_accounts\n    ADD CONSTRAINT annotated_accounts_pkey PRIMARY KEY (id);\n\n\n--\n-- Name: annotated_assets_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY annotated_assets\n    ADD CONSTRAINT annotated_assets_pkey PRIMARY KEY (id);\n\n\n--\n-- Name: annotated_outputs_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY annotated_outputs\n    ADD CONSTRAINT annotated_outputs_pkey PRIMARY KEY (block_height, tx_pos, output_index);\n\n\n--\n-- Name: annotated_txs_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY annotated_txs\n    ADD CONSTRAINT annotated_txs_pkey PRIMARY KEY (block_height, tx_pos);\n\n\n--\n-- Name: asset_tags_asset_id_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY asset_tags\n    ADD CONSTRAINT asset_tags_asset_id_key UNIQUE (asset_id);\n\n\n--\n-- Name: assets_alias_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY assets\n    ADD CONSTRAINT assets_alias_key UNIQUE (alias);\n\n\n--\n-- Name: assets_client_token_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY assets\n    ADD CONSTRAINT assets_client_token_key UNIQUE (client_token);\n\n\n--\n-- Name: assets_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY assets\n    ADD CONSTRAINT assets_pkey PRIMARY KEY (id);\n\n\n--\n-- Name: blocks_height_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY blocks\n    ADD CONSTRAINT blocks_height_key UNIQUE (height);\n\n\n--\n-- Name: blocks_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY blocks\n    ADD CONSTRAINT blocks_pkey PRIMARY KEY (block_hash);\n\n\n--\n-- Name: config_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY config\n    ADD CONSTRAINT config_pkey PRIMARY KEY (singleton);\n\n\n--\n-- Name: generator_pending_block_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY generator_pending_block\n    ADD CONSTRAINT generator_pending_block_pkey PRIMARY KEY (singleton);\n\n\n--\n-- Name: leader_singleton_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY leader\n    ADD CONSTRAINT leader_singleton_key UNIQUE (singleton);\n\n\n--\n-- Name: mockhsm_alias_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY mockhsm\n    ADD CONSTRAINT mockhsm_alias_key UNIQUE (alias);\n\n\n--\n-- Name: mockhsm_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY mockhsm\n    ADD CONSTRAINT mockhsm_pkey PRIMARY KEY (pub);\n\n\n--\n-- Name: pool_txs_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY pool_txs\n    ADD CONSTRAINT pool_txs_pkey PRIMARY KEY (tx_hash);\n\n\n--\n-- Name: pool_txs_sort_id_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY pool_txs\n    ADD CONSTRAINT pool_txs_sort_id_key UNIQUE (sort_id);\n\n\n--\n-- Name: query_blocks_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY query_blocks\n    ADD CONSTRAINT query_blocks_pkey PRIMARY KEY (height);\n\n\n--\n-- Name: reservations_idempotency_key_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY reservations\n    ADD CONSTRAINT reservations_idempotency_key_key UNIQUE (idempotency_key);\n\n\n--\n-- Name: reservations_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY reservations\n    ADD CONSTRAINT reservations_pkey PRIMARY KEY (reservation_id);\n\n\n--\n-- Name: signers_client_token_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY signers\n    ADD CONSTRAINT signers_client_token_key UNIQUE (client_token);\n\n\n--\n-- Name: signers_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY signers\n    ADD CONSTRAINT signers_pkey PRIMARY KEY (id);\n\n\n--\n-- Name: sort_id_index; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY mockhsm\n    ADD CONSTRAINT sort_id_index UNIQUE (sort_id);\n\n\n--\n-- Name: state_trees_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY snapshots\n    ADD CONSTRAINT state_trees_pkey PRIMARY KEY (height);\n\n\n--\n-- Name: submitted_txs_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY submitted_txs\n    ADD CONSTRAINT submitted_txs_pkey PRIMARY KEY (tx_id);\n\n\n--\n-- Name: txfeeds_alias_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY txfeeds\n    ADD CONSTRAINT txfeeds_alias_key UNIQUE (alias);\n\n\n--\n-- Name: txfeeds_client_token_key; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY txfeeds\n    ADD CONSTRAINT txfeeds_client_token_key UNIQUE (client_token);\n\n\n--\n-- Name: txfeeds_pkey; Type: CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY txfeeds\n    ADD CONSTRAINT txfeeds_pkey PRIMARY KEY (id);\n\n\n--\n-- Name: account_control_programs_control_program_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX account_control_programs_control_program_idx ON account_control_programs USING btree (control_program);\n\n\n--\n-- Name: account_utxos_account_id; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX account_utxos_account_id ON account_utxos USING btree (account_id);\n\n\n--\n-- Name: account_utxos_account_id_asset_id_tx_hash_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX account_utxos_account_id_asset_id_tx_hash_idx ON account_utxos USING btree (account_id, asset_id, tx_hash);\n\n\n--\n-- Name: account_utxos_expiry_height_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX account_utxos_expiry_height_idx ON account_utxos USING btree (expiry_height) WHERE (confirmed_in IS NULL);\n\n\n--\n-- Name: account_utxos_reservation_id_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX account_utxos_reservation_id_idx ON account_utxos USING btree (reservation_id);\n\n\n--\n-- Name: annotated_accounts_jsondata_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX annotated_accounts_jsondata_idx ON annotated_accounts USING gin (data jsonb_path_ops);\n\n\n--\n-- Name: annotated_assets_jsondata_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX annotated_assets_jsondata_idx ON annotated_assets USING gin (data jsonb_path_ops);\n\n\n--\n-- Name: annotated_assets_sort_id; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX annotated_assets_sort_id ON annotated_assets USING btree (sort_id);\n\n\n--\n-- Name: annotated_outputs_jsondata_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX annotated_outputs_jsondata_idx ON annotated_outputs USING gin (data jsonb_path_ops);\n\n\n--\n-- Name: annotated_outputs_outpoint_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX annotated_outputs_outpoint_idx ON annotated_outputs USING btree (tx_hash, output_index);\n\n\n--\n-- Name: annotated_outputs_timespan_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX annotated_outputs_timespan_idx ON annotated_outputs USING gist (timespan);\n\n\n--\n-- Name: annotated_txs_data; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX annotated_txs_data ON annotated_txs USING gin (data);\n\n\n--\n-- Name: assets_sort_id; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX assets_sort_id ON assets USING btree (sort_id);\n\n\n--\n-- Name: query_blocks_timestamp_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX query_blocks_timestamp_idx ON query_blocks USING btree (\"timestamp\");\n\n\n--\n-- Name: reservations_asset_id_account_id_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX reservations_asset_id_account_id_idx ON reservations USING btree (asset_id, account_id);\n\n\n--\n-- Name: reservations_expiry; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX reservations_expiry ON reservations USING btree (expiry);\n\n\n--\n-- Name: signed_blocks_block_height_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE UNIQUE INDEX signed_blocks_block_height_idx ON signed_blocks USING btree (block_height);\n\n\n--\n-- Name: signers_type_id_idx; Type: INDEX; Schema: public; Owner: -\n--\n\nCREATE INDEX signers_type_id_idx ON signers USING btree (type, id);\n\n\n--\n-- Name: account_utxos_reservation_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -\n--\n\nALTER TABLE ONLY account_utxos\n    ADD CONSTRAINT account_utxos_reservation_id_fkey FOREIGN KEY (reservation_id) REFERENCES reservations(reservation_id) ON DELETE SET NULL;\n\n\n--\n-- PostgreSQL database dump complete\n--\n\n"},
 	{Name: "2016-10-19.0.core.add-core-id.sql", SQL: "ALTER TABLE config ADD COLUMN id text NOT NULL;\n"},
+	{Name: "2016-10-20.0.core.add-access-token-account.sql", SQL: "ALTER TABLE access_tokens ADD COLUMN account_id text;\n"},
+	{Name: "2016-10-21.0.core.add-submitted-tx-client-token.sql", SQL: "ALTER TABLE submitted_txs ADD COLUMN client_token text;\nALTER TABLE ONLY submitted_txs ADD CONSTRAINT submitted_txs_client_token_key UNIQUE (client_token);\n"},
+	{Name: "2016-10-22.0.core.add-account-utxo-pending-spend.sql", SQL: "ALTER TABLE account_utxos ADD COLUMN pending_spend_expiry_height bigint;\n"},
+	{Name: "2016-10-23.0.core.create-subscriptions.sql", SQL: "CREATE TABLE subscriptions (\n    id text NOT NULL,\n    payee_account_id text NOT NULL,\n    terms jsonb NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY subscriptions ADD CONSTRAINT subscriptions_pkey PRIMARY KEY (id);\nCREATE TABLE subscription_events (\n    subscription_id text NOT NULL,\n    period_start bigint NOT NULL,\n    kind text NOT NULL,\n    detail text NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY subscription_events ADD CONSTRAINT subscription_events_pkey PRIMARY KEY (subscription_id, period_start, kind);\n"},
+	{Name: "2016-10-24.0.core.create-payment-channels.sql", SQL: "CREATE TABLE payment_channels (\n    id text NOT NULL,\n    side text NOT NULL,\n    xpub text NOT NULL,\n    capacity bigint NOT NULL,\n    peer_url text NOT NULL,\n    peer_access_token text NOT NULL,\n    terms jsonb NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY payment_channels ADD CONSTRAINT payment_channels_pkey PRIMARY KEY (id);\nCREATE TABLE payment_channel_states (\n    channel_id text NOT NULL,\n    sequence bigint NOT NULL,\n    balance_a bigint NOT NULL,\n    balance_b bigint NOT NULL,\n    signature_a bytea,\n    signature_b bytea,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY payment_channel_states ADD CONSTRAINT payment_channel_states_pkey PRIMARY KEY (channel_id, sequence);\n"},
+	{Name: "2016-10-25.0.core.add-archived-at.sql", SQL: "ALTER TABLE accounts ADD COLUMN archived_at timestamp with time zone;\nALTER TABLE assets ADD COLUMN archived_at timestamp with time zone;\n"},
+	{Name: "2016-10-26.0.core.reserve-confirmed-only.sql", SQL: "DROP FUNCTION reserve_utxos(text, text, text, bigint, bigint, timestamp with time zone, text);\nCREATE FUNCTION reserve_utxos(inp_asset_id text, inp_account_id text, inp_tx_hash text, inp_out_index bigint, inp_amt bigint, inp_expiry timestamp with time zone, inp_idempotency_key text, inp_confirmed_only boolean) RETURNS record\n    LANGUAGE plpgsql\n    AS $$\nDECLARE\n    res RECORD;\n    row RECORD;\n    ret RECORD;\n    available BIGINT := 0;\n    unavailable BIGINT := 0;\nBEGIN\n    SELECT * FROM create_reservation(inp_asset_id, inp_account_id, inp_expiry, inp_idempotency_key) INTO STRICT res;\n    IF res.already_existed THEN\n      SELECT res.reservation_id, res.already_existed, res.existing_change, CAST(0 AS BIGINT) AS amount, FALSE AS insufficient INTO ret;\n      RETURN ret;\n    END IF;\n\n    LOOP\n        SELECT tx_hash, index, amount INTO row\n            FROM account_utxos u\n            WHERE asset_id = inp_asset_id\n                  AND inp_account_id = account_id\n                  AND (inp_tx_hash IS NULL OR inp_tx_hash = tx_hash)\n                  AND (inp_out_index IS NULL OR inp_out_index = index)\n                  AND reservation_id IS NULL\n                  AND (NOT inp_confirmed_only OR confirmed_in IS NOT NULL)\n            LIMIT 1\n            FOR UPDATE\n            SKIP LOCKED;\n        IF FOUND THEN\n            UPDATE account_utxos SET reservation_id = res.reservation_id\n                WHERE (tx_hash, index) = (row.tx_hash, row.index);\n            available := available + row.amount;\n            IF available >= inp_amt THEN\n                EXIT;\n            END IF;\n        ELSE\n            EXIT;\n        END IF;\n    END LOOP;\n\n    IF available < inp_amt THEN\n        SELECT SUM(change) AS change INTO STRICT row\n            FROM reservations\n            WHERE asset_id = inp_asset_id AND account_id = inp_account_id;\n        unavailable := row.change;\n        PERFORM cancel_reservation(res.reservation_id);\n        res.reservation_id := 0;\n    ELSE\n        UPDATE reservations SET change = available - inp_amt\n            WHERE reservation_id = res.reservation_id;\n    END IF;\n\n    SELECT res.reservation_id, res.already_existed, CAST(0 AS BIGINT) AS existing_change, available AS amount, (available+unavailable < inp_amt) AS insufficient INTO ret;\n    RETURN ret;\nEND;\n$$;\n"},
+	{Name: "2016-10-27.0.core.add-timelocked-control-programs.sql", SQL: "ALTER TABLE account_control_programs ADD COLUMN unlock_time bigint;\nALTER TABLE account_utxos ADD COLUMN unlock_time bigint;\nDROP FUNCTION reserve_utxos(text, text, text, bigint, bigint, timestamp with time zone, text, boolean);\nCREATE FUNCTION reserve_utxos(inp_asset_id text, inp_account_id text, inp_tx_hash text, inp_out_index bigint, inp_amt bigint, inp_expiry timestamp with time zone, inp_idempotency_key text, inp_confirmed_only boolean, inp_now bigint) RETURNS record\n    LANGUAGE plpgsql\n    AS $$\nDECLARE\n    res RECORD;\n    row RECORD;\n    ret RECORD;\n    available BIGINT := 0;\n    unavailable BIGINT := 0;\nBEGIN\n    SELECT * FROM create_reservation(inp_asset_id, inp_account_id, inp_expiry, inp_idempotency_key) INTO STRICT res;\n    IF res.already_existed THEN\n      SELECT res.reservation_id, res.already_existed, res.existing_change, CAST(0 AS BIGINT) AS amount, FALSE AS insufficient INTO ret;\n      RETURN ret;\n    END IF;\n\n    LOOP\n        SELECT tx_hash, index, amount INTO row\n            FROM account_utxos u\n            WHERE asset_id = inp_asset_id\n                  AND inp_account_id = account_id\n                  AND (inp_tx_hash IS NULL OR inp_tx_hash = tx_hash)\n                  AND (inp_out_index IS NULL OR inp_out_index = index)\n                  AND reservation_id IS NULL\n                  AND (NOT inp_confirmed_only OR confirmed_in IS NOT NULL)\n                  AND (unlock_time IS NULL OR unlock_time <= inp_now)\n            LIMIT 1\n            FOR UPDATE\n            SKIP LOCKED;\n        IF FOUND THEN\n            UPDATE account_utxos SET reservation_id = res.reservation_id\n                WHERE (tx_hash, index) = (row.tx_hash, row.index);\n            available := available + row.amount;\n            IF available >= inp_amt THEN\n                EXIT;\n            END IF;\n        ELSE\n            EXIT;\n        END IF;\n    END LOOP;\n\n    IF available < inp_amt THEN\n        SELECT SUM(change) AS change INTO STRICT row\n            FROM reservations\n            WHERE asset_id = inp_asset_id AND account_id = inp_account_id;\n        unavailable := row.change;\n        PERFORM cancel_reservation(res.reservation_id);\n        res.reservation_id := 0;\n    ELSE\n        UPDATE reservations SET change = available - inp_amt\n            WHERE reservation_id = res.reservation_id;\n    END IF;\n\n    SELECT res.reservation_id, res.already_existed, CAST(0 AS BIGINT) AS existing_change, available AS amount, (available+unavailable < inp_amt) AS insufficient INTO ret;\n    RETURN ret;\nEND;\n$$;\n"},
+	{Name: "2016-10-28.0.core.create-retirements.sql", SQL: "CREATE TABLE retirements (\n    block_height bigint NOT NULL,\n    tx_pos integer NOT NULL,\n    output_index integer NOT NULL,\n    tx_hash text NOT NULL,\n    asset_id text NOT NULL,\n    amount bigint NOT NULL,\n    account_id text,\n    reference_data jsonb NOT NULL,\n    \"timestamp\" bigint NOT NULL\n);\nALTER TABLE ONLY retirements ADD CONSTRAINT retirements_pkey PRIMARY KEY (block_height, tx_pos, output_index);\nCREATE INDEX retirements_asset_id_idx ON retirements USING btree (asset_id, block_height, tx_pos, output_index);\n"},
+	{Name: "2016-10-29.0.core.create-transaction-labels.sql", SQL: "CREATE TABLE transaction_labels (\n    tx_hash text NOT NULL,\n    labels jsonb NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY transaction_labels ADD CONSTRAINT transaction_labels_pkey PRIMARY KEY (tx_hash);\n"},
+	{Name: "2016-10-30.0.core.create-forwarding-rules.sql", SQL: "CREATE TABLE forwarding_rules (\n    id text DEFAULT next_chain_id('fwd'::text) NOT NULL,\n    account_id text NOT NULL,\n    asset_id text NOT NULL,\n    destination_account_id text,\n    control_program bytea,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY forwarding_rules ADD CONSTRAINT forwarding_rules_pkey PRIMARY KEY (id);\nALTER TABLE ONLY forwarding_rules ADD CONSTRAINT forwarding_rules_account_id_asset_id_key UNIQUE (account_id, asset_id);\nCREATE TABLE forwarding_events (\n    rule_id text NOT NULL,\n    asset_id text NOT NULL,\n    amount bigint NOT NULL,\n    kind text NOT NULL,\n    detail text NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nCREATE INDEX forwarding_events_rule_id_idx ON forwarding_events USING btree (rule_id, asset_id, created_at);\n"},
+	{Name: "2016-10-31.0.core.add-asset-issuance-policies.sql", SQL: "ALTER TABLE assets ADD COLUMN max_issuance bigint;\nALTER TABLE assets ADD COLUMN one_time_issuance boolean DEFAULT false NOT NULL;\nALTER TABLE assets ADD COLUMN issued_amount numeric DEFAULT 0 NOT NULL;\nALTER TABLE assets ADD COLUMN issued_height bigint DEFAULT 0 NOT NULL;\n"},
+	{Name: "2016-10-31.1.core.create-asset-definitions.sql", SQL: "CREATE TABLE asset_definitions (\n    asset_id text NOT NULL,\n    version integer NOT NULL,\n    definition jsonb,\n    tx_hash text NOT NULL,\n    block_height bigint NOT NULL,\n    block_time timestamp with time zone NOT NULL\n);\nALTER TABLE ONLY asset_definitions ADD CONSTRAINT asset_definitions_pkey PRIMARY KEY (asset_id, version);\n"},
+	{Name: "2016-10-31.2.core.create-wallet-tiers.sql", SQL: "CREATE TABLE wallet_tiers (\n    id text DEFAULT next_chain_id('tier'::text) NOT NULL,\n    asset_id text NOT NULL,\n    hot_account_id text NOT NULL,\n    cold_account_id text NOT NULL,\n    high_water bigint NOT NULL,\n    low_water bigint NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY wallet_tiers ADD CONSTRAINT wallet_tiers_pkey PRIMARY KEY (id);\nALTER TABLE ONLY wallet_tiers ADD CONSTRAINT wallet_tiers_hot_account_id_asset_id_key UNIQUE (hot_account_id, asset_id);\nCREATE SEQUENCE wallet_tier_templates_seq\n    START WITH 1\n    INCREMENT BY 1\n    NO MINVALUE\n    NO MAXVALUE\n    CACHE 1;\nCREATE TABLE wallet_tier_templates (\n    seq bigint DEFAULT nextval('wallet_tier_templates_seq'::regclass) NOT NULL,\n    tier_id text NOT NULL,\n    template jsonb NOT NULL,\n    used_at timestamp with time zone\n);\nALTER TABLE ONLY wallet_tier_templates ADD CONSTRAINT wallet_tier_templates_pkey PRIMARY KEY (seq);\nCREATE INDEX wallet_tier_templates_tier_id_idx ON wallet_tier_templates USING btree (tier_id, seq) WHERE (used_at IS NULL);\nCREATE TABLE wallet_tier_events (\n    tier_id text NOT NULL,\n    kind text NOT NULL,\n    amount bigint NOT NULL,\n    detail text NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nCREATE INDEX wallet_tier_events_tier_id_idx ON wallet_tier_events USING btree (tier_id, created_at);\n"},
+	{Name: "2016-10-31.3.core.create-blocklist.sql", SQL: "CREATE TABLE blocklist (\n    id text DEFAULT next_chain_id('blk'::text) NOT NULL,\n    control_program bytea,\n    tx_hash text,\n    output_index integer,\n    reason text NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY blocklist ADD CONSTRAINT blocklist_pkey PRIMARY KEY (id);\nCREATE TABLE blocklist_version (\n    singleton boolean DEFAULT true NOT NULL,\n    version bigint NOT NULL,\n    CONSTRAINT blocklist_version_singleton CHECK (singleton)\n);\nALTER TABLE ONLY blocklist_version ADD CONSTRAINT blocklist_version_pkey PRIMARY KEY (singleton);\n"},
+	{Name: "2016-10-31.4.core.create-asset-definition-schemas.sql", SQL: "CREATE TABLE asset_definition_schemas (\n    id text DEFAULT next_chain_id('ads'::text) NOT NULL,\n    alias text,\n    schema jsonb NOT NULL,\n    created_at timestamp with time zone DEFAULT now() NOT NULL\n);\nALTER TABLE ONLY asset_definition_schemas ADD CONSTRAINT asset_definition_schemas_pkey PRIMARY KEY (id);\nALTER TABLE ONLY asset_definition_schemas ADD CONSTRAINT asset_definition_schemas_alias_key UNIQUE (alias);\nALTER TABLE assets ADD COLUMN definition_schema_id text;\n"},
+	{Name: "2016-10-31.5.core.add-account-control-program-used.sql", SQL: "ALTER TABLE account_control_programs ADD COLUMN used boolean DEFAULT false NOT NULL;\nUPDATE account_control_programs SET used = true\n    WHERE control_program IN (SELECT control_program FROM account_utxos);\n"},
+	{Name: "2016-10-31.6.core.add-submitted-tx-max-time.sql", SQL: "ALTER TABLE submitted_txs ADD COLUMN max_time bigint;\n"},
+	{Name: "2016-10-31.7.core.add-pool-tx-min-time.sql", SQL: "ALTER TABLE pool_txs ADD COLUMN min_time bigint DEFAULT 0 NOT NULL;\n"},
+	{Name: "2016-10-31.8.core.create-issuance-reservations.sql", SQL: "ALTER TABLE assets ADD COLUMN pending_issuance numeric DEFAULT 0 NOT NULL;\nCREATE TABLE issuance_reservations (\n    asset_id text NOT NULL,\n    nonce bytea NOT NULL,\n    amount numeric NOT NULL,\n    expiry timestamp with time zone NOT NULL\n);\nALTER TABLE ONLY issuance_reservations ADD CONSTRAINT issuance_reservations_pkey PRIMARY KEY (asset_id, nonce);\nCREATE INDEX issuance_reservations_expiry_idx ON issuance_reservations USING btree (expiry);\n"},
+	{Name: "2016-10-31.9.core.create-output-tags-version.sql", SQL: "CREATE TABLE output_tags_version (\n    singleton boolean DEFAULT true NOT NULL,\n    version bigint NOT NULL,\n    CONSTRAINT output_tags_version_singleton CHECK (singleton)\n);\nALTER TABLE ONLY output_tags_version ADD CONSTRAINT output_tags_version_pkey PRIMARY KEY (singleton);\n"},
+	{Name: "2016-11-01.0.query.index-annotated-txs-tx-hash.sql", SQL: "CREATE INDEX annotated_txs_tx_hash ON annotated_txs USING btree (tx_hash);\n"},
+	{Name: "2016-11-01.1.core.backfill-account-control-program-used.sql", SQL: "UPDATE account_control_programs SET used = true\n    WHERE NOT used AND control_program IN (\n        SELECT decode(o->>'control_program', 'hex')\n        FROM annotated_txs, jsonb_array_elements(data->'outputs') o\n    );\n"},
+	{Name: "2016-11-01.2.query.index-annotated-outputs-spent.sql", SQL: "CREATE INDEX annotated_outputs_spent_idx ON annotated_outputs USING btree (upper(timespan)) WHERE (NOT upper_inf(timespan));\n"},
 }
@@ -0,0 +1,99 @@
+package mockhsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"expvar"
+	"sync"
+
+	"chain/crypto/sha3pool"
+	"chain/errors"
+	"chain/log"
+)
+
+// maxAuditedSigs caps the number of signatures remembered
+// for drift checks. When it is reached, they are forgotten
+// and the checks start over.
+const maxAuditedSigs = 10000
+
+// errSignatureDrift is logged when a key signs a message it
+// signed before and the signature differs.
+var errSignatureDrift = errors.New("mockhsm: signature differs from an earlier one of the same message")
+
+var (
+	// signatures counts signatures made by each stored key,
+	// keyed by its hex-encoded public key. Signatures by keys
+	// derived from an xpub count toward the xpub itself, so
+	// the map grows with the number of keys, not of derivation
+	// paths.
+	signatures = expvar.NewMap("mockhsm.signatures")
+
+	// signatureDrift counts signatures that differed from an
+	// earlier signature of the same message by the same key.
+	signatureDrift = expvar.NewInt("mockhsm.signature_drift")
+
+	auditMu     sync.Mutex
+	auditedSigs = make(map[[32]byte][]byte)
+)
+
+// audit counts sig, the signature of msg by pub, or by the key
+// derived from pub along path, and checks it for drift.
+// If h.Audit is set, it also logs the key, the hash of msg,
+// and the running count for the key.
+//
+// Ed25519 signing is deterministic, so a repeated
+// (key, message) pair must produce the same signature,
+// and does not leak the key the way nonce reuse would.
+// A different signature means the signer is faulty; it
+// is counted in mockhsm.signature_drift and logged as an
+// error whether or not h.Audit is set. The log is for
+// spotting unexpected signing activity.
+func (h *HSM) audit(ctx context.Context, pub []byte, path [][]byte, msg, sig []byte) {
+	key := hex.EncodeToString(pub)
+	signatures.Add(key, 1)
+
+	var msgHash [32]byte
+	sha3pool.Sum256(msgHash[:], msg)
+	if checkDrift(pub, path, msgHash, sig) {
+		signatureDrift.Add(1)
+		log.Error(ctx, errSignatureDrift, "pub", key, "msghash", hex.EncodeToString(msgHash[:]))
+	}
+	if !h.Audit {
+		return
+	}
+	log.Write(ctx,
+		"at", "mockhsm sign",
+		"pub", key,
+		"msghash", hex.EncodeToString(msgHash[:]),
+		"count", signatures.Get(key),
+	)
+}
+
+// checkDrift remembers sig as the signature of the message
+// with hash msgHash by pub along path, and reports whether
+// it differs from one remembered earlier.
+func checkDrift(pub []byte, path [][]byte, msgHash [32]byte, sig []byte) bool {
+	var buf bytes.Buffer
+	buf.Write(pub)
+	for _, p := range path {
+		var n [binary.MaxVarintLen64]byte
+		buf.Write(n[:binary.PutUvarint(n[:], uint64(len(p)))])
+		buf.Write(p)
+	}
+	buf.Write(msgHash[:])
+	var k [32]byte
+	sha3pool.Sum256(k[:], buf.Bytes())
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	if prev, ok := auditedSigs[k]; ok {
+		return !bytes.Equal(prev, sig)
+	}
+	if len(auditedSigs) >= maxAuditedSigs {
+		auditedSigs = make(map[[32]byte][]byte)
+	}
+	auditedSigs[k] = sig
+	return false
+}
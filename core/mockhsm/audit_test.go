@@ -0,0 +1,64 @@
+package mockhsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"chain/log"
+)
+
+func TestAudit(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	ctx := context.Background()
+	h := &HSM{}
+	pub := []byte("audit test key")
+	key := hex.EncodeToString(pub)
+	msg := []byte("message")
+	sig := []byte("signature")
+	path := [][]byte{{1}}
+
+	// Signatures along a derivation path count toward the
+	// root key. Without h.Audit set, nothing is logged.
+	h.audit(ctx, pub, nil, msg, sig)
+	h.audit(ctx, pub, path, msg, []byte("derived signature"))
+	if got := signatures.Get(key).String(); got != "2" {
+		t.Errorf("signature count = %s, want 2", got)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("logged %q without audit", buf.String())
+	}
+
+	h.Audit = true
+	h.audit(ctx, pub, nil, msg, sig)
+	if got := signatures.Get(key).String(); got != "3" {
+		t.Errorf("signature count = %s, want 3", got)
+	}
+	for _, want := range []string{"mockhsm sign", "pub=" + key, "count=3"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("audit log %q does not contain %q", buf.String(), want)
+		}
+	}
+	if strings.Contains(buf.String(), "error") {
+		t.Errorf("repeated signature logged as an error: %q", buf.String())
+	}
+
+	// A different signature of the same message by the
+	// same key is drift.
+	drift := signatureDrift.Value()
+	buf.Reset()
+	h.Audit = false
+	h.audit(ctx, pub, path, msg, []byte("other signature"))
+	if got := signatureDrift.Value() - drift; got != 1 {
+		t.Errorf("drift count went up by %d, want 1", got)
+	}
+	if !strings.Contains(buf.String(), errSignatureDrift.Error()) {
+		t.Errorf("drift log %q does not report the drift", buf.String())
+	}
+}
@@ -31,6 +31,9 @@ var (
 type HSM struct {
 	db pg.DB
 
+	// Audit, if set, logs every signature.
+	Audit bool
+
 	cacheMu sync.Mutex
 	kdCache map[chainkd.XPub]chainkd.XPrv
 	edCache map[string]ed25519.PrivateKey // ed25519.PublicKeys must be turned into strings before being used as map keys
@@ -226,7 +229,9 @@ func (h *HSM) XSign(ctx context.Context, xpub chainkd.XPub, path [][]byte, msg [
 	if len(path) > 0 {
 		xprv = xprv.Derive(path)
 	}
-	return xprv.Sign(msg), nil
+	sig := xprv.Sign(msg)
+	h.audit(ctx, xpub.Bytes(), path, msg, sig)
+	return sig, nil
 }
 
 func (h *HSM) DeleteChainKDKey(ctx context.Context, xpub chainkd.XPub) error {
@@ -269,5 +274,7 @@ func (h *HSM) Sign(ctx context.Context, pub ed25519.PublicKey, msg []byte) ([]by
 	if len(prv) != ed25519.PrivateKeySize {
 		return nil, ErrInvalidKeySize
 	}
-	return ed25519.Sign(prv, msg), nil
+	sig := ed25519.Sign(prv, msg)
+	h.audit(ctx, pub, nil, msg, sig)
+	return sig, nil
 }
@@ -0,0 +1,91 @@
+package mockhsm
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+
+	"chain/crypto/ed25519/chainkd"
+	"chain/crypto/shamir"
+	"chain/database/pg"
+	"chain/errors"
+	"chain/log"
+)
+
+// ErrSharesMismatch is returned when a set of key shares
+// doesn't reconstruct the expected xprv.
+var ErrSharesMismatch = errors.New("shares do not match key")
+
+// XSplit splits the xprv for xpub into n Shamir shares,
+// any quorum of which can restore it with XRestore.
+// Each split is recorded in the log.
+func (h *HSM) XSplit(ctx context.Context, xpub chainkd.XPub, n, quorum int) ([][]byte, error) {
+	xprv, err := h.loadChainKDKey(ctx, xpub)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := shamir.Split(xprv.Bytes(), n, quorum)
+	if err != nil {
+		return nil, err
+	}
+	log.Write(ctx, "at", "mockhsm key split", "xpub", xpub, "shares", n, "quorum", quorum)
+	return shares, nil
+}
+
+// XVerifyShares reports whether shares reconstruct the xprv for xpub,
+// without storing anything. Callers can use it to check a set of
+// shares after distributing them.
+func (h *HSM) XVerifyShares(ctx context.Context, xpub chainkd.XPub, shares [][]byte) error {
+	_, err := combineXPrv(xpub, shares)
+	if err != nil {
+		return err
+	}
+	log.Write(ctx, "at", "mockhsm key shares verified", "xpub", xpub, "shares", len(shares))
+	return nil
+}
+
+// XRestore reconstructs the xprv for xpub from shares
+// and stores it under alias. If the key is already present,
+// XRestore only checks the shares.
+// Each restore is recorded in the log.
+func (h *HSM) XRestore(ctx context.Context, alias string, xpub chainkd.XPub, shares [][]byte) (*XPub, error) {
+	xprv, err := combineXPrv(xpub, shares)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlAlias := sql.NullString{String: alias, Valid: alias != ""}
+	var ptrAlias *string
+	if alias != "" {
+		ptrAlias = &alias
+	}
+	const q = `
+		INSERT INTO mockhsm (pub, prv, alias, key_type) VALUES ($1, $2, $3, 'chain_kd')
+		ON CONFLICT (pub) DO NOTHING
+	`
+	_, err = h.db.Exec(ctx, q, xpub.Bytes(), xprv.Bytes(), sqlAlias)
+	if pg.IsUniqueViolation(err) {
+		return nil, errors.WithDetailf(ErrDuplicateKeyAlias, "value: %q", alias)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "storing restored xprv")
+	}
+	log.Write(ctx, "at", "mockhsm key restored", "xpub", xpub, "shares", len(shares))
+	return &XPub{XPub: xpub, Alias: ptrAlias}, nil
+}
+
+func combineXPrv(xpub chainkd.XPub, shares [][]byte) (xprv chainkd.XPrv, err error) {
+	b, err := shamir.Combine(shares)
+	if err != nil {
+		return xprv, err
+	}
+	if len(b) != len(xprv) {
+		return xprv, errors.WithDetail(ErrSharesMismatch, "wrong secret length")
+	}
+	copy(xprv[:], b)
+	got := xprv.XPub()
+	if !bytes.Equal(got.Bytes(), xpub.Bytes()) {
+		// Too few shares, or shares from different splits.
+		return xprv, errors.Wrap(ErrSharesMismatch)
+	}
+	return xprv, nil
+}
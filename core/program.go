@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/query"
+	"chain/crypto/ed25519"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/net/http/httpjson"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+// programTemplate names a known contract and its parameters.
+// It has the form reported by query.ProgramTemplate.
+type programTemplate struct {
+	Type    string               `json:"type"`
+	Quorum  int                  `json:"quorum"`
+	Pubkeys []chainjson.HexBytes `json:"pubkeys"`
+}
+
+// assembleProgram converts VM assembly, or a known template with
+// its parameters, to a program, for contract development.
+//
+// POST /assemble-program
+func (h *Handler) assembleProgram(ctx context.Context, in struct {
+	Asm      string           `json:"asm"`
+	Template *programTemplate `json:"template"`
+}) (interface{}, error) {
+	var (
+		prog []byte
+		err  error
+	)
+	switch {
+	case in.Asm != "" && in.Template != nil:
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "only one of asm and template may be given")
+	case in.Asm != "":
+		prog, err = vm.Assemble(in.Asm)
+		if err != nil {
+			return nil, errors.WithDetailf(httpjson.ErrBadRequest, "assembling program: %s", err)
+		}
+	case in.Template != nil:
+		prog, err = templateProgram(in.Template)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "one of asm and template is required")
+	}
+	return describeProgramResp(prog), nil
+}
+
+// disassembleProgram converts a program to VM assembly, and
+// names its template parameters if it follows a known template.
+//
+// POST /disassemble-program
+func (h *Handler) disassembleProgram(ctx context.Context, in struct {
+	Program chainjson.HexBytes `json:"program"`
+}) (interface{}, error) {
+	if len(in.Program) == 0 {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing program")
+	}
+	if _, err := vm.Disassemble(in.Program); err != nil {
+		return nil, errors.WithDetailf(httpjson.ErrBadRequest, "disassembling program: %s", err)
+	}
+	return describeProgramResp(in.Program), nil
+}
+
+func templateProgram(t *programTemplate) ([]byte, error) {
+	switch t.Type {
+	case "retire":
+		return []byte{byte(vm.OP_FAIL)}, nil
+	case "multisig":
+		pubkeys := make([]ed25519.PublicKey, 0, len(t.Pubkeys))
+		for i, pub := range t.Pubkeys {
+			if len(pub) != ed25519.PublicKeySize {
+				return nil, errors.WithDetailf(httpjson.ErrBadRequest, "pubkey %d has length %d", i, len(pub))
+			}
+			pubkeys = append(pubkeys, ed25519.PublicKey(pub))
+		}
+		prog, err := vmutil.P2SPMultiSigProgram(pubkeys, t.Quorum)
+		if err != nil {
+			return nil, errors.WithDetailf(httpjson.ErrBadRequest, "building multisig program: %s", err)
+		}
+		return prog, nil
+	}
+	return nil, errors.WithDetailf(httpjson.ErrBadRequest, "unknown template type %q", t.Type)
+}
+
+func describeProgramResp(prog []byte) map[string]interface{} {
+	resp := map[string]interface{}{"program": chainjson.HexBytes(prog)}
+	if asm, err := vm.Disassemble(prog); err == nil {
+		resp["asm"] = asm
+	}
+	if tmpl := query.ProgramTemplate(prog); tmpl != nil {
+		resp["template"] = tmpl
+	}
+	return resp
+}
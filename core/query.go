@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"time"
 
 	"chain/core/query"
 	"chain/core/query/filter"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
 	"chain/errors"
 	"chain/net/http/httpjson"
+	"chain/protocol"
+	"chain/protocol/bc"
 )
 
 // These types enforce the ordering of JSON fields in API output.
@@ -49,7 +54,9 @@ type (
 		BlockID       interface{} `json:"block_id"`
 		BlockHeight   interface{} `json:"block_height"`
 		Position      interface{} `json:"position"`
+		Size          interface{} `json:"size,omitempty"`
 		ReferenceData interface{} `json:"reference_data"`
+		Labels        interface{} `json:"labels,omitempty"`
 		IsLocal       interface{} `json:"is_local"`
 		Inputs        interface{} `json:"inputs"`
 		Outputs       interface{} `json:"outputs"`
@@ -61,11 +68,11 @@ type (
 	}
 )
 
-// listTransactions is an http handler for listing transactions matching
+// ListTransactions lists transactions matching
 // an index or an ad-hoc filter.
 //
 // POST /list-transactions
-func (h *Handler) listTransactions(ctx context.Context, in requestQuery) (result page, err error) {
+func (h *Handler) ListTransactions(ctx context.Context, in QueryRequest) (result Page, err error) {
 	var c context.CancelFunc
 	timeout := in.Timeout.Duration
 	if timeout != 0 {
@@ -78,12 +85,12 @@ func (h *Handler) listTransactions(ctx context.Context, in requestQuery) (result
 	)
 
 	// Build the filter predicate.
-	p, err = filter.Parse(in.Filter)
+	p, filterParams, err := scopeFilter(ctx, in.Filter, in.FilterParams, txAccountCond)
 	if err != nil {
 		return result, err
 	}
 
-	endTimeMS := in.EndTimeMS
+	endTimeMS := uint64(in.EndTimeMS)
 	if endTimeMS == 0 {
 		endTimeMS = math.MaxInt64
 	} else if endTimeMS > math.MaxInt64 {
@@ -96,14 +103,14 @@ func (h *Handler) listTransactions(ctx context.Context, in requestQuery) (result
 			return result, errors.Wrap(err, "decoding `after`")
 		}
 	} else {
-		after, err = h.Indexer.LookupTxAfter(ctx, in.StartTimeMS, endTimeMS)
+		after, err = h.Indexer.LookupTxAfter(ctx, uint64(in.StartTimeMS), endTimeMS)
 		if err != nil {
 			return result, err
 		}
 	}
 
 	limit := defGenericPageSize
-	txns, nextAfter, err := h.Indexer.Transactions(ctx, p, in.FilterParams, after, limit, in.AscLongPoll)
+	txns, nextAfter, err := h.Indexer.Transactions(ctx, p, filterParams, after, limit, in.AscLongPoll)
 	if err != nil {
 		return result, errors.Wrap(err, "running tx query")
 	}
@@ -194,7 +201,9 @@ func (h *Handler) listTransactions(ctx context.Context, in requestQuery) (result
 			BlockID:       tx["block_id"],
 			BlockHeight:   tx["block_height"],
 			Position:      tx["position"],
+			Size:          tx["size"],
 			ReferenceData: tx["reference_data"],
+			Labels:        tx["labels"],
 			IsLocal:       tx["is_local"],
 			Inputs:        inResps,
 			Outputs:       outResps,
@@ -204,31 +213,31 @@ func (h *Handler) listTransactions(ctx context.Context, in requestQuery) (result
 
 	out := in
 	out.After = nextAfter.String()
-	return page{
+	return Page{
 		Items:    httpjson.Array(resp),
 		LastPage: len(resp) < limit,
 		Next:     out,
 	}, nil
 }
 
-// listAccounts is an http handler for listing accounts matching
+// ListAccounts lists accounts matching
 // an index or an ad-hoc filter.
 //
 // POST /list-accounts
-func (h *Handler) listAccounts(ctx context.Context, in requestQuery) (page, error) {
+func (h *Handler) ListAccounts(ctx context.Context, in QueryRequest) (Page, error) {
 	limit := defGenericPageSize
 
 	// Build the filter predicate.
 	p, err := filter.Parse(in.Filter)
 	if err != nil {
-		return page{}, errors.Wrap(err, "parsing acc query")
+		return Page{}, errors.Wrap(err, "parsing acc query")
 	}
 	after := in.After
 
 	// Use the filter engine for querying account tags.
-	accounts, after, err := h.Indexer.Accounts(ctx, p, in.FilterParams, after, limit)
+	accounts, after, err := h.Indexer.Accounts(ctx, p, in.FilterParams, after, limit, in.IncludeArchived)
 	if err != nil {
-		return page{}, errors.Wrap(err, "running acc query")
+		return Page{}, errors.Wrap(err, "running acc query")
 	}
 
 	result := make([]*accountResponse, 0, len(accounts))
@@ -254,6 +263,8 @@ func (h *Handler) listAccounts(ctx context.Context, in requestQuery) (page, erro
 			Keys:   orderedKeys,
 			Quorum: a["quorum"],
 			Tags:   a["tags"],
+
+			ArchivedAt: a["archived_at"],
 		}
 		result = append(result, r)
 	}
@@ -261,18 +272,240 @@ func (h *Handler) listAccounts(ctx context.Context, in requestQuery) (page, erro
 	// Pull in the accounts by the IDs
 	out := in
 	out.After = after
-	return page{
+	return Page{
 		Items:    httpjson.Array(result),
 		LastPage: len(result) < limit,
 		Next:     out,
 	}, nil
 }
 
+// listTransactionsByEndToEndID lists transactions whose reference
+// data carries the given end-to-end id. The returned page's Next
+// can be passed to /list-transactions to continue.
+//
+// POST /list-transactions-by-end-to-end-id
+func (h *Handler) listTransactionsByEndToEndID(ctx context.Context, in struct {
+	EndToEndID string `json:"end_to_end_id"`
+}) (Page, error) {
+	if in.EndToEndID == "" {
+		return Page{}, errors.WithDetail(httpjson.ErrBadRequest, "missing end_to_end_id")
+	}
+	return h.ListTransactions(ctx, QueryRequest{
+		Filter:       "reference_data.end_to_end_id=$1",
+		FilterParams: []interface{}{in.EndToEndID},
+	})
+}
+
+// traceTransactions walks the transaction graph from an output.
+// Direction "forward" follows the transactions that spent it,
+// and "backward" (the default) the transactions it came from.
+//
+// POST /trace-transactions
+func (h *Handler) traceTransactions(ctx context.Context, in struct {
+	TransactionID bc.Hash `json:"transaction_id"`
+	Position      uint32  `json:"position"`
+	Direction     string  `json:"direction"`
+	Hops          int     `json:"hops"`
+}) (interface{}, error) {
+	var forward bool
+	switch in.Direction {
+	case "", "backward":
+	case "forward":
+		forward = true
+	default:
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "direction must be forward or backward")
+	}
+	hops := in.Hops
+	if hops == 0 {
+		hops = 1
+	}
+	if hops < 0 || hops > query.MaxTraceHops {
+		return nil, errors.WithDetailf(httpjson.ErrBadRequest, "hops must be between 1 and %d", query.MaxTraceHops)
+	}
+
+	start := bc.Outpoint{Hash: in.TransactionID, Index: in.Position}
+	steps, truncated, err := h.Indexer.Trace(ctx, start, forward, hops)
+	if err != nil {
+		return nil, errors.Wrap(err, "tracing transactions")
+	}
+	return map[string]interface{}{
+		"items":     steps,
+		"truncated": truncated,
+	}, nil
+}
+
+// listAssetHolders lists the accounts and external control programs
+// holding an asset, with each one's share of the unspent supply.
+// If block_height is set, it lists holders as of that block.
+//
+// POST /list-asset-holders
+func (h *Handler) listAssetHolders(ctx context.Context, in struct {
+	AssetID     bc.AssetID `json:"asset_id"`
+	AssetAlias  string     `json:"asset_alias,omitempty"`
+	BlockHeight uint64     `json:"block_height,omitempty"`
+	After       string     `json:"after"`
+}) (interface{}, error) {
+	if in.AssetAlias != "" {
+		a, err := h.Assets.FindByAlias(ctx, in.AssetAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid asset alias %s", in.AssetAlias)
+		}
+		in.AssetID = a.AssetID
+	}
+	if in.AssetID == (bc.AssetID{}) {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing asset_id or asset_alias")
+	}
+
+	timestampMS := uint64(math.MaxInt64)
+	if in.BlockHeight != 0 {
+		var err error
+		timestampMS, err = h.Indexer.BlockTimestamp(ctx, in.BlockHeight)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	limit := defGenericPageSize
+	holders, supply, err := h.Indexer.AssetHolders(ctx, in.AssetID, timestampMS, in.After, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing asset holders")
+	}
+
+	out := in
+	if len(holders) > 0 {
+		out.After = query.HolderAfter(holders[len(holders)-1])
+	}
+	return map[string]interface{}{
+		"items":     httpjson.Array(holders),
+		"supply":    supply,
+		"last_page": len(holders) < limit,
+		"next":      out,
+	}, nil
+}
+
+// listRetirements lists the retirements of an asset in the
+// order they were confirmed, with the total amount retired.
+//
+// POST /list-retirements
+func (h *Handler) listRetirements(ctx context.Context, in struct {
+	AssetID    bc.AssetID `json:"asset_id"`
+	AssetAlias string     `json:"asset_alias,omitempty"`
+	After      string     `json:"after"`
+}) (interface{}, error) {
+	if in.AssetAlias != "" {
+		a, err := h.Assets.FindByAlias(ctx, in.AssetAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid asset alias %s", in.AssetAlias)
+		}
+		in.AssetID = a.AssetID
+	}
+	if in.AssetID == (bc.AssetID{}) {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing asset_id or asset_alias")
+	}
+
+	limit := defGenericPageSize
+	retirements, total, err := h.Indexer.AssetRetirements(ctx, in.AssetID, in.After, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing retirements")
+	}
+
+	out := in
+	if len(retirements) > 0 {
+		out.After = query.RetirementAfter(retirements[len(retirements)-1])
+	}
+	return map[string]interface{}{
+		"items":         httpjson.Array(retirements),
+		"total_retired": total,
+		"last_page":     len(retirements) < limit,
+		"next":          out,
+	}, nil
+}
+
+// utxoStats reports on the size and shape of the unspent
+// output set, to help schedule sweeps and plan capacity.
+//
+// POST /utxo-stats
+func (h *Handler) utxoStats(ctx context.Context, in struct {
+	MostFragmented int `json:"most_fragmented"`
+	Days           int `json:"days"`
+}) (*query.UTXOStats, error) {
+	if in.MostFragmented == 0 {
+		in.MostFragmented = 10
+	}
+	if in.Days == 0 {
+		in.Days = 30
+	}
+	if in.MostFragmented < 0 || in.MostFragmented > defGenericPageSize {
+		return nil, errors.WithDetailf(httpjson.ErrBadRequest, "most_fragmented must be between 1 and %d", defGenericPageSize)
+	}
+	if in.Days < 0 || in.Days > 366 {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "days must be between 1 and 366")
+	}
+	stats, err := h.Indexer.UTXOStats(ctx, in.MostFragmented, in.Days)
+	return stats, errors.Wrap(err, "computing utxo stats")
+}
+
+// decodeTransaction decodes a raw transaction, which need not
+// have been built by this core or submitted, for debugging.
+//
+// POST /decode-transaction
+func (h *Handler) decodeTransaction(ctx context.Context, in struct {
+	RawTransaction *bc.TxData `json:"raw_transaction"`
+}) (interface{}, error) {
+	if in.RawTransaction == nil {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing raw_transaction")
+	}
+	tx := bc.NewTx(*in.RawTransaction)
+	return h.Indexer.DecodeTransaction(ctx, tx)
+}
+
+// blockStats reports the size of a block, by default the latest,
+// so integrators can see how close blocks are to their limits.
+// Blocks are limited by transaction count, not by size.
+//
+// POST /block-stats
+func (h *Handler) blockStats(ctx context.Context, in struct {
+	BlockHeight uint64 `json:"block_height"`
+}) (interface{}, error) {
+	height := in.BlockHeight
+	if height == 0 {
+		height = h.Chain.Height()
+	}
+	if height == 0 || height > h.Chain.Height() {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "block height %d", in.BlockHeight)
+	}
+	raw, err := h.Store.GetRawBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	var b bc.Block
+	err = b.Scan(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding block")
+	}
+
+	var txBytes int64
+	for _, tx := range b.Transactions {
+		txBytes += tx.SerializedSize()
+	}
+	return map[string]interface{}{
+		"block_height":      b.Height,
+		"timestamp":         b.Time(),
+		"size":              len(raw),
+		"transaction_count": len(b.Transactions),
+		"transaction_bytes": txBytes,
+		"max_transactions":  protocol.MaxBlockTxs,
+	}, nil
+}
+
+// ListBalances sums the amounts of unspent outputs matching
+// a filter, grouped by the fields in sum_by.
+//
 // POST /list-balances
-func (h *Handler) listBalances(ctx context.Context, in requestQuery) (result page, err error) {
+func (h *Handler) ListBalances(ctx context.Context, in QueryRequest) (result Page, err error) {
 	var p filter.Predicate
 	var sumBy []filter.Field
-	p, err = filter.Parse(in.Filter)
+	p, filterParams, err := scopeFilter(ctx, in.Filter, in.FilterParams, outputAccountCond)
 	if err != nil {
 		return result, err
 	}
@@ -291,7 +524,7 @@ func (h *Handler) listBalances(ctx context.Context, in requestQuery) (result pag
 		sumBy = append(sumBy, f)
 	}
 
-	timestampMS := in.TimestampMS
+	timestampMS := uint64(in.TimestampMS)
 	if timestampMS == 0 {
 		timestampMS = math.MaxInt64
 	} else if timestampMS > math.MaxInt64 {
@@ -299,7 +532,7 @@ func (h *Handler) listBalances(ctx context.Context, in requestQuery) (result pag
 	}
 
 	// TODO(jackson): paginate this endpoint.
-	balances, err := h.Indexer.Balances(ctx, p, in.FilterParams, sumBy, timestampMS)
+	balances, err := h.Indexer.Balances(ctx, p, filterParams, sumBy, timestampMS)
 	if err != nil {
 		return result, err
 	}
@@ -330,10 +563,13 @@ type utxoResp struct {
 	IsLocal         interface{} `json:"is_local"`
 }
 
+// ListUnspentOutputs lists unspent outputs matching
+// a filter.
+//
 // POST /list-unspent-outputs
-func (h *Handler) listUnspentOutputs(ctx context.Context, in requestQuery) (result page, err error) {
+func (h *Handler) ListUnspentOutputs(ctx context.Context, in QueryRequest) (result Page, err error) {
 	var p filter.Predicate
-	p, err = filter.Parse(in.Filter)
+	p, filterParams, err := scopeFilter(ctx, in.Filter, in.FilterParams, outputAccountCond)
 	if err != nil {
 		return result, err
 	}
@@ -346,14 +582,22 @@ func (h *Handler) listUnspentOutputs(ctx context.Context, in requestQuery) (resu
 		}
 	}
 
-	timestampMS := in.TimestampMS
+	// Pin the timestamp of the first page, so that blocks
+	// landing during pagination don't cause later pages to
+	// skip or repeat outputs. Block timestamps come from the
+	// generator's clock, which can be ahead of the local one,
+	// so the pin is at least the latest block's timestamp.
+	timestampMS := uint64(in.TimestampMS)
 	if timestampMS == 0 {
-		timestampMS = math.MaxInt64
+		timestampMS = bc.Millis(time.Now())
+		if b, _ := h.Chain.State(); b != nil && b.TimestampMS > timestampMS {
+			timestampMS = b.TimestampMS
+		}
 	} else if timestampMS > math.MaxInt64 {
 		return result, errors.WithDetail(httpjson.ErrBadRequest, "timestamp is too large")
 	}
 	limit := defGenericPageSize
-	outputs, nextAfter, err := h.Indexer.Outputs(ctx, p, in.FilterParams, timestampMS, after, limit)
+	outputs, nextAfter, err := h.Indexer.Outputs(ctx, p, filterParams, timestampMS, after, limit)
 	if err != nil {
 		return result, errors.Wrap(err, "querying outputs")
 	}
@@ -395,32 +639,33 @@ func (h *Handler) listUnspentOutputs(ctx context.Context, in requestQuery) (resu
 
 	outQuery := in
 	outQuery.After = nextAfter.String()
-	return page{
+	outQuery.TimestampMS = chainjson.Millis(timestampMS)
+	return Page{
 		Items:    resp,
 		LastPage: len(resp) < limit,
 		Next:     outQuery,
 	}, nil
 }
 
-// listAssets is an http handler for listing assets matching
+// ListAssets lists assets matching
 // an index or an ad-hoc filter.
 //
 // POST /list-assets
-func (h *Handler) listAssets(ctx context.Context, in requestQuery) (page, error) {
+func (h *Handler) ListAssets(ctx context.Context, in QueryRequest) (Page, error) {
 	limit := defGenericPageSize
 
 	// Build the filter predicate.
 	p, err := filter.Parse(in.Filter)
 	if err != nil {
-		return page{}, err
+		return Page{}, err
 	}
 	after := in.After
 
 	// Use the query engine for querying asset tags.
 	var assets []map[string]interface{}
-	assets, after, err = h.Indexer.Assets(ctx, p, in.FilterParams, after, limit)
+	assets, after, err = h.Indexer.Assets(ctx, p, in.FilterParams, after, limit, in.IncludeArchived)
 	if err != nil {
-		return page{}, errors.Wrap(err, "running asset query")
+		return Page{}, errors.Wrap(err, "running asset query")
 	}
 
 	result := make([]*assetResponse, 0, len(assets))
@@ -448,6 +693,7 @@ func (h *Handler) listAssets(ctx context.Context, in requestQuery) (page, error)
 			Definition:      a["definition"],
 			Tags:            a["tags"],
 			IsLocal:         a["is_local"],
+			ArchivedAt:      a["archived_at"],
 		}
 		if alias, ok := a["alias"].(string); ok && alias != "" {
 			r.Alias = &alias
@@ -457,7 +703,7 @@ func (h *Handler) listAssets(ctx context.Context, in requestQuery) (page, error)
 
 	out := in
 	out.After = after
-	return page{
+	return Page{
 		Items:    httpjson.Array(result),
 		LastPage: len(result) < limit,
 		Next:     out,
@@ -478,18 +724,18 @@ func txAccountFromMap(m map[string]interface{}) *txAccount {
 // listTxFeeds is an http handler for listing txfeeds. It does not take a filter.
 //
 // POST /list-transaction-feeds
-func (h *Handler) listTxFeeds(ctx context.Context, in requestQuery) (page, error) {
+func (h *Handler) listTxFeeds(ctx context.Context, in QueryRequest) (Page, error) {
 	limit := defGenericPageSize
 	after := in.After
 
 	txfeeds, after, err := h.Indexer.TxFeeds(ctx, after, limit)
 	if err != nil {
-		return page{}, errors.Wrap(err, "running txfeed query")
+		return Page{}, errors.Wrap(err, "running txfeed query")
 	}
 
 	out := in
 	out.After = after
-	return page{
+	return Page{
 		Items:    httpjson.Array(txfeeds),
 		LastPage: len(txfeeds) < limit,
 		Next:     out,
@@ -12,6 +12,11 @@ import (
 )
 
 // SaveAnnotatedAccount saves an annotated account to the query indexes.
+//
+// The account's unspent outputs are given its current tags, so
+// that balances and unspent outputs can be queried by the tags
+// an account has now. Transactions and spent outputs keep the
+// tags the account had when they were indexed.
 func (ind *Indexer) SaveAnnotatedAccount(ctx context.Context, accountID string, account map[string]interface{}) error {
 	b, err := json.Marshal(account)
 	if err != nil {
@@ -23,11 +28,37 @@ func (ind *Indexer) SaveAnnotatedAccount(ctx context.Context, accountID string,
 		ON CONFLICT (id) DO UPDATE SET data = $2
 	`
 	_, err = ind.db.Exec(ctx, q, accountID, b)
-	return errors.Wrap(err, "saving annotated account")
+	if err != nil {
+		return errors.Wrap(err, "saving annotated account")
+	}
+
+	tags, err := json.Marshal(account["tags"])
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	// Retagging changes balances grouped by tags without a new
+	// block, so it bumps the version that balance caches check.
+	const retagQ = `
+		WITH retagged AS (
+			UPDATE annotated_outputs SET data = CASE
+				WHEN $2::jsonb = 'null' THEN data - 'account_tags'
+				ELSE jsonb_set(data, '{account_tags}', $2::jsonb)
+			END
+			WHERE data @> jsonb_build_object('account_id', $1::text) AND upper_inf(timespan)
+				AND COALESCE(data->'account_tags', 'null') <> $2::jsonb
+			RETURNING 1
+		)
+		INSERT INTO output_tags_version (version)
+		SELECT 1 WHERE EXISTS (SELECT 1 FROM retagged)
+		ON CONFLICT (singleton) DO UPDATE SET version = output_tags_version.version + 1
+	`
+	_, err = ind.db.Exec(ctx, retagQ, accountID, string(tags))
+	return errors.Wrap(err, "retagging account outputs")
 }
 
 // Accounts queries the blockchain for accounts matching the query `q`.
-func (ind *Indexer) Accounts(ctx context.Context, p filter.Predicate, vals []interface{}, after string, limit int) ([]map[string]interface{}, string, error) {
+// Archived accounts are included only if includeArchived is true.
+func (ind *Indexer) Accounts(ctx context.Context, p filter.Predicate, vals []interface{}, after string, limit int, includeArchived bool) ([]map[string]interface{}, string, error) {
 	if len(vals) != p.Parameters {
 		return nil, "", ErrParameterCountMismatch
 	}
@@ -36,7 +67,7 @@ func (ind *Indexer) Accounts(ctx context.Context, p filter.Predicate, vals []int
 		return nil, "", errors.Wrap(err, "converting to SQL")
 	}
 
-	queryStr, queryArgs := constructAccountsQuery(expr, after, limit)
+	queryStr, queryArgs := constructAccountsQuery(expr, after, limit, includeArchived)
 	rows, err := ind.db.Query(ctx, queryStr, queryArgs...)
 	if err != nil {
 		return nil, "", errors.Wrap(err, "executing acc query")
@@ -66,7 +97,7 @@ func (ind *Indexer) Accounts(ctx context.Context, p filter.Predicate, vals []int
 	return accounts, after, errors.Wrap(rows.Err())
 }
 
-func constructAccountsQuery(expr filter.SQLExpr, after string, limit int) (string, []interface{}) {
+func constructAccountsQuery(expr filter.SQLExpr, after string, limit int, includeArchived bool) (string, []interface{}) {
 	var buf bytes.Buffer
 	var vals []interface{}
 
@@ -81,6 +112,11 @@ func constructAccountsQuery(expr filter.SQLExpr, after string, limit int) (strin
 		buf.WriteString(") AND ")
 	}
 
+	// archived accounts are hidden unless asked for
+	if !includeArchived {
+		buf.WriteString("data->>'archived_at' IS NULL AND ")
+	}
+
 	// add after conditions
 	buf.WriteString(fmt.Sprintf("($%d='' OR id < $%d) ", len(vals)+1, len(vals)+1))
 	vals = append(vals, after)
@@ -0,0 +1,136 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"chain/errors"
+)
+
+// MaxActivityTxs bounds the transactions listed by AccountActivity.
+const MaxActivityTxs = 1000
+
+// AssetDelta is the net change in an account's
+// holdings of an asset.
+type AssetDelta struct {
+	AssetID    string `json:"asset_id"`
+	AssetAlias string `json:"asset_alias,omitempty"`
+	Delta      int64  `json:"delta"`
+}
+
+// ActivityTx is a transaction that changed an
+// account's holdings, with its net effect on them.
+type ActivityTx struct {
+	ID          string       `json:"id"`
+	BlockHeight uint64       `json:"block_height"`
+	Position    uint32       `json:"position"`
+	Deltas      []AssetDelta `json:"deltas"`
+}
+
+// activityTx holds the parts of an annotated tx
+// needed to compute an account's deltas.
+type activityTx struct {
+	ID      string          `json:"id"`
+	Inputs  []activityEntry `json:"inputs"`
+	Outputs []activityEntry `json:"outputs"`
+}
+
+type activityEntry struct {
+	AccountID  string `json:"account_id"`
+	AssetID    string `json:"asset_id"`
+	AssetAlias string `json:"asset_alias"`
+	Amount     uint64 `json:"amount"`
+}
+
+// AccountActivity returns the net change in each asset held by
+// the account with ID accountID over the blocks after fromHeight
+// up to and including toHeight, and the transactions making it,
+// in the order they were confirmed. Transactions whose inputs and
+// outputs of the account cancel out are listed with no deltas.
+//
+// The deltas cover every transaction, but at most MaxActivityTxs
+// transactions are listed. The returned bool reports whether
+// the list was cut short.
+func (ind *Indexer) AccountActivity(ctx context.Context, accountID string, fromHeight, toHeight uint64) ([]AssetDelta, []ActivityTx, bool, error) {
+	const q = `
+		SELECT block_height, tx_pos, data FROM annotated_txs
+		WHERE block_height > $1 AND block_height <= $2
+			AND (data @> jsonb_build_object('inputs', jsonb_build_array(jsonb_build_object('account_id', $3::text)))
+				OR data @> jsonb_build_object('outputs', jsonb_build_array(jsonb_build_object('account_id', $3::text))))
+		ORDER BY block_height ASC, tx_pos ASC
+	`
+	rows, err := ind.db.Query(ctx, q, fromHeight, toHeight, accountID)
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err, "querying account activity")
+	}
+	defer rows.Close()
+
+	var (
+		totals    = make(map[string]int64)
+		aliases   = make(map[string]string)
+		txs       = []ActivityTx{}
+		truncated bool
+	)
+	for rows.Next() {
+		var (
+			height uint64
+			pos    uint32
+			data   []byte
+			tx     activityTx
+		)
+		err = rows.Scan(&height, &pos, &data)
+		if err != nil {
+			return nil, nil, false, errors.Wrap(err, "scanning account activity")
+		}
+		err = json.Unmarshal(data, &tx)
+		if err != nil {
+			return nil, nil, false, errors.Wrap(err, "decoding annotated tx")
+		}
+
+		deltas := make(map[string]int64)
+		for _, in := range tx.Inputs {
+			if in.AccountID == accountID {
+				deltas[in.AssetID] -= int64(in.Amount)
+				aliases[in.AssetID] = in.AssetAlias
+			}
+		}
+		for _, out := range tx.Outputs {
+			if out.AccountID == accountID {
+				deltas[out.AssetID] += int64(out.Amount)
+				aliases[out.AssetID] = out.AssetAlias
+			}
+		}
+		for assetID, d := range deltas {
+			totals[assetID] += d
+		}
+
+		if len(txs) >= MaxActivityTxs {
+			truncated = true
+			continue
+		}
+		txs = append(txs, ActivityTx{
+			ID:          tx.ID,
+			BlockHeight: height,
+			Position:    pos,
+			Deltas:      sortedDeltas(deltas, aliases),
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, false, errors.Wrap(err)
+	}
+	return sortedDeltas(totals, aliases), txs, truncated, nil
+}
+
+// sortedDeltas returns the nonzero deltas in
+// deltas, in order of asset ID.
+func sortedDeltas(deltas map[string]int64, aliases map[string]string) []AssetDelta {
+	res := []AssetDelta{}
+	for assetID, d := range deltas {
+		if d != 0 {
+			res = append(res, AssetDelta{AssetID: assetID, AssetAlias: aliases[assetID], Delta: d})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].AssetID < res[j].AssetID })
+	return res
+}
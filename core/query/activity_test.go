@@ -0,0 +1,106 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func TestAccountActivity(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewTx(t)
+
+	indexer := NewIndexer(db, &protocol.Chain{})
+	// Attribute every spend, and every output to OP_TRUE, to account "acc1".
+	indexer.RegisterAnnotator(func(ctx context.Context, txs []map[string]interface{}) error {
+		for _, tx := range txs {
+			for _, in := range tx["inputs"].([]interface{}) {
+				in := in.(map[string]interface{})
+				if in["type"] == "spend" {
+					in["account_id"] = "acc1"
+				}
+			}
+			for _, out := range tx["outputs"].([]interface{}) {
+				out := out.(map[string]interface{})
+				if out["control_program"] == "51" {
+					out["account_id"] = "acc1"
+				}
+			}
+		}
+		return nil
+	})
+
+	var (
+		assetID = bc.AssetID{1}
+		ours    = []byte{byte(vm.OP_TRUE)}
+		theirs  = []byte{byte(vm.OP_FALSE)}
+	)
+	blocks := []*bc.Block{{
+		BlockHeader: bc.BlockHeader{Height: 2, TimestampMS: 1000},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{
+				Inputs: []*bc.TxInput{
+					bc.NewSpendInput(bc.Hash{9}, 0, nil, assetID, 10, ours, nil),
+				},
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 7, ours, nil),
+					bc.NewTxOutput(assetID, 3, theirs, nil),
+				},
+			}),
+			bc.NewTx(bc.TxData{
+				Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 4, theirs, nil)},
+			}),
+		},
+	}, {
+		BlockHeader: bc.BlockHeader{Height: 3, TimestampMS: 2000},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{
+				Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 5, ours, nil)},
+			}),
+		},
+	}}
+	for _, b := range blocks {
+		err := indexer.IndexTransactions(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deltas, txs, truncated, err := indexer.AccountActivity(ctx, "acc1", 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AssetDelta{{AssetID: assetID.String(), Delta: 2}}
+	if !reflect.DeepEqual(deltas, want) {
+		t.Errorf("deltas = %+v, want %+v", deltas, want)
+	}
+	wantTxs := []ActivityTx{{
+		ID:          blocks[0].Transactions[0].Hash.String(),
+		BlockHeight: 2,
+		Deltas:      []AssetDelta{{AssetID: assetID.String(), Delta: -3}},
+	}, {
+		ID:          blocks[1].Transactions[0].Hash.String(),
+		BlockHeight: 3,
+		Deltas:      []AssetDelta{{AssetID: assetID.String(), Delta: 5}},
+	}}
+	if !reflect.DeepEqual(txs, wantTxs) {
+		t.Errorf("txs = %+v, want %+v", txs, wantTxs)
+	}
+	if truncated {
+		t.Error("activity truncated")
+	}
+
+	deltas, _, _, err = indexer.AccountActivity(ctx, "acc1", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []AssetDelta{{AssetID: assetID.String(), Delta: 5}}
+	if !reflect.DeepEqual(deltas, want) {
+		t.Errorf("deltas after height 2 = %+v, want %+v", deltas, want)
+	}
+}
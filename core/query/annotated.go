@@ -20,6 +20,7 @@ func transactionObject(orig *bc.Tx, b *bc.Block, indexInBlock uint32) map[string
 		"block_id":       b.Hash().String(),
 		"block_height":   b.Height,
 		"position":       indexInBlock,
+		"size":           orig.SerializedSize(),
 		"reference_data": unmarshalReferenceData(orig.ReferenceData),
 	}
 
@@ -28,7 +28,8 @@ func (ind *Indexer) SaveAnnotatedAsset(ctx context.Context, assetID bc.AssetID,
 }
 
 // Assets queries the blockchain for annotated assets matching the query.
-func (ind *Indexer) Assets(ctx context.Context, p filter.Predicate, vals []interface{}, after string, limit int) ([]map[string]interface{}, string, error) {
+// Archived assets are included only if includeArchived is true.
+func (ind *Indexer) Assets(ctx context.Context, p filter.Predicate, vals []interface{}, after string, limit int, includeArchived bool) ([]map[string]interface{}, string, error) {
 	if len(vals) != p.Parameters {
 		return nil, "", ErrParameterCountMismatch
 	}
@@ -37,7 +38,7 @@ func (ind *Indexer) Assets(ctx context.Context, p filter.Predicate, vals []inter
 		return nil, "", errors.Wrap(err, "converting to SQL")
 	}
 
-	queryStr, queryArgs := constructAssetsQuery(expr, after, limit)
+	queryStr, queryArgs := constructAssetsQuery(expr, after, limit, includeArchived)
 	rows, err := ind.db.Query(ctx, queryStr, queryArgs...)
 	if err != nil {
 		return nil, "", errors.Wrap(err, "executing assets query")
@@ -74,7 +75,7 @@ func (ind *Indexer) Assets(ctx context.Context, p filter.Predicate, vals []inter
 	return assets, after, nil
 }
 
-func constructAssetsQuery(expr filter.SQLExpr, after string, limit int) (string, []interface{}) {
+func constructAssetsQuery(expr filter.SQLExpr, after string, limit int, includeArchived bool) (string, []interface{}) {
 	var buf bytes.Buffer
 	var vals []interface{}
 
@@ -89,6 +90,11 @@ func constructAssetsQuery(expr filter.SQLExpr, after string, limit int) (string,
 		buf.WriteString(") AND ")
 	}
 
+	// archived assets are hidden unless asked for
+	if !includeArchived {
+		buf.WriteString("data->>'archived_at' IS NULL AND ")
+	}
+
 	// add after conditions
 	buf.WriteString(fmt.Sprintf("($%d='' OR sort_id < $%d) ", len(vals)+1, len(vals)+1))
 	vals = append(vals, after)
@@ -3,8 +3,11 @@ package query
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
+	"sync"
 
 	"github.com/lib/pq"
 
@@ -17,11 +20,50 @@ func (ind *Indexer) Balances(ctx context.Context, p filter.Predicate, vals []int
 	if len(vals) != p.Parameters {
 		return nil, ErrParameterCountMismatch
 	}
+	err := ind.checkRetention(timestampMS)
+	if err != nil {
+		return nil, err
+	}
 	expr, err := filter.AsSQL(p, "data", vals)
 	if err != nil {
 		return nil, err
 	}
 	queryStr, queryArgs := constructBalancesQuery(expr, sumBy, timestampMS)
+
+	// Current balances change when a block lands, and when
+	// account tags are updated on unspent outputs. The chain
+	// height advances only after a block is indexed, and the
+	// output tags version, kept in the database so that every
+	// process sees it, after outputs are retagged. Results are
+	// cached until either changes.
+	var (
+		cacheKey string
+		gen      balanceGen
+	)
+	if timestampMS == math.MaxInt64 && ind.c != nil {
+		gen.height = ind.c.Height()
+		const q = `SELECT COALESCE(MAX(version), 0) FROM output_tags_version`
+		err = ind.db.QueryRow(ctx, q).Scan(&gen.tagsVersion)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading output tags version")
+		}
+		cacheKey = balanceCacheKey(queryStr, queryArgs)
+		if balances, ok := ind.balances.get(gen, cacheKey); ok {
+			return balances, nil
+		}
+	}
+
+	balances, err := ind.queryBalances(ctx, queryStr, queryArgs, sumBy)
+	if err != nil {
+		return nil, err
+	}
+	if cacheKey != "" {
+		ind.balances.put(gen, cacheKey, balances)
+	}
+	return balances, nil
+}
+
+func (ind *Indexer) queryBalances(ctx context.Context, queryStr string, queryArgs []interface{}, sumBy []filter.Field) ([]interface{}, error) {
 	rows, err := ind.db.Query(ctx, queryStr, queryArgs...)
 	if err != nil {
 		return nil, err
@@ -99,3 +141,55 @@ func constructBalancesQuery(expr filter.SQLExpr, sumBy []filter.Field, timestamp
 	// TODO(jackson): Support pagination.
 	return buf.String(), vals
 }
+
+// maxCachedBalances bounds the number of distinct balance
+// queries cached at one generation.
+const maxCachedBalances = 1000
+
+// balanceGen identifies a state of the current balances:
+// a blockchain height and an output tags version.
+type balanceGen struct {
+	height      uint64
+	tagsVersion uint64
+}
+
+// balanceCache holds balance query results
+// for a single generation.
+type balanceCache struct {
+	mu      sync.Mutex
+	gen     balanceGen
+	entries map[string][]interface{}
+}
+
+func balanceCacheKey(queryStr string, queryArgs []interface{}) string {
+	b, err := json.Marshal(queryArgs)
+	if err != nil {
+		return ""
+	}
+	return queryStr + "\x00" + string(b)
+}
+
+func (c *balanceCache) get(gen balanceGen, key string) ([]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen != gen {
+		return nil, false
+	}
+	balances, ok := c.entries[key]
+	return balances, ok
+}
+
+func (c *balanceCache) put(gen balanceGen, key string, balances []interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen.height < c.gen.height || gen.tagsVersion < c.gen.tagsVersion {
+		return
+	}
+	if gen != c.gen || c.entries == nil {
+		c.gen = gen
+		c.entries = make(map[string][]interface{})
+	}
+	if len(c.entries) < maxCachedBalances {
+		c.entries[key] = balances
+	}
+}
@@ -229,3 +229,41 @@ func jsonRT(tb testing.TB, v interface{}) interface{} {
 	}
 	return x
 }
+
+func TestBalanceCache(t *testing.T) {
+	var c balanceCache
+	want := []interface{}{"x"}
+	g5 := balanceGen{height: 5}
+	g6 := balanceGen{height: 6}
+
+	c.put(g5, "k", want)
+	if got, ok := c.get(g5, "k"); !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("get(5, k) = %v, %v want %v, true", got, ok, want)
+	}
+	if _, ok := c.get(g6, "k"); ok {
+		t.Error("get(6, k) hit, want miss at new height")
+	}
+
+	// A result computed at an older height must not
+	// replace entries for a newer one.
+	c.put(g6, "k2", want)
+	c.put(g5, "k", want)
+	if _, ok := c.get(g5, "k"); ok {
+		t.Error("get(5, k) hit after cache moved to height 6")
+	}
+	if _, ok := c.get(g6, "k2"); !ok {
+		t.Error("get(6, k2) miss, want hit")
+	}
+
+	// Retagging outputs invalidates the cache
+	// without a new block.
+	retagged := balanceGen{height: 6, tagsVersion: 1}
+	if _, ok := c.get(retagged, "k2"); ok {
+		t.Error("get(6, k2) hit after retagging, want miss")
+	}
+	c.put(retagged, "k2", want)
+	c.put(g6, "k", want)
+	if _, ok := c.get(retagged, "k2"); !ok {
+		t.Error("get(6, k2) miss after retagging, want hit")
+	}
+}
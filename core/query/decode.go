@@ -0,0 +1,88 @@
+package query
+
+import (
+	"context"
+	"encoding/hex"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+// DecodeTransaction annotates tx, which need not be in any block,
+// the same way indexed transactions are annotated, omitting the
+// block fields. Each program is also disassembled and, if it
+// matches a known template, described.
+func (ind *Indexer) DecodeTransaction(ctx context.Context, tx *bc.Tx) (map[string]interface{}, error) {
+	m := map[string]interface{}{
+		"id":             tx.Hash.String(),
+		"witness_hash":   tx.WitnessHash().String(),
+		"version":        tx.Version,
+		"min_time":       tx.MinTime,
+		"max_time":       tx.MaxTime,
+		"size":           tx.SerializedSize(),
+		"reference_data": unmarshalReferenceData(tx.ReferenceData),
+	}
+	inputs := make([]interface{}, 0, len(tx.Inputs))
+	for _, in := range tx.Inputs {
+		obj := transactionInput(in)
+		if in.IsIssuance() {
+			describeProgram(obj, "issuance_program", in.IssuanceProgram())
+		} else {
+			describeProgram(obj, "control_program", in.ControlProgram())
+		}
+		inputs = append(inputs, obj)
+	}
+	outputs := make([]interface{}, 0, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		obj := transactionOutput(out, uint32(i))
+		describeProgram(obj, "control_program", out.ControlProgram)
+		outputs = append(outputs, obj)
+	}
+	m["inputs"] = inputs
+	m["outputs"] = outputs
+
+	txs := []map[string]interface{}{m}
+	for _, annotator := range ind.annotators {
+		err := annotator(ctx, txs)
+		if err != nil {
+			return nil, errors.Wrap(err, "adding external annotations")
+		}
+	}
+	localAnnotator(ctx, txs)
+	return m, nil
+}
+
+// describeProgram adds the disassembly of prog to obj under
+// field+"_asm", and a description of its template, if known,
+// under field+"_template".
+func describeProgram(obj map[string]interface{}, field string, prog []byte) {
+	if asm, err := vm.Disassemble(prog); err == nil {
+		obj[field+"_asm"] = asm
+	}
+	if tmpl := ProgramTemplate(prog); tmpl != nil {
+		obj[field+"_template"] = tmpl
+	}
+}
+
+// ProgramTemplate describes the known template prog follows,
+// with its named parameters, or returns nil if it follows none.
+func ProgramTemplate(prog []byte) map[string]interface{} {
+	if vmutil.IsUnspendable(prog) {
+		return map[string]interface{}{"type": "retire"}
+	}
+	pubkeys, quorum, err := vmutil.ParseP2SPMultiSigProgram(prog)
+	if err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(pubkeys))
+	for _, pub := range pubkeys {
+		keys = append(keys, hex.EncodeToString(pub))
+	}
+	return map[string]interface{}{
+		"type":    "multisig",
+		"quorum":  quorum,
+		"pubkeys": keys,
+	}
+}
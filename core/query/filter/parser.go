@@ -50,6 +50,26 @@ func Parse(predicate string) (p Predicate, err error) {
 	}, nil
 }
 
+// And returns a predicate matching what both p and q match.
+// It combines the parsed predicates, so neither can change how
+// the other is grouped, as splicing their source text could.
+func And(p, q Predicate) Predicate {
+	if p.expr == nil {
+		return q
+	}
+	if q.expr == nil {
+		return p
+	}
+	params := p.Parameters
+	if q.Parameters > params {
+		params = q.Parameters
+	}
+	return Predicate{
+		Parameters: params,
+		expr:       binaryExpr{op: binaryOps["AND"], l: parenExpr{p.expr}, r: parenExpr{q.expr}},
+	}
+}
+
 // Field is a type for simple expressions that simply access an attribute of
 // the queried object. They're used for GROUP BYs.
 type Field struct {
@@ -149,3 +149,24 @@ func TestParseInvalid(t *testing.T) {
 		}
 	}
 }
+
+func TestAnd(t *testing.T) {
+	p, err := Parse("a = $1 OR b = $2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := Parse("c = $3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := And(p, q)
+	if want := "(a = $1 OR b = $2) AND (c = $3)"; got.String() != want {
+		t.Errorf("And(p, q) = %q want %q", got, want)
+	}
+	if got.Parameters != 3 {
+		t.Errorf("And(p, q).Parameters = %d want 3", got.Parameters)
+	}
+	if got := And(Predicate{}, q); got.String() != q.String() {
+		t.Errorf("And(empty, q) = %q want %q", got, q)
+	}
+}
@@ -0,0 +1,109 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// Holder is the total amount of an asset held by one account,
+// or by one control program outside any local account.
+type Holder struct {
+	AccountID      string  `json:"account_id,omitempty"`
+	ControlProgram string  `json:"control_program,omitempty"`
+	Amount         uint64  `json:"amount"`
+	Percentage     float64 `json:"percentage"`
+}
+
+// AssetHolders lists holders of assetID as of timestampMS,
+// ordered by account ID or control program. It returns the
+// holders after the one identified by after, at most limit of
+// them, and the total unspent amount of the asset, which is the
+// basis for each holder's percentage.
+//
+// Retired units are not indexed, so they are excluded from
+// the total.
+func (ind *Indexer) AssetHolders(ctx context.Context, assetID bc.AssetID, timestampMS uint64, after string, limit int) ([]Holder, uint64, error) {
+	err := ind.checkRetention(timestampMS)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Containment queries can use the GIN index on data.
+	const totalQ = `
+		SELECT COALESCE(SUM((data->>'amount')::bigint), 0) FROM annotated_outputs
+		WHERE data @> jsonb_build_object('asset_id', $1::text) AND timespan @> $2::int8
+	`
+	var total uint64
+	err = ind.db.QueryRow(ctx, totalQ, assetID.String(), timestampMS).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "summing asset supply")
+	}
+
+	// Account outputs are grouped by account, and all
+	// others by control program. The account prefix keeps
+	// the two kinds of key from colliding.
+	const q = `
+		SELECT holder, SUM(amount) FROM (
+			SELECT
+				CASE WHEN data ? 'account_id' THEN 'acc:' || (data->>'account_id')
+				ELSE 'cp:' || (data->>'control_program') END AS holder,
+				(data->>'amount')::bigint AS amount
+			FROM annotated_outputs
+			WHERE data @> jsonb_build_object('asset_id', $1::text) AND timespan @> $2::int8
+		) AS o
+		WHERE holder > $3
+		GROUP BY holder ORDER BY holder LIMIT $4
+	`
+	rows, err := ind.db.Query(ctx, q, assetID.String(), timestampMS, after, limit)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "querying asset holders")
+	}
+	defer rows.Close()
+
+	var holders []Holder
+	for rows.Next() {
+		var (
+			key string
+			h   Holder
+		)
+		err = rows.Scan(&key, &h.Amount)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "scanning asset holder")
+		}
+		switch {
+		case len(key) > 4 && key[:4] == "acc:":
+			h.AccountID = key[4:]
+		case len(key) > 3 && key[:3] == "cp:":
+			h.ControlProgram = key[3:]
+		}
+		if total > 0 {
+			h.Percentage = 100 * float64(h.Amount) / float64(total)
+		}
+		holders = append(holders, h)
+	}
+	return holders, total, errors.Wrap(rows.Err())
+}
+
+// HolderAfter returns the pagination cursor for h,
+// to pass to AssetHolders.
+func HolderAfter(h Holder) string {
+	if h.AccountID != "" {
+		return "acc:" + h.AccountID
+	}
+	return "cp:" + h.ControlProgram
+}
+
+// BlockTimestamp returns the timestamp of the indexed
+// block at height.
+func (ind *Indexer) BlockTimestamp(ctx context.Context, height uint64) (uint64, error) {
+	var ts uint64
+	err := ind.db.QueryRow(ctx, `SELECT timestamp FROM query_blocks WHERE height = $1`, height).Scan(&ts)
+	if err == sql.ErrNoRows {
+		return 0, errors.WithDetailf(pg.ErrUserInputNotFound, "block height %d", height)
+	}
+	return ts, errors.Wrap(err, "looking up block timestamp")
+}
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func TestAssetHolders(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewTx(t)
+
+	indexer := NewIndexer(db, &protocol.Chain{})
+	// Attribute every output to OP_TRUE to account "acc1".
+	indexer.RegisterAnnotator(func(ctx context.Context, txs []map[string]interface{}) error {
+		for _, tx := range txs {
+			for _, out := range tx["outputs"].([]interface{}) {
+				out := out.(map[string]interface{})
+				if out["control_program"] == "51" {
+					out["account_id"] = "acc1"
+				}
+			}
+		}
+		return nil
+	})
+
+	var (
+		assetID = bc.AssetID{1}
+		other   = bc.AssetID{2}
+		ours    = []byte{byte(vm.OP_TRUE)}
+		theirs  = []byte{byte(vm.OP_FALSE)}
+	)
+	blocks := []*bc.Block{{
+		BlockHeader: bc.BlockHeader{Height: 2, TimestampMS: 1000},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 6, ours, nil),
+					bc.NewTxOutput(assetID, 3, theirs, nil),
+					bc.NewTxOutput(other, 5, ours, nil),
+				},
+			}),
+		},
+	}, {
+		BlockHeader: bc.BlockHeader{Height: 3, TimestampMS: 2000},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{
+				Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 1, ours, nil)},
+			}),
+		},
+	}}
+	for _, b := range blocks {
+		err := indexer.IndexTransactions(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	holders, total, err := indexer.AssetHolders(ctx, assetID, 2000, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 10 {
+		t.Errorf("total = %d, want 10", total)
+	}
+	want := []Holder{{AccountID: "acc1", Amount: 7, Percentage: 70}}
+	if !reflect.DeepEqual(holders, want) {
+		t.Fatalf("first page = %+v, want %+v", holders, want)
+	}
+
+	holders, _, err = indexer.AssetHolders(ctx, assetID, 2000, HolderAfter(holders[0]), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Holder{{ControlProgram: "00", Amount: 3, Percentage: 30}}
+	if !reflect.DeepEqual(holders, want) {
+		t.Errorf("second page = %+v, want %+v", holders, want)
+	}
+
+	// Before the second block, acc1 held 6 of 9.
+	holders, total, err = indexer.AssetHolders(ctx, assetID, 1500, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 9 || len(holders) != 2 || holders[0].Amount != 6 {
+		t.Errorf("at 1500: total %d, holders %+v; want total 9, acc1 holding 6", total, holders)
+	}
+}
@@ -67,6 +67,10 @@ func (ind *Indexer) insertAnnotatedTxs(ctx context.Context, b *bc.Block) ([]map[
 		}
 	}
 	localAnnotator(ctx, annotatedTxsDecoded)
+	err := ind.annotateLabels(ctx, b, annotatedTxsDecoded)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, decoded := range annotatedTxsDecoded {
 		b, err := json.Marshal(decoded)
@@ -82,7 +86,7 @@ func (ind *Indexer) insertAnnotatedTxs(ctx context.Context, b *bc.Block) ([]map[
 		SELECT $1, unnest($2::integer[]), unnest($3::text[]), unnest($4::jsonb[])
 		ON CONFLICT (block_height, tx_pos) DO NOTHING;
 	`
-	_, err := ind.db.Exec(ctx, insertQ, b.Height, positions, hashes, annotatedTxs)
+	_, err = ind.db.Exec(ctx, insertQ, b.Height, positions, hashes, annotatedTxs)
 	if err != nil {
 		return nil, errors.Wrap(err, "inserting annotated_txs to db")
 	}
@@ -97,6 +101,7 @@ func (ind *Indexer) insertAnnotatedOutputs(ctx context.Context, b *bc.Block, ann
 		outputData        pq.StringArray
 		prevoutHashes     pq.StringArray
 		prevoutIndexes    pg.Uint32s
+		retired           retirementBatch
 	)
 
 	for pos, tx := range b.Transactions {
@@ -117,9 +122,14 @@ func (ind *Indexer) insertAnnotatedOutputs(ctx context.Context, b *bc.Block, ann
 				return errors.Wrap(fmt.Errorf("bad output type %T", out))
 			}
 
-			// Don't index retired outputs.
+			// Don't index retired outputs, but
+			// record them in the retirement ledger.
 			typ, ok := txOut["type"].(string)
 			if ok && typ == "retire" {
+				err := retired.add(tx, uint32(pos), uint32(outIndex), annotatedTxs[pos], txOut)
+				if err != nil {
+					return err
+				}
 				continue
 			}
 
@@ -159,5 +169,9 @@ func (ind *Indexer) insertAnnotatedOutputs(ctx context.Context, b *bc.Block, ann
 		WHERE (tx_hash, output_index) IN (SELECT unnest($2::text[]), unnest($3::integer[]))
 	`
 	_, err = ind.db.Exec(ctx, updateQ, b.TimestampMS, prevoutHashes, prevoutIndexes)
-	return errors.Wrap(err, "updating spent annotated outputs")
+	if err != nil {
+		return errors.Wrap(err, "updating spent annotated outputs")
+	}
+
+	return ind.insertRetirements(ctx, b, &retired)
 }
@@ -1,6 +1,8 @@
 package query
 
 import (
+	"time"
+
 	"chain/database/pg"
 	"chain/protocol"
 )
@@ -19,4 +21,6 @@ type Indexer struct {
 	db         pg.DB
 	c          *protocol.Chain
 	annotators []Annotator
+	balances   balanceCache
+	retention  time.Duration
 }
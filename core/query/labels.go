@@ -0,0 +1,66 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/lib/pq"
+
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// SaveTransactionLabels records labels for the transaction
+// with hash txHash, to be added to its annotations when it
+// is indexed. The first labels saved for a transaction are
+// kept; later calls for the same transaction do nothing.
+func (ind *Indexer) SaveTransactionLabels(ctx context.Context, txHash bc.Hash, labels map[string]string) error {
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return errors.Wrap(err, "serializing transaction labels")
+	}
+	const q = `
+		INSERT INTO transaction_labels (tx_hash, labels) VALUES ($1, $2)
+		ON CONFLICT (tx_hash) DO NOTHING
+	`
+	_, err = ind.db.Exec(ctx, q, txHash.String(), data)
+	return errors.Wrap(err, "saving transaction labels")
+}
+
+// annotateLabels adds the saved labels of each
+// transaction in b to its annotated transaction.
+func (ind *Indexer) annotateLabels(ctx context.Context, b *bc.Block, txs []map[string]interface{}) error {
+	if len(b.Transactions) == 0 {
+		return nil
+	}
+	hashes := make(pq.StringArray, 0, len(b.Transactions))
+	positions := make(map[string]int, len(b.Transactions))
+	for pos, tx := range b.Transactions {
+		hashes = append(hashes, tx.Hash.String())
+		positions[tx.Hash.String()] = pos
+	}
+
+	const q = `SELECT tx_hash, labels FROM transaction_labels WHERE tx_hash = ANY($1)`
+	rows, err := ind.db.Query(ctx, q, hashes)
+	if err != nil {
+		return errors.Wrap(err, "querying transaction labels")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			hash   string
+			data   []byte
+			labels map[string]interface{}
+		)
+		err = rows.Scan(&hash, &data)
+		if err != nil {
+			return errors.Wrap(err, "scanning transaction labels")
+		}
+		err = json.Unmarshal(data, &labels)
+		if err != nil {
+			return errors.Wrap(err, "decoding transaction labels")
+		}
+		txs[positions[hash]]["labels"] = labels
+	}
+	return errors.Wrap(rows.Err())
+}
@@ -0,0 +1,52 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol"
+	"chain/protocol/bc"
+)
+
+func TestTransactionLabels(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewTx(t)
+	indexer := NewIndexer(db, &protocol.Chain{})
+
+	labeled, unlabeled := bc.Hash{1}, bc.Hash{2}
+	err := indexer.SaveTransactionLabels(ctx, labeled, map[string]string{"batch_id": "b1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first labels saved win.
+	err = indexer.SaveTransactionLabels(ctx, labeled, map[string]string{"batch_id": "b2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bc.Block{
+		Transactions: []*bc.Tx{{Hash: unlabeled}, {Hash: labeled}},
+	}
+	txs, err := indexer.insertAnnotatedTxs(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := txs[0]["labels"]; ok {
+		t.Errorf("unlabeled tx has labels %v", txs[0]["labels"])
+	}
+	want := map[string]interface{}{"batch_id": "b1"}
+	if !reflect.DeepEqual(txs[1]["labels"], want) {
+		t.Errorf("labels = %v, want %v", txs[1]["labels"], want)
+	}
+
+	var n int
+	err = db.QueryRow(ctx, `SELECT COUNT(*) FROM annotated_txs WHERE data->'labels'->>'batch_id' = 'b1'`).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("got %d indexed txs labeled b1, want 1", n)
+	}
+}
@@ -51,6 +51,10 @@ func (ind *Indexer) Outputs(ctx context.Context, p filter.Predicate, vals []inte
 	if len(vals) != p.Parameters {
 		return nil, nil, ErrParameterCountMismatch
 	}
+	err := ind.checkRetention(timestampMS)
+	if err != nil {
+		return nil, nil, err
+	}
 	expr, err := filter.AsSQL(p, "data", vals)
 	if err != nil {
 		return nil, nil, err
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+)
+
+// ErrPruned is returned for queries of output history
+// older than the indexer's retention period.
+var ErrPruned = errors.New("output history pruned")
+
+// pruneBatchSize is the most outputs deleted in one statement,
+// to keep each delete's locks and WAL volume small.
+const pruneBatchSize = 10000
+
+// SetRetention sets how long the indexer keeps outputs after they
+// are spent. Zero, the default, keeps them forever. It must be
+// called before the indexer is used.
+//
+// Only spent outputs are pruned. Annotated transactions and raw
+// blocks are kept, so transaction history remains complete; only
+// queries of unspent outputs or balances as of a time before the
+// retention period are refused. They are not answered from the
+// kept blocks instead.
+func (ind *Indexer) SetRetention(d time.Duration) {
+	ind.retention = d
+}
+
+// checkRetention returns ErrPruned if outputs unspent
+// at timestampMS may have been pruned.
+func (ind *Indexer) checkRetention(timestampMS uint64) error {
+	if ind.retention == 0 {
+		return nil
+	}
+	// An output unspent at timestampMS was spent after it,
+	// so it is kept as long as timestampMS is within the
+	// retention period.
+	horizon := time.Now().Add(-ind.retention)
+	if timestampMS < bc.Millis(horizon) {
+		return errors.WithDetailf(ErrPruned, "outputs spent before %s are not retained; list transactions to see older history", horizon.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// retentionHorizon returns the time, in milliseconds, before
+// which spent outputs may have been pruned, or 0 if they are
+// kept forever.
+func (ind *Indexer) retentionHorizon() uint64 {
+	if ind.retention == 0 {
+		return 0
+	}
+	return bc.Millis(time.Now().Add(-ind.retention))
+}
+
+// PruneSpentOutputs is meant to be run as a goroutine. Every period,
+// it deletes outputs spent longer ago than the retention period.
+// It returns immediately if there is no retention period, and
+// otherwise when its context is canceled.
+func (ind *Indexer) PruneSpentOutputs(ctx context.Context, period time.Duration) {
+	if ind.retention == 0 {
+		return
+	}
+	ticks := time.Tick(period)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Messagef(ctx, "Deposed, PruneSpentOutputs exiting")
+			return
+		case <-ticks:
+			n, err := ind.pruneSpentOutputs(ctx, ind.retentionHorizon())
+			if err != nil {
+				log.Error(ctx, err)
+			} else if n > 0 {
+				log.Messagef(ctx, "pruned %d spent outputs", n)
+			}
+		}
+	}
+}
+
+// pruneSpentOutputs deletes outputs spent before beforeMS, in
+// batches. The partial index annotated_outputs_spent_idx finds
+// them without scanning the unspent outputs.
+func (ind *Indexer) pruneSpentOutputs(ctx context.Context, beforeMS uint64) (int64, error) {
+	const q = `
+		DELETE FROM annotated_outputs WHERE (block_height, tx_pos, output_index) IN (
+			SELECT block_height, tx_pos, output_index FROM annotated_outputs
+			WHERE NOT upper_inf(timespan) AND upper(timespan) < $1
+			LIMIT $2
+		)
+	`
+	var total int64
+	for {
+		res, err := ind.db.Exec(ctx, q, beforeMS, pruneBatchSize)
+		if err != nil {
+			return total, errors.Wrap(err, "pruning spent outputs")
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, errors.Wrap(err)
+		}
+		total += n
+		if n < pruneBatchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func TestCheckRetention(t *testing.T) {
+	ind := NewIndexer(nil, &protocol.Chain{})
+	now := time.Now()
+	old := bc.Millis(now.Add(-72 * time.Hour))
+	recent := bc.Millis(now.Add(-time.Hour))
+
+	if err := ind.checkRetention(old); err != nil {
+		t.Errorf("without retention: checkRetention(old) = %v, want nil", err)
+	}
+	ind.SetRetention(48 * time.Hour)
+	if err := ind.checkRetention(recent); err != nil {
+		t.Errorf("checkRetention(recent) = %v, want nil", err)
+	}
+	if err := ind.checkRetention(old); errors.Root(err) != ErrPruned {
+		t.Errorf("checkRetention(old) = %v, want %v", err, ErrPruned)
+	}
+}
+
+func TestPruneSpentOutputs(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewTx(t)
+	ind := NewIndexer(db, &protocol.Chain{})
+	ind.SetRetention(48 * time.Hour)
+
+	var (
+		assetID = bc.AssetID{1}
+		prog    = []byte{byte(vm.OP_TRUE)}
+		now     = time.Now()
+	)
+	// tx0 and tx1 are 10 and 9 days old. tx1 spends one
+	// of tx0's outputs, and tx2, an hour old, spends tx1's.
+	tx0 := bc.NewTx(bc.TxData{
+		Inputs: []*bc.TxInput{bc.NewSpendInput(bc.Hash{9}, 0, nil, assetID, 10, prog, nil)},
+		Outputs: []*bc.TxOutput{
+			bc.NewTxOutput(assetID, 6, prog, nil),
+			bc.NewTxOutput(assetID, 4, prog, nil),
+		},
+	})
+	tx1 := bc.NewTx(bc.TxData{
+		Inputs:  []*bc.TxInput{bc.NewSpendInput(tx0.Hash, 0, nil, assetID, 6, prog, nil)},
+		Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 6, prog, nil)},
+	})
+	tx2 := bc.NewTx(bc.TxData{
+		Inputs:  []*bc.TxInput{bc.NewSpendInput(tx1.Hash, 0, nil, assetID, 6, prog, nil)},
+		Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 6, prog, nil)},
+	})
+	blocks := []*bc.Block{{
+		BlockHeader:  bc.BlockHeader{Height: 2, TimestampMS: bc.Millis(now.Add(-240 * time.Hour))},
+		Transactions: []*bc.Tx{tx0},
+	}, {
+		BlockHeader:  bc.BlockHeader{Height: 3, TimestampMS: bc.Millis(now.Add(-216 * time.Hour))},
+		Transactions: []*bc.Tx{tx1},
+	}, {
+		BlockHeader:  bc.BlockHeader{Height: 4, TimestampMS: bc.Millis(now.Add(-time.Hour))},
+		Transactions: []*bc.Tx{tx2},
+	}}
+	for _, b := range blocks {
+		err := ind.IndexTransactions(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only tx0's first output was spent before the horizon.
+	n, err := ind.pruneSpentOutputs(ctx, ind.retentionHorizon())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("pruned %d outputs, want 1", n)
+	}
+	var left int
+	err = db.QueryRow(ctx, `SELECT COUNT(*) FROM annotated_outputs`).Scan(&left)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left != 3 {
+		t.Errorf("%d outputs left, want 3", left)
+	}
+
+	// Growth reports only the days after the horizon,
+	// which saw tx2 spend tx1's output and create its own.
+	stats, err := ind.UTXOStats(ctx, 10, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Outputs != 2 {
+		t.Errorf("stats.Outputs = %d, want 2", stats.Outputs)
+	}
+	var created, spent uint64
+	for _, g := range stats.Growth {
+		created += g.Created
+		spent += g.Spent
+	}
+	if created != 1 || spent != 1 {
+		t.Errorf("growth created %d and spent %d, want 1 and 1 (growth %+v)", created, spent, stats.Growth)
+	}
+}
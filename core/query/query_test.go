@@ -38,11 +38,11 @@ func setupQueryTest(t *testing.T) (context.Context, *Indexer, time.Time, time.Ti
 
 	asset1Tags := map[string]interface{}{"currency": "USD"}
 
-	asset1, err := assets.Define(ctx, []string{testutil.TestXPub.String()}, 1, nil, "", asset1Tags, nil)
+	asset1, err := assets.Define(ctx, []string{testutil.TestXPub.String()}, 1, nil, "", asset1Tags, nil, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	asset2, err := assets.Define(ctx, []string{testutil.TestXPub.String()}, 1, nil, "", nil, nil)
+	asset2, err := assets.Define(ctx, []string{testutil.TestXPub.String()}, 1, nil, "", nil, nil, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,153 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// Retirement is one retire output: an amount of an asset
+// removed from circulation.
+type Retirement struct {
+	TransactionID string           `json:"transaction_id"`
+	Position      uint32           `json:"position"`
+	AssetID       string           `json:"asset_id"`
+	Amount        uint64           `json:"amount"`
+	AccountID     string           `json:"account_id,omitempty"`
+	BlockHeight   uint64           `json:"block_height"`
+	Timestamp     uint64           `json:"timestamp"`
+	ReferenceData *json.RawMessage `json:"reference_data"`
+
+	txPos uint32
+}
+
+// retirementBatch collects the retire outputs of one block,
+// for a single insert.
+type retirementBatch struct {
+	txPositions   pg.Uint32s
+	outputIndexes pg.Uint32s
+	txHashes      pq.StringArray
+	assetIDs      pq.StringArray
+	amounts       pq.Int64Array
+	accountIDs    pq.StringArray
+	refData       pq.StringArray
+}
+
+// add records the annotated retire output txOut at
+// outIndex of tx. The units are attributed to the account
+// of the first of the transaction's inputs of the same asset
+// that belongs to a local account, if any.
+func (r *retirementBatch) add(tx *bc.Tx, pos, outIndex uint32, annotatedTx, txOut map[string]interface{}) error {
+	out := tx.Outputs[outIndex]
+
+	var accountID string
+	ins, _ := annotatedTx["inputs"].([]interface{})
+	for _, inObj := range ins {
+		in, ok := inObj.(map[string]interface{})
+		if !ok {
+			return errors.Wrap(fmt.Errorf("bad input type %T", inObj))
+		}
+		if in["asset_id"] != out.AssetID.String() {
+			continue
+		}
+		if id, ok := in["account_id"].(string); ok {
+			accountID = id
+			break
+		}
+	}
+
+	refData, err := json.Marshal(txOut["reference_data"])
+	if err != nil {
+		return errors.Wrap(err, "serializing retirement reference data")
+	}
+
+	r.txPositions = append(r.txPositions, pos)
+	r.outputIndexes = append(r.outputIndexes, outIndex)
+	r.txHashes = append(r.txHashes, tx.Hash.String())
+	r.assetIDs = append(r.assetIDs, out.AssetID.String())
+	r.amounts = append(r.amounts, int64(out.Amount))
+	r.accountIDs = append(r.accountIDs, accountID)
+	r.refData = append(r.refData, string(refData))
+	return nil
+}
+
+func (ind *Indexer) insertRetirements(ctx context.Context, b *bc.Block, r *retirementBatch) error {
+	if len(r.txPositions) == 0 {
+		return nil
+	}
+	const q = `
+		INSERT INTO retirements (block_height, tx_pos, output_index, tx_hash,
+			asset_id, amount, account_id, reference_data, timestamp)
+		SELECT $1, unnest($2::integer[]), unnest($3::integer[]), unnest($4::text[]),
+			unnest($5::text[]), unnest($6::bigint[]), NULLIF(unnest($7::text[]), ''),
+			unnest($8::jsonb[]), $9
+		ON CONFLICT (block_height, tx_pos, output_index) DO NOTHING
+	`
+	_, err := ind.db.Exec(ctx, q, b.Height, r.txPositions, r.outputIndexes, r.txHashes,
+		r.assetIDs, r.amounts, r.accountIDs, r.refData, b.TimestampMS)
+	return errors.Wrap(err, "batch inserting retirements")
+}
+
+// AssetRetirements lists the retirements of assetID in the
+// order they were confirmed. It returns the retirements after
+// the one identified by after, at most limit of them, and the
+// total amount of the asset ever retired.
+func (ind *Indexer) AssetRetirements(ctx context.Context, assetID bc.AssetID, after string, limit int) ([]Retirement, uint64, error) {
+	var height, txPos, outIndex int64 = -1, -1, -1
+	if after != "" {
+		_, err := fmt.Sscanf(after, "%d:%d:%d", &height, &txPos, &outIndex)
+		if err != nil {
+			return nil, 0, errors.Wrap(ErrBadAfter)
+		}
+	}
+
+	const totalQ = `
+		SELECT COALESCE(SUM(amount), 0) FROM retirements WHERE asset_id = $1
+	`
+	var total uint64
+	err := ind.db.QueryRow(ctx, totalQ, assetID.String()).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "summing retirements")
+	}
+
+	const q = `
+		SELECT block_height, tx_pos, output_index, tx_hash, amount,
+			COALESCE(account_id, ''), reference_data, timestamp
+		FROM retirements
+		WHERE asset_id = $1 AND (block_height, tx_pos, output_index) > ($2, $3, $4)
+		ORDER BY block_height, tx_pos, output_index
+		LIMIT $5
+	`
+	rows, err := ind.db.Query(ctx, q, assetID.String(), height, txPos, outIndex, limit)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "querying retirements")
+	}
+	defer rows.Close()
+
+	var retirements []Retirement
+	for rows.Next() {
+		r := Retirement{AssetID: assetID.String()}
+		var refData []byte
+		err = rows.Scan(&r.BlockHeight, &r.txPos, &r.Position, &r.TransactionID,
+			&r.Amount, &r.AccountID, &refData, &r.Timestamp)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "scanning retirement")
+		}
+		raw := json.RawMessage(refData)
+		r.ReferenceData = &raw
+		retirements = append(retirements, r)
+	}
+	return retirements, total, errors.Wrap(rows.Err())
+}
+
+// RetirementAfter returns the pagination cursor for r,
+// to pass to AssetRetirements.
+func RetirementAfter(r Retirement) string {
+	return fmt.Sprintf("%d:%d:%d", r.BlockHeight, r.txPos, r.Position)
+}
@@ -0,0 +1,86 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func TestAssetRetirements(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewTx(t)
+
+	indexer := NewIndexer(db, &protocol.Chain{})
+	// Attribute every spend to account "acc1".
+	indexer.RegisterAnnotator(func(ctx context.Context, txs []map[string]interface{}) error {
+		for _, tx := range txs {
+			for _, in := range tx["inputs"].([]interface{}) {
+				in := in.(map[string]interface{})
+				if in["type"] == "spend" {
+					in["account_id"] = "acc1"
+				}
+			}
+		}
+		return nil
+	})
+
+	var (
+		assetID = bc.AssetID{1}
+		other   = bc.AssetID{2}
+		retire  = []byte{byte(vm.OP_FAIL)}
+	)
+	b := &bc.Block{
+		BlockHeader: bc.BlockHeader{Height: 2, TimestampMS: 1000},
+		Transactions: []*bc.Tx{
+			bc.NewTx(bc.TxData{
+				Inputs: []*bc.TxInput{
+					bc.NewSpendInput(bc.Hash{9}, 0, nil, assetID, 10, []byte{byte(vm.OP_TRUE)}, nil),
+				},
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 7, []byte{byte(vm.OP_TRUE)}, nil),
+					bc.NewTxOutput(assetID, 3, retire, []byte(`{"reason":"burn"}`)),
+				},
+			}),
+			bc.NewTx(bc.TxData{
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 5, retire, nil),
+					bc.NewTxOutput(other, 4, retire, nil),
+				},
+			}),
+		},
+	}
+	err := indexer.IndexTransactions(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, total, err := indexer.AssetRetirements(ctx, assetID, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 8 {
+		t.Errorf("total retired = %d, want 8", total)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d retirements, want 1", len(got))
+	}
+	r := got[0]
+	if r.TransactionID != b.Transactions[0].Hash.String() || r.Position != 1 || r.Amount != 3 || r.AccountID != "acc1" || r.Timestamp != 1000 {
+		t.Errorf("first retirement = %+v", r)
+	}
+	if string(*r.ReferenceData) != `{"reason": "burn"}` {
+		t.Errorf("reference data = %s", *r.ReferenceData)
+	}
+
+	got, _, err = indexer.AssetRetirements(ctx, assetID, RetirementAfter(r), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Amount != 5 || got[0].AccountID != "" {
+		t.Errorf("second page = %+v, want the unattributed 5-unit retirement", got)
+	}
+}
@@ -0,0 +1,200 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// MaxTraceHops and MaxTraceTxs bound the work done by Trace.
+const (
+	MaxTraceHops = 10
+	MaxTraceTxs  = 1000
+)
+
+// TraceStep is a transaction reached by Trace, with the
+// number of hops from the transaction containing the
+// starting output (which is hop 0).
+type TraceStep struct {
+	Hop         int              `json:"hop"`
+	Transaction *json.RawMessage `json:"transaction"`
+}
+
+// tracedTx holds the parts of an annotated tx
+// needed to follow the graph.
+type tracedTx struct {
+	ID     string `json:"id"`
+	Inputs []struct {
+		SpentOutput *struct {
+			TransactionID string `json:"transaction_id"`
+			Position      uint32 `json:"position"`
+		} `json:"spent_output"`
+	} `json:"inputs"`
+	Outputs []struct {
+		Position uint32 `json:"position"`
+	} `json:"outputs"`
+}
+
+// Trace walks the transaction graph up to hops steps from
+// the output at start. If forward is true it follows the
+// transactions that spend outputs, otherwise the transactions
+// whose outputs were spent. Only indexed transactions are
+// visited; issuances end a backward walk.
+//
+// Trace stops after MaxTraceTxs transactions. The returned
+// bool reports whether it stopped early for that reason.
+func (ind *Indexer) Trace(ctx context.Context, start bc.Outpoint, forward bool, hops int) ([]TraceStep, bool, error) {
+	origin, err := ind.tracedTxs(ctx, `tx_hash = ANY($1)`, pq.StringArray{start.Hash.String()})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(origin) == 0 || int(start.Index) >= len(origin[0].tx.Outputs) {
+		return nil, false, errors.WithDetailf(pg.ErrUserInputNotFound, "output %s", start)
+	}
+
+	steps := []TraceStep{{Hop: 0, Transaction: origin[0].raw}}
+	seen := map[string]bool{origin[0].tx.ID: true}
+
+	// frontier holds outpoints (forward) or tx IDs (backward)
+	// to expand at the next hop.
+	var (
+		frontierOuts = []bc.Outpoint{start}
+		frontierTxs  []string
+	)
+	for _, in := range origin[0].tx.Inputs {
+		if in.SpentOutput != nil {
+			frontierTxs = append(frontierTxs, in.SpentOutput.TransactionID)
+		}
+	}
+
+	for hop := 1; hop <= hops; hop++ {
+		var found []tracedRow
+		if forward {
+			if len(frontierOuts) == 0 {
+				break
+			}
+			found, err = ind.spendingTxs(ctx, frontierOuts)
+		} else {
+			if len(frontierTxs) == 0 {
+				break
+			}
+			found, err = ind.tracedTxs(ctx, `tx_hash = ANY($1)`, pq.StringArray(frontierTxs))
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		frontierOuts, frontierTxs = nil, nil
+		for _, r := range found {
+			if seen[r.tx.ID] {
+				continue
+			}
+			if len(steps) == MaxTraceTxs {
+				return steps, true, nil
+			}
+			seen[r.tx.ID] = true
+			steps = append(steps, TraceStep{Hop: hop, Transaction: r.raw})
+
+			if forward {
+				var h bc.Hash
+				err = h.UnmarshalText([]byte(r.tx.ID))
+				if err != nil {
+					return nil, false, errors.Wrap(err, "decoding traced transaction id")
+				}
+				for _, out := range r.tx.Outputs {
+					frontierOuts = append(frontierOuts, bc.Outpoint{Hash: h, Index: out.Position})
+				}
+			} else {
+				for _, in := range r.tx.Inputs {
+					if in.SpentOutput != nil {
+						frontierTxs = append(frontierTxs, in.SpentOutput.TransactionID)
+					}
+				}
+			}
+		}
+	}
+	return steps, false, nil
+}
+
+type tracedRow struct {
+	tx  tracedTx
+	raw *json.RawMessage
+}
+
+// spendingTxs returns the transactions that spend any of outs.
+func (ind *Indexer) spendingTxs(ctx context.Context, outs []bc.Outpoint) ([]tracedRow, error) {
+	// Query in batches to stay well under the
+	// Postgres limit on bind parameters.
+	const batchSize = 500
+
+	var res []tracedRow
+	for len(outs) > 0 {
+		n := len(outs)
+		if n > batchSize {
+			n = batchSize
+		}
+		var (
+			buf  bytes.Buffer
+			vals []interface{}
+		)
+		for i, out := range outs[:n] {
+			if i > 0 {
+				buf.WriteString(" OR ")
+			}
+			// Containment queries can use the GIN index on data.
+			spent, err := json.Marshal(map[string]interface{}{
+				"inputs": []interface{}{map[string]interface{}{
+					"spent_output": map[string]interface{}{
+						"transaction_id": out.Hash.String(),
+						"position":       out.Index,
+					},
+				}},
+			})
+			if err != nil {
+				return nil, errors.Wrap(err)
+			}
+			vals = append(vals, string(spent))
+			fmt.Fprintf(&buf, "data @> $%d::jsonb", len(vals))
+		}
+		rows, err := ind.tracedTxs(ctx, buf.String(), vals...)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rows...)
+		outs = outs[n:]
+	}
+	return res, nil
+}
+
+func (ind *Indexer) tracedTxs(ctx context.Context, where string, vals ...interface{}) ([]tracedRow, error) {
+	q := "SELECT data FROM annotated_txs WHERE " + where + " ORDER BY block_height, tx_pos"
+	rows, err := ind.db.Query(ctx, q, vals...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying traced transactions")
+	}
+	defer rows.Close()
+
+	var res []tracedRow
+	for rows.Next() {
+		var data []byte
+		err = rows.Scan(&data)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning traced transaction")
+		}
+		var r tracedRow
+		err = json.Unmarshal(data, &r.tx)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding traced transaction")
+		}
+		r.raw = (*json.RawMessage)(&data)
+		res = append(res, r)
+	}
+	return res, errors.Wrap(rows.Err())
+}
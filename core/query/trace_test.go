@@ -0,0 +1,117 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"chain/database/pg"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func TestTrace(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewTx(t)
+	indexer := NewIndexer(db, &protocol.Chain{})
+
+	var (
+		assetID = bc.AssetID{1}
+		prog    = []byte{byte(vm.OP_TRUE)}
+	)
+	// tx0 has two outputs. tx1 spends the first and tx3 the
+	// second; tx2 spends tx1's output.
+	tx0 := bc.NewTx(bc.TxData{
+		Inputs: []*bc.TxInput{bc.NewSpendInput(bc.Hash{9}, 0, nil, assetID, 10, prog, nil)},
+		Outputs: []*bc.TxOutput{
+			bc.NewTxOutput(assetID, 6, prog, nil),
+			bc.NewTxOutput(assetID, 4, prog, nil),
+		},
+	})
+	tx1 := bc.NewTx(bc.TxData{
+		Inputs:  []*bc.TxInput{bc.NewSpendInput(tx0.Hash, 0, nil, assetID, 6, prog, nil)},
+		Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 6, prog, nil)},
+	})
+	tx2 := bc.NewTx(bc.TxData{
+		Inputs:  []*bc.TxInput{bc.NewSpendInput(tx1.Hash, 0, nil, assetID, 6, prog, nil)},
+		Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 6, prog, nil)},
+	})
+	tx3 := bc.NewTx(bc.TxData{
+		Inputs:  []*bc.TxInput{bc.NewSpendInput(tx0.Hash, 1, nil, assetID, 4, prog, nil)},
+		Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 4, prog, nil)},
+	})
+	blocks := []*bc.Block{{
+		BlockHeader:  bc.BlockHeader{Height: 2, TimestampMS: 1000},
+		Transactions: []*bc.Tx{tx0, tx1},
+	}, {
+		BlockHeader:  bc.BlockHeader{Height: 3, TimestampMS: 2000},
+		Transactions: []*bc.Tx{tx2, tx3},
+	}}
+	for _, b := range blocks {
+		err := indexer.IndexTransactions(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		start   bc.Outpoint
+		forward bool
+		hops    int
+		want    map[bc.Hash]int
+	}{{
+		start:   bc.Outpoint{Hash: tx0.Hash, Index: 0},
+		forward: true,
+		hops:    MaxTraceHops,
+		want:    map[bc.Hash]int{tx0.Hash: 0, tx1.Hash: 1, tx2.Hash: 2},
+	}, {
+		start:   bc.Outpoint{Hash: tx0.Hash, Index: 0},
+		forward: true,
+		hops:    1,
+		want:    map[bc.Hash]int{tx0.Hash: 0, tx1.Hash: 1},
+	}, {
+		start:   bc.Outpoint{Hash: tx0.Hash, Index: 1},
+		forward: true,
+		hops:    MaxTraceHops,
+		want:    map[bc.Hash]int{tx0.Hash: 0, tx3.Hash: 1},
+	}, {
+		// The spent output of tx0 is not indexed, so
+		// the backward walk ends there.
+		start:   bc.Outpoint{Hash: tx2.Hash, Index: 0},
+		forward: false,
+		hops:    MaxTraceHops,
+		want:    map[bc.Hash]int{tx2.Hash: 0, tx1.Hash: 1, tx0.Hash: 2},
+	}}
+	for i, c := range cases {
+		steps, truncated, err := indexer.Trace(ctx, c.start, c.forward, c.hops)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if truncated {
+			t.Errorf("case %d: trace truncated", i)
+		}
+		got := make(map[bc.Hash]int)
+		for _, s := range steps {
+			var tx struct {
+				ID bc.Hash `json:"id"`
+			}
+			err = json.Unmarshal(*s.Transaction, &tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got[tx.ID] = s.Hop
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("case %d: hops = %v, want %v", i, got, c.want)
+		}
+	}
+
+	_, _, err := indexer.Trace(ctx, bc.Outpoint{Hash: tx0.Hash, Index: 2}, true, 1)
+	if errors.Root(err) != pg.ErrUserInputNotFound {
+		t.Errorf("tracing a nonexistent output: err = %v, want %v", err, pg.ErrUserInputNotFound)
+	}
+}
@@ -0,0 +1,174 @@
+package query
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"chain/errors"
+)
+
+// UTXOStats describes the current unspent output set.
+type UTXOStats struct {
+	Outputs    uint64              `json:"outputs"`
+	Assets     []AssetUTXOStats    `json:"assets"`
+	Fragmented []FragmentedAccount `json:"most_fragmented"`
+	Growth     []UTXOGrowth        `json:"growth"`
+}
+
+// AssetUTXOStats describes the unspent outputs of one asset.
+// Sizes counts them by order of magnitude of their amounts.
+type AssetUTXOStats struct {
+	AssetID string       `json:"asset_id"`
+	Outputs uint64       `json:"outputs"`
+	Amount  uint64       `json:"amount"`
+	Sizes   []SizeBucket `json:"sizes"`
+}
+
+// SizeBucket counts outputs with an amount of at most MaxAmount
+// and more than the MaxAmount of the previous bucket.
+type SizeBucket struct {
+	MaxAmount uint64 `json:"max_amount"`
+	Outputs   uint64 `json:"outputs"`
+}
+
+// FragmentedAccount is an account's unspent outputs of one asset.
+// Accounts holding many small outputs are candidates for a sweep.
+type FragmentedAccount struct {
+	AccountID string `json:"account_id"`
+	AssetID   string `json:"asset_id"`
+	Outputs   uint64 `json:"outputs"`
+	Amount    uint64 `json:"amount"`
+}
+
+// UTXOGrowth is the change in the unspent output set on one
+// (UTC) day, and its size at the end of that day.
+type UTXOGrowth struct {
+	Day     string `json:"day"`
+	Created uint64 `json:"created"`
+	Spent   uint64 `json:"spent"`
+	Outputs uint64 `json:"outputs"`
+}
+
+const msPerDay = 24 * 60 * 60 * 1000
+
+// UTXOStats reports on the unspent outputs in the index.
+// It includes up to accounts of the most fragmented account
+// holdings, and the growth of the set over the last days
+// days on which outputs were created or spent. Growth omits
+// days that began before the retention period, since outputs
+// spent on those days may have been pruned.
+func (ind *Indexer) UTXOStats(ctx context.Context, accounts, days int) (*UTXOStats, error) {
+	stats := new(UTXOStats)
+
+	// Bucket b holds amounts below 10^b; zero amounts are in bucket 0.
+	const sizesQ = `
+		SELECT asset_id, bucket, COUNT(*), SUM(amount) FROM (
+			SELECT data->>'asset_id' AS asset_id, (data->>'amount')::bigint AS amount,
+				CASE WHEN (data->>'amount')::bigint = 0 THEN 0
+				ELSE FLOOR(LOG((data->>'amount')::numeric))::int + 1 END AS bucket
+			FROM annotated_outputs WHERE upper_inf(timespan)
+		) AS o
+		GROUP BY asset_id, bucket ORDER BY asset_id, bucket
+	`
+	rows, err := ind.db.Query(ctx, sizesQ)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying output sizes")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			assetID       string
+			bucket        int
+			count, amount uint64
+		)
+		err = rows.Scan(&assetID, &bucket, &count, &amount)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning output sizes")
+		}
+		if n := len(stats.Assets); n == 0 || stats.Assets[n-1].AssetID != assetID {
+			stats.Assets = append(stats.Assets, AssetUTXOStats{AssetID: assetID})
+		}
+		a := &stats.Assets[len(stats.Assets)-1]
+		a.Outputs += count
+		a.Amount += amount
+		a.Sizes = append(a.Sizes, SizeBucket{MaxAmount: bucketMax(bucket), Outputs: count})
+		stats.Outputs += count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	const fragQ = `
+		SELECT data->>'account_id', data->>'asset_id', COUNT(*), SUM((data->>'amount')::bigint)
+		FROM annotated_outputs
+		WHERE upper_inf(timespan) AND data ? 'account_id'
+		GROUP BY 1, 2 ORDER BY 3 DESC, 1, 2 LIMIT $1
+	`
+	rows, err = ind.db.Query(ctx, fragQ, accounts)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying account fragmentation")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f FragmentedAccount
+		err = rows.Scan(&f.AccountID, &f.AssetID, &f.Outputs, &f.Amount)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning account fragmentation")
+		}
+		stats.Fragmented = append(stats.Fragmented, f)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	const growthQ = `
+		SELECT day, SUM(created), SUM(spent) FROM (
+			SELECT LOWER(timespan) / $1 AS day, 1 AS created, 0 AS spent FROM annotated_outputs
+			UNION ALL
+			SELECT UPPER(timespan) / $1, 0, 1 FROM annotated_outputs WHERE NOT upper_inf(timespan)
+		) AS d
+		WHERE day >= $3
+		GROUP BY day ORDER BY day DESC LIMIT $2
+	`
+	// The first whole day after the retention horizon.
+	firstDay := (ind.retentionHorizon() + msPerDay - 1) / msPerDay
+	rows, err = ind.db.Query(ctx, growthQ, msPerDay, days, firstDay)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying output set growth")
+	}
+	defer rows.Close()
+
+	// Days come newest first, so the set size at the end of
+	// each day is found by undoing the later days' changes.
+	size := stats.Outputs
+	for rows.Next() {
+		var (
+			day int64
+			g   UTXOGrowth
+		)
+		err = rows.Scan(&day, &g.Created, &g.Spent)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning output set growth")
+		}
+		g.Day = time.Unix(day*msPerDay/1000, 0).UTC().Format("2006-01-02")
+		g.Outputs = size
+		size = size + g.Spent - g.Created
+		stats.Growth = append(stats.Growth, g)
+	}
+	return stats, errors.Wrap(rows.Err())
+}
+
+func bucketMax(b int) uint64 {
+	if b == 0 {
+		return 0
+	}
+	if b >= 19 {
+		return math.MaxInt64
+	}
+	max := uint64(1)
+	for i := 0; i < b; i++ {
+		max *= 10
+	}
+	return max - 1
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol"
+)
+
+func TestBucketMax(t *testing.T) {
+	cases := []struct {
+		bucket int
+		want   uint64
+	}{
+		{0, 0},
+		{1, 9},
+		{2, 99},
+		{3, 999},
+		{18, 999999999999999999},
+		{19, math.MaxInt64},
+	}
+	for _, c := range cases {
+		if got := bucketMax(c.bucket); got != c.want {
+			t.Errorf("bucketMax(%d) = %d, want %d", c.bucket, got, c.want)
+		}
+	}
+}
+
+func TestUTXOStats(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewTx(t)
+	ind := NewIndexer(db, &protocol.Chain{})
+
+	const day0 = 17000 // days since the epoch
+	var (
+		unspent = sql.NullInt64{}
+		on      = func(day int64) int64 { return (day0 + day) * msPerDay }
+		spentOn = func(day int64) sql.NullInt64 { return sql.NullInt64{Int64: on(day), Valid: true} }
+	)
+	outs := []struct {
+		data    string
+		created int64
+		spent   sql.NullInt64
+	}{
+		{`{"asset_id": "a", "amount": 0, "account_id": "acc1"}`, on(0), unspent},
+		{`{"asset_id": "a", "amount": 5, "account_id": "acc1"}`, on(1), unspent},
+		{`{"asset_id": "a", "amount": 9, "account_id": "acc1"}`, on(1), unspent},
+		{`{"asset_id": "a", "amount": 10, "account_id": "acc2"}`, on(2), unspent},
+		{`{"asset_id": "a", "amount": 999}`, on(2), unspent},
+		{`{"asset_id": "b", "amount": 7, "account_id": "acc2"}`, on(2), unspent},
+		{`{"asset_id": "a", "amount": 50, "account_id": "acc1"}`, on(0), spentOn(1)},
+		{`{"asset_id": "b", "amount": 3, "account_id": "acc2"}`, on(1), spentOn(2)},
+	}
+	for i, out := range outs {
+		pgtest.Exec(ctx, db, t, `
+			INSERT INTO annotated_outputs (block_height, tx_pos, output_index, tx_hash, data, timespan)
+			VALUES ($1, 0, 0, '', $2, int8range($3, $4))
+		`, i+1, out.data, out.created, out.spent)
+	}
+
+	stats, err := ind.UTXOStats(ctx, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Outputs != 6 {
+		t.Errorf("stats.Outputs = %d, want 6", stats.Outputs)
+	}
+	wantAssets := []AssetUTXOStats{{
+		AssetID: "a",
+		Outputs: 5,
+		Amount:  1023,
+		Sizes:   []SizeBucket{{0, 1}, {9, 2}, {99, 1}, {999, 1}},
+	}, {
+		AssetID: "b",
+		Outputs: 1,
+		Amount:  7,
+		Sizes:   []SizeBucket{{9, 1}},
+	}}
+	if !reflect.DeepEqual(stats.Assets, wantAssets) {
+		t.Errorf("stats.Assets = %+v, want %+v", stats.Assets, wantAssets)
+	}
+
+	// Most outputs first, then by account and asset.
+	wantFrag := []FragmentedAccount{
+		{AccountID: "acc1", AssetID: "a", Outputs: 3, Amount: 14},
+		{AccountID: "acc2", AssetID: "a", Outputs: 1, Amount: 10},
+	}
+	if !reflect.DeepEqual(stats.Fragmented, wantFrag) {
+		t.Errorf("stats.Fragmented = %+v, want %+v", stats.Fragmented, wantFrag)
+	}
+
+	// Newest day first. Each day ends with the next day's
+	// size, less what the next day created, plus what it spent.
+	wantGrowth := []UTXOGrowth{
+		{Day: "2016-07-20", Created: 3, Spent: 1, Outputs: 6},
+		{Day: "2016-07-19", Created: 3, Spent: 1, Outputs: 4},
+	}
+	if !reflect.DeepEqual(stats.Growth, wantGrowth) {
+		t.Errorf("stats.Growth = %+v, want %+v", stats.Growth, wantGrowth)
+	}
+}
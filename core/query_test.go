@@ -32,7 +32,7 @@ func TestQueryWithClockSkew(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	p, err := h.listTransactions(ctx, requestQuery{})
+	p, err := h.ListTransactions(ctx, QueryRequest{})
 	if err != nil {
 		t.Fatal(err)
 	}
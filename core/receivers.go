@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// POST /create-account-receiver
+func (h *Handler) createAccountReceiver(ctx context.Context, ins []struct {
+	AccountID    string    `json:"account_id"`
+	AccountAlias string    `json:"account_alias"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Memo         string    `json:"memo"`
+}) []interface{} {
+	responses := make([]interface{}, len(ins))
+	var wg sync.WaitGroup
+	wg.Add(len(responses))
+
+	for i := range responses {
+		go func(i int) {
+			defer wg.Done()
+			accountID := ins[i].AccountID
+			if accountID == "" {
+				acc, err := h.Accounts.FindByAlias(ctx, ins[i].AccountAlias)
+				if err != nil {
+					logHTTPError(ctx, err)
+					responses[i], _ = errInfo(ctx, err)
+					return
+				}
+				accountID = acc.ID
+			}
+			err := checkAccountScope(ctx, accountID)
+			if err != nil {
+				logHTTPError(ctx, err)
+				responses[i], _ = errInfo(ctx, err)
+				return
+			}
+			r, err := h.Accounts.CreateReceiver(ctx, accountID, ins[i].ExpiresAt, ins[i].Memo)
+			if err != nil {
+				logHTTPError(ctx, err)
+				responses[i], _ = errInfo(ctx, err)
+				return
+			}
+			responses[i] = r
+		}(i)
+	}
+
+	wg.Wait()
+	return responses
+}
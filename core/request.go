@@ -2,9 +2,13 @@ package core
 
 import (
 	"context"
+	stdjson "encoding/json"
+	"strings"
 
+	"chain/core/txbuilder"
 	"chain/encoding/json"
 	"chain/errors"
+	"chain/net/http/httpjson"
 	"chain/protocol/bc"
 )
 
@@ -12,34 +16,225 @@ var (
 	errBadActionType = errors.New("bad action type")
 	errBadAlias      = errors.New("bad alias")
 	errBadAction     = errors.New("bad action object")
+	errBadEndToEndID = errors.New("end-to-end id conflicts with reference data")
+	errBadFee        = errors.New("invalid fee")
+	errBadMinTime    = errors.New("min time too far in the future")
 )
 
-type buildRequest struct {
+// maxLabels is the most labels a transaction may carry.
+const maxLabels = 20
+
+// BuildRequest is the request to build one transaction,
+// from actions and an optional base transaction.
+// See BuildTransaction.
+type BuildRequest struct {
 	Tx      *bc.TxData               `json:"base_transaction"`
 	Actions []map[string]interface{} `json:"actions"`
 	TTL     json.Duration            `json:"ttl"`
+
+	// ReferenceData, if set, is the transaction's reference data.
+	// It is a shorthand for a set_transaction_reference_data action.
+	ReferenceData map[string]interface{} `json:"reference_data"`
+
+	// EndToEndID is an optional caller-assigned reference, recorded
+	// in the transaction reference data under "end_to_end_id".
+	EndToEndID string `json:"end_to_end_id"`
+
+	// Fee, if set, adds an output paying the fee to the
+	// network's fee control program. See applyFee.
+	Fee *FeeRequest `json:"fee"`
+
+	// Labels are copied to the template. See txbuilder.Template.
+	Labels map[string]string `json:"labels"`
+
+	// MinTime, if set, is the earliest time the transaction can be
+	// confirmed. A transaction with a future min time can be
+	// submitted ahead of time and waits in the pool until then.
+	MinTime json.Millis `json:"min_time"`
+}
+
+// FeeRequest is the fee to pay in a BuildRequest.
+type FeeRequest struct {
+	AssetID    string `json:"asset_id"`
+	AssetAlias string `json:"asset_alias"`
+	Amount     uint64 `json:"amount"`
+
+	// AccountID or AccountAlias, if set, names the account
+	// that pays the fee. Otherwise the request's other actions
+	// must provide it.
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
 }
 
-func (h *Handler) filterAliases(ctx context.Context, br *buildRequest) error {
+// applyEndToEndID records br.EndToEndID in the transaction
+// reference data, either by merging it into an existing
+// set_transaction_reference_data action or by adding one.
+// A base transaction that already has reference data must
+// already carry the same end-to-end id, since its reference
+// data cannot be changed.
+func applyEndToEndID(br *BuildRequest) error {
+	id := br.EndToEndID
+	if id == "" {
+		return nil
+	}
 	for i, m := range br.Actions {
-		id, _ := m["assset_id"].(string)
-		alias, _ := m["asset_alias"].(string)
-		if id == "" && alias != "" {
-			asset, err := h.Assets.FindByAlias(ctx, alias)
-			if err != nil {
-				return errors.WithDetailf(err, "invalid asset alias %s on action %d", alias, i)
-			}
-			m["asset_id"] = asset.AssetID
+		if m["type"] != "set_transaction_reference_data" {
+			continue
+		}
+		rd, ok := m["reference_data"].(map[string]interface{})
+		if !ok && m["reference_data"] != nil {
+			return errors.WithDetailf(errBadEndToEndID, "action %d has reference data that is not an object", i)
+		}
+		if rd == nil {
+			rd = make(map[string]interface{})
+		}
+		if v, ok := rd["end_to_end_id"]; ok && v != id {
+			return errors.WithDetailf(errBadEndToEndID, "action %d has end_to_end_id %v", i, v)
+		}
+		rd["end_to_end_id"] = id
+		m["reference_data"] = rd
+		return nil
+	}
+
+	if br.Tx != nil && len(br.Tx.ReferenceData) > 0 {
+		var rd map[string]interface{}
+		err := stdjson.Unmarshal(br.Tx.ReferenceData, &rd)
+		if err != nil || rd["end_to_end_id"] != id {
+			return errors.WithDetail(errBadEndToEndID, "base transaction reference data does not contain end_to_end_id")
+		}
+		return nil
+	}
+
+	br.Actions = append(br.Actions, map[string]interface{}{
+		"type":           "set_transaction_reference_data",
+		"reference_data": map[string]interface{}{"end_to_end_id": id},
+	})
+	return nil
+}
+
+// applyReferenceData adds a set_transaction_reference_data
+// action for the request's reference data. The transaction
+// can have only one set of reference data, so the request
+// must not already set it by other means.
+func applyReferenceData(br *BuildRequest) error {
+	if br.ReferenceData == nil {
+		return nil
+	}
+	for i, m := range br.Actions {
+		if m["type"] == "set_transaction_reference_data" {
+			return errors.WithDetailf(txbuilder.ErrBadRefData, "action %d also sets transaction reference data", i)
+		}
+	}
+	if br.Tx != nil && len(br.Tx.ReferenceData) > 0 {
+		return errors.WithDetail(txbuilder.ErrBadRefData, "base transaction already has reference data")
+	}
+	br.Actions = append(br.Actions, map[string]interface{}{
+		"type":           "set_transaction_reference_data",
+		"reference_data": br.ReferenceData,
+	})
+	return nil
+}
+
+// checkLabels returns an error if labels has too
+// many entries or an empty key.
+func checkLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return errors.WithDetailf(httpjson.ErrBadRequest, "%d labels, at most %d allowed", len(labels), maxLabels)
+	}
+	for k := range labels {
+		if k == "" {
+			return errors.WithDetail(httpjson.ErrBadRequest, "empty label name")
 		}
+	}
+	return nil
+}
+
+// applyFee adds actions for br.Fee: a control_program action paying
+// the fee to program and, if the fee names an account, a
+// spend_account action funding it.
+func applyFee(br *BuildRequest, program []byte) error {
+	fee := br.Fee
+	if fee == nil {
+		return nil
+	}
+	if len(program) == 0 {
+		return errors.WithDetail(errBadFee, "this core has no fee control program configured")
+	}
+	if fee.Amount == 0 {
+		return errors.WithDetail(errBadFee, "fee amount must be positive")
+	}
+	if fee.AssetID == "" && fee.AssetAlias == "" {
+		return errors.WithDetail(errBadFee, "fee must have an asset_id or asset_alias")
+	}
 
-		id, _ = m["account_id"].(string)
-		alias, _ = m["account_alias"].(string)
-		if id == "" && alias != "" {
-			acc, err := h.Accounts.FindByAlias(ctx, alias)
+	br.Actions = append(br.Actions, map[string]interface{}{
+		"type":            "control_program",
+		"asset_id":        fee.AssetID,
+		"asset_alias":     fee.AssetAlias,
+		"amount":          fee.Amount,
+		"control_program": json.HexBytes(program),
+	})
+	if fee.AccountID != "" || fee.AccountAlias != "" {
+		br.Actions = append(br.Actions, map[string]interface{}{
+			"type":          "spend_account",
+			"asset_id":      fee.AssetID,
+			"asset_alias":   fee.AssetAlias,
+			"amount":        fee.Amount,
+			"account_id":    fee.AccountID,
+			"account_alias": fee.AccountAlias,
+		})
+	}
+	return nil
+}
+
+// filterAliases replaces the aliases in br's actions with IDs.
+// An alias can be given for any asset or account ID, at any depth,
+// as in an auction's payment_asset_alias for its payment_asset_id
+// or a destination_account_alias for a destination_account_id.
+func (h *Handler) filterAliases(ctx context.Context, br *BuildRequest) error {
+	for i, m := range br.Actions {
+		err := h.filterActionAliases(ctx, m, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterActionAliases sets each key ending in asset_id or
+// account_id in m, and in the objects within it, from the
+// matching key ending in asset_alias or account_alias, unless
+// the ID is already set. Errors name action number i.
+func (h *Handler) filterActionAliases(ctx context.Context, m map[string]interface{}, i int) error {
+	for k, v := range m {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			err := h.filterActionAliases(ctx, v, i)
 			if err != nil {
-				return errors.WithDetailf(err, "invalid account alias %s on action %d", alias, i)
+				return err
+			}
+		case string:
+			if v == "" || !strings.HasSuffix(k, "_alias") {
+				continue
+			}
+			idKey := strings.TrimSuffix(k, "alias") + "id"
+			if id, _ := m[idKey].(string); id != "" {
+				continue
+			}
+			switch {
+			case strings.HasSuffix(k, "asset_alias"):
+				asset, err := h.Assets.FindByAlias(ctx, v)
+				if err != nil {
+					return errors.WithDetailf(err, "invalid asset alias %s on action %d", v, i)
+				}
+				m[idKey] = asset.AssetID
+			case strings.HasSuffix(k, "account_alias"):
+				acc, err := h.Accounts.FindByAlias(ctx, v)
+				if err != nil {
+					return errors.WithDetailf(err, "invalid account alias %s on action %d", v, i)
+				}
+				m[idKey] = acc.ID
 			}
-			m["account_id"] = acc.ID
 		}
 	}
 	return nil
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"chain/core/generator"
 	chainjson "chain/encoding/json"
 	"chain/errors"
 	"chain/net/http/httpjson"
+	"chain/net/http/reqid"
 	"chain/protocol/bc"
 )
 
@@ -52,6 +54,26 @@ func (h *Handler) getSnapshotInfoRPC(ctx context.Context) (resp snapshotInfoResp
 	return resp, err
 }
 
+// admitTx adds a transaction submitted by another core to the
+// pending pool, after checking it against MinOutputAmounts and
+// the minimum fee.
+func (h *Handler) admitTx(ctx context.Context, tx *bc.Tx) error {
+	err := h.MinOutputAmounts.Check(&tx.TxData)
+	if err != nil {
+		return err
+	}
+	err = h.Fees.Check(&tx.TxData)
+	if err != nil {
+		return err
+	}
+	err = h.Chain.AddTx(ctx, tx)
+	if err != nil {
+		return err
+	}
+	generator.RecordSubmission(tx, reqid.CoreIDFromContext(ctx))
+	return nil
+}
+
 // getSnapshotRPC returns the raw protobuf snapshot at the provided height.
 // Non-generators can call this endpoint to get raw data
 // that they can use to populate their own snapshot table.
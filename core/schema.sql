@@ -236,10 +236,10 @@ $$;
 
 
 --
--- Name: reserve_utxos(text, text, text, bigint, bigint, timestamp with time zone, text); Type: FUNCTION; Schema: public; Owner: -
+-- Name: reserve_utxos(text, text, text, bigint, bigint, timestamp with time zone, text, boolean, bigint); Type: FUNCTION; Schema: public; Owner: -
 --
 
-CREATE FUNCTION reserve_utxos(inp_asset_id text, inp_account_id text, inp_tx_hash text, inp_out_index bigint, inp_amt bigint, inp_expiry timestamp with time zone, inp_idempotency_key text) RETURNS record
+CREATE FUNCTION reserve_utxos(inp_asset_id text, inp_account_id text, inp_tx_hash text, inp_out_index bigint, inp_amt bigint, inp_expiry timestamp with time zone, inp_idempotency_key text, inp_confirmed_only boolean, inp_now bigint) RETURNS record
     LANGUAGE plpgsql
     AS $$
 DECLARE
@@ -263,6 +263,8 @@ BEGIN
                   AND (inp_tx_hash IS NULL OR inp_tx_hash = tx_hash)
                   AND (inp_out_index IS NULL OR inp_out_index = index)
                   AND reservation_id IS NULL
+                  AND (NOT inp_confirmed_only OR confirmed_in IS NOT NULL)
+                  AND (unlock_time IS NULL OR unlock_time <= inp_now)
             LIMIT 1
             FOR UPDATE
             SKIP LOCKED;
@@ -309,7 +311,8 @@ CREATE TABLE access_tokens (
     sort_id text DEFAULT next_chain_id('at'::text),
     type access_token_type NOT NULL,
     hashed_secret bytea NOT NULL,
-    created timestamp with time zone DEFAULT now() NOT NULL
+    created timestamp with time zone DEFAULT now() NOT NULL,
+    account_id text
 );
 
 
@@ -334,7 +337,9 @@ CREATE TABLE account_control_programs (
     signer_id text NOT NULL,
     key_index bigint NOT NULL,
     control_program bytea NOT NULL,
-    change boolean NOT NULL
+    change boolean NOT NULL,
+    unlock_time bigint,
+    used boolean DEFAULT false NOT NULL
 );
 
 
@@ -355,7 +360,9 @@ CREATE TABLE account_utxos (
     confirmed_in bigint,
     block_pos integer,
     block_timestamp bigint,
-    expiry_height bigint
+    expiry_height bigint,
+    pending_spend_expiry_height bigint,
+    unlock_time bigint
 );
 
 
@@ -366,7 +373,8 @@ CREATE TABLE account_utxos (
 CREATE TABLE accounts (
     account_id text NOT NULL,
     tags jsonb,
-    alias text
+    alias text,
+    archived_at timestamp with time zone
 );
 
 
@@ -417,6 +425,32 @@ CREATE TABLE annotated_txs (
 );
 
 
+--
+-- Name: asset_definition_schemas; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE asset_definition_schemas (
+    id text DEFAULT next_chain_id('ads'::text) NOT NULL,
+    alias text,
+    schema jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: asset_definitions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE asset_definitions (
+    asset_id text NOT NULL,
+    version integer NOT NULL,
+    definition jsonb,
+    tx_hash text NOT NULL,
+    block_height bigint NOT NULL,
+    block_time timestamp with time zone NOT NULL
+);
+
+
 --
 -- Name: asset_tags; Type: TABLE; Schema: public; Owner: -
 --
@@ -442,7 +476,14 @@ CREATE TABLE assets (
     signer_id text,
     definition jsonb,
     alias text,
-    first_block_height bigint
+    first_block_height bigint,
+    archived_at timestamp with time zone,
+    max_issuance bigint,
+    one_time_issuance boolean DEFAULT false NOT NULL,
+    issued_amount numeric DEFAULT 0 NOT NULL,
+    issued_height bigint DEFAULT 0 NOT NULL,
+    definition_schema_id text,
+    pending_issuance numeric DEFAULT 0 NOT NULL
 );
 
 
@@ -458,6 +499,31 @@ CREATE SEQUENCE assets_key_index_seq
     CACHE 1;
 
 
+--
+-- Name: blocklist; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE blocklist (
+    id text DEFAULT next_chain_id('blk'::text) NOT NULL,
+    control_program bytea,
+    tx_hash text,
+    output_index integer,
+    reason text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: blocklist_version; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE blocklist_version (
+    singleton boolean DEFAULT true NOT NULL,
+    version bigint NOT NULL,
+    CONSTRAINT blocklist_version_singleton CHECK (singleton)
+);
+
+
 --
 -- Name: blocks; Type: TABLE; Schema: public; Owner: -
 --
@@ -502,6 +568,34 @@ CREATE TABLE config (
 );
 
 
+--
+-- Name: forwarding_events; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE forwarding_events (
+    rule_id text NOT NULL,
+    asset_id text NOT NULL,
+    amount bigint NOT NULL,
+    kind text NOT NULL,
+    detail text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: forwarding_rules; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE forwarding_rules (
+    id text DEFAULT next_chain_id('fwd'::text) NOT NULL,
+    account_id text NOT NULL,
+    asset_id text NOT NULL,
+    destination_account_id text,
+    control_program bytea,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
 --
 -- Name: generator_pending_block; Type: TABLE; Schema: public; Owner: -
 --
@@ -513,6 +607,18 @@ CREATE TABLE generator_pending_block (
 );
 
 
+--
+-- Name: issuance_reservations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE issuance_reservations (
+    asset_id text NOT NULL,
+    nonce bytea NOT NULL,
+    amount numeric NOT NULL,
+    expiry timestamp with time zone NOT NULL
+);
+
+
 --
 -- Name: leader; Type: TABLE; Schema: public; Owner: -
 --
@@ -562,6 +668,48 @@ CREATE TABLE mockhsm (
 );
 
 
+--
+-- Name: output_tags_version; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE output_tags_version (
+    singleton boolean DEFAULT true NOT NULL,
+    version bigint NOT NULL,
+    CONSTRAINT output_tags_version_singleton CHECK (singleton)
+);
+
+
+--
+-- Name: payment_channel_states; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE payment_channel_states (
+    channel_id text NOT NULL,
+    sequence bigint NOT NULL,
+    balance_a bigint NOT NULL,
+    balance_b bigint NOT NULL,
+    signature_a bytea,
+    signature_b bytea,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: payment_channels; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE payment_channels (
+    id text NOT NULL,
+    side text NOT NULL,
+    xpub text NOT NULL,
+    capacity bigint NOT NULL,
+    peer_url text NOT NULL,
+    peer_access_token text NOT NULL,
+    terms jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
 --
 -- Name: pool_tx_sort_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
@@ -581,7 +729,8 @@ CREATE SEQUENCE pool_tx_sort_id_seq
 CREATE UNLOGGED TABLE pool_txs (
     tx_hash text NOT NULL,
     data bytea NOT NULL,
-    sort_id bigint DEFAULT nextval('pool_tx_sort_id_seq'::regclass) NOT NULL
+    sort_id bigint DEFAULT nextval('pool_tx_sort_id_seq'::regclass) NOT NULL,
+    min_time bigint DEFAULT 0 NOT NULL
 );
 
 
@@ -621,6 +770,23 @@ CREATE TABLE reservations (
 );
 
 
+--
+-- Name: retirements; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE retirements (
+    block_height bigint NOT NULL,
+    tx_pos integer NOT NULL,
+    output_index integer NOT NULL,
+    tx_hash text NOT NULL,
+    asset_id text NOT NULL,
+    amount bigint NOT NULL,
+    account_id text,
+    reference_data jsonb NOT NULL,
+    "timestamp" bigint NOT NULL
+);
+
+
 --
 -- Name: signed_blocks; Type: TABLE; Schema: public; Owner: -
 --
@@ -681,7 +847,45 @@ CREATE TABLE snapshots (
 CREATE TABLE submitted_txs (
     tx_id text NOT NULL,
     height bigint NOT NULL,
-    submitted_at timestamp without time zone DEFAULT now() NOT NULL
+    submitted_at timestamp without time zone DEFAULT now() NOT NULL,
+    client_token text,
+    max_time bigint
+);
+
+
+--
+-- Name: subscription_events; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE subscription_events (
+    subscription_id text NOT NULL,
+    period_start bigint NOT NULL,
+    kind text NOT NULL,
+    detail text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: subscriptions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE subscriptions (
+    id text NOT NULL,
+    payee_account_id text NOT NULL,
+    terms jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: transaction_labels; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE transaction_labels (
+    tx_hash text NOT NULL,
+    labels jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
 );
 
 
@@ -698,6 +902,58 @@ CREATE TABLE txfeeds (
 );
 
 
+--
+-- Name: wallet_tier_events; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE wallet_tier_events (
+    tier_id text NOT NULL,
+    kind text NOT NULL,
+    amount bigint NOT NULL,
+    detail text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: wallet_tier_templates_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE wallet_tier_templates_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: wallet_tier_templates; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE wallet_tier_templates (
+    seq bigint DEFAULT nextval('wallet_tier_templates_seq'::regclass) NOT NULL,
+    tier_id text NOT NULL,
+    template jsonb NOT NULL,
+    used_at timestamp with time zone
+);
+
+
+--
+-- Name: wallet_tiers; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE wallet_tiers (
+    id text DEFAULT next_chain_id('tier'::text) NOT NULL,
+    asset_id text NOT NULL,
+    hot_account_id text NOT NULL,
+    cold_account_id text NOT NULL,
+    high_water bigint NOT NULL,
+    low_water bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
 --
 -- Name: key_index; Type: DEFAULT; Schema: public; Owner: -
 --
@@ -769,6 +1025,30 @@ ALTER TABLE ONLY annotated_txs
     ADD CONSTRAINT annotated_txs_pkey PRIMARY KEY (block_height, tx_pos);
 
 
+--
+-- Name: asset_definition_schemas_alias_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY asset_definition_schemas
+    ADD CONSTRAINT asset_definition_schemas_alias_key UNIQUE (alias);
+
+
+--
+-- Name: asset_definition_schemas_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY asset_definition_schemas
+    ADD CONSTRAINT asset_definition_schemas_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: asset_definitions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY asset_definitions
+    ADD CONSTRAINT asset_definitions_pkey PRIMARY KEY (asset_id, version);
+
+
 --
 -- Name: asset_tags_asset_id_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -801,6 +1081,22 @@ ALTER TABLE ONLY assets
     ADD CONSTRAINT assets_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: blocklist_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY blocklist
+    ADD CONSTRAINT blocklist_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: blocklist_version_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY blocklist_version
+    ADD CONSTRAINT blocklist_version_pkey PRIMARY KEY (singleton);
+
+
 --
 -- Name: blocks_height_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -825,6 +1121,22 @@ ALTER TABLE ONLY config
     ADD CONSTRAINT config_pkey PRIMARY KEY (singleton);
 
 
+--
+-- Name: forwarding_rules_account_id_asset_id_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY forwarding_rules
+    ADD CONSTRAINT forwarding_rules_account_id_asset_id_key UNIQUE (account_id, asset_id);
+
+
+--
+-- Name: forwarding_rules_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY forwarding_rules
+    ADD CONSTRAINT forwarding_rules_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: generator_pending_block_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -833,6 +1145,14 @@ ALTER TABLE ONLY generator_pending_block
     ADD CONSTRAINT generator_pending_block_pkey PRIMARY KEY (singleton);
 
 
+--
+-- Name: issuance_reservations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY issuance_reservations
+    ADD CONSTRAINT issuance_reservations_pkey PRIMARY KEY (asset_id, nonce);
+
+
 --
 -- Name: leader_singleton_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -865,6 +1185,30 @@ ALTER TABLE ONLY mockhsm
     ADD CONSTRAINT mockhsm_pkey PRIMARY KEY (pub);
 
 
+--
+-- Name: output_tags_version_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY output_tags_version
+    ADD CONSTRAINT output_tags_version_pkey PRIMARY KEY (singleton);
+
+
+--
+-- Name: payment_channel_states_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY payment_channel_states
+    ADD CONSTRAINT payment_channel_states_pkey PRIMARY KEY (channel_id, sequence);
+
+
+--
+-- Name: payment_channels_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY payment_channels
+    ADD CONSTRAINT payment_channels_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: pool_txs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -905,6 +1249,14 @@ ALTER TABLE ONLY reservations
     ADD CONSTRAINT reservations_pkey PRIMARY KEY (reservation_id);
 
 
+--
+-- Name: retirements_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY retirements
+    ADD CONSTRAINT retirements_pkey PRIMARY KEY (block_height, tx_pos, output_index);
+
+
 --
 -- Name: signers_client_token_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -937,6 +1289,14 @@ ALTER TABLE ONLY snapshots
     ADD CONSTRAINT state_trees_pkey PRIMARY KEY (height);
 
 
+--
+-- Name: submitted_txs_client_token_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY submitted_txs
+    ADD CONSTRAINT submitted_txs_client_token_key UNIQUE (client_token);
+
+
 --
 -- Name: submitted_txs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -945,6 +1305,30 @@ ALTER TABLE ONLY submitted_txs
     ADD CONSTRAINT submitted_txs_pkey PRIMARY KEY (tx_id);
 
 
+--
+-- Name: subscription_events_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY subscription_events
+    ADD CONSTRAINT subscription_events_pkey PRIMARY KEY (subscription_id, period_start, kind);
+
+
+--
+-- Name: subscriptions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY subscriptions
+    ADD CONSTRAINT subscriptions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: transaction_labels_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY transaction_labels
+    ADD CONSTRAINT transaction_labels_pkey PRIMARY KEY (tx_hash);
+
+
 --
 -- Name: txfeeds_alias_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -969,6 +1353,30 @@ ALTER TABLE ONLY txfeeds
     ADD CONSTRAINT txfeeds_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: wallet_tier_templates_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY wallet_tier_templates
+    ADD CONSTRAINT wallet_tier_templates_pkey PRIMARY KEY (seq);
+
+
+--
+-- Name: wallet_tiers_hot_account_id_asset_id_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY wallet_tiers
+    ADD CONSTRAINT wallet_tiers_hot_account_id_asset_id_key UNIQUE (hot_account_id, asset_id);
+
+
+--
+-- Name: wallet_tiers_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY wallet_tiers
+    ADD CONSTRAINT wallet_tiers_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: account_control_programs_control_program_idx; Type: INDEX; Schema: public; Owner: -
 --
@@ -1039,6 +1447,13 @@ CREATE INDEX annotated_outputs_jsondata_idx ON annotated_outputs USING gin (data
 CREATE INDEX annotated_outputs_outpoint_idx ON annotated_outputs USING btree (tx_hash, output_index);
 
 
+--
+-- Name: annotated_outputs_spent_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX annotated_outputs_spent_idx ON annotated_outputs USING btree (upper(timespan)) WHERE (NOT upper_inf(timespan));
+
+
 --
 -- Name: annotated_outputs_timespan_idx; Type: INDEX; Schema: public; Owner: -
 --
@@ -1053,6 +1468,13 @@ CREATE INDEX annotated_outputs_timespan_idx ON annotated_outputs USING gist (tim
 CREATE INDEX annotated_txs_data ON annotated_txs USING gin (data);
 
 
+--
+-- Name: annotated_txs_tx_hash; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX annotated_txs_tx_hash ON annotated_txs USING btree (tx_hash);
+
+
 --
 -- Name: assets_sort_id; Type: INDEX; Schema: public; Owner: -
 --
@@ -1060,6 +1482,20 @@ CREATE INDEX annotated_txs_data ON annotated_txs USING gin (data);
 CREATE INDEX assets_sort_id ON assets USING btree (sort_id);
 
 
+--
+-- Name: forwarding_events_rule_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX forwarding_events_rule_id_idx ON forwarding_events USING btree (rule_id, asset_id, created_at);
+
+
+--
+-- Name: issuance_reservations_expiry_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX issuance_reservations_expiry_idx ON issuance_reservations USING btree (expiry);
+
+
 --
 -- Name: query_blocks_timestamp_idx; Type: INDEX; Schema: public; Owner: -
 --
@@ -1081,6 +1517,13 @@ CREATE INDEX reservations_asset_id_account_id_idx ON reservations USING btree (a
 CREATE INDEX reservations_expiry ON reservations USING btree (expiry);
 
 
+--
+-- Name: retirements_asset_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX retirements_asset_id_idx ON retirements USING btree (asset_id, block_height, tx_pos, output_index);
+
+
 --
 -- Name: signed_blocks_block_height_idx; Type: INDEX; Schema: public; Owner: -
 --
@@ -1095,6 +1538,20 @@ CREATE UNIQUE INDEX signed_blocks_block_height_idx ON signed_blocks USING btree
 CREATE INDEX signers_type_id_idx ON signers USING btree (type, id);
 
 
+--
+-- Name: wallet_tier_events_tier_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX wallet_tier_events_tier_id_idx ON wallet_tier_events USING btree (tier_id, created_at);
+
+
+--
+-- Name: wallet_tier_templates_tier_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX wallet_tier_templates_tier_id_idx ON wallet_tier_templates USING btree (tier_id, seq) WHERE (used_at IS NULL);
+
+
 --
 -- Name: account_utxos_reservation_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -1109,3 +1566,27 @@ ALTER TABLE ONLY account_utxos
 
 insert into migrations (filename, hash) values ('2016-10-17.0.core.schema-snapshot.sql', 'cff5210e2d6af410719c223a76443f73c5c12fe875f0efecb9a0a5937cf029cd');
 insert into migrations (filename, hash) values ('2016-10-19.0.core.add-core-id.sql', '9353da072a571d7a633140f2a44b6ac73ffe9e27223f7c653ccdef8df3e8139e');
+insert into migrations (filename, hash) values ('2016-10-20.0.core.add-access-token-account.sql', 'b567c75fd25af14b442a9afd8039c4e1ca72d17d12a2dc1576f0172fda2cce06');
+insert into migrations (filename, hash) values ('2016-10-21.0.core.add-submitted-tx-client-token.sql', '866d8edbc19dd3661ebe68b319435dcf8c9b6be1c75efaab6303a0deecedcfa8');
+insert into migrations (filename, hash) values ('2016-10-22.0.core.add-account-utxo-pending-spend.sql', 'd5add4f26c8909ad6979861566a05b5d1bad948730edd7d2197a61fad3e6e47a');
+insert into migrations (filename, hash) values ('2016-10-23.0.core.create-subscriptions.sql', '2cf3665d53e946c69de44abe4a30d6ff9deb382b244b871fa3bf5b8edd2be046');
+insert into migrations (filename, hash) values ('2016-10-24.0.core.create-payment-channels.sql', 'f21d4a14f9a011cb4ae81abc2f12dab72effe03662413c09073dcca8402b8d73');
+insert into migrations (filename, hash) values ('2016-10-25.0.core.add-archived-at.sql', '72d69a26aa659e006a885e186a3e710c4a8362b9a28ca382d2cd9c3c9bfbcfb1');
+insert into migrations (filename, hash) values ('2016-10-26.0.core.reserve-confirmed-only.sql', '621553d468b1b1e60bc0e9dfac0007ef8de7e56701a076cce32e925e984080a5');
+insert into migrations (filename, hash) values ('2016-10-27.0.core.add-timelocked-control-programs.sql', '2bfbd06ff84715a6cfaf89ff3707a52a1d73a38201b82622a53ffdef97d17756');
+insert into migrations (filename, hash) values ('2016-10-28.0.core.create-retirements.sql', '55aa0585c2ee816034bb915a06a3fa76e0dd7dd76993c2a7e9ac58e84b953a55');
+insert into migrations (filename, hash) values ('2016-10-29.0.core.create-transaction-labels.sql', 'dd491b2980c90b62f993d0818a34eb19dd2f9b818a345fea126c85ffbf34f94b');
+insert into migrations (filename, hash) values ('2016-10-30.0.core.create-forwarding-rules.sql', 'c417be81e369d88dd10ad71d9cfbfdbb7c0d74179d4df9d1cab9ca4953874036');
+insert into migrations (filename, hash) values ('2016-10-31.0.core.add-asset-issuance-policies.sql', 'ae14864f0f0b84e8097adca0323bbf3676845c017decd757f44167405f9b5975');
+insert into migrations (filename, hash) values ('2016-10-31.1.core.create-asset-definitions.sql', '7e4ad84d7d2fcf1ab7500778b94ef1cac6076864cdfd0e42ae7bef6b4c94bfca');
+insert into migrations (filename, hash) values ('2016-10-31.2.core.create-wallet-tiers.sql', '77c9739445c66664c7b2aaeecb6af06fe2a15fd4b87e34d650b88845e6a7ef1a');
+insert into migrations (filename, hash) values ('2016-10-31.3.core.create-blocklist.sql', 'aac3dce85d5f8419a0acded6244b551fcf03663090d4b5db1e2942d471361948');
+insert into migrations (filename, hash) values ('2016-10-31.4.core.create-asset-definition-schemas.sql', 'd07b9b89ee071f9235929114350a71848bff2f8dc48e2fb68d34f1aa850e45ee');
+insert into migrations (filename, hash) values ('2016-10-31.5.core.add-account-control-program-used.sql', '7a4ef0be929fb481637ccfc2e08dc8a7edc33bc25e7d396f854bad96e11093ea');
+insert into migrations (filename, hash) values ('2016-10-31.6.core.add-submitted-tx-max-time.sql', 'bc5934bf3c88cf0930f4c1bdb5258c20577a71995208996462f34ca04dea9309');
+insert into migrations (filename, hash) values ('2016-10-31.7.core.add-pool-tx-min-time.sql', 'ea9f8d2dca32bf0a64aa5c0e4ce13c8e8234dbfb80e127369bd48c5d6dc8c813');
+insert into migrations (filename, hash) values ('2016-10-31.8.core.create-issuance-reservations.sql', 'cc246ff08cf538773b6e21cd770fbdb2ff3ea006b31d7fe475766acf9f6b1fb0');
+insert into migrations (filename, hash) values ('2016-10-31.9.core.create-output-tags-version.sql', '9e8bc77987da6e10c37b623cbf82913b32bc98a48ddc59d7e0c4443ace45d658');
+insert into migrations (filename, hash) values ('2016-11-01.0.query.index-annotated-txs-tx-hash.sql', '024af9e28442d2d85147f5d80c46f297940b5a8409c4cce144368c04267e6737');
+insert into migrations (filename, hash) values ('2016-11-01.1.core.backfill-account-control-program-used.sql', '7604e07d003de8c8152740cae82d6a6d21f0190af9e8bc3a9efc24d0581965e2');
+insert into migrations (filename, hash) values ('2016-11-01.2.query.index-annotated-outputs-spent.sql', 'be3f7f108a9556f4971cfb8e580cacc7f90d75073aaab326416a8ee6c7aa020d');
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"chain/core/query/filter"
+	"chain/errors"
+)
+
+// errAccountScope is returned when a request made with an
+// account-scoped access token reaches outside its account.
+var errAccountScope = errors.New("access token is limited to one account")
+
+type scopeKey int
+
+const accountScopeKey scopeKey = 0
+
+func withAccountScope(ctx context.Context, accountID string) context.Context {
+	return context.WithValue(ctx, accountScopeKey, accountID)
+}
+
+// accountScope returns the ID of the account the request's
+// access token is limited to, or "" if it is not limited.
+func accountScope(ctx context.Context) string {
+	id, _ := ctx.Value(accountScopeKey).(string)
+	return id
+}
+
+// scopedEndpoints are the endpoints open to account-scoped
+// tokens. Each one applies the scope itself.
+var scopedEndpoints = map[string]bool{
+	"/list-transactions":                  true,
+	"/list-transactions-by-end-to-end-id": true,
+	"/list-balances":                      true,
+	"/get-account-balance":                true,
+	"/get-account-activity":               true,
+	"/list-unspent-outputs":               true,
+	"/create-control-program":             true,
+	"/create-account-receiver":            true,
+	"/list-htlcs":                         true,
+	"/build-transaction":                  true,
+	"/build-transfer":                     true,
+}
+
+// scopeActions are the build actions open to account-scoped tokens.
+// Spends must come from the token's account.
+var scopeActions = map[string]bool{
+	"spend_account":                  true,
+	"spend_account_unspent_output":   true,
+	"control_account":                true,
+	"control_account_timelocked":     true,
+	"control_program":                true,
+	"control_receiver":               true,
+	"retire":                         true,
+	"set_transaction_reference_data": true,
+}
+
+// accountScoped rejects requests from account-scoped tokens
+// to endpoints not in scopedEndpoints.
+func accountScoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if id := accountScope(req.Context()); id != "" && !scopedEndpoints[req.URL.Path] {
+			err := errors.WithDetailf(errAccountScope, "endpoint %s is not available to account-scoped tokens", req.URL.Path)
+			WriteHTTPError(req.Context(), w, err)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// scopeFilter parses a query filter and narrows it to the account
+// in ctx, if any. The account condition is built by cond from the
+// index of the placeholder for the account ID. The client's filter
+// is parsed on its own and combined with the condition as a parsed
+// predicate, so no filter text can escape the condition.
+func scopeFilter(ctx context.Context, filt string, params []interface{}, cond func(n int) string) (filter.Predicate, []interface{}, error) {
+	p, err := filter.Parse(filt)
+	if err != nil {
+		return p, nil, err
+	}
+	id := accountScope(ctx)
+	if id == "" {
+		return p, params, nil
+	}
+	n := len(params) + 1
+	if p.Parameters >= n {
+		return p, nil, errors.WithDetailf(filter.ErrBadFilter, "filter uses $%d but only %d params were given", p.Parameters, len(params))
+	}
+	scope, err := filter.Parse(cond(n))
+	if err != nil {
+		return p, nil, errors.Wrap(err, "parsing account scope")
+	}
+	return filter.And(p, scope), append(params[:len(params):len(params)], id), nil
+}
+
+func txAccountCond(n int) string {
+	return fmt.Sprintf("inputs(account_id=$%d) OR outputs(account_id=$%d)", n, n)
+}
+
+func outputAccountCond(n int) string {
+	return fmt.Sprintf("account_id=$%d", n)
+}
+
+// checkAccountScope verifies that accountID is the account
+// the request's token is limited to, if any.
+func checkAccountScope(ctx context.Context, accountID string) error {
+	if id := accountScope(ctx); id != "" && id != accountID {
+		return errors.WithDetailf(errAccountScope, "account %s is outside the token's scope", accountID)
+	}
+	return nil
+}
+
+// checkActionScope verifies that the actions in br, after alias
+// filtering, stay within the request's account scope, if any.
+func checkActionScope(ctx context.Context, br *BuildRequest) error {
+	if accountScope(ctx) == "" {
+		return nil
+	}
+	for i, m := range br.Actions {
+		typ, _ := m["type"].(string)
+		if !scopeActions[typ] {
+			return errors.WithDetailf(errAccountScope, "action type %q on action %d is not available to account-scoped tokens", typ, i)
+		}
+		if typ == "spend_account" || typ == "spend_account_unspent_output" {
+			id, _ := m["account_id"].(string)
+			err := checkAccountScope(ctx, id)
+			if err != nil {
+				return errors.WithDetailf(err, "on action %d", i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"chain/core/query/filter"
+	"chain/errors"
+)
+
+func TestScopeFilter(t *testing.T) {
+	ctx := context.Background()
+	p, params, err := scopeFilter(ctx, "asset_alias=$1", []interface{}{"gold"}, outputAccountCond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "asset_alias = $1" || !reflect.DeepEqual(params, []interface{}{"gold"}) {
+		t.Errorf("unscoped: got %q %v", p, params)
+	}
+
+	ctx = withAccountScope(ctx, "acc1")
+	cases := []struct {
+		filter     string
+		params     []interface{}
+		cond       func(int) string
+		want       string
+		wantParams []interface{}
+	}{{
+		filter:     "asset_alias=$1",
+		params:     []interface{}{"gold"},
+		cond:       outputAccountCond,
+		want:       "(asset_alias = $1) AND (account_id = $2)",
+		wantParams: []interface{}{"gold", "acc1"},
+	}, {
+		filter:     "",
+		cond:       txAccountCond,
+		want:       "inputs(account_id = $1) OR outputs(account_id = $1)",
+		wantParams: []interface{}{"acc1"},
+	}, {
+		// The client's filter cannot regroup the account condition.
+		filter:     "asset_alias=$1) OR (asset_alias=$1",
+		params:     []interface{}{"gold"},
+		cond:       outputAccountCond,
+		wantParams: nil,
+	}}
+	for i, c := range cases {
+		p, params, err := scopeFilter(ctx, c.filter, c.params, c.cond)
+		if c.want == "" {
+			if errors.Root(err) != filter.ErrBadFilter {
+				t.Errorf("case %d: err = %v want %v", i, err, filter.ErrBadFilter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+			continue
+		}
+		if p.String() != c.want {
+			t.Errorf("case %d: filter = %q want %q", i, p, c.want)
+		}
+		if !reflect.DeepEqual(params, c.wantParams) {
+			t.Errorf("case %d: params = %v want %v", i, params, c.wantParams)
+		}
+	}
+
+	// A filter may not refer to the account ID's placeholder.
+	_, _, err = scopeFilter(ctx, "account_id=$2", []interface{}{"gold"}, outputAccountCond)
+	if errors.Root(err) != filter.ErrBadFilter {
+		t.Errorf("filter using the scope's placeholder: err = %v want %v", err, filter.ErrBadFilter)
+	}
+}
+
+func TestCheckActionScope(t *testing.T) {
+	ctx := withAccountScope(context.Background(), "acc1")
+	cases := []struct {
+		actions []map[string]interface{}
+		ok      bool
+	}{
+		{[]map[string]interface{}{
+			{"type": "spend_account", "account_id": "acc1"},
+			{"type": "control_program"},
+		}, true},
+		{[]map[string]interface{}{
+			{"type": "spend_account", "account_id": "acc2"},
+		}, false},
+		{[]map[string]interface{}{
+			{"type": "spend_account_unspent_output", "account_id": "acc1"},
+		}, true},
+		{[]map[string]interface{}{
+			{"type": "spend_account_unspent_output"},
+		}, false},
+		{[]map[string]interface{}{
+			{"type": "issue"},
+		}, false},
+	}
+	for i, c := range cases {
+		err := checkActionScope(ctx, &BuildRequest{Actions: c.actions})
+		if c.ok && err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+		}
+		if !c.ok && errors.Root(err) != errAccountScope {
+			t.Errorf("case %d: err = %v want %v", i, err, errAccountScope)
+		}
+	}
+}
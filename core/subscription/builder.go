@@ -0,0 +1,176 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+)
+
+func (m *Manager) DecodePullAction(data []byte) (txbuilder.Action, error) {
+	a := &pullAction{subscriptions: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// pullAction pays Amount from Subscription's contract to the
+// payee, relocking the remainder for the next period. It must
+// be the first action of its transaction, and the payee program
+// must belong to an account in this core.
+type pullAction struct {
+	subscriptions *Manager
+	Subscription  Subscription `json:"subscription"`
+	Amount        uint64       `json:"amount"`
+}
+
+func (a *pullAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	s := &a.Subscription
+	err := s.Validate()
+	if err != nil {
+		return nil, err
+	}
+	c, err := a.subscriptions.contract(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if a.Amount == 0 || a.Amount > s.Cap || a.Amount > c.Amount {
+		return nil, errors.WithDetailf(txbuilder.ErrBadAmount, "pull amount must be positive and at most %d", min(s.Cap, c.Amount))
+	}
+	if bc.Millis(time.Now()) < c.PeriodStart {
+		return nil, errors.WithDetailf(ErrPeriod, "the next pull is at %d", c.PeriodStart)
+	}
+
+	res, err := a.subscriptions.spendContract(ctx, s, c, s.Payee, clausePull, a.Amount)
+	if err != nil {
+		return nil, errors.WithDetail(err, "payee program is not controlled by this core")
+	}
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(s.AssetID, a.Amount, s.Payee, nil))
+	if rem := c.Amount - a.Amount; rem > 0 {
+		ref, err := refData(s)
+		if err != nil {
+			return nil, err
+		}
+		res.Outputs = append(res.Outputs, bc.NewTxOutput(s.AssetID, rem, s.Program(c.PeriodStart+s.Period), ref))
+	}
+	res.MinTimeMS = c.PeriodStart
+	return res, nil
+}
+
+func (m *Manager) DecodeTopUpAction(data []byte) (txbuilder.Action, error) {
+	a := &topUpAction{subscriptions: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// topUpAction adds Amount to Subscription's contract, or funds
+// the contract if it has none. The added amount must come from
+// other actions, such as spend_account. Unless it funds a new
+// contract, it must be the first action of its transaction, and
+// the payer program must belong to an account in this core.
+type topUpAction struct {
+	subscriptions *Manager
+	Subscription  Subscription `json:"subscription"`
+	Amount        uint64       `json:"amount"`
+}
+
+func (a *topUpAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	s := &a.Subscription
+	err := s.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if a.Amount == 0 {
+		return nil, errors.WithDetail(txbuilder.ErrBadAmount, "top-up amount must be positive")
+	}
+	ref, err := refData(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := a.subscriptions.contract(ctx, s)
+	if errors.Root(err) == ErrNotFunded {
+		out := bc.NewTxOutput(s.AssetID, a.Amount, s.Program(s.Start), ref)
+		return &txbuilder.BuildResult{Outputs: []*bc.TxOutput{out}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	total := c.Amount + a.Amount
+	res, err := a.subscriptions.spendContract(ctx, s, c, s.Payer, clauseTopUp, total)
+	if err != nil {
+		return nil, errors.WithDetail(err, "payer program is not controlled by this core")
+	}
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(s.AssetID, total, c.ControlProgram, ref))
+	return res, nil
+}
+
+func (m *Manager) DecodeCancelAction(data []byte) (txbuilder.Action, error) {
+	a := &cancelAction{subscriptions: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// cancelAction returns the whole of Subscription's contract to
+// the payer. The payer program must belong to an account in
+// this core.
+type cancelAction struct {
+	subscriptions *Manager
+	Subscription  Subscription `json:"subscription"`
+}
+
+func (a *cancelAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	s := &a.Subscription
+	err := s.Validate()
+	if err != nil {
+		return nil, err
+	}
+	c, err := a.subscriptions.contract(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	res, err := a.subscriptions.spendContract(ctx, s, c, s.Payer, clauseCancel)
+	if err != nil {
+		return nil, errors.WithDetail(err, "payer program is not controlled by this core")
+	}
+	res.Outputs = append(res.Outputs, bc.NewTxOutput(s.AssetID, c.Amount, s.Payer, nil))
+	return res, nil
+}
+
+// spendContract returns a build result spending c through
+// clause, signed for by signer's account, with args as the
+// clause's arguments.
+func (m *Manager) spendContract(ctx context.Context, s *Subscription, c *Contract, signer []byte, clause int64, args ...uint64) (*txbuilder.BuildResult, error) {
+	amt := bc.AssetAmount{AssetID: s.AssetID, Amount: c.Amount}
+	sigInst, err := m.accounts.SigningInstruction(ctx, signer, amt)
+	if err != nil {
+		return nil, err
+	}
+
+	// The contract finds its arguments at the bottom of
+	// the stack, below those of the signer's program.
+	sig := sigInst.WitnessComponents
+	sigInst.WitnessComponents = nil
+	sigInst.AddDataWitness(vm.Int64Bytes(clause))
+	for _, arg := range args {
+		sigInst.AddDataWitness(vm.Int64Bytes(int64(arg)))
+	}
+	sigInst.WitnessComponents = append(sigInst.WitnessComponents, sig...)
+
+	in := bc.NewSpendInput(c.TransactionID, c.Position, nil, s.AssetID, c.Amount, c.ControlProgram, nil)
+	return &txbuilder.BuildResult{
+		Inputs:              []*bc.TxInput{in},
+		SigningInstructions: []*txbuilder.SigningInstruction{sigInst},
+	}, nil
+}
+
+func min(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
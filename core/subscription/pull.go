@@ -0,0 +1,208 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+)
+
+// Kinds of Event. The last three are dunning events: the pull
+// for the period did not happen, and is retried until it does.
+const (
+	EventPulled            = "pulled"
+	EventInsufficientFunds = "insufficient_funds"
+	EventUnfunded          = "unfunded"
+	EventFailed            = "failed"
+)
+
+// Event records an attempt to collect a subscription payment.
+// There is at most one event of each kind per period.
+type Event struct {
+	PeriodStart uint64    `json:"period_start"`
+	Kind        string    `json:"kind"`
+	Detail      string    `json:"detail"`
+	Time        time.Time `json:"time"`
+}
+
+// Record is a subscription whose payments this core
+// collects for the payee account.
+type Record struct {
+	ID             string        `json:"id"`
+	PayeeAccountID string        `json:"payee_account_id"`
+	Subscription   *Subscription `json:"subscription"`
+}
+
+// Create stores s, so that its payments are collected
+// for the account accountID, which controls s's payee program.
+// Storing the same subscription again has no effect.
+func (m *Manager) Create(ctx context.Context, accountID string, s *Subscription) (*Record, error) {
+	err := s.Validate()
+	if err != nil {
+		return nil, err
+	}
+	terms, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	r := &Record{ID: s.ID().String(), PayeeAccountID: accountID, Subscription: s}
+
+	const q = `
+		INSERT INTO subscriptions (id, payee_account_id, terms) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err = m.db.Exec(ctx, q, r.ID, r.PayeeAccountID, terms)
+	return r, errors.Wrap(err, "inserting subscription")
+}
+
+// List returns the stored subscriptions with IDs after after,
+// in ID order, at most limit of them.
+func (m *Manager) List(ctx context.Context, after string, limit int) ([]*Record, error) {
+	const q = `
+		SELECT id, payee_account_id, terms FROM subscriptions
+		WHERE id > $1 ORDER BY id LIMIT $2
+	`
+	rows, err := m.db.Query(ctx, q, after, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying subscriptions")
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		var (
+			r     Record
+			terms []byte
+		)
+		err = rows.Scan(&r.ID, &r.PayeeAccountID, &terms)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning subscription")
+		}
+		r.Subscription = new(Subscription)
+		err = json.Unmarshal(terms, r.Subscription)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding subscription terms")
+		}
+		records = append(records, &r)
+	}
+	return records, errors.Wrap(rows.Err())
+}
+
+func (m *Manager) events(ctx context.Context, id string) ([]*Event, error) {
+	const q = `
+		SELECT period_start, kind, detail, created_at FROM subscription_events
+		WHERE subscription_id = $1 ORDER BY created_at
+	`
+	rows, err := m.db.Query(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying subscription events")
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		e := new(Event)
+		err = rows.Scan(&e.PeriodStart, &e.Kind, &e.Detail, &e.Time)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning subscription event")
+		}
+		e.Time = e.Time.UTC()
+		events = append(events, e)
+	}
+	return events, errors.Wrap(rows.Err())
+}
+
+func (m *Manager) recordEvent(ctx context.Context, id string, periodStart uint64, kind, detail string) error {
+	const q = `
+		INSERT INTO subscription_events (subscription_id, period_start, kind, detail)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id, period_start, kind) DO NOTHING
+	`
+	_, err := m.db.Exec(ctx, q, id, periodStart, kind, detail)
+	return errors.Wrap(err, "recording subscription event")
+}
+
+// PullDue pulls the due payment of each stored subscription, for
+// the full cap, and records an event for the outcome. It signs
+// the pull transactions with sign and submits them with submit.
+//
+// A subscription that falls behind, such as while this core is
+// down, catches up one period per call.
+func (m *Manager) PullDue(ctx context.Context, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) error {
+	const limit = 100
+	var after string
+	for {
+		records, err := m.List(ctx, after, limit)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			err = m.pull(ctx, r.Subscription, sign, submit)
+			if err != nil {
+				log.Error(ctx, err, "pulling subscription "+r.ID)
+			}
+		}
+		if len(records) < limit {
+			return nil
+		}
+		after = records[len(records)-1].ID
+	}
+}
+
+func (m *Manager) pull(ctx context.Context, s *Subscription, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) error {
+	id := s.ID().String()
+	now := bc.Millis(time.Now())
+	if now < s.Start {
+		return nil
+	}
+
+	c, err := m.contract(ctx, s)
+	if errors.Root(err) == ErrNotFunded {
+		// Without a contract there is no next pull to go by,
+		// so the period is the one that includes now, unless
+		// its payment was already pulled.
+		periodStart := s.Start + (now-s.Start)/s.Period*s.Period
+		var pulled bool
+		const q = `
+			SELECT EXISTS (SELECT 1 FROM subscription_events
+			WHERE subscription_id = $1 AND period_start = $2 AND kind = $3)
+		`
+		err = m.db.QueryRow(ctx, q, id, periodStart, EventPulled).Scan(&pulled)
+		if err != nil || pulled {
+			return errors.Wrap(err, "checking for pulled payment")
+		}
+		return m.recordEvent(ctx, id, periodStart, EventUnfunded, "no contract output")
+	}
+	if err != nil {
+		return err
+	}
+	if now < c.PeriodStart {
+		return nil
+	}
+	if c.Amount < s.Cap {
+		detail := fmt.Sprintf("contract holds %d of %d", c.Amount, s.Cap)
+		return m.recordEvent(ctx, id, c.PeriodStart, EventInsufficientFunds, detail)
+	}
+
+	a := &pullAction{subscriptions: m, Subscription: *s, Amount: s.Cap}
+	tpl, err := txbuilder.Build(ctx, nil, []txbuilder.Action{a}, time.Now().Add(5*time.Minute))
+	if err == nil {
+		// The client token makes a retried pull for the
+		// same period wait for the first one.
+		tpl.ClientToken = fmt.Sprintf("subscription:%s:%d", id, c.PeriodStart)
+		err = txbuilder.Sign(ctx, tpl, txbuilder.SigningXPubs(tpl), sign)
+	}
+	if err == nil {
+		err = submit(ctx, tpl)
+	}
+	if err != nil {
+		return m.recordEvent(ctx, id, c.PeriodStart, EventFailed, err.Error())
+	}
+	tx := bc.NewTx(*tpl.Transaction)
+	return m.recordEvent(ctx, id, c.PeriodStart, EventPulled, tx.Hash.String())
+}
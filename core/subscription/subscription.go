@@ -0,0 +1,304 @@
+// Package subscription implements recurring pull payments. A payer
+// locks funds in a contract output from which a payee may pull up
+// to a capped amount once per billing period. The payer can top up
+// the contract, or cancel it and reclaim what is left.
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"chain/core/account"
+	"chain/core/query"
+	"chain/core/query/filter"
+	"chain/crypto/sha3pool"
+	"chain/database/pg"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+var (
+	ErrBadSubscription = errors.New("invalid subscription")
+	ErrNotFunded       = errors.New("subscription has no funded contract")
+	ErrPeriod          = errors.New("billing period has not started")
+)
+
+// Clause selectors, the first witness argument to a contract.
+const (
+	clausePull   = 0
+	clauseTopUp  = 1
+	clauseCancel = 2
+)
+
+// maxTime bounds Start and Period, so that the start of
+// later periods stays well within the VM's number range.
+const maxTime = 1 << 62
+
+// Subscription describes the terms of a subscription. Like its
+// ID, they do not change over the subscription's life.
+type Subscription struct {
+	AssetID bc.AssetID `json:"asset_id"`
+
+	// Cap is the most the payee may pull in one period.
+	Cap uint64 `json:"cap"`
+
+	// Period is the length of a billing period in milliseconds,
+	// and Start is the millisecond Unix timestamp at which the
+	// first period begins. Each pull moves the start of the
+	// next pull one period later.
+	Period uint64 `json:"period"`
+	Start  uint64 `json:"start"`
+
+	// Payer can top up or cancel the contract, and Payee can
+	// pull from it. Both must be multisig control programs,
+	// such as ones created for accounts.
+	Payer chainjson.HexBytes `json:"payer_program"`
+	Payee chainjson.HexBytes `json:"payee_program"`
+}
+
+// Validate checks that s's terms are usable.
+func (s *Subscription) Validate() error {
+	if s.Cap == 0 || s.Cap >= math.MaxInt64 {
+		return errors.WithDetail(ErrBadSubscription, "cap must be positive and less than 2^63-1")
+	}
+	if s.Period == 0 || s.Period > maxTime {
+		return errors.WithDetail(ErrBadSubscription, "period must be positive and at most 2^62")
+	}
+	if s.Start > maxTime {
+		return errors.WithDetail(ErrBadSubscription, "start must be at most 2^62")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(s.Payer); err != nil {
+		return errors.WithDetail(ErrBadSubscription, "payer program must be a multisig program")
+	}
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(s.Payee); err != nil {
+		return errors.WithDetail(ErrBadSubscription, "payee program must be a multisig program")
+	}
+	return nil
+}
+
+// ID returns the hash that identifies s. Contract outputs
+// record it in their reference data.
+func (s *Subscription) ID() bc.Hash {
+	var buf [32]byte
+	binary.LittleEndian.PutUint64(buf[:8], s.Cap)
+	binary.LittleEndian.PutUint64(buf[8:16], s.Period)
+	binary.LittleEndian.PutUint64(buf[16:24], s.Start)
+	binary.LittleEndian.PutUint64(buf[24:], uint64(len(s.Payer)))
+
+	var h bc.Hash
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(s.AssetID[:])
+	sha.Write(buf[:])
+	sha.Write(s.Payer)
+	sha.Write(s.Payee)
+	sha.Read(h[:])
+	return h
+}
+
+// Program returns the control program for a contract output
+// of s whose current period starts at start.
+//
+// The program begins by pushing start as 8 bytes, so a pull can
+// build the program for the next period from the rest of its own
+// code. Its witness arguments are a clause selector, an amount for
+// a pull or top-up, and the arguments to the payee's or payer's
+// program. The clauses are:
+//
+//	pull:   once the period has started, the payee may pay itself
+//	        up to Cap in the output at the input's index, and must
+//	        relock the remainder, if any, in the next output for
+//	        the following period.
+//	top-up: the payer may relock a larger amount under the same
+//	        program in the output at the input's index.
+//	cancel: the payer may spend the contract freely.
+//
+// Tying outputs to the input's index keeps a single output
+// from satisfying the contracts of several inputs.
+func (s *Subscription) Program(start uint64) []byte {
+	var startBytes [8]byte
+	binary.LittleEndian.PutUint64(startBytes[:], start)
+
+	a := vmutil.NewAssembler()
+	a.AddData(startBytes[:])
+	a.AddOp(vm.OP_DEPTH).AddInt64(1).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the selector
+	a.AddOp(vm.OP_DUP).AddInt64(clauseTopUp).AddOp(vm.OP_NUMEQUAL)
+	a.Jump(vm.OP_JUMPIF, "topup")
+	a.AddOp(vm.OP_DUP).AddInt64(clauseCancel).AddOp(vm.OP_NUMEQUAL)
+	a.Jump(vm.OP_JUMPIF, "cancel")
+	a.AddInt64(clausePull).AddOp(vm.OP_NUMEQUALVERIFY)
+
+	// pull
+	a.AddOp(vm.OP_DUP).AddOp(vm.OP_MINTIME).AddOp(vm.OP_LESSTHANOREQUAL).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_DEPTH).AddInt64(2).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the amount
+	a.AddOp(vm.OP_DUP).AddInt64(1).AddInt64(int64(s.Cap) + 1).AddOp(vm.OP_WITHIN).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_DUP).AddOp(vm.OP_TOALTSTACK)
+	a.AddOp(vm.OP_INDEX).AddData(nil).AddOp(vm.OP_ROT)
+	a.AddData(s.AssetID[:]).AddInt64(1).AddData(s.Payee).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_AMOUNT).AddOp(vm.OP_FROMALTSTACK).AddOp(vm.OP_SUB) // the remainder
+	a.AddOp(vm.OP_DUP).AddInt64(0).AddOp(vm.OP_NUMEQUAL)
+	a.Jump(vm.OP_JUMPIF, "paid")
+	a.AddOp(vm.OP_SWAP).AddInt64(int64(s.Period)).AddOp(vm.OP_ADD)
+	a.AddData(make([]byte, 8)).AddOp(vm.OP_CAT).AddInt64(8).AddOp(vm.OP_LEFT)
+	a.AddData([]byte{byte(vm.OP_DATA_8)}).AddOp(vm.OP_SWAP).AddOp(vm.OP_CAT)
+	a.AddOp(vm.OP_PROGRAM).AddOp(vm.OP_SIZE).AddInt64(9).AddOp(vm.OP_SUB).AddOp(vm.OP_RIGHT).AddOp(vm.OP_CAT)
+	a.AddOp(vm.OP_TOALTSTACK)
+	a.AddOp(vm.OP_INDEX).AddOp(vm.OP_1ADD).AddData(nil).AddOp(vm.OP_ROT)
+	a.AddData(s.AssetID[:]).AddInt64(1).AddOp(vm.OP_FROMALTSTACK).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	a.Jump(vm.OP_JUMP, "payee")
+	a.Label("paid")
+	a.AddOp(vm.OP_DROP).AddOp(vm.OP_DROP)
+	a.Label("payee")
+	a.AddRawBytes(s.Payee)
+	a.Jump(vm.OP_JUMP, "end")
+
+	a.Label("topup")
+	a.AddOp(vm.OP_DROP).AddOp(vm.OP_DROP)
+	a.AddOp(vm.OP_DEPTH).AddInt64(2).AddOp(vm.OP_SUB).AddOp(vm.OP_PICK) // the amount
+	a.AddOp(vm.OP_DUP).AddOp(vm.OP_AMOUNT).AddOp(vm.OP_GREATERTHAN).AddOp(vm.OP_VERIFY)
+	a.AddOp(vm.OP_INDEX).AddData(nil).AddOp(vm.OP_ROT)
+	a.AddData(s.AssetID[:]).AddInt64(1).AddOp(vm.OP_PROGRAM).AddOp(vm.OP_CHECKOUTPUT).AddOp(vm.OP_VERIFY)
+	a.Jump(vm.OP_JUMP, "payer")
+
+	a.Label("cancel")
+	a.AddOp(vm.OP_DROP).AddOp(vm.OP_DROP)
+	a.Label("payer")
+	a.AddRawBytes(s.Payer)
+	a.Label("end")
+	return a.Build()
+}
+
+// periodStart returns the start of the current period of the
+// contract with control program prog, and whether prog is a
+// contract program for s at all.
+func (s *Subscription) periodStart(prog []byte) (uint64, bool) {
+	if len(prog) < 9 || prog[0] != byte(vm.OP_DATA_8) {
+		return 0, false
+	}
+	start := binary.LittleEndian.Uint64(prog[1:9])
+	return start, bytes.Equal(prog, s.Program(start))
+}
+
+// Manager builds transactions for subscriptions, and collects
+// the payments of those this core has stored as payee.
+type Manager struct {
+	db       pg.DB
+	accounts *account.Manager
+	indexer  *query.Indexer
+}
+
+func NewManager(db pg.DB, accounts *account.Manager, indexer *query.Indexer) *Manager {
+	return &Manager{db: db, accounts: accounts, indexer: indexer}
+}
+
+// Contract is the unspent contract output of a subscription.
+type Contract struct {
+	TransactionID  bc.Hash            `json:"transaction_id"`
+	Position       uint32             `json:"position"`
+	Amount         uint64             `json:"amount"`
+	PeriodStart    uint64             `json:"period_start"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+}
+
+// Status describes the state of a subscription.
+type Status struct {
+	ID       bc.Hash   `json:"id"`
+	Status   string    `json:"status"` // "due", "paid", or "unfunded"
+	Contract *Contract `json:"contract"`
+	Events   []*Event  `json:"events"`
+}
+
+// Status reports on s's confirmed contract output, and lists
+// the events of collecting its payments, if this core collects
+// them. A subscription is due if the payee may pull from it now,
+// and paid if its next pull is in a later period.
+func (m *Manager) Status(ctx context.Context, s *Subscription) (*Status, error) {
+	err := s.Validate()
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{ID: s.ID(), Status: "unfunded"}
+	st.Contract, err = m.contract(ctx, s)
+	if err != nil && errors.Root(err) != ErrNotFunded {
+		return nil, err
+	}
+	if st.Contract != nil {
+		st.Status = "paid"
+		if bc.Millis(time.Now()) >= st.Contract.PeriodStart {
+			st.Status = "due"
+		}
+	}
+	st.Events, err = m.events(ctx, st.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// contract returns the unspent contract output of s.
+// Outputs that only claim to belong to s, by their reference
+// data, but have some other control program or asset are
+// skipped. If there are several, the first one found is used.
+func (m *Manager) contract(ctx context.Context, s *Subscription) (*Contract, error) {
+	p, err := filter.Parse("reference_data.subscription=$1")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	vals := []interface{}{s.ID().String()}
+	timestampMS := bc.Millis(time.Now())
+
+	const limit = 100
+	var after *query.OutputsAfter
+	for {
+		outs, next, err := m.indexer.Outputs(ctx, p, vals, timestampMS, after, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "querying contract outputs")
+		}
+		for _, o := range outs {
+			raw, ok := o.(*json.RawMessage)
+			if !ok || raw == nil {
+				return nil, errors.Wrap(fmt.Errorf("unexpected %T in Indexer.Outputs output", o))
+			}
+			var out struct {
+				TransactionID  bc.Hash            `json:"transaction_id"`
+				Position       uint32             `json:"position"`
+				AssetID        bc.AssetID         `json:"asset_id"`
+				Amount         uint64             `json:"amount"`
+				ControlProgram chainjson.HexBytes `json:"control_program"`
+			}
+			err = json.Unmarshal(*raw, &out)
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding contract output")
+			}
+			start, ok := s.periodStart(out.ControlProgram)
+			if !ok || out.AssetID != s.AssetID {
+				continue
+			}
+			return &Contract{
+				TransactionID:  out.TransactionID,
+				Position:       out.Position,
+				Amount:         out.Amount,
+				PeriodStart:    start,
+				ControlProgram: out.ControlProgram,
+			}, nil
+		}
+		if len(outs) < limit {
+			return nil, errors.WithDetailf(ErrNotFunded, "subscription %s", s.ID())
+		}
+		after = next
+	}
+}
+
+// refData is the reference data of every contract output of s.
+func refData(s *Subscription) ([]byte, error) {
+	b, err := json.Marshal(map[string]string{"subscription": s.ID().String()})
+	return b, errors.Wrap(err)
+}
@@ -0,0 +1,167 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"chain/core/contracttest"
+	"chain/core/coretest"
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+func TestProgram(t *testing.T) {
+	payerPub, payerPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payeePub, payeePriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payerProg, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{payerPub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payeeProg, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{payeePub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Subscription{
+		AssetID: bc.AssetID{1},
+		Cap:     10,
+		Period:  100,
+		Start:   1000,
+		Payer:   payerProg,
+		Payee:   payeeProg,
+	}
+	err = s.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, next := s.Program(1000), s.Program(1100)
+	out := func(amount uint64, prog []byte) *bc.TxOutput {
+		return bc.NewTxOutput(s.AssetID, amount, prog, nil)
+	}
+
+	cases := []struct {
+		name     string
+		minTime  uint64
+		balance  uint64
+		args     []int64
+		outputs  []*bc.TxOutput
+		signer   ed25519.PrivateKey
+		wantPass bool
+	}{
+		{"pull", 1000, 50, []int64{clausePull, 10}, []*bc.TxOutput{out(10, payeeProg), out(40, next)}, payeePriv, true},
+		{"pull under cap", 1000, 50, []int64{clausePull, 5}, []*bc.TxOutput{out(5, payeeProg), out(45, next)}, payeePriv, true},
+		{"pull rest of balance", 1000, 10, []int64{clausePull, 10}, []*bc.TxOutput{out(10, payeeProg)}, payeePriv, true},
+		{"pull before period", 999, 50, []int64{clausePull, 10}, []*bc.TxOutput{out(10, payeeProg), out(40, next)}, payeePriv, false},
+		{"pull over cap", 1000, 50, []int64{clausePull, 11}, []*bc.TxOutput{out(11, payeeProg), out(39, next)}, payeePriv, false},
+		{"pull without relock", 1000, 50, []int64{clausePull, 10}, []*bc.TxOutput{out(10, payeeProg), out(40, payeeProg)}, payeePriv, false},
+		{"pull relocking same period", 1000, 50, []int64{clausePull, 10}, []*bc.TxOutput{out(10, payeeProg), out(40, prog)}, payeePriv, false},
+		{"pull signed by payer", 1000, 50, []int64{clausePull, 10}, []*bc.TxOutput{out(10, payeeProg), out(40, next)}, payerPriv, false},
+		{"top-up", 0, 50, []int64{clauseTopUp, 60}, []*bc.TxOutput{out(60, prog)}, payerPriv, true},
+		{"top-up without increase", 0, 50, []int64{clauseTopUp, 50}, []*bc.TxOutput{out(50, prog)}, payerPriv, false},
+		{"top-up signed by payee", 0, 50, []int64{clauseTopUp, 60}, []*bc.TxOutput{out(60, prog)}, payeePriv, false},
+		{"cancel", 0, 50, []int64{clauseCancel}, []*bc.TxOutput{out(50, payerProg)}, payerPriv, true},
+		{"cancel signed by payee", 0, 50, []int64{clauseCancel}, []*bc.TxOutput{out(50, payeeProg)}, payeePriv, false},
+		{"unknown clause", 0, 50, []int64{3}, []*bc.TxOutput{out(50, payeeProg)}, payeePriv, false},
+	}
+	for _, tc := range cases {
+		tx := &bc.TxData{
+			Version: 1,
+			MinTime: tc.minTime,
+			MaxTime: 2000,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{2}, 0, nil, s.AssetID, tc.balance, prog, nil),
+			},
+			Outputs: tc.outputs,
+		}
+		contracttest.Sign(tx, tc.signer, contracttest.Ints(tc.args...))
+
+		ok, err := vm.VerifyTxInput(bc.NewTx(*tx), 0)
+		if ok != tc.wantPass {
+			t.Errorf("%s: got ok=%t (err %v), want %t", tc.name, ok, err, tc.wantPass)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	a := &Subscription{AssetID: bc.AssetID{1}, Cap: 10, Period: 100, Start: 1000, Payer: []byte{1}, Payee: []byte{2}}
+	start, ok := a.periodStart(a.Program(1100))
+	if !ok || start != 1100 {
+		t.Errorf("periodStart = %d, %t, want 1100, true", start, ok)
+	}
+	b := *a
+	b.Cap = 11
+	if _, ok := a.periodStart(b.Program(1100)); ok {
+		t.Error("periodStart accepted the program of another subscription")
+	}
+}
+
+func TestBuildAndStatus(t *testing.T) {
+	ctx := context.Background()
+	core := contracttest.NewCore(t)
+	m := NewManager(core.DB, core.Accounts, core.Indexer)
+
+	payer, assetID := core.Fund(ctx, t, 100)
+	payee := coretest.CreateAccount(ctx, t, core.Accounts, "", nil)
+	s := &Subscription{
+		AssetID: assetID,
+		Cap:     30,
+		Period:  uint64(time.Hour / time.Millisecond),
+		Start:   bc.Millis(time.Now().Add(-time.Minute)),
+		Payer:   core.Program(ctx, t, payer),
+		Payee:   core.Program(ctx, t, payee),
+	}
+	checkStatus := func(want string, amount, periodStart uint64) {
+		st, err := m.Status(ctx, s)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if st.Status != want {
+			t.Fatalf("status = %s, want %s", st.Status, want)
+		}
+		if st.Contract == nil {
+			return
+		}
+		if st.Contract.Amount != amount || st.Contract.PeriodStart != periodStart {
+			t.Errorf("contract holds %d from %d, want %d from %d", st.Contract.Amount, st.Contract.PeriodStart, amount, periodStart)
+		}
+	}
+	checkStatus("unfunded", 0, 0)
+
+	// A top-up with no contract funds a new one.
+	core.Submit(ctx, t,
+		core.Accounts.NewSpendAction(bc.AssetAmount{AssetID: assetID, Amount: 100}, payer, nil, nil, nil, nil),
+		contracttest.Action(t, m.DecodeTopUpAction, map[string]interface{}{"subscription": s, "amount": 100}),
+	)
+	checkStatus("due", 100, s.Start)
+
+	tx := core.Submit(ctx, t, contracttest.Action(t, m.DecodePullAction, map[string]interface{}{"subscription": s, "amount": 30}))
+	if len(tx.Outputs) != 2 || tx.Outputs[0].Amount != 30 || !bytes.Equal(tx.Outputs[0].ControlProgram, s.Payee) {
+		t.Fatalf("pull did not pay the payee 30 and relock the rest: %+v", tx.Outputs)
+	}
+	checkStatus("paid", 70, s.Start+s.Period)
+
+	// The next pull waits for the next period.
+	pull := contracttest.Action(t, m.DecodePullAction, map[string]interface{}{"subscription": s, "amount": 30})
+	_, err := pull.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrPeriod {
+		t.Errorf("early pull: got error %v, want %v", err, ErrPeriod)
+	}
+
+	tx = core.Submit(ctx, t, contracttest.Action(t, m.DecodeCancelAction, map[string]interface{}{"subscription": s}))
+	if len(tx.Outputs) != 1 || tx.Outputs[0].Amount != 70 || !bytes.Equal(tx.Outputs[0].ControlProgram, s.Payer) {
+		t.Fatalf("cancel did not return 70 to the payer: %+v", tx.Outputs)
+	}
+	checkStatus("unfunded", 0, 0)
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"chain/core/subscription"
+	"chain/core/txbuilder"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/log"
+	"chain/net/http/httpjson"
+	"chain/protocol/bc"
+)
+
+// createSubscription stores a subscription that this core
+// collects for a payee account, with a new control program of
+// the account as the payee program. The payer funds the
+// subscription's contract with the subscription_top_up action.
+//
+// POST /create-subscription
+func (h *Handler) createSubscription(ctx context.Context, in struct {
+	AccountID    string             `json:"account_id"`
+	AccountAlias string             `json:"account_alias"`
+	AssetID      bc.AssetID         `json:"asset_id"`
+	AssetAlias   string             `json:"asset_alias"`
+	Cap          uint64             `json:"cap"`
+	Period       uint64             `json:"period"`
+	Start        uint64             `json:"start"`
+	PayerProgram chainjson.HexBytes `json:"payer_program"`
+}) (*subscription.Record, error) {
+	if in.AccountID == "" && in.AccountAlias != "" {
+		acc, err := h.Accounts.FindByAlias(ctx, in.AccountAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account alias %s", in.AccountAlias)
+		}
+		in.AccountID = acc.ID
+	}
+	if in.AccountID == "" {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing account_id or account_alias")
+	}
+	assetID, err := h.assetID(ctx, in.AssetID, in.AssetAlias)
+	if err != nil {
+		return nil, err
+	}
+	payee, err := h.Accounts.CreateControlProgram(ctx, in.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+	return h.subscriptions.Create(ctx, in.AccountID, &subscription.Subscription{
+		AssetID: assetID,
+		Cap:     in.Cap,
+		Period:  in.Period,
+		Start:   in.Start,
+		Payer:   in.PayerProgram,
+		Payee:   payee,
+	})
+}
+
+// POST /list-subscriptions
+func (h *Handler) listSubscriptions(ctx context.Context, in QueryRequest) (Page, error) {
+	limit := defGenericPageSize
+
+	records, err := h.subscriptions.List(ctx, in.After, limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	out := in
+	if len(records) > 0 {
+		out.After = records[len(records)-1].ID
+	}
+	return Page{
+		Items:    httpjson.Array(records),
+		LastPage: len(records) < limit,
+		Next:     out,
+	}, nil
+}
+
+// getSubscription reports on a subscription's contract, and
+// lists the events of collecting its payments if this core
+// collects them. The request gives the subscription's terms,
+// so payers, who do not store them, can use it too.
+//
+// POST /get-subscription
+func (h *Handler) getSubscription(ctx context.Context, in struct {
+	Subscription subscription.Subscription `json:"subscription"`
+}) (*subscription.Status, error) {
+	return h.subscriptions.Status(ctx, &in.Subscription)
+}
+
+// PullSubscriptions collects the due payments of the subscriptions
+// stored in this core, checking once every period. It signs each
+// pull with the Mock HSM, which must hold the payee account's keys.
+func (h *Handler) PullSubscriptions(ctx context.Context, period time.Duration) {
+	h.once.Do(h.init)
+	ticker := time.NewTicker(period)
+	for {
+		select {
+		case <-ticker.C:
+			err := h.subscriptions.PullDue(ctx, h.mockhsmSignTemplate, h.submitWait)
+			if err != nil {
+				log.Error(ctx, err)
+			}
+		case <-ctx.Done():
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// submitWait submits tpl for the background jobs that build
+// their own transactions, and waits for it to be confirmed.
+func (h *Handler) submitWait(ctx context.Context, tpl *txbuilder.Template) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	_, err := h.finalizeTxWait(ctx, h.Chain, tpl)
+	return err
+}
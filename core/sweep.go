@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+
+	"chain/encoding/json"
+	"chain/errors"
+	"chain/net/http/httpjson"
+	"chain/protocol/bc"
+)
+
+// buildAccountSweep builds transactions that move an account's
+// entire balance to another account on this core, or to a control
+// program, such as one created by another core the account is
+// being migrated to. There is one transaction per asset, in the
+// same form as the response to /build-transaction; each must
+// still be signed and submitted.
+//
+// Only spendable balances are swept, so outputs that are
+// reserved, unconfirmed, or time-locked stay in the account
+// and need another sweep once they can be spent.
+//
+// POST /build-account-sweep
+func (h *Handler) buildAccountSweep(ctx context.Context, in struct {
+	AccountID               string                 `json:"account_id"`
+	AccountAlias            string                 `json:"account_alias"`
+	DestinationAccountID    string                 `json:"destination_account_id"`
+	DestinationAccountAlias string                 `json:"destination_account_alias"`
+	ControlProgram          json.HexBytes          `json:"control_program"`
+	TTL                     json.Duration          `json:"ttl"`
+	ReferenceData           map[string]interface{} `json:"reference_data"`
+}) (interface{}, error) {
+	if in.AccountAlias != "" {
+		acc, err := h.Accounts.FindByAlias(ctx, in.AccountAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account alias %s", in.AccountAlias)
+		}
+		in.AccountID = acc.ID
+	}
+	if in.DestinationAccountAlias != "" {
+		acc, err := h.Accounts.FindByAlias(ctx, in.DestinationAccountAlias)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account alias %s", in.DestinationAccountAlias)
+		}
+		in.DestinationAccountID = acc.ID
+	}
+	if in.AccountID == "" {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "missing account_id or account_alias")
+	}
+	if (in.DestinationAccountID == "") == (len(in.ControlProgram) == 0) {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "provide exactly one of a destination account or control_program")
+	}
+	if in.DestinationAccountID == in.AccountID {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "cannot sweep an account into itself")
+	}
+
+	balances, err := h.Accounts.SpendableBalances(ctx, in.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := make([]*BuildRequest, 0, len(balances))
+	for _, b := range balances {
+		dest := map[string]interface{}{
+			"asset_id": b.AssetID.String(),
+			"amount":   b.Amount,
+		}
+		if in.DestinationAccountID != "" {
+			dest["type"] = "control_account"
+			dest["account_id"] = in.DestinationAccountID
+		} else {
+			dest["type"] = "control_program"
+			dest["control_program"] = in.ControlProgram
+		}
+		actions := []map[string]interface{}{
+			{
+				"type":       "spend_account",
+				"asset_id":   b.AssetID.String(),
+				"amount":     b.Amount,
+				"account_id": in.AccountID,
+			},
+			dest,
+		}
+		if in.ReferenceData != nil {
+			actions = append(actions, map[string]interface{}{
+				"type":           "set_transaction_reference_data",
+				"reference_data": in.ReferenceData,
+			})
+		}
+		reqs = append(reqs, &BuildRequest{Actions: actions, TTL: in.TTL})
+	}
+	return h.build(ctx, reqs)
+}
+
+// defaultConsolidationInputs is the number of outputs
+// a consolidation merges if the request doesn't say.
+const defaultConsolidationInputs = 20
+
+// buildAccountConsolidation builds a transaction merging an
+// account's smallest outputs of an asset into one output to the
+// same account. Accounts that receive many small payments can
+// otherwise need more inputs than is practical to spend them.
+// The response is a single transaction template, which must
+// still be signed and submitted.
+//
+// POST /build-account-consolidation
+func (h *Handler) buildAccountConsolidation(ctx context.Context, in struct {
+	AccountID    string        `json:"account_id"`
+	AccountAlias string        `json:"account_alias"`
+	AssetID      bc.AssetID    `json:"asset_id"`
+	AssetAlias   string        `json:"asset_alias"`
+	MaxInputs    int           `json:"max_inputs"`
+	TTL          json.Duration `json:"ttl"`
+}) (interface{}, error) {
+	accountID, err := h.accountID(ctx, in.AccountID, in.AccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	assetID, err := h.assetID(ctx, in.AssetID, in.AssetAlias)
+	if err != nil {
+		return nil, err
+	}
+	if in.MaxInputs == 0 {
+		in.MaxInputs = defaultConsolidationInputs
+	}
+	if in.MaxInputs < 2 {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "max_inputs must be at least 2")
+	}
+
+	outs, total, err := h.Accounts.Consolidate(ctx, accountID, assetID, in.MaxInputs)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]map[string]interface{}, 0, len(outs)+1)
+	for _, out := range outs {
+		actions = append(actions, map[string]interface{}{
+			"type":           "spend_account_unspent_output",
+			"transaction_id": out.Hash.String(),
+			"position":       out.Index,
+		})
+	}
+	actions = append(actions, map[string]interface{}{
+		"type":       "control_account",
+		"asset_id":   assetID.String(),
+		"amount":     total,
+		"account_id": accountID,
+	})
+	return h.BuildTransaction(ctx, &BuildRequest{Actions: actions, TTL: in.TTL})
+}
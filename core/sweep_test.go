@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"chain/core/account"
+	"chain/core/asset"
+	"chain/core/coretest"
+	"chain/core/query"
+	"chain/core/txbuilder"
+	"chain/database/pg/pgtest"
+	"chain/encoding/json"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/protocol/vm"
+	"chain/testutil"
+)
+
+func TestBuildAccountSweep(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	assets := asset.NewRegistry(db, c)
+	accounts := account.NewManager(db, c)
+	accounts.IndexAccounts(query.NewIndexer(db, c))
+	h := &Handler{Assets: assets, Accounts: accounts, DB: db, Chain: c}
+
+	acc, err := accounts.Create(ctx, []string{testutil.TestXPub.String()}, 1, "", nil, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	asset1 := coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+	asset2 := coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+	coretest.IssueAssets(ctx, t, c, assets, accounts, asset1, 100, acc.ID)
+	coretest.IssueAssets(ctx, t, c, assets, accounts, asset1, 20, acc.ID)
+	coretest.IssueAssets(ctx, t, c, assets, accounts, asset2, 50, acc.ID)
+	prottest.MakeBlock(t, c)
+
+	// An output still in the pool isn't spendable,
+	// so it stays out of the sweep.
+	coretest.IssueAssets(ctx, t, c, assets, accounts, asset2, 7, acc.ID)
+
+	prog := []byte{byte(vm.OP_TRUE)}
+	in := struct {
+		AccountID               string                 `json:"account_id"`
+		AccountAlias            string                 `json:"account_alias"`
+		DestinationAccountID    string                 `json:"destination_account_id"`
+		DestinationAccountAlias string                 `json:"destination_account_alias"`
+		ControlProgram          json.HexBytes          `json:"control_program"`
+		TTL                     json.Duration          `json:"ttl"`
+		ReferenceData           map[string]interface{} `json:"reference_data"`
+	}{AccountID: acc.ID, ControlProgram: prog}
+	resp, err := h.buildAccountSweep(ctx, in)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	want := map[bc.AssetID]uint64{asset1: 120, asset2: 50}
+	responses := resp.([]interface{})
+	if len(responses) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(responses), len(want))
+	}
+	for _, r := range responses {
+		tpl, ok := r.(*txbuilder.Template)
+		if !ok {
+			t.Fatalf("response = %+v, want a template", r)
+		}
+		var swept []*bc.TxOutput
+		for _, out := range tpl.Transaction.Outputs {
+			if string(out.ControlProgram) == string(prog) {
+				swept = append(swept, out)
+			}
+		}
+		if len(swept) != 1 {
+			t.Fatalf("template pays the destination %d times, want once", len(swept))
+		}
+		if got := swept[0].Amount; got != want[swept[0].AssetID] {
+			t.Errorf("swept %d of asset %s, want %d", got, swept[0].AssetID, want[swept[0].AssetID])
+		}
+		delete(want, swept[0].AssetID)
+	}
+}
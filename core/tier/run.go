@@ -0,0 +1,216 @@
+package tier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"chain/core/txbuilder"
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+)
+
+// Kinds of Event.
+const (
+	EventSwept       = "swept"
+	EventReplenished = "replenished"
+	EventAlert       = "alert"
+)
+
+// Event records a sweep, a replenishment, or an alert
+// that a tier's hot balance could not be kept in limits.
+// Amount is the amount swept; replenishment amounts are
+// set by their templates and not recorded.
+type Event struct {
+	Kind   string    `json:"kind"`
+	Amount uint64    `json:"amount"`
+	Detail string    `json:"detail"`
+	Time   time.Time `json:"time"`
+}
+
+// Status reports the balances of a tier's accounts, how many
+// replenishment templates it has left, and its events.
+type Status struct {
+	*Tier
+	HotBalance    uint64   `json:"hot_balance"`
+	ColdBalance   uint64   `json:"cold_balance"`
+	TemplatesLeft int      `json:"templates_left"`
+	Events        []*Event `json:"events"`
+}
+
+// Status returns the status of the tier with the given ID.
+// Balances count only spendable funds: confirmed outputs
+// that are not reserved or time-locked.
+func (m *Manager) Status(ctx context.Context, id string) (*Status, error) {
+	t, err := m.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s := &Status{Tier: t}
+	s.HotBalance, err = m.balance(ctx, t.HotAccountID, t.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	s.ColdBalance, err = m.balance(ctx, t.ColdAccountID, t.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	s.TemplatesLeft, err = m.templatesLeft(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.Events, err = m.events(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (m *Manager) balance(ctx context.Context, accountID string, assetID bc.AssetID) (uint64, error) {
+	balances, err := m.accounts.SpendableBalances(ctx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	for _, amt := range balances {
+		if amt.AssetID == assetID {
+			return amt.Amount, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *Manager) events(ctx context.Context, id string) ([]*Event, error) {
+	const q = `
+		SELECT kind, amount, detail, created_at FROM wallet_tier_events
+		WHERE tier_id = $1 ORDER BY created_at
+	`
+	rows, err := m.db.Query(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying wallet tier events")
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		e := new(Event)
+		err = rows.Scan(&e.Kind, &e.Amount, &e.Detail, &e.Time)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning wallet tier event")
+		}
+		e.Time = e.Time.UTC()
+		events = append(events, e)
+	}
+	return events, errors.Wrap(rows.Err())
+}
+
+// recordEvent records an event for tier t. An alert that repeats
+// the latest event, as when the hot balance stays low with no
+// templates left, is not recorded again. Alerts are also logged.
+func (m *Manager) recordEvent(ctx context.Context, t *Tier, kind string, amount uint64, detail string) error {
+	if kind == EventAlert {
+		log.Messagef(ctx, "wallet tier %s alert: %s", t.ID, detail)
+	}
+	const q = `
+		INSERT INTO wallet_tier_events (tier_id, kind, amount, detail)
+		SELECT $1, $2, $3, $4
+		WHERE $2 <> 'alert' OR NOT EXISTS (
+			SELECT 1 FROM (
+				SELECT kind, detail FROM wallet_tier_events
+				WHERE tier_id = $1 ORDER BY created_at DESC LIMIT 1
+			) AS latest WHERE kind = $2 AND detail = $4
+		)
+	`
+	_, err := m.db.Exec(ctx, q, t.ID, kind, amount, detail)
+	return errors.Wrap(err, "recording wallet tier event")
+}
+
+// RunAll brings the hot balance of each tier within its limits:
+// it sweeps the excess above the high water mark to the cold
+// account, signing with sign, or submits the next replenishment
+// template when the balance is below the low water mark. It
+// submits transactions with submit.
+func (m *Manager) RunAll(ctx context.Context, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) error {
+	const limit = 100
+	var after string
+	for {
+		tiers, err := m.List(ctx, after, limit)
+		if err != nil {
+			return err
+		}
+		for _, t := range tiers {
+			err := m.run(ctx, t, sign, submit)
+			if err != nil {
+				log.Error(ctx, err, "running wallet tier "+t.ID)
+			}
+		}
+		if len(tiers) < limit {
+			return nil
+		}
+		after = tiers[len(tiers)-1].ID
+	}
+}
+
+func (m *Manager) run(ctx context.Context, t *Tier, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) error {
+	hot, err := m.balance(ctx, t.HotAccountID, t.AssetID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case hot > t.HighWater:
+		amount := hot - t.HighWater
+		txHash, err := m.sweep(ctx, t, amount, sign, submit)
+		if err != nil {
+			return m.recordEvent(ctx, t, EventAlert, amount, "sweep failed: "+err.Error())
+		}
+		return m.recordEvent(ctx, t, EventSwept, amount, txHash.String())
+	case hot < t.LowWater:
+		return m.replenish(ctx, t, hot, submit)
+	}
+	return nil
+}
+
+func (m *Manager) sweep(ctx context.Context, t *Tier, amount uint64, sign txbuilder.SignFunc, submit func(context.Context, *txbuilder.Template) error) (bc.Hash, error) {
+	amt := bc.AssetAmount{AssetID: t.AssetID, Amount: amount}
+	actions := []txbuilder.Action{
+		m.accounts.NewSpendAction(amt, t.HotAccountID, nil, nil, nil, nil),
+		m.accounts.NewControlAction(amt, t.ColdAccountID, nil),
+	}
+	tpl, err := txbuilder.Build(ctx, nil, actions, time.Now().Add(5*time.Minute))
+	if err != nil {
+		return bc.Hash{}, err
+	}
+	err = txbuilder.Sign(ctx, tpl, txbuilder.SigningXPubs(tpl), sign)
+	if err != nil {
+		return bc.Hash{}, err
+	}
+	err = submit(ctx, tpl)
+	if err != nil {
+		return bc.Hash{}, err
+	}
+	return bc.NewTx(*tpl.Transaction).Hash, nil
+}
+
+// replenish submits the next template of t. The template is
+// used up whether or not it is accepted, so that a template
+// whose inputs were spent elsewhere does not block the ones
+// after it.
+func (m *Manager) replenish(ctx context.Context, t *Tier, hot uint64, submit func(context.Context, *txbuilder.Template) error) error {
+	tpl, seq, err := m.nextTemplate(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	if tpl == nil {
+		detail := fmt.Sprintf("hot balance %d is below %d and no replenishment templates are left", hot, t.LowWater)
+		return m.recordEvent(ctx, t, EventAlert, 0, detail)
+	}
+	err = m.useTemplate(ctx, seq)
+	if err != nil {
+		return err
+	}
+	err = submit(ctx, tpl)
+	if err != nil {
+		return m.recordEvent(ctx, t, EventAlert, 0, "replenishment failed: "+err.Error())
+	}
+	return m.recordEvent(ctx, t, EventReplenished, 0, bc.NewTx(*tpl.Transaction).Hash.String())
+}
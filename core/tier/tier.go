@@ -0,0 +1,227 @@
+// Package tier keeps the balance of a hot account, whose keys are
+// online, between a low and a high water mark. Funds above the high
+// water mark are swept to a cold account, whose keys are offline.
+// The hot account is replenished from templates pre-approved, and
+// signed, by the holders of offline keys.
+package tier
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"chain/core/account"
+	"chain/core/txbuilder"
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+var ErrBadTier = errors.New("invalid wallet tier")
+
+// Tier holds the balance of AssetID in HotAccountID between
+// LowWater and HighWater. Funds above HighWater are swept to
+// ColdAccountID. Below LowWater, the next replenishment
+// template is submitted.
+type Tier struct {
+	ID            string     `json:"id"`
+	AssetID       bc.AssetID `json:"asset_id"`
+	HotAccountID  string     `json:"hot_account_id"`
+	ColdAccountID string     `json:"cold_account_id"`
+	HighWater     uint64     `json:"high_water"`
+	LowWater      uint64     `json:"low_water"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Validate checks that t's fields are usable.
+func (t *Tier) Validate() error {
+	if t.HotAccountID == "" || t.ColdAccountID == "" {
+		return errors.WithDetail(ErrBadTier, "missing hot or cold account")
+	}
+	if t.HotAccountID == t.ColdAccountID {
+		return errors.WithDetail(ErrBadTier, "hot and cold accounts must differ")
+	}
+	if t.AssetID == (bc.AssetID{}) {
+		return errors.WithDetail(ErrBadTier, "missing asset")
+	}
+	if t.HighWater == 0 || t.LowWater >= t.HighWater {
+		return errors.WithDetail(ErrBadTier, "low water must be less than a nonzero high water")
+	}
+	return nil
+}
+
+// Manager stores wallet tiers and keeps their balances
+// within limits.
+type Manager struct {
+	db       pg.DB
+	accounts *account.Manager
+}
+
+func NewManager(db pg.DB, accounts *account.Manager) *Manager {
+	return &Manager{db: db, accounts: accounts}
+}
+
+// Create stores t and returns it with its ID and creation time
+// set. If there is already a tier for the same hot account and
+// asset, its cold account and limits are replaced, and it keeps
+// its ID and templates.
+//
+// The hot account's keys must be in this core's Mock HSM for
+// sweeps to be signed. The cold account's keys need not be:
+// sweeping only pays to it.
+func (m *Manager) Create(ctx context.Context, t *Tier) (*Tier, error) {
+	err := t.Validate()
+	if err != nil {
+		return nil, err
+	}
+	const q = `
+		INSERT INTO wallet_tiers (asset_id, hot_account_id, cold_account_id, high_water, low_water)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (hot_account_id, asset_id) DO UPDATE
+			SET cold_account_id = excluded.cold_account_id,
+				high_water = excluded.high_water,
+				low_water = excluded.low_water
+		RETURNING id, created_at
+	`
+	err = m.db.QueryRow(ctx, q, t.AssetID, t.HotAccountID, t.ColdAccountID, t.HighWater, t.LowWater).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting wallet tier")
+	}
+	t.CreatedAt = t.CreatedAt.UTC()
+	return t, nil
+}
+
+const selectTierQ = `
+	SELECT id, asset_id, hot_account_id, cold_account_id, high_water, low_water, created_at
+	FROM wallet_tiers
+`
+
+func scanTier(s interface {
+	Scan(...interface{}) error
+}) (*Tier, error) {
+	var t Tier
+	err := s.Scan(&t.ID, &t.AssetID, &t.HotAccountID, &t.ColdAccountID, &t.HighWater, &t.LowWater, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.CreatedAt = t.CreatedAt.UTC()
+	return &t, nil
+}
+
+// Find returns the tier with the given ID.
+func (m *Manager) Find(ctx context.Context, id string) (*Tier, error) {
+	t, err := scanTier(m.db.QueryRow(ctx, selectTierQ+`WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "wallet tier id: %s", id)
+	}
+	return t, errors.Wrap(err, "looking up wallet tier")
+}
+
+// List returns the tiers with IDs after after,
+// in ID order, at most limit of them.
+func (m *Manager) List(ctx context.Context, after string, limit int) ([]*Tier, error) {
+	const q = selectTierQ + `WHERE id > $1 ORDER BY id LIMIT $2`
+	rows, err := m.db.Query(ctx, q, after, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying wallet tiers")
+	}
+	defer rows.Close()
+
+	var tiers []*Tier
+	for rows.Next() {
+		t, err := scanTier(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning wallet tier")
+		}
+		tiers = append(tiers, t)
+	}
+	return tiers, errors.Wrap(rows.Err())
+}
+
+// Delete deletes the tier with the given ID and its unused
+// templates. Its events are kept.
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	res, err := m.db.Exec(ctx, `DELETE FROM wallet_tiers WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrap(err, "deleting wallet tier")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "deleting wallet tier")
+	}
+	if n == 0 {
+		return errors.WithDetailf(pg.ErrUserInputNotFound, "wallet tier id: %s", id)
+	}
+	_, err = m.db.Exec(ctx, `DELETE FROM wallet_tier_templates WHERE tier_id = $1 AND used_at IS NULL`, id)
+	return errors.Wrap(err, "deleting wallet tier templates")
+}
+
+// AddTemplate queues tpl, a transaction replenishing the hot
+// account of the tier with ID id, to be submitted when the hot
+// balance falls below the tier's low water mark. Templates are
+// submitted in the order they were added, each at most once.
+// Its signatures must commit to the whole transaction, since it
+// is submitted as is.
+func (m *Manager) AddTemplate(ctx context.Context, id string, tpl *txbuilder.Template) error {
+	if tpl.Transaction == nil {
+		return errors.WithDetail(ErrBadTier, "missing raw transaction")
+	}
+	if tpl.AllowAdditional {
+		return errors.WithDetail(ErrBadTier, "template must not allow additional actions")
+	}
+	_, err := m.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(tpl)
+	if err != nil {
+		return errors.Wrap(err, "encoding template")
+	}
+	const q = `INSERT INTO wallet_tier_templates (tier_id, template) VALUES ($1, $2)`
+	_, err = m.db.Exec(ctx, q, id, string(data))
+	return errors.Wrap(err, "inserting wallet tier template")
+}
+
+// nextTemplate returns the oldest unused template of the
+// tier with ID id, and its sequence number, or nil if there
+// are none left.
+func (m *Manager) nextTemplate(ctx context.Context, id string) (*txbuilder.Template, int64, error) {
+	const q = `
+		SELECT seq, template FROM wallet_tier_templates
+		WHERE tier_id = $1 AND used_at IS NULL
+		ORDER BY seq LIMIT 1
+	`
+	var (
+		seq  int64
+		data []byte
+	)
+	err := m.db.QueryRow(ctx, q, id).Scan(&seq, &data)
+	if err == sql.ErrNoRows {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, errors.Wrap(err, "looking up wallet tier template")
+	}
+	tpl := new(txbuilder.Template)
+	err = json.Unmarshal(data, tpl)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "decoding wallet tier template")
+	}
+	return tpl, seq, nil
+}
+
+// useTemplate marks the template with sequence number
+// seq as used, so it is not submitted again.
+func (m *Manager) useTemplate(ctx context.Context, seq int64) error {
+	const q = `UPDATE wallet_tier_templates SET used_at = now() WHERE seq = $1`
+	_, err := m.db.Exec(ctx, q, seq)
+	return errors.Wrap(err, "marking wallet tier template used")
+}
+
+// templatesLeft counts the unused templates of the tier with ID id.
+func (m *Manager) templatesLeft(ctx context.Context, id string) (int, error) {
+	const q = `SELECT COUNT(*) FROM wallet_tier_templates WHERE tier_id = $1 AND used_at IS NULL`
+	var n int
+	err := m.db.QueryRow(ctx, q, id).Scan(&n)
+	return n, errors.Wrap(err, "counting wallet tier templates")
+}
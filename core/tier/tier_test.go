@@ -0,0 +1,90 @@
+package tier
+
+import (
+	"context"
+	"testing"
+
+	"chain/core/txbuilder"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+func TestValidate(t *testing.T) {
+	asset := bc.AssetID{1}
+	cases := []struct {
+		t    Tier
+		good bool
+	}{
+		{Tier{AssetID: asset, HotAccountID: "h", ColdAccountID: "c", HighWater: 10, LowWater: 5}, true},
+		{Tier{AssetID: asset, HotAccountID: "h", ColdAccountID: "c", HighWater: 10}, true},
+		{Tier{AssetID: asset, HotAccountID: "h", HighWater: 10, LowWater: 5}, false},
+		{Tier{AssetID: asset, HotAccountID: "h", ColdAccountID: "h", HighWater: 10, LowWater: 5}, false},
+		{Tier{HotAccountID: "h", ColdAccountID: "c", HighWater: 10, LowWater: 5}, false},
+		{Tier{AssetID: asset, HotAccountID: "h", ColdAccountID: "c", HighWater: 5, LowWater: 5}, false},
+		{Tier{AssetID: asset, HotAccountID: "h", ColdAccountID: "c"}, false},
+	}
+	for i, c := range cases {
+		err := c.t.Validate()
+		if (err == nil) != c.good {
+			t.Errorf("case %d: Validate() = %v, want good = %v", i, err, c.good)
+		}
+		if err != nil && errors.Root(err) != ErrBadTier {
+			t.Errorf("case %d: error = %v, want %v", i, err, ErrBadTier)
+		}
+	}
+}
+
+func TestReplenish(t *testing.T) {
+	// Use a database, not a transaction, so that each
+	// event gets a later created_at than the one before.
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	m := NewManager(db, nil)
+
+	tr, err := m.Create(ctx, &Tier{AssetID: bc.AssetID{1}, HotAccountID: "hot", ColdAccountID: "cold", HighWater: 10, LowWater: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &txbuilder.Template{Transaction: &bc.TxData{Version: 1}}
+	err = m.AddTemplate(ctx, tr.ID, tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.AddTemplate(ctx, tr.ID, &txbuilder.Template{})
+	if errors.Root(err) != ErrBadTier {
+		t.Errorf("AddTemplate(no tx) = %v, want %v", err, ErrBadTier)
+	}
+
+	var submitted int
+	submit := func(context.Context, *txbuilder.Template) error {
+		submitted++
+		return nil
+	}
+	// The first replenishment uses the template; the next two
+	// find none left and record a single alert.
+	for i := 0; i < 3; i++ {
+		err = m.replenish(ctx, tr, 0, submit)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if submitted != 1 {
+		t.Errorf("submitted %d templates, want 1", submitted)
+	}
+
+	events, err := m.events(ctx, tr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Kind != EventReplenished || events[1].Kind != EventAlert {
+		t.Errorf("events = %+v, want a replenishment and an alert", events)
+	}
+	n, err := m.templatesLeft(ctx, tr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("templates left = %d, want 0", n)
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"chain/core/tier"
+	"chain/core/txbuilder"
+	"chain/errors"
+	"chain/log"
+	"chain/net/http/httpjson"
+	"chain/protocol/bc"
+)
+
+// createWalletTier stores a tier keeping a hot account's balance
+// of an asset between a low and a high water mark, sweeping the
+// excess to a cold account.
+//
+// POST /create-wallet-tier
+func (h *Handler) createWalletTier(ctx context.Context, in struct {
+	AssetID          bc.AssetID `json:"asset_id"`
+	AssetAlias       string     `json:"asset_alias"`
+	HotAccountID     string     `json:"hot_account_id"`
+	HotAccountAlias  string     `json:"hot_account_alias"`
+	ColdAccountID    string     `json:"cold_account_id"`
+	ColdAccountAlias string     `json:"cold_account_alias"`
+	HighWater        uint64     `json:"high_water"`
+	LowWater         uint64     `json:"low_water"`
+}) (*tier.Tier, error) {
+	assetID, err := h.assetID(ctx, in.AssetID, in.AssetAlias)
+	if err != nil {
+		return nil, err
+	}
+	hotID, err := h.accountID(ctx, in.HotAccountID, in.HotAccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	coldID, err := h.accountID(ctx, in.ColdAccountID, in.ColdAccountAlias)
+	if err != nil {
+		return nil, err
+	}
+	return h.tiers.Create(ctx, &tier.Tier{
+		AssetID:       assetID,
+		HotAccountID:  hotID,
+		ColdAccountID: coldID,
+		HighWater:     in.HighWater,
+		LowWater:      in.LowWater,
+	})
+}
+
+// POST /list-wallet-tiers
+func (h *Handler) listWalletTiers(ctx context.Context, in QueryRequest) (Page, error) {
+	limit := defGenericPageSize
+
+	tiers, err := h.tiers.List(ctx, in.After, limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	out := in
+	if len(tiers) > 0 {
+		out.After = tiers[len(tiers)-1].ID
+	}
+	return Page{
+		Items:    httpjson.Array(tiers),
+		LastPage: len(tiers) < limit,
+		Next:     out,
+	}, nil
+}
+
+// getWalletTier returns a tier with the balances of its
+// accounts, its replenishment templates left, and its
+// sweeps, replenishments, and alerts.
+//
+// POST /get-wallet-tier
+func (h *Handler) getWalletTier(ctx context.Context, in struct {
+	ID string `json:"id"`
+}) (*tier.Status, error) {
+	return h.tiers.Status(ctx, in.ID)
+}
+
+// deleteWalletTier stops managing a tier.
+// Its unused templates are discarded.
+//
+// POST /delete-wallet-tier
+func (h *Handler) deleteWalletTier(ctx context.Context, in struct {
+	ID string `json:"id"`
+}) error {
+	return h.tiers.Delete(ctx, in.ID)
+}
+
+// addWalletTierTemplate queues a signed transaction template
+// replenishing a tier's hot account, to be submitted when its
+// balance falls below the low water mark.
+//
+// POST /add-wallet-tier-template
+func (h *Handler) addWalletTierTemplate(ctx context.Context, in struct {
+	ID       string              `json:"id"`
+	Template *txbuilder.Template `json:"template"`
+}) error {
+	if in.Template == nil {
+		return errors.WithDetail(httpjson.ErrBadRequest, "missing template")
+	}
+	return h.tiers.AddTemplate(ctx, in.ID, in.Template)
+}
+
+// RunWalletTiers brings the hot balances of wallet tiers
+// within their limits every period, signing sweeps with the
+// Mock HSM. It blocks until ctx is canceled.
+func (h *Handler) RunWalletTiers(ctx context.Context, period time.Duration) {
+	h.once.Do(h.init)
+	ticker := time.NewTicker(period)
+	for {
+		select {
+		case <-ticker.C:
+			err := h.tiers.RunAll(ctx, h.mockhsmSignTemplate, h.submitWait)
+			if err != nil {
+				log.Error(ctx, err)
+			}
+		case <-ctx.Done():
+			ticker.Stop()
+			return
+		}
+	}
+}
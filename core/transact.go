@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"chain/core/fetch"
+	"chain/core/generator"
 	"chain/core/txbuilder"
 	"chain/database/pg"
 	chainjson "chain/encoding/json"
@@ -19,8 +20,36 @@ import (
 
 var defaultTxTTL = 5 * time.Minute
 
-func (h *Handler) buildSingle(ctx context.Context, req *buildRequest) (*txbuilder.Template, error) {
-	err := h.filterAliases(ctx, req)
+// BuildTransaction builds a transaction template from req,
+// reserving the outputs it spends until the template's TTL.
+// The template must then be signed and passed to
+// SubmitTransaction.
+func (h *Handler) BuildTransaction(ctx context.Context, req *BuildRequest) (*txbuilder.Template, error) {
+	h.once.Do(h.init)
+
+	// The fee's actions go in first so that
+	// their aliases are resolved with the rest.
+	err := applyFee(req, h.Fees.Program)
+	if err != nil {
+		return nil, err
+	}
+	err = h.filterAliases(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	err = applyReferenceData(req)
+	if err != nil {
+		return nil, err
+	}
+	err = applyEndToEndID(req)
+	if err != nil {
+		return nil, err
+	}
+	err = checkActionScope(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	err = checkLabels(req.Labels)
 	if err != nil {
 		return nil, err
 	}
@@ -52,11 +81,40 @@ func (h *Handler) buildSingle(ctx context.Context, req *buildRequest) (*txbuilde
 	if ttl == 0 {
 		ttl = defaultTxTTL
 	}
-	maxTime := time.Now().Add(ttl)
+	// A future-dated transaction is valid for ttl from its min
+	// time, and its inputs stay reserved until then.
+	start := time.Now()
+	minTime := time.Unix(0, int64(req.MinTime)*int64(time.Millisecond))
+	if max := h.Chain.MaxFutureTime; max != 0 && minTime.After(start.Add(max)) {
+		return nil, errors.WithDetailf(errBadMinTime, "min_time may be at most %s in the future", max)
+	}
+	if minTime.After(start) {
+		start = minTime
+	}
+	maxTime := start.Add(ttl)
+	var baseInputs int
+	if req.Tx != nil {
+		baseInputs = len(req.Tx.Inputs)
+	}
 	tpl, err := txbuilder.Build(ctx, req.Tx, actions, maxTime)
 	if err != nil {
 		return nil, err
 	}
+	if uint64(req.MinTime) > tpl.Transaction.MinTime {
+		tpl.Transaction.MinTime = uint64(req.MinTime)
+	}
+	err = h.MinOutputAmounts.Check(tpl.Transaction)
+	if err != nil {
+		h.releaseInputs(ctx, tpl.Transaction, baseInputs)
+		return nil, err
+	}
+	err = h.Accounts.CheckProgramReuse(ctx, tpl.Transaction)
+	if err != nil {
+		h.releaseInputs(ctx, tpl.Transaction, baseInputs)
+		return nil, err
+	}
+	tpl.EstimatedSize = txbuilder.EstimateSize(tpl)
+	tpl.Labels = req.Labels
 
 	// ensure null is never returned for signing instructions
 	if tpl.SigningInstructions == nil {
@@ -66,7 +124,7 @@ func (h *Handler) buildSingle(ctx context.Context, req *buildRequest) (*txbuilde
 }
 
 // POST /build-transaction
-func (h *Handler) build(ctx context.Context, buildReqs []*buildRequest) (interface{}, error) {
+func (h *Handler) build(ctx context.Context, buildReqs []*BuildRequest) (interface{}, error) {
 	responses := make([]interface{}, len(buildReqs))
 	var wg sync.WaitGroup
 	wg.Add(len(responses))
@@ -75,10 +133,10 @@ func (h *Handler) build(ctx context.Context, buildReqs []*buildRequest) (interfa
 		go func(i int) {
 			defer wg.Done()
 
-			resp, err := h.buildSingle(reqid.NewSubContext(ctx, reqid.New()), buildReqs[i])
+			resp, err := h.BuildTransaction(reqid.NewSubContext(ctx, reqid.New()), buildReqs[i])
 			if err != nil {
 				logHTTPError(ctx, err)
-				responses[i], _ = errInfo(err)
+				responses[i], _ = errInfo(ctx, err)
 			} else {
 				responses[i] = resp
 			}
@@ -89,29 +147,92 @@ func (h *Handler) build(ctx context.Context, buildReqs []*buildRequest) (interfa
 	return responses, nil
 }
 
-type submitSingleArg struct {
-	tpl  *txbuilder.Template
-	wait chainjson.Duration
+// cancelReservation releases the outputs reserved to build a
+// transaction, for a client abandoning the transaction before
+// its TTL ends.
+//
+// POST /cancel-reservation
+func (h *Handler) cancelReservation(ctx context.Context, in struct {
+	RawTransaction *bc.TxData `json:"raw_transaction"`
+}) (interface{}, error) {
+	if in.RawTransaction == nil {
+		return nil, errors.Wrap(txbuilder.ErrMissingRawTx)
+	}
+	n, err := h.Accounts.CancelReservations(ctx, spentOutpoints(in.RawTransaction))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int64{"reservations": n}, nil
 }
 
-func (h *Handler) submitSingle(ctx context.Context, c *protocol.Chain, x submitSingleArg) (interface{}, error) {
-	// TODO(bobg): Set up an expiring context object outside this
-	// function, perhaps in handler.ServeHTTPContext, and perhaps
-	// initialize the timeout from the HTTP Timeout field.  (Or just
-	// switch to gRPC.)
-	timeout := x.wait.Duration
-	if timeout <= 0 {
-		timeout = 30 * time.Second
+// extendReservation keeps the outputs reserved to build a
+// transaction reserved for ttl from now. It does not change
+// the transaction's max time.
+//
+// POST /extend-reservation
+func (h *Handler) extendReservation(ctx context.Context, in struct {
+	RawTransaction *bc.TxData         `json:"raw_transaction"`
+	TTL            chainjson.Duration `json:"ttl"`
+}) (interface{}, error) {
+	if in.RawTransaction == nil {
+		return nil, errors.Wrap(txbuilder.ErrMissingRawTx)
 	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	err := h.finalizeTxWait(ctx, c, x.tpl)
+	ttl := in.TTL.Duration
+	if ttl == 0 {
+		ttl = defaultTxTTL
+	}
+	exp := time.Now().Add(ttl)
+	n, err := h.Accounts.ExtendReservations(ctx, spentOutpoints(in.RawTransaction), exp)
 	if err != nil {
 		return nil, err
 	}
+	return map[string]interface{}{"reservations": n, "expiry": bc.Millis(exp)}, nil
+}
+
+// releaseInputs releases the reservations of the spend inputs
+// of tx past the first n, those a build added, once the build
+// fails a check after reserving them. The inputs of a base transaction
+// stay reserved for the build that added them.
+func (h *Handler) releaseInputs(ctx context.Context, tx *bc.TxData, n int) {
+	tx = &bc.TxData{Inputs: tx.Inputs[n:]}
+	_, err := h.Accounts.CancelReservations(ctx, spentOutpoints(tx))
+	if err != nil {
+		log.Error(ctx, err, "releasing inputs of a failed build")
+	}
+}
+
+// spentOutpoints returns the outpoints spent by tx's spend inputs.
+func spentOutpoints(tx *bc.TxData) []bc.Outpoint {
+	var outs []bc.Outpoint
+	for _, in := range tx.Inputs {
+		if !in.IsIssuance() {
+			outs = append(outs, in.Outpoint())
+		}
+	}
+	return outs
+}
+
+// SubmitTransaction submits the signed transaction in tpl and
+// waits for it to be confirmed, for at most wait, or 30 seconds
+// if wait is not positive. It returns the transaction's hash.
+// If the wait ends first, the error is context.DeadlineExceeded,
+// and the transaction may still be confirmed later. Submitting
+// the same transaction again is safe.
+func (h *Handler) SubmitTransaction(ctx context.Context, tpl *txbuilder.Template, wait time.Duration) (bc.Hash, error) {
+	if wait <= 0 {
+		wait = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
 
-	return map[string]string{"id": x.tpl.Transaction.Hash().String()}, nil
+	return h.finalizeTxWait(ctx, h.Chain, tpl)
+}
+
+// submittedTx is the record of a submitted transaction.
+type submittedTx struct {
+	hash    bc.Hash
+	height  uint64
+	maxTime uint64
 }
 
 // recordSubmittedTx records a lower bound height at which the tx
@@ -120,20 +241,46 @@ func (h *Handler) submitSingle(ctx context.Context, c *protocol.Chain, x submitS
 // blocks starting at this height.
 //
 // If the tx has already been submitted, it returns the existing
-// height.
+// record.
+//
+// If clientToken is not empty and was recorded with an earlier
+// submission, it returns that submission's record instead, and
+// records nothing.
+//
+// The returned bool reports whether a new record was made.
 // TODO(jackson): Prune entries older than some threshold periodically.
-func recordSubmittedTx(ctx context.Context, db pg.DB, txHash bc.Hash, currentHeight uint64) (height uint64, err error) {
+func recordSubmittedTx(ctx context.Context, db pg.DB, tx *bc.Tx, currentHeight uint64, clientToken string) (submittedTx, bool, error) {
+	// A row for the client token takes precedence
+	// over an earlier row for the same tx.
 	const q = `
 		WITH inserted AS (
-			INSERT INTO submitted_txs (tx_id, height) VALUES($1, $2)
-			ON CONFLICT DO NOTHING RETURNING height
+			INSERT INTO submitted_txs (tx_id, height, client_token, max_time) VALUES($1, $2, NULLIF($3, ''), NULLIF($4, 0))
+			ON CONFLICT DO NOTHING RETURNING tx_id, height, max_time
 		)
-		SELECT height FROM inserted
-		UNION
-		SELECT height FROM submitted_txs WHERE tx_id = $1
+		SELECT tx_id, height, COALESCE(max_time, 0), TRUE FROM inserted
+		UNION ALL
+		(SELECT tx_id, height, COALESCE(max_time, 0), FALSE FROM submitted_txs
+			WHERE tx_id = $1 OR client_token = NULLIF($3, '')
+			ORDER BY client_token = NULLIF($3, '') DESC NULLS LAST
+			LIMIT 1)
+		LIMIT 1
 	`
-	err = db.QueryRow(ctx, q, txHash, currentHeight).Scan(&height)
-	return height, err
+	var (
+		sub      submittedTx
+		inserted bool
+	)
+	err := db.QueryRow(ctx, q, tx.Hash, currentHeight, clientToken, tx.MaxTime).Scan(&sub.hash, &sub.height, &sub.maxTime, &inserted)
+	return sub, inserted, err
+}
+
+// forgetSubmittedTx deletes the record of a submission that
+// never reached the pool, so that a retry under the same
+// client token submits its own transaction.
+func forgetSubmittedTx(ctx context.Context, db pg.DB, txHash bc.Hash) {
+	_, err := db.Exec(ctx, `DELETE FROM submitted_txs WHERE tx_id = $1`, txHash)
+	if err != nil {
+		log.Error(ctx, errors.Wrap(err, "forgetting submitted tx"))
+	}
 }
 
 // CleanupSubmittedTxs will periodically delete records of submitted txs
@@ -168,9 +315,22 @@ func CleanupSubmittedTxs(ctx context.Context, db pg.DB) {
 // confirmed on the blockchain.  ErrRejected means a conflicting tx is
 // on the blockchain.  context.DeadlineExceeded means ctx is an
 // expiring context that timed out.
-func (h *Handler) finalizeTxWait(ctx context.Context, c *protocol.Chain, txTemplate *txbuilder.Template) error {
+//
+// It returns the hash of the submitted transaction. If the template's
+// client token was used by an earlier submission, it waits for that
+// transaction instead and returns its hash.
+func (h *Handler) finalizeTxWait(ctx context.Context, c *protocol.Chain, txTemplate *txbuilder.Template) (bc.Hash, error) {
 	if txTemplate.Transaction == nil {
-		return errors.Wrap(txbuilder.ErrMissingRawTx)
+		return bc.Hash{}, errors.Wrap(txbuilder.ErrMissingRawTx)
+	}
+
+	err := h.MinOutputAmounts.Check(txTemplate.Transaction)
+	if err != nil {
+		return bc.Hash{}, err
+	}
+	err = h.Fees.Check(txTemplate.Transaction)
+	if err != nil {
+		return bc.Hash{}, err
 	}
 
 	// Use the current generator height as the lower bound of the block height
@@ -181,16 +341,41 @@ func (h *Handler) finalizeTxWait(ctx context.Context, c *protocol.Chain, txTempl
 		generatorHeight = localHeight
 	}
 
+	if len(txTemplate.Labels) > 0 {
+		err = checkLabels(txTemplate.Labels)
+		if err != nil {
+			return bc.Hash{}, err
+		}
+	}
+
 	// Remember this height in case we retry this submit call.
 	tx := bc.NewTx(*txTemplate.Transaction)
-	height, err := recordSubmittedTx(ctx, h.DB, tx.Hash, generatorHeight)
+	sub, inserted, err := recordSubmittedTx(ctx, h.DB, tx, generatorHeight, txTemplate.ClientToken)
 	if err != nil {
-		return errors.Wrap(err, "saving tx submitted height")
+		return bc.Hash{}, errors.Wrap(err, "saving tx submitted height")
+	}
+	height := sub.height
+	if sub.hash != tx.Hash {
+		// A retry of an earlier submission under the same
+		// client token. The earlier transaction reached the
+		// pool, or its record would have been deleted, so
+		// only wait for it.
+		return sub.hash, waitTx(ctx, c, sub.hash, height, sub.maxTime)
 	}
 
-	err = txbuilder.FinalizeTx(ctx, c, tx)
+	err = h.submitToPool(ctx, c, tx, txTemplate.Labels)
 	if err != nil {
-		return err
+		if inserted {
+			forgetSubmittedTx(ctx, h.DB, tx.Hash)
+		}
+		return bc.Hash{}, err
+	}
+
+	// A future-dated transaction waits in the pool until its min
+	// time, so there is nothing to wait for yet. Its outputs are
+	// not indexed as unconfirmed, since they would expire first.
+	if tx.MinTime > bc.Millis(time.Now()) {
+		return tx.Hash, nil
 	}
 
 	// As a rule we only index confirmed blockchain data to prevent dirty
@@ -201,7 +386,7 @@ func (h *Handler) finalizeTxWait(ctx context.Context, c *protocol.Chain, txTempl
 	if txTemplate.Local {
 		err := h.Accounts.IndexUnconfirmedUTXOs(ctx, tx)
 		if err != nil {
-			return errors.Wrap(err, "indexing unconfirmed account utxos")
+			return bc.Hash{}, errors.Wrap(err, "indexing unconfirmed account utxos")
 		}
 	}
 
@@ -209,22 +394,22 @@ func (h *Handler) finalizeTxWait(ctx context.Context, c *protocol.Chain, txTempl
 		height++
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return bc.Hash{}, ctx.Err()
 
 		case <-waitBlock(ctx, c, height):
 			b, err := c.GetBlock(ctx, height)
 			if err != nil {
-				return errors.Wrap(err, "getting block that just landed")
+				return bc.Hash{}, errors.Wrap(err, "getting block that just landed")
 			}
 			for _, confirmed := range b.Transactions {
 				if confirmed.Hash == tx.Hash {
 					// confirmed
-					return nil
+					return tx.Hash, nil
 				}
 			}
 
 			if tx.MaxTime > 0 && tx.MaxTime < b.TimestampMS {
-				return txbuilder.ErrRejected
+				return bc.Hash{}, txbuilder.ErrRejected
 			}
 
 			// might still be in pool or might be rejected; we can't
@@ -233,7 +418,7 @@ func (h *Handler) finalizeTxWait(ctx context.Context, c *protocol.Chain, txTempl
 			// Re-insert into the pool in case it was dropped.
 			err = txbuilder.FinalizeTx(ctx, c, tx)
 			if err != nil {
-				return err
+				return bc.Hash{}, err
 			}
 
 			// TODO(jackson): Do simple rejection checks like checking if
@@ -242,6 +427,55 @@ func (h *Handler) finalizeTxWait(ctx context.Context, c *protocol.Chain, txTempl
 	}
 }
 
+// submitToPool saves the labels of tx, if any,
+// and gives it to the pool.
+func (h *Handler) submitToPool(ctx context.Context, c *protocol.Chain, tx *bc.Tx, labels map[string]string) error {
+	if len(labels) > 0 {
+		err := h.Indexer.SaveTransactionLabels(ctx, tx.Hash, labels)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := txbuilder.FinalizeTx(ctx, c, tx)
+	if err != nil {
+		return err
+	}
+	if h.Config.IsGenerator {
+		generator.RecordSubmission(tx, "local")
+	}
+	return nil
+}
+
+// waitTx waits for the tx with hash txHash to land in a block
+// after height. Unlike finalizeTxWait, it does not have the tx
+// to resubmit. It returns ErrRejected once a block lands after
+// maxTime without the tx; with no maxTime, it waits until ctx
+// is done.
+func waitTx(ctx context.Context, c *protocol.Chain, txHash bc.Hash, height, maxTime uint64) error {
+	for {
+		height++
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-waitBlock(ctx, c, height):
+			b, err := c.GetBlock(ctx, height)
+			if err != nil {
+				return errors.Wrap(err, "getting block that just landed")
+			}
+			for _, confirmed := range b.Transactions {
+				if confirmed.Hash == txHash {
+					return nil
+				}
+			}
+			if maxTime > 0 && maxTime < b.TimestampMS {
+				return txbuilder.ErrRejected
+			}
+		}
+	}
+}
+
 func waitBlock(ctx context.Context, c *protocol.Chain, height uint64) <-chan struct{} {
 	done := make(chan struct{}, 1)
 	go func() {
@@ -251,6 +485,20 @@ func waitBlock(ctx context.Context, c *protocol.Chain, height uint64) <-chan str
 	return done
 }
 
+// getSigningProgress reports which keys have signed each input
+// of a template, so that co-signers passing a template between
+// cores can see whose signatures it still needs.
+//
+// POST /get-signing-progress
+func (h *Handler) getSigningProgress(ctx context.Context, tpl *txbuilder.Template) (interface{}, error) {
+	inputs := txbuilder.Progress(tpl)
+	complete := true
+	for _, in := range inputs {
+		complete = complete && in.Complete
+	}
+	return map[string]interface{}{"complete": complete, "inputs": inputs}, nil
+}
+
 type submitArg struct {
 	Transactions []*txbuilder.Template
 	wait         chainjson.Duration
@@ -264,12 +512,12 @@ func (h *Handler) submit(ctx context.Context, x submitArg) interface{} {
 	wg.Add(len(responses))
 	for i := range responses {
 		go func(i int) {
-			resp, err := h.submitSingle(reqid.NewSubContext(ctx, reqid.New()), h.Chain, submitSingleArg{tpl: x.Transactions[i], wait: x.wait})
+			txHash, err := h.SubmitTransaction(reqid.NewSubContext(ctx, reqid.New()), x.Transactions[i], x.wait.Duration)
 			if err != nil {
 				logHTTPError(ctx, err)
-				responses[i], _ = errInfo(err)
+				responses[i], _ = errInfo(ctx, err)
 			} else {
-				responses[i] = resp
+				responses[i] = map[string]string{"id": txHash.String()}
 			}
 			wg.Done()
 		}(i)
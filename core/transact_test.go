@@ -2,17 +2,22 @@ package core
 
 import (
 	"context"
+	"encoding/hex"
+	"reflect"
 	"testing"
 	"time"
 
 	"chain/core/account"
 	"chain/core/asset"
 	"chain/core/coretest"
+	"chain/core/query"
 	"chain/core/txbuilder"
+	"chain/database/pg"
 	"chain/database/pg/pgtest"
-	chainjson "chain/encoding/json"
+	"chain/errors"
 	"chain/protocol/bc"
 	"chain/protocol/prottest"
+	"chain/protocol/vm"
 	"chain/testutil"
 )
 
@@ -47,7 +52,7 @@ func TestLocalAccountTransfer(t *testing.T) {
 	// Submit the transaction but w/o waiting long for confirmation.
 	// The outputs should be indexed because the transaction template
 	// indicates that the transaction is completely local to this Core.
-	_, _ = h.submitSingle(ctx, c, submitSingleArg{tpl: tmpl, wait: chainjson.Duration{time.Millisecond}})
+	_, _ = h.SubmitTransaction(ctx, tmpl, time.Millisecond)
 
 	// Add a new source, spending the change output produced above.
 	sources = accounts.NewSpendAction(assetAmt, acc.ID, nil, nil, nil, nil)
@@ -67,3 +72,299 @@ func TestLocalAccountTransfer(t *testing.T) {
 		t.Errorf("len(b.Transactions) = %d, want 2", len(b.Transactions))
 	}
 }
+
+func TestApplyEndToEndID(t *testing.T) {
+	cases := []struct {
+		req     *BuildRequest
+		wantErr bool
+	}{{
+		req: &BuildRequest{EndToEndID: "e2e"},
+	}, {
+		req: &BuildRequest{
+			EndToEndID: "e2e",
+			Actions: []map[string]interface{}{{
+				"type":           "set_transaction_reference_data",
+				"reference_data": map[string]interface{}{"memo": "x"},
+			}},
+		},
+	}, {
+		req: &BuildRequest{
+			EndToEndID: "e2e",
+			Actions: []map[string]interface{}{{
+				"type":           "set_transaction_reference_data",
+				"reference_data": map[string]interface{}{"end_to_end_id": "other"},
+			}},
+		},
+		wantErr: true,
+	}, {
+		req: &BuildRequest{
+			EndToEndID: "e2e",
+			Actions: []map[string]interface{}{{
+				"type":           "set_transaction_reference_data",
+				"reference_data": "memo",
+			}},
+		},
+		wantErr: true,
+	}, {
+		req: &BuildRequest{
+			EndToEndID: "e2e",
+			Tx:         &bc.TxData{ReferenceData: []byte(`{"end_to_end_id":"e2e"}`)},
+		},
+	}, {
+		req: &BuildRequest{
+			EndToEndID: "e2e",
+			Tx:         &bc.TxData{ReferenceData: []byte(`{"memo":"x"}`)},
+		},
+		wantErr: true,
+	}}
+
+	for i, c := range cases {
+		err := applyEndToEndID(c.req)
+		if c.wantErr {
+			if errors.Root(err) != errBadEndToEndID {
+				t.Errorf("case %d: err = %v want %v", i, err, errBadEndToEndID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+			continue
+		}
+		if c.req.Tx != nil {
+			continue
+		}
+		var found bool
+		for _, a := range c.req.Actions {
+			rd, _ := a["reference_data"].(map[string]interface{})
+			if a["type"] == "set_transaction_reference_data" && rd["end_to_end_id"] == "e2e" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("case %d: end_to_end_id not set in actions %v", i, c.req.Actions)
+		}
+	}
+}
+
+func TestApplyReferenceData(t *testing.T) {
+	refData := map[string]interface{}{"invoice": "INV-1"}
+	cases := []struct {
+		req     *BuildRequest
+		wantErr bool
+	}{{
+		req: &BuildRequest{},
+	}, {
+		req: &BuildRequest{ReferenceData: refData},
+	}, {
+		req: &BuildRequest{
+			ReferenceData: refData,
+			Actions: []map[string]interface{}{{
+				"type":           "set_transaction_reference_data",
+				"reference_data": map[string]interface{}{"memo": "x"},
+			}},
+		},
+		wantErr: true,
+	}, {
+		req: &BuildRequest{
+			ReferenceData: refData,
+			Tx:            &bc.TxData{ReferenceData: []byte(`{"memo":"x"}`)},
+		},
+		wantErr: true,
+	}}
+
+	for i, c := range cases {
+		err := applyReferenceData(c.req)
+		if c.wantErr {
+			if errors.Root(err) != txbuilder.ErrBadRefData {
+				t.Errorf("case %d: err = %v want %v", i, err, txbuilder.ErrBadRefData)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+			continue
+		}
+		var got []map[string]interface{}
+		if c.req.ReferenceData != nil {
+			got = []map[string]interface{}{{
+				"type":           "set_transaction_reference_data",
+				"reference_data": c.req.ReferenceData,
+			}}
+		}
+		if !reflect.DeepEqual(c.req.Actions, got) {
+			t.Errorf("case %d: actions = %v want %v", i, c.req.Actions, got)
+		}
+	}
+}
+
+func TestApplyFee(t *testing.T) {
+	prog := []byte("fee")
+	cases := []struct {
+		fee     *FeeRequest
+		prog    []byte
+		want    []string
+		wantErr bool
+	}{
+		{fee: nil, prog: prog},
+		{fee: &FeeRequest{AssetID: "a", Amount: 5}, prog: prog, want: []string{"control_program"}},
+		{fee: &FeeRequest{AssetAlias: "a", Amount: 5, AccountAlias: "b"}, prog: prog, want: []string{"control_program", "spend_account"}},
+		{fee: &FeeRequest{AssetID: "a", Amount: 5}, wantErr: true},
+		{fee: &FeeRequest{AssetID: "a"}, prog: prog, wantErr: true},
+		{fee: &FeeRequest{Amount: 5}, prog: prog, wantErr: true},
+	}
+	for i, c := range cases {
+		req := &BuildRequest{Fee: c.fee}
+		err := applyFee(req, c.prog)
+		if c.wantErr {
+			if errors.Root(err) != errBadFee {
+				t.Errorf("case %d: err = %v want %v", i, err, errBadFee)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+			continue
+		}
+		var got []string
+		for _, a := range req.Actions {
+			got = append(got, a["type"].(string))
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("case %d: action types = %v want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestFilterAliases(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	h := &Handler{Assets: asset.NewRegistry(db, c), Accounts: account.NewManager(db, c)}
+	gold := coretest.CreateAsset(ctx, t, h.Assets, nil, "gold", nil)
+	usd := coretest.CreateAsset(ctx, t, h.Assets, nil, "usd", nil)
+	alice := coretest.CreateAccount(ctx, t, h.Accounts, "alice", nil)
+	bob := coretest.CreateAccount(ctx, t, h.Accounts, "bob", nil)
+
+	req := &BuildRequest{Actions: []map[string]interface{}{{
+		"type":                      "offer_auction",
+		"account_alias":             "alice",
+		"destination_account_alias": "bob",
+		"auction": map[string]interface{}{
+			"asset_alias":         "gold",
+			"payment_asset_alias": "usd",
+		},
+	}}}
+	err := h.filterAliases(ctx, req)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	auction := req.Actions[0]["auction"].(map[string]interface{})
+	if auction["asset_id"] != gold {
+		t.Errorf("asset_id = %v, want %v", auction["asset_id"], gold)
+	}
+	if auction["payment_asset_id"] != usd {
+		t.Errorf("payment_asset_id = %v, want %v", auction["payment_asset_id"], usd)
+	}
+	if got := req.Actions[0]["account_id"]; got != alice {
+		t.Errorf("account_id = %v, want %v", got, alice)
+	}
+	if got := req.Actions[0]["destination_account_id"]; got != bob {
+		t.Errorf("destination_account_id = %v, want %v", got, bob)
+	}
+
+	req = &BuildRequest{Actions: []map[string]interface{}{{"type": "issue", "asset_alias": "silver"}}}
+	err = h.filterAliases(ctx, req)
+	if errors.Root(err) != pg.ErrUserInputNotFound {
+		t.Errorf("unknown alias: got error %v, want %v", err, pg.ErrUserInputNotFound)
+	}
+}
+
+func TestRecordSubmittedTxClientToken(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	tx1 := bc.NewTx(bc.TxData{MaxTime: 5})
+	tx2 := bc.NewTx(bc.TxData{MaxTime: 6})
+
+	sub, inserted, err := recordSubmittedTx(ctx, db, tx1, 10, "token")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !inserted || sub.hash != tx1.Hash || sub.height != 10 || sub.maxTime != 5 {
+		t.Fatalf("first submission = %+v, %v", sub, inserted)
+	}
+
+	// A retry under the same token finds the first submission.
+	sub, inserted, err = recordSubmittedTx(ctx, db, tx2, 11, "token")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if inserted || sub.hash != tx1.Hash || sub.height != 10 {
+		t.Fatalf("retry = %+v, %v, want first submission", sub, inserted)
+	}
+
+	// Once the first submission is forgotten, as when it never
+	// reached the pool, the token is free for a corrected tx.
+	forgetSubmittedTx(ctx, db, tx1.Hash)
+	sub, inserted, err = recordSubmittedTx(ctx, db, tx2, 11, "token")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !inserted || sub.hash != tx2.Hash || sub.height != 11 || sub.maxTime != 6 {
+		t.Fatalf("corrected submission = %+v, %v", sub, inserted)
+	}
+}
+
+func TestBuildReleasesReservations(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	assets := asset.NewRegistry(db, c)
+	accounts := account.NewManager(db, c)
+	accounts.IndexAccounts(query.NewIndexer(db, c))
+	h := &Handler{Assets: assets, Accounts: accounts, DB: db, Chain: c}
+
+	acc, err := accounts.Create(ctx, []string{testutil.TestXPub.String()}, 1, "", nil, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	assetID := coretest.CreateAsset(ctx, t, assets, nil, "", nil)
+	out := coretest.IssueAssets(ctx, t, c, assets, accounts, assetID, 100, acc.ID)
+	prottest.MakeBlock(t, c)
+	h.MinOutputAmounts = txbuilder.MinOutputAmounts{assetID: 10}
+
+	build := func(prog []byte, amounts ...uint64) error {
+		actions := []map[string]interface{}{{
+			"type":       "spend_account",
+			"account_id": acc.ID,
+			"asset_id":   assetID.String(),
+			"amount":     100,
+		}}
+		for _, amount := range amounts {
+			actions = append(actions, map[string]interface{}{
+				"type":            "control_program",
+				"asset_id":        assetID.String(),
+				"amount":          amount,
+				"control_program": hex.EncodeToString(prog),
+			})
+		}
+		_, err := h.BuildTransaction(ctx, &BuildRequest{Actions: actions})
+		return err
+	}
+
+	// Each failed build comes after the spend has reserved
+	// the account's only output, and must release it.
+	prog := []byte{byte(vm.OP_TRUE)}
+	err = build(prog, 95, 5)
+	if errors.Root(err) != txbuilder.ErrDustOutput {
+		t.Fatalf("build with dust output: err = %v, want %v", err, txbuilder.ErrDustOutput)
+	}
+	accounts.SingleUsePrograms(true)
+	err = build(out.ControlProgram, 100)
+	if errors.Root(err) != account.ErrProgramReused {
+		t.Fatalf("build paying a used program: err = %v, want %v", err, account.ErrProgramReused)
+	}
+	err = build(prog, 100)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/txbuilder"
+	"chain/encoding/json"
+	"chain/errors"
+	"chain/net/http/httpjson"
+	"chain/protocol/bc"
+)
+
+// TransferLeg moves Amount units of an asset from an account
+// on this core to another account on this core, or to a
+// control program.
+type TransferLeg struct {
+	AccountID               string        `json:"account_id"`
+	AccountAlias            string        `json:"account_alias"`
+	AssetID                 bc.AssetID    `json:"asset_id"`
+	AssetAlias              string        `json:"asset_alias"`
+	Amount                  uint64        `json:"amount"`
+	DestinationAccountID    string        `json:"destination_account_id"`
+	DestinationAccountAlias string        `json:"destination_account_alias"`
+	ControlProgram          json.HexBytes `json:"control_program"`
+}
+
+// TransferRequest is a set of transfer legs to make in
+// a single transaction.
+type TransferRequest struct {
+	Legs          []*TransferLeg         `json:"legs"`
+	TTL           json.Duration          `json:"ttl"`
+	ReferenceData map[string]interface{} `json:"reference_data"`
+}
+
+// Transfer builds one transaction that makes every leg of req,
+// so that they all happen or none do, as in a multi-asset swap
+// between accounts. Legs spending the same asset from the same
+// account are combined into one spend, so the account gets one
+// change output per asset. The template must then be signed
+// and passed to SubmitTransaction.
+func (h *Handler) Transfer(ctx context.Context, req *TransferRequest) (*txbuilder.Template, error) {
+	if len(req.Legs) == 0 {
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "no transfer legs")
+	}
+
+	type source struct {
+		accountID string
+		assetID   bc.AssetID
+	}
+	var (
+		spends  = make(map[source]map[string]interface{})
+		actions []map[string]interface{}
+		dests   []map[string]interface{}
+	)
+	for i, leg := range req.Legs {
+		accountID, err := h.accountID(ctx, leg.AccountID, leg.AccountAlias)
+		if err != nil {
+			return nil, errors.WithDetailf(err, "leg %d", i)
+		}
+		assetID, err := h.assetID(ctx, leg.AssetID, leg.AssetAlias)
+		if err != nil {
+			return nil, errors.WithDetailf(err, "leg %d", i)
+		}
+		destID := leg.DestinationAccountID
+		if destID == "" && leg.DestinationAccountAlias != "" {
+			destID, err = h.accountID(ctx, "", leg.DestinationAccountAlias)
+			if err != nil {
+				return nil, errors.WithDetailf(err, "leg %d", i)
+			}
+		}
+		if (destID == "") == (len(leg.ControlProgram) == 0) {
+			return nil, errors.WithDetailf(httpjson.ErrBadRequest, "leg %d: provide exactly one of a destination account or control_program", i)
+		}
+		if leg.Amount == 0 {
+			return nil, errors.WithDetailf(httpjson.ErrBadRequest, "leg %d: amount must be positive", i)
+		}
+
+		src := source{accountID, assetID}
+		if spend, ok := spends[src]; ok {
+			total := spend["amount"].(uint64) + leg.Amount
+			if total < leg.Amount {
+				return nil, errors.WithDetailf(httpjson.ErrBadRequest, "leg %d: total amount overflows", i)
+			}
+			spend["amount"] = total
+		} else {
+			spend = map[string]interface{}{
+				"type":       "spend_account",
+				"asset_id":   assetID.String(),
+				"amount":     leg.Amount,
+				"account_id": accountID,
+			}
+			spends[src] = spend
+			actions = append(actions, spend)
+		}
+
+		dest := map[string]interface{}{
+			"asset_id": assetID.String(),
+			"amount":   leg.Amount,
+		}
+		if destID != "" {
+			dest["type"] = "control_account"
+			dest["account_id"] = destID
+		} else {
+			dest["type"] = "control_program"
+			dest["control_program"] = leg.ControlProgram
+		}
+		dests = append(dests, dest)
+	}
+
+	return h.BuildTransaction(ctx, &BuildRequest{
+		Actions:       append(actions, dests...),
+		TTL:           req.TTL,
+		ReferenceData: req.ReferenceData,
+	})
+}
+
+// POST /build-transfer
+func (h *Handler) buildTransferLegs(ctx context.Context, req TransferRequest) (*txbuilder.Template, error) {
+	return h.Transfer(ctx, &req)
+}
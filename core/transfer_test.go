@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"chain/core/account"
+	"chain/core/asset"
+	"chain/core/coretest"
+	"chain/core/query"
+	"chain/core/txbuilder"
+	"chain/database/pg/pgtest"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestMultiAssetTransfer(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	h := &Handler{
+		Chain:    c,
+		Assets:   asset.NewRegistry(db, c),
+		Accounts: account.NewManager(db, c),
+		Indexer:  query.NewIndexer(db, c),
+		DB:       db,
+	}
+	h.Accounts.IndexAccounts(h.Indexer)
+
+	asset1 := coretest.CreateAsset(ctx, t, h.Assets, nil, "", nil)
+	asset2 := coretest.CreateAsset(ctx, t, h.Assets, nil, "", nil)
+	alice := coretest.CreateAccount(ctx, t, h.Accounts, "alice", nil)
+	bob := coretest.CreateAccount(ctx, t, h.Accounts, "bob", nil)
+	coretest.IssueAssets(ctx, t, c, h.Assets, h.Accounts, asset1, 100, alice)
+	coretest.IssueAssets(ctx, t, c, h.Assets, h.Accounts, asset2, 100, bob)
+	prottest.MakeBlock(t, c)
+
+	tpl, err := h.Transfer(ctx, &TransferRequest{Legs: []*TransferLeg{
+		{AccountID: alice, AssetID: asset1, Amount: 30, DestinationAccountAlias: "bob"},
+		{AccountAlias: "bob", AssetID: asset2, Amount: 50, DestinationAccountID: alice},
+		{AccountID: alice, AssetID: asset1, Amount: 20, DestinationAccountID: bob},
+	}})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// Alice's two legs share a spend, so each account
+	// spends one output and gets one change output.
+	if got := len(tpl.Transaction.Inputs); got != 2 {
+		t.Errorf("len(inputs) = %d, want 2", got)
+	}
+	if got := len(tpl.Transaction.Outputs); got != 5 {
+		t.Errorf("len(outputs) = %d, want 5", got)
+	}
+
+	coretest.SignTxTemplate(t, ctx, tpl, &testutil.TestXPrv)
+	err = txbuilder.FinalizeTx(ctx, c, bc.NewTx(*tpl.Transaction))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
@@ -7,8 +7,17 @@ import (
 
 	"chain/encoding/json"
 	"chain/protocol/bc"
+	"chain/protocol/vm"
 )
 
+func NewControlProgramAction(amt bc.AssetAmount, program []byte, refData json.Map) Action {
+	return &controlProgramAction{
+		AssetAmount:   amt,
+		Program:       program,
+		ReferenceData: refData,
+	}
+}
+
 func DecodeControlProgramAction(data []byte) (Action, error) {
 	a := new(controlProgramAction)
 	err := stdjson.Unmarshal(data, a)
@@ -26,6 +35,24 @@ func (c *controlProgramAction) Build(ctx context.Context, maxTime time.Time) (*B
 	return &BuildResult{Outputs: []*bc.TxOutput{out}}, nil
 }
 
+func DecodeRetireAction(data []byte) (Action, error) {
+	a := new(retireAction)
+	err := stdjson.Unmarshal(data, a)
+	return a, err
+}
+
+// retireAction removes units of an asset from circulation
+// by sending them to a program that always fails.
+type retireAction struct {
+	bc.AssetAmount
+	ReferenceData json.Map `json:"reference_data"`
+}
+
+func (a *retireAction) Build(ctx context.Context, maxTime time.Time) (*BuildResult, error) {
+	out := bc.NewTxOutput(a.AssetID, a.Amount, []byte{byte(vm.OP_FAIL)}, a.ReferenceData)
+	return &BuildResult{Outputs: []*bc.TxOutput{out}}, nil
+}
+
 func DecodeSetTxRefDataAction(data []byte) (Action, error) {
 	a := new(setTxRefDataAction)
 	err := stdjson.Unmarshal(data, a)
@@ -0,0 +1,75 @@
+package txbuilder
+
+import (
+	"strconv"
+	"strings"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vmutil"
+)
+
+// ErrDustOutput is returned for transactions with an output
+// smaller than its asset's minimum.
+var ErrDustOutput = errors.New("output amount below asset minimum")
+
+// MinOutputAmounts maps asset IDs to the smallest amount
+// allowed in a standard multisig output of that asset.
+//
+// Outputs to any other control program, such as contracts
+// and retirements, are exempt: they are not ordinary holdings,
+// and some contracts legitimately need small amounts.
+type MinOutputAmounts map[bc.AssetID]uint64
+
+// ParseMinOutputAmounts parses items of the form
+// "<asset id>=<amount>".
+func ParseMinOutputAmounts(items []string) (MinOutputAmounts, error) {
+	m, err := parseAssetAmounts(items, "min output amount")
+	return MinOutputAmounts(m), err
+}
+
+// parseAssetAmounts parses items of the form "<asset id>=<amount>"
+// into a map. What names the setting in error messages.
+func parseAssetAmounts(items []string, what string) (map[bc.AssetID]uint64, error) {
+	m := make(map[bc.AssetID]uint64)
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			return nil, errors.New(what + " " + strconv.Quote(item) + " is not of the form assetid=amount")
+		}
+		var assetID bc.AssetID
+		err := assetID.UnmarshalText([]byte(item[:i]))
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing asset id in "+strconv.Quote(item))
+		}
+		amount, err := strconv.ParseUint(item[i+1:], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing amount in "+strconv.Quote(item))
+		}
+		m[assetID] = amount
+	}
+	return m, nil
+}
+
+// Check returns ErrDustOutput if any standard multisig
+// output of tx is below its asset's minimum.
+func (m MinOutputAmounts) Check(tx *bc.TxData) error {
+	if len(m) == 0 || tx == nil {
+		return nil
+	}
+	for i, out := range tx.Outputs {
+		min, ok := m[out.AssetID]
+		if !ok || out.Amount >= min {
+			continue
+		}
+		if _, _, err := vmutil.ParseP2SPMultiSigProgram(out.ControlProgram); err != nil {
+			continue // not a standard program; exempt
+		}
+		return errors.WithDetailf(ErrDustOutput, "output %d has amount %d of asset %s, minimum is %d", i, out.Amount, out.AssetID, min)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package txbuilder
+
+import (
+	"testing"
+
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+func TestMinOutputAmounts(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	acctProg, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pub}, 1)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	contractProg := []byte{0x51} // TRUE
+
+	assetID := bc.AssetID{1}
+	mins, err := ParseMinOutputAmounts([]string{assetID.String() + "=100"})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	cases := []struct {
+		amount uint64
+		prog   []byte
+		ok     bool
+	}{
+		{100, acctProg, true},
+		{99, acctProg, false},
+		{1, contractProg, true},
+	}
+	for i, c := range cases {
+		tx := &bc.TxData{Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, c.amount, c.prog, nil)}}
+		err := mins.Check(tx)
+		if c.ok && err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+		}
+		if !c.ok && errors.Root(err) != ErrDustOutput {
+			t.Errorf("case %d: err = %v want %v", i, err, ErrDustOutput)
+		}
+	}
+
+	// Other assets are unrestricted.
+	tx := &bc.TxData{Outputs: []*bc.TxOutput{bc.NewTxOutput(bc.AssetID{2}, 1, acctProg, nil)}}
+	if err := mins.Check(tx); err != nil {
+		t.Errorf("unrestricted asset: unexpected error %v", err)
+	}
+
+	_, err = ParseMinOutputAmounts([]string{"nope"})
+	if err == nil {
+		t.Error("expected error parsing malformed item")
+	}
+}
@@ -0,0 +1,72 @@
+package txbuilder
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+// ErrInsufficientFee is returned for transactions that
+// don't pay the minimum fee for their size.
+var ErrInsufficientFee = errors.New("transaction fee below minimum")
+
+// FeePolicy describes the fees paid to a network's block generator.
+//
+// The protocol requires every transaction to balance, so a fee
+// cannot be left over for the generator to collect. Instead it is
+// an ordinary output to Program, a control program controlled by
+// the generator's operator.
+type FeePolicy struct {
+	// Program is the control program that fee outputs pay to.
+	Program []byte
+
+	// MinPerKB maps asset IDs to the fee, in units of that
+	// asset, required per 1000 bytes of a transaction's
+	// serialized size. A transaction must pay the minimum in at
+	// least one of the assets. If MinPerKB is empty, no fee is
+	// required.
+	MinPerKB map[bc.AssetID]uint64
+}
+
+// ParseMinFees parses items of the form "<asset id>=<amount>"
+// into a FeePolicy's MinPerKB.
+func ParseMinFees(items []string) (map[bc.AssetID]uint64, error) {
+	return parseAssetAmounts(items, "min fee")
+}
+
+// MinFee returns the fee for a transaction of size bytes
+// at perKB per 1000 bytes, rounded up.
+func MinFee(size int64, perKB uint64) uint64 {
+	n := uint64(size)
+	return n/1000*perKB + (n%1000*perKB+999)/1000
+}
+
+// Check returns ErrInsufficientFee if tx does not pay the
+// minimum fee in any asset of p.MinPerKB.
+func (p FeePolicy) Check(tx *bc.TxData) error {
+	if len(p.MinPerKB) == 0 || tx == nil {
+		return nil
+	}
+	paid := make(map[bc.AssetID]uint64)
+	for _, out := range tx.Outputs {
+		if bytes.Equal(out.ControlProgram, p.Program) {
+			paid[out.AssetID] += out.Amount
+		}
+	}
+
+	size := tx.SerializedSize()
+	var mins []string
+	for assetID, perKB := range p.MinPerKB {
+		min := MinFee(size, perKB)
+		if paid[assetID] >= min {
+			return nil
+		}
+		mins = append(mins, assetID.String()+"="+strconv.FormatUint(min, 10))
+	}
+	sort.Strings(mins)
+	return errors.WithDetailf(ErrInsufficientFee, "a transaction of %d bytes must pay one of %s to the fee control program", size, strings.Join(mins, ", "))
+}
@@ -0,0 +1,72 @@
+package txbuilder
+
+import (
+	"testing"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/testutil"
+)
+
+func TestFeePolicy(t *testing.T) {
+	feeProg := []byte("fee")
+	feeAsset, otherAsset := bc.AssetID{1}, bc.AssetID{2}
+	mins, err := ParseMinFees([]string{feeAsset.String() + "=100000", otherAsset.String() + "=1000000000"})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	p := FeePolicy{Program: feeProg, MinPerKB: mins}
+
+	tx := func(fee uint64, prog []byte) *bc.TxData {
+		return &bc.TxData{
+			Version: 1,
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(bc.AssetID{3}, 10, []byte("dest"), nil),
+				bc.NewTxOutput(feeAsset, fee, prog, nil),
+			},
+		}
+	}
+	min := MinFee(tx(10000, feeProg).SerializedSize(), 100000)
+
+	cases := []struct {
+		tx *bc.TxData
+		ok bool
+	}{
+		{tx(min, feeProg), true},
+		{tx(min-1, feeProg), false},
+		{tx(min, []byte("elsewhere")), false},
+	}
+	for i, c := range cases {
+		err := p.Check(c.tx)
+		if c.ok && err != nil {
+			t.Errorf("case %d: unexpected error %v", i, err)
+		}
+		if !c.ok && errors.Root(err) != ErrInsufficientFee {
+			t.Errorf("case %d: got error %v, want ErrInsufficientFee", i, err)
+		}
+	}
+
+	err = FeePolicy{Program: feeProg}.Check(tx(0, nil))
+	if err != nil {
+		t.Errorf("no minimum: unexpected error %v", err)
+	}
+}
+
+func TestMinFee(t *testing.T) {
+	cases := []struct {
+		size  int64
+		perKB uint64
+		want  uint64
+	}{
+		{0, 1000, 0},
+		{1000, 1000, 1000},
+		{250, 10, 3},
+		{2001, 1, 3},
+	}
+	for _, c := range cases {
+		got := MinFee(c.size, c.perKB)
+		if got != c.want {
+			t.Errorf("MinFee(%d, %d) = %d want %d", c.size, c.perKB, got, c.want)
+		}
+	}
+}
@@ -273,7 +273,7 @@ func bootdb(ctx context.Context, db *sql.DB, t testing.TB) (*testInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	asset, err := assets.Define(ctx, []string{assetPub.String()}, 1, nil, "", nil, nil)
+	asset, err := assets.Define(ctx, []string{assetPub.String()}, 1, nil, "", nil, nil, "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,57 @@
+package txbuilder
+
+// InputProgress reports how far the signatures for one
+// input of a template have come.
+type InputProgress struct {
+	Position int `json:"position"`
+
+	// Quorum is the number of signatures the input needs,
+	// summed over its signature witness components.
+	Quorum int `json:"quorum"`
+
+	// Signed and Unsigned are the xpubs of the input's keys
+	// that have signed and that have not.
+	Signed   []string `json:"signed"`
+	Unsigned []string `json:"unsigned"`
+
+	// Complete is whether each signature witness component
+	// of the input has its quorum of signatures.
+	Complete bool `json:"complete"`
+}
+
+// Progress reports, for each signing instruction of tpl,
+// which keys have signed. Co-signers on other cores can
+// use it to see whose signatures a template still needs.
+// It does not check the signatures.
+func Progress(tpl *Template) []*InputProgress {
+	progress := make([]*InputProgress, 0, len(tpl.SigningInstructions))
+	for _, sigInst := range tpl.SigningInstructions {
+		p := &InputProgress{
+			Position: sigInst.Position,
+			Signed:   []string{},
+			Unsigned: []string{},
+			Complete: true,
+		}
+		for _, c := range sigInst.WitnessComponents {
+			sw, ok := c.(*SignatureWitness)
+			if !ok {
+				continue
+			}
+			p.Quorum += sw.Quorum
+			var n int
+			for i, key := range sw.Keys {
+				if i < len(sw.Sigs) && len(sw.Sigs[i]) > 0 {
+					p.Signed = append(p.Signed, key.XPub)
+					n++
+				} else {
+					p.Unsigned = append(p.Unsigned, key.XPub)
+				}
+			}
+			if n < sw.Quorum {
+				p.Complete = false
+			}
+		}
+		progress = append(progress, p)
+	}
+	return progress
+}
@@ -0,0 +1,47 @@
+package txbuilder
+
+import (
+	"reflect"
+	"testing"
+
+	chainjson "chain/encoding/json"
+)
+
+func TestProgress(t *testing.T) {
+	tpl := &Template{
+		SigningInstructions: []*SigningInstruction{
+			{
+				Position: 0,
+				WitnessComponents: []WitnessComponent{
+					&SignatureWitness{
+						Quorum: 2,
+						Keys:   []KeyID{{XPub: "a"}, {XPub: "b"}, {XPub: "c"}},
+						Sigs:   []chainjson.HexBytes{{1}, nil, {3}},
+					},
+				},
+			},
+			{
+				Position: 1,
+				WitnessComponents: []WitnessComponent{
+					DataWitness{1},
+					&SignatureWitness{
+						Quorum: 1,
+						Keys:   []KeyID{{XPub: "d"}},
+					},
+				},
+			},
+		},
+	}
+
+	got := Progress(tpl)
+	want := []*InputProgress{
+		{Position: 0, Quorum: 2, Signed: []string{"a", "c"}, Unsigned: []string{"b"}, Complete: true},
+		{Position: 1, Quorum: 1, Signed: []string{}, Unsigned: []string{"d"}, Complete: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for i := range got {
+			t.Logf("got %d: %+v", i, got[i])
+		}
+		t.Errorf("progress mismatch")
+	}
+}
@@ -0,0 +1,64 @@
+package txbuilder
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"time"
+
+	"chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+)
+
+var (
+	ErrBadReceiver     = errors.New("invalid receiver")
+	ErrReceiverExpired = errors.New("receiver has expired")
+)
+
+// Receiver is a control program to pay, with the time after
+// which the payee no longer expects payments to it. Payees
+// create receivers and send them to payers out of band, as
+// JSON. Memo is a note from the payee to the payer, such as
+// what the payment is for; it is not put on the blockchain.
+type Receiver struct {
+	ControlProgram json.HexBytes `json:"control_program"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	Memo           string        `json:"memo,omitempty"`
+}
+
+func NewControlReceiverAction(amt bc.AssetAmount, r *Receiver, refData json.Map) Action {
+	return &controlReceiverAction{
+		AssetAmount:   amt,
+		Receiver:      r,
+		ReferenceData: refData,
+	}
+}
+
+func DecodeControlReceiverAction(data []byte) (Action, error) {
+	a := new(controlReceiverAction)
+	err := stdjson.Unmarshal(data, a)
+	return a, err
+}
+
+// controlReceiverAction pays a receiver. Unlike a
+// controlProgramAction, it fails once the receiver
+// has expired.
+type controlReceiverAction struct {
+	bc.AssetAmount
+	Receiver      *Receiver `json:"receiver"`
+	ReferenceData json.Map  `json:"reference_data"`
+}
+
+func (a *controlReceiverAction) Build(ctx context.Context, maxTime time.Time) (*BuildResult, error) {
+	if a.Receiver == nil || len(a.Receiver.ControlProgram) == 0 {
+		return nil, errors.WithDetail(ErrBadReceiver, "missing receiver control program")
+	}
+	if a.Receiver.ExpiresAt.IsZero() {
+		return nil, errors.WithDetail(ErrBadReceiver, "missing receiver expiration")
+	}
+	if time.Now().After(a.Receiver.ExpiresAt) {
+		return nil, errors.WithDetailf(ErrReceiverExpired, "receiver expired at %s", a.Receiver.ExpiresAt.Format(time.RFC3339))
+	}
+	out := bc.NewTxOutput(a.AssetID, a.Amount, a.Receiver.ControlProgram, a.ReferenceData)
+	return &BuildResult{Outputs: []*bc.TxOutput{out}}, nil
+}
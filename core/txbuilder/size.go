@@ -0,0 +1,71 @@
+package txbuilder
+
+import "chain/protocol/vm"
+
+// sigSize is the size of an Ed25519 signature.
+const sigSize = 64
+
+// EstimateSize returns the serialized size, in bytes, that the
+// transaction in tpl will have once each signature witness has
+// a quorum of signatures. Signatures already present are counted
+// as they are; missing ones are counted at their fixed size.
+//
+// The estimate is exact unless the transaction is changed
+// after it is estimated, such as by adding more actions.
+func EstimateSize(tpl *Template) int64 {
+	tx := tpl.Transaction
+	if tx == nil {
+		return 0
+	}
+
+	// Swap in the witnesses as they will be materialized,
+	// then restore the originals.
+	saved := make(map[int][][]byte)
+	for _, sigInst := range tpl.SigningInstructions {
+		if sigInst.Position < 0 || sigInst.Position >= len(tx.Inputs) || tx.Inputs[sigInst.Position] == nil {
+			continue
+		}
+		in := tx.Inputs[sigInst.Position]
+		if _, ok := saved[sigInst.Position]; !ok {
+			saved[sigInst.Position] = in.Arguments()
+		}
+
+		var args [][]byte
+		for _, c := range sigInst.WitnessComponents {
+			if dw, ok := c.(DataWitness); ok {
+				args = append(args, dw)
+				continue
+			}
+			sw, ok := c.(*SignatureWitness)
+			if !ok {
+				continue
+			}
+			args = append(args, vm.Int64Bytes(int64(len(args))))
+			var nsigs int
+			for _, sig := range sw.Sigs {
+				if nsigs == sw.Quorum {
+					break
+				}
+				if len(sig) > 0 {
+					args = append(args, sig)
+					nsigs++
+				}
+			}
+			for ; nsigs < sw.Quorum; nsigs++ {
+				args = append(args, make([]byte, sigSize))
+			}
+			prog := sw.Program
+			if len(prog) == 0 {
+				prog = buildSigProgram(tpl, sigInst.Position)
+			}
+			args = append(args, prog)
+		}
+		in.SetArguments(args)
+	}
+
+	size := tx.SerializedSize()
+	for pos, args := range saved {
+		tx.Inputs[pos].SetArguments(args)
+	}
+	return size
+}
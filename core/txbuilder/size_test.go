@@ -0,0 +1,70 @@
+package txbuilder
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+
+	"chain/crypto/ed25519"
+	"chain/crypto/ed25519/chainkd"
+	"chain/encoding/json"
+	"chain/protocol/bc"
+	"chain/protocol/vmutil"
+)
+
+func TestEstimateSize(t *testing.T) {
+	var initialBlockHash bc.Hash
+	var (
+		prvs []chainkd.XPrv
+		pubs []chainkd.XPub
+		keys []KeyID
+	)
+	for i := 0; i < 3; i++ {
+		prv, pub, err := chainkd.NewXKeys(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prvs = append(prvs, prv)
+		pubs = append(pubs, pub)
+		keys = append(keys, KeyID{XPub: pub.String(), DerivationPath: []json.HexBytes{{0, 0, 0, 0}}})
+	}
+	issuanceProg, _ := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pubs[0].PublicKey(), pubs[1].PublicKey(), pubs[2].PublicKey()}, 2)
+	assetID := bc.ComputeAssetID(issuanceProg, initialBlockHash, 1)
+	tpl := &Template{
+		Transaction: &bc.TxData{
+			Version: 1,
+			Inputs: []*bc.TxInput{
+				bc.NewIssuanceInput(nil, 100, nil, initialBlockHash, issuanceProg, nil),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(assetID, 100, []byte{1}, nil),
+			},
+		},
+	}
+	sw := &SignatureWitness{Quorum: 2, Keys: keys}
+	tpl.SigningInstructions = []*SigningInstruction{{WitnessComponents: []WitnessComponent{sw}}}
+
+	unsigned := tpl.Transaction.SerializedSize()
+	est := EstimateSize(tpl)
+	if est <= unsigned {
+		t.Errorf("estimate %d is not larger than unsigned size %d", est, unsigned)
+	}
+	if got := tpl.Transaction.SerializedSize(); got != unsigned {
+		t.Errorf("size after estimate = %d want %d (witness not restored)", got, unsigned)
+	}
+
+	sw.Program = buildSigProgram(tpl, 0)
+	h := sha3.Sum256(sw.Program)
+	sw.Sigs = []json.HexBytes{prvs[0].Sign(h[:]), nil, prvs[2].Sign(h[:])}
+	if got := EstimateSize(tpl); got != est {
+		t.Errorf("estimate after signing = %d want %d", got, est)
+	}
+
+	err := materializeWitnesses(tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tpl.Transaction.SerializedSize(); got != est {
+		t.Errorf("signed size = %d want estimate %d", got, est)
+	}
+}
@@ -20,6 +20,7 @@ var (
 	ErrBadRefData          = errors.New("transaction reference data does not match previous template's reference data")
 	ErrBadTxInputIdx       = errors.New("unsigned tx missing input")
 	ErrBadWitnessComponent = errors.New("invalid witness component")
+	ErrBadSigHashMode      = errors.New("invalid sighash mode")
 	ErrBadAmount           = errors.New("bad asset amount")
 	ErrBlankCheck          = errors.New("unsafe transaction: leaves assets free to control")
 )
@@ -123,9 +124,13 @@ func KeyIDs(xpubs []chainkd.XPub, path [][]byte) []KeyID {
 }
 
 func Sign(ctx context.Context, tpl *Template, xpubs []string, signFn SignFunc) error {
+	err := checkSigHashModes(tpl)
+	if err != nil {
+		return err
+	}
 	for i, sigInst := range tpl.SigningInstructions {
 		for j, c := range sigInst.WitnessComponents {
-			err := c.Sign(ctx, tpl, i, xpubs, signFn)
+			err = c.Sign(ctx, tpl, i, xpubs, signFn)
 			if err != nil {
 				return errors.WithDetailf(err, "adding signature(s) to witness component %d of input %d", j, i)
 			}
@@ -134,6 +139,57 @@ func Sign(ctx context.Context, tpl *Template, xpubs []string, signFn SignFunc) e
 	return materializeWitnesses(tpl)
 }
 
+// SignWithMode is like Sign, but first sets the sighash mode of
+// each signing instruction that xpubs can sign and whose signature
+// program is still to be inferred. Instructions signed by others
+// keep their modes.
+//
+// It supports trading with a locked template: one party builds a
+// template paying what it offers and receiving what it asks for,
+// and signs with SigHashAnyoneCanPay, which binds its inputs to
+// those outputs. The other party adds its own actions to the
+// template and signs with the default mode.
+func SignWithMode(ctx context.Context, tpl *Template, xpubs []string, mode SigHashMode, signFn SignFunc) error {
+	switch mode {
+	case SigHashAll, SigHashAnyoneCanPay, SigHashSingleOutput:
+	default:
+		return errors.WithDetailf(ErrBadSigHashMode, "unknown sighash mode '%s'", mode)
+	}
+	for _, sigInst := range tpl.SigningInstructions {
+		for _, c := range sigInst.WitnessComponents {
+			sw, ok := c.(*SignatureWitness)
+			if !ok || len(sw.Program) > 0 {
+				continue
+			}
+			for _, k := range sw.Keys {
+				if contains(xpubs, k.XPub) {
+					sigInst.SigHashMode = mode
+					break
+				}
+			}
+		}
+	}
+	return Sign(ctx, tpl, xpubs, signFn)
+}
+
+// SigningXPubs returns the xpubs of every key
+// that can sign some input of tpl.
+func SigningXPubs(tpl *Template) []string {
+	var xpubs []string
+	for _, sigInst := range tpl.SigningInstructions {
+		for _, c := range sigInst.WitnessComponents {
+			sw, ok := c.(*SignatureWitness)
+			if !ok {
+				continue
+			}
+			for _, k := range sw.Keys {
+				xpubs = append(xpubs, k.XPub)
+			}
+		}
+	}
+	return xpubs
+}
+
 func checkBlankCheck(tx *bc.TxData) error {
 	assetMap := make(map[bc.AssetID]int64)
 	var ok bool
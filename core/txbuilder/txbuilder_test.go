@@ -1,6 +1,7 @@
 package txbuilder
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
@@ -471,3 +472,60 @@ func TestCheckBlankCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestSignWithMode(t *testing.T) {
+	ctx := context.Background()
+	_, other, err := chainkd.NewXKeys(nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	xpub := testutil.TestXPrv.XPub()
+	path := [][]byte{{1}}
+	assetID := bc.AssetID{1}
+
+	var sigInsts []*SigningInstruction
+	for i, key := range []chainkd.XPub{xpub, other} {
+		si := &SigningInstruction{Position: i, AssetAmount: bc.AssetAmount{AssetID: assetID, Amount: 5}}
+		si.AddWitnessKeys(KeyIDs([]chainkd.XPub{key}, path), 1)
+		sigInsts = append(sigInsts, si)
+	}
+	tpl := &Template{
+		Transaction: &bc.TxData{
+			Version: 1,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{2}, 0, nil, assetID, 5, nil, nil),
+				bc.NewSpendInput(bc.Hash{3}, 0, nil, assetID, 5, nil, nil),
+			},
+			Outputs: []*bc.TxOutput{bc.NewTxOutput(assetID, 10, []byte("dest"), nil)},
+		},
+		SigningInstructions: sigInsts,
+	}
+
+	err = SignWithMode(ctx, tpl, []string{xpub.String()}, SigHashAnyoneCanPay, func(_ context.Context, _ string, path [][]byte, data [32]byte) ([]byte, error) {
+		return testutil.TestXPrv.Derive(path).Sign(data[:]), nil
+	})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// Only the instruction this key signs takes the mode, and its
+	// program commits to the outputs rather than the whole tx.
+	if got := sigInsts[0].SigHashMode; got != SigHashAnyoneCanPay {
+		t.Errorf("signed instruction mode = %q, want %q", got, SigHashAnyoneCanPay)
+	}
+	if got := sigInsts[1].SigHashMode; got != SigHashAll {
+		t.Errorf("other instruction mode = %q, want %q", got, SigHashAll)
+	}
+	prog := sigInsts[0].WitnessComponents[0].(*SignatureWitness).Program
+	if want := buildSigProgram(tpl, 0); !bytes.Equal(prog, want) {
+		t.Errorf("program = %x, want %x", prog, want)
+	}
+	if h := tpl.Hash(0); bytes.Contains(prog, h[:]) {
+		t.Error("program commits to the whole transaction")
+	}
+
+	err = SignWithMode(ctx, tpl, nil, "bogus", nil)
+	if errors.Root(err) != ErrBadSigHashMode {
+		t.Errorf("SignWithMode(bogus) error = %v, want %v", err, ErrBadSigHashMode)
+	}
+}
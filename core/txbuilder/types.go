@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"time"
 
+	chainjson "chain/encoding/json"
 	"chain/errors"
 	"chain/protocol/bc"
 )
@@ -27,9 +28,36 @@ type Template struct {
 	// as a whole, and any change to the tx invalidates the signature.
 	AllowAdditional bool `json:"allow_additional_actions"`
 
+	// EstimatedSize is the serialized size the transaction will
+	// have once it is fully signed. It is set when the template
+	// is built or signed. See EstimateSize.
+	EstimatedSize int64 `json:"estimated_size"`
+
+	// ClientToken, if set, makes submission idempotent. Submitting
+	// a template with the same token as an earlier submission
+	// returns the earlier transaction instead of submitting again.
+	ClientToken string `json:"client_token,omitempty"`
+
+	// Labels are caller-assigned names for the transaction, such
+	// as a batch ID. They are not part of the transaction. When
+	// the template is submitted, they are saved and added to the
+	// transaction's annotations once it is indexed.
+	Labels map[string]string `json:"labels,omitempty"`
+
 	sigHasher *bc.SigHasher
 }
 
+func (t *Template) UnmarshalJSON(b []byte) error {
+	type template Template // lose the UnmarshalJSON method
+	var pre template
+	err := json.Unmarshal(b, &pre)
+	if err != nil {
+		return err
+	}
+	*t = Template(pre)
+	return checkSigHashModes(t)
+}
+
 func (t *Template) Hash(idx int) bc.Hash {
 	if t.sigHasher == nil {
 		t.sigHasher = bc.NewSigHasher(t.Transaction)
@@ -42,8 +70,33 @@ type SigningInstruction struct {
 	Position int `json:"position"`
 	bc.AssetAmount
 	WitnessComponents []WitnessComponent `json:"witness_components,omitempty"`
+
+	// SigHashMode selects what an inferred signature program for
+	// this input commits to. See SigHashMode.
+	SigHashMode SigHashMode `json:"sighash_mode,omitempty"`
 }
 
+// SigHashMode selects the parts of a transaction that a signature
+// on one input commits to, when the signature program is inferred.
+type SigHashMode string
+
+const (
+	// SigHashAll commits to the whole transaction, or, if the
+	// template allows additional actions, to the current input
+	// and all current outputs. It is the default.
+	SigHashAll SigHashMode = ""
+
+	// SigHashAnyoneCanPay commits to the current input and all
+	// current outputs, whether or not the template allows
+	// additional actions. Others may add inputs and outputs.
+	SigHashAnyoneCanPay SigHashMode = "anyone_can_pay"
+
+	// SigHashSingleOutput commits to the current input and only
+	// the output at the same position, which must exist. Others
+	// may add inputs and outputs and change the other outputs.
+	SigHashSingleOutput SigHashMode = "single_output"
+)
+
 func (si *SigningInstruction) UnmarshalJSON(b []byte) error {
 	var pre struct {
 		bc.AssetAmount
@@ -51,21 +104,34 @@ func (si *SigningInstruction) UnmarshalJSON(b []byte) error {
 		WitnessComponents []struct {
 			Type string
 			SignatureWitness
+			Data chainjson.HexBytes `json:"data"`
 		} `json:"witness_components"`
+		SigHashMode SigHashMode `json:"sighash_mode"`
 	}
 	err := json.Unmarshal(b, &pre)
 	if err != nil {
 		return err
 	}
 
+	switch pre.SigHashMode {
+	case SigHashAll, SigHashAnyoneCanPay, SigHashSingleOutput:
+	default:
+		return errors.WithDetailf(ErrBadSigHashMode, "unknown sighash mode '%s'", pre.SigHashMode)
+	}
+
 	si.AssetAmount = pre.AssetAmount
 	si.Position = pre.Position
+	si.SigHashMode = pre.SigHashMode
 	si.WitnessComponents = make([]WitnessComponent, 0, len(pre.WitnessComponents))
 	for i, w := range pre.WitnessComponents {
-		if w.Type != "signature" {
+		switch w.Type {
+		case "signature":
+			si.WitnessComponents = append(si.WitnessComponents, &w.SignatureWitness)
+		case "data":
+			si.WitnessComponents = append(si.WitnessComponents, DataWitness(w.Data))
+		default:
 			return errors.WithDetailf(ErrBadWitnessComponent, "witness component %d has unknown type '%s'", i, w.Type)
 		}
-		si.WitnessComponents = append(si.WitnessComponents, &w.SignatureWitness)
 	}
 	return nil
 }
@@ -0,0 +1,209 @@
+package txbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"chain/crypto/ed25519/chainkd"
+	"chain/protocol/bc"
+	"chain/testutil"
+)
+
+// The Java SDK's offline signer (package com.chain.signing) is a
+// port of Sign. It is tested against vectors produced here, so
+// that the two cannot drift apart. After a deliberate change to
+// signing, regenerate the vectors with
+//
+//	go test chain/core/txbuilder -run TestSigningVectors -update-vectors
+var updateVectors = flag.Bool("update-vectors", false, "rewrite the Java SDK signing vectors")
+
+const vectorsFile = "../../sdk/java/src/test/resources/signing-vectors.json"
+
+type signingVector struct {
+	Name string `json:"name"`
+
+	// XPrv is the root key to sign with. Keys in the template
+	// that don't match its xpub are left unsigned.
+	XPrv chainkd.XPrv `json:"xprv"`
+
+	// Template is the unsigned template, as returned by build.
+	Template json.RawMessage `json:"template"`
+
+	// SigHashes are the sighashes of the transaction's inputs,
+	// in order.
+	SigHashes []bc.Hash `json:"sighashes"`
+
+	// SignedTemplate is Template after Sign.
+	SignedTemplate json.RawMessage `json:"signed_template"`
+}
+
+func TestSigningVectors(t *testing.T) {
+	vectors := makeSigningVectors(t)
+	got, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	got = append(got, '\n')
+
+	if *updateVectors {
+		err = ioutil.WriteFile(vectorsFile, got, 0644)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(vectorsFile)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; rerun this test with -update-vectors", vectorsFile)
+	}
+}
+
+func makeSigningVectors(t *testing.T) []*signingVector {
+	xprv, err := chainkd.NewXPrv(bytes.NewReader(bytes.Repeat([]byte{7}, 32)))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, other, err := chainkd.NewXKeys(bytes.NewReader(bytes.Repeat([]byte{8}, 32)))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	xpub := xprv.XPub()
+	path := [][]byte{{0, 0, 0, 0, 0, 0, 0, 1}, {0, 0, 0, 0, 0, 0, 0, 2}}
+	keys := KeyIDs([]chainkd.XPub{xpub}, path)
+
+	assetID := bc.AssetID{1}
+	issuance := bc.NewIssuanceInput([]byte{9, 9, 9, 9, 9, 9, 9, 9}, 100, []byte(`{"memo":"issue"}`), bc.Hash{2}, []byte{0x51}, nil)
+
+	sigInst := func(pos int, mode SigHashMode, keys []KeyID, quorum int) *SigningInstruction {
+		si := &SigningInstruction{Position: pos, SigHashMode: mode}
+		si.AssetAmount = bc.AssetAmount{AssetID: assetID, Amount: 10}
+		si.AddWitnessKeys(keys, quorum)
+		return si
+	}
+	clause := sigInst(0, SigHashAnyoneCanPay, keys, 1)
+	clause.WitnessComponents = append([]WitnessComponent{DataWitness{1}}, clause.WitnessComponents...)
+
+	cases := []struct {
+		name string
+		tpl  *Template
+	}{{
+		name: "whole transaction",
+		tpl: &Template{
+			Transaction: &bc.TxData{
+				Version: 1,
+				Inputs: []*bc.TxInput{
+					bc.NewSpendInput(bc.Hash{3}, 1, nil, assetID, 10, []byte("spend"), nil),
+				},
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 7, []byte("dest"), nil),
+					bc.NewTxOutput(assetID, 3, []byte("change"), nil),
+				},
+			},
+			SigningInstructions: []*SigningInstruction{sigInst(0, SigHashAll, keys, 1)},
+		},
+	}, {
+		name: "allow additional actions",
+		tpl: &Template{
+			Transaction: &bc.TxData{
+				Version:       1,
+				MinTime:       1477000000000,
+				MaxTime:       1477000300000,
+				ReferenceData: []byte(`{"invoice":"1234"}`),
+				Inputs: []*bc.TxInput{
+					issuance,
+					bc.NewSpendInput(bc.Hash{4}, 200, nil, assetID, 10, []byte("spend"), []byte(`{"from":"alice"}`)),
+				},
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 110, bytes.Repeat([]byte{0xab}, 80), []byte(`{"to":"bob"}`)),
+				},
+			},
+			SigningInstructions: []*SigningInstruction{
+				sigInst(0, SigHashAll, keys, 1),
+				sigInst(1, SigHashAll, keys, 1),
+			},
+			AllowAdditional: true,
+		},
+	}, {
+		name: "anyone can pay with a data witness",
+		tpl: &Template{
+			Transaction: &bc.TxData{
+				Version: 1,
+				MaxTime: 1477000300000,
+				Inputs: []*bc.TxInput{
+					bc.NewSpendInput(bc.Hash{5}, 0, nil, assetID, 10, []byte("contract"), nil),
+				},
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 10, []byte("dest"), []byte("ref")),
+				},
+			},
+			SigningInstructions: []*SigningInstruction{clause},
+		},
+	}, {
+		name: "single output and multisig",
+		tpl: &Template{
+			Transaction: &bc.TxData{
+				Version: 1,
+				Inputs: []*bc.TxInput{
+					bc.NewSpendInput(bc.Hash{6}, 0, nil, assetID, 10, []byte("spend"), nil),
+					bc.NewSpendInput(bc.Hash{6}, 1, nil, assetID, 20, []byte("multisig"), nil),
+				},
+				Outputs: []*bc.TxOutput{
+					bc.NewTxOutput(assetID, 10, []byte("first"), nil),
+					bc.NewTxOutput(assetID, 20, []byte("second"), nil),
+				},
+			},
+			SigningInstructions: []*SigningInstruction{
+				sigInst(0, SigHashAll, keys, 1),
+				sigInst(1, SigHashSingleOutput, KeyIDs([]chainkd.XPub{other, xpub}, path), 2),
+			},
+		},
+	}}
+
+	signFn := func(_ context.Context, _ string, path [][]byte, h [32]byte) ([]byte, error) {
+		return xprv.Derive(path).Sign(h[:]), nil
+	}
+
+	var vectors []*signingVector
+	for _, c := range cases {
+		unsigned, err := json.Marshal(c.tpl)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+
+		// Sign a copy decoded from JSON, as a signer
+		// receiving the template from build would.
+		tpl := new(Template)
+		err = json.Unmarshal(unsigned, tpl)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		var sigHashes []bc.Hash
+		for i := range tpl.Transaction.Inputs {
+			sigHashes = append(sigHashes, tpl.Hash(i))
+		}
+		err = Sign(context.Background(), tpl, []string{xpub.String()}, signFn)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		signed, err := json.Marshal(tpl)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+
+		vectors = append(vectors, &signingVector{
+			Name:           c.name,
+			XPrv:           xprv,
+			Template:       unsigned,
+			SigHashes:      sigHashes,
+			SignedTemplate: signed,
+		})
+	}
+	return vectors
+}
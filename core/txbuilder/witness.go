@@ -81,6 +81,11 @@ type (
 		Sigs []chainjson.HexBytes `json:"signatures"`
 	}
 
+	// DataWitness is a witness component that adds a fixed
+	// argument, such as a contract clause selector, ahead of
+	// any signature witness for the same input.
+	DataWitness chainjson.HexBytes
+
 	KeyID struct {
 		XPub           string               `json:"xpub"`
 		DerivationPath []chainjson.HexBytes `json:"derivation_path"`
@@ -99,6 +104,8 @@ var ErrEmptyProgram = errors.New("empty signature program")
 //  - the mintime and maxtime of the transaction (if non-zero)
 //  - the outpoint and (if non-empty) reference data of the current input
 //  - the assetID, amount, control program, and (if non-empty) reference data of each output.
+// The signing instruction's SigHashMode can narrow the outputs to one,
+// or make a program of this form even if tpl.AllowAdditional is false.
 func (sw *SignatureWitness) Sign(ctx context.Context, tpl *Template, index int, xpubs []string, signFn SignFunc) error {
 	// Compute the predicate to sign. This is either a
 	// txsighash program if tpl.AllowAdditional is false (i.e., the tx is complete
@@ -151,7 +158,8 @@ func contains(list []string, key string) bool {
 }
 
 func buildSigProgram(tpl *Template, index int) []byte {
-	if !tpl.AllowAdditional {
+	mode := sigHashMode(tpl, index)
+	if mode == SigHashAll && !tpl.AllowAdditional {
 		h := tpl.Hash(index)
 		builder := vmutil.NewBuilder()
 		builder.AddData(h[:])
@@ -179,6 +187,9 @@ func buildSigProgram(tpl *Template, index int) []byte {
 	constraints = append(constraints, refdataConstraint{inp.ReferenceData, false})
 
 	for i, out := range tpl.Transaction.Outputs {
+		if mode == SigHashSingleOutput && i != index {
+			continue
+		}
 		c := &payConstraint{
 			Index:       i,
 			AssetAmount: out.AssetAmount,
@@ -201,6 +212,33 @@ func buildSigProgram(tpl *Template, index int) []byte {
 	return program
 }
 
+// checkSigHashModes verifies that each signing instruction of
+// tpl in SigHashSingleOutput mode has an output at its position
+// to commit to. Without one, an inferred program would commit to
+// no output at all, and anyone could redirect the input's value.
+func checkSigHashModes(tpl *Template) error {
+	if tpl.Transaction == nil {
+		return nil
+	}
+	for _, sigInst := range tpl.SigningInstructions {
+		if sigInst.SigHashMode == SigHashSingleOutput && sigInst.Position >= len(tpl.Transaction.Outputs) {
+			return errors.WithDetailf(ErrBadSigHashMode, "input %d has no output at its position for sighash mode '%s'", sigInst.Position, SigHashSingleOutput)
+		}
+	}
+	return nil
+}
+
+// sigHashMode returns the sighash mode of the signing
+// instruction for the input at index.
+func sigHashMode(tpl *Template, index int) SigHashMode {
+	for _, sigInst := range tpl.SigningInstructions {
+		if sigInst.Position == index {
+			return sigInst.SigHashMode
+		}
+	}
+	return SigHashAll
+}
+
 func (sw SignatureWitness) Materialize(tpl *Template, index int, args *[][]byte) error {
 	// This is the value of N for the CHECKPREDICATE call. The code
 	// assumes that everything already in the arg list before this call
@@ -241,3 +279,28 @@ func (si *SigningInstruction) AddWitnessKeys(keys []KeyID, quorum int) {
 	}
 	si.WitnessComponents = append(si.WitnessComponents, sw)
 }
+
+func (dw DataWitness) Sign(context.Context, *Template, int, []string, SignFunc) error {
+	return nil
+}
+
+func (dw DataWitness) Materialize(tpl *Template, index int, args *[][]byte) error {
+	*args = append(*args, dw)
+	return nil
+}
+
+func (dw DataWitness) MarshalJSON() ([]byte, error) {
+	obj := struct {
+		Type string             `json:"type"`
+		Data chainjson.HexBytes `json:"data"`
+	}{
+		Type: "data",
+		Data: chainjson.HexBytes(dw),
+	}
+	return json.Marshal(obj)
+}
+
+// AddDataWitness appends a data witness component holding data.
+func (si *SigningInstruction) AddDataWitness(data []byte) {
+	si.WitnessComponents = append(si.WitnessComponents, DataWitness(data))
+}
@@ -2,6 +2,7 @@ package txbuilder
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"reflect"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 
 	chainjson "chain/encoding/json"
+	"chain/errors"
 	"chain/protocol/bc"
 	"chain/protocol/vm"
 )
@@ -37,6 +39,74 @@ func TestInferConstraints(t *testing.T) {
 	}
 }
 
+func TestInferConstraintsSingleOutput(t *testing.T) {
+	tpl := &Template{
+		Transaction: &bc.TxData{
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{}, 1, nil, bc.AssetID{}, 123, nil, []byte{1}),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(bc.AssetID{}, 123, []byte{10, 11, 12}, nil),
+				bc.NewTxOutput(bc.AssetID{}, 5, []byte{13}, nil),
+			},
+			MinTime: 1,
+			MaxTime: 2,
+		},
+		SigningInstructions: []*SigningInstruction{{
+			Position:    0,
+			SigHashMode: SigHashSingleOutput,
+		}},
+	}
+	prog := buildSigProgram(tpl, 0)
+	want, err := vm.Assemble("MINTIME 1 GREATERTHANOREQUAL VERIFY MAXTIME 2 LESSTHANOREQUAL VERIFY 0x0000000000000000000000000000000000000000000000000000000000000000 1 OUTPOINT ROT NUMEQUAL VERIFY EQUAL VERIFY 0x2767f15c8af2f2c7225d5273fdd683edc714110a987d1054697c348aed4e6cc7 REFDATAHASH EQUAL VERIFY 0 0 123 0x0000000000000000000000000000000000000000000000000000000000000000 1 0x0a0b0c CHECKOUTPUT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, prog) {
+		t.Errorf("expected sig witness program %x, got %x", want, prog)
+	}
+}
+
+func TestSingleOutputWithoutOutput(t *testing.T) {
+	tpl := &Template{
+		Transaction: &bc.TxData{
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{}, 1, nil, bc.AssetID{}, 123, nil, nil),
+				bc.NewSpendInput(bc.Hash{}, 2, nil, bc.AssetID{}, 5, nil, nil),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(bc.AssetID{}, 128, []byte{10, 11, 12}, nil),
+			},
+		},
+		SigningInstructions: []*SigningInstruction{{
+			Position: 1,
+			WitnessComponents: []WitnessComponent{
+				&SignatureWitness{Quorum: 1, Keys: []KeyID{{XPub: "a"}}},
+			},
+			SigHashMode: SigHashSingleOutput,
+		}},
+	}
+
+	signFn := func(context.Context, string, [][]byte, [32]byte) ([]byte, error) {
+		t.Fatal("signed an input with no output to commit to")
+		return nil, nil
+	}
+	err := Sign(context.Background(), tpl, []string{"a"}, signFn)
+	if errors.Root(err) != ErrBadSigHashMode {
+		t.Errorf("Sign error = %v, want %v", err, ErrBadSigHashMode)
+	}
+
+	b, err := json.Marshal(tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Template
+	err = json.Unmarshal(b, &got)
+	if errors.Root(err) != ErrBadSigHashMode {
+		t.Errorf("Unmarshal error = %v, want %v", err, ErrBadSigHashMode)
+	}
+}
+
 func TestWitnessJSON(t *testing.T) {
 	si := &SigningInstruction{
 		AssetAmount: bc.AssetAmount{
@@ -45,6 +115,7 @@ func TestWitnessJSON(t *testing.T) {
 		},
 		Position: 17,
 		WitnessComponents: []WitnessComponent{
+			DataWitness{1, 2},
 			&SignatureWitness{
 				Quorum: 4,
 				Keys: []KeyID{{
@@ -54,6 +125,7 @@ func TestWitnessJSON(t *testing.T) {
 				Sigs: []chainjson.HexBytes{{8, 9, 10}},
 			},
 		},
+		SigHashMode: SigHashAnyoneCanPay,
 	}
 
 	b, err := json.MarshalIndent(si, "", "  ")
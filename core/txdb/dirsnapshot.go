@@ -0,0 +1,144 @@
+package txdb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"chain/database/pg"
+	"chain/errors"
+)
+
+const snapshotFilePrefix = "snapshot-"
+
+// dirSnapshots is an experimental SnapshotStore that keeps each
+// snapshot in its own file in a directory, keyed by height.
+type dirSnapshots struct {
+	dir string
+
+	mu sync.Mutex // serializes writes
+}
+
+// NewDirSnapshotStore returns a SnapshotStore that keeps snapshots
+// as files in dir, creating dir if necessary. Writing a snapshot is
+// much cheaper than inserting it into Postgres, but the snapshots
+// are only on this machine's disk, so it is suitable for a single
+// Chain Core process.
+//
+// A Chain Core that switches snapshot stores starts with no
+// snapshot, and recovers its state by replaying every block.
+//
+// This is experimental.
+func NewDirSnapshotStore(dir string) (SnapshotStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating snapshot directory")
+	}
+	return &dirSnapshots{dir: dir}, nil
+}
+
+func (d *dirSnapshots) path(height uint64) string {
+	// Zero-pad the height so that names sort by height.
+	return filepath.Join(d.dir, fmt.Sprintf("%s%020d", snapshotFilePrefix, height))
+}
+
+func (d *dirSnapshots) SaveSnapshot(ctx context.Context, height uint64, data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Write to a temporary file and rename it into place, so that
+	// a crash never leaves a partial snapshot behind.
+	f, err := ioutil.TempFile(d.dir, "tmp-")
+	if err != nil {
+		return errors.Wrap(err, "creating snapshot file")
+	}
+	defer os.Remove(f.Name()) // no-op after a successful rename
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "writing snapshot file")
+	}
+	err = os.Rename(f.Name(), d.path(height))
+	return errors.Wrap(err, "renaming snapshot file")
+}
+
+// latest returns the name of the file of the latest
+// snapshot, or "" if there are none.
+func (d *dirSnapshots) latest() (string, error) {
+	f, err := os.Open(d.dir)
+	if err != nil {
+		return "", errors.Wrap(err, "opening snapshot directory")
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return "", errors.Wrap(err, "reading snapshot directory")
+	}
+
+	var snapshots []string
+	for _, name := range names {
+		if strings.HasPrefix(name, snapshotFilePrefix) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	if len(snapshots) == 0 {
+		return "", nil
+	}
+	sort.Strings(snapshots)
+	return snapshots[len(snapshots)-1], nil
+}
+
+func parseSnapshotName(name string) (height uint64, err error) {
+	_, err = fmt.Sscanf(name, snapshotFilePrefix+"%d", &height)
+	return height, errors.Wrapf(err, "parsing snapshot file name %q", name)
+}
+
+func (d *dirSnapshots) LatestSnapshot(ctx context.Context) ([]byte, uint64, error) {
+	name, err := d.latest()
+	if err != nil || name == "" {
+		return nil, 0, err
+	}
+	height, err := parseSnapshotName(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "reading snapshot file")
+	}
+	return data, height, nil
+}
+
+func (d *dirSnapshots) LatestSnapshotInfo(ctx context.Context) (height uint64, size uint64, err error) {
+	name, err := d.latest()
+	if err != nil || name == "" {
+		return 0, 0, err
+	}
+	height, err = parseSnapshotName(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	fi, err := os.Stat(filepath.Join(d.dir, name))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "reading snapshot file")
+	}
+	return height, uint64(fi.Size()), nil
+}
+
+func (d *dirSnapshots) GetSnapshot(ctx context.Context, height uint64) ([]byte, error) {
+	data, err := ioutil.ReadFile(d.path(height))
+	if os.IsNotExist(err) {
+		return nil, pg.ErrUserInputNotFound
+	}
+	return data, errors.Wrap(err, "reading snapshot file")
+}
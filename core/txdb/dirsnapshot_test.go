@@ -0,0 +1,93 @@
+package txdb
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/state"
+)
+
+func TestDirSnapshotStore(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ss, err := NewDirSnapshotStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, height, err := getStateSnapshot(ctx, ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 0 || snapshot.Tree.RootHash() != state.Empty().Tree.RootHash() {
+		t.Fatalf("getStateSnapshot(empty dir) = height %d, root %s; want empty snapshot", height, snapshot.Tree.RootHash())
+	}
+
+	// Heights 9 and 10 check that the latest snapshot
+	// is found by height, not by the order they were saved.
+	for _, h := range []uint64{10, 9} {
+		err = snapshot.Tree.Insert([]byte{byte(h)}, []byte{byte(h)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.Issuances[bc.Hash{byte(h)}] = h * 1000
+		err = storeStateSnapshot(ctx, ss, snapshot, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, height, err := getStateSnapshot(ctx, ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 10 {
+		t.Errorf("latest height = %d, want 10", height)
+	}
+	if got.Tree.Contains([]byte{9}, []byte{9}) {
+		t.Error("latest snapshot contains the height 9 insert, want the height 10 snapshot")
+	}
+	if !reflect.DeepEqual(got.Issuances, state.PriorIssuances{bc.Hash{10}: 10000}) {
+		t.Errorf("latest snapshot issuances = %v", got.Issuances)
+	}
+
+	data, err := ss.GetSnapshot(ctx, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = DecodeSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Tree.RootHash() != snapshot.Tree.RootHash() || !reflect.DeepEqual(got.Issuances, snapshot.Issuances) {
+		t.Error("GetSnapshot(9) differs from the saved snapshot")
+	}
+
+	height, size, err := ss.LatestSnapshotInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, _, err := ss.LatestSnapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 10 || size != uint64(len(latest)) {
+		t.Errorf("LatestSnapshotInfo() = %d, %d; want 10, %d", height, size, len(latest))
+	}
+
+	_, err = ss.GetSnapshot(ctx, 11)
+	if errors.Root(err) != pg.ErrUserInputNotFound {
+		t.Errorf("GetSnapshot(11) error = %v, want %v", err, pg.ErrUserInputNotFound)
+	}
+}
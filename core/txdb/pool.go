@@ -2,15 +2,31 @@ package txdb
 
 import (
 	"context"
+	"time"
 
 	"chain/database/pg"
+	"chain/database/sql"
 	"chain/errors"
 	"chain/protocol/bc"
 )
 
+const insertPoolTxQ = `
+	INSERT INTO pool_txs (tx_hash, data, min_time) VALUES ($1, $2, $3)
+	ON CONFLICT (tx_hash) DO NOTHING
+`
+
 // A Pool encapsulates storage of the pending transaction pool.
 type Pool struct {
 	db pg.DB
+
+	// inserts is nil unless the Pool batches inserts;
+	// see NewBatchPool.
+	inserts chan *poolInsert
+}
+
+type poolInsert struct {
+	tx   *bc.Tx
+	errc chan error
 }
 
 // NewPool creates and returns a new Pool object.
@@ -22,22 +38,130 @@ func NewPool(db pg.DB) *Pool {
 	return &Pool{db: db}
 }
 
+// NewBatchPool creates a Pool that groups concurrent inserts into
+// one database transaction, saving a commit for each. A batch is
+// committed window after its first insert, or as soon as it holds
+// max transactions. Each insert in a batch succeeds or fails on
+// its own.
+//
+// Inserts wait up to window longer to return, so batching suits
+// bursts of submissions. It stops when ctx is done, failing any
+// inserts still waiting.
+func NewBatchPool(ctx context.Context, db *sql.DB, window time.Duration, max int) *Pool {
+	p := &Pool{
+		db:      db,
+		inserts: make(chan *poolInsert, max),
+	}
+	go p.batchInserts(ctx, db, window, max)
+	return p
+}
+
 // Insert adds the transaction to the pending pool.
 func (p *Pool) Insert(ctx context.Context, tx *bc.Tx) error {
-	const q = `
-		INSERT INTO pool_txs (tx_hash, data) VALUES ($1, $2)
-		ON CONFLICT (tx_hash) DO NOTHING
-	`
-	_, err := p.db.Exec(ctx, q, tx.Hash, tx)
-	return errors.Wrap(err, "insert into pool txs")
-}
-
-// Dump returns the pooled transactions in topological order and
-// empties the pool.
-func (p *Pool) Dump(ctx context.Context) ([]*bc.Tx, error) {
-	const q = `DELETE FROM pool_txs RETURNING tx_hash, data`
+	if p.inserts == nil {
+		_, err := p.db.Exec(ctx, insertPoolTxQ, tx.Hash, tx, tx.MinTime)
+		return errors.Wrap(err, "insert into pool txs")
+	}
+
+	// The batch may outlive ctx, but inserting
+	// the same transaction again is harmless.
+	ins := &poolInsert{tx: tx, errc: make(chan error, 1)}
+	select {
+	case p.inserts <- ins:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-ins.errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) batchInserts(ctx context.Context, db *sql.DB, window time.Duration, max int) {
+	for {
+		var batch []*poolInsert
+		select {
+		case ins := <-p.inserts:
+			batch = append(batch, ins)
+		case <-ctx.Done():
+			return
+		}
+
+		timer := time.NewTimer(window)
+	collect:
+		for len(batch) < max {
+			select {
+			case ins := <-p.inserts:
+				batch = append(batch, ins)
+			case <-timer.C:
+				break collect
+			case <-ctx.Done():
+				timer.Stop()
+				for _, ins := range batch {
+					ins.errc <- ctx.Err()
+				}
+				return
+			}
+		}
+		timer.Stop()
+
+		errs := insertBatch(ctx, db, batch)
+		for i, ins := range batch {
+			ins.errc <- errs[i]
+		}
+	}
+}
+
+// insertBatch inserts the transactions of batch in one
+// database transaction. Each insert has its own savepoint,
+// so that one failure doesn't roll back the others.
+// It returns the error for each insert, in order.
+func insertBatch(ctx context.Context, db *sql.DB, batch []*poolInsert) []error {
+	errs := make([]error, len(batch))
+	fail := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	dbtx, err := db.Begin(ctx)
+	if err != nil {
+		return fail(errors.Wrap(err, "begin pool insert batch"))
+	}
+	defer dbtx.Rollback(ctx)
+
+	for i, ins := range batch {
+		_, err = dbtx.Exec(ctx, `SAVEPOINT pool_insert`)
+		if err != nil {
+			return fail(errors.Wrap(err, "pool insert savepoint"))
+		}
+		_, err = dbtx.Exec(ctx, insertPoolTxQ, ins.tx.Hash, ins.tx, ins.tx.MinTime)
+		if err != nil {
+			errs[i] = errors.Wrap(err, "insert into pool txs")
+			_, err = dbtx.Exec(ctx, `ROLLBACK TO SAVEPOINT pool_insert`)
+			if err != nil {
+				return fail(errors.Wrap(err, "roll back pool insert"))
+			}
+		}
+	}
+
+	err = dbtx.Commit(ctx)
+	if err != nil {
+		return fail(errors.Wrap(err, "commit pool insert batch"))
+	}
+	return errs
+}
+
+// Dump returns the pooled transactions whose min time is at or
+// before timestampMS in topological order, and removes them from
+// the pool. Future-dated transactions stay in the pool.
+func (p *Pool) Dump(ctx context.Context, timestampMS uint64) ([]*bc.Tx, error) {
+	const q = `DELETE FROM pool_txs WHERE min_time <= $1 RETURNING tx_hash, data`
 	var txs []*bc.Tx
-	err := pg.ForQueryRows(ctx, p.db, q, func(hash bc.Hash, data bc.TxData) {
+	err := pg.ForQueryRows(ctx, p.db, q, timestampMS, func(hash bc.Hash, data bc.TxData) {
 		txs = append(txs, &bc.Tx{TxData: data, Hash: hash})
 	})
 	if err != nil {
@@ -46,7 +46,37 @@ func DecodeSnapshot(data []byte) (*state.Snapshot, error) {
 	}, nil
 }
 
-func storeStateSnapshot(ctx context.Context, db pg.DB, snapshot *state.Snapshot, blockHeight uint64) error {
+// A SnapshotStore stores state snapshots, the set of unspent
+// outputs and recent issuances as of a block height, in their
+// binary protobuf representation.
+//
+// Snapshots are stored in Postgres by default. A single-node
+// Chain Core, which doesn't need to query them, may keep them
+// in an embedded store instead; see NewDirSnapshotStore.
+//
+// Only snapshots go through a SnapshotStore. The account UTXOs
+// that builds reserve and that the finalize path updates stay in
+// Postgres, so switching stores doesn't speed up finalizing
+// transactions, only saving snapshots.
+type SnapshotStore interface {
+	// SaveSnapshot stores data as the snapshot at height,
+	// replacing any snapshot already stored there.
+	SaveSnapshot(ctx context.Context, height uint64, data []byte) error
+
+	// LatestSnapshot returns the snapshot with the greatest
+	// height, or nil data and height 0 if there are none.
+	LatestSnapshot(ctx context.Context) (data []byte, height uint64, err error)
+
+	// LatestSnapshotInfo returns the height and size in bytes
+	// of the latest snapshot.
+	LatestSnapshotInfo(ctx context.Context) (height, size uint64, err error)
+
+	// GetSnapshot returns the snapshot at height. If there is
+	// none, it returns pg.ErrUserInputNotFound.
+	GetSnapshot(ctx context.Context, height uint64) ([]byte, error)
+}
+
+func encodeSnapshot(snapshot *state.Snapshot) ([]byte, error) {
 	var storedSnapshot storage.Snapshot
 	err := patricia.Walk(snapshot.Tree, func(l patricia.Leaf) error {
 		storedSnapshot.Nodes = append(storedSnapshot.Nodes, &storage.Snapshot_StateTreeNode{
@@ -56,7 +86,7 @@ func storeStateSnapshot(ctx context.Context, db pg.DB, snapshot *state.Snapshot,
 		return nil
 	})
 	if err != nil {
-		return errors.Wrap(err, "walking patricia tree")
+		return nil, errors.Wrap(err, "walking patricia tree")
 	}
 
 	storedSnapshot.Issuances = make([]*storage.Snapshot_Issuance, 0, len(snapshot.Issuances))
@@ -69,20 +99,49 @@ func storeStateSnapshot(ctx context.Context, db pg.DB, snapshot *state.Snapshot,
 	}
 
 	b, err := proto.Marshal(&storedSnapshot)
+	return b, errors.Wrap(err, "marshaling state snapshot")
+}
+
+func storeStateSnapshot(ctx context.Context, ss SnapshotStore, snapshot *state.Snapshot, blockHeight uint64) error {
+	b, err := encodeSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	return ss.SaveSnapshot(ctx, blockHeight, b)
+}
+
+func getStateSnapshot(ctx context.Context, ss SnapshotStore) (*state.Snapshot, uint64, error) {
+	data, height, err := ss.LatestSnapshot(ctx)
 	if err != nil {
-		return errors.Wrap(err, "marshaling state snapshot")
+		return nil, height, err
+	}
+	if data == nil {
+		return state.Empty(), 0, nil
+	}
+
+	snapshot, err := DecodeSnapshot(data)
+	if err != nil {
+		return nil, height, errors.Wrap(err, "decoding snapshot")
 	}
+	return snapshot, height, nil
+}
+
+// pgSnapshots is the default SnapshotStore, which keeps
+// snapshots in the snapshots table.
+type pgSnapshots struct {
+	db pg.DB
+}
 
+func (p pgSnapshots) SaveSnapshot(ctx context.Context, height uint64, data []byte) error {
 	const insertQ = `
 		INSERT INTO snapshots (height, data) VALUES($1, $2)
 		ON CONFLICT (height) DO UPDATE SET data = $2
 	`
-
-	_, err = db.Exec(ctx, insertQ, blockHeight, b)
+	_, err := p.db.Exec(ctx, insertQ, height, data)
 	return errors.Wrap(err, "writing state snapshot to database")
 }
 
-func getStateSnapshot(ctx context.Context, db pg.DB) (*state.Snapshot, uint64, error) {
+func (p pgSnapshots) LatestSnapshot(ctx context.Context) ([]byte, uint64, error) {
 	const q = `
 		SELECT data, height FROM snapshots ORDER BY height DESC LIMIT 1
 	`
@@ -90,26 +149,24 @@ func getStateSnapshot(ctx context.Context, db pg.DB) (*state.Snapshot, uint64, e
 		data   []byte
 		height uint64
 	)
-
-	err := db.QueryRow(ctx, q).Scan(&data, &height)
+	err := p.db.QueryRow(ctx, q).Scan(&data, &height)
 	if err == sql.ErrNoRows {
-		return state.Empty(), 0, nil
-	} else if err != nil {
-		return nil, height, errors.Wrap(err, "retrieving state snapshot blob")
+		return nil, 0, nil
 	}
+	return data, height, errors.Wrap(err, "retrieving state snapshot blob")
+}
 
-	snapshot, err := DecodeSnapshot(data)
-	if err != nil {
-		return nil, height, errors.Wrap(err, "decoding snapshot")
-	}
-	return snapshot, height, nil
+func (p pgSnapshots) LatestSnapshotInfo(ctx context.Context) (height uint64, size uint64, err error) {
+	const q = `
+		SELECT height, octet_length(data) FROM snapshots ORDER BY height DESC LIMIT 1
+	`
+	err = p.db.QueryRow(ctx, q).Scan(&height, &size)
+	return height, size, err
 }
 
-// getRawSnapshot returns the raw, protobuf-encoded snapshot data at the
-// provided height.
-func getRawSnapshot(ctx context.Context, db pg.DB, height uint64) (data []byte, err error) {
+func (p pgSnapshots) GetSnapshot(ctx context.Context, height uint64) (data []byte, err error) {
 	const q = `SELECT data FROM snapshots WHERE height = $1`
-	err = db.QueryRow(ctx, q, height).Scan(&data)
+	err = p.db.QueryRow(ctx, q, height).Scan(&data)
 	if err == sql.ErrNoRows {
 		return nil, pg.ErrUserInputNotFound
 	}
@@ -92,12 +92,12 @@ func TestReadWriteStateSnapshot(t *testing.T) {
 			}
 		}
 
-		err := storeStateSnapshot(ctx, dbtx, snapshot, uint64(i))
+		err := storeStateSnapshot(ctx, pgSnapshots{dbtx}, snapshot, uint64(i))
 		if err != nil {
 			t.Fatalf("Error writing state snapshot to db: %s\n", err)
 		}
 
-		loadedSnapshot, height, err := getStateSnapshot(ctx, dbtx)
+		loadedSnapshot, height, err := getStateSnapshot(ctx, pgSnapshots{dbtx})
 		if err != nil {
 			t.Fatalf("Error reading state snapshot from db: %s\n", err)
 		}
@@ -168,7 +168,7 @@ func benchmarkStoreSnapshot(nodes, issuances int, b *testing.B) {
 
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
-		err := storeStateSnapshot(ctx, db, snapshot, uint64(i))
+		err := storeStateSnapshot(ctx, pgSnapshots{db}, snapshot, uint64(i))
 		if err != nil {
 			b.Fatal(err)
 		}
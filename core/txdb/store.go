@@ -14,7 +14,8 @@ import (
 // It satisfies the interface protocol.Store, and provides additional
 // methods for querying current data.
 type Store struct {
-	db pg.DB
+	db        pg.DB
+	snapshots SnapshotStore
 
 	cache blockCache
 }
@@ -28,7 +29,8 @@ var _ protocol.Store = (*Store)(nil)
 // instead.
 func NewStore(db pg.DB) *Store {
 	return &Store{
-		db: db,
+		db:        db,
+		snapshots: pgSnapshots{db},
 		cache: newBlockCache(func(height uint64) (*bc.Block, error) {
 			const q = `SELECT data FROM blocks WHERE height = $1`
 			var b bc.Block
@@ -41,6 +43,12 @@ func NewStore(db pg.DB) *Store {
 	}
 }
 
+// SetSnapshotStore makes s keep state snapshots in ss
+// instead of Postgres. It must be called before s is used.
+func (s *Store) SetSnapshotStore(ss SnapshotStore) {
+	s.snapshots = ss
+}
+
 // Height returns the height of the blockchain.
 func (s *Store) Height(ctx context.Context) (uint64, error) {
 	const q = `SELECT COALESCE(MAX(height), 0) FROM blocks`
@@ -57,26 +65,22 @@ func (s *Store) GetBlock(ctx context.Context, height uint64) (*bc.Block, error)
 }
 
 // LatestSnapshot returns the most recent state snapshot stored in
-// the database and its corresponding block height.
+// the snapshot store and its corresponding block height.
 func (s *Store) LatestSnapshot(ctx context.Context) (*state.Snapshot, uint64, error) {
-	return getStateSnapshot(ctx, s.db)
+	return getStateSnapshot(ctx, s.snapshots)
 }
 
 // LatestSnapshotInfo returns the height and size of the most recent
-// state snapshot stored in the database.
+// state snapshot stored.
 func (s *Store) LatestSnapshotInfo(ctx context.Context) (height uint64, size uint64, err error) {
-	const q = `
-		SELECT height, octet_length(data) FROM snapshots ORDER BY height DESC LIMIT 1
-	`
-	err = s.db.QueryRow(ctx, q).Scan(&height, &size)
-	return height, size, err
+	return s.snapshots.LatestSnapshotInfo(ctx)
 }
 
 // GetSnapshot returns the state snapshot stored at the provided height,
 // in Chain Core's binary protobuf representation. If no snapshot exists
 // at the provided height, an error is returned.
 func (s *Store) GetSnapshot(ctx context.Context, height uint64) ([]byte, error) {
-	return getRawSnapshot(ctx, s.db, height)
+	return s.snapshots.GetSnapshot(ctx, height)
 }
 
 // SaveBlock persists a new block in the database.
@@ -95,9 +99,9 @@ func (s *Store) SaveBlock(ctx context.Context, block *bc.Block) error {
 	return nil
 }
 
-// SaveSnapshot saves a state snapshot to the database.
+// SaveSnapshot saves a state snapshot to the snapshot store.
 func (s *Store) SaveSnapshot(ctx context.Context, height uint64, snapshot *state.Snapshot) error {
-	err := storeStateSnapshot(ctx, s.db, snapshot, height)
+	err := storeStateSnapshot(ctx, s.snapshots, snapshot, height)
 	return errors.Wrap(err, "saving state tree")
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"chain/database/pg"
 	"chain/database/pg/pgtest"
@@ -29,7 +30,7 @@ func TestPoolTxs(t *testing.T) {
 	}
 
 	pool := NewPool(dbtx)
-	got, err := pool.Dump(ctx)
+	got, err := pool.Dump(ctx, bc.Millis(time.Now()))
 	if err != nil {
 		t.Fatalf("err got = %v want nil", err)
 	}
@@ -55,13 +56,73 @@ func TestInsertPoolTx(t *testing.T) {
 	dbtx := pgtest.NewTx(t)
 	ctx := context.Background()
 	tx := bc.NewTx(bc.TxData{ReferenceData: []byte("tx")})
-	err := (&Pool{dbtx}).Insert(ctx, tx)
+	err := (&Pool{db: dbtx}).Insert(ctx, tx)
 	if err != nil {
 		t.Log(errors.Stack(err))
 		t.Fatal(err)
 	}
 }
 
+func TestPoolDumpHoldsFutureTxs(t *testing.T) {
+	dbtx := pgtest.NewTx(t)
+	ctx := context.Background()
+	pool := NewPool(dbtx)
+	now := bc.Millis(time.Now())
+	ready := bc.NewTx(bc.TxData{MinTime: now, MaxTime: now + 1000})
+	future := bc.NewTx(bc.TxData{MinTime: now + 1000, MaxTime: now + 2000})
+	for _, tx := range []*bc.Tx{ready, future} {
+		err := pool.Insert(ctx, tx)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	got, err := pool.Dump(ctx, now)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(got) != 1 || got[0].Hash != ready.Hash {
+		t.Errorf("Dump(now) = %v, want [%v]", got, ready.Hash)
+	}
+
+	got, err = pool.Dump(ctx, now+1000)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(got) != 1 || got[0].Hash != future.Hash {
+		t.Errorf("Dump(now+1000) = %v, want [%v]", got, future.Hash)
+	}
+}
+
+func TestBatchPoolInsert(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A long window, so the batch is
+	// committed when it fills up.
+	pool := NewBatchPool(ctx, db, time.Hour, 5)
+	errs := make(chan error)
+	for i := 0; i < 5; i++ {
+		tx := bc.NewTx(bc.TxData{ReferenceData: []byte{byte(i)}})
+		go func() { errs <- pool.Insert(ctx, tx) }()
+	}
+	for i := 0; i < 5; i++ {
+		err := <-errs
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	got, err := pool.Dump(ctx, bc.Millis(time.Now()))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(got) != 5 {
+		t.Errorf("len(pool) = %d, want 5", len(got))
+	}
+}
+
 func TestGetBlock(t *testing.T) {
 	ctx := context.Background()
 	dbtx := pgtest.NewTx(t)
@@ -21,8 +21,38 @@ func (h *Handler) createTxFeed(ctx context.Context, in struct {
 	// idempotency of create txfeed requests. Duplicate create txfeed requests
 	// with the same client_token will only create one txfeed.
 	ClientToken *string `json:"client_token"`
+
+	// The feed starts after the current block unless
+	// one of these is given, to replay earlier history.
+	// After is a cursor previously returned for a feed or
+	// transaction query; StartBlockHeight is the first
+	// block to include.
+	After            string  `json:"after"`
+	StartBlockHeight *uint64 `json:"start_block_height"`
 }) (*txfeed.TxFeed, error) {
-	after := fmt.Sprintf("%d:%d-%d", h.Chain.Height(), math.MaxInt32, uint64(math.MaxInt64))
+	from := h.Chain.Height()
+	switch {
+	case in.After != "" && in.StartBlockHeight != nil:
+		return nil, errors.WithDetail(httpjson.ErrBadRequest, "provide at most one of after and start_block_height")
+	case in.After != "":
+		a, err := query.DecodeTxAfter(in.After)
+		if err != nil {
+			return nil, err
+		}
+		// A feed scans forward, so the cursor must not stop early.
+		a.StopBlockHeight = math.MaxInt64
+		return h.TxFeeds.Create(ctx, in.Alias, in.Filter, a.String(), in.ClientToken)
+	case in.StartBlockHeight != nil:
+		if *in.StartBlockHeight > from+1 {
+			return nil, errors.WithDetailf(httpjson.ErrBadRequest, "start_block_height %d is past the next block", *in.StartBlockHeight)
+		}
+		if *in.StartBlockHeight > 0 {
+			from = *in.StartBlockHeight - 1
+		} else {
+			from = 0
+		}
+	}
+	after := fmt.Sprintf("%d:%d-%d", from, math.MaxInt32, uint64(math.MaxInt64))
 	return h.TxFeeds.Create(ctx, in.Alias, in.Filter, after, in.ClientToken)
 }
 
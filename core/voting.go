@@ -0,0 +1,17 @@
+package core
+
+import (
+	"context"
+
+	"chain/core/voting"
+)
+
+// tallyVotes counts the votes on a ballot, by option. Ballots
+// are not stored, so the request gives the ballot's parameters.
+//
+// POST /tally-votes
+func (h *Handler) tallyVotes(ctx context.Context, in struct {
+	Ballot voting.Ballot `json:"ballot"`
+}) (*voting.Tally, error) {
+	return h.voting.Tally(ctx, &in.Ballot)
+}
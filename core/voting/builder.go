@@ -0,0 +1,223 @@
+package voting
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"chain/core/query"
+	"chain/core/txbuilder"
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vmutil"
+)
+
+func (m *Manager) DecodeIssueAction(data []byte) (txbuilder.Action, error) {
+	a := &issueAction{voting: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// issueAction issues Ballot's voting-right tokens to the current
+// holders of its asset, one token per unit held. Holders in this
+// core's accounts receive them at a new control program for the
+// account; other holders, at their own control program, if it is
+// a multisig program. Holdings in contracts get no voting rights.
+type issueAction struct {
+	voting *Manager
+	Ballot Ballot `json:"ballot"`
+}
+
+func (a *issueAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	b := &a.Ballot
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if bc.Millis(maxTime) > b.Deadline {
+		return nil, errors.WithDetail(ErrDeadline, "voting rights must be issued before the deadline")
+	}
+
+	// Any units of the right asset, whether held or voted,
+	// mean the rights for this ballot were already issued.
+	now := bc.Millis(time.Now())
+	_, issued, err := a.voting.indexer.AssetHolders(ctx, b.RightAssetID, now, "", 1)
+	if err != nil {
+		return nil, err
+	}
+	if issued > 0 {
+		return nil, errors.WithDetailf(ErrIssued, "%d units of the voting right asset are outstanding", issued)
+	}
+
+	const limit = 100
+	var (
+		outs  []*bc.TxOutput
+		total uint64
+		after string
+	)
+	for {
+		holders, _, err := a.voting.indexer.AssetHolders(ctx, b.AssetID, now, after, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range holders {
+			var prog []byte
+			if h.AccountID != "" {
+				prog, err = a.voting.accounts.CreateControlProgram(ctx, h.AccountID, false)
+				if err != nil {
+					return nil, errors.Wrap(err, "creating control program for voting rights")
+				}
+			} else {
+				prog, err = hex.DecodeString(h.ControlProgram)
+				if err != nil {
+					continue
+				}
+				if _, _, err := vmutil.ParseP2SPMultiSigProgram(prog); err != nil {
+					continue
+				}
+			}
+			outs = append(outs, bc.NewTxOutput(b.RightAssetID, h.Amount, prog, nil))
+			total += h.Amount
+		}
+		if len(holders) < limit {
+			break
+		}
+		after = query.HolderAfter(holders[len(holders)-1])
+	}
+	if total == 0 {
+		return nil, errors.WithDetail(ErrBadBallot, "the voting asset has no holders to issue rights to")
+	}
+
+	amt := bc.AssetAmount{AssetID: b.RightAssetID, Amount: total}
+	res, err := a.voting.assets.NewIssueAction(amt, nil).Build(ctx, maxTime)
+	if err != nil {
+		return nil, err
+	}
+	res.Outputs = append(res.Outputs, outs...)
+	return res, nil
+}
+
+func (m *Manager) DecodeCastAction(data []byte) (txbuilder.Action, error) {
+	a := &castAction{voting: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// castAction spends Amount voting-right tokens from an account
+// into a vote for Option, which the same account can recast
+// until the deadline.
+type castAction struct {
+	voting    *Manager
+	Ballot    Ballot `json:"ballot"`
+	AccountID string `json:"account_id"`
+	Amount    uint64 `json:"amount"`
+	Option    string `json:"option"`
+}
+
+func (a *castAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	b := &a.Ballot
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+	choice, err := b.choice(a.Option)
+	if err != nil {
+		return nil, err
+	}
+	if a.Amount == 0 {
+		return nil, errors.WithDetail(txbuilder.ErrBadAmount, "vote amount must be positive")
+	}
+	if bc.Millis(maxTime) > b.Deadline {
+		return nil, errors.WithDetail(ErrDeadline, "votes must land before the deadline")
+	}
+
+	amt := bc.AssetAmount{AssetID: b.RightAssetID, Amount: a.Amount}
+	res, err := a.voting.accounts.NewSpendAction(amt, a.AccountID, nil, nil, nil, nil).Build(ctx, maxTime)
+	if err != nil {
+		return nil, err
+	}
+
+	voter, err := a.voting.accounts.CreateControlProgram(ctx, a.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+	out, err := voteOutput(b, choice, voter, a.Amount)
+	if err != nil {
+		return nil, err
+	}
+	res.Outputs = append(res.Outputs, out)
+	return res, nil
+}
+
+func (m *Manager) DecodeRecastAction(data []byte) (txbuilder.Action, error) {
+	a := &recastAction{voting: m}
+	err := json.Unmarshal(data, a)
+	return a, err
+}
+
+// recastAction moves the vote at TxHash and TxOut to Option.
+// The voter program must belong to an account in this core.
+type recastAction struct {
+	voting *Manager
+	Ballot Ballot  `json:"ballot"`
+	TxHash bc.Hash `json:"transaction_id"`
+	TxOut  uint32  `json:"position"`
+	Option string  `json:"option"`
+}
+
+func (a *recastAction) Build(ctx context.Context, maxTime time.Time) (*txbuilder.BuildResult, error) {
+	b := &a.Ballot
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+	choice, err := b.choice(a.Option)
+	if err != nil {
+		return nil, err
+	}
+	if bc.Millis(maxTime) > b.Deadline {
+		return nil, errors.WithDetail(ErrDeadline, "votes must be recast before the deadline")
+	}
+
+	votes, err := a.voting.votes(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range votes {
+		if v.TransactionID != a.TxHash || v.Position != a.TxOut {
+			continue
+		}
+		amt := bc.AssetAmount{AssetID: b.RightAssetID, Amount: v.Amount}
+		sigInst, err := a.voting.accounts.SigningInstruction(ctx, v.VoterProgram, amt)
+		if err != nil {
+			return nil, errors.WithDetail(err, "voter program is not controlled by this core")
+		}
+		out, err := voteOutput(b, choice, v.VoterProgram, v.Amount)
+		if err != nil {
+			return nil, err
+		}
+		in := bc.NewSpendInput(v.TransactionID, v.Position, nil, b.RightAssetID, v.Amount, v.ControlProgram, nil)
+		return &txbuilder.BuildResult{
+			Inputs:              []*bc.TxInput{in},
+			Outputs:             []*bc.TxOutput{out},
+			SigningInstructions: []*txbuilder.SigningInstruction{sigInst},
+		}, nil
+	}
+	return nil, errors.WithDetailf(pg.ErrUserInputNotFound, "no unspent vote on the ballot at %s:%d", a.TxHash, a.TxOut)
+}
+
+// voteOutput returns an output locking amount voting-right
+// tokens in a vote on b for the option at index choice.
+func voteOutput(b *Ballot, choice int, voter []byte, amount uint64) (*bc.TxOutput, error) {
+	prog, err := VoteProgram(b, choice, voter)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refData(b, choice, voter)
+	if err != nil {
+		return nil, err
+	}
+	return bc.NewTxOutput(b.RightAssetID, amount, prog, ref), nil
+}
@@ -0,0 +1,288 @@
+// Package voting implements token-weighted ballots. Holders of an
+// asset receive voting-right tokens in proportion to their holdings,
+// and vote by locking the tokens in a contract output that records
+// their choice. Until the ballot's deadline, a voter can recast their
+// vote; after it, every vote is final and the tally cannot change.
+package voting
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"chain/core/account"
+	"chain/core/asset"
+	"chain/core/query"
+	"chain/core/query/filter"
+	"chain/crypto/sha3pool"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+)
+
+var (
+	ErrBadBallot = errors.New("invalid ballot")
+	ErrIssued    = errors.New("voting rights already issued")
+	ErrDeadline  = errors.New("too late for ballot deadline")
+)
+
+// maxOptions bounds the number of choices on a ballot.
+const maxOptions = 100
+
+// Ballot describes a vote among the holders of an asset. A ballot
+// is identified by its parameters alone; it is not stored.
+type Ballot struct {
+	// AssetID is the asset whose holders may vote, one vote
+	// per unit held when the voting rights are issued.
+	AssetID bc.AssetID `json:"asset_id"`
+
+	// RightAssetID is the voting-right token. It must be an
+	// asset of this core, used for no other ballot.
+	RightAssetID bc.AssetID `json:"right_asset_id"`
+
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+
+	// Deadline is a millisecond Unix timestamp. Votes must
+	// be cast and recast by the deadline.
+	Deadline uint64 `json:"deadline"`
+}
+
+// Validate checks that b's parameters are usable.
+func (b *Ballot) Validate() error {
+	if b.AssetID == b.RightAssetID {
+		return errors.WithDetail(ErrBadBallot, "voting right asset must differ from the voting asset")
+	}
+	if len(b.Options) < 2 || len(b.Options) > maxOptions {
+		return errors.WithDetailf(ErrBadBallot, "ballot must have between 2 and %d options", maxOptions)
+	}
+	seen := make(map[string]bool, len(b.Options))
+	for _, o := range b.Options {
+		if o == "" || seen[o] {
+			return errors.WithDetail(ErrBadBallot, "options must be nonempty and distinct")
+		}
+		seen[o] = true
+	}
+	if b.Deadline == 0 || b.Deadline > math.MaxInt64 {
+		return errors.WithDetail(ErrBadBallot, "deadline must be positive and at most 2^63-1")
+	}
+	return nil
+}
+
+// ID returns the hash that identifies b. Vote outputs
+// record it in their reference data.
+func (b *Ballot) ID() bc.Hash {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], b.Deadline)
+
+	var h bc.Hash
+	sha := sha3pool.Get256()
+	defer sha3pool.Put256(sha)
+	sha.Write(b.AssetID[:])
+	sha.Write(b.RightAssetID[:])
+	sha.Write(buf[:])
+	writeString(sha, b.Question)
+	for _, o := range b.Options {
+		writeString(sha, o)
+	}
+	sha.Read(h[:])
+	return h
+}
+
+// writeString writes s to w prefixed with its length,
+// so that adjacent strings hash unambiguously.
+func writeString(w io.Writer, s string) {
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(s)))
+	w.Write(n[:])
+	w.Write([]byte(s))
+}
+
+// choice returns the index of option in b's options.
+func (b *Ballot) choice(option string) (int, error) {
+	for i, o := range b.Options {
+		if o == option {
+			return i, nil
+		}
+	}
+	return 0, errors.WithDetailf(ErrBadBallot, "%q is not an option on the ballot", option)
+}
+
+// VoteProgram returns the control program for a vote on b
+// for the option at index choice, cast by voter, a multisig
+// control program.
+//
+// The ballot and choice are committed to in the program but
+// otherwise ignored. Until the deadline, the vote can be spent
+// with the signature of the voter's keys, to recast it. After
+// the deadline, it cannot be spent at all.
+func VoteProgram(b *Ballot, choice int, voter []byte) ([]byte, error) {
+	if _, _, err := vmutil.ParseP2SPMultiSigProgram(voter); err != nil {
+		return nil, errors.WithDetail(ErrBadBallot, "voter program must be a multisig program")
+	}
+	id := b.ID()
+
+	// The signature arguments for the voter's program are
+	// left on the stack for it, so it runs unchanged.
+	p := vmutil.NewBuilder()
+	p.AddOp(vm.OP_MAXTIME).AddInt64(int64(b.Deadline)).AddOp(vm.OP_LESSTHANOREQUAL).AddOp(vm.OP_VERIFY)
+	p.AddData(id[:]).AddInt64(int64(choice)).AddOp(vm.OP_2DROP)
+	p.AddRawBytes(voter)
+	return p.Program, nil
+}
+
+// Manager builds transactions for ballots and tallies them.
+type Manager struct {
+	accounts *account.Manager
+	assets   *asset.Registry
+	indexer  *query.Indexer
+}
+
+func NewManager(accounts *account.Manager, assets *asset.Registry, indexer *query.Indexer) *Manager {
+	return &Manager{accounts: accounts, assets: assets, indexer: indexer}
+}
+
+// Vote is an unspent vote output.
+type Vote struct {
+	TransactionID  bc.Hash            `json:"transaction_id"`
+	Position       uint32             `json:"position"`
+	Amount         uint64             `json:"amount"`
+	Option         string             `json:"option"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+	VoterProgram   chainjson.HexBytes `json:"voter_program"`
+
+	choice int
+}
+
+// OptionTally is the number of votes for one option.
+type OptionTally struct {
+	Option string `json:"option"`
+	Votes  uint64 `json:"votes"`
+}
+
+// Tally counts the votes on a ballot.
+type Tally struct {
+	ID       bc.Hash        `json:"id"`
+	Status   string         `json:"status"` // "open" or "closed"
+	Deadline uint64         `json:"deadline"`
+	Total    uint64         `json:"total"`
+	Options  []*OptionTally `json:"options"`
+	Votes    []*Vote        `json:"votes"`
+}
+
+// Tally counts the confirmed, unspent votes on b. Votes cannot be
+// spent after the deadline, so once it has passed, the tally is final.
+func (m *Manager) Tally(ctx context.Context, b *Ballot) (*Tally, error) {
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+	votes, err := m.votes(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tally{
+		ID:       b.ID(),
+		Status:   "open",
+		Deadline: b.Deadline,
+		Votes:    votes,
+	}
+	if bc.Millis(time.Now()) > b.Deadline {
+		t.Status = "closed"
+	}
+	for _, o := range b.Options {
+		t.Options = append(t.Options, &OptionTally{Option: o})
+	}
+	for _, v := range votes {
+		t.Options[v.choice].Votes += v.Amount
+		t.Total += v.Amount
+	}
+	return t, nil
+}
+
+// votes returns the unspent outputs that vote on b.
+// Outputs that only claim to, by their reference data, but
+// have some other control program or asset are skipped.
+func (m *Manager) votes(ctx context.Context, b *Ballot) ([]*Vote, error) {
+	p, err := filter.Parse("reference_data.voting_ballot=$1")
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	vals := []interface{}{b.ID().String()}
+	timestampMS := bc.Millis(time.Now())
+
+	const limit = 100
+	var (
+		votes []*Vote
+		after *query.OutputsAfter
+	)
+	for {
+		outs, next, err := m.indexer.Outputs(ctx, p, vals, timestampMS, after, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "querying votes")
+		}
+		for _, o := range outs {
+			raw, ok := o.(*json.RawMessage)
+			if !ok || raw == nil {
+				return nil, errors.Wrap(fmt.Errorf("unexpected %T in Indexer.Outputs output", o))
+			}
+			var out struct {
+				TransactionID  bc.Hash            `json:"transaction_id"`
+				Position       uint32             `json:"position"`
+				AssetID        bc.AssetID         `json:"asset_id"`
+				Amount         uint64             `json:"amount"`
+				ControlProgram chainjson.HexBytes `json:"control_program"`
+				ReferenceData  struct {
+					Choice       int    `json:"voting_choice"`
+					VoterProgram string `json:"voting_voter_program"`
+				} `json:"reference_data"`
+			}
+			err = json.Unmarshal(*raw, &out)
+			if err != nil {
+				return nil, errors.Wrap(err, "decoding vote")
+			}
+			choice := out.ReferenceData.Choice
+			voter, err := hex.DecodeString(out.ReferenceData.VoterProgram)
+			if err != nil || out.AssetID != b.RightAssetID || choice < 0 || choice >= len(b.Options) {
+				continue
+			}
+			want, err := VoteProgram(b, choice, voter)
+			if err != nil || !bytes.Equal(want, out.ControlProgram) {
+				continue
+			}
+			votes = append(votes, &Vote{
+				TransactionID:  out.TransactionID,
+				Position:       out.Position,
+				Amount:         out.Amount,
+				Option:         b.Options[choice],
+				ControlProgram: out.ControlProgram,
+				VoterProgram:   voter,
+				choice:         choice,
+			})
+		}
+		if len(outs) < limit {
+			return votes, nil
+		}
+		after = next
+	}
+}
+
+// refData is the reference data of a vote on b for the option
+// at index choice, which lets the tally find it.
+func refData(b *Ballot, choice int, voter []byte) ([]byte, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"voting_ballot":        b.ID().String(),
+		"voting_choice":        choice,
+		"voting_voter_program": hex.EncodeToString(voter),
+	})
+	return data, errors.Wrap(err)
+}
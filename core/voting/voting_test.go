@@ -0,0 +1,174 @@
+package voting
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"chain/core/contracttest"
+	"chain/core/coretest"
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/prottest"
+	"chain/protocol/vm"
+	"chain/protocol/vmutil"
+	"chain/testutil"
+)
+
+func TestVoteProgram(t *testing.T) {
+	voterPub, voterPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	voterProg, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{voterPub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Ballot{
+		AssetID:      bc.AssetID{1},
+		RightAssetID: bc.AssetID{2},
+		Question:     "Approve the merger?",
+		Options:      []string{"yes", "no"},
+		Deadline:     1000,
+	}
+	err = b.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	voteProg, err := VoteProgram(b, 0, voterProg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recastProg, err := VoteProgram(b, 1, voterProg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		maxTime  uint64
+		signer   ed25519.PrivateKey
+		wantPass bool
+	}{
+		{"recast", 1000, voterPriv, true},
+		{"recast after deadline", 1001, voterPriv, false},
+		{"recast by other", 1000, otherPriv, false},
+	}
+	for _, tc := range cases {
+		tx := &bc.TxData{
+			Version: 1,
+			MaxTime: tc.maxTime,
+			Inputs: []*bc.TxInput{
+				bc.NewSpendInput(bc.Hash{3}, 0, nil, b.RightAssetID, 10, voteProg, nil),
+			},
+			Outputs: []*bc.TxOutput{
+				bc.NewTxOutput(b.RightAssetID, 10, recastProg, nil),
+			},
+		}
+		contracttest.Sign(tx, tc.signer, nil)
+
+		ok, err := vm.VerifyTxInput(bc.NewTx(*tx), 0)
+		if ok != tc.wantPass {
+			t.Errorf("%s: got ok=%t (err %v), want %t", tc.name, ok, err, tc.wantPass)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		options []string
+		right   bc.AssetID
+		ok      bool
+	}{
+		{"valid", []string{"yes", "no"}, bc.AssetID{2}, true},
+		{"one option", []string{"yes"}, bc.AssetID{2}, false},
+		{"duplicate option", []string{"yes", "yes"}, bc.AssetID{2}, false},
+		{"empty option", []string{"yes", ""}, bc.AssetID{2}, false},
+		{"right asset is voting asset", []string{"yes", "no"}, bc.AssetID{1}, false},
+	}
+	for _, c := range cases {
+		b := &Ballot{AssetID: bc.AssetID{1}, RightAssetID: c.right, Options: c.options, Deadline: 1000}
+		err := b.Validate()
+		if (err == nil) != c.ok {
+			t.Errorf("%s: got error %v, want ok=%t", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestBuildAndTally(t *testing.T) {
+	ctx := context.Background()
+	core := contracttest.NewCore(t)
+	m := NewManager(core.Accounts, core.Assets, core.Indexer)
+
+	alice, assetID := core.Fund(ctx, t, 60)
+	bob := coretest.CreateAccount(ctx, t, core.Accounts, "", nil)
+	coretest.IssueAssets(ctx, t, core.Chain, core.Assets, core.Accounts, assetID, 40, bob)
+	prottest.MakeBlock(t, core.Chain)
+
+	b := &Ballot{
+		AssetID:      assetID,
+		RightAssetID: coretest.CreateAsset(ctx, t, core.Assets, nil, "", nil),
+		Question:     "Merge?",
+		Options:      []string{"yes", "no"},
+		Deadline:     bc.Millis(time.Now().Add(time.Hour)),
+	}
+	checkTally := func(yes, no uint64) {
+		tally, err := m.Tally(ctx, b)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if tally.Status != "open" || tally.Total != yes+no || tally.Options[0].Votes != yes || tally.Options[1].Votes != no {
+			t.Fatalf("tally is %s, %d yes and %d no; want open, %d yes and %d no",
+				tally.Status, tally.Options[0].Votes, tally.Options[1].Votes, yes, no)
+		}
+	}
+	issue := contracttest.Action(t, m.DecodeIssueAction, map[string]interface{}{"ballot": b})
+	cast := func(accountID string, amount uint64, option string) *bc.Tx {
+		return core.Submit(ctx, t, contracttest.Action(t, m.DecodeCastAction, map[string]interface{}{
+			"ballot":     b,
+			"account_id": accountID,
+			"amount":     amount,
+			"option":     option,
+		}))
+	}
+	checkTally(0, 0)
+
+	// Each holder gets one right per unit held, once.
+	tx := core.Submit(ctx, t, issue)
+	var rights []uint64
+	for _, out := range tx.Outputs {
+		if out.AssetID == b.RightAssetID {
+			rights = append(rights, out.Amount)
+		}
+	}
+	sort.Slice(rights, func(i, j int) bool { return rights[i] < rights[j] })
+	if !reflect.DeepEqual(rights, []uint64{40, 60}) {
+		t.Errorf("issued rights %v, want [40 60]", rights)
+	}
+	_, err := issue.Build(ctx, time.Now().Add(time.Minute))
+	if errors.Root(err) != ErrIssued {
+		t.Errorf("second issue: got error %v, want %v", err, ErrIssued)
+	}
+
+	cast(alice, 60, "yes")
+	tx = cast(bob, 40, "no")
+	checkTally(60, 40)
+
+	// Until the deadline, a vote can move to another option.
+	core.Submit(ctx, t, contracttest.Action(t, m.DecodeRecastAction, map[string]interface{}{
+		"ballot":         b,
+		"transaction_id": tx.Hash,
+		"position":       len(tx.Outputs) - 1,
+		"option":         "yes",
+	}))
+	checkTally(100, 0)
+}
@@ -0,0 +1,144 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8).
+//
+// A secret is split into n shares, any k of which reconstruct it.
+// Fewer than k shares reveal nothing about the secret.
+// Each byte of the secret is shared independently, using the
+// same x coordinate for every byte of a given share.
+//
+// A share is encoded as its x coordinate (one nonzero byte)
+// followed by one y byte per byte of the secret.
+package shamir
+
+import (
+	"crypto/rand"
+	"io"
+
+	"chain/errors"
+)
+
+var (
+	ErrInvalidParams = errors.New("invalid share count or threshold")
+	ErrInvalidShares = errors.New("invalid shares")
+)
+
+// Split divides secret into n shares,
+// any k of which can be combined to recover it.
+// It requires 1 < k <= n < 256.
+func Split(secret []byte, n, k int) ([][]byte, error) {
+	return split(rand.Reader, secret, n, k)
+}
+
+func split(r io.Reader, secret []byte, n, k int) ([][]byte, error) {
+	if k < 2 || n < k || n > 255 {
+		return nil, errors.WithDetailf(ErrInvalidParams, "n=%d k=%d", n, k)
+	}
+	if len(secret) == 0 {
+		return nil, errors.WithDetail(ErrInvalidParams, "empty secret")
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+
+	// coef[0] is the secret byte; coef[1:] are random.
+	coef := make([]byte, k)
+	for j, s := range secret {
+		coef[0] = s
+		_, err := io.ReadFull(r, coef[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "reading random coefficients")
+		}
+		for _, share := range shares {
+			share[j+1] = eval(coef, share[0])
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers a secret from shares produced by Split.
+// It needs at least the threshold number of shares;
+// with fewer, it returns a wrong secret rather than an error,
+// so callers should check the result against something known,
+// such as a public key.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.WithDetail(ErrInvalidShares, "need at least 2 shares")
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, errors.WithDetail(ErrInvalidShares, "share too short")
+	}
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if len(s) != size {
+			return nil, errors.WithDetail(ErrInvalidShares, "shares differ in length")
+		}
+		if s[0] == 0 || seen[s[0]] {
+			return nil, errors.WithDetail(ErrInvalidShares, "duplicate or zero share index")
+		}
+		seen[s[0]] = true
+	}
+
+	// Lagrange interpolation at x=0.
+	secret := make([]byte, size-1)
+	for i, si := range shares {
+		// basis = prod_{j != i} x_j / (x_j - x_i); subtraction is xor.
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(sj[0], sj[0]^si[0]))
+		}
+		for b := range secret {
+			secret[b] ^= mul(si[b+1], basis)
+		}
+	}
+	return secret, nil
+}
+
+// eval evaluates the polynomial with the given coefficients at x,
+// using Horner's method.
+func eval(coef []byte, x byte) byte {
+	var y byte
+	for i := len(coef) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coef[i]
+	}
+	return y
+}
+
+// Log and exp tables for GF(2^8) with the AES polynomial
+// x^8 + x^4 + x^3 + x + 1 and generator 3.
+var logTab, expTab [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTab[i] = x
+		logTab[x] = byte(i)
+		// x *= 3: x*2 ^ x, reducing x*2 by the polynomial.
+		x2 := x << 1
+		if x&0x80 != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	expTab[255] = expTab[0]
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTab[(int(logTab[a])+int(logTab[b]))%255]
+}
+
+// div returns a/b. b must be nonzero.
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTab[(int(logTab[a])+255-int(logTab[b]))%255]
+}
@@ -0,0 +1,77 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+
+	"chain/errors"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("a 64-byte chainkd extended private key would go right here......")
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("got %d shares, want 5", len(shares))
+	}
+
+	subsets := [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}}
+	for _, idx := range subsets {
+		var sub [][]byte
+		for _, i := range idx {
+			sub = append(sub, shares[i])
+		}
+		got, err := Combine(sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Errorf("Combine(shares %v) = %q, want %q", idx, got, secret)
+		}
+	}
+
+	got, err := Combine(shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got, secret) {
+		t.Error("Combine with fewer than k shares recovered the secret")
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	cases := []struct{ n, k int }{{3, 1}, {2, 3}, {256, 2}}
+	for _, c := range cases {
+		_, err := Split([]byte{1}, c.n, c.k)
+		if errors.Root(err) != ErrInvalidParams {
+			t.Errorf("Split(n=%d, k=%d) err = %v, want %v", c.n, c.k, err, ErrInvalidParams)
+		}
+	}
+}
+
+func TestCombineErrors(t *testing.T) {
+	cases := [][][]byte{
+		{{1, 2}},
+		{{1, 2}, {1, 3}},
+		{{0, 2}, {1, 3}},
+		{{1, 2}, {2, 3, 4}},
+	}
+	for _, c := range cases {
+		_, err := Combine(c)
+		if errors.Root(err) != ErrInvalidShares {
+			t.Errorf("Combine(%v) err = %v, want %v", c, err, ErrInvalidShares)
+		}
+	}
+}
+
+func TestField(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if got := div(mul(byte(a), byte(b)), byte(b)); got != byte(a) {
+				t.Fatalf("(%d*%d)/%d = %d", a, b, b, got)
+			}
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"sync"
 
 	"chain/errors"
 	"chain/log"
@@ -78,6 +79,9 @@ var ErrTxDone = sql.ErrTxDone
 // can be controlled with SetMaxIdleConns.
 type DB struct {
 	db *sql.DB
+
+	stmtMu sync.RWMutex
+	stmts  map[string]*sql.Stmt // see PrepareHot
 }
 
 // Tx is an in-progress database transaction.
@@ -91,6 +95,7 @@ type DB struct {
 // the transaction's Prepare or Stmt methods are closed
 // by the call to Commit or Rollback.
 type Tx struct {
+	db *DB
 	tx *sql.Tx
 }
 
@@ -159,6 +164,41 @@ func Open(driverName, dataSourceName string) (*DB, error) {
 	return &DB{db: db}, nil
 }
 
+// PrepareHot prepares each of queries on db.
+// Afterward, whenever db or a transaction begun on db
+// runs one of these exact query strings, it executes
+// the prepared statement instead of sending the query
+// text to be parsed and planned again.
+//
+// Call PrepareHot once at startup, for the few queries
+// on latency-sensitive paths.
+func (db *DB) PrepareHot(ctx context.Context, queries ...string) error {
+	for _, q := range queries {
+		stmt, err := db.db.Prepare(q)
+		if err != nil {
+			return errors.Wrap(err, "preparing hot query")
+		}
+		db.stmtMu.Lock()
+		if db.stmts == nil {
+			db.stmts = make(map[string]*sql.Stmt)
+		}
+		if old := db.stmts[q]; old != nil {
+			old.Close()
+		}
+		db.stmts[q] = stmt
+		db.stmtMu.Unlock()
+	}
+	return nil
+}
+
+// hot returns the statement prepared for query by
+// PrepareHot, or nil.
+func (db *DB) hot(query string) *sql.Stmt {
+	db.stmtMu.RLock()
+	defer db.stmtMu.RUnlock()
+	return db.stmts[query]
+}
+
 // Close closes the database, releasing any open resources.
 //
 // It is rare to Close a DB, as the DB handle is meant to be
@@ -197,13 +237,16 @@ func (db *DB) Begin(ctx context.Context) (*Tx, error) {
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
-	return &Tx{tx: tx}, nil
+	return &Tx{db: db, tx: tx}, nil
 }
 
 // Exec executes a query without returning any rows.
 // The args are for any placeholder parameters in the query.
 func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (Result, error) {
 	logQuery(ctx, query, args)
+	if stmt := db.hot(query); stmt != nil {
+		return stmt.Exec(args...)
+	}
 	return db.db.Exec(query, args...)
 }
 
@@ -211,7 +254,15 @@ func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (Resu
 // The args are for any placeholder parameters in the query.
 func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
 	logQuery(ctx, query, args)
-	rows, err := db.db.Query(query, args...)
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if stmt := db.hot(query); stmt != nil {
+		rows, err = stmt.Query(args...)
+	} else {
+		rows, err = db.db.Query(query, args...)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -223,7 +274,12 @@ func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (*Ro
 // Row's Scan method is called.
 func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
 	logQuery(ctx, query, args)
-	row := db.db.QueryRow(query, args...)
+	var row *sql.Row
+	if stmt := db.hot(query); stmt != nil {
+		row = stmt.QueryRow(args...)
+	} else {
+		row = db.db.QueryRow(query, args...)
+	}
 	return &Row{row: row, ctx: ctx}
 }
 
@@ -241,6 +297,9 @@ func (tx *Tx) Rollback(ctx context.Context) error {
 // For example: an INSERT and UPDATE.
 func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (Result, error) {
 	logQuery(ctx, query, args)
+	if stmt := tx.db.hot(query); stmt != nil {
+		return tx.tx.Stmt(stmt).Exec(args...)
+	}
 	return tx.tx.Exec(query, args...)
 }
 
@@ -248,7 +307,15 @@ func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (Resu
 // The args are for any placeholder parameters in the query.
 func (tx *Tx) Query(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
 	logQuery(ctx, query, args)
-	rows, err := tx.tx.Query(query, args...)
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if stmt := tx.db.hot(query); stmt != nil {
+		rows, err = tx.tx.Stmt(stmt).Query(args...)
+	} else {
+		rows, err = tx.tx.Query(query, args...)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -260,7 +327,12 @@ func (tx *Tx) Query(ctx context.Context, query string, args ...interface{}) (*Ro
 // Row's Scan method is called.
 func (tx *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
 	logQuery(ctx, query, args)
-	row := tx.tx.QueryRow(query, args...)
+	var row *sql.Row
+	if stmt := tx.db.hot(query); stmt != nil {
+		row = tx.tx.Stmt(stmt).QueryRow(args...)
+	} else {
+		row = tx.tx.QueryRow(query, args...)
+	}
 	return &Row{row: row, ctx: ctx}
 }
 
@@ -0,0 +1,39 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Millis is a time as a number of milliseconds since the Unix epoch.
+type Millis uint64
+
+// UnmarshalJSON fulfills the encoding/json.Unmarshaler interface.
+// It accepts a number of milliseconds, or a string in RFC 3339
+// format with any UTC offset, such as "2016-10-26T09:30:00-07:00".
+// Either way, the result is the same instant, independent of the
+// time zone it was given in.
+func (m *Millis) UnmarshalJSON(b []byte) error {
+	ms, err := strconv.ParseUint(string(b), 10, 64)
+	if err == nil {
+		*m = Millis(ms)
+		return nil
+	}
+
+	var str string
+	err = json.Unmarshal(b, &str)
+	if err != nil {
+		return errors.New("invalid json.Millis")
+	}
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return errors.New("invalid json.Millis: time must be milliseconds or RFC 3339")
+	}
+	if t.Before(time.Unix(0, 0)) {
+		return errors.New("invalid json.Millis: time cannot be before 1970")
+	}
+	*m = Millis(uint64(t.UnixNano()) / uint64(time.Millisecond))
+	return nil
+}
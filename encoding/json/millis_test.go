@@ -0,0 +1,53 @@
+package json
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnmarshalMillis(t *testing.T) {
+	const want = 1477499400000 // 2016-10-26T16:30:00Z
+
+	cases := []string{
+		`1477499400000`,
+		`"2016-10-26T16:30:00Z"`,
+		`"2016-10-26T09:30:00-07:00"`,
+		`"2016-10-27T01:30:00+09:00"`,
+		`"2016-10-26T16:30:00.000Z"`,
+	}
+	for _, c := range cases {
+		var m Millis
+		err := json.Unmarshal([]byte(c), &m)
+		if err != nil {
+			t.Errorf("Millis.UnmarshalJSON(%s): unexpected error %v", c, err)
+			continue
+		}
+		if m != want {
+			t.Errorf("Millis.UnmarshalJSON(%s) = %d want %d", c, m, want)
+		}
+	}
+
+	badCases := []string{
+		`-1`,
+		`"2016-10-26 16:30:00"`,
+		`"1969-12-31T23:59:59Z"`,
+		`true`,
+	}
+	for _, c := range badCases {
+		var m Millis
+		err := json.Unmarshal([]byte(c), &m)
+		if err == nil {
+			t.Errorf("Millis.UnmarshalJSON(%s) = %d, want error", c, m)
+		}
+	}
+}
+
+func TestMarshalMillis(t *testing.T) {
+	b, err := json.Marshal(Millis(1477499400000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `1477499400000`; got != want {
+		t.Errorf("Marshal(Millis) = %s want %s", got, want)
+	}
+}
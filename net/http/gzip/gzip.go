@@ -26,6 +26,12 @@ func getWriter(w io.Writer) *gzip.Writer {
 
 type Handler struct {
 	Handler http.Handler
+
+	// MinSize is the smallest response body, in bytes,
+	// that will be compressed. Smaller responses are
+	// sent as is, since the gzip framing would outweigh
+	// any savings.
+	MinSize int
 }
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -34,23 +40,82 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.Handler.ServeHTTP(w, r)
 		return
 	}
-	w.Header().Set("Content-Encoding", "gzip")
-	gz := getWriter(w)
-	w = &responseWriter{gz, w}
-	h.Handler.ServeHTTP(w, r)
-	gz.Close()
-	pool.Put(gz)
+	rw := &responseWriter{ResponseWriter: w, minSize: h.MinSize}
+	h.Handler.ServeHTTP(rw, r)
+	rw.close()
 }
 
+// responseWriter buffers the start of the response body
+// until it reaches minSize bytes, then decides whether
+// to compress it.
 type responseWriter struct {
-	w                   io.Writer // w wraps only method Write
-	http.ResponseWriter           // embedded for the other methods
+	http.ResponseWriter // embedded for the other methods
+
+	minSize int
+	status  int
+	buf     []byte
+	gz      *gzip.Writer
+	plain   bool
 }
 
 var _ http.ResponseWriter = (*responseWriter)(nil)
 var _ http.Hijacker = (*responseWriter)(nil)
 
-func (w *responseWriter) Write(p []byte) (int, error) { return w.w.Write(p) }
+func (w *responseWriter) WriteHeader(code int) {
+	if w.gz != nil || w.plain {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(p)
+	case w.plain:
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.minSize {
+		return len(p), nil
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.writeStatus()
+	w.gz = getWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf)
+	w.buf = nil
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *responseWriter) writeStatus() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// close finishes the response, sending any
+// buffered body uncompressed if it never
+// reached minSize.
+func (w *responseWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+		pool.Put(w.gz)
+		return
+	}
+	if !w.plain {
+		w.plain = true
+		w.writeStatus()
+		if len(w.buf) > 0 {
+			w.ResponseWriter.Write(w.buf)
+		}
+	}
+}
 
 func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	h, ok := w.ResponseWriter.(http.Hijacker)
@@ -1,7 +1,9 @@
 package gzip
 
 import (
+	"compress/gzip"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -28,7 +30,7 @@ func (n noOpWriter) WriteHeader(int) {}
 func BenchmarkGzipSmall(b *testing.B) {
 	r, _ := http.NewRequest("GET", "/foo", nil)
 	r.Header.Set("accept-encoding", "gzip")
-	h := Handler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	h := Handler{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write(small)
 	})}
 	w := noOpWriter{header: http.Header{}}
@@ -42,7 +44,7 @@ func BenchmarkGzipSmall(b *testing.B) {
 func BenchmarkGzipMedium(b *testing.B) {
 	r, _ := http.NewRequest("GET", "/foo", nil)
 	r.Header.Set("accept-encoding", "gzip")
-	h := Handler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	h := Handler{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write(medium)
 	})}
 	w := noOpWriter{header: http.Header{}}
@@ -56,7 +58,7 @@ func BenchmarkGzipMedium(b *testing.B) {
 func BenchmarkGzipLarge(b *testing.B) {
 	r, _ := http.NewRequest("GET", "/foo", nil)
 	r.Header.Set("accept-encoding", "gzip")
-	h := Handler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	h := Handler{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write(large)
 	})}
 	w := noOpWriter{header: http.Header{}}
@@ -71,7 +73,7 @@ func TestGzip(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/foo", nil)
 	r.Header.Set("accept-encoding", "gzip")
-	h := Handler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	h := Handler{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		io.WriteString(w, "hello, world")
 	})}
 	h.ServeHTTP(w, r)
@@ -83,7 +85,7 @@ func TestGzip(t *testing.T) {
 func TestNoGzip(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/foo", nil)
-	h := Handler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	h := Handler{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		io.WriteString(w, "hello, world")
 	})}
 	h.ServeHTTP(w, r)
@@ -91,3 +93,48 @@ func TestNoGzip(t *testing.T) {
 		t.Error("unexpected gzip")
 	}
 }
+
+func TestGzipMinSize(t *testing.T) {
+	cases := []struct {
+		body     string
+		wantGzip bool
+	}{
+		{"hello, world", false},
+		{string(medium), true},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		r.Header.Set("accept-encoding", "gzip")
+		h := Handler{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, c.body)
+			}),
+			MinSize: 100,
+		}
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusCreated {
+			t.Errorf("len %d: status = %d want %d", len(c.body), w.Code, http.StatusCreated)
+		}
+		gotGzip := w.HeaderMap.Get("content-encoding") == "gzip"
+		if gotGzip != c.wantGzip {
+			t.Errorf("len %d: gzip = %v want %v", len(c.body), gotGzip, c.wantGzip)
+			continue
+		}
+		body := w.Body.Bytes()
+		if gotGzip {
+			gz, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err = ioutil.ReadAll(gz)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if string(body) != c.body {
+			t.Errorf("len %d: body = %q want %q", len(c.body), body, c.body)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package limit
+
+import (
+	"expvar"
+	"net/http"
+)
+
+type concurrencyHandler struct {
+	next     http.Handler
+	limited  http.Handler
+	sem      chan struct{}
+	inflight *expvar.Int
+	queued   *expvar.Int
+}
+
+// Concurrency returns a handler that serves at most max requests
+// with next at a time. Further requests wait for a free slot
+// until their context is done, in which case they are served
+// with limited instead.
+//
+// If inflight and queued are non-nil, they track the number of
+// requests being served and the number waiting.
+func Concurrency(next, limited http.Handler, max int, inflight, queued *expvar.Int) http.Handler {
+	if inflight == nil {
+		inflight = new(expvar.Int)
+	}
+	if queued == nil {
+		queued = new(expvar.Int)
+	}
+	return &concurrencyHandler{
+		next:     next,
+		limited:  limited,
+		sem:      make(chan struct{}, max),
+		inflight: inflight,
+		queued:   queued,
+	}
+}
+
+func (h *concurrencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case h.sem <- struct{}{}:
+	default:
+		h.queued.Add(1)
+		select {
+		case h.sem <- struct{}{}:
+			h.queued.Add(-1)
+		case <-r.Context().Done():
+			h.queued.Add(-1)
+			h.limited.ServeHTTP(w, r)
+			return
+		}
+	}
+	h.inflight.Add(1)
+	defer func() {
+		h.inflight.Add(-1)
+		<-h.sem
+	}()
+	h.next.ServeHTTP(w, r)
+}
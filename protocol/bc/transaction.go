@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"strconv"
 
 	"chain/crypto/sha3pool"
@@ -321,6 +322,13 @@ func (tx *TxData) WriteTo(w io.Writer) (int64, error) {
 	return ew.Written(), ew.Err()
 }
 
+// SerializedSize returns the number of bytes
+// written by WriteTo.
+func (tx *TxData) SerializedSize() int64 {
+	n, _ := tx.WriteTo(ioutil.Discard) // error is impossible
+	return n
+}
+
 // assumes w has sticky errors
 func (tx *TxData) writeTo(w io.Writer, serflags byte) {
 	w.Write([]byte{serflags})
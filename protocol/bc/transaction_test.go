@@ -165,6 +165,9 @@ func TestTransaction(t *testing.T) {
 		if g := test.tx.WitnessHash(); g != test.witnessHash {
 			t.Errorf("test %d: witness hash = %s want %x", i, g, test.witnessHash)
 		}
+		if g := test.tx.SerializedSize(); g != int64(len(want)) {
+			t.Errorf("test %d: serialized size = %d want %d", i, g, len(want))
+		}
 
 		txJSON, err := json.Marshal(test.tx)
 		if err != nil {
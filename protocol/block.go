@@ -3,6 +3,7 @@ package protocol
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -16,17 +17,25 @@ import (
 	"chain/protocol/vmutil"
 )
 
-// maxBlockTxs limits the number of transactions
+// MaxBlockTxs limits the number of transactions
 // included in each block.
-const maxBlockTxs = 10000
+const MaxBlockTxs = 10000
 
 // saveSnapshotFrequency stores how often to save a state
 // snapshot to the Store.
 const saveSnapshotFrequency = time.Hour
 
+// MaxBlockTimeDrift is how far ahead of a node's clock
+// a new block's timestamp may be.
+const MaxBlockTimeDrift = 10 * time.Second
+
 // ErrBadBlock is returned when a block is invalid.
 var ErrBadBlock = errors.New("invalid block")
 
+// ErrFutureBlock is returned when a new block's timestamp
+// is too far ahead of the local clock.
+var ErrFutureBlock = errors.New("block timestamp is too far in the future")
+
 // ErrStaleState is returned when the Chain does not have a current
 // blockchain state.
 var ErrStaleState = errors.New("stale blockchain state")
@@ -41,8 +50,9 @@ func (c *Chain) GetBlock(ctx context.Context, height uint64) (*bc.Block, error)
 // the current pending transaction pool. It returns the new block and
 // a snapshot of what the state snapshot is if the block is applied.
 //
-// After generating the block, the pending transaction pool will be
-// empty.
+// After generating the block, the pending transaction pool will
+// hold only future-dated transactions, whose min time is after the
+// new block's timestamp. They are considered again for later blocks.
 func (c *Chain) GenerateBlock(ctx context.Context, prev *bc.Block, snapshot *state.Snapshot, now time.Time) (b *bc.Block, result *state.Snapshot, err error) {
 	timestampMS := bc.Millis(now)
 	if timestampMS < prev.TimestampMS {
@@ -53,10 +63,23 @@ func (c *Chain) GenerateBlock(ctx context.Context, prev *bc.Block, snapshot *sta
 	result = state.Copy(snapshot)
 	result.PruneIssuances(timestampMS)
 
-	txs, err := c.pool.Dump(ctx)
+	// Dump empties the pool, so the filter must be ready
+	// before it: a transaction can be dropped only for
+	// being rejected, not for the filter failing to load.
+	if c.TxFilter != nil {
+		err = c.TxFilter.Refresh(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "refreshing tx filter")
+		}
+	}
+
+	txs, err := c.pool.Dump(ctx, timestampMS)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "get pool TXs")
 	}
+	if c.TxPriority != nil && len(txs) > MaxBlockTxs {
+		txs = prioritize(txs, c.TxPriority)
+	}
 
 	b = &bc.Block{
 		BlockHeader: bc.BlockHeader{
@@ -69,10 +92,18 @@ func (c *Chain) GenerateBlock(ctx context.Context, prev *bc.Block, snapshot *sta
 	}
 
 	for _, tx := range txs {
-		if len(b.Transactions) >= maxBlockTxs {
+		if len(b.Transactions) >= MaxBlockTxs {
 			break
 		}
 
+		if c.TxFilter != nil {
+			err := c.TxFilter.Check(tx)
+			if err != nil {
+				log.Error(ctx, err, "dropping filtered tx "+tx.Hash.String())
+				continue
+			}
+		}
+
 		if validation.ConfirmTx(result, c.InitialBlockHash, b, tx) == nil {
 			validation.ApplyTx(result, tx)
 			b.Transactions = append(b.Transactions, tx)
@@ -83,6 +114,31 @@ func (c *Chain) GenerateBlock(ctx context.Context, prev *bc.Block, snapshot *sta
 	return b, result, nil
 }
 
+// prioritize returns txs, which are in topological order, sorted
+// by priority under p, highest first. A transaction that spends
+// the output of another in txs ranks no higher than that one,
+// so it still comes after it.
+func prioritize(txs []*bc.Tx, p TxPriority) []*bc.Tx {
+	prio := make(map[bc.Hash]int, len(txs))
+	for _, tx := range txs {
+		n := p.Priority(tx)
+		for _, in := range tx.Inputs {
+			if in.IsIssuance() {
+				continue
+			}
+			if prev, ok := prio[in.Outpoint().Hash]; ok && prev < n {
+				n = prev
+			}
+		}
+		prio[tx.Hash] = n
+	}
+	sorted := append([]*bc.Tx(nil), txs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return prio[sorted[i].Hash] > prio[sorted[j].Hash]
+	})
+	return sorted
+}
+
 // ValidateBlock performs validation on an incoming block, in advance
 // of committing the block. ValidateBlock returns the state after
 // the block has been applied.
@@ -205,10 +261,26 @@ func (c *Chain) ValidateBlockForSig(ctx context.Context, block *bc.Block) error
 	// TODO(kr): cache the applied snapshot, and maybe
 	// we can skip re-applying it later
 	snapshot = state.Copy(snapshot)
-	err := validation.ValidateBlock(ctx, snapshot, c.InitialBlockHash, prev, block, validation.CheckTxWellFormed)
+	err := validation.ValidateBlock(ctx, snapshot, c.InitialBlockHash, prev, block, c.ValidateTxCached)
 	return errors.Wrap(err, "validation")
 }
 
+// CheckBlockTime returns ErrFutureBlock if the timestamp of b,
+// a new block, is more than MaxBlockTimeDrift ahead of now.
+//
+// Block timestamps must not decrease, so one far in the future
+// would stall the chain until the generator's clock caught up,
+// and time-locked contracts would settle early. Validation only
+// requires a block to be no earlier than its predecessor, since
+// old blocks must stay valid; block signers apply this rule too
+// before signing a new block.
+func CheckBlockTime(b *bc.Block, now time.Time) error {
+	if drift := b.Time().Sub(now); drift > MaxBlockTimeDrift {
+		return errors.WithDetailf(ErrFutureBlock, "block %d is %s ahead of the local clock", b.Height, drift)
+	}
+	return nil
+}
+
 func NewInitialBlock(pubkeys []ed25519.PublicKey, nSigs int, timestamp time.Time) (*bc.Block, error) {
 	script, err := vmutil.BlockMultiSigProgram(pubkeys, nSigs)
 	if err != nil {
@@ -198,6 +198,66 @@ func TestGenerateBlock(t *testing.T) {
 	}
 }
 
+func TestGenerateBlockHoldsFutureTx(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(233400000, 0)
+	c, b1 := newTestChain(t, now)
+
+	tx := bc.NewTx(bc.TxData{
+		Version: 1,
+		MinTime: bc.Millis(now.Add(time.Hour)),
+		MaxTime: bc.Millis(now.Add(2 * time.Hour)),
+	})
+	err := c.pool.Insert(ctx, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	got, _, err := c.GenerateBlock(ctx, b1, state.Empty(), now)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(got.Transactions) != 0 {
+		t.Errorf("block has %d txs, want 0", len(got.Transactions))
+	}
+	pending, err := c.pool.Dump(ctx, bc.Millis(now.Add(time.Hour)))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(pending) != 1 || pending[0].Hash != tx.Hash {
+		t.Errorf("pool = %v, want [%v]", pending, tx.Hash)
+	}
+}
+
+type mapPriority map[bc.Hash]int
+
+func (m mapPriority) Priority(tx *bc.Tx) int { return m[tx.Hash] }
+
+func TestPrioritize(t *testing.T) {
+	issue := func(n uint64) *bc.Tx {
+		return bc.NewTx(bc.TxData{Inputs: []*bc.TxInput{
+			bc.NewIssuanceInput([]byte{byte(n)}, n, nil, bc.Hash{}, nil, nil),
+		}})
+	}
+	spend := func(prev *bc.Tx) *bc.Tx {
+		return bc.NewTx(bc.TxData{Inputs: []*bc.TxInput{
+			bc.NewSpendInput(prev.Hash, 0, nil, bc.AssetID{}, 1, nil, nil),
+		}})
+	}
+	low, mid, high := issue(1), issue(2), issue(3)
+	child := spend(low) // high priority, but spends low
+	prio := mapPriority{low.Hash: 0, mid.Hash: 1, high.Hash: 2, child.Hash: 2}
+
+	var got []bc.Hash
+	for _, tx := range prioritize([]*bc.Tx{low, mid, child, high}, prio) {
+		got = append(got, tx.Hash)
+	}
+	want := []bc.Hash{high.Hash, mid.Hash, low.Hash, child.Hash}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("prioritize:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
 func TestValidateBlockForSig(t *testing.T) {
 	initialBlock, err := NewInitialBlock(testutil.TestPubs, 1, time.Now())
 	if err != nil {
@@ -272,3 +332,23 @@ func mustDecodeHex(s string) []byte {
 	}
 	return data
 }
+
+func TestCheckBlockTime(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		offset time.Duration
+		want   error
+	}{
+		{-time.Hour, nil},
+		{0, nil},
+		{MaxBlockTimeDrift, nil},
+		{MaxBlockTimeDrift + time.Second, ErrFutureBlock},
+	}
+	for _, c := range cases {
+		b := &bc.Block{BlockHeader: bc.BlockHeader{Height: 2, TimestampMS: bc.Millis(now.Add(c.offset))}}
+		err := CheckBlockTime(b, now)
+		if errors.Root(err) != c.want {
+			t.Errorf("CheckBlockTime(now%+v) = %v, want %v", c.offset, err, c.want)
+		}
+	}
+}
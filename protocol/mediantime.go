@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+)
+
+// medianTimeBlocks is how many of the latest
+// blocks MedianTime takes the median time of.
+const medianTimeBlocks = 11
+
+// MedianTime returns the median timestamp of the latest
+// medianTimeBlocks blocks, or of every block if there are fewer.
+// It returns the zero time if there are no blocks.
+//
+// The median time lags the latest block's timestamp, but a single
+// block with a skewed timestamp cannot move it. Transaction
+// builders that fix a contract's time, such as the price of an
+// auction, should use it as the transaction's mintime instead of
+// a local clock: every later block's timestamp is at least the
+// median time, so the transaction can always be confirmed.
+func (c *Chain) MedianTime(ctx context.Context) (time.Time, error) {
+	height := c.Height()
+	if height == 0 {
+		return time.Time{}, nil
+	}
+	low := uint64(1)
+	if height > medianTimeBlocks {
+		low = height - medianTimeBlocks + 1
+	}
+
+	// Block timestamps never decrease, so the median
+	// is the timestamp of the middle block.
+	b, err := c.store.GetBlock(ctx, low+(height-low)/2)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "getting block for median time")
+	}
+	return b.Time(), nil
+}
@@ -0,0 +1,59 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/protocol/bc"
+	"chain/protocol/mempool"
+	"chain/protocol/memstore"
+)
+
+func TestMedianTime(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	c, err := NewChain(ctx, bc.Hash{}, store, mempool.New(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.MedianTime(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsZero() {
+		t.Errorf("MedianTime() with no blocks = %s, want zero time", got)
+	}
+
+	// Block h has timestamp h seconds.
+	cases := []struct {
+		height uint64
+		want   uint64 // seconds
+	}{
+		{1, 1},
+		{2, 1},
+		{3, 2},
+		{10, 5},
+		{11, 6},
+		{20, 15},
+	}
+	for h := uint64(1); h <= 20; h++ {
+		b := &bc.Block{BlockHeader: bc.BlockHeader{Height: h, TimestampMS: h * 1000}}
+		err := store.SaveBlock(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range cases {
+		c.state.height = tc.height
+		got, err := c.MedianTime(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := time.Unix(int64(tc.want), 0).UTC()
+		if !got.Equal(want) {
+			t.Errorf("MedianTime() at height %d = %s, want %s", tc.height, got, want)
+		}
+	}
+}
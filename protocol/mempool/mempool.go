@@ -27,10 +27,18 @@ func (m *MemPool) Insert(ctx context.Context, tx *bc.Tx) error {
 	return nil
 }
 
-// Dump returns all pending transactions in the pool and
-// empties the pool.
-func (m *MemPool) Dump(context.Context) ([]*bc.Tx, error) {
-	txs := m.pool[:len(m.pool):len(m.pool)]
-	m.pool = nil
+// Dump returns the pending transactions in the pool whose
+// min time is at or before timestampMS and removes them from
+// the pool.
+func (m *MemPool) Dump(_ context.Context, timestampMS uint64) ([]*bc.Tx, error) {
+	var txs, held []*bc.Tx
+	for _, tx := range m.pool {
+		if tx.MinTime > timestampMS {
+			held = append(held, tx)
+		} else {
+			txs = append(txs, tx)
+		}
+	}
+	m.pool = held
 	return txs, nil
 }
@@ -57,7 +57,9 @@ import (
 )
 
 // maxCachedValidatedTxs is the max number of validated txs to cache.
-const maxCachedValidatedTxs = 1000
+// It is enough to hold every tx in a full block, so that txs checked
+// on submission need not be checked again when their block lands.
+const maxCachedValidatedTxs = MaxBlockTxs
 
 var (
 	// ErrTheDistantFuture is returned when waiting for a blockheight
@@ -93,9 +95,31 @@ type Pool interface {
 	// It is required to be idempotent.
 	Insert(context.Context, *bc.Tx) error
 
-	// Dump wipes the pending transaction pool and returns all
-	// transactions that were in the pool.
-	Dump(context.Context) ([]*bc.Tx, error)
+	// Dump removes from the pool and returns all transactions
+	// whose min time is at or before timestampMS. Future-dated
+	// transactions stay in the pool until a block can include them.
+	Dump(ctx context.Context, timestampMS uint64) ([]*bc.Tx, error)
+}
+
+// TxFilter decides which transactions a generator accepts,
+// such as those not spending blocked outputs.
+type TxFilter interface {
+	// Refresh brings the filter up to date. It is called
+	// before the filter is consulted, and a failure stops
+	// whatever needed the filter.
+	Refresh(context.Context) error
+
+	// Check returns an error if tx must be rejected.
+	// It does no I/O, so any error is a rejection.
+	Check(*bc.Tx) error
+}
+
+// TxPriority ranks the pool transactions a generator considers
+// for a block, for when there are more than fit.
+type TxPriority interface {
+	// Priority returns the priority of tx. Transactions of
+	// higher priority are included first.
+	Priority(*bc.Tx) int
 }
 
 // Chain provides a complete, minimal blockchain database. It
@@ -106,6 +130,20 @@ type Chain struct {
 	InitialBlockHash  bc.Hash
 	MaxIssuanceWindow time.Duration // only used by generators
 
+	// MaxFutureTime is how far in the future a transaction's
+	// min time may be for the pool to accept it. 0 means no limit.
+	MaxFutureTime time.Duration
+
+	// TxFilter, if set, is consulted before a transaction is added
+	// to the pool and again before it is included in a block. Only
+	// used by generators.
+	TxFilter TxFilter
+
+	// TxPriority, if set, orders the pool transactions
+	// considered for a block when there are more than
+	// MaxBlockTxs of them. Only used by generators.
+	TxPriority TxPriority
+
 	blockCallbacks []BlockCallback
 	state          struct {
 		cond     sync.Cond // protects height, block, snapshot
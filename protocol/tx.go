@@ -3,6 +3,7 @@ package protocol
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/golang/groupcache/lru"
 
@@ -36,6 +37,26 @@ func (c *Chain) AddTx(ctx context.Context, tx *bc.Tx) error {
 		return errors.Wrap(err, "tx rejected")
 	}
 
+	// A future-dated transaction waits in the pool until its
+	// min time. Without a max time, it could wait forever.
+	if tx.MinTime > bc.Millis(time.Now()) && tx.MaxTime == 0 {
+		return errors.WithDetail(validation.ErrBadTx, "future-dated transaction has no max time")
+	}
+	if c.MaxFutureTime != 0 && tx.MinTime > bc.Millis(time.Now().Add(c.MaxFutureTime)) {
+		return errors.WithDetailf(validation.ErrBadTx, "min time is more than %s in the future", c.MaxFutureTime)
+	}
+
+	if c.TxFilter != nil {
+		err = c.TxFilter.Refresh(ctx)
+		if err != nil {
+			return errors.Wrap(err, "refreshing tx filter")
+		}
+		err = c.TxFilter.Check(tx)
+		if err != nil {
+			return errors.Wrap(err, "tx rejected")
+		}
+	}
+
 	// Update persistent tx pool state.
 	err = c.pool.Insert(ctx, tx)
 	return errors.Wrap(err, "applying tx to store")
@@ -43,15 +64,20 @@ func (c *Chain) AddTx(ctx context.Context, tx *bc.Tx) error {
 
 // ValidateTxCached checks a cache of prevalidated transactions
 // before attempting to perform a context-free validation of the tx.
+//
+// The cache is keyed by the witness hash, not the tx hash,
+// since the tx hash doesn't commit to the input witnesses
+// that carry the signatures being checked.
 func (c *Chain) ValidateTxCached(tx *bc.Tx) error {
 	// Consult a cache of prevalidated transactions.
-	err, ok := c.prevalidated.lookup(tx.Hash)
+	key := tx.WitnessHash()
+	err, ok := c.prevalidated.lookup(key)
 	if ok {
 		return err
 	}
 
 	err = validation.CheckTxWellFormed(tx)
-	c.prevalidated.cache(tx.Hash, err)
+	c.prevalidated.cache(key, err)
 	return err
 }
 
@@ -60,9 +86,9 @@ type prevalidatedTxsCache struct {
 	lru *lru.Cache
 }
 
-func (c *prevalidatedTxsCache) lookup(txID bc.Hash) (err error, ok bool) {
+func (c *prevalidatedTxsCache) lookup(key bc.Hash) (err error, ok bool) {
 	c.mu.Lock()
-	v, ok := c.lru.Get(txID)
+	v, ok := c.lru.Get(key)
 	c.mu.Unlock()
 	if !ok {
 		return err, ok
@@ -73,9 +99,9 @@ func (c *prevalidatedTxsCache) lookup(txID bc.Hash) (err error, ok bool) {
 	return v.(error), ok
 }
 
-func (c *prevalidatedTxsCache) cache(txID bc.Hash, err error) {
+func (c *prevalidatedTxsCache) cache(key bc.Hash, err error) {
 	c.mu.Lock()
-	c.lru.Add(txID, err)
+	c.lru.Add(key, err)
 	c.mu.Unlock()
 }
 
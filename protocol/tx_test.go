@@ -76,6 +76,145 @@ func TestAddTxBadMaxIssuanceWindow(t *testing.T) {
 	}
 }
 
+func TestAddTxFarFuture(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestChain(t, time.Now())
+	c.MaxFutureTime = time.Hour
+
+	asset, dest := newAsset(t), newDest(t)
+	assetCP, _ := asset.controlProgram()
+	destCP, _ := dest.controlProgram()
+	txdata := &bc.TxData{
+		Version: bc.CurrentTransactionVersion,
+		Inputs: []*bc.TxInput{
+			bc.NewIssuanceInput([]byte{1}, 1, nil, bc.Hash{}, assetCP, nil),
+		},
+		Outputs: []*bc.TxOutput{
+			bc.NewTxOutput(asset.AssetID, 1, destCP, nil),
+		},
+		MinTime: bc.Millis(time.Now().Add(2 * time.Hour)),
+		MaxTime: bc.Millis(time.Now().Add(3 * time.Hour)),
+	}
+	asset.sign(t, txdata, 0)
+	tx := bc.NewTx(*txdata)
+
+	err := c.AddTx(ctx, tx)
+	if errors.Root(err) != validation.ErrBadTx {
+		t.Errorf("AddTx(tx 2h ahead) = %v, want %v", err, validation.ErrBadTx)
+	}
+	c.MaxFutureTime = 3 * time.Hour
+	err = c.AddTx(ctx, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
+// testFilter rejects the transactions in blocked, and fails
+// to refresh with refreshErr.
+type testFilter struct {
+	refreshErr error
+	blocked    map[bc.Hash]bool
+}
+
+var errFiltered = errors.New("filtered")
+
+func (f *testFilter) Refresh(context.Context) error { return f.refreshErr }
+
+func (f *testFilter) Check(tx *bc.Tx) error {
+	if f.blocked[tx.Hash] {
+		return errFiltered
+	}
+	return nil
+}
+
+func TestTxFilter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c, b1 := newTestChain(t, now)
+	errRefresh := errors.New("refresh failed")
+	filt := &testFilter{blocked: make(map[bc.Hash]bool)}
+	c.TxFilter = filt
+
+	issued, _, _ := issue(t, nil, nil, 1)
+	filt.blocked[issued.Hash] = true
+	err := c.AddTx(ctx, issued)
+	if errors.Root(err) != errFiltered {
+		t.Errorf("AddTx(blocked tx) = %v, want %v", err, errFiltered)
+	}
+	delete(filt.blocked, issued.Hash)
+	filt.refreshErr = errRefresh
+	err = c.AddTx(ctx, issued)
+	if errors.Root(err) != errRefresh {
+		t.Errorf("AddTx with failing filter = %v, want %v", err, errRefresh)
+	}
+
+	// Both transactions reach the pool, and the second
+	// is blocked only later.
+	var txs []*bc.Tx
+	for i := 1; i <= 2; i++ {
+		tx := bc.NewTx(bc.TxData{
+			Version: 1,
+			MaxTime: bc.Millis(now.Add(time.Duration(i) * time.Hour)),
+		})
+		err = c.pool.Insert(ctx, tx)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		txs = append(txs, tx)
+	}
+	filt.blocked[txs[1].Hash] = true
+
+	// A filter failure leaves the pool as it was.
+	_, _, err = c.GenerateBlock(ctx, b1, state.Empty(), now)
+	if errors.Root(err) != errRefresh {
+		t.Errorf("GenerateBlock with failing filter = %v, want %v", err, errRefresh)
+	}
+
+	filt.refreshErr = nil
+	b, _, err := c.GenerateBlock(ctx, b1, state.Empty(), now)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(b.Transactions) != 1 || b.Transactions[0].Hash != txs[0].Hash {
+		t.Errorf("block txs = %v, want [%v]", b.Transactions, txs[0].Hash)
+	}
+}
+
+func TestValidateTxCachedWitness(t *testing.T) {
+	c, _ := newTestChain(t, time.Now())
+
+	good, _, _ := issue(t, nil, nil, 1)
+
+	// Same tx hash, but signed by the wrong key.
+	b, err := good.MarshalText()
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	var badData bc.TxData
+	err = badData.UnmarshalText(b)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	newDest(t).sign(t, &badData, 0)
+	bad := bc.NewTx(badData)
+	if bad.Hash != good.Hash {
+		t.Fatal("expected re-signed tx to have the same hash")
+	}
+
+	err = c.ValidateTxCached(bad)
+	if err == nil {
+		t.Fatal("expected badly signed tx to fail validation")
+	}
+	err = c.ValidateTxCached(good)
+	if err != nil {
+		t.Errorf("good tx got cached error from badly signed tx: %v", err)
+	}
+	err = c.ValidateTxCached(bad)
+	if err == nil {
+		t.Error("badly signed tx passed validation after good tx was cached")
+	}
+}
+
 type testDest struct {
 	privKey ed25519.PrivateKey
 }
@@ -0,0 +1,46 @@
+package vmutil
+
+import (
+	"encoding/binary"
+
+	"chain/protocol/vm"
+)
+
+// Assembler is a Builder for programs with forward jumps.
+// It fills in the jumps' addresses once the whole program
+// is known.
+type Assembler struct {
+	*Builder
+	labels map[string]int
+	jumps  map[int]string // offset of a jump address -> its label
+}
+
+func NewAssembler() *Assembler {
+	return &Assembler{
+		Builder: NewBuilder(),
+		labels:  make(map[string]int),
+		jumps:   make(map[int]string),
+	}
+}
+
+// Label marks the current end of the program
+// as the target of jumps to name.
+func (a *Assembler) Label(name string) {
+	a.labels[name] = len(a.Program)
+}
+
+// Jump adds op, a JUMP or JUMPIF, with the address
+// of label as its operand.
+func (a *Assembler) Jump(op vm.Op, label string) {
+	a.AddOp(op)
+	a.jumps[len(a.Program)] = label
+	a.AddRawBytes(make([]byte, 4))
+}
+
+// Build returns the program with its jump addresses filled in.
+func (a *Assembler) Build() []byte {
+	for at, label := range a.jumps {
+		binary.LittleEndian.PutUint32(a.Program[at:], uint32(a.labels[label]))
+	}
+	return a.Program
+}
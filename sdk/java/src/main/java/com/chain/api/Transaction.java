@@ -388,6 +388,15 @@ public class Transaction {
       return this;
     }
 
+    /**
+     * Returns whether signatures on the template commit only to the
+     * elements in the transaction so far. See {@link #allowAdditionalActions()}.
+     * @return true if the template allows additional actions
+     */
+    public boolean allowsAdditionalActions() {
+      return this.allowAdditionalActions;
+    }
+
     /**
      * A single signing instruction included in a transaction template.
      */
@@ -413,6 +422,14 @@ public class Transaction {
        */
       @SerializedName("witness_components")
       public WitnessComponent[] witnessComponents;
+
+      /**
+       * What an inferred signature program for the input commits to:
+       * null or empty (the default) for the whole transaction,
+       * "anyone_can_pay", or "single_output".
+       */
+      @SerializedName("sighash_mode")
+      public String sigHashMode;
     }
 
     /**
@@ -0,0 +1,114 @@
+package com.chain.signing;
+
+import net.i2p.crypto.eddsa.spec.EdDSANamedCurveSpec;
+import net.i2p.crypto.eddsa.spec.EdDSANamedCurveTable;
+
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.util.Arrays;
+
+/**
+ * ChainKd implements the parts of Chain's extended key scheme for
+ * ed25519 that a signer needs: public key computation, non-hardened
+ * derivation of private keys, and signing. Extended private keys are
+ * 64 bytes: a 32-byte scalar followed by a 32-byte chain code.
+ */
+final class ChainKd {
+  private static final EdDSANamedCurveSpec ED25519 =
+      EdDSANamedCurveTable.getByName(EdDSANamedCurveTable.CURVE_ED25519_SHA512);
+
+  private static final byte[] ONE = new byte[32];
+
+  static {
+    ONE[0] = 1;
+  }
+
+  private ChainKd() {}
+
+  /**
+   * Returns the extended public key of an extended private key.
+   */
+  static byte[] xpub(byte[] xprv) {
+    byte[] xpub = new byte[64];
+    System.arraycopy(publicKey(xprv), 0, xpub, 0, 32);
+    System.arraycopy(xprv, 32, xpub, 32, 32);
+    return xpub;
+  }
+
+  /**
+   * Derives the non-hardened child of xprv for each selector in
+   * path in turn.
+   */
+  static byte[] derive(byte[] xprv, byte[][] path) {
+    byte[] res = xprv;
+    for (byte[] sel : path) {
+      res = child(res, sel);
+    }
+    return res;
+  }
+
+  private static byte[] child(byte[] xprv, byte[] sel) {
+    MessageDigest h = sha512();
+    h.update((byte) 1);
+    h.update(publicKey(xprv));
+    h.update(xprv, 32, 32);
+    h.update(RawTransaction.uvarint(sel.length));
+    h.update(sel);
+    byte[] res = h.digest();
+    modifyScalar(res);
+
+    byte[] f = Arrays.copyOf(res, 32);
+    byte[] s = Arrays.copyOf(xprv, 32);
+    byte[] s2 = ED25519.getScalarOps().multiplyAndAdd(ONE, f, s);
+    System.arraycopy(s2, 0, res, 0, 32);
+    return res;
+  }
+
+  /**
+   * Signs msg with the key xprv.
+   */
+  static byte[] sign(byte[] xprv, byte[] msg) {
+    byte[] s = Arrays.copyOf(xprv, 32);
+    byte[] pubkey = publicKey(xprv);
+
+    MessageDigest h = sha512();
+    h.update((byte) 2);
+    h.update(xprv);
+    byte[] nonceKey = Arrays.copyOf(h.digest(), 32);
+
+    h.update(nonceKey);
+    h.update(msg);
+    byte[] r = ED25519.getScalarOps().reduce(h.digest());
+    byte[] R = ED25519.getB().scalarMultiply(r).toByteArray();
+
+    h.update(R);
+    h.update(pubkey);
+    h.update(msg);
+    byte[] k = ED25519.getScalarOps().reduce(h.digest());
+    byte[] S = ED25519.getScalarOps().multiplyAndAdd(k, s, r);
+
+    byte[] sig = new byte[64];
+    System.arraycopy(R, 0, sig, 0, 32);
+    System.arraycopy(S, 0, sig, 32, 32);
+    return sig;
+  }
+
+  private static byte[] publicKey(byte[] xprv) {
+    return ED25519.getB().scalarMultiply(Arrays.copyOf(xprv, 32)).toByteArray();
+  }
+
+  private static void modifyScalar(byte[] s) {
+    s[0] &= (byte) 248;
+    s[31] &= 127;
+    s[31] |= 64;
+  }
+
+  private static MessageDigest sha512() {
+    try {
+      return MessageDigest.getInstance("SHA-512");
+    } catch (NoSuchAlgorithmException e) {
+      // Every Java platform is required to support SHA-512.
+      throw new IllegalStateException(e);
+    }
+  }
+}
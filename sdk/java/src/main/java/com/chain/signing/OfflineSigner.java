@@ -0,0 +1,146 @@
+package com.chain.signing;
+
+import com.chain.api.Transaction;
+import com.chain.exception.ChainException;
+
+import org.bouncycastle.util.encoders.Hex;
+
+import java.util.*;
+
+/**
+ * OfflineSigner signs transaction templates with extended private
+ * keys held in this process, without calling an HSM or a Chain Core.
+ * It computes signature programs and signatures exactly as Chain
+ * Core's signer does, so its templates can be submitted as usual.
+ * Only templates with keys added to the OfflineSigner will be signed.
+ */
+public class OfflineSigner {
+  /**
+   * A map of hex-encoded extended public keys to the corresponding
+   * extended private keys.
+   */
+  private static Map<String, byte[]> xprvs = new HashMap<>();
+
+  /**
+   * Adds an extended private key to the OfflineSigner.
+   * @param xprv the hex-encoded extended private key
+   * @return the hex-encoded extended public key of xprv, as it
+   *         appears in the signing instructions of templates
+   * @throws ChainException if xprv is not a 64-byte hex string
+   */
+  public static String addKey(String xprv) throws ChainException {
+    byte[] key;
+    try {
+      key = Hex.decode(xprv);
+    } catch (RuntimeException e) {
+      throw new ChainException("bad key string");
+    }
+    if (key.length != 64) {
+      throw new ChainException("bad key length");
+    }
+    String xpub = Hex.toHexString(ChainKd.xpub(key));
+    xprvs.put(xpub, key);
+    return xpub;
+  }
+
+  /**
+   * Signs a transaction template with the keys added to the
+   * OfflineSigner, then writes the signatures and other witness
+   * components into the template's raw transaction.
+   * @param template transaction template to be signed
+   * @return the signed transaction template
+   * @throws ChainException if the template is malformed
+   */
+  public static Transaction.Template sign(Transaction.Template template) throws ChainException {
+    RawTransaction tx = RawTransaction.decode(template.rawTransaction);
+    if (template.signingInstructions.size() > tx.inputs.size()) {
+      throw new ChainException("too many signing instructions in template");
+    }
+
+    for (Transaction.Template.SigningInstruction si : template.signingInstructions) {
+      if (si.position < 0 || si.position >= tx.inputs.size()) {
+        throw new ChainException("signing instruction references missing tx input " + si.position);
+      }
+      for (Transaction.Template.WitnessComponent wc : witnessComponents(si)) {
+        if ("signature".equals(wc.type)) {
+          signComponent(template, tx, si.position, wc);
+        }
+      }
+    }
+
+    for (Transaction.Template.SigningInstruction si : template.signingInstructions) {
+      List<byte[]> args = new ArrayList<>();
+      for (Transaction.Template.WitnessComponent wc : witnessComponents(si)) {
+        switch (wc.type) {
+          case "data":
+            args.add(Hex.decode(wc.data == null ? "" : wc.data));
+            break;
+          case "signature":
+            // The signature program's N: everything already in
+            // the arguments is input to it.
+            args.add(SigProgram.int64Bytes(args.size()));
+            int nsigs = 0;
+            for (int i = 0; i < wc.signatures.length && nsigs < wc.quorum; i++) {
+              if (!wc.signatures[i].isEmpty()) {
+                args.add(Hex.decode(wc.signatures[i]));
+                nsigs++;
+              }
+            }
+            args.add(Hex.decode(wc.program));
+            break;
+          default:
+            throw new ChainException("unknown witness component type '" + wc.type + "'");
+        }
+      }
+      tx.inputs.get(si.position).arguments = args;
+    }
+
+    template.rawTransaction = tx.encode();
+    return template;
+  }
+
+  private static void signComponent(
+      Transaction.Template template,
+      RawTransaction tx,
+      int position,
+      Transaction.Template.WitnessComponent wc)
+      throws ChainException {
+    if (wc.program == null || wc.program.isEmpty()) {
+      wc.program = Hex.toHexString(SigProgram.build(template, tx, position));
+    }
+    Transaction.Template.KeyID[] keys = wc.keys == null ? new Transaction.Template.KeyID[0] : wc.keys;
+
+    // Each key may produce a signature. Make sure there is a
+    // slot for each, preserving any signatures already present.
+    String[] sigs = new String[Math.max(keys.length, wc.signatures == null ? 0 : wc.signatures.length)];
+    Arrays.fill(sigs, "");
+    for (int i = 0; wc.signatures != null && i < wc.signatures.length; i++) {
+      if (wc.signatures[i] != null) {
+        sigs[i] = wc.signatures[i];
+      }
+    }
+    wc.signatures = sigs;
+
+    byte[] h = RawTransaction.sha3(Hex.decode(wc.program));
+    for (int i = 0; i < keys.length; i++) {
+      byte[] xprv = xprvs.get(keys[i].xpub);
+      if (!sigs[i].isEmpty() || xprv == null) {
+        continue;
+      }
+      String[] path = keys[i].derivationPath == null ? new String[0] : keys[i].derivationPath;
+      byte[][] sels = new byte[path.length][];
+      for (int j = 0; j < path.length; j++) {
+        sels[j] = Hex.decode(path[j]);
+      }
+      sigs[i] = Hex.toHexString(ChainKd.sign(ChainKd.derive(xprv, sels), h));
+    }
+  }
+
+  private static Transaction.Template.WitnessComponent[] witnessComponents(
+      Transaction.Template.SigningInstruction si) {
+    if (si.witnessComponents == null) {
+      return new Transaction.Template.WitnessComponent[0];
+    }
+    return si.witnessComponents;
+  }
+}
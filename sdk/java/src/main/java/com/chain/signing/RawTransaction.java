@@ -0,0 +1,303 @@
+package com.chain.signing;
+
+import com.chain.exception.ChainException;
+
+import org.bouncycastle.crypto.digests.SHA3Digest;
+import org.bouncycastle.util.encoders.Hex;
+
+import java.io.ByteArrayOutputStream;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.List;
+
+/**
+ * RawTransaction is a decoded transaction, with just enough
+ * structure to compute signature hashes and signature programs and
+ * to replace input witness arguments. It follows the transaction
+ * serialization in Chain Core's protocol/bc package.
+ */
+final class RawTransaction {
+  private static final int SER_WITNESS = 1;
+  private static final int SER_PREVOUT = 2;
+  private static final int SER_METADATA = 4;
+  private static final int SER_REQUIRED = SER_WITNESS | SER_PREVOUT | SER_METADATA;
+
+  long version;
+  long minTime;
+  long maxTime;
+  List<Input> inputs = new ArrayList<>();
+  List<Output> outputs = new ArrayList<>();
+  byte[] referenceData;
+
+  static class Input {
+    long assetVersion;
+    byte[] commitment;
+    byte[] referenceData;
+    boolean issuance;
+
+    // Spends only: the outpoint, and the output commitment
+    // being spent, as serialized.
+    byte[] outpointHash;
+    long outpointIndex;
+    byte[] outputCommitment;
+
+    // Issuances only: the initial block hash, VM version, and
+    // issuance program that precede the arguments in the witness.
+    byte[] witnessPrefix = new byte[0];
+
+    List<byte[]> arguments = new ArrayList<>();
+  }
+
+  static class Output {
+    long assetVersion;
+    byte[] commitment;
+    byte[] assetID;
+    long amount;
+    byte[] controlProgram;
+    byte[] referenceData;
+  }
+
+  /**
+   * Decodes a hex-encoded transaction, as found in the raw_transaction
+   * field of a transaction template.
+   */
+  static RawTransaction decode(String hex) throws ChainException {
+    try {
+      return read(new Reader(Hex.decode(hex)));
+    } catch (IndexOutOfBoundsException | IllegalArgumentException | IllegalStateException e) {
+      throw new ChainException("malformed raw transaction: " + e.getMessage());
+    }
+  }
+
+  private static RawTransaction read(Reader r) throws ChainException {
+    RawTransaction tx = new RawTransaction();
+    int serflags = r.readByte();
+    if (serflags != SER_REQUIRED) {
+      throw new ChainException("unsupported serflags " + serflags);
+    }
+    tx.version = r.readVarint();
+
+    Reader common = new Reader(r.readVarstr());
+    tx.minTime = common.readVarint();
+    tx.maxTime = common.readVarint();
+    r.readVarstr(); // common witness, empty in version 1
+
+    for (long n = r.readVarint(); n > 0; n--) {
+      Input in = new Input();
+      in.assetVersion = r.readVarint();
+      if (in.assetVersion != 1) {
+        throw new ChainException("unsupported asset version " + in.assetVersion);
+      }
+      in.commitment = r.readVarstr();
+      Reader ic = new Reader(in.commitment);
+      switch (ic.readByte()) {
+        case 0:
+          in.issuance = true;
+          break;
+        case 1:
+          in.outpointHash = ic.readBytes(32);
+          in.outpointIndex = ic.readVarint();
+          int start = ic.pos;
+          ic.readVarstr();
+          in.outputCommitment = Arrays.copyOfRange(in.commitment, start, ic.pos);
+          break;
+        default:
+          throw new ChainException("unsupported input type");
+      }
+      in.referenceData = r.readVarstr();
+
+      Reader w = new Reader(r.readVarstr());
+      if (in.issuance) {
+        w.readBytes(32); // initial block
+        w.readVarint(); // VM version
+        w.readVarstr(); // issuance program
+        in.witnessPrefix = Arrays.copyOfRange(w.buf, 0, w.pos);
+      }
+      for (long nargs = w.readVarint(); nargs > 0; nargs--) {
+        in.arguments.add(w.readVarstr());
+      }
+      tx.inputs.add(in);
+    }
+
+    for (long n = r.readVarint(); n > 0; n--) {
+      Output out = new Output();
+      out.assetVersion = r.readVarint();
+      if (out.assetVersion != 1) {
+        throw new ChainException("unsupported asset version " + out.assetVersion);
+      }
+      int start = r.pos;
+      Reader oc = new Reader(r.readVarstr());
+      out.commitment = Arrays.copyOfRange(r.buf, start, r.pos);
+      out.assetID = oc.readBytes(32);
+      out.amount = oc.readVarint();
+      oc.readVarint(); // VM version
+      out.controlProgram = oc.readVarstr();
+      out.referenceData = r.readVarstr();
+      r.readVarstr(); // output witness, empty in version 1
+      tx.outputs.add(out);
+    }
+
+    tx.referenceData = r.readVarstr();
+    return tx;
+  }
+
+  /**
+   * Returns the hex encoding of the transaction, including
+   * witnesses, as accepted by Chain Core.
+   */
+  String encode() {
+    return Hex.toHexString(serialize(SER_REQUIRED));
+  }
+
+  /**
+   * Returns the hash that a signature on input idx commits to
+   * when its signature program checks TXSIGHASH.
+   */
+  byte[] sigHash(int idx) {
+    SHA3Digest h = new SHA3Digest(256);
+    write(h, sha3(serialize(0)));
+    write(h, uvarint(idx));
+    Input in = inputs.get(idx);
+    if (in.issuance) {
+      write(h, sha3(new byte[0]));
+    } else {
+      write(h, sha3(in.outputCommitment));
+    }
+    byte[] out = new byte[32];
+    h.doFinal(out, 0);
+    return out;
+  }
+
+  private byte[] serialize(int serflags) {
+    Writer w = new Writer();
+    w.write(new byte[] {(byte) serflags});
+    w.writeVarint(version);
+
+    Writer common = new Writer();
+    common.writeVarint(minTime);
+    common.writeVarint(maxTime);
+    w.writeVarstr(common.toByteArray());
+    w.writeVarstr(new byte[0]); // common witness
+
+    w.writeVarint(inputs.size());
+    for (Input in : inputs) {
+      w.writeVarint(in.assetVersion);
+      w.writeVarstr(in.commitment);
+      w.writeVarstr(in.referenceData);
+      if ((serflags & SER_WITNESS) != 0) {
+        Writer witness = new Writer();
+        witness.write(in.witnessPrefix);
+        witness.writeVarint(in.arguments.size());
+        for (byte[] arg : in.arguments) {
+          witness.writeVarstr(arg);
+        }
+        w.writeVarstr(witness.toByteArray());
+      }
+    }
+
+    w.writeVarint(outputs.size());
+    for (Output out : outputs) {
+      w.writeVarint(out.assetVersion);
+      w.write(out.commitment);
+      writeRefData(w, out.referenceData, serflags);
+      w.writeVarstr(new byte[0]); // output witness
+    }
+
+    writeRefData(w, referenceData, serflags);
+    return w.toByteArray();
+  }
+
+  private static void writeRefData(Writer w, byte[] data, int serflags) {
+    if ((serflags & SER_METADATA) != 0 || data.length == 0) {
+      w.writeVarstr(data);
+    } else {
+      w.writeVarstr(sha3(data));
+    }
+  }
+
+  static byte[] sha3(byte[] data) {
+    SHA3Digest h = new SHA3Digest(256);
+    write(h, data);
+    byte[] out = new byte[32];
+    h.doFinal(out, 0);
+    return out;
+  }
+
+  private static void write(SHA3Digest h, byte[] data) {
+    h.update(data, 0, data.length);
+  }
+
+  static byte[] uvarint(long n) {
+    Writer w = new Writer();
+    w.writeVarint(n);
+    return w.toByteArray();
+  }
+
+  private static class Reader {
+    final byte[] buf;
+    int pos;
+
+    Reader(byte[] buf) {
+      this.buf = buf;
+    }
+
+    int readByte() {
+      if (pos >= buf.length) {
+        throw new IndexOutOfBoundsException("unexpected end of data");
+      }
+      return buf[pos++] & 0xff;
+    }
+
+    byte[] readBytes(int n) {
+      if (n < 0 || n > buf.length - pos) {
+        throw new IndexOutOfBoundsException("unexpected end of data");
+      }
+      byte[] b = Arrays.copyOfRange(buf, pos, pos + n);
+      pos += n;
+      return b;
+    }
+
+    long readVarint() {
+      long n = 0;
+      for (int shift = 0; shift < 64; shift += 7) {
+        int b = readByte();
+        n |= (long) (b & 0x7f) << shift;
+        if ((b & 0x80) == 0) {
+          if (n < 0) {
+            throw new IllegalArgumentException("varint overflow");
+          }
+          return n;
+        }
+      }
+      throw new IllegalArgumentException("varint overflow");
+    }
+
+    byte[] readVarstr() {
+      long n = readVarint();
+      if (n > Integer.MAX_VALUE) {
+        throw new IllegalArgumentException("varstr too long");
+      }
+      return readBytes((int) n);
+    }
+  }
+
+  private static class Writer extends ByteArrayOutputStream {
+    @Override
+    public void write(byte[] b) {
+      write(b, 0, b.length);
+    }
+
+    void writeVarint(long n) {
+      while ((n & ~0x7fL) != 0) {
+        write((int) ((n & 0x7f) | 0x80));
+        n >>>= 7;
+      }
+      write((int) n);
+    }
+
+    void writeVarstr(byte[] b) {
+      writeVarint(b.length);
+      write(b);
+    }
+  }
+}
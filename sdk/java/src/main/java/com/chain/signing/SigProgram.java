@@ -0,0 +1,197 @@
+package com.chain.signing;
+
+import com.chain.api.Transaction;
+import com.chain.exception.ChainException;
+
+import java.io.ByteArrayOutputStream;
+import java.util.ArrayList;
+import java.util.List;
+
+/**
+ * SigProgram infers the signature program for an input of a
+ * transaction template, as Chain Core's txbuilder package does
+ * when a signature witness component has no program.
+ */
+final class SigProgram {
+  private static final int OP_0 = 0x00;
+  private static final int OP_1 = 0x51;
+  private static final int OP_DATA_1 = 0x01;
+  private static final int OP_PUSHDATA1 = 0x4c;
+  private static final int OP_PUSHDATA2 = 0x4d;
+  private static final int OP_PUSHDATA4 = 0x4e;
+  private static final int OP_VERIFY = 0x69;
+  private static final int OP_ROT = 0x7b;
+  private static final int OP_EQUAL = 0x87;
+  private static final int OP_NUMEQUAL = 0x9c;
+  private static final int OP_LESSTHANOREQUAL = 0xa1;
+  private static final int OP_GREATERTHANOREQUAL = 0xa2;
+  private static final int OP_TXSIGHASH = 0xae;
+  private static final int OP_CHECKOUTPUT = 0xc1;
+  private static final int OP_MINTIME = 0xc5;
+  private static final int OP_MAXTIME = 0xc6;
+  private static final int OP_TXREFDATAHASH = 0xc7;
+  private static final int OP_REFDATAHASH = 0xc8;
+  private static final int OP_OUTPOINT = 0xcb;
+
+  private SigProgram() {}
+
+  /**
+   * Returns the program a signature on input index of tx commits
+   * to. If the input's sighash mode is the default and tpl does not
+   * allow additional actions, the program checks the whole
+   * transaction's signature hash. Otherwise it checks the time
+   * bounds, the input's outpoint and reference data, the
+   * transaction's reference data, if any, and the outputs, or only
+   * the output at index in single_output mode.
+   */
+  static byte[] build(Transaction.Template tpl, RawTransaction tx, int index)
+      throws ChainException {
+    String mode = sigHashMode(tpl, index);
+    if (mode.isEmpty() && !tpl.allowsAdditionalActions()) {
+      Builder b = new Builder();
+      b.addData(tx.sigHash(index)).addOp(OP_TXSIGHASH).addOp(OP_EQUAL);
+      return b.toByteArray();
+    }
+
+    List<byte[]> constraints = new ArrayList<>();
+    constraints.add(timeConstraint(tx.minTime, tx.maxTime));
+
+    RawTransaction.Input in = tx.inputs.get(index);
+    if (!in.issuance) {
+      Builder b = new Builder();
+      b.addData(in.outpointHash).addInt64(in.outpointIndex);
+      b.addOp(OP_OUTPOINT).addOp(OP_ROT).addOp(OP_NUMEQUAL).addOp(OP_VERIFY).addOp(OP_EQUAL);
+      constraints.add(b.toByteArray());
+    }
+
+    // As in Chain Core, the transaction's reference data is
+    // committed to only if it is set; the input's, always.
+    if (tx.referenceData.length > 0) {
+      Builder b = new Builder();
+      b.addData(RawTransaction.sha3(tx.referenceData)).addOp(OP_TXREFDATAHASH).addOp(OP_EQUAL);
+      constraints.add(b.toByteArray());
+    }
+    Builder ref = new Builder();
+    ref.addData(RawTransaction.sha3(in.referenceData)).addOp(OP_REFDATAHASH).addOp(OP_EQUAL);
+    constraints.add(ref.toByteArray());
+
+    for (int i = 0; i < tx.outputs.size(); i++) {
+      if (mode.equals("single_output") && i != index) {
+        continue;
+      }
+      RawTransaction.Output out = tx.outputs.get(i);
+      Builder b = new Builder();
+      b.addInt64(i);
+      if (out.referenceData.length > 0) {
+        b.addData(RawTransaction.sha3(out.referenceData));
+      } else {
+        b.addData(new byte[0]);
+      }
+      b.addInt64(out.amount).addData(out.assetID).addInt64(1).addData(out.controlProgram);
+      b.addOp(OP_CHECKOUTPUT);
+      constraints.add(b.toByteArray());
+    }
+
+    Builder program = new Builder();
+    for (int i = 0; i < constraints.size(); i++) {
+      program.write(constraints.get(i));
+      if (i < constraints.size() - 1) { // leave the final bool on top of the stack
+        program.addOp(OP_VERIFY);
+      }
+    }
+    return program.toByteArray();
+  }
+
+  private static byte[] timeConstraint(long minTime, long maxTime) {
+    Builder b = new Builder();
+    if (minTime == 0 && maxTime == 0) {
+      return b.addOp(OP_1).toByteArray();
+    }
+    if (minTime > 0) {
+      b.addOp(OP_MINTIME).addInt64(minTime).addOp(OP_GREATERTHANOREQUAL);
+    }
+    if (maxTime > 0) {
+      if (minTime > 0) {
+        b.addOp(OP_VERIFY);
+      }
+      b.addOp(OP_MAXTIME).addInt64(maxTime).addOp(OP_LESSTHANOREQUAL);
+    }
+    return b.toByteArray();
+  }
+
+  private static String sigHashMode(Transaction.Template tpl, int index) throws ChainException {
+    for (Transaction.Template.SigningInstruction si : tpl.signingInstructions) {
+      if (si.position != index) {
+        continue;
+      }
+      String mode = si.sigHashMode == null ? "" : si.sigHashMode;
+      switch (mode) {
+        case "":
+        case "anyone_can_pay":
+        case "single_output":
+          return mode;
+        default:
+          throw new ChainException("unknown sighash mode '" + mode + "'");
+      }
+    }
+    return "";
+  }
+
+  /**
+   * Returns the minimal little-endian encoding of n, as the
+   * Chain VM represents numbers.
+   */
+  static byte[] int64Bytes(long n) {
+    ByteArrayOutputStream out = new ByteArrayOutputStream();
+    for (int i = 0; i < 8 && (n >>> (8 * i)) != 0; i++) {
+      out.write((int) (n >>> (8 * i)));
+    }
+    return out.toByteArray();
+  }
+
+  private static class Builder extends ByteArrayOutputStream {
+    @Override
+    public void write(byte[] b) {
+      write(b, 0, b.length);
+    }
+
+    Builder addOp(int op) {
+      write(op);
+      return this;
+    }
+
+    Builder addInt64(long n) {
+      if (n == 0) {
+        return addOp(OP_0);
+      }
+      if (n >= 1 && n <= 16) {
+        return addOp(OP_1 + (int) n - 1);
+      }
+      return addData(int64Bytes(n));
+    }
+
+    Builder addData(byte[] data) {
+      int l = data.length;
+      if (l == 0) {
+        addOp(OP_0);
+      } else if (l <= 75) {
+        addOp(OP_DATA_1 + l - 1);
+      } else if (l < 1 << 8) {
+        addOp(OP_PUSHDATA1);
+        write(l);
+      } else if (l < 1 << 16) {
+        addOp(OP_PUSHDATA2);
+        write(l);
+        write(l >>> 8);
+      } else {
+        addOp(OP_PUSHDATA4);
+        write(l);
+        write(l >>> 8);
+        write(l >>> 16);
+        write(l >>> 24);
+      }
+      write(data);
+      return this;
+    }
+  }
+}
@@ -0,0 +1,82 @@
+package com.chain.signing;
+
+import com.chain.api.Transaction;
+
+import com.google.gson.Gson;
+import com.google.gson.JsonElement;
+import com.google.gson.annotations.SerializedName;
+import com.google.gson.reflect.TypeToken;
+
+import org.bouncycastle.util.encoders.Hex;
+import org.junit.Test;
+
+import java.io.InputStreamReader;
+import java.io.Reader;
+import java.util.List;
+
+import static org.junit.Assert.assertArrayEquals;
+import static org.junit.Assert.assertEquals;
+
+/**
+ * OfflineSignerTest checks the OfflineSigner against vectors
+ * produced by Chain Core's own signer. They are regenerated by
+ * TestSigningVectors in the core/txbuilder Go package.
+ */
+public class OfflineSignerTest {
+  static class Vector {
+    String name;
+    String xprv;
+    JsonElement template;
+    String[] sighashes;
+
+    @SerializedName("signed_template")
+    JsonElement signedTemplate;
+  }
+
+  @Test
+  public void testVectors() throws Exception {
+    Gson gson = new Gson();
+    List<Vector> vectors;
+    try (Reader r =
+        new InputStreamReader(getClass().getResourceAsStream("/signing-vectors.json"), "UTF-8")) {
+      vectors = gson.fromJson(r, new TypeToken<List<Vector>>() {}.getType());
+    }
+
+    for (Vector v : vectors) {
+      Transaction.Template tpl = gson.fromJson(v.template, Transaction.Template.class);
+      Transaction.Template want = gson.fromJson(v.signedTemplate, Transaction.Template.class);
+
+      RawTransaction tx = RawTransaction.decode(tpl.rawTransaction);
+      for (int i = 0; i < v.sighashes.length; i++) {
+        assertArrayEquals(v.name + ": sighash " + i, Hex.decode(v.sighashes[i]), tx.sigHash(i));
+      }
+
+      OfflineSigner.addKey(v.xprv);
+      Transaction.Template got = OfflineSigner.sign(tpl);
+      for (int i = 0; i < want.signingInstructions.size(); i++) {
+        Transaction.Template.WitnessComponent[] wantWCs =
+            want.signingInstructions.get(i).witnessComponents;
+        Transaction.Template.WitnessComponent[] gotWCs =
+            got.signingInstructions.get(i).witnessComponents;
+        for (int j = 0; j < wantWCs.length; j++) {
+          if (wantWCs[j].type.equals("signature")) {
+            assertArrayEquals(
+                v.name + ": signatures of instruction " + i,
+                wantWCs[j].signatures,
+                gotWCs[j].signatures);
+          }
+        }
+      }
+      assertEquals(v.name + ": raw transaction", want.rawTransaction, got.rawTransaction);
+    }
+  }
+
+  @Test
+  public void testInt64Bytes() {
+    assertArrayEquals(new byte[0], SigProgram.int64Bytes(0));
+    assertArrayEquals(new byte[] {1}, SigProgram.int64Bytes(1));
+    assertArrayEquals(new byte[] {0, 1}, SigProgram.int64Bytes(256));
+    assertArrayEquals(
+        new byte[] {-1, -1, -1, -1, -1, -1, -1, -1}, SigProgram.int64Bytes(-1));
+  }
+}
@@ -0,0 +1,47 @@
+import { APIError, isErrorObject } from './errors'
+
+// BatchResponse holds the result of a batch request: for each item
+// of the request, by position, either a success or an APIError.
+export class BatchResponse<T> {
+  private results: Array<T | APIError>
+
+  constructor(items: any[], requestId?: string) {
+    this.results = items.map(item =>
+      isErrorObject(item) ? new APIError(item, requestId) : item as T
+    )
+  }
+
+  // from makes a BatchResponse from results already decoded.
+  static from<T>(results: Array<T | APIError>): BatchResponse<T> {
+    let b = new BatchResponse<T>([])
+    b.results = results
+    return b
+  }
+
+  get length(): number {
+    return this.results.length
+  }
+
+  isSuccess(i: number): boolean {
+    return !(this.results[i] instanceof APIError)
+  }
+
+  // get returns the success at position i, or throws its error.
+  get(i: number): T {
+    let r = this.results[i]
+    if (r instanceof APIError) {
+      throw r
+    }
+    return r
+  }
+
+  // successes returns the successful items, in order.
+  successes(): T[] {
+    return this.results.filter(r => !(r instanceof APIError)) as T[]
+  }
+
+  // errors returns the errors, in order.
+  errors(): APIError[] {
+    return this.results.filter(r => r instanceof APIError) as APIError[]
+  }
+}
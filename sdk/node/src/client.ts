@@ -0,0 +1,114 @@
+import { APIError, ConnectivityError, isErrorObject } from './errors'
+import { BatchResponse } from './batch'
+import {
+  BuildRequest,
+  Key,
+  Page,
+  Query,
+  SubmitResult,
+  Template,
+} from './types'
+
+const fetch = require('node-fetch')
+const version = require('../package.json').version
+
+// Client makes requests to a Chain Core, or to an HSM
+// that serves the MockHSM signing endpoints.
+export class Client {
+  baseUrl: string
+  accessToken?: string
+
+  transactions: Transactions
+
+  constructor(baseUrl: string, accessToken?: string) {
+    this.baseUrl = baseUrl.replace(/\/+$/, '')
+    this.accessToken = accessToken
+    this.transactions = new Transactions(this)
+  }
+
+  // mockHsm returns a client for the core's MockHSM, for
+  // use with HsmSigner.
+  mockHsm(): MockHsm {
+    return new MockHsm(this.baseUrl + '/mockhsm', this.accessToken)
+  }
+
+  // request posts body to path and resolves to the decoded
+  // response. An error object in response rejects with APIError.
+  request(path: string, body: any = {}): Promise<any> {
+    let headers: {[name: string]: string} = {
+      'Accept': 'application/json',
+      'Content-Type': 'application/json',
+      'User-Agent': 'chain-sdk-node/' + version,
+    }
+    if (this.accessToken) {
+      headers['Authorization'] = 'Basic ' + new Buffer(this.accessToken).toString('base64')
+    }
+
+    return fetch(this.baseUrl + path, {
+      method: 'POST',
+      headers: headers,
+      body: JSON.stringify(body),
+    }).catch((err: Error) => {
+      throw new ConnectivityError('Fetch error: ' + err.message)
+    }).then((resp: any) => {
+      let requestId = resp.headers.get('Chain-Request-Id')
+      if (!requestId) {
+        throw new ConnectivityError('Chain-Request-Id header is missing. There may be an issue with your proxy or network configuration.')
+      }
+      if (resp.status == 204) {
+        return {}
+      }
+      return resp.json().catch(() => {
+        throw new ConnectivityError('Could not parse JSON response. Request-ID: ' + requestId)
+      }).then((data: any) => {
+        if (Math.floor(resp.status / 100) != 2 || isErrorObject(data)) {
+          throw new APIError(data, requestId, resp.status)
+        }
+        return data
+      })
+    })
+  }
+
+  // batchRequest posts items to path, an endpoint that takes a
+  // batch, and resolves to the result for each item.
+  batchRequest<T>(path: string, items: any): Promise<BatchResponse<T>> {
+    return this.request(path, items).then((data: any[]) => new BatchResponse<T>(data))
+  }
+}
+
+// MockHsm is a client for the MockHSM endpoints of a core.
+export class MockHsm extends Client {
+  createKey(alias?: string): Promise<Key> {
+    return this.request('/create-key', {alias: alias})
+  }
+
+  listKeys(query: Query = {}): Promise<Page<Key>> {
+    return this.request('/list-keys', query)
+  }
+
+  // signTransactions signs templates with those of xpubs
+  // whose private keys the HSM holds.
+  signTransactions(templates: Template[], xpubs: string[]): Promise<BatchResponse<Template>> {
+    return this.batchRequest<Template>('/sign-transaction', {
+      transactions: templates,
+      xpubs: xpubs,
+    })
+  }
+}
+
+// Transactions builds and submits transactions.
+export class Transactions {
+  constructor(private client: Client) {}
+
+  build(requests: BuildRequest[]): Promise<BatchResponse<Template>> {
+    return this.client.batchRequest<Template>('/build-transaction', requests)
+  }
+
+  submit(templates: Template[]): Promise<BatchResponse<SubmitResult>> {
+    return this.client.batchRequest<SubmitResult>('/submit-transaction', {transactions: templates})
+  }
+
+  query(query: Query = {}): Promise<Page<any>> {
+    return this.client.request('/list-transactions', query)
+  }
+}
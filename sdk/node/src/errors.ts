@@ -0,0 +1,67 @@
+import { APIErrorObject } from './types'
+
+// ChainError is the base class of errors from the client.
+// Each constructor resets the prototype, which extending Error
+// loses when compiled to ES5, so that instanceof works.
+export class ChainError extends Error {
+  constructor(message: string) {
+    super(message)
+    this.message = message
+    this.name = 'ChainError'
+    Object.setPrototypeOf(this, ChainError.prototype)
+  }
+}
+
+// ConnectivityError means the request did not reach the core,
+// or its response could not be read.
+export class ConnectivityError extends ChainError {
+  constructor(message: string) {
+    super(message)
+    this.name = 'ConnectivityError'
+    Object.setPrototypeOf(this, ConnectivityError.prototype)
+  }
+}
+
+// APIError is an error object returned by the core, either for
+// a whole request or for one item of a batch request.
+export class APIError extends ChainError {
+  code: string
+  chainMessage: string
+  detail?: string
+  temporary: boolean
+  data?: any
+  requestId?: string
+  status?: number
+
+  constructor(obj: APIErrorObject, requestId?: string, status?: number) {
+    super(formatErrMsg(obj, requestId))
+    this.name = 'APIError'
+    Object.setPrototypeOf(this, APIError.prototype)
+    this.code = obj.code
+    this.chainMessage = obj.message
+    this.detail = obj.detail
+    this.temporary = !!obj.temporary
+    this.data = obj.data
+    this.requestId = requestId
+    this.status = status
+  }
+}
+
+export function isErrorObject(v: any): v is APIErrorObject {
+  return !!v && typeof v.code === 'string' && v.code.length > 0
+}
+
+function formatErrMsg(obj: APIErrorObject, requestId?: string): string {
+  let tokens: string[] = []
+  if (obj.code) {
+    tokens.push('Code: ' + obj.code)
+  }
+  tokens.push('Message: ' + obj.message)
+  if (obj.detail) {
+    tokens.push('Detail: ' + obj.detail)
+  }
+  if (requestId) {
+    tokens.push('Request-ID: ' + requestId)
+  }
+  return tokens.join(' ')
+}
@@ -0,0 +1,61 @@
+import { APIError } from './errors'
+import { BatchResponse } from './batch'
+import { MockHsm } from './client'
+import { Key, Template } from './types'
+
+// HsmSigner routes signing requests for transaction templates to
+// the HSMs that hold their keys. Only keys added to the signer
+// are used.
+export class HsmSigner {
+  private hsms: Array<{hsm: MockHsm, xpubs: string[]}> = []
+
+  // addKey records that hsm holds the private key for key.
+  addKey(key: string | Key, hsm: MockHsm): void {
+    let xpub = typeof key === 'string' ? key : key.xpub
+    let entry = this.hsms.filter(e => e.hsm === hsm)[0]
+    if (!entry) {
+      entry = {hsm: hsm, xpubs: []}
+      this.hsms.push(entry)
+    }
+    entry.xpubs.push(xpub)
+  }
+
+  // sign signs template with each HSM in turn.
+  sign(template: Template): Promise<Template> {
+    return this.signBatch([template]).then(batch => batch.get(0))
+  }
+
+  // signBatch signs templates with each HSM in turn. A template
+  // that one HSM fails to sign is not sent to the rest, and its
+  // error is reported at its position in the result.
+  signBatch(templates: Template[]): Promise<BatchResponse<Template>> {
+    let results: Array<Template | APIError> = templates.slice()
+
+    let step = (i: number): Promise<BatchResponse<Template>> => {
+      if (i == this.hsms.length) {
+        return Promise.resolve(BatchResponse.from(results))
+      }
+      let {hsm, xpubs} = this.hsms[i]
+      let pending: number[] = []
+      results.forEach((r, j) => {
+        if (!(r instanceof APIError)) {
+          pending.push(j)
+        }
+      })
+      if (pending.length == 0) {
+        return step(this.hsms.length)
+      }
+      return hsm.signTransactions(pending.map(j => results[j] as Template), xpubs).then(batch => {
+        pending.forEach((j, k) => {
+          try {
+            results[j] = batch.get(k)
+          } catch (err) {
+            results[j] = err
+          }
+        })
+        return step(i + 1)
+      })
+    }
+    return step(0)
+  }
+}
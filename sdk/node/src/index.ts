@@ -0,0 +1,5 @@
+export { Client, MockHsm, Transactions } from './client'
+export { HsmSigner } from './hsmSigner'
+export { BatchResponse } from './batch'
+export { APIError, ChainError, ConnectivityError } from './errors'
+export * from './types'
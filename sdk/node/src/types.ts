@@ -0,0 +1,172 @@
+// Request and response objects of the Chain Core API.
+// See core/api-spec.md for the meaning of each field.
+
+// Hex is a hex-encoded byte string, such as an ID or a program.
+export type Hex = string
+
+// Time is a millisecond Unix timestamp, or, where the core
+// accepts it, an RFC 3339 string with a time zone offset.
+export type Time = number | string
+
+export interface APIErrorObject {
+  code: string
+  message: string
+  detail?: string
+  temporary?: boolean
+  data?: any
+}
+
+// AssetRef names an asset by ID or by alias.
+export interface AssetRef {
+  asset_id?: Hex
+  asset_alias?: string
+}
+
+// AccountRef names an account by ID or by alias.
+export interface AccountRef {
+  account_id?: string
+  account_alias?: string
+}
+
+export interface SpendAccountAction extends AssetRef, AccountRef {
+  type: 'spend_account'
+  amount: number
+  reference_data?: Object
+  ttl?: number
+}
+
+export interface SpendAccountUnspentOutputAction {
+  type: 'spend_account_unspent_output'
+  transaction_id: Hex
+  position: number
+  reference_data?: Object
+  ttl?: number
+}
+
+export interface IssueAction extends AssetRef {
+  type: 'issue'
+  amount: number
+  reference_data?: Object
+  ttl?: number
+}
+
+export interface ControlAccountAction extends AssetRef, AccountRef {
+  type: 'control_account'
+  amount: number
+  reference_data?: Object
+}
+
+export interface ControlAccountTimelockedAction extends AssetRef, AccountRef {
+  type: 'control_account_timelocked'
+  amount: number
+  unlock_time: Time
+  reference_data?: Object
+}
+
+export interface ControlProgramAction extends AssetRef {
+  type: 'control_program'
+  amount: number
+  control_program: Hex
+  reference_data?: Object
+}
+
+export interface SetTransactionReferenceDataAction {
+  type: 'set_transaction_reference_data'
+  reference_data: Object
+}
+
+// ContractAction is an action of one of the contract packages,
+// such as crowdfund_pledge or escrow_release. Its fields are
+// described with the contract in the API spec.
+export interface ContractAction {
+  type: string
+  [field: string]: any
+}
+
+export type Action =
+  SpendAccountAction |
+  SpendAccountUnspentOutputAction |
+  IssueAction |
+  ControlAccountAction |
+  ControlAccountTimelockedAction |
+  ControlProgramAction |
+  SetTransactionReferenceDataAction |
+  ContractAction
+
+export interface BuildRequest {
+  // base_transaction is an unsubmitted transaction
+  // to which the actions are appended.
+  base_transaction?: Hex
+  actions: Action[]
+  ttl?: number
+  end_to_end_id?: string
+}
+
+export interface KeyID {
+  xpub: string
+  derivation_path: Hex[]
+}
+
+export interface SignatureWitnessComponent {
+  type: 'signature'
+  quorum: number
+  keys: KeyID[]
+  program?: Hex
+  signatures: Hex[]
+}
+
+export interface DataWitnessComponent {
+  type: 'data'
+  data: Hex
+}
+
+export type WitnessComponent = SignatureWitnessComponent | DataWitnessComponent
+
+export type SigHashMode = 'anyone_can_pay' | 'single_output'
+
+export interface SigningInstruction {
+  position: number
+  asset_id: Hex
+  amount: number
+  witness_components: WitnessComponent[]
+  sighash_mode?: SigHashMode
+}
+
+export interface Template {
+  raw_transaction: Hex
+  signing_instructions: SigningInstruction[]
+  local?: boolean
+  allow_additional_actions?: boolean
+  estimated_size?: number
+  client_token?: string
+}
+
+export interface SubmitResult {
+  id: Hex
+}
+
+export interface Key {
+  alias?: string
+  xpub: string
+}
+
+export interface Page<T> {
+  items: T[]
+  next: Query
+  last_page: boolean
+}
+
+export interface Query {
+  filter?: string
+  filter_params?: any[]
+  sum_by?: string[]
+  page_size?: number
+  ascending_with_long_poll?: boolean
+  timeout?: number
+  after?: string
+  start_time?: Time
+  end_time?: Time
+  timestamp?: Time
+  aliases?: string[]
+  include_archived?: boolean
+}